@@ -0,0 +1,179 @@
+// Command fake-terraform imitates the parts of the terraform CLI used by the
+// run worker so runs can be executed end to end without network access or
+// real infrastructure. Point worker.terraform_bin at the built binary.
+//
+// Every plan adds one resource; every apply increments the state serial.
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const version = "1.9.8"
+
+func main() {
+	if len(os.Args) < 2 {
+		fail("usage: fake-terraform <command> [args]")
+	}
+
+	command, args := os.Args[1], os.Args[2:]
+	switch command {
+	case "version", "-version", "--version":
+		fmt.Printf("Terraform v%s\non linux_amd64\n", version)
+	case "init":
+		fmt.Println("Initializing the backend...")
+		fmt.Println()
+		fmt.Println("Terraform has been successfully initialized!")
+	case "plan":
+		plan(args)
+	case "apply":
+		apply(args)
+	case "show":
+		show(args)
+	default:
+		fail("fake-terraform: unsupported command %q", command)
+	}
+}
+
+func plan(args []string) {
+	var out string
+	var detailed bool
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "-out="):
+			out = strings.TrimPrefix(arg, "-out=")
+		case arg == "-detailed-exitcode":
+			detailed = true
+		}
+	}
+
+	if out != "" {
+		if err := os.WriteFile(out, []byte("fake-terraform plan\n"), 0o640); err != nil {
+			fail("writing plan: %v", err)
+		}
+	}
+
+	fmt.Println("Terraform will perform the following actions:")
+	fmt.Println()
+	fmt.Println("  # null_resource.fake will be created")
+	fmt.Println()
+	fmt.Println("Plan: 1 to add, 0 to change, 0 to destroy.")
+	if detailed {
+		os.Exit(2)
+	}
+}
+
+// show prints a JSON plan in the `terraform show -json` format with one
+// sensitive attribute, so redaction can be exercised.
+func show(args []string) {
+	if len(args) != 2 || args[0] != "-json" {
+		fail("usage: fake-terraform show -json PLAN")
+	}
+	if _, err := os.Stat(args[1]); err != nil {
+		fail("reading plan: %v", err)
+	}
+
+	values := map[string]any{"id": nil, "triggers": map[string]any{"password": "hunter2"}}
+	sensitive := map[string]any{"triggers": map[string]any{"password": true}}
+	plan := map[string]any{
+		"format_version":    "1.2",
+		"terraform_version": version,
+		"planned_values": map[string]any{
+			"root_module": map[string]any{
+				"resources": []any{map[string]any{
+					"address":          "null_resource.fake",
+					"mode":             "managed",
+					"type":             "null_resource",
+					"name":             "fake",
+					"values":           values,
+					"sensitive_values": sensitive,
+				}},
+			},
+		},
+		"resource_changes": []any{map[string]any{
+			"address": "null_resource.fake",
+			"mode":    "managed",
+			"type":    "null_resource",
+			"name":    "fake",
+			"change": map[string]any{
+				"actions":          []string{"create"},
+				"before":           nil,
+				"after":            values,
+				"after_unknown":    map[string]any{"id": true},
+				"before_sensitive": false,
+				"after_sensitive":  sensitive,
+			},
+		}},
+	}
+	if err := json.NewEncoder(os.Stdout).Encode(plan); err != nil {
+		fail("encoding plan: %v", err)
+	}
+}
+
+func apply(args []string) {
+	if len(args) > 0 {
+		planFile := args[len(args)-1]
+		if !strings.HasPrefix(planFile, "-") {
+			if _, err := os.Stat(planFile); err != nil {
+				fail("reading plan: %v", err)
+			}
+		}
+	}
+
+	state := map[string]any{
+		"version":           4,
+		"terraform_version": version,
+		"serial":            0,
+		"lineage":           "",
+		"outputs":           map[string]any{},
+		"resources":         []any{},
+	}
+	if data, err := os.ReadFile("terraform.tfstate"); err == nil {
+		if err := json.Unmarshal(data, &state); err != nil {
+			fail("reading state: %v", err)
+		}
+	}
+
+	serial, _ := state["serial"].(float64)
+	state["serial"] = int64(serial) + 1
+	if lineage, _ := state["lineage"].(string); lineage == "" {
+		state["lineage"] = newLineage()
+	}
+	state["resources"] = []any{map[string]any{
+		"mode":      "managed",
+		"type":      "null_resource",
+		"name":      "fake",
+		"provider":  `provider["registry.terraform.io/hashicorp/null"]`,
+		"instances": []any{map[string]any{"schema_version": 0, "attributes": map[string]any{"id": "fake"}}},
+	}}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		fail("encoding state: %v", err)
+	}
+	if err := os.WriteFile("terraform.tfstate", data, 0o640); err != nil {
+		fail("writing state: %v", err)
+	}
+
+	fmt.Println("null_resource.fake: Creating...")
+	fmt.Println("null_resource.fake: Creation complete after 0s [id=fake]")
+	fmt.Println()
+	fmt.Println("Apply complete! Resources: 1 added, 0 changed, 0 destroyed.")
+}
+
+func newLineage() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	s := hex.EncodeToString(b)
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+func fail(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
@@ -1,32 +1,90 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/open-tfe/tfe-service/internal/agent"
 	"github.com/open-tfe/tfe-service/internal/api/router"
 	"github.com/open-tfe/tfe-service/internal/initialize"
+	"github.com/open-tfe/tfe-service/internal/mirror"
 	"github.com/open-tfe/tfe-service/internal/service"
+	"github.com/open-tfe/tfe-service/internal/worker"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
 
+// Usage: tfe-service [server|worker|agent|mirror sync <dir>]
+//
+// server (the default) serves the API and, when worker.enabled is set, also
+// executes runs in-process. worker only executes runs. agent executes the
+// runs of an agent pool through the agent API and needs no database. mirror
+// sync uploads the provider packages of a directory written by
+// `terraform providers mirror` to the provider network mirror.
+// taskResultExpiryInterval is how often overdue run task results are expired.
+const taskResultExpiryInterval = 30 * time.Second
+
 func main() {
 	// Initialize logger
 	logger, _ := zap.NewDevelopment()
 	defer logger.Sync()
 
-	// Initialize configuration and database
+	command := "server"
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+	if command != "server" && command != "worker" && command != "agent" && command != "mirror" {
+		fmt.Fprintf(os.Stderr, "unknown command %q\nusage: %s [server|worker|agent|mirror sync <dir>]\n", command, os.Args[0])
+		os.Exit(2)
+	}
+	if command == "mirror" && (len(os.Args) != 4 || os.Args[2] != "sync") {
+		fmt.Fprintf(os.Stderr, "usage: %s mirror sync <dir>\n", os.Args[0])
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Initialize configuration, database, blob storage, encryption, mail,
+	// policy evaluation and run tasks
 	initialize.Config(logger)
+	if command == "agent" {
+		runAgent(ctx, logger)
+		return
+	}
 	db := initialize.Database(logger)
+	store := initialize.Storage(logger)
+	cipher := initialize.Secrets(logger)
+	mailer := initialize.Mailer(logger)
+	policies := initialize.Policies(logger)
+	runTasks := initialize.RunTasks(logger)
+	notifications := initialize.Notifications(logger)
 
 	// Initialize services
-	service := service.NewService(db, logger)
+	service := service.NewService(db, store, cipher, mailer, policies, runTasks, notifications, logger)
+
+	if command == "worker" {
+		runWorker(ctx, service, logger)
+		return
+	}
+	if command == "mirror" {
+		runMirrorSync(ctx, service, os.Args[3], logger)
+		return
+	}
+	if viper.GetBool("worker.enabled") {
+		go runWorker(ctx, service, logger)
+	}
+	go expireTaskResults(ctx, service, logger)
 
 	jwtSecret := viper.GetString("jwt_secret")
 	// Initialize router
-	r := router.NewRouter(jwtSecret, service, logger)
+	r := router.NewRouter(jwtSecret, viper.GetString("server.external_url"), service, logger)
 
 	// Configure server
 	addr := fmt.Sprintf("%s:%d",
@@ -48,3 +106,63 @@ func main() {
 		logger.Fatal("Server failed to start", zap.Error(err))
 	}
 }
+
+func runWorker(ctx context.Context, svc service.Service, logger *zap.Logger) {
+	w := worker.New(svc, workerConfig(), logger)
+	if err := w.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		logger.Fatal("Worker failed", zap.Error(err))
+	}
+}
+
+func runAgent(ctx context.Context, logger *zap.Logger) {
+	err := agent.Run(ctx, agent.Config{
+		Address:           viper.GetString("agent.address"),
+		Token:             viper.GetString("agent.token"),
+		Name:              viper.GetString("agent.name"),
+		HeartbeatInterval: viper.GetDuration("agent.heartbeat_interval"),
+		Worker:            workerConfig(),
+	}, logger)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		logger.Fatal("Agent failed", zap.Error(err))
+	}
+}
+
+// expireTaskResults periodically errors run task results whose tasks did
+// not report back in time, so that their runs can move on.
+func expireTaskResults(ctx context.Context, svc service.Service, logger *zap.Logger) {
+	ticker := time.NewTicker(taskResultExpiryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := svc.ExpireTaskResults(service.SystemContext(ctx)); err != nil {
+				logger.Error("Failed to expire task results", zap.Error(err))
+			}
+		}
+	}
+}
+
+// runMirrorSync runs as the system, since it is started by an operator
+// with direct access to the database.
+func runMirrorSync(ctx context.Context, svc service.Service, dir string, logger *zap.Logger) {
+	result, err := mirror.Sync(service.SystemContext(ctx), svc, dir, logger)
+	if err != nil {
+		logger.Fatal("Mirror sync failed", zap.Error(err))
+	}
+	logger.Info("Mirror sync finished",
+		zap.Int("uploaded", result.Uploaded),
+		zap.Int("unchanged", result.Unchanged),
+		zap.Int("skipped", result.Skipped),
+	)
+}
+
+func workerConfig() worker.Config {
+	return worker.Config{
+		TerraformBin: viper.GetString("worker.terraform_bin"),
+		WorkDir:      viper.GetString("worker.work_dir"),
+		Concurrency:  viper.GetInt("worker.concurrency"),
+		PollInterval: viper.GetDuration("worker.poll_interval"),
+	}
+}
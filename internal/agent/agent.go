@@ -0,0 +1,67 @@
+// Package agent is a reference self-hosted agent. It registers with the
+// pool of its agent token and executes the jobs it claims with the regular
+// worker, talking to the service only through the agent API.
+package agent
+
+import (
+	"context"
+	"time"
+
+	"github.com/open-tfe/tfe-service/internal/worker"
+	"go.uber.org/zap"
+)
+
+// Config controls how an agent connects and executes runs.
+type Config struct {
+	// Address is the base URL of the service, without the API path.
+	Address string
+	// Token is an agent token of the pool to join.
+	Token string
+	// Name is the name the agent registers with.
+	Name string
+	// HeartbeatInterval is how often the agent checks in while idle or busy.
+	HeartbeatInterval time.Duration
+	// Worker configures the execution of the claimed runs.
+	Worker worker.Config
+}
+
+// Run registers the agent, executes jobs until ctx is canceled and then
+// reports that the agent exited.
+func Run(ctx context.Context, cfg Config, logger *zap.Logger) error {
+	if cfg.HeartbeatInterval <= 0 {
+		cfg.HeartbeatInterval = 30 * time.Second
+	}
+	logger = logger.With(zap.String("component", "agent"))
+
+	client := NewClient(cfg.Address, cfg.Token)
+	if err := client.Register(ctx, cfg.Name); err != nil {
+		return err
+	}
+	logger.Info("Agent registered", zap.String("agent", client.AgentID()), zap.String("address", cfg.Address))
+
+	go heartbeat(ctx, client, cfg.HeartbeatInterval, logger)
+	err := worker.New(client, cfg.Worker, logger).Run(ctx)
+
+	// ctx is done by now, so exit on a context of its own.
+	exitCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if exitErr := client.Exit(exitCtx); exitErr != nil {
+		logger.Warn("failed to report agent exit", zap.Error(exitErr))
+	}
+	return err
+}
+
+func heartbeat(ctx context.Context, client *Client, interval time.Duration, logger *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := client.Heartbeat(ctx); err != nil && ctx.Err() == nil {
+			logger.Warn("failed to send heartbeat", zap.Error(err))
+		}
+	}
+}
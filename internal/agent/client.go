@@ -0,0 +1,255 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/jsonapi"
+	"github.com/open-tfe/tfe-service/internal/constants"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"gorm.io/gorm"
+)
+
+// Client speaks the agent protocol for one registered agent. Once
+// registered it implements worker.Backend, so the regular worker executes
+// the jobs the agent claims.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+	agentID string
+}
+
+func NewClient(address, token string) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(address, "/") + constants.APIVersionPath + constants.AgentPath,
+		token:   token,
+		http:    &http.Client{},
+	}
+}
+
+// Register registers the agent with the pool of the client's token.
+func (c *Client) Register(ctx context.Context, name string) error {
+	var body bytes.Buffer
+	if err := jsonapi.MarshalPayloadWithoutIncluded(&body, &tfe.Agent{Name: name}); err != nil {
+		return err
+	}
+	resp, err := c.do(ctx, http.MethodPost, "/register", nil, &body, "application/vnd.api+json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var agent tfe.Agent
+	if err := jsonapi.UnmarshalPayload(resp.Body, &agent); err != nil {
+		return err
+	}
+	c.agentID = agent.ID
+	return nil
+}
+
+// AgentID returns the ID the agent was registered with.
+func (c *Client) AgentID() string {
+	return c.agentID
+}
+
+func (c *Client) Heartbeat(ctx context.Context) error {
+	return c.post(ctx, c.agentPath("/heartbeat"), nil)
+}
+
+func (c *Client) Exit(ctx context.Context) error {
+	return c.post(ctx, c.agentPath("/exit"), nil)
+}
+
+func (c *Client) ClaimRunJob(ctx context.Context) (*service.RunJob, error) {
+	resp, err := c.do(ctx, http.MethodPost, c.agentPath("/jobs"), nil, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	var job service.RunJob
+	if err := json.NewDecoder(resp.Body).Decode(&job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (c *Client) ReadRun(ctx context.Context, runID string) (*tfe.Run, error) {
+	resp, err := c.do(ctx, http.MethodGet, c.runPath(runID, ""), nil, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var run tfe.Run
+	if err := jsonapi.UnmarshalPayload(resp.Body, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (c *Client) DownloadConfigurationVersion(ctx context.Context, cvID string) (io.ReadCloser, error) {
+	return c.download(ctx, c.agentPath("/configuration-versions/"+url.PathEscape(cvID)))
+}
+
+func (c *Client) DownloadRunState(ctx context.Context, runID string) (io.ReadCloser, error) {
+	return c.download(ctx, c.runPath(runID, "/state"))
+}
+
+func (c *Client) DownloadPlanFile(ctx context.Context, runID string) (io.ReadCloser, error) {
+	return c.download(ctx, c.runPath(runID, "/plan-file"))
+}
+
+func (c *Client) UploadPlanFile(ctx context.Context, runID string, r io.Reader) error {
+	return c.upload(ctx, c.runPath(runID, "/plan-file"), r)
+}
+
+func (c *Client) UploadPlanJSON(ctx context.Context, runID string, r io.Reader) error {
+	return c.upload(ctx, c.runPath(runID, "/plan-json"), r)
+}
+
+func (c *Client) CreateRunStateVersion(ctx context.Context, runID string, options tfe.StateVersionCreateOptions, r io.Reader) (*tfe.StateVersion, error) {
+	query := url.Values{}
+	if options.Serial != nil {
+		query.Set("serial", strconv.FormatInt(*options.Serial, 10))
+	}
+	if options.MD5 != nil {
+		query.Set("md5", *options.MD5)
+	}
+	if options.Lineage != nil {
+		query.Set("lineage", *options.Lineage)
+	}
+
+	resp, err := c.do(ctx, http.MethodPost, c.runPath(runID, "/state-versions"), query, r, "application/octet-stream")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var sv tfe.StateVersion
+	if err := jsonapi.UnmarshalPayload(resp.Body, &sv); err != nil {
+		return nil, err
+	}
+	return &sv, nil
+}
+
+func (c *Client) AppendRunLog(ctx context.Context, runID, phase string, data []byte) error {
+	return c.post(ctx, c.runPath(runID, "/logs/"+url.PathEscape(phase)), bytes.NewReader(data))
+}
+
+func (c *Client) SealRunLog(ctx context.Context, runID, phase string) error {
+	return c.post(ctx, c.runPath(runID, "/logs/"+url.PathEscape(phase)+"/seal"), nil)
+}
+
+func (c *Client) FinishPlan(ctx context.Context, runID string, result service.PlanResult) error {
+	body, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return c.post(ctx, c.runPath(runID, "/plan-finished"), bytes.NewReader(body))
+}
+
+func (c *Client) FinishApply(ctx context.Context, runID string) error {
+	return c.post(ctx, c.runPath(runID, "/apply-finished"), nil)
+}
+
+func (c *Client) AcknowledgeRunCancel(ctx context.Context, runID string) error {
+	return c.post(ctx, c.runPath(runID, "/cancel-acknowledged"), nil)
+}
+
+func (c *Client) FailRun(ctx context.Context, runID string, cause string) error {
+	body, err := json.Marshal(map[string]string{"cause": cause})
+	if err != nil {
+		return err
+	}
+	return c.post(ctx, c.runPath(runID, "/fail"), bytes.NewReader(body))
+}
+
+func (c *Client) agentPath(path string) string {
+	return "/" + url.PathEscape(c.agentID) + path
+}
+
+func (c *Client) runPath(runID, path string) string {
+	return c.agentPath("/runs/" + url.PathEscape(runID) + path)
+}
+
+func (c *Client) post(ctx context.Context, path string, body io.Reader) error {
+	resp, err := c.do(ctx, http.MethodPost, path, nil, body, "application/json")
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (c *Client) upload(ctx context.Context, path string, r io.Reader) error {
+	resp, err := c.do(ctx, http.MethodPut, path, nil, r, "application/octet-stream")
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (c *Client) download(ctx context.Context, path string) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, http.MethodGet, path, nil, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// do sends a request to the agent API. Error responses are translated back
+// into the service errors they were written from, so the worker can tell a
+// missing resource apart from a failure.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body io.Reader, contentType string) (*http.Response, error) {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if contentType != "" && body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 400 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	detail := http.StatusText(resp.StatusCode)
+	var doc jsonapi.ErrorsPayload
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err == nil && len(doc.Errors) > 0 && doc.Errors[0].Detail != "" {
+		detail = doc.Errors[0].Detail
+	}
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s %s", gorm.ErrRecordNotFound, method, path)
+	case http.StatusConflict:
+		return nil, fmt.Errorf("%w: %s", service.ErrConflict, detail)
+	case http.StatusForbidden:
+		return nil, fmt.Errorf("%w: %s", service.ErrForbidden, detail)
+	case http.StatusUnprocessableEntity:
+		return nil, fmt.Errorf("%w: %s", service.ErrInvalidAttribute, detail)
+	default:
+		return nil, fmt.Errorf("%s %s: %s", method, path, detail)
+	}
+}
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/jsonapi"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// agentFailRequest is the body of a request that fails a run phase.
+type agentFailRequest struct {
+	Cause string `json:"cause"`
+}
+
+// AgentHandler serves the protocol spoken by self-hosted agents. Job
+// endpoints act on a run phase that the agent in the path has claimed.
+type AgentHandler struct {
+	svc    service.Service
+	logger *zap.Logger
+}
+
+func NewAgentHandler(svc service.Service, logger *zap.Logger) *AgentHandler {
+	return &AgentHandler{
+		svc:    svc,
+		logger: logger.With(zap.String("handler", "agent")),
+	}
+}
+
+func (h *AgentHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var agent tfe.Agent
+	if err := jsonapi.UnmarshalPayload(r.Body, &agent); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	registered, err := h.svc.RegisterAgent(r.Context(), agent.Name, ip)
+	if err != nil {
+		h.logger.Debug("failed to register agent", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	if err := jsonapi.MarshalPayload(w, registered); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+	}
+}
+
+func (h *AgentHandler) Heartbeat(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.AgentHeartbeat(r.Context(), vars["agent_id"]); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AgentHandler) Exit(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.ExitAgent(r.Context(), vars["agent_id"]); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ClaimJob hands the agent the next queued run phase of its pool, or
+// answers 204 when there is none.
+func (h *AgentHandler) ClaimJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	job, err := h.svc.ClaimAgentJob(r.Context(), vars["agent_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	if job == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+	}
+}
+
+func (h *AgentHandler) ReadRun(w http.ResponseWriter, r *http.Request) {
+	ctx, runID, ok := h.authorizeRun(w, r)
+	if !ok {
+		return
+	}
+
+	run, err := h.svc.ReadRun(ctx, runID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	if err := jsonapi.MarshalPayload(w, run); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *AgentHandler) DownloadConfigurationVersion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	content, err := h.svc.DownloadAgentConfigurationVersion(r.Context(), vars["agent_id"], vars["configuration_version_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.stream(w, content, "application/x-gzip")
+}
+
+func (h *AgentHandler) DownloadState(w http.ResponseWriter, r *http.Request) {
+	ctx, runID, ok := h.authorizeRun(w, r)
+	if !ok {
+		return
+	}
+
+	content, err := h.svc.DownloadRunState(ctx, runID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.stream(w, content, "application/json")
+}
+
+func (h *AgentHandler) DownloadPlanFile(w http.ResponseWriter, r *http.Request) {
+	ctx, runID, ok := h.authorizeRun(w, r)
+	if !ok {
+		return
+	}
+
+	content, err := h.svc.DownloadPlanFile(ctx, runID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.stream(w, content, "application/octet-stream")
+}
+
+func (h *AgentHandler) UploadPlanFile(w http.ResponseWriter, r *http.Request) {
+	h.act(w, r, func(ctx context.Context, runID string) error {
+		return h.svc.UploadPlanFile(ctx, runID, r.Body)
+	})
+}
+
+func (h *AgentHandler) UploadPlanJSON(w http.ResponseWriter, r *http.Request) {
+	h.act(w, r, func(ctx context.Context, runID string) error {
+		return h.svc.UploadPlanJSON(ctx, runID, r.Body)
+	})
+}
+
+// CreateStateVersion stores the state in the body. The serial, MD5 and
+// lineage of the state are passed as query parameters.
+func (h *AgentHandler) CreateStateVersion(w http.ResponseWriter, r *http.Request) {
+	ctx, runID, ok := h.authorizeRun(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	serial, err := strconv.ParseInt(query.Get("serial"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid serial", http.StatusBadRequest)
+		return
+	}
+	options := tfe.StateVersionCreateOptions{
+		Serial:  tfe.Int64(serial),
+		MD5:     tfe.String(query.Get("md5")),
+		Lineage: tfe.String(query.Get("lineage")),
+	}
+
+	sv, err := h.svc.CreateRunStateVersion(ctx, runID, options, r.Body)
+	if err != nil {
+		h.logger.Debug("failed to create state version", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	if err := jsonapi.MarshalPayload(w, sv); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+	}
+}
+
+func (h *AgentHandler) AppendLog(w http.ResponseWriter, r *http.Request) {
+	h.act(w, r, func(ctx context.Context, runID string) error {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			return err
+		}
+		return h.svc.AppendRunLog(ctx, runID, mux.Vars(r)["phase"], data)
+	})
+}
+
+func (h *AgentHandler) SealLog(w http.ResponseWriter, r *http.Request) {
+	h.act(w, r, func(ctx context.Context, runID string) error {
+		return h.svc.SealRunLog(ctx, runID, mux.Vars(r)["phase"])
+	})
+}
+
+func (h *AgentHandler) FinishPlan(w http.ResponseWriter, r *http.Request) {
+	var result service.PlanResult
+	if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.act(w, r, func(ctx context.Context, runID string) error {
+		return h.svc.FinishPlan(ctx, runID, result)
+	})
+}
+
+func (h *AgentHandler) FinishApply(w http.ResponseWriter, r *http.Request) {
+	h.act(w, r, h.svc.FinishApply)
+}
+
+func (h *AgentHandler) AcknowledgeCancel(w http.ResponseWriter, r *http.Request) {
+	h.act(w, r, h.svc.AcknowledgeRunCancel)
+}
+
+func (h *AgentHandler) Fail(w http.ResponseWriter, r *http.Request) {
+	var req agentFailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.act(w, r, func(ctx context.Context, runID string) error {
+		return h.svc.FailRun(ctx, runID, req.Cause)
+	})
+}
+
+// authorizeRun checks that the agent in the path holds the run in the path
+// and returns the context to act on the run in.
+func (h *AgentHandler) authorizeRun(w http.ResponseWriter, r *http.Request) (context.Context, string, bool) {
+	vars := mux.Vars(r)
+
+	ctx, err := h.svc.AuthorizeAgentRun(r.Context(), vars["agent_id"], vars["run_id"])
+	if err != nil {
+		WriteError(w, err)
+		return nil, "", false
+	}
+	return ctx, vars["run_id"], true
+}
+
+// act runs do on an authorized run and answers 204 when it succeeds.
+func (h *AgentHandler) act(w http.ResponseWriter, r *http.Request, do func(ctx context.Context, runID string) error) {
+	ctx, runID, ok := h.authorizeRun(w, r)
+	if !ok {
+		return
+	}
+	if err := do(ctx, runID); err != nil {
+		h.logger.Debug("agent request failed", zap.String("run", runID), zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AgentHandler) stream(w http.ResponseWriter, content io.ReadCloser, contentType string) {
+	defer content.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	if _, err := io.Copy(w, content); err != nil {
+		h.logger.Error("failed to stream content", zap.Error(err))
+	}
+}
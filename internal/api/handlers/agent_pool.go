@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/jsonapi"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// AgentPoolHandler serves agent pools, their tokens and their agents.
+type AgentPoolHandler struct {
+	svc    service.Service
+	logger *zap.Logger
+}
+
+func NewAgentPoolHandler(svc service.Service, logger *zap.Logger) *AgentPoolHandler {
+	return &AgentPoolHandler{
+		svc:    svc,
+		logger: logger.With(zap.String("handler", "agent_pool")),
+	}
+}
+
+func (h *AgentPoolHandler) List(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+
+	options := &tfe.AgentPoolListOptions{
+		ListOptions:           ParseListOptions(r),
+		Query:                 query.Get("q"),
+		AllowedWorkspacesName: query.Get("filter[allowed_workspaces][name]"),
+	}
+	pools, pagination, err := h.svc.ListAgentPools(r.Context(), vars["organization_name"], options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, pools, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *AgentPoolHandler) Create(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var options tfe.AgentPoolCreateOptions
+	if err := jsonapi.UnmarshalPayload(r.Body, &options); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pool, err := h.svc.CreateAgentPool(r.Context(), vars["organization_name"], options)
+	if err != nil {
+		h.logger.Debug("failed to create agent pool", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+	h.created(w, pool)
+}
+
+func (h *AgentPoolHandler) Read(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	pool, err := h.svc.ReadAgentPool(r.Context(), vars["agent_pool_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, pool)
+}
+
+func (h *AgentPoolHandler) Update(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var options tfe.AgentPoolUpdateOptions
+	if err := jsonapi.UnmarshalPayload(r.Body, &options); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pool, err := h.svc.UpdateAgentPool(r.Context(), vars["agent_pool_id"], options)
+	if err != nil {
+		h.logger.Debug("failed to update agent pool", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, pool)
+}
+
+func (h *AgentPoolHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.DeleteAgentPool(r.Context(), vars["agent_pool_id"]); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AgentPoolHandler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	tokens, err := h.svc.ListAgentTokens(r.Context(), vars["agent_pool_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, tokens, nil); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *AgentPoolHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	options, err := parseTokenRequest(r)
+	if err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.svc.CreateAgentToken(r.Context(), vars["agent_pool_id"], options)
+	if err != nil {
+		h.logger.Debug("failed to create agent token", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+	h.created(w, token)
+}
+
+func (h *AgentPoolHandler) ListAgents(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	options := &tfe.AgentListOptions{ListOptions: ParseListOptions(r)}
+	if since := r.URL.Query().Get("filter[last-ping-since]"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			http.Error(w, "invalid filter[last-ping-since]", http.StatusBadRequest)
+			return
+		}
+		options.LastPingSince = t
+	}
+
+	agents, pagination, err := h.svc.ListAgents(r.Context(), vars["agent_pool_id"], options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, agents, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *AgentPoolHandler) ReadAgent(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	agent, err := h.svc.ReadAgent(r.Context(), vars["agent_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, agent)
+}
+
+func (h *AgentPoolHandler) created(w http.ResponseWriter, payload interface{}) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	if err := jsonapi.MarshalPayload(w, payload); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+	}
+}
+
+func (h *AgentPoolHandler) marshal(w http.ResponseWriter, payload interface{}) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	if err := jsonapi.MarshalPayload(w, payload); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
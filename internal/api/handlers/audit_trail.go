@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// auditTrailList is the audit trail response. Unlike the rest of the API it
+// is plain JSON rather than JSON:API.
+type auditTrailList struct {
+	Items      []*models.AuditTrailEvent `json:"data"`
+	Pagination *tfe.AuditTrailPagination `json:"pagination"`
+}
+
+// AuditTrailHandler serves the audit trail of the organization of the
+// calling token.
+type AuditTrailHandler struct {
+	svc    service.Service
+	logger *zap.Logger
+}
+
+func NewAuditTrailHandler(svc service.Service, logger *zap.Logger) *AuditTrailHandler {
+	return &AuditTrailHandler{
+		svc:    svc,
+		logger: logger.With(zap.String("handler", "audit_trail")),
+	}
+}
+
+func (h *AuditTrailHandler) List(w http.ResponseWriter, r *http.Request) {
+	listOptions := ParseListOptions(r)
+	options := &tfe.AuditTrailListOptions{ListOptions: &listOptions}
+	if since := r.URL.Query().Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			http.Error(w, "since must be an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		options.Since = t
+	}
+
+	events, pagination, err := h.svc.ListAuditTrail(r.Context(), options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	list := auditTrailList{
+		Items: events,
+		Pagination: &tfe.AuditTrailPagination{
+			CurrentPage:  pagination.CurrentPage,
+			PreviousPage: pagination.PreviousPage,
+			NextPage:     pagination.NextPage,
+			TotalPages:   pagination.TotalPages,
+			TotalCount:   pagination.TotalCount,
+		},
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(list); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+	}
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/jsonapi"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// tokenRequest is the body of token create requests. go-tfe sends team and
+// organization token options without a resource type, which the jsonapi
+// decoder rejects, so the body is decoded as plain JSON.
+type tokenRequest struct {
+	Data struct {
+		Attributes struct {
+			Description string     `json:"description"`
+			ExpiredAt   *time.Time `json:"expired-at"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// parseTokenRequest reads token create options. The body is optional.
+func parseTokenRequest(r *http.Request) (service.TokenCreateOptions, error) {
+	var req tokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		return service.TokenCreateOptions{}, err
+	}
+	return service.TokenCreateOptions{
+		Description: req.Data.Attributes.Description,
+		ExpiredAt:   req.Data.Attributes.ExpiredAt,
+	}, nil
+}
+
+// parseTokenType reads the token query parameter that selects audit trail
+// tokens on the organization token endpoints.
+func parseTokenType(r *http.Request) *tfe.TokenType {
+	if token := r.URL.Query().Get("token"); token != "" {
+		tokenType := tfe.TokenType(token)
+		return &tokenType
+	}
+	return nil
+}
+
+type AuthenticationTokenHandler struct {
+	svc    service.Service
+	logger *zap.Logger
+}
+
+func NewAuthenticationTokenHandler(svc service.Service, logger *zap.Logger) *AuthenticationTokenHandler {
+	return &AuthenticationTokenHandler{
+		svc:    svc,
+		logger: logger.With(zap.String("handler", "authentication_token")),
+	}
+}
+
+func (h *AuthenticationTokenHandler) ListUserTokens(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	tokens, err := h.svc.ListUserTokens(r.Context(), vars["user_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, tokens, nil); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *AuthenticationTokenHandler) CreateUserToken(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	options, err := parseTokenRequest(r)
+	if err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.svc.CreateUserToken(r.Context(), vars["user_id"], options)
+	if err != nil {
+		h.logger.Debug("failed to create user token", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+	h.created(w, token)
+}
+
+// ReadToken serves user and agent tokens, which share the
+// authentication-tokens endpoint.
+func (h *AuthenticationTokenHandler) ReadToken(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	token, err := h.svc.ReadUserToken(r.Context(), vars["token_id"])
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		agentToken, err := h.svc.ReadAgentToken(r.Context(), vars["token_id"])
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+		h.marshal(w, agentToken)
+		return
+	}
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, token)
+}
+
+// DeleteToken revokes a user or agent token.
+func (h *AuthenticationTokenHandler) DeleteToken(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	err := h.svc.DeleteUserToken(r.Context(), vars["token_id"])
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = h.svc.DeleteAgentToken(r.Context(), vars["token_id"])
+	}
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AuthenticationTokenHandler) CreateTeamToken(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	options, err := parseTokenRequest(r)
+	if err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.svc.CreateTeamToken(r.Context(), vars["team_id"], options)
+	if err != nil {
+		h.logger.Debug("failed to create team token", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+	h.created(w, token)
+}
+
+func (h *AuthenticationTokenHandler) ReadTeamToken(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	token, err := h.svc.ReadTeamToken(r.Context(), vars["team_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, token)
+}
+
+func (h *AuthenticationTokenHandler) DeleteTeamToken(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.DeleteTeamToken(r.Context(), vars["team_id"]); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AuthenticationTokenHandler) CreateOrganizationToken(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	options, err := parseTokenRequest(r)
+	if err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.svc.CreateOrganizationToken(r.Context(), vars["organization_name"], parseTokenType(r), options)
+	if err != nil {
+		h.logger.Debug("failed to create organization token", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+	h.created(w, token)
+}
+
+func (h *AuthenticationTokenHandler) ReadOrganizationToken(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	token, err := h.svc.ReadOrganizationToken(r.Context(), vars["organization_name"], parseTokenType(r))
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, token)
+}
+
+func (h *AuthenticationTokenHandler) DeleteOrganizationToken(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.DeleteOrganizationToken(r.Context(), vars["organization_name"], parseTokenType(r)); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AuthenticationTokenHandler) created(w http.ResponseWriter, payload interface{}) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	if err := jsonapi.MarshalPayload(w, payload); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+	}
+}
+
+func (h *AuthenticationTokenHandler) marshal(w http.ResponseWriter, payload interface{}) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	if err := jsonapi.MarshalPayload(w, payload); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
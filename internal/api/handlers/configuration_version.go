@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/jsonapi"
+	"github.com/open-tfe/tfe-service/internal/auth"
+	"github.com/open-tfe/tfe-service/internal/constants"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// configurationVersionCreateRequest extends the go-tfe create options with
+// the VCS metadata that CI pipelines may attach to an uploaded archive.
+type configurationVersionCreateRequest struct {
+	Type              string            `jsonapi:"primary,configuration-versions"`
+	AutoQueueRuns     *bool             `jsonapi:"attr,auto-queue-runs,omitempty"`
+	Speculative       *bool             `jsonapi:"attr,speculative,omitempty"`
+	Provisional       *bool             `jsonapi:"attr,provisional,omitempty"`
+	IngressAttributes ingressAttributes `jsonapi:"attr,ingress-attributes,omitempty"`
+}
+
+// ingressAttributes mirrors tfe.IngressAttributes without the primary key,
+// which jsonapi cannot decode inside a nested attribute.
+type ingressAttributes struct {
+	Branch            string `jsonapi:"attr,branch"`
+	CloneURL          string `jsonapi:"attr,clone-url"`
+	CommitMessage     string `jsonapi:"attr,commit-message"`
+	CommitSHA         string `jsonapi:"attr,commit-sha"`
+	CommitURL         string `jsonapi:"attr,commit-url"`
+	CompareURL        string `jsonapi:"attr,compare-url"`
+	Identifier        string `jsonapi:"attr,identifier"`
+	IsPullRequest     bool   `jsonapi:"attr,is-pull-request"`
+	OnDefaultBranch   bool   `jsonapi:"attr,on-default-branch"`
+	PullRequestNumber int    `jsonapi:"attr,pull-request-number"`
+	PullRequestURL    string `jsonapi:"attr,pull-request-url"`
+	PullRequestTitle  string `jsonapi:"attr,pull-request-title"`
+	PullRequestBody   string `jsonapi:"attr,pull-request-body"`
+	Tag               string `jsonapi:"attr,tag"`
+	SenderUsername    string `jsonapi:"attr,sender-username"`
+	SenderAvatarURL   string `jsonapi:"attr,sender-avatar-url"`
+	SenderHTMLURL     string `jsonapi:"attr,sender-html-url"`
+}
+
+// toTFE returns nil when no ingress attributes were sent.
+func (ia ingressAttributes) toTFE() *tfe.IngressAttributes {
+	if ia == (ingressAttributes{}) {
+		return nil
+	}
+	return &tfe.IngressAttributes{
+		Branch:            ia.Branch,
+		CloneURL:          ia.CloneURL,
+		CommitMessage:     ia.CommitMessage,
+		CommitSHA:         ia.CommitSHA,
+		CommitURL:         ia.CommitURL,
+		CompareURL:        ia.CompareURL,
+		Identifier:        ia.Identifier,
+		IsPullRequest:     ia.IsPullRequest,
+		OnDefaultBranch:   ia.OnDefaultBranch,
+		PullRequestNumber: ia.PullRequestNumber,
+		PullRequestURL:    ia.PullRequestURL,
+		PullRequestTitle:  ia.PullRequestTitle,
+		PullRequestBody:   ia.PullRequestBody,
+		Tag:               ia.Tag,
+		SenderUsername:    ia.SenderUsername,
+		SenderAvatarURL:   ia.SenderAvatarURL,
+		SenderHTMLURL:     ia.SenderHTMLURL,
+	}
+}
+
+type ConfigurationVersionHandler struct {
+	svc    service.Service
+	signer *auth.URLSigner
+	logger *zap.Logger
+}
+
+func NewConfigurationVersionHandler(svc service.Service, signer *auth.URLSigner, logger *zap.Logger) *ConfigurationVersionHandler {
+	return &ConfigurationVersionHandler{
+		svc:    svc,
+		signer: signer,
+		logger: logger.With(zap.String("handler", "configuration_version")),
+	}
+}
+
+func (h *ConfigurationVersionHandler) List(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	options := &tfe.ConfigurationVersionListOptions{ListOptions: ParseListOptions(r)}
+
+	cvs, pagination, err := h.svc.ListConfigurationVersions(r.Context(), vars["workspace_id"], options)
+	if err != nil {
+		h.logger.Error("failed to list configuration versions", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+	for _, cv := range cvs {
+		h.signURL(r, cv)
+	}
+
+	if err := MarshalListPayload(w, cvs, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *ConfigurationVersionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	workspaceID := vars["workspace_id"]
+	h.logger.Debug("creating configuration version", zap.String("workspace_id", workspaceID))
+
+	var req configurationVersionCreateRequest
+	if err := jsonapi.UnmarshalPayload(r.Body, &req); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	options := tfe.ConfigurationVersionCreateOptions{
+		AutoQueueRuns: req.AutoQueueRuns,
+		Speculative:   req.Speculative,
+		Provisional:   req.Provisional,
+	}
+	cv, err := h.svc.CreateConfigurationVersion(r.Context(), workspaceID, options, req.IngressAttributes.toTFE())
+	if err != nil {
+		h.logger.Debug("failed to create configuration version", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	h.marshal(w, r, cv)
+}
+
+func (h *ConfigurationVersionHandler) Read(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	cv, err := h.svc.ReadConfigurationVersion(r.Context(), vars["configuration_version_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	h.marshal(w, r, cv)
+}
+
+func (h *ConfigurationVersionHandler) ReadIngressAttributes(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	ia, err := h.svc.ReadIngressAttributes(r.Context(), vars["configuration_version_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	if err := jsonapi.MarshalPayload(w, ia); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *ConfigurationVersionHandler) Archive(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.ArchiveConfigurationVersion(r.Context(), vars["configuration_version_id"]); err != nil {
+		h.logger.Debug("failed to archive configuration version", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Download streams the uploaded archive to an authenticated client.
+func (h *ConfigurationVersionHandler) Download(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	content, err := h.svc.DownloadConfigurationVersion(r.Context(), vars["configuration_version_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", "application/x-gzip")
+	if _, err := io.Copy(w, content); err != nil {
+		h.logger.Error("failed to stream configuration version", zap.Error(err))
+	}
+}
+
+// Upload receives the archive through a signed upload URL.
+func (h *ConfigurationVersionHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	cvID := vars["configuration_version_id"]
+
+	if err := h.svc.UploadConfigurationVersion(r.Context(), cvID, r.Body); err != nil {
+		h.logger.Debug("failed to store configuration upload", zap.String("configuration_version_id", cvID), zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *ConfigurationVersionHandler) marshal(w http.ResponseWriter, r *http.Request, cv *tfe.ConfigurationVersion) {
+	h.signURL(r, cv)
+	if err := jsonapi.MarshalPayload(w, cv); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// signURL fills in the upload URL while the archive is still expected.
+func (h *ConfigurationVersionHandler) signURL(r *http.Request, cv *tfe.ConfigurationVersion) {
+	if cv.Status == tfe.ConfigurationPending {
+		cv.UploadURL = h.signer.SignUpload(r, constants.ArchivistPath+"/configuration-versions/"+cv.ID+"/upload", constants.SignedURLTTL)
+	}
+}
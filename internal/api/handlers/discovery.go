@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/open-tfe/tfe-service/internal/constants"
+	"go.uber.org/zap"
+)
+
+// LoginService describes the login.v1 service discovery entry used by
+// `terraform login`.
+type LoginService struct {
+	Client     string   `json:"client"`
+	GrantTypes []string `json:"grant_types"`
+	Authz      string   `json:"authz"`
+	Token      string   `json:"token"`
+	Ports      []int    `json:"ports"`
+}
+
+type DiscoveryHandler struct {
+	logger *zap.Logger
+}
+
+func NewDiscoveryHandler(logger *zap.Logger) *DiscoveryHandler {
+	return &DiscoveryHandler{
+		logger: logger.With(zap.String("handler", "discovery")),
+	}
+}
+
+// Discovery serves the Terraform remote service discovery document.
+func (h *DiscoveryHandler) Discovery(w http.ResponseWriter, r *http.Request) {
+	apiPath := constants.APIVersionPath + "/"
+
+	resp := map[string]interface{}{
+		"tfe.v2":       apiPath,
+		"tfe.v2.1":     apiPath,
+		"tfe.v2.2":     apiPath,
+		"modules.v1":   constants.ModuleRegistryPath,
+		"providers.v1": constants.ProviderRegistryPath,
+		"login.v1": LoginService{
+			Client:     constants.OAuthClientID,
+			GrantTypes: []string{"authz_code"},
+			Authz:      constants.OAuthAuthorizationPath,
+			Token:      constants.OAuthTokenPath,
+			Ports:      []int{10000, 10010},
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("failed to encode discovery document", zap.Error(err))
+	}
+}
+
+// Ping is used by Terraform to check connectivity and read the API version headers.
+func (h *DiscoveryHandler) Ping(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/jsonapi"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// GPGKeyHandler serves the GPG keys of the private registry, which sign
+// provider releases.
+type GPGKeyHandler struct {
+	svc    service.Service
+	logger *zap.Logger
+}
+
+func NewGPGKeyHandler(svc service.Service, logger *zap.Logger) *GPGKeyHandler {
+	return &GPGKeyHandler{
+		svc:    svc,
+		logger: logger.With(zap.String("handler", "gpg_key")),
+	}
+}
+
+// List lists the keys of the namespaces in filter[namespace], which may be
+// repeated or comma-separated.
+func (h *GPGKeyHandler) List(w http.ResponseWriter, r *http.Request) {
+	var namespaces []string
+	for _, filter := range r.URL.Query()["filter[namespace]"] {
+		for _, namespace := range strings.Split(filter, ",") {
+			if namespace = strings.TrimSpace(namespace); namespace != "" {
+				namespaces = append(namespaces, namespace)
+			}
+		}
+	}
+
+	keys, pagination, err := h.svc.ListGPGKeys(r.Context(), namespaces, ParseListOptions(r))
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, keys, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *GPGKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var options tfe.GPGKeyCreateOptions
+	if err := jsonapi.UnmarshalPayload(r.Body, &options); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	key, err := h.svc.CreateGPGKey(r.Context(), tfe.RegistryName(vars["registry_name"]), options)
+	if err != nil {
+		h.logger.Debug("failed to create GPG key", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	h.marshal(w, key)
+}
+
+func (h *GPGKeyHandler) Read(w http.ResponseWriter, r *http.Request) {
+	key, err := h.svc.ReadGPGKey(r.Context(), gpgKeyIDFromPath(r))
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	h.marshal(w, key)
+}
+
+func (h *GPGKeyHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var options tfe.GPGKeyUpdateOptions
+	if err := jsonapi.UnmarshalPayload(r.Body, &options); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	key, err := h.svc.UpdateGPGKey(r.Context(), gpgKeyIDFromPath(r), options)
+	if err != nil {
+		h.logger.Debug("failed to update GPG key", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	h.marshal(w, key)
+}
+
+func (h *GPGKeyHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.DeleteGPGKey(r.Context(), gpgKeyIDFromPath(r)); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *GPGKeyHandler) marshal(w http.ResponseWriter, key *tfe.GPGKey) {
+	if err := jsonapi.MarshalPayload(w, key); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func gpgKeyIDFromPath(r *http.Request) tfe.GPGKeyID {
+	vars := mux.Vars(r)
+	return tfe.GPGKeyID{
+		RegistryName: tfe.RegistryName(vars["registry_name"]),
+		Namespace:    vars["namespace"],
+		KeyID:        vars["key_id"],
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var invitationTemplate = template.Must(template.New("invitation").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Organization invitation</title></head>
+<body>
+{{if .Done}}
+<h1>{{.Done}}</h1>
+{{else}}
+<h1>Join {{.Organization}}</h1>
+<p>You have been invited to join the {{.Organization}} organization as {{.Email}}.</p>
+{{if .Error}}<p style="color:red">{{.Error}}</p>{{end}}
+<form method="POST">
+  {{if .NeedsPassword}}<label>Choose a password <input type="password" name="password" autofocus></label><br>{{end}}
+  <button type="submit" name="action" value="accept">Accept</button>
+  <button type="submit" name="action" value="decline">Decline</button>
+</form>
+{{end}}
+</body>
+</html>
+`))
+
+type invitationPage struct {
+	*service.Invitation
+	Error string
+	Done  string
+}
+
+// InvitationHandler serves the page linked from organization invitation
+// emails. The token in the link is the only credential.
+type InvitationHandler struct {
+	svc    service.Service
+	logger *zap.Logger
+}
+
+func NewInvitationHandler(svc service.Service, logger *zap.Logger) *InvitationHandler {
+	return &InvitationHandler{
+		svc:    svc,
+		logger: logger.With(zap.String("handler", "invitation")),
+	}
+}
+
+// Show renders the invitation with accept and decline buttons.
+func (h *InvitationHandler) Show(w http.ResponseWriter, r *http.Request) {
+	invitation, ok := h.read(w, r)
+	if !ok {
+		return
+	}
+	h.render(w, http.StatusOK, &invitationPage{Invitation: invitation})
+}
+
+// Answer accepts or declines the invitation.
+func (h *InvitationHandler) Answer(w http.ResponseWriter, r *http.Request) {
+	invitation, ok := h.read(w, r)
+	if !ok {
+		return
+	}
+	token := mux.Vars(r)["token"]
+
+	page := &invitationPage{Invitation: invitation}
+	var err error
+	switch r.PostFormValue("action") {
+	case "accept":
+		err = h.svc.AcceptInvitation(r.Context(), token, r.PostFormValue("password"))
+		page.Done = "Welcome to " + invitation.Organization + ". You can now log in as " + invitation.Email + "."
+	case "decline":
+		err = h.svc.DeclineInvitation(r.Context(), token)
+		page.Done = "You declined the invitation to " + invitation.Organization + "."
+	default:
+		http.Error(w, "action must be accept or decline", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		page.Done = ""
+		page.Error = err.Error()
+		h.render(w, ErrorStatus(err), page)
+		return
+	}
+	h.render(w, http.StatusOK, page)
+}
+
+func (h *InvitationHandler) read(w http.ResponseWriter, r *http.Request) (*service.Invitation, bool) {
+	invitation, err := h.svc.ReadInvitation(r.Context(), mux.Vars(r)["token"])
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "This invitation is invalid or has already been answered.", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		h.logger.Error("failed to read invitation", zap.Error(err))
+		http.Error(w, "Failed to read invitation", http.StatusInternalServerError)
+		return nil, false
+	}
+	return invitation, true
+}
+
+func (h *InvitationHandler) render(w http.ResponseWriter, status int, page *invitationPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := invitationTemplate.Execute(w, page); err != nil {
+		h.logger.Error("failed to render invitation", zap.Error(err))
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/open-tfe/tfe-service/internal/auth"
+	"github.com/open-tfe/tfe-service/internal/constants"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// moduleVersionsResponse is the module versions document of the module
+// registry protocol.
+type moduleVersionsResponse struct {
+	Modules []moduleVersions `json:"modules"`
+}
+
+type moduleVersions struct {
+	Versions []moduleVersion `json:"versions"`
+}
+
+type moduleVersion struct {
+	Version string `json:"version"`
+}
+
+// ModuleRegistryHandler serves the Terraform module registry protocol
+// advertised as modules.v1. Module sources take the form
+// <host>/<organization>/<name>/<provider>.
+type ModuleRegistryHandler struct {
+	svc    service.Service
+	signer *auth.URLSigner
+	logger *zap.Logger
+}
+
+func NewModuleRegistryHandler(svc service.Service, signer *auth.URLSigner, logger *zap.Logger) *ModuleRegistryHandler {
+	return &ModuleRegistryHandler{
+		svc:    svc,
+		signer: signer,
+		logger: logger.With(zap.String("handler", "module_registry")),
+	}
+}
+
+func (h *ModuleRegistryHandler) ListVersions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	versions, err := h.svc.ListModuleRegistryVersions(r.Context(), vars["namespace"], vars["name"], vars["provider"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	module := moduleVersions{Versions: make([]moduleVersion, len(versions))}
+	for i, v := range versions {
+		module.Versions[i] = moduleVersion{Version: v}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(moduleVersionsResponse{Modules: []moduleVersions{module}}); err != nil {
+		h.logger.Error("failed to encode module versions", zap.Error(err))
+	}
+}
+
+// Download points Terraform at a signed archive URL in the X-Terraform-Get
+// header. The URL path ends in .tar.gz so that Terraform unpacks it.
+func (h *ModuleRegistryHandler) Download(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	rmv, err := h.svc.ReadModuleRegistryVersion(r.Context(), vars["namespace"], vars["name"], vars["provider"], vars["version"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("X-Terraform-Get", h.signer.Sign(r, constants.ArchivistPath+"/registry-module-versions/"+rmv.ID+"/module.tar.gz", constants.SignedURLTTL))
+	w.WriteHeader(http.StatusNoContent)
+}
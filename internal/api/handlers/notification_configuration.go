@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/jsonapi"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// notificationConfigurationRequest mirrors the go-tfe notification
+// configuration create and update options with plain string destination
+// types and triggers, which jsonapi cannot decode into
+// *tfe.NotificationDestinationType and []tfe.NotificationTriggerType. The
+// subscribable workspace is taken from the path.
+type notificationConfigurationRequest struct {
+	Type            string   `jsonapi:"primary,notification-configurations"`
+	DestinationType *string  `jsonapi:"attr,destination-type,omitempty"`
+	Enabled         *bool    `jsonapi:"attr,enabled,omitempty"`
+	Name            *string  `jsonapi:"attr,name,omitempty"`
+	Token           *string  `jsonapi:"attr,token,omitempty"`
+	Triggers        []string `jsonapi:"attr,triggers,omitempty"`
+	URL             *string  `jsonapi:"attr,url,omitempty"`
+}
+
+func (req *notificationConfigurationRequest) triggers() []tfe.NotificationTriggerType {
+	if req.Triggers == nil {
+		return nil
+	}
+	triggers := make([]tfe.NotificationTriggerType, len(req.Triggers))
+	for i, trigger := range req.Triggers {
+		triggers[i] = tfe.NotificationTriggerType(trigger)
+	}
+	return triggers
+}
+
+func (req *notificationConfigurationRequest) createOptions() tfe.NotificationConfigurationCreateOptions {
+	options := tfe.NotificationConfigurationCreateOptions{
+		Enabled:  req.Enabled,
+		Name:     req.Name,
+		Token:    req.Token,
+		Triggers: req.triggers(),
+		URL:      req.URL,
+	}
+	if req.DestinationType != nil {
+		destinationType := tfe.NotificationDestinationType(*req.DestinationType)
+		options.DestinationType = &destinationType
+	}
+	return options
+}
+
+func (req *notificationConfigurationRequest) updateOptions() tfe.NotificationConfigurationUpdateOptions {
+	return tfe.NotificationConfigurationUpdateOptions{
+		Enabled:  req.Enabled,
+		Name:     req.Name,
+		Token:    req.Token,
+		Triggers: req.triggers(),
+		URL:      req.URL,
+	}
+}
+
+// NotificationConfigurationHandler serves the notification configurations
+// of workspaces.
+type NotificationConfigurationHandler struct {
+	svc    service.Service
+	logger *zap.Logger
+}
+
+func NewNotificationConfigurationHandler(svc service.Service, logger *zap.Logger) *NotificationConfigurationHandler {
+	return &NotificationConfigurationHandler{
+		svc:    svc,
+		logger: logger.With(zap.String("handler", "notification_configuration")),
+	}
+}
+
+func (h *NotificationConfigurationHandler) List(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	options := &tfe.NotificationConfigurationListOptions{ListOptions: ParseListOptions(r)}
+	configs, pagination, err := h.svc.ListNotificationConfigurations(r.Context(), vars["workspace_id"], options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, configs, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *NotificationConfigurationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req notificationConfigurationRequest
+	if err := jsonapi.UnmarshalPayload(r.Body, &req); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	config, err := h.svc.CreateNotificationConfiguration(r.Context(), vars["workspace_id"], req.createOptions())
+	if err != nil {
+		h.logger.Debug("failed to create notification configuration", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	if err := jsonapi.MarshalPayload(w, config); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+	}
+}
+
+func (h *NotificationConfigurationHandler) Read(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	config, err := h.svc.ReadNotificationConfiguration(r.Context(), vars["notification_configuration_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, config)
+}
+
+func (h *NotificationConfigurationHandler) Update(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req notificationConfigurationRequest
+	if err := jsonapi.UnmarshalPayload(r.Body, &req); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	config, err := h.svc.UpdateNotificationConfiguration(r.Context(), vars["notification_configuration_id"], req.updateOptions())
+	if err != nil {
+		h.logger.Debug("failed to update notification configuration", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, config)
+}
+
+func (h *NotificationConfigurationHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.DeleteNotificationConfiguration(r.Context(), vars["notification_configuration_id"]); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Verify sends a test payload to the destination and returns the
+// configuration with the destination's response.
+func (h *NotificationConfigurationHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	config, err := h.svc.VerifyNotificationConfiguration(r.Context(), vars["notification_configuration_id"])
+	if err != nil {
+		h.logger.Debug("failed to verify notification configuration", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, config)
+}
+
+func (h *NotificationConfigurationHandler) marshal(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	if err := jsonapi.MarshalPayload(w, v); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
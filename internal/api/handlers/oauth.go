@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/json"
+	"html/template"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/open-tfe/tfe-service/internal/constants"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+var loginTemplate = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Terraform login</title></head>
+<body>
+<h1>Authorize Terraform CLI</h1>
+{{if .Error}}<p style="color:red">{{.Error}}</p>{{end}}
+<form method="POST">
+  <input type="hidden" name="client_id" value="{{.ClientID}}">
+  <input type="hidden" name="redirect_uri" value="{{.RedirectURI}}">
+  <input type="hidden" name="response_type" value="{{.ResponseType}}">
+  <input type="hidden" name="state" value="{{.State}}">
+  <input type="hidden" name="code_challenge" value="{{.CodeChallenge}}">
+  <input type="hidden" name="code_challenge_method" value="{{.CodeChallengeMethod}}">
+  <label>Username or email <input type="text" name="username" autofocus></label><br>
+  <label>Password <input type="password" name="password"></label><br>
+  <button type="submit">Authorize</button>
+</form>
+</body>
+</html>
+`))
+
+type authorizeRequest struct {
+	ClientID            string
+	RedirectURI         string
+	ResponseType        string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Error               string
+}
+
+type OAuthHandler struct {
+	svc    service.Service
+	logger *zap.Logger
+}
+
+func NewOAuthHandler(svc service.Service, logger *zap.Logger) *OAuthHandler {
+	return &OAuthHandler{
+		svc:    svc,
+		logger: logger.With(zap.String("handler", "oauth")),
+	}
+}
+
+// Authorize renders the login form for the `terraform login` authorization-code flow.
+func (h *OAuthHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.parseAuthorizeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := loginTemplate.Execute(w, req); err != nil {
+		h.logger.Error("failed to render login form", zap.Error(err))
+	}
+}
+
+// AuthorizeSubmit authenticates the user and redirects back to Terraform with an authorization code.
+func (h *OAuthHandler) AuthorizeSubmit(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.parseAuthorizeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.svc.AuthenticateUser(r.Context(), r.PostFormValue("username"), r.PostFormValue("password"))
+	if err != nil {
+		req.Error = err.Error()
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusUnauthorized)
+		if err := loginTemplate.Execute(w, req); err != nil {
+			h.logger.Error("failed to render login form", zap.Error(err))
+		}
+		return
+	}
+
+	code, err := h.svc.CreateAuthorizationCode(r.Context(), user.ID, req.ClientID, req.RedirectURI, req.CodeChallenge)
+	if err != nil {
+		h.logger.Error("failed to create authorization code", zap.Error(err))
+		http.Error(w, "Failed to create authorization code", http.StatusInternalServerError)
+		return
+	}
+
+	redirect, _ := url.Parse(req.RedirectURI)
+	query := redirect.Query()
+	query.Set("code", code)
+	query.Set("state", req.State)
+	redirect.RawQuery = query.Encode()
+
+	h.logger.Debug("issued authorization code", zap.String("user", user.Username))
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// Token exchanges an authorization code and PKCE verifier for an API token.
+func (h *OAuthHandler) Token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		writeOAuthError(w, "unsupported_grant_type", http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.svc.ExchangeAuthorizationCode(r.Context(),
+		r.PostForm.Get("code"),
+		r.PostForm.Get("client_id"),
+		r.PostForm.Get("redirect_uri"),
+		r.PostForm.Get("code_verifier"),
+	)
+	if err != nil {
+		h.logger.Debug("authorization code exchange failed", zap.Error(err))
+		writeOAuthError(w, "invalid_grant", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": token.Token,
+		"token_type":   "bearer",
+		"expires_in":   int(constants.LoginTokenTTL.Seconds()),
+	})
+}
+
+func (h *OAuthHandler) parseAuthorizeRequest(w http.ResponseWriter, r *http.Request) (*authorizeRequest, bool) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	req := &authorizeRequest{
+		ClientID:            r.Form.Get("client_id"),
+		RedirectURI:         r.Form.Get("redirect_uri"),
+		ResponseType:        r.Form.Get("response_type"),
+		State:               r.Form.Get("state"),
+		CodeChallenge:       r.Form.Get("code_challenge"),
+		CodeChallengeMethod: r.Form.Get("code_challenge_method"),
+	}
+
+	switch {
+	case req.ClientID != constants.OAuthClientID:
+		http.Error(w, "Unknown client_id", http.StatusBadRequest)
+	case req.ResponseType != "code":
+		http.Error(w, "Unsupported response_type", http.StatusBadRequest)
+	case !isLoopbackRedirect(req.RedirectURI):
+		http.Error(w, "redirect_uri must be a loopback address", http.StatusBadRequest)
+	case req.CodeChallenge == "" || req.CodeChallengeMethod != "S256":
+		http.Error(w, "PKCE with code_challenge_method S256 is required", http.StatusBadRequest)
+	default:
+		return req, true
+	}
+	return nil, false
+}
+
+// isLoopbackRedirect reports whether uri points at the local listener started by `terraform login`.
+func isLoopbackRedirect(uri string) bool {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "http" {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func writeOAuthError(w http.ResponseWriter, code string, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
@@ -38,7 +38,7 @@ func (h *OrganizationHandler) List(w http.ResponseWriter, r *http.Request) {
 	orgs, err := h.svc.ListOrganizations(r.Context(), query)
 	if err != nil {
 		h.logger.Error("failed to list organizations", zap.Error(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		WriteError(w, err)
 		return
 	}
 
@@ -80,7 +80,7 @@ func (h *OrganizationHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	createdOrg, err := h.svc.CreateOrganization(r.Context(), org.ToTFE())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		WriteError(w, err)
 		return
 	}
 
@@ -103,7 +103,7 @@ func (h *OrganizationHandler) Read(w http.ResponseWriter, r *http.Request) {
 
 	org, err := h.svc.ReadOrganization(r.Context(), name)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		WriteError(w, err)
 		return
 	}
 
@@ -139,7 +139,7 @@ func (h *OrganizationHandler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.svc.UpdateOrganization(r.Context(), name, org.ToTFE()); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		WriteError(w, err)
 		return
 	}
 
@@ -160,7 +160,7 @@ func (h *OrganizationHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	name := vars["name"]
 
 	if err := h.svc.DeleteOrganization(r.Context(), name); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		WriteError(w, err)
 		return
 	}
 
@@ -176,7 +176,7 @@ func (h *OrganizationHandler) ReadEntitlements(w http.ResponseWriter, r *http.Re
 	entitlements, err := h.svc.ReadOrganizationEntitlements(r.Context(), name)
 	if err != nil {
 		h.logger.Error("failed to read organization entitlements", zap.Error(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		WriteError(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/vnd.api+json")
@@ -188,9 +188,21 @@ func (h *OrganizationHandler) ReadEntitlements(w http.ResponseWriter, r *http.Re
 	}
 }
 
+// ShowModuleProducers lists the organizations whose private registry
+// modules the organization can use.
 func (h *OrganizationHandler) ShowModuleProducers(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{"data": []interface{}{}})
+	vars := mux.Vars(r)
+
+	producers, err := h.svc.ListModuleProducers(r.Context(), vars["name"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, producers, nil); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
 }
 
 func (h *OrganizationHandler) ShowDataRetentionPolicy(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/jsonapi"
+	"github.com/open-tfe/tfe-service/internal/auth"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+type OrganizationMembershipHandler struct {
+	svc    service.Service
+	signer *auth.URLSigner
+	logger *zap.Logger
+}
+
+func NewOrganizationMembershipHandler(svc service.Service, signer *auth.URLSigner, logger *zap.Logger) *OrganizationMembershipHandler {
+	return &OrganizationMembershipHandler{
+		svc:    svc,
+		signer: signer,
+		logger: logger.With(zap.String("handler", "organization_membership")),
+	}
+}
+
+func (h *OrganizationMembershipHandler) List(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+
+	options := &tfe.OrganizationMembershipListOptions{
+		ListOptions: ParseListOptions(r),
+		Status:      tfe.OrganizationMembershipStatus(query.Get("filter[status]")),
+		Query:       query.Get("q"),
+	}
+	if emails := query.Get("filter[email]"); emails != "" {
+		options.Emails = strings.Split(emails, ",")
+	}
+
+	memberships, pagination, err := h.svc.ListOrganizationMemberships(r.Context(), vars["organization_name"], options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, memberships, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// ListForCurrentUser lists the memberships and open invitations of the
+// authenticated user.
+func (h *OrganizationMembershipHandler) ListForCurrentUser(w http.ResponseWriter, r *http.Request) {
+	memberships, err := h.svc.ListCurrentUserOrganizationMemberships(r.Context())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, memberships, nil); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// Create invites a user to the organization by email.
+func (h *OrganizationMembershipHandler) Create(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var options tfe.OrganizationMembershipCreateOptions
+	if err := jsonapi.UnmarshalPayload(r.Body, &options); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	membership, err := h.svc.CreateOrganizationMembership(r.Context(), vars["organization_name"], options, h.signer.BaseURL(r))
+	if err != nil {
+		h.logger.Debug("failed to create organization membership", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	if err := jsonapi.MarshalPayload(w, membership); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+	}
+}
+
+func (h *OrganizationMembershipHandler) Read(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	membership, err := h.svc.ReadOrganizationMembership(r.Context(), vars["membership_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, membership)
+}
+
+func (h *OrganizationMembershipHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.DeleteOrganizationMembership(r.Context(), vars["membership_id"]); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Accept accepts an invitation addressed to the authenticated user.
+func (h *OrganizationMembershipHandler) Accept(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	membership, err := h.svc.AcceptOrganizationMembership(r.Context(), vars["membership_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, membership)
+}
+
+// Decline declines an invitation addressed to the authenticated user.
+func (h *OrganizationMembershipHandler) Decline(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.DeclineOrganizationMembership(r.Context(), vars["membership_id"]); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *OrganizationMembershipHandler) marshal(w http.ResponseWriter, membership *tfe.OrganizationMembership) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	if err := jsonapi.MarshalPayload(w, membership); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
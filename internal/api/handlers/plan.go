@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/hashicorp/jsonapi"
+	"github.com/open-tfe/tfe-service/internal/auth"
+	"github.com/open-tfe/tfe-service/internal/constants"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// PlanHandler serves the plan and apply phases of runs and their logs.
+type PlanHandler struct {
+	svc    service.Service
+	signer *auth.URLSigner
+	logger *zap.Logger
+}
+
+func NewPlanHandler(svc service.Service, signer *auth.URLSigner, logger *zap.Logger) *PlanHandler {
+	return &PlanHandler{
+		svc:    svc,
+		signer: signer,
+		logger: logger.With(zap.String("handler", "plan")),
+	}
+}
+
+func (h *PlanHandler) ReadPlan(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	plan, err := h.svc.ReadPlan(r.Context(), vars["plan_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	plan.LogReadURL = h.logURL(r, "/plans/"+plan.ID)
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	if err := jsonapi.MarshalPayload(w, plan); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *PlanHandler) ReadApply(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	apply, err := h.svc.ReadApply(r.Context(), vars["apply_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	apply.LogReadURL = h.logURL(r, "/applies/"+apply.ID)
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	if err := jsonapi.MarshalPayload(w, apply); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// JSONOutput serves the JSON plan with sensitive values redacted.
+func (h *PlanHandler) JSONOutput(w http.ResponseWriter, r *http.Request) {
+	h.jsonOutput(w, r, mux.Vars(r)["plan_id"], h.svc.ReadPlanJSONOutput)
+}
+
+// RunJSONOutput serves the redacted JSON plan of a run.
+func (h *PlanHandler) RunJSONOutput(w http.ResponseWriter, r *http.Request) {
+	h.jsonOutput(w, r, mux.Vars(r)["run_id"], h.svc.ReadRunPlanJSONOutput)
+}
+
+func (h *PlanHandler) jsonOutput(w http.ResponseWriter, r *http.Request, id string, open func(context.Context, string) (io.ReadCloser, error)) {
+	content, err := open(r.Context(), id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := io.Copy(w, content); err != nil {
+		h.logger.Error("failed to stream JSON plan", zap.Error(err))
+	}
+}
+
+// PlanLog serves a plan log through its signed read URL.
+func (h *PlanHandler) PlanLog(w http.ResponseWriter, r *http.Request) {
+	h.log(w, r, mux.Vars(r)["plan_id"], h.svc.ReadPlanLog)
+}
+
+// ApplyLog serves an apply log through its signed read URL.
+func (h *PlanHandler) ApplyLog(w http.ResponseWriter, r *http.Request) {
+	h.log(w, r, mux.Vars(r)["apply_id"], h.svc.ReadApplyLog)
+}
+
+// log answers an archivist log read. Clients poll with increasing offsets
+// until they receive the end-of-text marker.
+func (h *PlanHandler) log(w http.ResponseWriter, r *http.Request, id string, read func(context.Context, string, int64, int64) ([]byte, error)) {
+	query := r.URL.Query()
+	offset, err := parseLogParam(query.Get("offset"))
+	if err != nil {
+		http.Error(w, "Invalid offset", http.StatusBadRequest)
+		return
+	}
+	limit, err := parseLogParam(query.Get("limit"))
+	if err != nil {
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return
+	}
+
+	data, err := read(r.Context(), id, offset, limit)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	if _, err := w.Write(data); err != nil {
+		h.logger.Debug("failed to write log", zap.Error(err))
+	}
+}
+
+// logURL signs the read URL of a log. The signature is carried in the path
+// because the go-tfe log reader replaces the query string with its offset
+// and limit.
+func (h *PlanHandler) logURL(r *http.Request, path string) string {
+	return h.signer.SignPath(r, constants.ArchivistPath+"/logs", path, constants.LogURLTTL)
+}
+
+func parseLogParam(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/jsonapi"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// PolicyHandler serves policies and their Rego source.
+type PolicyHandler struct {
+	svc    service.Service
+	logger *zap.Logger
+}
+
+func NewPolicyHandler(svc service.Service, logger *zap.Logger) *PolicyHandler {
+	return &PolicyHandler{
+		svc:    svc,
+		logger: logger.With(zap.String("handler", "policy")),
+	}
+}
+
+func (h *PolicyHandler) List(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	query := r.URL.Query()
+	options := &tfe.PolicyListOptions{
+		ListOptions: ParseListOptions(r),
+		Search:      query.Get("search[name]"),
+		Kind:        tfe.PolicyKind(query.Get("filter[kind]")),
+	}
+	policies, pagination, err := h.svc.ListPolicies(r.Context(), vars["organization_name"], options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, policies, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *PolicyHandler) Create(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var options tfe.PolicyCreateOptions
+	if err := jsonapi.UnmarshalPayload(r.Body, &options); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	policy, err := h.svc.CreatePolicy(r.Context(), vars["organization_name"], options)
+	if err != nil {
+		h.logger.Debug("failed to create policy", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	if err := jsonapi.MarshalPayload(w, policy); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+	}
+}
+
+func (h *PolicyHandler) Read(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	policy, err := h.svc.ReadPolicy(r.Context(), vars["policy_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, policy)
+}
+
+func (h *PolicyHandler) Update(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var options tfe.PolicyUpdateOptions
+	if err := jsonapi.UnmarshalPayload(r.Body, &options); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	policy, err := h.svc.UpdatePolicy(r.Context(), vars["policy_id"], options)
+	if err != nil {
+		h.logger.Debug("failed to update policy", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, policy)
+}
+
+func (h *PolicyHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.DeletePolicy(r.Context(), vars["policy_id"]); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Upload stores the Rego source of a policy, sent as the raw request body.
+func (h *PolicyHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.UploadPolicy(r.Context(), vars["policy_id"], r.Body); err != nil {
+		h.logger.Debug("failed to upload policy", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *PolicyHandler) Download(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	content, err := h.svc.DownloadPolicy(r.Context(), vars["policy_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if _, err := io.Copy(w, content); err != nil {
+		h.logger.Error("failed to stream policy", zap.Error(err))
+	}
+}
+
+func (h *PolicyHandler) marshal(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	if err := jsonapi.MarshalPayload(w, v); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/jsonapi"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// PolicySetHandler serves policy sets and their scope.
+type PolicySetHandler struct {
+	svc    service.Service
+	logger *zap.Logger
+}
+
+func NewPolicySetHandler(svc service.Service, logger *zap.Logger) *PolicySetHandler {
+	return &PolicySetHandler{
+		svc:    svc,
+		logger: logger.With(zap.String("handler", "policy_set")),
+	}
+}
+
+func (h *PolicySetHandler) List(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	query := r.URL.Query()
+	options := &tfe.PolicySetListOptions{
+		ListOptions: ParseListOptions(r),
+		Search:      query.Get("search[name]"),
+		Kind:        tfe.PolicyKind(query.Get("filter[kind]")),
+	}
+	sets, pagination, err := h.svc.ListPolicySets(r.Context(), vars["organization_name"], options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, sets, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *PolicySetHandler) Create(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var options tfe.PolicySetCreateOptions
+	if err := jsonapi.UnmarshalPayload(r.Body, &options); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	set, err := h.svc.CreatePolicySet(r.Context(), vars["organization_name"], options)
+	if err != nil {
+		h.logger.Debug("failed to create policy set", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	if err := jsonapi.MarshalPayload(w, set); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+	}
+}
+
+func (h *PolicySetHandler) Read(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	set, err := h.svc.ReadPolicySet(r.Context(), vars["policy_set_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, set)
+}
+
+func (h *PolicySetHandler) Update(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var options tfe.PolicySetUpdateOptions
+	if err := jsonapi.UnmarshalPayload(r.Body, &options); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	set, err := h.svc.UpdatePolicySet(r.Context(), vars["policy_set_id"], options)
+	if err != nil {
+		h.logger.Debug("failed to update policy set", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, set)
+}
+
+func (h *PolicySetHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.DeletePolicySet(r.Context(), vars["policy_set_id"]); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *PolicySetHandler) AddPolicies(w http.ResponseWriter, r *http.Request) {
+	h.relationships(w, r, h.svc.AddPolicySetPolicies)
+}
+
+func (h *PolicySetHandler) RemovePolicies(w http.ResponseWriter, r *http.Request) {
+	h.relationships(w, r, h.svc.RemovePolicySetPolicies)
+}
+
+func (h *PolicySetHandler) AddWorkspaces(w http.ResponseWriter, r *http.Request) {
+	h.relationships(w, r, h.svc.AddPolicySetWorkspaces)
+}
+
+func (h *PolicySetHandler) RemoveWorkspaces(w http.ResponseWriter, r *http.Request) {
+	h.relationships(w, r, h.svc.RemovePolicySetWorkspaces)
+}
+
+func (h *PolicySetHandler) AddWorkspaceExclusions(w http.ResponseWriter, r *http.Request) {
+	h.relationships(w, r, h.svc.AddPolicySetWorkspaceExclusions)
+}
+
+func (h *PolicySetHandler) RemoveWorkspaceExclusions(w http.ResponseWriter, r *http.Request) {
+	h.relationships(w, r, h.svc.RemovePolicySetWorkspaceExclusions)
+}
+
+func (h *PolicySetHandler) AddProjects(w http.ResponseWriter, r *http.Request) {
+	h.relationships(w, r, h.svc.AddPolicySetProjects)
+}
+
+func (h *PolicySetHandler) RemoveProjects(w http.ResponseWriter, r *http.Request) {
+	h.relationships(w, r, h.svc.RemovePolicySetProjects)
+}
+
+// relationships answers the relationship endpoints that add policies to a
+// set or change the workspaces and projects it applies to.
+func (h *PolicySetHandler) relationships(w http.ResponseWriter, r *http.Request, update func(context.Context, string, []string) error) {
+	vars := mux.Vars(r)
+
+	ids, err := ParseRelationshipIDs(r)
+	if err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := update(r.Context(), vars["policy_set_id"], ids); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *PolicySetHandler) marshal(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	if err := jsonapi.MarshalPayload(w, v); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
@@ -30,14 +30,14 @@ func (h *ProjectHandler) List(w http.ResponseWriter, r *http.Request) {
 	orgID, err := h.svc.GetOrganizationIDByName(r.Context(), orgName)
 	if err != nil {
 		h.logger.Error("failed to get organization ID", zap.String("organization_name", orgName), zap.Error(err))
-		http.Error(w, "Organization not found", http.StatusNotFound)
+		WriteError(w, err)
 		return
 	}
 	h.logger.Debug("Get organization ID", zap.String("organization_id", orgID.String()))
 	_, projects, err := h.svc.ListProjects(r.Context(), orgID)
 	if err != nil {
 		h.logger.Error("failed to list projects", zap.String("organization_id", orgID.String()), zap.Error(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		WriteError(w, err)
 		return
 	}
 
@@ -51,7 +51,9 @@ func (h *ProjectHandler) List(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ProjectHandler) Create(w http.ResponseWriter, r *http.Request) {
-	h.logger.Debug("creating project")
+	vars := mux.Vars(r)
+	orgName := vars["organization_name"]
+	h.logger.Debug("creating project", zap.String("organization_name", orgName))
 
 	var project *tfe.Project
 	if err := jsonapi.UnmarshalPayload(r.Body, &project); err != nil {
@@ -59,10 +61,11 @@ func (h *ProjectHandler) Create(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	project.Organization = &tfe.Organization{Name: orgName}
 
 	createdProject, err := h.svc.CreateProject(r.Context(), project)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		WriteError(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/vnd.api+json")
@@ -81,7 +84,7 @@ func (h *ProjectHandler) Read(w http.ResponseWriter, r *http.Request) {
 
 	project, err := h.svc.ReadProject(r.Context(), projectID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		WriteError(w, err)
 		return
 	}
 
@@ -109,7 +112,7 @@ func (h *ProjectHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 	updatedProject, err := h.svc.UpdateProject(r.Context(), project)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		WriteError(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/vnd.api+json")
@@ -126,7 +129,7 @@ func (h *ProjectHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	projectID := vars["project_id"]
 
 	if err := h.svc.DeleteProject(r.Context(), projectID); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		WriteError(w, err)
 		return
 	}
 
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/hashicorp/jsonapi"
+	"github.com/open-tfe/tfe-service/internal/auth"
+	"github.com/open-tfe/tfe-service/internal/constants"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// mirrorIndexResponse is the available versions document of the provider
+// network mirror protocol.
+type mirrorIndexResponse struct {
+	Versions map[string]struct{} `json:"versions"`
+}
+
+// mirrorVersionResponse is the available installation packages document of
+// the provider network mirror protocol, keyed by <os>_<arch>.
+type mirrorVersionResponse struct {
+	Archives map[string]mirrorArchive `json:"archives"`
+}
+
+type mirrorArchive struct {
+	URL    string   `json:"url"`
+	Hashes []string `json:"hashes"`
+}
+
+// ProviderMirrorHandler serves the provider network mirror protocol below
+// ProviderMirrorPath and the admin API that fills the mirror.
+type ProviderMirrorHandler struct {
+	svc    service.Service
+	signer *auth.URLSigner
+	logger *zap.Logger
+}
+
+func NewProviderMirrorHandler(svc service.Service, signer *auth.URLSigner, logger *zap.Logger) *ProviderMirrorHandler {
+	return &ProviderMirrorHandler{
+		svc:    svc,
+		signer: signer,
+		logger: logger.With(zap.String("handler", "provider_mirror")),
+	}
+}
+
+func (h *ProviderMirrorHandler) List(w http.ResponseWriter, r *http.Request) {
+	packages, pagination, err := h.svc.ListProviderMirrorPackages(r.Context(), ParseListOptions(r))
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, packages, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// Upload stores the provider package zip in the request body, replacing the
+// package of the same version and platform.
+func (h *ProviderMirrorHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	pkg, err := h.svc.UploadProviderMirrorPackage(r.Context(), mirrorPackageIDFromPath(r), r.Body)
+	if err != nil {
+		h.logger.Debug("failed to store mirror package", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	h.marshal(w, pkg)
+}
+
+func (h *ProviderMirrorHandler) Read(w http.ResponseWriter, r *http.Request) {
+	pkg, err := h.svc.ReadProviderMirrorPackage(r.Context(), mirrorPackageIDFromPath(r))
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	h.marshal(w, pkg)
+}
+
+func (h *ProviderMirrorHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.DeleteProviderMirrorPackage(r.Context(), mirrorPackageIDFromPath(r)); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Download streams a package through the signed URL handed out in the
+// version document.
+func (h *ProviderMirrorHandler) Download(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	content, err := h.svc.DownloadProviderMirrorPackage(r.Context(), vars["package_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	if _, err := io.Copy(w, content); err != nil {
+		h.logger.Error("failed to stream mirror package", zap.Error(err))
+	}
+}
+
+func (h *ProviderMirrorHandler) Index(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	versions, err := h.svc.ListProviderMirrorVersions(r.Context(), vars["hostname"], vars["namespace"], vars["type"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	response := mirrorIndexResponse{Versions: map[string]struct{}{}}
+	for _, v := range versions {
+		response.Versions[v] = struct{}{}
+	}
+	h.encode(w, response)
+}
+
+func (h *ProviderMirrorHandler) Version(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	packages, err := h.svc.ListProviderMirrorArchives(r.Context(), vars["hostname"], vars["namespace"], vars["type"], vars["version"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	response := mirrorVersionResponse{Archives: map[string]mirrorArchive{}}
+	for _, pkg := range packages {
+		response.Archives[pkg.OS+"_"+pkg.Arch] = mirrorArchive{
+			URL:    h.signer.Sign(r, constants.ArchivistPath+"/provider-mirror-packages/"+pkg.ID+"/"+pkg.Filename, constants.SignedURLTTL),
+			Hashes: pkg.Hashes,
+		}
+	}
+	h.encode(w, response)
+}
+
+func (h *ProviderMirrorHandler) marshal(w http.ResponseWriter, pkg *service.ProviderMirrorPackage) {
+	if err := jsonapi.MarshalPayload(w, pkg); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *ProviderMirrorHandler) encode(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("failed to encode provider mirror response", zap.Error(err))
+	}
+}
+
+func mirrorPackageIDFromPath(r *http.Request) service.ProviderMirrorPackageID {
+	vars := mux.Vars(r)
+	return service.ProviderMirrorPackageID{
+		Hostname:  vars["hostname"],
+		Namespace: vars["namespace"],
+		Type:      vars["type"],
+		Version:   vars["version"],
+		OS:        vars["os"],
+		Arch:      vars["arch"],
+	}
+}
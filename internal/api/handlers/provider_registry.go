@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/open-tfe/tfe-service/internal/auth"
+	"github.com/open-tfe/tfe-service/internal/constants"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// providerVersionsResponse is the available versions document of the
+// provider registry protocol.
+type providerVersionsResponse struct {
+	Versions []service.ProviderRegistryVersion `json:"versions"`
+}
+
+// providerPackageResponse is the find a provider package document of the
+// provider registry protocol.
+type providerPackageResponse struct {
+	Protocols           []string            `json:"protocols"`
+	OS                  string              `json:"os"`
+	Arch                string              `json:"arch"`
+	Filename            string              `json:"filename"`
+	DownloadURL         string              `json:"download_url"`
+	ShasumsURL          string              `json:"shasums_url"`
+	ShasumsSignatureURL string              `json:"shasums_signature_url"`
+	Shasum              string              `json:"shasum"`
+	SigningKeys         providerSigningKeys `json:"signing_keys"`
+}
+
+type providerSigningKeys struct {
+	GPGPublicKeys []providerGPGPublicKey `json:"gpg_public_keys"`
+}
+
+type providerGPGPublicKey struct {
+	KeyID          string  `json:"key_id"`
+	ASCIIArmor     string  `json:"ascii_armor"`
+	TrustSignature string  `json:"trust_signature"`
+	Source         string  `json:"source"`
+	SourceURL      *string `json:"source_url"`
+}
+
+// ProviderRegistryHandler serves the Terraform provider registry protocol
+// advertised as providers.v1. Provider sources take the form
+// <host>/<organization>/<name>.
+type ProviderRegistryHandler struct {
+	svc    service.Service
+	signer *auth.URLSigner
+	logger *zap.Logger
+}
+
+func NewProviderRegistryHandler(svc service.Service, signer *auth.URLSigner, logger *zap.Logger) *ProviderRegistryHandler {
+	return &ProviderRegistryHandler{
+		svc:    svc,
+		signer: signer,
+		logger: logger.With(zap.String("handler", "provider_registry")),
+	}
+}
+
+func (h *ProviderRegistryHandler) ListVersions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	versions, err := h.svc.ListProviderRegistryVersions(r.Context(), vars["namespace"], vars["name"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	h.encode(w, providerVersionsResponse{Versions: versions})
+}
+
+// Download describes the package of a platform with signed URLs for the
+// package, the SHA256SUMS file and its signature.
+func (h *ProviderRegistryHandler) Download(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	pkg, err := h.svc.ReadProviderRegistryPackage(r.Context(), vars["namespace"], vars["name"], vars["version"], vars["os"], vars["arch"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	versionPrefix := constants.ArchivistPath + "/registry-provider-versions/" + pkg.VersionID
+	response := providerPackageResponse{
+		Protocols:           pkg.Protocols,
+		OS:                  pkg.OS,
+		Arch:                pkg.Arch,
+		Filename:            pkg.Filename,
+		DownloadURL:         h.signer.Sign(r, constants.ArchivistPath+"/registry-provider-platforms/"+pkg.PlatformID+"/binary", constants.SignedURLTTL),
+		ShasumsURL:          h.signer.Sign(r, versionPrefix+"/shasums", constants.SignedURLTTL),
+		ShasumsSignatureURL: h.signer.Sign(r, versionPrefix+"/shasums-sig", constants.SignedURLTTL),
+		Shasum:              pkg.Shasum,
+	}
+	for _, key := range pkg.SigningKeys {
+		response.SigningKeys.GPGPublicKeys = append(response.SigningKeys.GPGPublicKeys, providerGPGPublicKey{
+			KeyID:          key.KeyID,
+			ASCIIArmor:     key.AsciiArmor,
+			TrustSignature: key.TrustSignature,
+			Source:         key.Source,
+			SourceURL:      key.SourceURL,
+		})
+	}
+	h.encode(w, response)
+}
+
+func (h *ProviderRegistryHandler) encode(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("failed to encode provider registry response", zap.Error(err))
+	}
+}
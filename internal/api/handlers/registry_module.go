@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/jsonapi"
+	"github.com/open-tfe/tfe-service/internal/auth"
+	"github.com/open-tfe/tfe-service/internal/constants"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// registryModuleVersion mirrors tfe.RegistryModuleVersion with its links,
+// which jsonapi only marshals through the Linkable interface.
+type registryModuleVersion struct {
+	ID             string                          `jsonapi:"primary,registry-module-versions"`
+	Source         string                          `jsonapi:"attr,source"`
+	Status         tfe.RegistryModuleVersionStatus `jsonapi:"attr,status"`
+	Version        string                          `jsonapi:"attr,version"`
+	CreatedAt      string                          `jsonapi:"attr,created-at"`
+	UpdatedAt      string                          `jsonapi:"attr,updated-at"`
+	RegistryModule *tfe.RegistryModule             `jsonapi:"relation,registry-module"`
+
+	links jsonapi.Links
+}
+
+func (v *registryModuleVersion) JSONAPILinks() *jsonapi.Links {
+	return &v.links
+}
+
+type RegistryModuleHandler struct {
+	svc    service.Service
+	signer *auth.URLSigner
+	logger *zap.Logger
+}
+
+func NewRegistryModuleHandler(svc service.Service, signer *auth.URLSigner, logger *zap.Logger) *RegistryModuleHandler {
+	return &RegistryModuleHandler{
+		svc:    svc,
+		signer: signer,
+		logger: logger.With(zap.String("handler", "registry_module")),
+	}
+}
+
+func (h *RegistryModuleHandler) List(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	options := &tfe.RegistryModuleListOptions{ListOptions: ParseListOptions(r)}
+
+	modules, pagination, err := h.svc.ListRegistryModules(r.Context(), vars["organization_name"], options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, modules, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *RegistryModuleHandler) Create(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var options tfe.RegistryModuleCreateOptions
+	if err := jsonapi.UnmarshalPayload(r.Body, &options); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	module, err := h.svc.CreateRegistryModule(r.Context(), vars["organization_name"], options)
+	if err != nil {
+		h.logger.Debug("failed to create registry module", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	h.marshal(w, module)
+}
+
+func (h *RegistryModuleHandler) Read(w http.ResponseWriter, r *http.Request) {
+	module, err := h.svc.ReadRegistryModule(r.Context(), moduleIDFromPath(r))
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	h.marshal(w, module)
+}
+
+func (h *RegistryModuleHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var options tfe.RegistryModuleUpdateOptions
+	if err := jsonapi.UnmarshalPayload(r.Body, &options); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	module, err := h.svc.UpdateRegistryModule(r.Context(), moduleIDFromPath(r), options)
+	if err != nil {
+		h.logger.Debug("failed to update registry module", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	h.marshal(w, module)
+}
+
+// Delete deletes a module. Without a provider in the path every provider of
+// the module name is deleted.
+func (h *RegistryModuleHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.DeleteRegistryModule(r.Context(), moduleIDFromPath(r)); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *RegistryModuleHandler) CreateVersion(w http.ResponseWriter, r *http.Request) {
+	var options tfe.RegistryModuleCreateVersionOptions
+	if err := jsonapi.UnmarshalPayload(r.Body, &options); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rmv, err := h.svc.CreateRegistryModuleVersion(r.Context(), moduleIDFromPath(r), options)
+	if err != nil {
+		h.logger.Debug("failed to create registry module version", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	h.marshalVersion(w, r, rmv)
+}
+
+func (h *RegistryModuleHandler) ReadVersion(w http.ResponseWriter, r *http.Request) {
+	rmv, err := h.svc.ReadRegistryModuleVersion(r.Context(), moduleIDFromPath(r), r.URL.Query().Get("module_version"))
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	h.marshalVersion(w, r, rmv)
+}
+
+func (h *RegistryModuleHandler) DeleteVersion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.DeleteRegistryModuleVersion(r.Context(), moduleIDFromPath(r), vars["version"]); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Upload receives the archive of a version through a signed upload URL.
+func (h *RegistryModuleHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	versionID := vars["version_id"]
+
+	if err := h.svc.UploadRegistryModuleVersion(r.Context(), versionID, r.Body); err != nil {
+		h.logger.Debug("failed to store module upload", zap.String("version_id", versionID), zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Download streams the archive of a version through the signed URL handed
+// out by the module registry protocol.
+func (h *RegistryModuleHandler) Download(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	content, err := h.svc.DownloadRegistryModuleVersion(r.Context(), vars["version_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", "application/x-gzip")
+	if _, err := io.Copy(w, content); err != nil {
+		h.logger.Error("failed to stream module version", zap.Error(err))
+	}
+}
+
+func (h *RegistryModuleHandler) marshal(w http.ResponseWriter, module *tfe.RegistryModule) {
+	if err := jsonapi.MarshalPayload(w, module); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// marshalVersion adds the upload link while the archive is still expected.
+func (h *RegistryModuleHandler) marshalVersion(w http.ResponseWriter, r *http.Request, rmv *tfe.RegistryModuleVersion) {
+	payload := &registryModuleVersion{
+		ID:             rmv.ID,
+		Source:         rmv.Source,
+		Status:         rmv.Status,
+		Version:        rmv.Version,
+		CreatedAt:      rmv.CreatedAt,
+		UpdatedAt:      rmv.UpdatedAt,
+		RegistryModule: rmv.RegistryModule,
+		links:          jsonapi.Links{},
+	}
+	if rmv.Status == tfe.RegistryModuleVersionStatusPending {
+		payload.links["upload"] = h.signer.SignUpload(r, constants.ArchivistPath+"/registry-module-versions/"+rmv.ID+"/upload", constants.SignedURLTTL)
+	}
+	if err := jsonapi.MarshalPayload(w, payload); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// moduleIDFromPath reads a module address from the path. Routes address a
+// module either by ID or by organization, registry name, namespace, name
+// and provider; the older routes omit the registry name and namespace.
+func moduleIDFromPath(r *http.Request) tfe.RegistryModuleID {
+	vars := mux.Vars(r)
+	return tfe.RegistryModuleID{
+		ID:           vars["registry_module_id"],
+		Organization: vars["organization_name"],
+		Namespace:    vars["namespace"],
+		Name:         vars["name"],
+		Provider:     vars["provider"],
+		RegistryName: tfe.RegistryName(vars["registry_name"]),
+	}
+}
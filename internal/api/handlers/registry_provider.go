@@ -0,0 +1,417 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/jsonapi"
+	"github.com/open-tfe/tfe-service/internal/auth"
+	"github.com/open-tfe/tfe-service/internal/constants"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// registryProviderVersion mirrors tfe.RegistryProviderVersion with its
+// links, which jsonapi only marshals through the Linkable interface.
+type registryProviderVersion struct {
+	ID                        string                                 `jsonapi:"primary,registry-provider-versions"`
+	Version                   string                                 `jsonapi:"attr,version"`
+	CreatedAt                 string                                 `jsonapi:"attr,created-at"`
+	UpdatedAt                 string                                 `jsonapi:"attr,updated-at"`
+	KeyID                     string                                 `jsonapi:"attr,key-id"`
+	Protocols                 []string                               `jsonapi:"attr,protocols"`
+	Permissions               tfe.RegistryProviderVersionPermissions `jsonapi:"attr,permissions"`
+	ShasumsUploaded           bool                                   `jsonapi:"attr,shasums-uploaded"`
+	ShasumsSigUploaded        bool                                   `jsonapi:"attr,shasums-sig-uploaded"`
+	RegistryProvider          *tfe.RegistryProvider                  `jsonapi:"relation,registry-provider"`
+	RegistryProviderPlatforms []*tfe.RegistryProviderPlatform        `jsonapi:"relation,platforms"`
+
+	links jsonapi.Links
+}
+
+func (v *registryProviderVersion) JSONAPILinks() *jsonapi.Links {
+	return &v.links
+}
+
+// registryProviderPlatform mirrors tfe.RegistryProviderPlatform with its
+// links.
+type registryProviderPlatform struct {
+	ID                      string                       `jsonapi:"primary,registry-provider-platforms"`
+	OS                      string                       `jsonapi:"attr,os"`
+	Arch                    string                       `jsonapi:"attr,arch"`
+	Filename                string                       `jsonapi:"attr,filename"`
+	Shasum                  string                       `jsonapi:"attr,shasum"`
+	ProviderBinaryUploaded  bool                         `jsonapi:"attr,provider-binary-uploaded"`
+	RegistryProviderVersion *tfe.RegistryProviderVersion `jsonapi:"relation,registry-provider-version"`
+
+	links jsonapi.Links
+}
+
+func (p *registryProviderPlatform) JSONAPILinks() *jsonapi.Links {
+	return &p.links
+}
+
+type RegistryProviderHandler struct {
+	svc    service.Service
+	signer *auth.URLSigner
+	logger *zap.Logger
+}
+
+func NewRegistryProviderHandler(svc service.Service, signer *auth.URLSigner, logger *zap.Logger) *RegistryProviderHandler {
+	return &RegistryProviderHandler{
+		svc:    svc,
+		signer: signer,
+		logger: logger.With(zap.String("handler", "registry_provider")),
+	}
+}
+
+func (h *RegistryProviderHandler) List(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+	options := &tfe.RegistryProviderListOptions{
+		ListOptions:  ParseListOptions(r),
+		RegistryName: tfe.RegistryName(query.Get("filter[registry_name]")),
+		Search:       query.Get("q"),
+	}
+
+	providers, pagination, err := h.svc.ListRegistryProviders(r.Context(), vars["organization_name"], options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, providers, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *RegistryProviderHandler) Create(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var options tfe.RegistryProviderCreateOptions
+	if err := jsonapi.UnmarshalPayload(r.Body, &options); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	provider, err := h.svc.CreateRegistryProvider(r.Context(), vars["organization_name"], options)
+	if err != nil {
+		h.logger.Debug("failed to create registry provider", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	h.marshal(w, provider)
+}
+
+func (h *RegistryProviderHandler) Read(w http.ResponseWriter, r *http.Request) {
+	provider, err := h.svc.ReadRegistryProvider(r.Context(), providerIDFromPath(r))
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	h.marshal(w, provider)
+}
+
+func (h *RegistryProviderHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.DeleteRegistryProvider(r.Context(), providerIDFromPath(r)); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *RegistryProviderHandler) ListVersions(w http.ResponseWriter, r *http.Request) {
+	options := &tfe.RegistryProviderVersionListOptions{ListOptions: ParseListOptions(r)}
+
+	versions, pagination, err := h.svc.ListRegistryProviderVersions(r.Context(), providerIDFromPath(r), options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	payload := make([]*registryProviderVersion, len(versions))
+	for i, v := range versions {
+		payload[i] = h.versionPayload(r, v)
+	}
+	if err := MarshalListPayload(w, payload, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *RegistryProviderHandler) CreateVersion(w http.ResponseWriter, r *http.Request) {
+	var options tfe.RegistryProviderVersionCreateOptions
+	if err := jsonapi.UnmarshalPayload(r.Body, &options); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v, err := h.svc.CreateRegistryProviderVersion(r.Context(), providerIDFromPath(r), options)
+	if err != nil {
+		h.logger.Debug("failed to create provider version", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	h.marshal(w, h.versionPayload(r, v))
+}
+
+func (h *RegistryProviderHandler) ReadVersion(w http.ResponseWriter, r *http.Request) {
+	v, err := h.svc.ReadRegistryProviderVersion(r.Context(), providerVersionIDFromPath(r))
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	h.marshal(w, h.versionPayload(r, v))
+}
+
+func (h *RegistryProviderHandler) DeleteVersion(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.DeleteRegistryProviderVersion(r.Context(), providerVersionIDFromPath(r)); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *RegistryProviderHandler) ListPlatforms(w http.ResponseWriter, r *http.Request) {
+	options := &tfe.RegistryProviderPlatformListOptions{ListOptions: ParseListOptions(r)}
+
+	platforms, pagination, err := h.svc.ListRegistryProviderPlatforms(r.Context(), providerVersionIDFromPath(r), options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	payload := make([]*registryProviderPlatform, len(platforms))
+	for i, p := range platforms {
+		payload[i] = h.platformPayload(r, p)
+	}
+	if err := MarshalListPayload(w, payload, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *RegistryProviderHandler) CreatePlatform(w http.ResponseWriter, r *http.Request) {
+	var options tfe.RegistryProviderPlatformCreateOptions
+	if err := jsonapi.UnmarshalPayload(r.Body, &options); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	platform, err := h.svc.CreateRegistryProviderPlatform(r.Context(), providerVersionIDFromPath(r), options)
+	if err != nil {
+		h.logger.Debug("failed to create provider platform", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	h.marshal(w, h.platformPayload(r, platform))
+}
+
+func (h *RegistryProviderHandler) ReadPlatform(w http.ResponseWriter, r *http.Request) {
+	platform, err := h.svc.ReadRegistryProviderPlatform(r.Context(), providerPlatformIDFromPath(r))
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	h.marshal(w, h.platformPayload(r, platform))
+}
+
+func (h *RegistryProviderHandler) DeletePlatform(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.DeleteRegistryProviderPlatform(r.Context(), providerPlatformIDFromPath(r)); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UploadShasums receives the SHA256SUMS file of a version through a signed
+// upload URL.
+func (h *RegistryProviderHandler) UploadShasums(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.UploadProviderShasums(r.Context(), vars["version_id"], r.Body); err != nil {
+		h.logger.Debug("failed to store SHA256SUMS", zap.String("version_id", vars["version_id"]), zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// UploadShasumsSignature receives the detached signature of the SHA256SUMS
+// file through a signed upload URL.
+func (h *RegistryProviderHandler) UploadShasumsSignature(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.UploadProviderShasumsSignature(r.Context(), vars["version_id"], r.Body); err != nil {
+		h.logger.Debug("failed to store SHA256SUMS signature", zap.String("version_id", vars["version_id"]), zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *RegistryProviderHandler) DownloadShasums(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	content, err := h.svc.DownloadProviderShasums(r.Context(), vars["version_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.stream(w, content, "text/plain")
+}
+
+func (h *RegistryProviderHandler) DownloadShasumsSignature(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	content, err := h.svc.DownloadProviderShasumsSignature(r.Context(), vars["version_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.stream(w, content, "application/octet-stream")
+}
+
+// UploadBinary receives the package of a platform through a signed upload
+// URL.
+func (h *RegistryProviderHandler) UploadBinary(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.UploadProviderBinary(r.Context(), vars["platform_id"], r.Body); err != nil {
+		h.logger.Debug("failed to store provider binary", zap.String("platform_id", vars["platform_id"]), zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *RegistryProviderHandler) DownloadBinary(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	content, err := h.svc.DownloadProviderBinary(r.Context(), vars["platform_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.stream(w, content, "application/zip")
+}
+
+func (h *RegistryProviderHandler) stream(w http.ResponseWriter, content io.ReadCloser, contentType string) {
+	defer content.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	if _, err := io.Copy(w, content); err != nil {
+		h.logger.Error("failed to stream provider file", zap.Error(err))
+	}
+}
+
+func (h *RegistryProviderHandler) marshal(w http.ResponseWriter, payload interface{}) {
+	if err := jsonapi.MarshalPayload(w, payload); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// versionPayload adds upload links for the files still expected and
+// download links for the uploaded ones.
+func (h *RegistryProviderHandler) versionPayload(r *http.Request, v *tfe.RegistryProviderVersion) *registryProviderVersion {
+	payload := &registryProviderVersion{
+		ID:                        v.ID,
+		Version:                   v.Version,
+		CreatedAt:                 v.CreatedAt,
+		UpdatedAt:                 v.UpdatedAt,
+		KeyID:                     v.KeyID,
+		Protocols:                 v.Protocols,
+		Permissions:               v.Permissions,
+		ShasumsUploaded:           v.ShasumsUploaded,
+		ShasumsSigUploaded:        v.ShasumsSigUploaded,
+		RegistryProvider:          v.RegistryProvider,
+		RegistryProviderPlatforms: v.RegistryProviderPlatforms,
+		links:                     jsonapi.Links{},
+	}
+	prefix := constants.ArchivistPath + "/registry-provider-versions/" + v.ID
+	if v.ShasumsUploaded {
+		payload.links["shasums-download"] = h.signer.Sign(r, prefix+"/shasums", constants.SignedURLTTL)
+	} else {
+		payload.links["shasums-upload"] = h.signer.SignUpload(r, prefix+"/shasums", constants.SignedURLTTL)
+	}
+	if v.ShasumsSigUploaded {
+		payload.links["shasums-sig-download"] = h.signer.Sign(r, prefix+"/shasums-sig", constants.SignedURLTTL)
+	} else {
+		payload.links["shasums-sig-upload"] = h.signer.SignUpload(r, prefix+"/shasums-sig", constants.SignedURLTTL)
+	}
+	return payload
+}
+
+func (h *RegistryProviderHandler) platformPayload(r *http.Request, p *tfe.RegistryProviderPlatform) *registryProviderPlatform {
+	payload := &registryProviderPlatform{
+		ID:                      p.ID,
+		OS:                      p.OS,
+		Arch:                    p.Arch,
+		Filename:                p.Filename,
+		Shasum:                  p.Shasum,
+		ProviderBinaryUploaded:  p.ProviderBinaryUploaded,
+		RegistryProviderVersion: p.RegistryProviderVersion,
+		links:                   jsonapi.Links{},
+	}
+	binary := constants.ArchivistPath + "/registry-provider-platforms/" + p.ID + "/binary"
+	if p.ProviderBinaryUploaded {
+		payload.links["provider-binary-download"] = h.signer.Sign(r, binary, constants.SignedURLTTL)
+	} else {
+		payload.links["provider-binary-upload"] = h.signer.SignUpload(r, binary, constants.SignedURLTTL)
+	}
+	return payload
+}
+
+func providerIDFromPath(r *http.Request) tfe.RegistryProviderID {
+	vars := mux.Vars(r)
+	return tfe.RegistryProviderID{
+		OrganizationName: vars["organization_name"],
+		RegistryName:     tfe.RegistryName(vars["registry_name"]),
+		Namespace:        vars["namespace"],
+		Name:             vars["name"],
+	}
+}
+
+func providerVersionIDFromPath(r *http.Request) tfe.RegistryProviderVersionID {
+	return tfe.RegistryProviderVersionID{
+		RegistryProviderID: providerIDFromPath(r),
+		Version:            mux.Vars(r)["version"],
+	}
+}
+
+func providerPlatformIDFromPath(r *http.Request) tfe.RegistryProviderPlatformID {
+	vars := mux.Vars(r)
+	return tfe.RegistryProviderPlatformID{
+		RegistryProviderVersionID: providerVersionIDFromPath(r),
+		OS:                        vars["os"],
+		Arch:                      vars["arch"],
+	}
+}
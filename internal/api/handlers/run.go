@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/jsonapi"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+type RunHandler struct {
+	svc    service.Service
+	logger *zap.Logger
+}
+
+func NewRunHandler(svc service.Service, logger *zap.Logger) *RunHandler {
+	return &RunHandler{
+		svc:    svc,
+		logger: logger.With(zap.String("handler", "run")),
+	}
+}
+
+func (h *RunHandler) List(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	runs, pagination, err := h.svc.ListRuns(r.Context(), vars["workspace_id"], parseRunListOptions(r))
+	if err != nil {
+		h.logger.Error("failed to list runs", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+	h.marshalList(w, runs, pagination)
+}
+
+func (h *RunHandler) ListForOrganization(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	runs, pagination, err := h.svc.ListOrganizationRuns(r.Context(), vars["organization_name"], parseRunListOptions(r))
+	if err != nil {
+		h.logger.Error("failed to list organization runs", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+	h.marshalList(w, runs, pagination)
+}
+
+func (h *RunHandler) ReadQueue(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	options := tfe.ReadRunQueueOptions{ListOptions: ParseListOptions(r)}
+
+	runs, pagination, err := h.svc.ReadRunQueue(r.Context(), vars["organization_name"], options)
+	if err != nil {
+		h.logger.Error("failed to read run queue", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+	h.marshalList(w, runs, pagination)
+}
+
+func (h *RunHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var options tfe.RunCreateOptions
+	if err := jsonapi.UnmarshalPayload(r.Body, &options); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	run, err := h.svc.CreateRun(r.Context(), options)
+	if err != nil {
+		h.logger.Debug("failed to create run", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	h.marshal(w, run)
+}
+
+func (h *RunHandler) Read(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	run, err := h.svc.ReadRun(r.Context(), vars["run_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	h.marshal(w, run)
+}
+
+func (h *RunHandler) Apply(w http.ResponseWriter, r *http.Request) {
+	var options tfe.RunApplyOptions
+	h.action(w, r, &options, func(runID string) error {
+		return h.svc.ApplyRun(r.Context(), runID, options)
+	})
+}
+
+func (h *RunHandler) Discard(w http.ResponseWriter, r *http.Request) {
+	var options tfe.RunDiscardOptions
+	h.action(w, r, &options, func(runID string) error {
+		return h.svc.DiscardRun(r.Context(), runID, options)
+	})
+}
+
+func (h *RunHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	var options tfe.RunCancelOptions
+	h.action(w, r, &options, func(runID string) error {
+		return h.svc.CancelRun(r.Context(), runID, options)
+	})
+}
+
+func (h *RunHandler) ForceCancel(w http.ResponseWriter, r *http.Request) {
+	var options tfe.RunForceCancelOptions
+	h.action(w, r, &options, func(runID string) error {
+		return h.svc.ForceCancelRun(r.Context(), runID, options)
+	})
+}
+
+func (h *RunHandler) ForceExecute(w http.ResponseWriter, r *http.Request) {
+	h.action(w, r, nil, func(runID string) error {
+		return h.svc.ForceExecuteRun(r.Context(), runID)
+	})
+}
+
+// action decodes the optional plain JSON body of a run action into options
+// and answers 202 once the action has been accepted.
+func (h *RunHandler) action(w http.ResponseWriter, r *http.Request, options interface{}, do func(runID string) error) {
+	vars := mux.Vars(r)
+
+	if options != nil {
+		if err := json.NewDecoder(r.Body).Decode(options); err != nil && !errors.Is(err, io.EOF) {
+			h.logger.Error("failed to decode request body", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := do(vars["run_id"]); err != nil {
+		h.logger.Debug("run action failed", zap.String("run_id", vars["run_id"]), zap.String("path", r.URL.Path), zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *RunHandler) marshal(w http.ResponseWriter, run *tfe.Run) {
+	if err := jsonapi.MarshalPayload(w, run); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *RunHandler) marshalList(w http.ResponseWriter, runs []*tfe.Run, pagination *tfe.Pagination) {
+	if err := MarshalListPayload(w, runs, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func parseRunListOptions(r *http.Request) *tfe.RunListOptions {
+	query := r.URL.Query()
+	return &tfe.RunListOptions{
+		ListOptions: ParseListOptions(r),
+		User:        query.Get("search[user]"),
+		Commit:      query.Get("search[commit]"),
+		Search:      query.Get("search[basic]"),
+		Status:      query.Get("filter[status]"),
+		Source:      query.Get("filter[source]"),
+		Operation:   query.Get("filter[operation]"),
+	}
+}
@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/jsonapi"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// runTaskRequest mirrors the go-tfe run task create and update options with
+// the global configuration as a plain map: jsonapi cannot decode its
+// pointer fields, and go-tfe sends it with Go field names rather than the
+// attribute names of the API.
+type runTaskRequest struct {
+	Type        string                 `jsonapi:"primary,tasks"`
+	Name        *string                `jsonapi:"attr,name,omitempty"`
+	URL         *string                `jsonapi:"attr,url,omitempty"`
+	Description *string                `jsonapi:"attr,description,omitempty"`
+	Category    *string                `jsonapi:"attr,category,omitempty"`
+	HMACKey     *string                `jsonapi:"attr,hmac-key,omitempty"`
+	Enabled     *bool                  `jsonapi:"attr,enabled,omitempty"`
+	Global      map[string]interface{} `jsonapi:"attr,global-configuration,omitempty"`
+}
+
+// global decodes the global configuration, matching keys regardless of
+// case and dashes so that both spellings are accepted.
+func (req *runTaskRequest) global() (*tfe.GlobalRunTaskOptions, error) {
+	if req.Global == nil {
+		return nil, nil
+	}
+	options := &tfe.GlobalRunTaskOptions{}
+	for key, value := range req.Global {
+		switch strings.ToLower(strings.ReplaceAll(key, "-", "")) {
+		case "enabled":
+			enabled, ok := value.(bool)
+			if !ok {
+				return nil, fmt.Errorf("global-configuration enabled must be a boolean")
+			}
+			options.Enabled = &enabled
+		case "stages":
+			values, ok := value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("global-configuration stages must be a list")
+			}
+			stages := make([]tfe.Stage, len(values))
+			for i, v := range values {
+				stage, ok := v.(string)
+				if !ok {
+					return nil, fmt.Errorf("global-configuration stages must be strings")
+				}
+				stages[i] = tfe.Stage(stage)
+			}
+			options.Stages = &stages
+		case "enforcementlevel":
+			level, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("global-configuration enforcement-level must be a string")
+			}
+			enforcementLevel := tfe.TaskEnforcementLevel(level)
+			options.EnforcementLevel = &enforcementLevel
+		}
+	}
+	return options, nil
+}
+
+func (req *runTaskRequest) createOptions() (tfe.RunTaskCreateOptions, error) {
+	global, err := req.global()
+	if err != nil {
+		return tfe.RunTaskCreateOptions{}, err
+	}
+	options := tfe.RunTaskCreateOptions{
+		Description: req.Description,
+		HMACKey:     req.HMACKey,
+		Enabled:     req.Enabled,
+		Global:      global,
+	}
+	if req.Name != nil {
+		options.Name = *req.Name
+	}
+	if req.URL != nil {
+		options.URL = *req.URL
+	}
+	if req.Category != nil {
+		options.Category = *req.Category
+	}
+	return options, nil
+}
+
+func (req *runTaskRequest) updateOptions() (tfe.RunTaskUpdateOptions, error) {
+	global, err := req.global()
+	if err != nil {
+		return tfe.RunTaskUpdateOptions{}, err
+	}
+	return tfe.RunTaskUpdateOptions{
+		Name:        req.Name,
+		URL:         req.URL,
+		Description: req.Description,
+		Category:    req.Category,
+		HMACKey:     req.HMACKey,
+		Enabled:     req.Enabled,
+		Global:      global,
+	}, nil
+}
+
+// workspaceRunTaskRequest mirrors the go-tfe workspace run task create and
+// update options with plain string stages, which jsonapi cannot decode into
+// *tfe.Stage and *[]tfe.Stage.
+type workspaceRunTaskRequest struct {
+	Type             string       `jsonapi:"primary,workspace-tasks"`
+	EnforcementLevel string       `jsonapi:"attr,enforcement-level,omitempty"`
+	RunTask          *tfe.RunTask `jsonapi:"relation,task"`
+	Stage            *string      `jsonapi:"attr,stage,omitempty"`
+	Stages           []string     `jsonapi:"attr,stages,omitempty"`
+}
+
+func (req *workspaceRunTaskRequest) stages() (*tfe.Stage, *[]tfe.Stage) {
+	var stage *tfe.Stage
+	if req.Stage != nil {
+		s := tfe.Stage(*req.Stage)
+		stage = &s
+	}
+	if req.Stages == nil {
+		return stage, nil
+	}
+	stages := make([]tfe.Stage, len(req.Stages))
+	for i, s := range req.Stages {
+		stages[i] = tfe.Stage(s)
+	}
+	return stage, &stages
+}
+
+func (req *workspaceRunTaskRequest) createOptions() tfe.WorkspaceRunTaskCreateOptions {
+	stage, stages := req.stages()
+	return tfe.WorkspaceRunTaskCreateOptions{
+		EnforcementLevel: tfe.TaskEnforcementLevel(req.EnforcementLevel),
+		RunTask:          req.RunTask,
+		Stage:            stage,
+		Stages:           stages,
+	}
+}
+
+func (req *workspaceRunTaskRequest) updateOptions() tfe.WorkspaceRunTaskUpdateOptions {
+	stage, stages := req.stages()
+	return tfe.WorkspaceRunTaskUpdateOptions{
+		EnforcementLevel: tfe.TaskEnforcementLevel(req.EnforcementLevel),
+		Stage:            stage,
+		Stages:           stages,
+	}
+}
+
+// RunTaskHandler serves the run tasks of organizations and their
+// attachments to workspaces.
+type RunTaskHandler struct {
+	svc    service.Service
+	logger *zap.Logger
+}
+
+func NewRunTaskHandler(svc service.Service, logger *zap.Logger) *RunTaskHandler {
+	return &RunTaskHandler{
+		svc:    svc,
+		logger: logger.With(zap.String("handler", "run_task")),
+	}
+}
+
+func (h *RunTaskHandler) List(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	options := &tfe.RunTaskListOptions{ListOptions: ParseListOptions(r)}
+	tasks, pagination, err := h.svc.ListRunTasks(r.Context(), vars["organization_name"], options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, tasks, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *RunTaskHandler) Create(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req runTaskRequest
+	if err := jsonapi.UnmarshalPayload(r.Body, &req); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	options, err := req.createOptions()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.svc.CreateRunTask(r.Context(), vars["organization_name"], options)
+	if err != nil {
+		h.logger.Debug("failed to create run task", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	if err := jsonapi.MarshalPayload(w, task); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+	}
+}
+
+func (h *RunTaskHandler) Read(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	task, err := h.svc.ReadRunTask(r.Context(), vars["task_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, task)
+}
+
+func (h *RunTaskHandler) Update(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req runTaskRequest
+	if err := jsonapi.UnmarshalPayload(r.Body, &req); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	options, err := req.updateOptions()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.svc.UpdateRunTask(r.Context(), vars["task_id"], options)
+	if err != nil {
+		h.logger.Debug("failed to update run task", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, task)
+}
+
+func (h *RunTaskHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.DeleteRunTask(r.Context(), vars["task_id"]); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *RunTaskHandler) ListWorkspaceTasks(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	options := &tfe.WorkspaceRunTaskListOptions{ListOptions: ParseListOptions(r)}
+	tasks, pagination, err := h.svc.ListWorkspaceRunTasks(r.Context(), vars["workspace_id"], options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, tasks, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *RunTaskHandler) CreateWorkspaceTask(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req workspaceRunTaskRequest
+	if err := jsonapi.UnmarshalPayload(r.Body, &req); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.svc.CreateWorkspaceRunTask(r.Context(), vars["workspace_id"], req.createOptions())
+	if err != nil {
+		h.logger.Debug("failed to create workspace run task", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	if err := jsonapi.MarshalPayload(w, task); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+	}
+}
+
+func (h *RunTaskHandler) ReadWorkspaceTask(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	task, err := h.svc.ReadWorkspaceRunTask(r.Context(), vars["workspace_id"], vars["workspace_task_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, task)
+}
+
+func (h *RunTaskHandler) UpdateWorkspaceTask(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req workspaceRunTaskRequest
+	if err := jsonapi.UnmarshalPayload(r.Body, &req); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.svc.UpdateWorkspaceRunTask(r.Context(), vars["workspace_id"], vars["workspace_task_id"], req.updateOptions())
+	if err != nil {
+		h.logger.Debug("failed to update workspace run task", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, task)
+}
+
+func (h *RunTaskHandler) DeleteWorkspaceTask(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.DeleteWorkspaceRunTask(r.Context(), vars["workspace_id"], vars["workspace_task_id"]); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *RunTaskHandler) marshal(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	if err := jsonapi.MarshalPayload(w, v); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/jsonapi"
+	"github.com/open-tfe/tfe-service/internal/auth"
+	"github.com/open-tfe/tfe-service/internal/constants"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+type StateVersionHandler struct {
+	svc    service.Service
+	signer *auth.URLSigner
+	logger *zap.Logger
+}
+
+func NewStateVersionHandler(svc service.Service, signer *auth.URLSigner, logger *zap.Logger) *StateVersionHandler {
+	return &StateVersionHandler{
+		svc:    svc,
+		signer: signer,
+		logger: logger.With(zap.String("handler", "state_version")),
+	}
+}
+
+func (h *StateVersionHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	options := &tfe.StateVersionListOptions{
+		ListOptions:  ParseListOptions(r),
+		Organization: query.Get("filter[organization][name]"),
+		Workspace:    query.Get("filter[workspace][name]"),
+	}
+
+	stateVersions, pagination, err := h.svc.ListStateVersions(r.Context(), options)
+	if err != nil {
+		h.logger.Error("failed to list state versions", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+	for _, sv := range stateVersions {
+		h.signURLs(r, sv)
+	}
+
+	if err := MarshalListPayload(w, stateVersions, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *StateVersionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	workspaceID := vars["workspace_id"]
+	h.logger.Debug("creating state version", zap.String("workspace_id", workspaceID))
+
+	var options tfe.StateVersionCreateOptions
+	if err := jsonapi.UnmarshalPayload(r.Body, &options); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sv, err := h.svc.CreateStateVersion(r.Context(), workspaceID, options)
+	if err != nil {
+		h.logger.Debug("failed to create state version", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	h.marshal(w, r, sv)
+}
+
+func (h *StateVersionHandler) Read(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	sv, err := h.svc.ReadStateVersion(r.Context(), vars["state_version_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	h.marshal(w, r, sv)
+}
+
+func (h *StateVersionHandler) ReadCurrent(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	sv, err := h.svc.ReadCurrentStateVersion(r.Context(), vars["workspace_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	h.marshal(w, r, sv)
+}
+
+func (h *StateVersionHandler) ListOutputs(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	outputs, err := h.svc.ListStateVersionOutputs(r.Context(), vars["state_version_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.marshalOutputs(w, outputs)
+}
+
+func (h *StateVersionHandler) ReadCurrentOutputs(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	outputs, err := h.svc.ReadCurrentStateVersionOutputs(r.Context(), vars["workspace_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.marshalOutputs(w, outputs)
+}
+
+func (h *StateVersionHandler) ReadOutput(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	output, err := h.svc.ReadStateVersionOutput(r.Context(), vars["output_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	if err := jsonapi.MarshalPayload(w, output); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// UploadState receives the raw state through a signed upload URL.
+func (h *StateVersionHandler) UploadState(w http.ResponseWriter, r *http.Request) {
+	h.upload(w, r, h.svc.UploadStateVersionContent)
+}
+
+// UploadJSONState receives the JSON state through a signed upload URL.
+func (h *StateVersionHandler) UploadJSONState(w http.ResponseWriter, r *http.Request) {
+	h.upload(w, r, h.svc.UploadStateVersionJSONContent)
+}
+
+// DownloadState serves the raw state through a signed download URL.
+func (h *StateVersionHandler) DownloadState(w http.ResponseWriter, r *http.Request) {
+	h.download(w, r, h.svc.DownloadStateVersionContent)
+}
+
+// DownloadJSONState serves the JSON state through a signed download URL.
+func (h *StateVersionHandler) DownloadJSONState(w http.ResponseWriter, r *http.Request) {
+	h.download(w, r, h.svc.DownloadStateVersionJSONContent)
+}
+
+func (h *StateVersionHandler) upload(w http.ResponseWriter, r *http.Request, store func(ctx context.Context, svID string, r io.Reader) error) {
+	vars := mux.Vars(r)
+	svID := vars["state_version_id"]
+
+	if err := store(r.Context(), svID, r.Body); err != nil {
+		h.logger.Debug("failed to store state upload", zap.String("state_version_id", svID), zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// signURLs fills in the hosted state URLs that match the state version status.
+func (h *StateVersionHandler) signURLs(r *http.Request, sv *tfe.StateVersion) {
+	base := constants.ArchivistPath + "/state-versions/" + sv.ID
+	if sv.Status == tfe.StateVersionPending {
+		sv.UploadURL = h.signer.SignUpload(r, base+"/state", constants.SignedURLTTL)
+		sv.JSONUploadURL = h.signer.SignUpload(r, base+"/json-state", constants.SignedURLTTL)
+		return
+	}
+	sv.DownloadURL = h.signer.Sign(r, base+"/state", constants.SignedURLTTL)
+	sv.JSONDownloadURL = h.signer.Sign(r, base+"/json-state", constants.SignedURLTTL)
+}
+
+func (h *StateVersionHandler) marshal(w http.ResponseWriter, r *http.Request, sv *tfe.StateVersion) {
+	h.signURLs(r, sv)
+	if err := jsonapi.MarshalPayload(w, sv); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *StateVersionHandler) marshalOutputs(w http.ResponseWriter, outputs []*tfe.StateVersionOutput) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	if err := jsonapi.MarshalPayload(w, outputs); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *StateVersionHandler) download(w http.ResponseWriter, r *http.Request, open func(ctx context.Context, svID string) (io.ReadCloser, error)) {
+	vars := mux.Vars(r)
+
+	content, err := open(r.Context(), vars["state_version_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := io.Copy(w, content); err != nil {
+		h.logger.Error("failed to stream state", zap.Error(err))
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/jsonapi"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// TaskResultHandler serves the results of run tasks and receives the
+// outcomes tasks report for them.
+type TaskResultHandler struct {
+	svc    service.Service
+	logger *zap.Logger
+}
+
+func NewTaskResultHandler(svc service.Service, logger *zap.Logger) *TaskResultHandler {
+	return &TaskResultHandler{
+		svc:    svc,
+		logger: logger.With(zap.String("handler", "task_result")),
+	}
+}
+
+func (h *TaskResultHandler) Read(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	result, err := h.svc.ReadTaskResult(r.Context(), vars["task_result_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	if err := jsonapi.MarshalPayload(w, result); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// Callback receives the outcome of a task, as sent by
+// RunTasksIntegration.Callback. The task authenticates with the access
+// token of its request rather than an API token.
+func (h *TaskResultHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		WriteError(w, service.ErrInvalidToken)
+		return
+	}
+
+	var options tfe.TaskResultCallbackRequestOptions
+	if err := jsonapi.UnmarshalPayload(r.Body, &options); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.svc.CallbackTaskResult(r.Context(), vars["task_result_id"], token, options); err != nil {
+		h.logger.Debug("failed to record task result", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+
+	"github.com/gorilla/mux"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/jsonapi"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// policySetOutcomeFilter matches the filter[n][field] query parameters of
+// the policy set outcome list.
+var policySetOutcomeFilter = regexp.MustCompile(`^filter\[([^\]]+)\]\[(status|enforcement_level)\]$`)
+
+// TaskStageHandler serves the task stages of runs and the policy
+// evaluations recorded in them.
+type TaskStageHandler struct {
+	svc    service.Service
+	logger *zap.Logger
+}
+
+func NewTaskStageHandler(svc service.Service, logger *zap.Logger) *TaskStageHandler {
+	return &TaskStageHandler{
+		svc:    svc,
+		logger: logger.With(zap.String("handler", "task_stage")),
+	}
+}
+
+func (h *TaskStageHandler) List(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	options := &tfe.TaskStageListOptions{ListOptions: ParseListOptions(r)}
+	stages, pagination, err := h.svc.ListRunTaskStages(r.Context(), vars["run_id"], options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, stages, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *TaskStageHandler) Read(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	stage, err := h.svc.ReadTaskStage(r.Context(), vars["task_stage_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, stage)
+}
+
+// Override lets a run held by failed mandatory policies continue. The
+// request may carry a plain JSON comment, as sent by TaskStages.Override.
+func (h *TaskStageHandler) Override(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var options tfe.TaskStageOverrideOptions
+	if err := json.NewDecoder(r.Body).Decode(&options); err != nil && err != io.EOF {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stage, err := h.svc.OverrideTaskStage(r.Context(), vars["task_stage_id"], options)
+	if err != nil {
+		h.logger.Debug("failed to override task stage", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, stage)
+}
+
+func (h *TaskStageHandler) ListPolicyEvaluations(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	options := &tfe.PolicyEvaluationListOptions{ListOptions: ParseListOptions(r)}
+	evaluations, pagination, err := h.svc.ListPolicyEvaluations(r.Context(), vars["task_stage_id"], options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, evaluations, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *TaskStageHandler) ListPolicySetOutcomes(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	listOptions := ParseListOptions(r)
+	options := &tfe.PolicySetOutcomeListOptions{ListOptions: &listOptions}
+	for key, values := range r.URL.Query() {
+		m := policySetOutcomeFilter.FindStringSubmatch(key)
+		if m == nil || len(values) == 0 {
+			continue
+		}
+		if options.Filter == nil {
+			options.Filter = map[string]tfe.PolicySetOutcomeListFilter{}
+		}
+		filter := options.Filter[m[1]]
+		if m[2] == "status" {
+			filter.Status = values[0]
+		} else {
+			filter.EnforcementLevel = values[0]
+		}
+		options.Filter[m[1]] = filter
+	}
+
+	outcomes, pagination, err := h.svc.ListPolicySetOutcomes(r.Context(), vars["policy_evaluation_id"], options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, outcomes, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *TaskStageHandler) ReadPolicySetOutcome(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	outcome, err := h.svc.ReadPolicySetOutcome(r.Context(), vars["policy_set_outcome_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, outcome)
+}
+
+func (h *TaskStageHandler) marshal(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	if err := jsonapi.MarshalPayload(w, v); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
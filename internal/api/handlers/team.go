@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/jsonapi"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// organizationAccessRequest mirrors tfe.OrganizationAccessOptions, whose
+// json tags the jsonapi decoder ignores.
+type organizationAccessRequest struct {
+	ManagePolicies           *bool `jsonapi:"attr,manage-policies,omitempty"`
+	ManagePolicyOverrides    *bool `jsonapi:"attr,manage-policy-overrides,omitempty"`
+	ManageWorkspaces         *bool `jsonapi:"attr,manage-workspaces,omitempty"`
+	ManageVCSSettings        *bool `jsonapi:"attr,manage-vcs-settings,omitempty"`
+	ManageProviders          *bool `jsonapi:"attr,manage-providers,omitempty"`
+	ManageModules            *bool `jsonapi:"attr,manage-modules,omitempty"`
+	ManageRunTasks           *bool `jsonapi:"attr,manage-run-tasks,omitempty"`
+	ManageProjects           *bool `jsonapi:"attr,manage-projects,omitempty"`
+	ReadWorkspaces           *bool `jsonapi:"attr,read-workspaces,omitempty"`
+	ReadProjects             *bool `jsonapi:"attr,read-projects,omitempty"`
+	ManageMembership         *bool `jsonapi:"attr,manage-membership,omitempty"`
+	ManageTeams              *bool `jsonapi:"attr,manage-teams,omitempty"`
+	ManageOrganizationAccess *bool `jsonapi:"attr,manage-organization-access,omitempty"`
+	AccessSecretTeams        *bool `jsonapi:"attr,access-secret-teams,omitempty"`
+	ManageAgentPools         *bool `jsonapi:"attr,manage-agent-pools,omitempty"`
+}
+
+// teamRequest is the body of team create and update requests.
+type teamRequest struct {
+	Type                       string                     `jsonapi:"primary,teams"`
+	Name                       *string                    `jsonapi:"attr,name,omitempty"`
+	SSOTeamID                  *string                    `jsonapi:"attr,sso-team-id,omitempty"`
+	OrganizationAccess         *organizationAccessRequest `jsonapi:"attr,organization-access,omitempty"`
+	Visibility                 *string                    `jsonapi:"attr,visibility,omitempty"`
+	AllowMemberTokenManagement *bool                      `jsonapi:"attr,allow-member-token-management,omitempty"`
+}
+
+func (req *teamRequest) organizationAccess() *tfe.OrganizationAccessOptions {
+	a := req.OrganizationAccess
+	if a == nil {
+		return nil
+	}
+	return &tfe.OrganizationAccessOptions{
+		ManagePolicies:           a.ManagePolicies,
+		ManagePolicyOverrides:    a.ManagePolicyOverrides,
+		ManageWorkspaces:         a.ManageWorkspaces,
+		ManageVCSSettings:        a.ManageVCSSettings,
+		ManageProviders:          a.ManageProviders,
+		ManageModules:            a.ManageModules,
+		ManageRunTasks:           a.ManageRunTasks,
+		ManageProjects:           a.ManageProjects,
+		ReadWorkspaces:           a.ReadWorkspaces,
+		ReadProjects:             a.ReadProjects,
+		ManageMembership:         a.ManageMembership,
+		ManageTeams:              a.ManageTeams,
+		ManageOrganizationAccess: a.ManageOrganizationAccess,
+		AccessSecretTeams:        a.AccessSecretTeams,
+		ManageAgentPools:         a.ManageAgentPools,
+	}
+}
+
+func (req *teamRequest) createOptions() tfe.TeamCreateOptions {
+	return tfe.TeamCreateOptions{
+		Name:                       req.Name,
+		SSOTeamID:                  req.SSOTeamID,
+		OrganizationAccess:         req.organizationAccess(),
+		Visibility:                 req.Visibility,
+		AllowMemberTokenManagement: req.AllowMemberTokenManagement,
+	}
+}
+
+func (req *teamRequest) updateOptions() tfe.TeamUpdateOptions {
+	return tfe.TeamUpdateOptions{
+		Name:                       req.Name,
+		SSOTeamID:                  req.SSOTeamID,
+		OrganizationAccess:         req.organizationAccess(),
+		Visibility:                 req.Visibility,
+		AllowMemberTokenManagement: req.AllowMemberTokenManagement,
+	}
+}
+
+type TeamHandler struct {
+	svc    service.Service
+	logger *zap.Logger
+}
+
+func NewTeamHandler(svc service.Service, logger *zap.Logger) *TeamHandler {
+	return &TeamHandler{
+		svc:    svc,
+		logger: logger.With(zap.String("handler", "team")),
+	}
+}
+
+func (h *TeamHandler) List(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+
+	options := &tfe.TeamListOptions{
+		ListOptions: ParseListOptions(r),
+		Query:       query.Get("q"),
+	}
+	if names := query.Get("filter[names]"); names != "" {
+		options.Names = strings.Split(names, ",")
+	}
+
+	teams, pagination, err := h.svc.ListTeams(r.Context(), vars["organization_name"], options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, teams, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *TeamHandler) Create(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req teamRequest
+	if err := jsonapi.UnmarshalPayload(r.Body, &req); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	team, err := h.svc.CreateTeam(r.Context(), vars["organization_name"], req.createOptions())
+	if err != nil {
+		h.logger.Debug("failed to create team", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	if err := jsonapi.MarshalPayload(w, team); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+	}
+}
+
+func (h *TeamHandler) Read(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	team, err := h.svc.ReadTeam(r.Context(), vars["team_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, team)
+}
+
+func (h *TeamHandler) Update(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req teamRequest
+	if err := jsonapi.UnmarshalPayload(r.Body, &req); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	team, err := h.svc.UpdateTeam(r.Context(), vars["team_id"], req.updateOptions())
+	if err != nil {
+		h.logger.Debug("failed to update team", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, team)
+}
+
+func (h *TeamHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.DeleteTeam(r.Context(), vars["team_id"]); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddUsers adds users to a team. The relationship identifies users by username.
+func (h *TeamHandler) AddUsers(w http.ResponseWriter, r *http.Request) {
+	h.members(w, r, func(ctx context.Context, teamID string, ids []string) error {
+		return h.svc.AddTeamMembers(ctx, teamID, tfe.TeamMemberAddOptions{Usernames: ids})
+	})
+}
+
+// RemoveUsers removes users, identified by username, from a team.
+func (h *TeamHandler) RemoveUsers(w http.ResponseWriter, r *http.Request) {
+	h.members(w, r, func(ctx context.Context, teamID string, ids []string) error {
+		return h.svc.RemoveTeamMembers(ctx, teamID, tfe.TeamMemberRemoveOptions{Usernames: ids})
+	})
+}
+
+// AddOrganizationMemberships adds the users of organization memberships to a team.
+func (h *TeamHandler) AddOrganizationMemberships(w http.ResponseWriter, r *http.Request) {
+	h.members(w, r, func(ctx context.Context, teamID string, ids []string) error {
+		return h.svc.AddTeamMembers(ctx, teamID, tfe.TeamMemberAddOptions{OrganizationMembershipIDs: ids})
+	})
+}
+
+// RemoveOrganizationMemberships removes the users of organization memberships from a team.
+func (h *TeamHandler) RemoveOrganizationMemberships(w http.ResponseWriter, r *http.Request) {
+	h.members(w, r, func(ctx context.Context, teamID string, ids []string) error {
+		return h.svc.RemoveTeamMembers(ctx, teamID, tfe.TeamMemberRemoveOptions{OrganizationMembershipIDs: ids})
+	})
+}
+
+func (h *TeamHandler) members(w http.ResponseWriter, r *http.Request, update func(context.Context, string, []string) error) {
+	vars := mux.Vars(r)
+
+	ids, err := ParseRelationshipIDs(r)
+	if err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := update(r.Context(), vars["team_id"], ids); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TeamHandler) marshal(w http.ResponseWriter, team *tfe.Team) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	if err := jsonapi.MarshalPayload(w, team); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/jsonapi"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// projectAccessRequest mirrors tfe.TeamProjectAccessProjectPermissionsOptions,
+// whose json tags the jsonapi decoder ignores.
+type projectAccessRequest struct {
+	Settings *tfe.ProjectSettingsPermissionType `jsonapi:"attr,settings,omitempty"`
+	Teams    *tfe.ProjectTeamsPermissionType    `jsonapi:"attr,teams,omitempty"`
+}
+
+// workspaceAccessRequest mirrors tfe.TeamProjectAccessWorkspacePermissionsOptions.
+type workspaceAccessRequest struct {
+	Runs          *tfe.WorkspaceRunsPermissionType          `jsonapi:"attr,runs,omitempty"`
+	SentinelMocks *tfe.WorkspaceSentinelMocksPermissionType `jsonapi:"attr,sentinel-mocks,omitempty"`
+	StateVersions *tfe.WorkspaceStateVersionsPermissionType `jsonapi:"attr,state-versions,omitempty"`
+	Variables     *tfe.WorkspaceVariablesPermissionType     `jsonapi:"attr,variables,omitempty"`
+	Create        *bool                                     `jsonapi:"attr,create,omitempty"`
+	Locking       *bool                                     `jsonapi:"attr,locking,omitempty"`
+	Move          *bool                                     `jsonapi:"attr,move,omitempty"`
+	Delete        *bool                                     `jsonapi:"attr,delete,omitempty"`
+	RunTasks      *bool                                     `jsonapi:"attr,run-tasks,omitempty"`
+}
+
+// teamProjectAccessRequest is the body of team project access add and
+// update requests.
+type teamProjectAccessRequest struct {
+	Type            string                  `jsonapi:"primary,team-projects"`
+	Access          *string                 `jsonapi:"attr,access,omitempty"`
+	ProjectAccess   *projectAccessRequest   `jsonapi:"attr,project-access,omitempty"`
+	WorkspaceAccess *workspaceAccessRequest `jsonapi:"attr,workspace-access,omitempty"`
+	Team            *tfe.Team               `jsonapi:"relation,team,omitempty"`
+	Project         *tfe.Project            `jsonapi:"relation,project,omitempty"`
+}
+
+func (req *teamProjectAccessRequest) projectAccess() *tfe.TeamProjectAccessProjectPermissionsOptions {
+	a := req.ProjectAccess
+	if a == nil {
+		return nil
+	}
+	return &tfe.TeamProjectAccessProjectPermissionsOptions{
+		Settings: a.Settings,
+		Teams:    a.Teams,
+	}
+}
+
+func (req *teamProjectAccessRequest) workspaceAccess() *tfe.TeamProjectAccessWorkspacePermissionsOptions {
+	a := req.WorkspaceAccess
+	if a == nil {
+		return nil
+	}
+	return &tfe.TeamProjectAccessWorkspacePermissionsOptions{
+		Runs:          a.Runs,
+		SentinelMocks: a.SentinelMocks,
+		StateVersions: a.StateVersions,
+		Variables:     a.Variables,
+		Create:        a.Create,
+		Locking:       a.Locking,
+		Move:          a.Move,
+		Delete:        a.Delete,
+		RunTasks:      a.RunTasks,
+	}
+}
+
+func (req *teamProjectAccessRequest) addOptions() tfe.TeamProjectAccessAddOptions {
+	options := tfe.TeamProjectAccessAddOptions{
+		ProjectAccess:   req.projectAccess(),
+		WorkspaceAccess: req.workspaceAccess(),
+		Team:            req.Team,
+		Project:         req.Project,
+	}
+	if req.Access != nil {
+		options.Access = tfe.TeamProjectAccessType(*req.Access)
+	}
+	return options
+}
+
+func (req *teamProjectAccessRequest) updateOptions() tfe.TeamProjectAccessUpdateOptions {
+	options := tfe.TeamProjectAccessUpdateOptions{
+		ProjectAccess:   req.projectAccess(),
+		WorkspaceAccess: req.workspaceAccess(),
+	}
+	if req.Access != nil {
+		options.Access = tfe.ProjectAccess(tfe.TeamProjectAccessType(*req.Access))
+	}
+	return options
+}
+
+type TeamAccessHandler struct {
+	svc    service.Service
+	logger *zap.Logger
+}
+
+func NewTeamAccessHandler(svc service.Service, logger *zap.Logger) *TeamAccessHandler {
+	return &TeamAccessHandler{
+		svc:    svc,
+		logger: logger.With(zap.String("handler", "team_access")),
+	}
+}
+
+func (h *TeamAccessHandler) ListWorkspaceAccesses(w http.ResponseWriter, r *http.Request) {
+	options := &tfe.TeamAccessListOptions{
+		ListOptions: ParseListOptions(r),
+		WorkspaceID: r.URL.Query().Get("filter[workspace][id]"),
+	}
+
+	accesses, pagination, err := h.svc.ListTeamWorkspaceAccesses(r.Context(), options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, accesses, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *TeamAccessHandler) AddWorkspaceAccess(w http.ResponseWriter, r *http.Request) {
+	var options tfe.TeamAccessAddOptions
+	if err := jsonapi.UnmarshalPayload(r.Body, &options); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	access, err := h.svc.AddTeamWorkspaceAccess(r.Context(), options)
+	if err != nil {
+		h.logger.Debug("failed to add team workspace access", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	if err := jsonapi.MarshalPayload(w, access); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+	}
+}
+
+func (h *TeamAccessHandler) ReadWorkspaceAccess(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	access, err := h.svc.ReadTeamWorkspaceAccess(r.Context(), vars["team_access_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, access)
+}
+
+func (h *TeamAccessHandler) UpdateWorkspaceAccess(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var options tfe.TeamAccessUpdateOptions
+	if err := jsonapi.UnmarshalPayload(r.Body, &options); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	access, err := h.svc.UpdateTeamWorkspaceAccess(r.Context(), vars["team_access_id"], options)
+	if err != nil {
+		h.logger.Debug("failed to update team workspace access", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, access)
+}
+
+func (h *TeamAccessHandler) RemoveWorkspaceAccess(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.RemoveTeamWorkspaceAccess(r.Context(), vars["team_access_id"]); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TeamAccessHandler) ListProjectAccesses(w http.ResponseWriter, r *http.Request) {
+	options := &tfe.TeamProjectAccessListOptions{
+		ListOptions: ParseListOptions(r),
+		ProjectID:   r.URL.Query().Get("filter[project][id]"),
+	}
+
+	accesses, pagination, err := h.svc.ListTeamProjectAccesses(r.Context(), options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, accesses, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *TeamAccessHandler) AddProjectAccess(w http.ResponseWriter, r *http.Request) {
+	var req teamProjectAccessRequest
+	if err := jsonapi.UnmarshalPayload(r.Body, &req); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	access, err := h.svc.AddTeamProjectAccess(r.Context(), req.addOptions())
+	if err != nil {
+		h.logger.Debug("failed to add team project access", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	if err := jsonapi.MarshalPayload(w, access); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+	}
+}
+
+func (h *TeamAccessHandler) ReadProjectAccess(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	access, err := h.svc.ReadTeamProjectAccess(r.Context(), vars["team_project_access_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, access)
+}
+
+func (h *TeamAccessHandler) UpdateProjectAccess(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req teamProjectAccessRequest
+	if err := jsonapi.UnmarshalPayload(r.Body, &req); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	access, err := h.svc.UpdateTeamProjectAccess(r.Context(), vars["team_project_access_id"], req.updateOptions())
+	if err != nil {
+		h.logger.Debug("failed to update team project access", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, access)
+}
+
+func (h *TeamAccessHandler) RemoveProjectAccess(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.RemoveTeamProjectAccess(r.Context(), vars["team_project_access_id"]); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TeamAccessHandler) marshal(w http.ResponseWriter, payload interface{}) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	if err := jsonapi.MarshalPayload(w, payload); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
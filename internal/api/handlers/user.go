@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -26,7 +27,7 @@ func (h *UserHandler) List(w http.ResponseWriter, r *http.Request) {
 	users, err := h.svc.ListUsers(r.Context())
 	if err != nil {
 		h.logger.Error("failed to list users", zap.Error(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		WriteError(w, err)
 		return
 	}
 
@@ -49,7 +50,7 @@ func (h *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	createdUser, err := h.svc.CreateUser(r.Context(), &user)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		WriteError(w, err)
 		return
 	}
 
@@ -69,7 +70,7 @@ func (h *UserHandler) Read(w http.ResponseWriter, r *http.Request) {
 
 	user, err := h.svc.ReadUser(r.Context(), userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		WriteError(w, err)
 		return
 	}
 
@@ -95,7 +96,7 @@ func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 	updatedUser, err := h.svc.UpdateUser(r.Context(), userID, &user)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		WriteError(w, err)
 		return
 	}
 
@@ -113,7 +114,7 @@ func (h *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	userID := vars["user_id"]
 
 	if err := h.svc.DeleteUser(r.Context(), userID); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		WriteError(w, err)
 		return
 	}
 
@@ -123,7 +124,7 @@ func (h *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
 func (h *UserHandler) AccountDetails(w http.ResponseWriter, r *http.Request) {
 	user, err := h.svc.ReadCurrentUser(r.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		WriteError(w, err)
 		return
 	}
 
@@ -135,3 +136,36 @@ func (h *UserHandler) AccountDetails(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// passwordUpdate is the JSON:API payload accepted by PATCH /account/password.
+type passwordUpdate struct {
+	ID                   string `jsonapi:"primary,users"`
+	CurrentPassword      string `jsonapi:"attr,current_password"`
+	Password             string `jsonapi:"attr,password"`
+	PasswordConfirmation string `jsonapi:"attr,password_confirmation"`
+}
+
+func (h *UserHandler) UpdatePassword(w http.ResponseWriter, r *http.Request) {
+	var update passwordUpdate
+	if err := jsonapi.UnmarshalPayload(r.Body, &update); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if update.Password == "" || update.Password != update.PasswordConfirmation {
+		http.Error(w, "Password and confirmation must match", http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := h.svc.UpdateCurrentUserPassword(r.Context(), update.CurrentPassword, update.Password); err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		WriteError(w, err)
+		return
+	}
+
+	h.AccountDetails(w, r)
+}
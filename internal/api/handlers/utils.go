@@ -1,10 +1,96 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
 	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/jsonapi"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"gorm.io/gorm"
 )
 
 // ParseUUID converts a string to UUID
 func ParseUUID(id string) (uuid.UUID, error) {
 	return uuid.Parse(id)
 }
+
+// ParseListOptions reads the JSON:API page[number] and page[size] query parameters
+func ParseListOptions(r *http.Request) tfe.ListOptions {
+	query := r.URL.Query()
+	pageNumber, _ := strconv.Atoi(query.Get("page[number]"))
+	pageSize, _ := strconv.Atoi(query.Get("page[size]"))
+	return tfe.ListOptions{
+		PageNumber: pageNumber,
+		PageSize:   pageSize,
+	}
+}
+
+// MarshalListPayload writes a JSON:API collection with pagination metadata
+func MarshalListPayload(w http.ResponseWriter, models interface{}, pagination *tfe.Pagination) error {
+	payload, err := jsonapi.Marshal(models)
+	if err != nil {
+		return err
+	}
+
+	if many, ok := payload.(*jsonapi.ManyPayload); ok && pagination != nil {
+		many.Meta = &jsonapi.Meta{"pagination": pagination}
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	return json.NewEncoder(w).Encode(payload)
+}
+
+// ErrorStatus maps a service error to the HTTP status code Terraform Enterprise uses for it
+func ErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidAttribute):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, service.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrInvalidToken):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// WriteError writes err as a JSON:API error document, which go-tfe decodes
+// to tell apart errors that share a status code
+func WriteError(w http.ResponseWriter, err error) {
+	status := ErrorStatus(err)
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(status)
+	jsonapi.MarshalErrors(w, []*jsonapi.ErrorObject{{
+		Status: strconv.Itoa(status),
+		Title:  http.StatusText(status),
+		Detail: err.Error(),
+	}})
+}
+
+// ParseRelationshipIDs reads a JSON:API relationship document, a data array
+// of resource identifiers, and returns the identifiers.
+func ParseRelationshipIDs(r *http.Request) ([]string, error) {
+	var doc struct {
+		Data []struct {
+			Type string `json:"type"`
+			ID   string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(doc.Data))
+	for i, ref := range doc.Data {
+		ids[i] = ref.ID
+	}
+	return ids, nil
+}
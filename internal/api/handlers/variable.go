@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/jsonapi"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// variableRequest mirrors the go-tfe variable create and update options with
+// a plain string category, which jsonapi cannot decode into *tfe.CategoryType.
+type variableRequest struct {
+	Type        string  `jsonapi:"primary,vars"`
+	Key         *string `jsonapi:"attr,key,omitempty"`
+	Value       *string `jsonapi:"attr,value,omitempty"`
+	Description *string `jsonapi:"attr,description,omitempty"`
+	Category    *string `jsonapi:"attr,category,omitempty"`
+	HCL         *bool   `jsonapi:"attr,hcl,omitempty"`
+	Sensitive   *bool   `jsonapi:"attr,sensitive,omitempty"`
+}
+
+func (req *variableRequest) category() *tfe.CategoryType {
+	if req.Category == nil {
+		return nil
+	}
+	return tfe.Category(tfe.CategoryType(*req.Category))
+}
+
+func (req *variableRequest) createOptions() tfe.VariableCreateOptions {
+	return tfe.VariableCreateOptions{
+		Key:         req.Key,
+		Value:       req.Value,
+		Description: req.Description,
+		Category:    req.category(),
+		HCL:         req.HCL,
+		Sensitive:   req.Sensitive,
+	}
+}
+
+func (req *variableRequest) updateOptions() tfe.VariableUpdateOptions {
+	return tfe.VariableUpdateOptions{
+		Key:         req.Key,
+		Value:       req.Value,
+		Description: req.Description,
+		Category:    req.category(),
+		HCL:         req.HCL,
+		Sensitive:   req.Sensitive,
+	}
+}
+
+func (req *variableRequest) variableSetCreateOptions() tfe.VariableSetVariableCreateOptions {
+	return tfe.VariableSetVariableCreateOptions{
+		Key:         req.Key,
+		Value:       req.Value,
+		Description: req.Description,
+		Category:    req.category(),
+		HCL:         req.HCL,
+		Sensitive:   req.Sensitive,
+	}
+}
+
+func (req *variableRequest) variableSetUpdateOptions() tfe.VariableSetVariableUpdateOptions {
+	return tfe.VariableSetVariableUpdateOptions{
+		Key:         req.Key,
+		Value:       req.Value,
+		Description: req.Description,
+		HCL:         req.HCL,
+		Sensitive:   req.Sensitive,
+	}
+}
+
+// VariableHandler serves workspace variables.
+type VariableHandler struct {
+	svc    service.Service
+	logger *zap.Logger
+}
+
+func NewVariableHandler(svc service.Service, logger *zap.Logger) *VariableHandler {
+	return &VariableHandler{
+		svc:    svc,
+		logger: logger.With(zap.String("handler", "variable")),
+	}
+}
+
+func (h *VariableHandler) List(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	options := &tfe.VariableListOptions{ListOptions: ParseListOptions(r)}
+	variables, pagination, err := h.svc.ListVariables(r.Context(), vars["workspace_id"], options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, variables, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// ListEffective lists the merged variables the next run of a workspace receives.
+func (h *VariableHandler) ListEffective(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	variables, err := h.svc.ListEffectiveVariables(r.Context(), vars["workspace_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, variables, nil); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *VariableHandler) Create(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req variableRequest
+	if err := jsonapi.UnmarshalPayload(r.Body, &req); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	variable, err := h.svc.CreateVariable(r.Context(), vars["workspace_id"], req.createOptions())
+	if err != nil {
+		h.logger.Debug("failed to create variable", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	if err := jsonapi.MarshalPayload(w, variable); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+	}
+}
+
+func (h *VariableHandler) Read(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	variable, err := h.svc.ReadVariable(r.Context(), vars["workspace_id"], vars["variable_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	if err := jsonapi.MarshalPayload(w, variable); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *VariableHandler) Update(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req variableRequest
+	if err := jsonapi.UnmarshalPayload(r.Body, &req); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	variable, err := h.svc.UpdateVariable(r.Context(), vars["workspace_id"], vars["variable_id"], req.updateOptions())
+	if err != nil {
+		h.logger.Debug("failed to update variable", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	if err := jsonapi.MarshalPayload(w, variable); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *VariableHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.DeleteVariable(r.Context(), vars["workspace_id"], vars["variable_id"]); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
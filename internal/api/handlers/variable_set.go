@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/jsonapi"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// variableSetUpdateRequest extends the go-tfe update options with the
+// workspaces relationship sent by VariableSets.UpdateWorkspaces.
+type variableSetUpdateRequest struct {
+	Type        string           `jsonapi:"primary,varsets"`
+	Name        *string          `jsonapi:"attr,name,omitempty"`
+	Description *string          `jsonapi:"attr,description,omitempty"`
+	Global      *bool            `jsonapi:"attr,global,omitempty"`
+	Priority    *bool            `jsonapi:"attr,priority,omitempty"`
+	Workspaces  []*tfe.Workspace `jsonapi:"relation,workspaces,omitempty"`
+}
+
+// VariableSetHandler serves variable sets and their variables.
+type VariableSetHandler struct {
+	svc    service.Service
+	logger *zap.Logger
+}
+
+func NewVariableSetHandler(svc service.Service, logger *zap.Logger) *VariableSetHandler {
+	return &VariableSetHandler{
+		svc:    svc,
+		logger: logger.With(zap.String("handler", "variable_set")),
+	}
+}
+
+func (h *VariableSetHandler) List(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, mux.Vars(r)["organization_name"], h.svc.ListVariableSets)
+}
+
+func (h *VariableSetHandler) ListForWorkspace(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, mux.Vars(r)["workspace_id"], h.svc.ListWorkspaceVariableSets)
+}
+
+func (h *VariableSetHandler) ListForProject(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, mux.Vars(r)["project_id"], h.svc.ListProjectVariableSets)
+}
+
+func (h *VariableSetHandler) Create(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var options tfe.VariableSetCreateOptions
+	if err := jsonapi.UnmarshalPayload(r.Body, &options); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	set, err := h.svc.CreateVariableSet(r.Context(), vars["organization_name"], options)
+	if err != nil {
+		h.logger.Debug("failed to create variable set", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	if err := jsonapi.MarshalPayload(w, set); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+	}
+}
+
+func (h *VariableSetHandler) Read(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	set, err := h.svc.ReadVariableSet(r.Context(), vars["variable_set_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, set)
+}
+
+// Update changes the settings of a variable set. When the request carries
+// a workspaces relationship, the set is applied to exactly those workspaces.
+func (h *VariableSetHandler) Update(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req variableSetUpdateRequest
+	if err := jsonapi.UnmarshalPayload(bytes.NewReader(body), &req); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// jsonapi cannot tell an empty relationship from a missing one.
+	var doc struct {
+		Data struct {
+			Relationships map[string]json.RawMessage `json:"relationships"`
+		} `json:"data"`
+	}
+	json.Unmarshal(body, &doc)
+	_, replaceWorkspaces := doc.Data.Relationships["workspaces"]
+
+	set, err := h.svc.UpdateVariableSet(r.Context(), vars["variable_set_id"], tfe.VariableSetUpdateOptions{
+		Name:        req.Name,
+		Description: req.Description,
+		Global:      req.Global,
+		Priority:    req.Priority,
+	})
+	if err == nil && replaceWorkspaces {
+		ids := make([]string, len(req.Workspaces))
+		for i, ws := range req.Workspaces {
+			ids[i] = ws.ID
+		}
+		set, err = h.svc.ReplaceVariableSetWorkspaces(r.Context(), vars["variable_set_id"], ids)
+	}
+	if err != nil {
+		h.logger.Debug("failed to update variable set", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, set)
+}
+
+func (h *VariableSetHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.DeleteVariableSet(r.Context(), vars["variable_set_id"]); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *VariableSetHandler) ApplyToWorkspaces(w http.ResponseWriter, r *http.Request) {
+	h.relationships(w, r, h.svc.ApplyVariableSetToWorkspaces)
+}
+
+func (h *VariableSetHandler) RemoveFromWorkspaces(w http.ResponseWriter, r *http.Request) {
+	h.relationships(w, r, h.svc.RemoveVariableSetFromWorkspaces)
+}
+
+func (h *VariableSetHandler) ApplyToProjects(w http.ResponseWriter, r *http.Request) {
+	h.relationships(w, r, h.svc.ApplyVariableSetToProjects)
+}
+
+func (h *VariableSetHandler) RemoveFromProjects(w http.ResponseWriter, r *http.Request) {
+	h.relationships(w, r, h.svc.RemoveVariableSetFromProjects)
+}
+
+func (h *VariableSetHandler) ListVariables(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	options := &tfe.VariableSetVariableListOptions{ListOptions: ParseListOptions(r)}
+	variables, pagination, err := h.svc.ListVariableSetVariables(r.Context(), vars["variable_set_id"], options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, variables, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *VariableSetHandler) CreateVariable(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req variableRequest
+	if err := jsonapi.UnmarshalPayload(r.Body, &req); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	variable, err := h.svc.CreateVariableSetVariable(r.Context(), vars["variable_set_id"], req.variableSetCreateOptions())
+	if err != nil {
+		h.logger.Debug("failed to create variable set variable", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	if err := jsonapi.MarshalPayload(w, variable); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+	}
+}
+
+func (h *VariableSetHandler) ReadVariable(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	variable, err := h.svc.ReadVariableSetVariable(r.Context(), vars["variable_set_id"], vars["variable_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, variable)
+}
+
+func (h *VariableSetHandler) UpdateVariable(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req variableRequest
+	if err := jsonapi.UnmarshalPayload(r.Body, &req); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	variable, err := h.svc.UpdateVariableSetVariable(r.Context(), vars["variable_set_id"], vars["variable_id"], req.variableSetUpdateOptions())
+	if err != nil {
+		h.logger.Debug("failed to update variable set variable", zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+	h.marshal(w, variable)
+}
+
+func (h *VariableSetHandler) DeleteVariable(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.DeleteVariableSetVariable(r.Context(), vars["variable_set_id"], vars["variable_id"]); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *VariableSetHandler) list(w http.ResponseWriter, r *http.Request, id string, list func(context.Context, string, *tfe.VariableSetListOptions) ([]*tfe.VariableSet, *tfe.Pagination, error)) {
+	options := &tfe.VariableSetListOptions{
+		ListOptions: ParseListOptions(r),
+		Query:       r.URL.Query().Get("q"),
+	}
+	sets, pagination, err := list(r.Context(), id, options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, sets, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// relationships answers the relationship endpoints that attach a set to or
+// detach it from workspaces and projects.
+func (h *VariableSetHandler) relationships(w http.ResponseWriter, r *http.Request, update func(context.Context, string, []string) error) {
+	vars := mux.Vars(r)
+
+	ids, err := ParseRelationshipIDs(r)
+	if err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := update(r.Context(), vars["variable_set_id"], ids); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *VariableSetHandler) marshal(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	if err := jsonapi.MarshalPayload(w, v); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/jsonapi"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+type WorkspaceHandler struct {
+	svc    service.Service
+	logger *zap.Logger
+}
+
+func NewWorkspaceHandler(svc service.Service, logger *zap.Logger) *WorkspaceHandler {
+	return &WorkspaceHandler{
+		svc:    svc,
+		logger: logger.With(zap.String("handler", "workspace")),
+	}
+}
+
+func (h *WorkspaceHandler) List(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	orgName := vars["organization_name"]
+	query := r.URL.Query()
+	h.logger.Debug("listing workspaces", zap.String("organization_name", orgName))
+
+	options := &tfe.WorkspaceListOptions{
+		ListOptions:  ParseListOptions(r),
+		Search:       query.Get("search[name]"),
+		WildcardName: query.Get("search[wildcard-name]"),
+		ProjectID:    query.Get("filter[project][id]"),
+	}
+
+	workspaces, pagination, err := h.svc.ListWorkspaces(r.Context(), orgName, options)
+	if err != nil {
+		h.logger.Error("failed to list workspaces", zap.String("organization_name", orgName), zap.Error(err))
+		WriteError(w, err)
+		return
+	}
+
+	if err := MarshalListPayload(w, workspaces, pagination); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *WorkspaceHandler) Create(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	orgName := vars["organization_name"]
+	h.logger.Debug("creating workspace", zap.String("organization_name", orgName))
+
+	var options tfe.WorkspaceCreateOptions
+	if err := jsonapi.UnmarshalPayload(r.Body, &options); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	workspace, err := h.svc.CreateWorkspace(r.Context(), orgName, options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusCreated)
+	h.marshal(w, workspace)
+}
+
+func (h *WorkspaceHandler) Read(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	workspace, err := h.svc.ReadWorkspace(r.Context(), vars["organization_name"], vars["workspace_name"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	h.marshal(w, workspace)
+}
+
+func (h *WorkspaceHandler) ReadByID(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	workspace, err := h.svc.ReadWorkspaceByID(r.Context(), vars["workspace_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	h.marshal(w, workspace)
+}
+
+func (h *WorkspaceHandler) Update(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var options tfe.WorkspaceUpdateOptions
+	if err := jsonapi.UnmarshalPayload(r.Body, &options); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	workspace, err := h.svc.UpdateWorkspace(r.Context(), vars["organization_name"], vars["workspace_name"], options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	h.marshal(w, workspace)
+}
+
+func (h *WorkspaceHandler) UpdateByID(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var options tfe.WorkspaceUpdateOptions
+	if err := jsonapi.UnmarshalPayload(r.Body, &options); err != nil {
+		h.logger.Error("failed to decode request body", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	workspace, err := h.svc.UpdateWorkspaceByID(r.Context(), vars["workspace_id"], options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	h.marshal(w, workspace)
+}
+
+func (h *WorkspaceHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.DeleteWorkspace(r.Context(), vars["organization_name"], vars["workspace_name"]); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WorkspaceHandler) DeleteByID(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.DeleteWorkspaceByID(r.Context(), vars["workspace_id"]); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WorkspaceHandler) SafeDelete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.SafeDeleteWorkspace(r.Context(), vars["organization_name"], vars["workspace_name"]); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WorkspaceHandler) SafeDeleteByID(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.svc.SafeDeleteWorkspaceByID(r.Context(), vars["workspace_id"]); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WorkspaceHandler) Lock(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var options tfe.WorkspaceLockOptions
+	if r.ContentLength != 0 {
+		if err := jsonapi.UnmarshalPayload(r.Body, &options); err != nil {
+			h.logger.Error("failed to decode request body", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	workspace, err := h.svc.LockWorkspace(r.Context(), vars["workspace_id"], options)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	h.marshal(w, workspace)
+}
+
+func (h *WorkspaceHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	workspace, err := h.svc.UnlockWorkspace(r.Context(), vars["workspace_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	h.marshal(w, workspace)
+}
+
+func (h *WorkspaceHandler) ForceUnlock(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	workspace, err := h.svc.ForceUnlockWorkspace(r.Context(), vars["workspace_id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	h.marshal(w, workspace)
+}
+
+func (h *WorkspaceHandler) marshal(w http.ResponseWriter, workspace *tfe.Workspace) {
+	if err := jsonapi.MarshalPayload(w, workspace); err != nil {
+		h.logger.Error("failed to marshal response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,56 @@
+package router
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/open-tfe/tfe-service/internal/api/handlers"
+	"github.com/open-tfe/tfe-service/internal/constants"
+)
+
+func (r *Router) registerAgentPoolRoutes(api *mux.Router) {
+	agentPoolHandler := handlers.NewAgentPoolHandler(r.service, r.logger)
+
+	// Agent pool endpoints
+	api.HandleFunc("/organizations/{organization_name}/agent-pools", agentPoolHandler.List).Methods("GET")
+	api.HandleFunc("/organizations/{organization_name}/agent-pools", agentPoolHandler.Create).Methods("POST")
+	api.HandleFunc("/agent-pools/{agent_pool_id}", agentPoolHandler.Read).Methods("GET")
+	api.HandleFunc("/agent-pools/{agent_pool_id}", agentPoolHandler.Update).Methods("PATCH")
+	api.HandleFunc("/agent-pools/{agent_pool_id}", agentPoolHandler.Delete).Methods("DELETE")
+
+	// Agent token endpoints; tokens are read and revoked through /authentication-tokens
+	api.HandleFunc("/agent-pools/{agent_pool_id}/authentication-tokens", agentPoolHandler.ListTokens).Methods("GET")
+	api.HandleFunc("/agent-pools/{agent_pool_id}/authentication-tokens", agentPoolHandler.CreateToken).Methods("POST")
+
+	// Agent endpoints
+	api.HandleFunc("/agent-pools/{agent_pool_id}/agents", agentPoolHandler.ListAgents).Methods("GET")
+	api.HandleFunc("/agents/{agent_id}", agentPoolHandler.ReadAgent).Methods("GET")
+}
+
+func (r *Router) registerAgentRoutes(api *mux.Router) {
+	agentHandler := handlers.NewAgentHandler(r.service, r.logger)
+	agent := api.PathPrefix(constants.AgentPath).Subrouter()
+
+	// Agent lifecycle
+	agent.HandleFunc("/register", agentHandler.Register).Methods("POST")
+	agent.HandleFunc("/{agent_id}/heartbeat", agentHandler.Heartbeat).Methods("POST")
+	agent.HandleFunc("/{agent_id}/exit", agentHandler.Exit).Methods("POST")
+	agent.HandleFunc("/{agent_id}/jobs", agentHandler.ClaimJob).Methods("POST")
+
+	// Job inputs
+	agent.HandleFunc("/{agent_id}/configuration-versions/{configuration_version_id}", agentHandler.DownloadConfigurationVersion).Methods("GET")
+	agent.HandleFunc("/{agent_id}/runs/{run_id}", agentHandler.ReadRun).Methods("GET")
+	agent.HandleFunc("/{agent_id}/runs/{run_id}/state", agentHandler.DownloadState).Methods("GET")
+	agent.HandleFunc("/{agent_id}/runs/{run_id}/plan-file", agentHandler.DownloadPlanFile).Methods("GET")
+
+	// Job outputs
+	agent.HandleFunc("/{agent_id}/runs/{run_id}/plan-file", agentHandler.UploadPlanFile).Methods("PUT")
+	agent.HandleFunc("/{agent_id}/runs/{run_id}/plan-json", agentHandler.UploadPlanJSON).Methods("PUT")
+	agent.HandleFunc("/{agent_id}/runs/{run_id}/state-versions", agentHandler.CreateStateVersion).Methods("POST")
+	agent.HandleFunc("/{agent_id}/runs/{run_id}/logs/{phase}", agentHandler.AppendLog).Methods("POST")
+	agent.HandleFunc("/{agent_id}/runs/{run_id}/logs/{phase}/seal", agentHandler.SealLog).Methods("POST")
+
+	// Job outcomes
+	agent.HandleFunc("/{agent_id}/runs/{run_id}/plan-finished", agentHandler.FinishPlan).Methods("POST")
+	agent.HandleFunc("/{agent_id}/runs/{run_id}/apply-finished", agentHandler.FinishApply).Methods("POST")
+	agent.HandleFunc("/{agent_id}/runs/{run_id}/cancel-acknowledged", agentHandler.AcknowledgeCancel).Methods("POST")
+	agent.HandleFunc("/{agent_id}/runs/{run_id}/fail", agentHandler.Fail).Methods("POST")
+}
@@ -0,0 +1,14 @@
+package router
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/open-tfe/tfe-service/internal/api/handlers"
+)
+
+func (r *Router) registerAuditTrailRoutes(api *mux.Router) {
+	auditTrailHandler := handlers.NewAuditTrailHandler(r.service, r.logger)
+
+	// Audit trail endpoint. The organization is that of the calling
+	// organization or audit trail token.
+	api.HandleFunc("/organization/audit-trail", auditTrailHandler.List).Methods("GET")
+}
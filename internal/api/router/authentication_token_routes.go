@@ -0,0 +1,26 @@
+package router
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/open-tfe/tfe-service/internal/api/handlers"
+)
+
+func (r *Router) registerAuthenticationTokenRoutes(api *mux.Router) {
+	tokenHandler := handlers.NewAuthenticationTokenHandler(r.service, r.logger)
+
+	// User token endpoints. Agent tokens are also read and revoked by ID here.
+	api.HandleFunc("/users/{user_id}/authentication-tokens", tokenHandler.ListUserTokens).Methods("GET")
+	api.HandleFunc("/users/{user_id}/authentication-tokens", tokenHandler.CreateUserToken).Methods("POST")
+	api.HandleFunc("/authentication-tokens/{token_id}", tokenHandler.ReadToken).Methods("GET")
+	api.HandleFunc("/authentication-tokens/{token_id}", tokenHandler.DeleteToken).Methods("DELETE")
+
+	// Team token endpoints
+	api.HandleFunc("/teams/{team_id}/authentication-token", tokenHandler.CreateTeamToken).Methods("POST")
+	api.HandleFunc("/teams/{team_id}/authentication-token", tokenHandler.ReadTeamToken).Methods("GET")
+	api.HandleFunc("/teams/{team_id}/authentication-token", tokenHandler.DeleteTeamToken).Methods("DELETE")
+
+	// Organization and audit trail token endpoints, selected by ?token=audit-trails
+	api.HandleFunc("/organizations/{organization_name}/authentication-token", tokenHandler.CreateOrganizationToken).Methods("POST")
+	api.HandleFunc("/organizations/{organization_name}/authentication-token", tokenHandler.ReadOrganizationToken).Methods("GET")
+	api.HandleFunc("/organizations/{organization_name}/authentication-token", tokenHandler.DeleteOrganizationToken).Methods("DELETE")
+}
@@ -0,0 +1,21 @@
+package router
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/open-tfe/tfe-service/internal/api/handlers"
+)
+
+func (r *Router) registerConfigurationVersionRoutes(api *mux.Router, archivist *mux.Router) {
+	configurationVersionHandler := handlers.NewConfigurationVersionHandler(r.service, r.signer, r.logger)
+
+	// Configuration version endpoints
+	api.HandleFunc("/workspaces/{workspace_id}/configuration-versions", configurationVersionHandler.List).Methods("GET")
+	api.HandleFunc("/workspaces/{workspace_id}/configuration-versions", configurationVersionHandler.Create).Methods("POST")
+	api.HandleFunc("/configuration-versions/{configuration_version_id}", configurationVersionHandler.Read).Methods("GET")
+	api.HandleFunc("/configuration-versions/{configuration_version_id}/ingress-attributes", configurationVersionHandler.ReadIngressAttributes).Methods("GET")
+	api.HandleFunc("/configuration-versions/{configuration_version_id}/download", configurationVersionHandler.Download).Methods("GET")
+	api.HandleFunc("/configuration-versions/{configuration_version_id}/actions/archive", configurationVersionHandler.Archive).Methods("POST")
+
+	// Signed archive upload URL
+	archivist.HandleFunc("/configuration-versions/{configuration_version_id}/upload", configurationVersionHandler.Upload).Methods("PUT")
+}
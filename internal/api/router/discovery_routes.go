@@ -0,0 +1,27 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/open-tfe/tfe-service/internal/api/handlers"
+	"github.com/open-tfe/tfe-service/internal/constants"
+)
+
+func (r *Router) registerDiscoveryRoutes(public *mux.Router) {
+	discoveryHandler := handlers.NewDiscoveryHandler(r.logger)
+
+	// Service discovery and connectivity endpoints, reachable without a token
+	public.HandleFunc(constants.DiscoveryPath, discoveryHandler.Discovery).Methods("GET")
+	public.HandleFunc(constants.APIVersionPath+"/ping", discoveryHandler.Ping).Methods("GET")
+}
+
+// versionHeaders adds the Terraform Enterprise version headers to every response.
+func versionHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("TFP-API-Version", constants.TFEAPIVersion)
+		w.Header().Set("TFP-AppName", constants.TFEAppName)
+		w.Header().Set("X-TFE-Version", constants.TFEVersion)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,18 @@
+package router
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/open-tfe/tfe-service/internal/api/handlers"
+)
+
+func (r *Router) registerNotificationConfigurationRoutes(api *mux.Router) {
+	notificationConfigurationHandler := handlers.NewNotificationConfigurationHandler(r.service, r.logger)
+
+	// Notification configuration endpoints
+	api.HandleFunc("/workspaces/{workspace_id}/notification-configurations", notificationConfigurationHandler.List).Methods("GET")
+	api.HandleFunc("/workspaces/{workspace_id}/notification-configurations", notificationConfigurationHandler.Create).Methods("POST")
+	api.HandleFunc("/notification-configurations/{notification_configuration_id}", notificationConfigurationHandler.Read).Methods("GET")
+	api.HandleFunc("/notification-configurations/{notification_configuration_id}", notificationConfigurationHandler.Update).Methods("PATCH")
+	api.HandleFunc("/notification-configurations/{notification_configuration_id}", notificationConfigurationHandler.Delete).Methods("DELETE")
+	api.HandleFunc("/notification-configurations/{notification_configuration_id}/actions/verify", notificationConfigurationHandler.Verify).Methods("POST")
+}
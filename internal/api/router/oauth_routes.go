@@ -0,0 +1,16 @@
+package router
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/open-tfe/tfe-service/internal/api/handlers"
+	"github.com/open-tfe/tfe-service/internal/constants"
+)
+
+func (r *Router) registerOAuthRoutes(public *mux.Router) {
+	oauthHandler := handlers.NewOAuthHandler(r.service, r.logger)
+
+	// terraform login authorization-code flow
+	public.HandleFunc(constants.OAuthAuthorizationPath, oauthHandler.Authorize).Methods("GET")
+	public.HandleFunc(constants.OAuthAuthorizationPath, oauthHandler.AuthorizeSubmit).Methods("POST")
+	public.HandleFunc(constants.OAuthTokenPath, oauthHandler.Token).Methods("POST")
+}
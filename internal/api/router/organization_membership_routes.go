@@ -0,0 +1,27 @@
+package router
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/open-tfe/tfe-service/internal/api/handlers"
+	"github.com/open-tfe/tfe-service/internal/constants"
+)
+
+func (r *Router) registerOrganizationMembershipRoutes(api, public *mux.Router) {
+	membershipHandler := handlers.NewOrganizationMembershipHandler(r.service, r.signer, r.logger)
+	invitationHandler := handlers.NewInvitationHandler(r.service, r.logger)
+
+	// Organization membership endpoints
+	api.HandleFunc("/organizations/{organization_name}/organization-memberships", membershipHandler.List).Methods("GET")
+	api.HandleFunc("/organizations/{organization_name}/organization-memberships", membershipHandler.Create).Methods("POST")
+	api.HandleFunc("/organization-memberships", membershipHandler.ListForCurrentUser).Methods("GET")
+	api.HandleFunc("/organization-memberships/{membership_id}", membershipHandler.Read).Methods("GET")
+	api.HandleFunc("/organization-memberships/{membership_id}", membershipHandler.Delete).Methods("DELETE")
+
+	// Invitation answers by the invited user
+	api.HandleFunc("/organization-memberships/{membership_id}/actions/accept", membershipHandler.Accept).Methods("POST")
+	api.HandleFunc("/organization-memberships/{membership_id}/actions/decline", membershipHandler.Decline).Methods("POST")
+
+	// Invitation email links, authenticated by the token in the path
+	public.HandleFunc(constants.InvitationPath+"/{token}", invitationHandler.Show).Methods("GET")
+	public.HandleFunc(constants.InvitationPath+"/{token}", invitationHandler.Answer).Methods("POST")
+}
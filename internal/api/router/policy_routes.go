@@ -0,0 +1,37 @@
+package router
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/open-tfe/tfe-service/internal/api/handlers"
+)
+
+func (r *Router) registerPolicyRoutes(api *mux.Router) {
+	policyHandler := handlers.NewPolicyHandler(r.service, r.logger)
+	policySetHandler := handlers.NewPolicySetHandler(r.service, r.logger)
+
+	// Policy endpoints
+	api.HandleFunc("/organizations/{organization_name}/policies", policyHandler.List).Methods("GET")
+	api.HandleFunc("/organizations/{organization_name}/policies", policyHandler.Create).Methods("POST")
+	api.HandleFunc("/policies/{policy_id}", policyHandler.Read).Methods("GET")
+	api.HandleFunc("/policies/{policy_id}", policyHandler.Update).Methods("PATCH")
+	api.HandleFunc("/policies/{policy_id}", policyHandler.Delete).Methods("DELETE")
+	api.HandleFunc("/policies/{policy_id}/upload", policyHandler.Upload).Methods("PUT")
+	api.HandleFunc("/policies/{policy_id}/download", policyHandler.Download).Methods("GET")
+
+	// Policy set endpoints
+	api.HandleFunc("/organizations/{organization_name}/policy-sets", policySetHandler.List).Methods("GET")
+	api.HandleFunc("/organizations/{organization_name}/policy-sets", policySetHandler.Create).Methods("POST")
+	api.HandleFunc("/policy-sets/{policy_set_id}", policySetHandler.Read).Methods("GET")
+	api.HandleFunc("/policy-sets/{policy_set_id}", policySetHandler.Update).Methods("PATCH")
+	api.HandleFunc("/policy-sets/{policy_set_id}", policySetHandler.Delete).Methods("DELETE")
+
+	// Policy set contents and scope
+	api.HandleFunc("/policy-sets/{policy_set_id}/relationships/policies", policySetHandler.AddPolicies).Methods("POST")
+	api.HandleFunc("/policy-sets/{policy_set_id}/relationships/policies", policySetHandler.RemovePolicies).Methods("DELETE")
+	api.HandleFunc("/policy-sets/{policy_set_id}/relationships/workspaces", policySetHandler.AddWorkspaces).Methods("POST")
+	api.HandleFunc("/policy-sets/{policy_set_id}/relationships/workspaces", policySetHandler.RemoveWorkspaces).Methods("DELETE")
+	api.HandleFunc("/policy-sets/{policy_set_id}/relationships/workspace-exclusions", policySetHandler.AddWorkspaceExclusions).Methods("POST")
+	api.HandleFunc("/policy-sets/{policy_set_id}/relationships/workspace-exclusions", policySetHandler.RemoveWorkspaceExclusions).Methods("DELETE")
+	api.HandleFunc("/policy-sets/{policy_set_id}/relationships/projects", policySetHandler.AddProjects).Methods("POST")
+	api.HandleFunc("/policy-sets/{policy_set_id}/relationships/projects", policySetHandler.RemoveProjects).Methods("DELETE")
+}
@@ -0,0 +1,26 @@
+package router
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/open-tfe/tfe-service/internal/api/handlers"
+)
+
+func (r *Router) registerProviderMirrorRoutes(api *mux.Router, archivist *mux.Router, mirror *mux.Router) {
+	providerMirrorHandler := handlers.NewProviderMirrorHandler(r.service, r.signer, r.logger)
+
+	// Provider mirror admin endpoints
+	api.HandleFunc("/admin/provider-mirror", providerMirrorHandler.List).Methods("GET")
+
+	packages := "/admin/provider-mirror/{hostname}/{namespace}/{type}/{version}/{os}/{arch}"
+	api.HandleFunc(packages, providerMirrorHandler.Upload).Methods("PUT")
+	api.HandleFunc(packages, providerMirrorHandler.Read).Methods("GET")
+	api.HandleFunc(packages, providerMirrorHandler.Delete).Methods("DELETE")
+
+	// Provider network mirror protocol endpoints; index.json must match
+	// before the version document
+	mirror.HandleFunc("/{hostname}/{namespace}/{type}/index.json", providerMirrorHandler.Index).Methods("GET")
+	mirror.HandleFunc("/{hostname}/{namespace}/{type}/{version}.json", providerMirrorHandler.Version).Methods("GET")
+
+	// Signed mirror package download URLs
+	archivist.HandleFunc("/provider-mirror-packages/{package_id}/{filename}", providerMirrorHandler.Download).Methods("GET")
+}
@@ -0,0 +1,41 @@
+package router
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/open-tfe/tfe-service/internal/api/handlers"
+)
+
+func (r *Router) registerRegistryModuleRoutes(api *mux.Router, archivist *mux.Router) {
+	registryModuleHandler := handlers.NewRegistryModuleHandler(r.service, r.signer, r.logger)
+
+	// Registry module endpoints
+	api.HandleFunc("/organizations/{organization_name}/registry-modules", registryModuleHandler.List).Methods("GET")
+	api.HandleFunc("/organizations/{organization_name}/registry-modules", registryModuleHandler.Create).Methods("POST")
+	api.HandleFunc("/registry-modules/{registry_module_id}", registryModuleHandler.Read).Methods("GET")
+
+	modules := "/organizations/{organization_name}/registry-modules/{registry_name}/{namespace}/{name}"
+	api.HandleFunc(modules, registryModuleHandler.Delete).Methods("DELETE")
+	api.HandleFunc(modules+"/{provider}", registryModuleHandler.Read).Methods("GET")
+	api.HandleFunc(modules+"/{provider}", registryModuleHandler.Update).Methods("PATCH")
+	api.HandleFunc(modules+"/{provider}", registryModuleHandler.Delete).Methods("DELETE")
+	api.HandleFunc(modules+"/{provider}/version", registryModuleHandler.ReadVersion).Methods("GET")
+	api.HandleFunc(modules+"/{provider}/{version}", registryModuleHandler.DeleteVersion).Methods("DELETE")
+
+	// Older endpoints that address modules without registry name and namespace
+	api.HandleFunc("/registry-modules/actions/delete/{organization_name}/{name}", registryModuleHandler.Delete).Methods("POST")
+	api.HandleFunc("/registry-modules/actions/delete/{organization_name}/{name}/{provider}", registryModuleHandler.Delete).Methods("POST")
+	api.HandleFunc("/registry-modules/actions/delete/{organization_name}/{name}/{provider}/{version}", registryModuleHandler.DeleteVersion).Methods("POST")
+	api.HandleFunc("/registry-modules/{organization_name}/{name}/{provider}/versions", registryModuleHandler.CreateVersion).Methods("POST")
+
+	// Signed module archive transfer URLs
+	archivist.HandleFunc("/registry-module-versions/{version_id}/upload", registryModuleHandler.Upload).Methods("PUT")
+	archivist.HandleFunc("/registry-module-versions/{version_id}/module.tar.gz", registryModuleHandler.Download).Methods("GET")
+}
+
+func (r *Router) registerModuleRegistryRoutes(modules *mux.Router) {
+	moduleRegistryHandler := handlers.NewModuleRegistryHandler(r.service, r.signer, r.logger)
+
+	// Module registry protocol endpoints
+	modules.HandleFunc("/{namespace}/{name}/{provider}/versions", moduleRegistryHandler.ListVersions).Methods("GET")
+	modules.HandleFunc("/{namespace}/{name}/{provider}/{version}/download", moduleRegistryHandler.Download).Methods("GET")
+}
@@ -0,0 +1,53 @@
+package router
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/open-tfe/tfe-service/internal/api/handlers"
+)
+
+func (r *Router) registerRegistryProviderRoutes(api *mux.Router, archivist *mux.Router) {
+	registryProviderHandler := handlers.NewRegistryProviderHandler(r.service, r.signer, r.logger)
+
+	// Registry provider endpoints
+	api.HandleFunc("/organizations/{organization_name}/registry-providers", registryProviderHandler.List).Methods("GET")
+	api.HandleFunc("/organizations/{organization_name}/registry-providers", registryProviderHandler.Create).Methods("POST")
+
+	providers := "/organizations/{organization_name}/registry-providers/{registry_name}/{namespace}/{name}"
+	api.HandleFunc(providers, registryProviderHandler.Read).Methods("GET")
+	api.HandleFunc(providers, registryProviderHandler.Delete).Methods("DELETE")
+	api.HandleFunc(providers+"/versions", registryProviderHandler.ListVersions).Methods("GET")
+	api.HandleFunc(providers+"/versions", registryProviderHandler.CreateVersion).Methods("POST")
+	api.HandleFunc(providers+"/versions/{version}", registryProviderHandler.ReadVersion).Methods("GET")
+	api.HandleFunc(providers+"/versions/{version}", registryProviderHandler.DeleteVersion).Methods("DELETE")
+	api.HandleFunc(providers+"/versions/{version}/platforms", registryProviderHandler.ListPlatforms).Methods("GET")
+	api.HandleFunc(providers+"/versions/{version}/platforms", registryProviderHandler.CreatePlatform).Methods("POST")
+	api.HandleFunc(providers+"/versions/{version}/platforms/{os}/{arch}", registryProviderHandler.ReadPlatform).Methods("GET")
+	api.HandleFunc(providers+"/versions/{version}/platforms/{os}/{arch}", registryProviderHandler.DeletePlatform).Methods("DELETE")
+
+	// Signed provider release transfer URLs
+	archivist.HandleFunc("/registry-provider-versions/{version_id}/shasums", registryProviderHandler.UploadShasums).Methods("PUT")
+	archivist.HandleFunc("/registry-provider-versions/{version_id}/shasums", registryProviderHandler.DownloadShasums).Methods("GET")
+	archivist.HandleFunc("/registry-provider-versions/{version_id}/shasums-sig", registryProviderHandler.UploadShasumsSignature).Methods("PUT")
+	archivist.HandleFunc("/registry-provider-versions/{version_id}/shasums-sig", registryProviderHandler.DownloadShasumsSignature).Methods("GET")
+	archivist.HandleFunc("/registry-provider-platforms/{platform_id}/binary", registryProviderHandler.UploadBinary).Methods("PUT")
+	archivist.HandleFunc("/registry-provider-platforms/{platform_id}/binary", registryProviderHandler.DownloadBinary).Methods("GET")
+}
+
+func (r *Router) registerGPGKeyRoutes(registry *mux.Router) {
+	gpgKeyHandler := handlers.NewGPGKeyHandler(r.service, r.logger)
+
+	// GPG key endpoints
+	registry.HandleFunc("/gpg-keys", gpgKeyHandler.List).Methods("GET")
+	registry.HandleFunc("/gpg-keys", gpgKeyHandler.Create).Methods("POST")
+	registry.HandleFunc("/gpg-keys/{namespace}/{key_id}", gpgKeyHandler.Read).Methods("GET")
+	registry.HandleFunc("/gpg-keys/{namespace}/{key_id}", gpgKeyHandler.Update).Methods("PATCH")
+	registry.HandleFunc("/gpg-keys/{namespace}/{key_id}", gpgKeyHandler.Delete).Methods("DELETE")
+}
+
+func (r *Router) registerProviderRegistryRoutes(providers *mux.Router) {
+	providerRegistryHandler := handlers.NewProviderRegistryHandler(r.service, r.signer, r.logger)
+
+	// Provider registry protocol endpoints
+	providers.HandleFunc("/{namespace}/{name}/versions", providerRegistryHandler.ListVersions).Methods("GET")
+	providers.HandleFunc("/{namespace}/{name}/{version}/download/{os}/{arch}", providerRegistryHandler.Download).Methods("GET")
+}
@@ -1,8 +1,12 @@
 package router
 
 import (
+	"context"
+	"net"
 	"net/http"
+	"strings"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/open-tfe/tfe-service/internal/auth"
 	"github.com/open-tfe/tfe-service/internal/constants"
@@ -13,34 +17,115 @@ import (
 // Router holds all the route configurations
 type Router struct {
 	*mux.Router
-	service service.Service
-	logger  *zap.Logger
+	service   service.Service
+	jwtSecret string
+	signer    *auth.URLSigner
+	logger    *zap.Logger
 }
 
-// NewRouter creates and configures a new router
-func NewRouter(jwtSecret string, service service.Service, logger *zap.Logger) *Router {
+// NewRouter creates and configures a new router. externalURL is the public
+// base URL used in signed upload and download links; when empty it is taken
+// from each request.
+func NewRouter(jwtSecret, externalURL string, service service.Service, logger *zap.Logger) *Router {
 	r := &Router{
-		Router:  mux.NewRouter(),
-		service: service,
-		logger:  logger,
+		Router:    mux.NewRouter(),
+		service:   service,
+		jwtSecret: jwtSecret,
+		signer:    auth.NewURLSigner(jwtSecret, externalURL),
+		logger:    logger,
 	}
 
+	r.Use(versionHeaders, requestMetadata)
+
+	// Public routes, registered before the API subrouter so they match first
+	public := r.NewRoute().Subrouter()
+	r.registerDiscoveryRoutes(public)
+	r.registerOAuthRoutes(public)
+
+	// Signed object transfer routes, authenticated by URL signature instead of a token
+	archivist := r.PathPrefix(constants.ArchivistPath).Subrouter()
+	archivist.Use(r.signer.Middleware(r.logger), systemContext)
+
 	// API v2 routes
 	api := r.PathPrefix(constants.APIVersionPath).Subrouter()
-	api.Use(auth.JWTMiddleware(jwtSecret, r.logger))
+	api.Use(auth.Middleware(r.jwtSecret, r.service, r.logger))
+
+	// Module registry protocol routes, authenticated like the API
+	modules := r.PathPrefix(strings.TrimSuffix(constants.ModuleRegistryPath, "/")).Subrouter()
+	modules.Use(auth.Middleware(r.jwtSecret, r.service, r.logger))
+
+	// Provider registry protocol routes, authenticated like the API
+	providers := r.PathPrefix(strings.TrimSuffix(constants.ProviderRegistryPath, "/")).Subrouter()
+	providers.Use(auth.Middleware(r.jwtSecret, r.service, r.logger))
+
+	// Provider network mirror routes, authenticated like the API
+	mirror := r.PathPrefix(strings.TrimSuffix(constants.ProviderMirrorPath, "/")).Subrouter()
+	mirror.Use(auth.Middleware(r.jwtSecret, r.service, r.logger))
+
+	// Registry GPG key routes, authenticated like the API
+	registry := r.PathPrefix(constants.GPGKeyRegistryPath).Subrouter()
+	registry.Use(auth.Middleware(r.jwtSecret, r.service, r.logger))
 
 	// Register all routes
 	r.registerOrganizationRoutes(api)
+	r.registerAuditTrailRoutes(api)
 	r.registerProjectRoutes(api)
+	r.registerWorkspaceRoutes(api)
+	r.registerVariableRoutes(api)
+	r.registerVariableSetRoutes(api)
+	r.registerPolicyRoutes(api)
+	r.registerStateVersionRoutes(api, archivist)
+	r.registerConfigurationVersionRoutes(api, archivist)
+	r.registerRunRoutes(api, archivist)
+	r.registerTaskStageRoutes(api)
+	r.registerRunTaskRoutes(api, public)
+	r.registerNotificationConfigurationRoutes(api)
+	r.registerTeamRoutes(api)
+	r.registerTeamAccessRoutes(api)
+	r.registerOrganizationMembershipRoutes(api, public)
 	r.registerUserRoutes(api)
+	r.registerAuthenticationTokenRoutes(api)
+	r.registerAgentPoolRoutes(api)
+	r.registerAgentRoutes(api)
+	r.registerRegistryModuleRoutes(api, archivist)
+	r.registerModuleRegistryRoutes(modules)
+	r.registerRegistryProviderRoutes(api, archivist)
+	r.registerGPGKeyRoutes(registry)
+	r.registerProviderRegistryRoutes(providers)
+	r.registerProviderMirrorRoutes(api, archivist, mirror)
 
-	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	r.NotFoundHandler = versionHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
 		logger.Debug("Not Found",
 			zap.String("method", r.Method),
 			zap.String("url", r.URL.String()),
 		)
-	})
+	}))
 
 	return r
 }
+
+// systemContext runs signed requests as the system. The signature was issued
+// to a caller that was authorized when the URL was signed.
+func systemContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(service.SystemContext(r.Context())))
+	})
+}
+
+// requestMetadata identifies every request and records the client's address,
+// for the audit trail of the mutations it makes. The ID is echoed in the
+// X-Request-Id response header.
+func requestMetadata(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := uuid.NewString()
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+		w.Header().Set("X-Request-Id", requestID)
+		ctx := context.WithValue(r.Context(), constants.RequestIDKey, requestID)
+		ctx = context.WithValue(ctx, constants.ClientIPKey, ip)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,37 @@
+package router
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/open-tfe/tfe-service/internal/api/handlers"
+)
+
+func (r *Router) registerRunRoutes(api *mux.Router, archivist *mux.Router) {
+	runHandler := handlers.NewRunHandler(r.service, r.logger)
+	planHandler := handlers.NewPlanHandler(r.service, r.signer, r.logger)
+
+	// Run endpoints
+	api.HandleFunc("/runs", runHandler.Create).Methods("POST")
+	api.HandleFunc("/runs/{run_id}", runHandler.Read).Methods("GET")
+	api.HandleFunc("/workspaces/{workspace_id}/runs", runHandler.List).Methods("GET")
+	api.HandleFunc("/organizations/{organization_name}/runs", runHandler.ListForOrganization).Methods("GET")
+	api.HandleFunc("/organizations/{organization_name}/runs/queue", runHandler.ReadQueue).Methods("GET")
+
+	// Run actions
+	api.HandleFunc("/runs/{run_id}/actions/apply", runHandler.Apply).Methods("POST")
+	api.HandleFunc("/runs/{run_id}/actions/discard", runHandler.Discard).Methods("POST")
+	api.HandleFunc("/runs/{run_id}/actions/cancel", runHandler.Cancel).Methods("POST")
+	api.HandleFunc("/runs/{run_id}/actions/force-cancel", runHandler.ForceCancel).Methods("POST")
+	api.HandleFunc("/runs/{run_id}/actions/force-execute", runHandler.ForceExecute).Methods("POST")
+
+	// Run phases
+	api.HandleFunc("/plans/{plan_id}", planHandler.ReadPlan).Methods("GET")
+	api.HandleFunc("/applies/{apply_id}", planHandler.ReadApply).Methods("GET")
+	api.HandleFunc("/plans/{plan_id}/json-output", planHandler.JSONOutput).Methods("GET")
+	api.HandleFunc("/plans/{plan_id}/json-output-redacted", planHandler.JSONOutput).Methods("GET")
+	api.HandleFunc("/runs/{run_id}/plan/json-output", planHandler.RunJSONOutput).Methods("GET")
+	api.HandleFunc("/runs/{run_id}/plan/json-output-redacted", planHandler.RunJSONOutput).Methods("GET")
+
+	// Signed log read URLs, with the signature in the path
+	archivist.HandleFunc("/logs/{signature}/plans/{plan_id}", planHandler.PlanLog).Methods("GET")
+	archivist.HandleFunc("/logs/{signature}/applies/{apply_id}", planHandler.ApplyLog).Methods("GET")
+}
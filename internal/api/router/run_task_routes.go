@@ -0,0 +1,31 @@
+package router
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/open-tfe/tfe-service/internal/api/handlers"
+	"github.com/open-tfe/tfe-service/internal/constants"
+)
+
+func (r *Router) registerRunTaskRoutes(api, public *mux.Router) {
+	runTaskHandler := handlers.NewRunTaskHandler(r.service, r.logger)
+	taskResultHandler := handlers.NewTaskResultHandler(r.service, r.logger)
+
+	// Run task endpoints
+	api.HandleFunc("/organizations/{organization_name}/tasks", runTaskHandler.List).Methods("GET")
+	api.HandleFunc("/organizations/{organization_name}/tasks", runTaskHandler.Create).Methods("POST")
+	api.HandleFunc("/tasks/{task_id}", runTaskHandler.Read).Methods("GET")
+	api.HandleFunc("/tasks/{task_id}", runTaskHandler.Update).Methods("PATCH")
+	api.HandleFunc("/tasks/{task_id}", runTaskHandler.Delete).Methods("DELETE")
+
+	// Workspace run task endpoints
+	api.HandleFunc("/workspaces/{workspace_id}/tasks", runTaskHandler.ListWorkspaceTasks).Methods("GET")
+	api.HandleFunc("/workspaces/{workspace_id}/tasks", runTaskHandler.CreateWorkspaceTask).Methods("POST")
+	api.HandleFunc("/workspaces/{workspace_id}/tasks/{workspace_task_id}", runTaskHandler.ReadWorkspaceTask).Methods("GET")
+	api.HandleFunc("/workspaces/{workspace_id}/tasks/{workspace_task_id}", runTaskHandler.UpdateWorkspaceTask).Methods("PATCH")
+	api.HandleFunc("/workspaces/{workspace_id}/tasks/{workspace_task_id}", runTaskHandler.DeleteWorkspaceTask).Methods("DELETE")
+
+	// Task result endpoints. Tasks report back with the access token of
+	// their request, so the callback is not behind API authentication.
+	api.HandleFunc("/task-results/{task_result_id}", taskResultHandler.Read).Methods("GET")
+	public.HandleFunc(constants.APIVersionPath+"/task-results/{task_result_id}/callback", taskResultHandler.Callback).Methods("PATCH")
+}
@@ -0,0 +1,27 @@
+package router
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/open-tfe/tfe-service/internal/api/handlers"
+)
+
+func (r *Router) registerStateVersionRoutes(api *mux.Router, archivist *mux.Router) {
+	stateVersionHandler := handlers.NewStateVersionHandler(r.service, r.signer, r.logger)
+
+	// State version endpoints
+	api.HandleFunc("/state-versions", stateVersionHandler.List).Methods("GET")
+	api.HandleFunc("/workspaces/{workspace_id}/state-versions", stateVersionHandler.Create).Methods("POST")
+	api.HandleFunc("/workspaces/{workspace_id}/current-state-version", stateVersionHandler.ReadCurrent).Methods("GET")
+	api.HandleFunc("/state-versions/{state_version_id}", stateVersionHandler.Read).Methods("GET")
+
+	// State version outputs
+	api.HandleFunc("/state-versions/{state_version_id}/outputs", stateVersionHandler.ListOutputs).Methods("GET")
+	api.HandleFunc("/workspaces/{workspace_id}/current-state-version-outputs", stateVersionHandler.ReadCurrentOutputs).Methods("GET")
+	api.HandleFunc("/state-version-outputs/{output_id}", stateVersionHandler.ReadOutput).Methods("GET")
+
+	// Signed state transfer URLs
+	archivist.HandleFunc("/state-versions/{state_version_id}/state", stateVersionHandler.UploadState).Methods("PUT")
+	archivist.HandleFunc("/state-versions/{state_version_id}/state", stateVersionHandler.DownloadState).Methods("GET")
+	archivist.HandleFunc("/state-versions/{state_version_id}/json-state", stateVersionHandler.UploadJSONState).Methods("PUT")
+	archivist.HandleFunc("/state-versions/{state_version_id}/json-state", stateVersionHandler.DownloadJSONState).Methods("GET")
+}
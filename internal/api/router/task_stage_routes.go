@@ -0,0 +1,20 @@
+package router
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/open-tfe/tfe-service/internal/api/handlers"
+)
+
+func (r *Router) registerTaskStageRoutes(api *mux.Router) {
+	taskStageHandler := handlers.NewTaskStageHandler(r.service, r.logger)
+
+	// Task stage endpoints
+	api.HandleFunc("/runs/{run_id}/task-stages", taskStageHandler.List).Methods("GET")
+	api.HandleFunc("/task-stages/{task_stage_id}", taskStageHandler.Read).Methods("GET")
+	api.HandleFunc("/task-stages/{task_stage_id}/actions/override", taskStageHandler.Override).Methods("POST")
+
+	// Policy evaluation endpoints
+	api.HandleFunc("/task-stages/{task_stage_id}/policy-evaluations", taskStageHandler.ListPolicyEvaluations).Methods("GET")
+	api.HandleFunc("/policy-evaluations/{policy_evaluation_id}/policy-set-outcomes", taskStageHandler.ListPolicySetOutcomes).Methods("GET")
+	api.HandleFunc("/policy-set-outcomes/{policy_set_outcome_id}", taskStageHandler.ReadPolicySetOutcome).Methods("GET")
+}
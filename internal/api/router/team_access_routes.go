@@ -0,0 +1,24 @@
+package router
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/open-tfe/tfe-service/internal/api/handlers"
+)
+
+func (r *Router) registerTeamAccessRoutes(api *mux.Router) {
+	teamAccessHandler := handlers.NewTeamAccessHandler(r.service, r.logger)
+
+	// Team workspace access endpoints
+	api.HandleFunc("/team-workspaces", teamAccessHandler.ListWorkspaceAccesses).Methods("GET")
+	api.HandleFunc("/team-workspaces", teamAccessHandler.AddWorkspaceAccess).Methods("POST")
+	api.HandleFunc("/team-workspaces/{team_access_id}", teamAccessHandler.ReadWorkspaceAccess).Methods("GET")
+	api.HandleFunc("/team-workspaces/{team_access_id}", teamAccessHandler.UpdateWorkspaceAccess).Methods("PATCH")
+	api.HandleFunc("/team-workspaces/{team_access_id}", teamAccessHandler.RemoveWorkspaceAccess).Methods("DELETE")
+
+	// Team project access endpoints
+	api.HandleFunc("/team-projects", teamAccessHandler.ListProjectAccesses).Methods("GET")
+	api.HandleFunc("/team-projects", teamAccessHandler.AddProjectAccess).Methods("POST")
+	api.HandleFunc("/team-projects/{team_project_access_id}", teamAccessHandler.ReadProjectAccess).Methods("GET")
+	api.HandleFunc("/team-projects/{team_project_access_id}", teamAccessHandler.UpdateProjectAccess).Methods("PATCH")
+	api.HandleFunc("/team-projects/{team_project_access_id}", teamAccessHandler.RemoveProjectAccess).Methods("DELETE")
+}
@@ -0,0 +1,23 @@
+package router
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/open-tfe/tfe-service/internal/api/handlers"
+)
+
+func (r *Router) registerTeamRoutes(api *mux.Router) {
+	teamHandler := handlers.NewTeamHandler(r.service, r.logger)
+
+	// Team endpoints
+	api.HandleFunc("/organizations/{organization_name}/teams", teamHandler.List).Methods("GET")
+	api.HandleFunc("/organizations/{organization_name}/teams", teamHandler.Create).Methods("POST")
+	api.HandleFunc("/teams/{team_id}", teamHandler.Read).Methods("GET")
+	api.HandleFunc("/teams/{team_id}", teamHandler.Update).Methods("PATCH")
+	api.HandleFunc("/teams/{team_id}", teamHandler.Delete).Methods("DELETE")
+
+	// Team membership
+	api.HandleFunc("/teams/{team_id}/relationships/users", teamHandler.AddUsers).Methods("POST")
+	api.HandleFunc("/teams/{team_id}/relationships/users", teamHandler.RemoveUsers).Methods("DELETE")
+	api.HandleFunc("/teams/{team_id}/relationships/organization-memberships", teamHandler.AddOrganizationMemberships).Methods("POST")
+	api.HandleFunc("/teams/{team_id}/relationships/organization-memberships", teamHandler.RemoveOrganizationMemberships).Methods("DELETE")
+}
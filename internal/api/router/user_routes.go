@@ -15,4 +15,5 @@ func (r *Router) registerUserRoutes(api *mux.Router) {
 	api.HandleFunc("/users/{user_id}", userHandler.Update).Methods("PATCH")
 	api.HandleFunc("/users/{user_id}", userHandler.Delete).Methods("DELETE")
 	api.HandleFunc("/account/details", userHandler.AccountDetails).Methods("GET")
+	api.HandleFunc("/account/password", userHandler.UpdatePassword).Methods("PATCH")
 }
@@ -0,0 +1,20 @@
+package router
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/open-tfe/tfe-service/internal/api/handlers"
+)
+
+func (r *Router) registerVariableRoutes(api *mux.Router) {
+	variableHandler := handlers.NewVariableHandler(r.service, r.logger)
+
+	// Workspace variable endpoints
+	api.HandleFunc("/workspaces/{workspace_id}/vars", variableHandler.List).Methods("GET")
+	api.HandleFunc("/workspaces/{workspace_id}/vars", variableHandler.Create).Methods("POST")
+	api.HandleFunc("/workspaces/{workspace_id}/vars/{variable_id}", variableHandler.Read).Methods("GET")
+	api.HandleFunc("/workspaces/{workspace_id}/vars/{variable_id}", variableHandler.Update).Methods("PATCH")
+	api.HandleFunc("/workspaces/{workspace_id}/vars/{variable_id}", variableHandler.Delete).Methods("DELETE")
+
+	// Merged view of workspace, variable set and priority variables
+	api.HandleFunc("/workspaces/{workspace_id}/effective-variables", variableHandler.ListEffective).Methods("GET")
+}
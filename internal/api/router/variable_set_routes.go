@@ -0,0 +1,32 @@
+package router
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/open-tfe/tfe-service/internal/api/handlers"
+)
+
+func (r *Router) registerVariableSetRoutes(api *mux.Router) {
+	variableSetHandler := handlers.NewVariableSetHandler(r.service, r.logger)
+
+	// Variable set endpoints
+	api.HandleFunc("/organizations/{organization_name}/varsets", variableSetHandler.List).Methods("GET")
+	api.HandleFunc("/organizations/{organization_name}/varsets", variableSetHandler.Create).Methods("POST")
+	api.HandleFunc("/workspaces/{workspace_id}/varsets", variableSetHandler.ListForWorkspace).Methods("GET")
+	api.HandleFunc("/projects/{project_id}/varsets", variableSetHandler.ListForProject).Methods("GET")
+	api.HandleFunc("/varsets/{variable_set_id}", variableSetHandler.Read).Methods("GET")
+	api.HandleFunc("/varsets/{variable_set_id}", variableSetHandler.Update).Methods("PATCH")
+	api.HandleFunc("/varsets/{variable_set_id}", variableSetHandler.Delete).Methods("DELETE")
+
+	// Variable set scope
+	api.HandleFunc("/varsets/{variable_set_id}/relationships/workspaces", variableSetHandler.ApplyToWorkspaces).Methods("POST")
+	api.HandleFunc("/varsets/{variable_set_id}/relationships/workspaces", variableSetHandler.RemoveFromWorkspaces).Methods("DELETE")
+	api.HandleFunc("/varsets/{variable_set_id}/relationships/projects", variableSetHandler.ApplyToProjects).Methods("POST")
+	api.HandleFunc("/varsets/{variable_set_id}/relationships/projects", variableSetHandler.RemoveFromProjects).Methods("DELETE")
+
+	// Variable set variables
+	api.HandleFunc("/varsets/{variable_set_id}/relationships/vars", variableSetHandler.ListVariables).Methods("GET")
+	api.HandleFunc("/varsets/{variable_set_id}/relationships/vars", variableSetHandler.CreateVariable).Methods("POST")
+	api.HandleFunc("/varsets/{variable_set_id}/relationships/vars/{variable_id}", variableSetHandler.ReadVariable).Methods("GET")
+	api.HandleFunc("/varsets/{variable_set_id}/relationships/vars/{variable_id}", variableSetHandler.UpdateVariable).Methods("PATCH")
+	api.HandleFunc("/varsets/{variable_set_id}/relationships/vars/{variable_id}", variableSetHandler.DeleteVariable).Methods("DELETE")
+}
@@ -0,0 +1,29 @@
+package router
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/open-tfe/tfe-service/internal/api/handlers"
+)
+
+func (r *Router) registerWorkspaceRoutes(api *mux.Router) {
+	workspaceHandler := handlers.NewWorkspaceHandler(r.service, r.logger)
+
+	// Workspace endpoints addressed by organization and name
+	api.HandleFunc("/organizations/{organization_name}/workspaces", workspaceHandler.List).Methods("GET")
+	api.HandleFunc("/organizations/{organization_name}/workspaces", workspaceHandler.Create).Methods("POST")
+	api.HandleFunc("/organizations/{organization_name}/workspaces/{workspace_name}", workspaceHandler.Read).Methods("GET")
+	api.HandleFunc("/organizations/{organization_name}/workspaces/{workspace_name}", workspaceHandler.Update).Methods("PATCH")
+	api.HandleFunc("/organizations/{organization_name}/workspaces/{workspace_name}", workspaceHandler.Delete).Methods("DELETE")
+	api.HandleFunc("/organizations/{organization_name}/workspaces/{workspace_name}/actions/safe-delete", workspaceHandler.SafeDelete).Methods("POST")
+
+	// Workspace endpoints addressed by ID
+	api.HandleFunc("/workspaces/{workspace_id}", workspaceHandler.ReadByID).Methods("GET")
+	api.HandleFunc("/workspaces/{workspace_id}", workspaceHandler.UpdateByID).Methods("PATCH")
+	api.HandleFunc("/workspaces/{workspace_id}", workspaceHandler.DeleteByID).Methods("DELETE")
+	api.HandleFunc("/workspaces/{workspace_id}/actions/safe-delete", workspaceHandler.SafeDeleteByID).Methods("POST")
+
+	// Workspace locking
+	api.HandleFunc("/workspaces/{workspace_id}/actions/lock", workspaceHandler.Lock).Methods("POST")
+	api.HandleFunc("/workspaces/{workspace_id}/actions/unlock", workspaceHandler.Unlock).Methods("POST")
+	api.HandleFunc("/workspaces/{workspace_id}/actions/force-unlock", workspaceHandler.ForceUnlock).Methods("POST")
+}
@@ -12,7 +12,16 @@ import (
 	"go.uber.org/zap"
 )
 
-func JWTMiddleware(secret string, logger *zap.Logger) mux.MiddlewareFunc {
+// TokenAuthenticator resolves opaque API tokens into a context carrying the
+// token's principal.
+type TokenAuthenticator interface {
+	AuthenticateToken(ctx context.Context, token string) (context.Context, error)
+}
+
+// Middleware authenticates API requests by bearer token. Opaque API tokens
+// are resolved by tokens; any other token must be a JWT signed with secret
+// carrying the user's email.
+func Middleware(secret string, tokens TokenAuthenticator, logger *zap.Logger) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			logger.Debug("Processing request", zap.String("path", r.URL.Path))
@@ -26,6 +35,17 @@ func JWTMiddleware(secret string, logger *zap.Logger) mux.MiddlewareFunc {
 
 			logger.Debug("Found Authorization header, parsing token")
 			tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+			if strings.Contains(tokenString, constants.APITokenSeparator) {
+				ctx, err := tokens.AuthenticateToken(r.Context(), tokenString)
+				if err != nil {
+					logger.Debug("API token authentication failed", zap.Error(err))
+					http.Error(w, "Invalid token", http.StatusUnauthorized)
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, constants.UserTokenKey, tokenString)))
+				return
+			}
+
 			token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 					return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
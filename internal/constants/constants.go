@@ -8,10 +8,66 @@ const (
 	APIVersionPath = "/api/v2"
 )
 
+// Terraform Enterprise compatibility constants. Terraform and go-tfe read
+// these from the response headers to decide which API features are usable.
+const (
+	TFEAPIVersion = "2.6"
+	TFEVersion    = "v202410-1"
+	TFEAppName    = "Terraform Enterprise"
+)
+
+// Service discovery paths advertised in /.well-known/terraform.json
+const (
+	DiscoveryPath          = "/.well-known/terraform.json"
+	ModuleRegistryPath     = "/api/registry/v1/modules/"
+	ProviderRegistryPath   = "/api/registry/v1/providers/"
+	OAuthAuthorizationPath = "/app/oauth2/authorize"
+	OAuthTokenPath         = "/oauth2/token"
+	OAuthClientID          = "terraform-cli"
+)
+
+// GPGKeyRegistryPath prefixes the registry GPG key endpoints, which live
+// outside APIVersionPath.
+const GPGKeyRegistryPath = "/api/registry/{registry_name}/v2"
+
+// ProviderMirrorPath is the base URL of the provider network mirror, for
+// use in a provider_installation network_mirror block. Mirrors are not
+// advertised through service discovery.
+const ProviderMirrorPath = "/v1/providers/"
+
+// InvitationPath is where the links in organization invitation emails point.
+const InvitationPath = "/app/invitations"
+
+// Signed object URLs used for state, configuration and log transfers
+const (
+	ArchivistPath = "/_archivist"
+	SignedURLTTL  = time.Hour
+
+	// LogURLTTL covers clients that keep tailing a log for a whole run.
+	LogURLTTL = 24 * time.Hour
+)
+
+// LoginTokenTTL is the lifetime of API tokens issued through `terraform login`.
+const LoginTokenTTL = 365 * 24 * time.Hour
+
+// APITokenSeparator joins the ID and secret of opaque API tokens, which
+// take the Terraform Enterprise form <id>.atlasv1.<secret>.
+const APITokenSeparator = ".atlasv1."
+
+// AgentPath prefixes the agent protocol endpoints below APIVersionPath.
+// Agents authenticate with an agent token of their pool.
+const AgentPath = "/agent"
+
 // Context key constants
 type ContextKey string
 
 const (
 	UserEmailKey ContextKey = "userEmail"
 	UserTokenKey ContextKey = "userToken"
+	RequestIDKey ContextKey = "requestID"
+	ClientIPKey  ContextKey = "clientIP"
 )
+
+// ForceCancelCooldown is how long a run must ignore a cancel request before
+// it can be forcefully canceled.
+const ForceCancelCooldown = time.Minute
@@ -1,9 +1,16 @@
 package initialize
 
 import (
+	"context"
 	"fmt"
 	"time"
 
+	"github.com/open-tfe/tfe-service/internal/mail"
+	"github.com/open-tfe/tfe-service/internal/notification"
+	"github.com/open-tfe/tfe-service/internal/policy"
+	"github.com/open-tfe/tfe-service/internal/runtask"
+	"github.com/open-tfe/tfe-service/internal/secrets"
+	"github.com/open-tfe/tfe-service/internal/storage"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 	"gorm.io/driver/postgres"
@@ -23,6 +30,7 @@ func Config(logger *zap.Logger) {
 		zap.String("config_file", viper.ConfigFileUsed()),
 		zap.Any("server_config", viper.GetStringMap("server")),
 		zap.Any("database_config", viper.GetStringMap("database")),
+		zap.String("storage_driver", viper.GetString("storage.driver")),
 		zap.String("jwt_secret", viper.GetString("jwt_secret")),
 	)
 }
@@ -55,3 +63,99 @@ func Database(logger *zap.Logger) *gorm.DB {
 
 	return db
 }
+
+func Storage(logger *zap.Logger) storage.BlobStore {
+	switch driver := viper.GetString("storage.driver"); driver {
+	case "", "local":
+		path := viper.GetString("storage.local.path")
+		logger.Debug("Using local blob storage", zap.String("path", path))
+
+		store, err := storage.NewLocalStore(path)
+		if err != nil {
+			logger.Fatal("Failed to initialize local blob storage", zap.Error(err))
+		}
+		return store
+	case "s3":
+		cfg := storage.S3Config{
+			Endpoint:  viper.GetString("storage.s3.endpoint"),
+			Bucket:    viper.GetString("storage.s3.bucket"),
+			Region:    viper.GetString("storage.s3.region"),
+			AccessKey: viper.GetString("storage.s3.access_key"),
+			SecretKey: viper.GetString("storage.s3.secret_key"),
+			UseSSL:    viper.GetBool("storage.s3.use_ssl"),
+			PartSize:  viper.GetUint64("storage.s3.part_size"),
+		}
+		logger.Debug("Using S3 blob storage", zap.String("endpoint", cfg.Endpoint), zap.String("bucket", cfg.Bucket))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		store, err := storage.NewS3Store(ctx, cfg)
+		if err != nil {
+			logger.Fatal("Failed to initialize S3 blob storage", zap.Error(err))
+		}
+		return store
+	default:
+		logger.Fatal("Unknown storage driver", zap.String("driver", driver))
+		return nil
+	}
+}
+
+func Secrets(logger *zap.Logger) *secrets.Cipher {
+	cipher, err := secrets.NewCipher(viper.GetString("encryption_key"))
+	if err != nil {
+		logger.Fatal("Failed to initialize encryption", zap.Error(err))
+	}
+	return cipher
+}
+
+// Mailer returns an SMTP mailer, or one that only logs messages when no
+// SMTP host is configured.
+func Mailer(logger *zap.Logger) mail.Mailer {
+	cfg := mail.SMTPConfig{
+		Host:     viper.GetString("mail.smtp.host"),
+		Port:     viper.GetInt("mail.smtp.port"),
+		Username: viper.GetString("mail.smtp.username"),
+		Password: viper.GetString("mail.smtp.password"),
+		From:     viper.GetString("mail.from"),
+	}
+	if cfg.Host == "" {
+		logger.Debug("No SMTP host configured, logging emails instead")
+		return mail.NewLogMailer(logger)
+	}
+
+	mailer, err := mail.NewSMTPMailer(cfg)
+	if err != nil {
+		logger.Fatal("Failed to initialize mailer", zap.Error(err))
+	}
+	logger.Debug("Using SMTP mailer", zap.String("host", cfg.Host), zap.Int("port", cfg.Port))
+	return mailer
+}
+
+// Policies returns the engine that evaluates OPA policy sets after plans.
+func Policies(logger *zap.Logger) *policy.Engine {
+	bin := viper.GetString("policy.opa_bin")
+	logger.Debug("Using opa for policy evaluation", zap.String("opa", bin))
+	return policy.NewEngine(bin)
+}
+
+// RunTasks returns the client that sends runs to run tasks. Tasks report
+// back to server.external_url, or to the local server when it is empty.
+func RunTasks(logger *zap.Logger) *runtask.Client {
+	baseURL := viper.GetString("server.external_url")
+	if baseURL == "" {
+		baseURL = fmt.Sprintf("http://localhost:%d", viper.GetInt("server.port"))
+		logger.Warn("server.external_url is not set; run tasks call back to the local server", zap.String("url", baseURL))
+	}
+	return runtask.NewClient(baseURL)
+}
+
+// Notifications returns the client that delivers run notifications. Run
+// links point at server.external_url, or at the local server when it is
+// empty.
+func Notifications(logger *zap.Logger) *notification.Client {
+	baseURL := viper.GetString("server.external_url")
+	if baseURL == "" {
+		baseURL = fmt.Sprintf("http://localhost:%d", viper.GetInt("server.port"))
+	}
+	return notification.NewClient(baseURL)
+}
@@ -0,0 +1,26 @@
+package mail
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// LogMailer writes messages to the log instead of sending them. It is used
+// when no SMTP relay is configured.
+type LogMailer struct {
+	logger *zap.Logger
+}
+
+func NewLogMailer(logger *zap.Logger) *LogMailer {
+	return &LogMailer{logger: logger.With(zap.String("component", "mail"))}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	m.logger.Info("Email not sent, no SMTP server configured",
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.String("body", msg.Body),
+	)
+	return nil
+}
@@ -0,0 +1,20 @@
+// Package mail delivers notification emails such as organization
+// invitations.
+package mail
+
+import (
+	"context"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends email messages.
+type Mailer interface {
+	// Send delivers msg or returns an error when it could not be handed off.
+	Send(ctx context.Context, msg Message) error
+}
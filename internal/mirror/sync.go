@@ -0,0 +1,130 @@
+// Package mirror fills the provider network mirror from a directory of
+// provider packages.
+package mirror
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Result counts the packages a sync looked at.
+type Result struct {
+	Uploaded  int
+	Unchanged int
+	Skipped   int
+}
+
+// Sync uploads the provider packages below dir to the mirror. dir uses the
+// packed layout that `terraform providers mirror` writes:
+//
+//	<hostname>/<namespace>/<type>/terraform-provider-<type>_<version>_<os>_<arch>.zip
+//
+// Packages the mirror already holds with the same checksum are left alone,
+// so a sync can be repeated. Other files, such as the index.json files of
+// the layout, are skipped.
+func Sync(ctx context.Context, svc service.Service, dir string, logger *zap.Logger) (*Result, error) {
+	logger = logger.With(zap.String("component", "mirror_sync"))
+	result := &Result{}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".zip") {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		packageID, ok := parsePackagePath(filepath.ToSlash(rel))
+		if !ok {
+			logger.Warn("Skipping file outside the packed mirror layout", zap.String("path", rel))
+			result.Skipped++
+			return nil
+		}
+
+		uploaded, err := syncPackage(ctx, svc, packageID, path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", rel, err)
+		}
+		if uploaded {
+			logger.Info("Uploaded provider package", zap.String("path", rel))
+			result.Uploaded++
+		} else {
+			logger.Debug("Provider package unchanged", zap.String("path", rel))
+			result.Unchanged++
+		}
+		return nil
+	})
+	return result, err
+}
+
+// syncPackage uploads one package unless the mirror holds it already.
+func syncPackage(ctx context.Context, svc service.Service, packageID service.ProviderMirrorPackageID, path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return false, err
+	}
+	existing, err := svc.ReadProviderMirrorPackage(ctx, packageID)
+	switch {
+	case err == nil && slices.Contains(existing.Hashes, "zh:"+hex.EncodeToString(hash.Sum(nil))):
+		return false, nil
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		return false, err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+	if _, err := svc.UploadProviderMirrorPackage(ctx, packageID, f); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// parsePackagePath reads a package address from its path in the packed
+// layout.
+func parsePackagePath(rel string) (service.ProviderMirrorPackageID, bool) {
+	parts := strings.Split(rel, "/")
+	if len(parts) != 4 {
+		return service.ProviderMirrorPackageID{}, false
+	}
+	hostname, namespace, providerType, filename := parts[0], parts[1], parts[2], parts[3]
+
+	prefix := "terraform-provider-" + providerType + "_"
+	if !strings.HasPrefix(filename, prefix) {
+		return service.ProviderMirrorPackageID{}, false
+	}
+	fields := strings.Split(strings.TrimSuffix(strings.TrimPrefix(filename, prefix), ".zip"), "_")
+	if len(fields) != 3 {
+		return service.ProviderMirrorPackageID{}, false
+	}
+	return service.ProviderMirrorPackageID{
+		Hostname:  hostname,
+		Namespace: namespace,
+		Type:      providerType,
+		Version:   fields[0],
+		OS:        fields[1],
+		Arch:      fields[2],
+	}, true
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-tfe"
+	"gorm.io/gorm"
+)
+
+// Agent statuses. Idle and busy are derived from the runs an agent holds
+// each time it checks in.
+const (
+	AgentIdle    = "idle"
+	AgentBusy    = "busy"
+	AgentUnknown = "unknown"
+	AgentExited  = "exited"
+)
+
+// AgentPingTimeout is how long an agent may stay silent before its status
+// is reported as unknown.
+const AgentPingTimeout = 2 * time.Minute
+
+// AgentPool groups the self-hosted agents that execute runs of workspaces
+// in agent execution mode. An organization scoped pool serves every
+// workspace of the organization; otherwise only its allowed workspaces may
+// use it.
+type AgentPool struct {
+	gorm.Model
+	ID                 uuid.UUID     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,agent-pools"`
+	Name               string        `gorm:"not null" jsonapi:"attr,name"`
+	OrganizationScoped bool          `gorm:"default:true" jsonapi:"attr,organization-scoped"`
+	OrganizationID     uuid.UUID     `gorm:"type:uuid;not null"`
+	Organization       *Organization `gorm:"foreignKey:OrganizationID"`
+	Workspaces         []*Workspace  `gorm:"foreignKey:AgentPoolID"`
+	AllowedWorkspaces  []*Workspace  `gorm:"many2many:agent_pool_allowed_workspaces"`
+	Agents             []*Agent      `gorm:"foreignKey:AgentPoolID"`
+}
+
+// AgentPoolAllowedWorkspace allows a workspace to use an agent pool that is
+// not organization scoped.
+type AgentPoolAllowedWorkspace struct {
+	AgentPoolID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	WorkspaceID uuid.UUID `gorm:"type:uuid;primaryKey"`
+}
+
+// Agent is a self-hosted agent registered with a pool.
+type Agent struct {
+	gorm.Model
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,agents"`
+	Name        string     `gorm:"type:varchar(255)" jsonapi:"attr,name"`
+	IPAddress   string     `gorm:"type:varchar(255)" jsonapi:"attr,ip-address"`
+	Status      string     `gorm:"type:varchar(255);not null" jsonapi:"attr,status"`
+	LastPingAt  *time.Time `gorm:"type:timestamp"`
+	AgentPoolID uuid.UUID  `gorm:"type:uuid;not null"`
+	AgentPool   *AgentPool `gorm:"foreignKey:AgentPoolID"`
+}
+
+// ToTFE converts the internal AgentPool model to TFE format. The agent
+// count covers agents that have not exited.
+func (p *AgentPool) ToTFE() *tfe.AgentPool {
+	pool := &tfe.AgentPool{
+		ID:                 p.ID.String(),
+		Name:               p.Name,
+		OrganizationScoped: p.OrganizationScoped,
+		Workspaces:         make([]*tfe.Workspace, len(p.Workspaces)),
+		AllowedWorkspaces:  make([]*tfe.Workspace, len(p.AllowedWorkspaces)),
+	}
+	if p.Organization != nil {
+		pool.Organization = &tfe.Organization{Name: p.Organization.Name}
+	}
+	for i, ws := range p.Workspaces {
+		pool.Workspaces[i] = &tfe.Workspace{ID: ws.ID.String()}
+	}
+	for i, ws := range p.AllowedWorkspaces {
+		pool.AllowedWorkspaces[i] = &tfe.Workspace{ID: ws.ID.String()}
+	}
+	for _, agent := range p.Agents {
+		if agent.Status != AgentExited {
+			pool.AgentCount++
+		}
+	}
+	return pool
+}
+
+// ToTFE converts the internal Agent model to TFE format. Agents that have
+// not checked in within AgentPingTimeout are reported as unknown.
+func (a *Agent) ToTFE() *tfe.Agent {
+	agent := &tfe.Agent{
+		ID:     a.ID.String(),
+		Name:   a.Name,
+		IP:     a.IPAddress,
+		Status: a.Status,
+	}
+	if a.LastPingAt != nil {
+		agent.LastPingAt = a.LastPingAt.UTC().Format(time.RFC3339)
+		if a.Status != AgentExited && time.Since(*a.LastPingAt) > AgentPingTimeout {
+			agent.Status = AgentUnknown
+		}
+	}
+	return agent
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-tfe"
+)
+
+// Audit event versions and types.
+const (
+	AuditEventVersion = "0"
+	AuditEventType    = "Resource"
+)
+
+// Audit event actions. Mutations other than creating, updating and
+// deleting a resource are recorded with the name of the operation.
+const (
+	AuditActionCreate      = "create"
+	AuditActionUpdate      = "update"
+	AuditActionDelete      = "delete"
+	AuditActionUpload      = "upload"
+	AuditActionArchive     = "archive"
+	AuditActionLock        = "lock"
+	AuditActionUnlock      = "unlock"
+	AuditActionForceUnlock = "force-unlock"
+	AuditActionApply       = "apply"
+	AuditActionDiscard     = "discard"
+	AuditActionCancel      = "cancel"
+	AuditActionForceCancel = "force-cancel"
+	AuditActionForceRun    = "force-execute"
+	AuditActionOverride    = "override"
+	AuditActionVerify      = "verify"
+	AuditActionAccept      = "accept"
+	AuditActionDecline     = "decline"
+)
+
+// AuditEvent records one mutation of an organization's resources. Events
+// are append-only: they have no update or delete path and outlive the
+// resources, and even the organizations, they describe, so they carry no
+// foreign keys and no soft-delete column.
+type AuditEvent struct {
+	ID             uuid.UUID              `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CreatedAt      time.Time              `gorm:"not null"`
+	OrganizationID *uuid.UUID             `gorm:"type:uuid"`
+	ActorType      string                 `gorm:"type:varchar(255);not null"`
+	ActorID        string                 `gorm:"type:varchar(255)"`
+	ActorName      string                 `gorm:"type:text"`
+	TokenID        *uuid.UUID             `gorm:"type:uuid"`
+	IPAddress      string                 `gorm:"type:varchar(255)"`
+	RequestID      string                 `gorm:"type:varchar(255)"`
+	ResourceType   string                 `gorm:"type:varchar(255);not null"`
+	ResourceID     string                 `gorm:"type:varchar(255);not null"`
+	Action         string                 `gorm:"type:varchar(255);not null"`
+	Changes        map[string]AuditChange `gorm:"type:jsonb;serializer:json"`
+}
+
+// AuditChange is the value of one attribute before and after a mutation.
+// Before is null for created resources and After for deleted ones.
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditTrailEvent is an audit event in the format of the audit trail API,
+// extended with the token and IP address of the request.
+type AuditTrailEvent struct {
+	tfe.AuditTrail
+	Auth    AuditTrailEventAuth    `json:"auth"`
+	Request AuditTrailEventRequest `json:"request"`
+}
+
+// AuditTrailEventAuth adds the ID of the token that made the request to
+// the actor of an audit trail event.
+type AuditTrailEventAuth struct {
+	tfe.AuditTrailAuth
+	TokenID *string `json:"token_id"`
+}
+
+// AuditTrailEventRequest adds the client's IP address to the request of an
+// audit trail event.
+type AuditTrailEventRequest struct {
+	tfe.AuditTrailRequest
+	IPAddress string `json:"ip_address"`
+}
+
+// ToTFE converts the internal AuditEvent model to the audit trail format.
+// The changes are reported in the resource's meta.
+func (e *AuditEvent) ToTFE() *AuditTrailEvent {
+	event := &AuditTrailEvent{
+		AuditTrail: tfe.AuditTrail{
+			ID:        e.ID.String(),
+			Version:   AuditEventVersion,
+			Type:      AuditEventType,
+			Timestamp: e.CreatedAt,
+			Resource: tfe.AuditTrailResource{
+				ID:     e.ResourceID,
+				Type:   e.ResourceType,
+				Action: e.Action,
+				Meta:   map[string]interface{}{"changes": e.Changes},
+			},
+		},
+		Auth: AuditTrailEventAuth{
+			AuditTrailAuth: tfe.AuditTrailAuth{
+				AccessorID:  e.ActorID,
+				Description: e.ActorName,
+				Type:        e.ActorType,
+			},
+		},
+		Request: AuditTrailEventRequest{
+			AuditTrailRequest: tfe.AuditTrailRequest{ID: e.RequestID},
+			IPAddress:         e.IPAddress,
+		},
+	}
+	if e.OrganizationID != nil {
+		event.Auth.OrganizationID = e.OrganizationID.String()
+	}
+	if e.TokenID != nil {
+		tokenID := e.TokenID.String()
+		event.Auth.TokenID = &tokenID
+	}
+	if e.Changes == nil {
+		event.Resource.Meta["changes"] = map[string]AuditChange{}
+	}
+	return event
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-tfe"
+	"gorm.io/gorm"
+)
+
+// Kinds of authentication tokens. Each kind belongs to a different owner.
+const (
+	TokenKindUser         = "user"
+	TokenKindTeam         = "team"
+	TokenKindOrganization = "organization"
+	TokenKindAuditTrail   = "audit-trail"
+	TokenKindAgent        = "agent"
+)
+
+// AuthenticationToken is an opaque API token. Only the SHA-256 hash of its
+// secret is stored; revoked tokens are soft deleted.
+type AuthenticationToken struct {
+	gorm.Model
+	ID             uuid.UUID     `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Kind           string        `gorm:"type:varchar(255);not null"`
+	Description    string        `gorm:"type:text"`
+	SecretHash     string        `gorm:"type:varchar(255);not null"`
+	ExpiredAt      *time.Time    `gorm:"type:timestamp"`
+	LastUsedAt     *time.Time    `gorm:"type:timestamp"`
+	UserID         *uuid.UUID    `gorm:"type:uuid"`
+	User           *User         `gorm:"foreignKey:UserID"`
+	TeamID         *uuid.UUID    `gorm:"type:uuid"`
+	Team           *Team         `gorm:"foreignKey:TeamID"`
+	OrganizationID *uuid.UUID    `gorm:"type:uuid"`
+	Organization   *Organization `gorm:"foreignKey:OrganizationID"`
+	AgentPoolID    *uuid.UUID    `gorm:"type:uuid"`
+	AgentPool      *AgentPool    `gorm:"foreignKey:AgentPoolID"`
+	CreatedByID    *uuid.UUID    `gorm:"type:uuid"`
+}
+
+// Expired reports whether the token is past its expiry.
+func (t *AuthenticationToken) Expired() bool {
+	return t.ExpiredAt != nil && time.Now().After(*t.ExpiredAt)
+}
+
+// Singular reports whether the owner holds at most one token of this kind,
+// so that creating a token replaces the existing one.
+func (t *AuthenticationToken) Singular() bool {
+	return t.Kind != TokenKindUser && t.Kind != TokenKindAgent
+}
+
+func (t *AuthenticationToken) createdBy() *tfe.CreatedByChoice {
+	if t.CreatedByID == nil {
+		return nil
+	}
+	return &tfe.CreatedByChoice{User: &tfe.User{ID: t.CreatedByID.String()}}
+}
+
+func optionalTime(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+// ToTFEUserToken converts a user token to TFE format. The token value is
+// only known when the token is created and is set by the caller.
+func (t *AuthenticationToken) ToTFEUserToken() *tfe.UserToken {
+	return &tfe.UserToken{
+		ID:          t.ID.String(),
+		CreatedAt:   t.CreatedAt,
+		Description: t.Description,
+		LastUsedAt:  optionalTime(t.LastUsedAt),
+		ExpiredAt:   optionalTime(t.ExpiredAt),
+		CreatedBy:   t.createdBy(),
+	}
+}
+
+// ToTFETeamToken converts a team token to TFE format.
+func (t *AuthenticationToken) ToTFETeamToken() *tfe.TeamToken {
+	return &tfe.TeamToken{
+		ID:          t.ID.String(),
+		CreatedAt:   t.CreatedAt,
+		Description: t.Description,
+		LastUsedAt:  optionalTime(t.LastUsedAt),
+		ExpiredAt:   optionalTime(t.ExpiredAt),
+		CreatedBy:   t.createdBy(),
+	}
+}
+
+// ToTFEOrganizationToken converts an organization or audit trail token to
+// TFE format.
+func (t *AuthenticationToken) ToTFEOrganizationToken() *tfe.OrganizationToken {
+	return &tfe.OrganizationToken{
+		ID:          t.ID.String(),
+		CreatedAt:   t.CreatedAt,
+		Description: t.Description,
+		LastUsedAt:  optionalTime(t.LastUsedAt),
+		ExpiredAt:   optionalTime(t.ExpiredAt),
+		CreatedBy:   t.createdBy(),
+	}
+}
+
+// ToTFEAgentToken converts an agent token to TFE format.
+func (t *AuthenticationToken) ToTFEAgentToken() *tfe.AgentToken {
+	return &tfe.AgentToken{
+		ID:          t.ID.String(),
+		CreatedAt:   t.CreatedAt,
+		Description: t.Description,
+		LastUsedAt:  optionalTime(t.LastUsedAt),
+	}
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-tfe"
+	"gorm.io/gorm"
+)
+
+type ConfigurationVersion struct {
+	gorm.Model
+	ID                uuid.UUID          `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,configuration-versions"`
+	Status            string             `gorm:"type:varchar(255);not null" jsonapi:"attr,status"`
+	Source            string             `gorm:"type:varchar(255);not null" jsonapi:"attr,source"`
+	AutoQueueRuns     bool               `gorm:"default:false" jsonapi:"attr,auto-queue-runs"`
+	Speculative       bool               `gorm:"default:false" jsonapi:"attr,speculative"`
+	Provisional       bool               `gorm:"default:false" jsonapi:"attr,provisional"`
+	Error             string             `gorm:"type:varchar(255)"`
+	ErrorMessage      string             `gorm:"type:text"`
+	WorkspaceID       uuid.UUID          `gorm:"type:uuid;not null"`
+	Workspace         *Workspace         `gorm:"foreignKey:WorkspaceID"`
+	CreatedByID       *uuid.UUID         `gorm:"type:uuid"`
+	IngressAttributes *IngressAttributes `gorm:"foreignKey:ConfigurationVersionID"`
+
+	// Status timestamps
+	QueuedAt   *time.Time
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+	ArchivedAt *time.Time
+}
+
+// IngressAttributes records the VCS commit a configuration version was built from.
+type IngressAttributes struct {
+	gorm.Model
+	ID                     uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,ingress-attributes"`
+	Branch                 string
+	CloneURL               string
+	CommitMessage          string `gorm:"type:text"`
+	CommitSHA              string
+	CommitURL              string
+	CompareURL             string
+	Identifier             string
+	IsPullRequest          bool `gorm:"default:false"`
+	OnDefaultBranch        bool `gorm:"default:false"`
+	PullRequestNumber      int
+	PullRequestURL         string
+	PullRequestTitle       string
+	PullRequestBody        string `gorm:"type:text"`
+	Tag                    string
+	SenderUsername         string
+	SenderAvatarURL        string
+	SenderHTMLURL          string
+	ConfigurationVersionID uuid.UUID `gorm:"type:uuid;not null"`
+}
+
+// ToTFE converts the internal ConfigurationVersion model to TFE format. The
+// upload URL is signed per request and filled in by the caller.
+func (cv *ConfigurationVersion) ToTFE() *tfe.ConfigurationVersion {
+	tfeCV := &tfe.ConfigurationVersion{
+		ID:               cv.ID.String(),
+		AutoQueueRuns:    cv.AutoQueueRuns,
+		Error:            cv.Error,
+		ErrorMessage:     cv.ErrorMessage,
+		Source:           tfe.ConfigurationSource(cv.Source),
+		Speculative:      cv.Speculative,
+		Provisional:      cv.Provisional,
+		Status:           tfe.ConfigurationStatus(cv.Status),
+		StatusTimestamps: &tfe.CVStatusTimestamps{},
+	}
+	if cv.QueuedAt != nil {
+		tfeCV.StatusTimestamps.QueuedAt = *cv.QueuedAt
+	}
+	if cv.StartedAt != nil {
+		tfeCV.StatusTimestamps.StartedAt = *cv.StartedAt
+	}
+	if cv.FinishedAt != nil {
+		tfeCV.StatusTimestamps.FinishedAt = *cv.FinishedAt
+	}
+	if cv.ArchivedAt != nil {
+		tfeCV.StatusTimestamps.ArchivedAt = *cv.ArchivedAt
+	}
+	if cv.IngressAttributes != nil {
+		tfeCV.IngressAttributes = cv.IngressAttributes.ToTFE()
+	}
+	return tfeCV
+}
+
+// ToTFE converts the internal IngressAttributes model to TFE format
+func (ia *IngressAttributes) ToTFE() *tfe.IngressAttributes {
+	return &tfe.IngressAttributes{
+		ID:                ia.ID.String(),
+		Branch:            ia.Branch,
+		CloneURL:          ia.CloneURL,
+		CommitMessage:     ia.CommitMessage,
+		CommitSHA:         ia.CommitSHA,
+		CommitURL:         ia.CommitURL,
+		CompareURL:        ia.CompareURL,
+		Identifier:        ia.Identifier,
+		IsPullRequest:     ia.IsPullRequest,
+		OnDefaultBranch:   ia.OnDefaultBranch,
+		PullRequestNumber: ia.PullRequestNumber,
+		PullRequestURL:    ia.PullRequestURL,
+		PullRequestTitle:  ia.PullRequestTitle,
+		PullRequestBody:   ia.PullRequestBody,
+		Tag:               ia.Tag,
+		SenderUsername:    ia.SenderUsername,
+		SenderAvatarURL:   ia.SenderAvatarURL,
+		SenderHTMLURL:     ia.SenderHTMLURL,
+	}
+}
+
+// FromTFEIngressAttributes converts go-tfe ingress attributes to the internal model
+func FromTFEIngressAttributes(ia *tfe.IngressAttributes) *IngressAttributes {
+	if ia == nil {
+		return nil
+	}
+	return &IngressAttributes{
+		Branch:            ia.Branch,
+		CloneURL:          ia.CloneURL,
+		CommitMessage:     ia.CommitMessage,
+		CommitSHA:         ia.CommitSHA,
+		CommitURL:         ia.CommitURL,
+		CompareURL:        ia.CompareURL,
+		Identifier:        ia.Identifier,
+		IsPullRequest:     ia.IsPullRequest,
+		OnDefaultBranch:   ia.OnDefaultBranch,
+		PullRequestNumber: ia.PullRequestNumber,
+		PullRequestURL:    ia.PullRequestURL,
+		PullRequestTitle:  ia.PullRequestTitle,
+		PullRequestBody:   ia.PullRequestBody,
+		Tag:               ia.Tag,
+		SenderUsername:    ia.SenderUsername,
+		SenderAvatarURL:   ia.SenderAvatarURL,
+		SenderHTMLURL:     ia.SenderHTMLURL,
+	}
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-tfe"
+	"gorm.io/gorm"
+)
+
+// GPGKey is a public key that signs the provider releases of an
+// organization's private registry. Its namespace is the organization name.
+type GPGKey struct {
+	gorm.Model
+	ID             uuid.UUID     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,gpg-keys"`
+	KeyID          string        `gorm:"type:varchar(255);not null" jsonapi:"attr,key-id"`
+	AsciiArmor     string        `gorm:"type:text;not null" jsonapi:"attr,ascii-armor"`
+	OrganizationID uuid.UUID     `gorm:"type:uuid;not null"`
+	Organization   *Organization `gorm:"foreignKey:OrganizationID"`
+}
+
+// ToTFE converts the internal GPGKey model to TFE format
+func (k *GPGKey) ToTFE() *tfe.GPGKey {
+	key := &tfe.GPGKey{
+		ID:         k.ID.String(),
+		AsciiArmor: k.AsciiArmor,
+		KeyID:      k.KeyID,
+		CreatedAt:  k.CreatedAt,
+		UpdatedAt:  k.UpdatedAt,
+	}
+	if k.Organization != nil {
+		key.Namespace = k.Organization.Name
+	}
+	return key
+}
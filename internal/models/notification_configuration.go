@@ -0,0 +1,84 @@
+package models
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-tfe"
+	"gorm.io/gorm"
+)
+
+// NotificationConfiguration sends notifications about the runs of a
+// workspace to a webhook, Slack or Microsoft Teams when one of its
+// triggers fires. The token that signs generic notifications is only ever
+// stored encrypted.
+type NotificationConfiguration struct {
+	gorm.Model
+	ID                uuid.UUID                       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,notification-configurations"`
+	Name              string                          `gorm:"not null" jsonapi:"attr,name"`
+	DestinationType   string                          `gorm:"type:varchar(255);not null" jsonapi:"attr,destination-type"`
+	Enabled           bool                            `gorm:"not null" jsonapi:"attr,enabled"`
+	URL               string                          `gorm:"type:text;not null" jsonapi:"attr,url"`
+	EncryptedToken    string                          `gorm:"type:text"`
+	Triggers          []string                        `gorm:"serializer:json" jsonapi:"attr,triggers"`
+	WorkspaceID       uuid.UUID                       `gorm:"type:uuid;not null"`
+	Workspace         *Workspace                      `gorm:"foreignKey:WorkspaceID"`
+	DeliveryResponses []*NotificationDeliveryResponse `gorm:"foreignKey:NotificationConfigurationID"`
+}
+
+// NotificationDeliveryResponse records how a destination answered one
+// delivery attempt.
+type NotificationDeliveryResponse struct {
+	gorm.Model
+	ID                          uuid.UUID           `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	URL                         string              `gorm:"type:text;not null"`
+	Code                        int                 `gorm:"not null"`
+	Headers                     map[string][]string `gorm:"serializer:json"`
+	Body                        string              `gorm:"type:text"`
+	Successful                  bool                `gorm:"not null"`
+	SentAt                      time.Time           `gorm:"not null"`
+	NotificationConfigurationID uuid.UUID           `gorm:"type:uuid;not null"`
+}
+
+// HasTrigger reports whether trigger fires the configuration.
+func (nc *NotificationConfiguration) HasTrigger(trigger string) bool {
+	for _, t := range nc.Triggers {
+		if t == trigger {
+			return true
+		}
+	}
+	return false
+}
+
+// ToTFE converts the internal NotificationConfiguration model to TFE
+// format. The token is write-only and never included.
+func (nc *NotificationConfiguration) ToTFE() *tfe.NotificationConfiguration {
+	config := &tfe.NotificationConfiguration{
+		ID:                nc.ID.String(),
+		CreatedAt:         nc.CreatedAt,
+		UpdatedAt:         nc.UpdatedAt,
+		DestinationType:   tfe.NotificationDestinationType(nc.DestinationType),
+		Enabled:           nc.Enabled,
+		Name:              nc.Name,
+		Triggers:          nc.Triggers,
+		URL:               nc.URL,
+		EmailAddresses:    []string{},
+		DeliveryResponses: make([]*tfe.DeliveryResponse, len(nc.DeliveryResponses)),
+		Subscribable:      &tfe.Workspace{ID: nc.WorkspaceID.String()},
+	}
+	if config.Triggers == nil {
+		config.Triggers = []string{}
+	}
+	for i, r := range nc.DeliveryResponses {
+		config.DeliveryResponses[i] = &tfe.DeliveryResponse{
+			Body:       r.Body,
+			Code:       strconv.Itoa(r.Code),
+			Headers:    r.Headers,
+			SentAt:     r.SentAt,
+			Successful: strconv.FormatBool(r.Successful),
+			URL:        r.URL,
+		}
+	}
+	return config
+}
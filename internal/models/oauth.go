@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OAuthAuthorizationCode is a single-use code issued during the `terraform login`
+// authorization-code flow. Only the SHA-256 hash of the code is stored.
+type OAuthAuthorizationCode struct {
+	gorm.Model
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CodeHash      string    `gorm:"uniqueIndex;not null"`
+	ClientID      string    `gorm:"not null"`
+	RedirectURI   string    `gorm:"type:text;not null"`
+	CodeChallenge string    `gorm:"not null"`
+	ExpiresAt     time.Time `gorm:"not null"`
+	UserID        uuid.UUID `gorm:"type:uuid;not null"`
+	User          *User     `gorm:"foreignKey:UserID"`
+}
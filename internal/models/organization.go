@@ -37,9 +37,10 @@ type Organization struct {
 	AllowForceDeleteWorkspaces bool `gorm:"default:false" jsonapi:"attr,allow-force-delete-workspaces"`
 
 	// Relations
-	DefaultProject *Project   `gorm:"foreignKey:OrganizationID" jsonapi:"relation,default-project"`
-	Projects       []*Project `gorm:"foreignKey:OrganizationID"`
-	// DefaultAgentPool *AgentPool `jsonapi:"relation,default-agent-pool"`
+	DefaultProject     *Project   `gorm:"foreignKey:OrganizationID" jsonapi:"relation,default-project"`
+	Projects           []*Project `gorm:"foreignKey:OrganizationID"`
+	DefaultAgentPoolID *uuid.UUID `gorm:"type:uuid"`
+	DefaultAgentPool   *AgentPool `gorm:"foreignKey:DefaultAgentPoolID" jsonapi:"relation,default-agent-pool"`
 
 	// Deprecated: Use DataRetentionPolicyChoice instead.
 	// DataRetentionPolicy *DataRetentionPolicy
@@ -50,7 +51,7 @@ type Organization struct {
 
 // ToTFE converts the internal Organization model to TFE format
 func (o *Organization) ToTFE() *tfe.Organization {
-	return &tfe.Organization{
+	org := &tfe.Organization{
 		Name:                  o.Name,
 		AssessmentsEnforced:   o.AssessmentsEnforced,
 		CostEstimationEnabled: o.CostEstimationEnabled,
@@ -74,11 +75,15 @@ func (o *Organization) ToTFE() *tfe.Organization {
 		// CollaboratorAuthPolicy: o.CollaboratorAuthPolicy,
 		// Add other fields as needed
 	}
+	if o.DefaultAgentPoolID != nil {
+		org.DefaultAgentPool = &tfe.AgentPool{ID: o.DefaultAgentPoolID.String()}
+	}
+	return org
 }
 
 // FromTFEOrganization converts a TFE Organization to internal model
 func FromTFEOrganization(tfeOrg *tfe.Organization) *Organization {
-	return &Organization{
+	org := &Organization{
 		// ID:                    id,
 		Name:                  tfeOrg.Name,
 		AssessmentsEnforced:   tfeOrg.AssessmentsEnforced,
@@ -103,4 +108,10 @@ func FromTFEOrganization(tfeOrg *tfe.Organization) *Organization {
 		// CollaboratorAuthPolicy: tfeOrg.CollaboratorAuthPolicy,
 		// Add other fields as needed
 	}
+	if tfeOrg.DefaultAgentPool != nil {
+		if id, err := uuid.Parse(tfeOrg.DefaultAgentPool.ID); err == nil {
+			org.DefaultAgentPoolID = &id
+		}
+	}
+	return org
 }
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-tfe"
+	"gorm.io/gorm"
+)
+
+// OrganizationMembership makes a user a member of an organization. A
+// membership starts out invited and becomes active once the user accepts
+// the invitation.
+type OrganizationMembership struct {
+	gorm.Model
+	ID             uuid.UUID     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,organization-memberships"`
+	Status         string        `gorm:"type:varchar(255);not null" jsonapi:"attr,status"`
+	OrganizationID uuid.UUID     `gorm:"type:uuid;not null"`
+	Organization   *Organization `gorm:"foreignKey:OrganizationID" jsonapi:"relation,organization"`
+	UserID         uuid.UUID     `gorm:"type:uuid;not null"`
+	User           *User         `gorm:"foreignKey:UserID" jsonapi:"relation,user"`
+	// InvitationTokenHash is the SHA-256 digest of the token sent in the
+	// invitation email. It is cleared once the invitation is answered.
+	InvitationTokenHash string     `gorm:"type:varchar(255)"`
+	InvitedByID         *uuid.UUID `gorm:"type:uuid"`
+
+	// Teams holds the organization's teams the user belongs to. It is
+	// loaded separately because it is derived from team membership.
+	Teams []*Team `gorm:"-"`
+}
+
+// ToTFE converts the internal OrganizationMembership model to TFE format
+func (m *OrganizationMembership) ToTFE() *tfe.OrganizationMembership {
+	membership := &tfe.OrganizationMembership{
+		ID:     m.ID.String(),
+		Status: tfe.OrganizationMembershipStatus(m.Status),
+		Teams:  make([]*tfe.Team, len(m.Teams)),
+	}
+	if m.Organization != nil {
+		membership.Organization = &tfe.Organization{Name: m.Organization.Name}
+	}
+	if m.User != nil {
+		membership.Email = m.User.Email
+		membership.User = &tfe.User{
+			ID:               m.User.ID.String(),
+			Username:         m.User.Username,
+			Email:            m.User.Email,
+			AvatarURL:        m.User.AvatarURL,
+			UnconfirmedEmail: m.User.UnconfirmedEmail,
+		}
+	}
+	for i, t := range m.Teams {
+		membership.Teams[i] = &tfe.Team{ID: t.ID.String(), Name: t.Name}
+	}
+	return membership
+}
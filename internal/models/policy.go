@@ -0,0 +1,126 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-tfe"
+	"gorm.io/gorm"
+)
+
+// Policy is an OPA policy of an organization. The Rego source is uploaded
+// separately and kept in blob storage. The policy fails a run when Query
+// yields a result against the run's plan.
+type Policy struct {
+	gorm.Model
+	ID               uuid.UUID     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,policies"`
+	Name             string        `gorm:"not null" jsonapi:"attr,name"`
+	Description      string        `gorm:"type:text" jsonapi:"attr,description"`
+	Kind             string        `gorm:"type:varchar(255);not null" jsonapi:"attr,kind"`
+	Query            string        `gorm:"type:text" jsonapi:"attr,query"`
+	EnforcementLevel string        `gorm:"type:varchar(255);not null" jsonapi:"attr,enforcement-level"`
+	Uploaded         bool          `gorm:"default:false"`
+	OrganizationID   uuid.UUID     `gorm:"type:uuid;not null"`
+	Organization     *Organization `gorm:"foreignKey:OrganizationID"`
+	PolicySets       []*PolicySet  `gorm:"many2many:policy_set_policies"`
+}
+
+// PolicySet groups policies that are evaluated after the plan of every run
+// in scope. A set applies to every workspace when Global is set, and
+// otherwise to the workspaces and projects it is attached to; excluded
+// workspaces are never in scope. Mandatory failures of an overridable set
+// can be overridden by users who may manage policy overrides.
+type PolicySet struct {
+	gorm.Model
+	ID                  uuid.UUID     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,policy-sets"`
+	Name                string        `gorm:"not null" jsonapi:"attr,name"`
+	Description         string        `gorm:"type:text" jsonapi:"attr,description"`
+	Kind                string        `gorm:"type:varchar(255);not null" jsonapi:"attr,kind"`
+	Global              bool          `gorm:"default:false" jsonapi:"attr,global"`
+	Overridable         bool          `gorm:"not null" jsonapi:"attr,overridable"`
+	OrganizationID      uuid.UUID     `gorm:"type:uuid;not null"`
+	Organization        *Organization `gorm:"foreignKey:OrganizationID"`
+	Policies            []*Policy     `gorm:"many2many:policy_set_policies"`
+	Workspaces          []*Workspace  `gorm:"many2many:policy_set_workspaces"`
+	WorkspaceExclusions []*Workspace  `gorm:"many2many:policy_set_workspace_exclusions"`
+	Projects            []*Project    `gorm:"many2many:policy_set_projects"`
+}
+
+// PolicySetPolicy adds a policy to a policy set.
+type PolicySetPolicy struct {
+	PolicySetID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	PolicyID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+}
+
+// PolicySetWorkspace attaches a policy set to a workspace.
+type PolicySetWorkspace struct {
+	PolicySetID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	WorkspaceID uuid.UUID `gorm:"type:uuid;primaryKey"`
+}
+
+// PolicySetWorkspaceExclusion keeps a workspace out of the scope of a policy set.
+type PolicySetWorkspaceExclusion struct {
+	PolicySetID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	WorkspaceID uuid.UUID `gorm:"type:uuid;primaryKey"`
+}
+
+// PolicySetProject attaches a policy set to every workspace of a project.
+type PolicySetProject struct {
+	PolicySetID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ProjectID   uuid.UUID `gorm:"type:uuid;primaryKey"`
+}
+
+// ToTFE converts the internal Policy model to TFE format
+func (p *Policy) ToTFE() *tfe.Policy {
+	query := p.Query
+	policy := &tfe.Policy{
+		ID:               p.ID.String(),
+		Name:             p.Name,
+		Description:      p.Description,
+		Kind:             tfe.PolicyKind(p.Kind),
+		Query:            &query,
+		EnforcementLevel: tfe.EnforcementLevel(p.EnforcementLevel),
+		PolicySetCount:   len(p.PolicySets),
+		UpdatedAt:        p.UpdatedAt,
+	}
+	if p.Organization != nil {
+		policy.Organization = &tfe.Organization{Name: p.Organization.Name}
+	}
+	return policy
+}
+
+// ToTFE converts the internal PolicySet model to TFE format
+func (ps *PolicySet) ToTFE() *tfe.PolicySet {
+	overridable := ps.Overridable
+	set := &tfe.PolicySet{
+		ID:                  ps.ID.String(),
+		Name:                ps.Name,
+		Description:         ps.Description,
+		Kind:                tfe.PolicyKind(ps.Kind),
+		Global:              ps.Global,
+		Overridable:         &overridable,
+		PolicyCount:         len(ps.Policies),
+		WorkspaceCount:      len(ps.Workspaces),
+		ProjectCount:        len(ps.Projects),
+		CreatedAt:           ps.CreatedAt,
+		UpdatedAt:           ps.UpdatedAt,
+		Policies:            make([]*tfe.Policy, len(ps.Policies)),
+		Workspaces:          make([]*tfe.Workspace, len(ps.Workspaces)),
+		WorkspaceExclusions: make([]*tfe.Workspace, len(ps.WorkspaceExclusions)),
+		Projects:            make([]*tfe.Project, len(ps.Projects)),
+	}
+	if ps.Organization != nil {
+		set.Organization = &tfe.Organization{Name: ps.Organization.Name}
+	}
+	for i, p := range ps.Policies {
+		set.Policies[i] = &tfe.Policy{ID: p.ID.String()}
+	}
+	for i, ws := range ps.Workspaces {
+		set.Workspaces[i] = &tfe.Workspace{ID: ws.ID.String()}
+	}
+	for i, ws := range ps.WorkspaceExclusions {
+		set.WorkspaceExclusions[i] = &tfe.Workspace{ID: ws.ID.String()}
+	}
+	for i, p := range ps.Projects {
+		set.Projects[i] = &tfe.Project{ID: p.ID.String()}
+	}
+	return set
+}
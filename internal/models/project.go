@@ -19,21 +19,37 @@ type Project struct {
 
 // ToTFE converts the internal Project model to TFE format
 func (p *Project) ToTFE() *tfe.Project {
-	return &tfe.Project{
+	if p == nil {
+		return nil
+	}
+	proj := &tfe.Project{
 		ID:          p.ID.String(),
 		IsUnified:   p.IsUnified,
 		Name:        p.Name,
 		Description: p.Description,
 	}
+	if p.Organization != nil {
+		proj.Organization = &tfe.Organization{Name: p.Organization.Name}
+	}
+	return proj
 }
 
 // FromTFEProject converts a TFE Project to internal model
 func FromTFEProject(proj *tfe.Project) *Project {
-	id, err := uuid.Parse(proj.ID)
-	if err != nil {
+	if proj == nil {
 		return nil
 	}
 
+	// New projects arrive without an ID, so one is generated here.
+	id := uuid.New()
+	if proj.ID != "" {
+		parsed, err := uuid.Parse(proj.ID)
+		if err != nil {
+			return nil
+		}
+		id = parsed
+	}
+
 	return &Project{
 		ID:          id,
 		Name:        proj.Name,
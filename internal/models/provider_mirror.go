@@ -0,0 +1,29 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ProviderMirrorPackage is a provider package served by the provider
+// network mirror, for one version and platform of a provider from any
+// origin registry.
+type ProviderMirrorPackage struct {
+	gorm.Model
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Hostname  string    `gorm:"type:varchar(255);not null"`
+	Namespace string    `gorm:"type:varchar(255);not null"`
+	Type      string    `gorm:"type:varchar(255);not null"`
+	Version   string    `gorm:"type:varchar(255);not null"`
+	OS        string    `gorm:"type:varchar(255);not null"`
+	Arch      string    `gorm:"type:varchar(255);not null"`
+	// Shasum is the hex SHA-256 of the package zip, its "zh:" hash.
+	Shasum string `gorm:"type:varchar(255);not null"`
+	// Hash1 is the "h1:" hash of the package contents.
+	Hash1 string `gorm:"type:varchar(255);not null"`
+}
+
+// Filename returns the conventional file name of the package.
+func (p *ProviderMirrorPackage) Filename() string {
+	return "terraform-provider-" + p.Type + "_" + p.Version + "_" + p.OS + "_" + p.Arch + ".zip"
+}
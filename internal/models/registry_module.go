@@ -0,0 +1,107 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/go-version"
+	"gorm.io/gorm"
+)
+
+// RegistryModuleVersionSourceAPI marks versions uploaded through the API.
+const RegistryModuleVersionSourceAPI = "tfe-api"
+
+// RegistryModule is a module of an organization's private registry. Its
+// namespace is always the organization name.
+type RegistryModule struct {
+	gorm.Model
+	ID             uuid.UUID                `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,registry-modules"`
+	Name           string                   `gorm:"not null" jsonapi:"attr,name"`
+	Provider       string                   `gorm:"not null" jsonapi:"attr,provider"`
+	NoCode         bool                     `gorm:"default:false" jsonapi:"attr,no-code"`
+	OrganizationID uuid.UUID                `gorm:"type:uuid;not null"`
+	Organization   *Organization            `gorm:"foreignKey:OrganizationID"`
+	Versions       []*RegistryModuleVersion `gorm:"foreignKey:RegistryModuleID"`
+}
+
+// RegistryModuleVersion is a published version of a registry module. Its
+// archive is uploaded separately and the version stays pending until then.
+type RegistryModuleVersion struct {
+	gorm.Model
+	ID               uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,registry-module-versions"`
+	Version          string          `gorm:"not null" jsonapi:"attr,version"`
+	Status           string          `gorm:"type:varchar(255);not null" jsonapi:"attr,status"`
+	Source           string          `gorm:"type:varchar(255)" jsonapi:"attr,source"`
+	Error            string          `gorm:"type:text"`
+	RegistryModuleID uuid.UUID       `gorm:"type:uuid;not null"`
+	RegistryModule   *RegistryModule `gorm:"foreignKey:RegistryModuleID"`
+}
+
+// ToTFE converts the internal RegistryModule model to TFE format. The
+// module is set up once one of its versions was uploaded successfully.
+// Permissions depend on the caller and are filled in by the service.
+func (m *RegistryModule) ToTFE() *tfe.RegistryModule {
+	module := &tfe.RegistryModule{
+		ID:                  m.ID.String(),
+		Name:                m.Name,
+		Provider:            m.Provider,
+		RegistryName:        tfe.PrivateRegistry,
+		NoCode:              m.NoCode,
+		PublishingMechanism: tfe.PublishingMechanismTag,
+		Status:              tfe.RegistryModuleStatusPending,
+		VersionStatuses:     []tfe.RegistryModuleVersionStatuses{},
+		CreatedAt:           m.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:           m.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if m.Organization != nil {
+		module.Namespace = m.Organization.Name
+		module.Organization = &tfe.Organization{Name: m.Organization.Name}
+	}
+	for _, v := range SortRegistryModuleVersions(m.Versions) {
+		module.VersionStatuses = append(module.VersionStatuses, tfe.RegistryModuleVersionStatuses{
+			Version: v.Version,
+			Status:  tfe.RegistryModuleVersionStatus(v.Status),
+			Error:   v.Error,
+		})
+		if v.Status == string(tfe.RegistryModuleVersionStatusOk) {
+			module.Status = tfe.RegistryModuleStatusSetupComplete
+		}
+	}
+	return module
+}
+
+// ToTFE converts the internal RegistryModuleVersion model to TFE format. The
+// upload link is signed per request and filled in by the caller.
+func (v *RegistryModuleVersion) ToTFE() *tfe.RegistryModuleVersion {
+	rmv := &tfe.RegistryModuleVersion{
+		ID:        v.ID.String(),
+		Source:    v.Source,
+		Status:    tfe.RegistryModuleVersionStatus(v.Status),
+		Version:   v.Version,
+		CreatedAt: v.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt: v.UpdatedAt.UTC().Format(time.RFC3339),
+		Links:     map[string]interface{}{},
+	}
+	if v.RegistryModule != nil {
+		rmv.RegistryModule = &tfe.RegistryModule{ID: v.RegistryModule.ID.String()}
+	}
+	return rmv
+}
+
+// SortRegistryModuleVersions orders versions from newest to oldest by
+// semantic version.
+func SortRegistryModuleVersions(versions []*RegistryModuleVersion) []*RegistryModuleVersion {
+	sorted := make([]*RegistryModuleVersion, len(versions))
+	copy(sorted, versions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, aerr := version.NewVersion(sorted[i].Version)
+		b, berr := version.NewVersion(sorted[j].Version)
+		if aerr != nil || berr != nil {
+			return sorted[i].Version > sorted[j].Version
+		}
+		return a.GreaterThan(b)
+	})
+	return sorted
+}
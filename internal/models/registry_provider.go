@@ -0,0 +1,122 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-tfe"
+	"gorm.io/gorm"
+)
+
+// RegistryProvider is a provider of an organization's private registry. Its
+// namespace is always the organization name.
+type RegistryProvider struct {
+	gorm.Model
+	ID             uuid.UUID                  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,registry-providers"`
+	Name           string                     `gorm:"not null" jsonapi:"attr,name"`
+	OrganizationID uuid.UUID                  `gorm:"type:uuid;not null"`
+	Organization   *Organization              `gorm:"foreignKey:OrganizationID"`
+	Versions       []*RegistryProviderVersion `gorm:"foreignKey:RegistryProviderID"`
+}
+
+// RegistryProviderVersion is a release of a registry provider. A release is
+// installable once its SHA256SUMS file and signature are uploaded; the
+// signature is checked against the GPG key of the release.
+type RegistryProviderVersion struct {
+	gorm.Model
+	ID                 uuid.UUID                   `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,registry-provider-versions"`
+	Version            string                      `gorm:"not null" jsonapi:"attr,version"`
+	KeyID              string                      `gorm:"type:varchar(255);not null" jsonapi:"attr,key-id"`
+	Protocols          string                      `gorm:"type:varchar(255);not null"`
+	ShasumsUploaded    bool                        `gorm:"default:false" jsonapi:"attr,shasums-uploaded"`
+	ShasumsSigUploaded bool                        `gorm:"default:false" jsonapi:"attr,shasums-sig-uploaded"`
+	RegistryProviderID uuid.UUID                   `gorm:"type:uuid;not null"`
+	RegistryProvider   *RegistryProvider           `gorm:"foreignKey:RegistryProviderID"`
+	Platforms          []*RegistryProviderPlatform `gorm:"foreignKey:RegistryProviderVersionID"`
+}
+
+// RegistryProviderPlatform is the binary of a provider release for one
+// operating system and architecture.
+type RegistryProviderPlatform struct {
+	gorm.Model
+	ID                        uuid.UUID                `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,registry-provider-platforms"`
+	OS                        string                   `gorm:"type:varchar(255);not null" jsonapi:"attr,os"`
+	Arch                      string                   `gorm:"type:varchar(255);not null" jsonapi:"attr,arch"`
+	Filename                  string                   `gorm:"type:varchar(255);not null" jsonapi:"attr,filename"`
+	Shasum                    string                   `gorm:"type:varchar(255);not null" jsonapi:"attr,shasum"`
+	ProviderBinaryUploaded    bool                     `gorm:"default:false" jsonapi:"attr,provider-binary-uploaded"`
+	RegistryProviderVersionID uuid.UUID                `gorm:"type:uuid;not null"`
+	RegistryProviderVersion   *RegistryProviderVersion `gorm:"foreignKey:RegistryProviderVersionID"`
+}
+
+// ToTFE converts the internal RegistryProvider model to TFE format.
+// Permissions depend on the caller and are filled in by the service.
+func (p *RegistryProvider) ToTFE() *tfe.RegistryProvider {
+	provider := &tfe.RegistryProvider{
+		ID:                       p.ID.String(),
+		Name:                     p.Name,
+		RegistryName:             tfe.PrivateRegistry,
+		CreatedAt:                p.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:                p.UpdatedAt.UTC().Format(time.RFC3339),
+		RegistryProviderVersions: make([]*tfe.RegistryProviderVersion, len(p.Versions)),
+	}
+	if p.Organization != nil {
+		provider.Namespace = p.Organization.Name
+		provider.Organization = &tfe.Organization{Name: p.Organization.Name}
+	}
+	for i, v := range p.Versions {
+		provider.RegistryProviderVersions[i] = &tfe.RegistryProviderVersion{ID: v.ID.String()}
+	}
+	return provider
+}
+
+// ToTFE converts the internal RegistryProviderVersion model to TFE format.
+// Transfer links are signed per request and filled in by the caller.
+func (v *RegistryProviderVersion) ToTFE() *tfe.RegistryProviderVersion {
+	version := &tfe.RegistryProviderVersion{
+		ID:                        v.ID.String(),
+		Version:                   v.Version,
+		CreatedAt:                 v.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:                 v.UpdatedAt.UTC().Format(time.RFC3339),
+		KeyID:                     v.KeyID,
+		Protocols:                 v.ProtocolList(),
+		ShasumsUploaded:           v.ShasumsUploaded,
+		ShasumsSigUploaded:        v.ShasumsSigUploaded,
+		RegistryProviderPlatforms: make([]*tfe.RegistryProviderPlatform, len(v.Platforms)),
+		Links:                     map[string]interface{}{},
+	}
+	if v.RegistryProvider != nil {
+		version.RegistryProvider = &tfe.RegistryProvider{ID: v.RegistryProvider.ID.String()}
+	}
+	for i, p := range v.Platforms {
+		version.RegistryProviderPlatforms[i] = &tfe.RegistryProviderPlatform{ID: p.ID.String()}
+	}
+	return version
+}
+
+// ProtocolList returns the plugin protocol versions the release supports.
+func (v *RegistryProviderVersion) ProtocolList() []string {
+	if v.Protocols == "" {
+		return []string{}
+	}
+	return strings.Split(v.Protocols, ",")
+}
+
+// ToTFE converts the internal RegistryProviderPlatform model to TFE format.
+// Transfer links are signed per request and filled in by the caller.
+func (p *RegistryProviderPlatform) ToTFE() *tfe.RegistryProviderPlatform {
+	platform := &tfe.RegistryProviderPlatform{
+		ID:                     p.ID.String(),
+		OS:                     p.OS,
+		Arch:                   p.Arch,
+		Filename:               p.Filename,
+		Shasum:                 p.Shasum,
+		ProviderBinaryUploaded: p.ProviderBinaryUploaded,
+		Links:                  map[string]interface{}{},
+	}
+	if p.RegistryProviderVersion != nil {
+		platform.RegistryProviderVersion = &tfe.RegistryProviderVersion{ID: p.RegistryProviderVersion.ID.String()}
+	}
+	return platform
+}
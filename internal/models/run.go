@@ -0,0 +1,260 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-tfe"
+	"gorm.io/gorm"
+)
+
+// StatusTimestamps records when a resource entered each status, keyed the
+// same way as the status-timestamps attribute (for example "planned-at").
+type StatusTimestamps map[string]time.Time
+
+type Run struct {
+	gorm.Model
+	ID                     uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,runs"`
+	Status                 string           `gorm:"type:varchar(255);not null" jsonapi:"attr,status"`
+	StatusTimestamps       StatusTimestamps `gorm:"type:jsonb;serializer:json"`
+	Message                string           `gorm:"type:text" jsonapi:"attr,message"`
+	Source                 string           `gorm:"type:varchar(255);not null" jsonapi:"attr,source"`
+	IsDestroy              bool             `gorm:"default:false" jsonapi:"attr,is-destroy"`
+	PlanOnly               bool             `gorm:"default:false" jsonapi:"attr,plan-only"`
+	Refresh                bool             `jsonapi:"attr,refresh"`
+	RefreshOnly            bool             `gorm:"default:false" jsonapi:"attr,refresh-only"`
+	AutoApply              bool             `gorm:"default:false" jsonapi:"attr,auto-apply"`
+	AllowEmptyApply        bool             `gorm:"default:false" jsonapi:"attr,allow-empty-apply"`
+	AllowConfigGeneration  bool             `gorm:"default:false" jsonapi:"attr,allow-config-generation"`
+	SavePlan               bool             `gorm:"default:false" jsonapi:"attr,save-plan"`
+	HasChanges             bool             `gorm:"default:false" jsonapi:"attr,has-changes"`
+	TerraformVersion       string           `jsonapi:"attr,terraform-version"`
+	TargetAddrs            []string         `gorm:"serializer:json" jsonapi:"attr,target-addrs"`
+	ReplaceAddrs           []string         `gorm:"serializer:json" jsonapi:"attr,replace-addrs"`
+	Variables              []RunVariable    `gorm:"serializer:json"`
+	ForceCancelAvailableAt *time.Time
+
+	WorkspaceID            uuid.UUID             `gorm:"type:uuid;not null"`
+	Workspace              *Workspace            `gorm:"foreignKey:WorkspaceID"`
+	ConfigurationVersionID uuid.UUID             `gorm:"type:uuid;not null"`
+	ConfigurationVersion   *ConfigurationVersion `gorm:"foreignKey:ConfigurationVersionID"`
+	CreatedByID            *uuid.UUID            `gorm:"type:uuid"`
+	CreatedBy              *User                 `gorm:"foreignKey:CreatedByID"`
+	Plan                   *Plan                 `gorm:"foreignKey:RunID"`
+	Apply                  *Apply                `gorm:"foreignKey:RunID"`
+
+	// AgentID is the agent that claimed the run's current phase on a
+	// workspace in agent execution mode.
+	AgentID *uuid.UUID `gorm:"type:uuid"`
+}
+
+// RunVariable is a run-specific variable value passed at creation time.
+type RunVariable struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Plan is the plan phase of a run.
+type Plan struct {
+	gorm.Model
+	ID                   uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,plans"`
+	Status               string           `gorm:"type:varchar(255);not null" jsonapi:"attr,status"`
+	StatusTimestamps     StatusTimestamps `gorm:"type:jsonb;serializer:json"`
+	HasChanges           bool             `gorm:"default:false" jsonapi:"attr,has-changes"`
+	ResourceAdditions    int              `jsonapi:"attr,resource-additions"`
+	ResourceChanges      int              `jsonapi:"attr,resource-changes"`
+	ResourceDestructions int              `jsonapi:"attr,resource-destructions"`
+	ResourceImports      int              `jsonapi:"attr,resource-imports"`
+	RunID                uuid.UUID        `gorm:"type:uuid;not null"`
+}
+
+// Apply is the apply phase of a run.
+type Apply struct {
+	gorm.Model
+	ID                   uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,applies"`
+	Status               string           `gorm:"type:varchar(255);not null" jsonapi:"attr,status"`
+	StatusTimestamps     StatusTimestamps `gorm:"type:jsonb;serializer:json"`
+	ResourceAdditions    int              `jsonapi:"attr,resource-additions"`
+	ResourceChanges      int              `jsonapi:"attr,resource-changes"`
+	ResourceDestructions int              `jsonapi:"attr,resource-destructions"`
+	ResourceImports      int              `jsonapi:"attr,resource-imports"`
+	RunID                uuid.UUID        `gorm:"type:uuid;not null"`
+}
+
+// confirmableRunStatuses are the statuses in which a run waits for a user to apply or discard it.
+var confirmableRunStatuses = map[tfe.RunStatus]bool{
+	tfe.RunPlanned:           true,
+	tfe.RunCostEstimated:     true,
+	tfe.RunPolicyChecked:     true,
+	tfe.RunPostPlanCompleted: true,
+}
+
+// IsConfirmable reports whether the run is waiting for confirmation.
+func (r *Run) IsConfirmable() bool {
+	return confirmableRunStatuses[tfe.RunStatus(r.Status)] && !r.PlanOnly
+}
+
+// IsDiscardable reports whether the run can be discarded, which includes
+// runs held by failed policies.
+func (r *Run) IsDiscardable() bool {
+	switch tfe.RunStatus(r.Status) {
+	case tfe.RunPending, tfe.RunPostPlanAwaitingDecision:
+		return true
+	default:
+		return confirmableRunStatuses[tfe.RunStatus(r.Status)]
+	}
+}
+
+// IsCancelable reports whether the run is queued or executing and can be canceled.
+func (r *Run) IsCancelable() bool {
+	switch tfe.RunStatus(r.Status) {
+	case tfe.RunPending, tfe.RunPrePlanRunning, tfe.RunPrePlanCompleted, tfe.RunPlanQueued, tfe.RunPlanning, tfe.RunPostPlanRunning,
+		tfe.RunConfirmed, tfe.RunPreApplyRunning, tfe.RunPreApplyCompleted, tfe.RunApplyQueued, tfe.RunApplying:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsForceCancelable reports whether a cancel request has gone unanswered
+// for long enough that the run may be forcefully canceled.
+func (r *Run) IsForceCancelable() bool {
+	return r.IsCancelable() && r.ForceCancelAvailableAt != nil && time.Now().After(*r.ForceCancelAvailableAt)
+}
+
+// ToTFE converts the internal Run model to TFE format
+func (r *Run) ToTFE() *tfe.Run {
+	run := &tfe.Run{
+		ID:                    r.ID.String(),
+		AutoApply:             r.AutoApply,
+		AllowConfigGeneration: &r.AllowConfigGeneration,
+		AllowEmptyApply:       r.AllowEmptyApply,
+		CreatedAt:             r.CreatedAt,
+		HasChanges:            r.HasChanges,
+		IsDestroy:             r.IsDestroy,
+		Message:               r.Message,
+		PlanOnly:              r.PlanOnly,
+		Refresh:               r.Refresh,
+		RefreshOnly:           r.RefreshOnly,
+		ReplaceAddrs:          r.ReplaceAddrs,
+		SavePlan:              r.SavePlan,
+		Source:                tfe.RunSource(r.Source),
+		Status:                tfe.RunStatus(r.Status),
+		StatusTimestamps:      r.StatusTimestamps.runTimestamps(),
+		TargetAddrs:           r.TargetAddrs,
+		TerraformVersion:      r.TerraformVersion,
+		Variables:             make([]*tfe.RunVariableAttr, len(r.Variables)),
+		Actions: &tfe.RunActions{
+			IsCancelable:      r.IsCancelable(),
+			IsConfirmable:     r.IsConfirmable(),
+			IsDiscardable:     r.IsDiscardable(),
+			IsForceCancelable: r.IsForceCancelable(),
+		},
+		Permissions: &tfe.RunPermissions{
+			CanApply:        true,
+			CanCancel:       true,
+			CanDiscard:      true,
+			CanForceCancel:  true,
+			CanForceExecute: true,
+		},
+		ConfigurationVersion: &tfe.ConfigurationVersion{ID: r.ConfigurationVersionID.String()},
+		Workspace:            &tfe.Workspace{ID: r.WorkspaceID.String()},
+	}
+	if r.ForceCancelAvailableAt != nil {
+		run.ForceCancelAvailableAt = *r.ForceCancelAvailableAt
+	}
+	for i, v := range r.Variables {
+		run.Variables[i] = &tfe.RunVariableAttr{Key: v.Key, Value: v.Value}
+	}
+	if r.Workspace != nil {
+		run.Workspace = r.Workspace.ToTFE()
+	}
+	if r.ConfigurationVersion != nil {
+		run.ConfigurationVersion = r.ConfigurationVersion.ToTFE()
+	}
+	if r.CreatedBy != nil {
+		run.CreatedBy = r.CreatedBy.ToTFE()
+	}
+	if r.Plan != nil {
+		run.Plan = r.Plan.ToTFE()
+	}
+	if r.Apply != nil {
+		run.Apply = r.Apply.ToTFE()
+	}
+	return run
+}
+
+// ToTFE converts the internal Plan model to TFE format. The log URL is
+// signed per request and filled in by the caller.
+func (p *Plan) ToTFE() *tfe.Plan {
+	ts := p.StatusTimestamps
+	return &tfe.Plan{
+		ID:                   p.ID.String(),
+		HasChanges:           p.HasChanges,
+		ResourceAdditions:    p.ResourceAdditions,
+		ResourceChanges:      p.ResourceChanges,
+		ResourceDestructions: p.ResourceDestructions,
+		ResourceImports:      p.ResourceImports,
+		Status:               tfe.PlanStatus(p.Status),
+		StatusTimestamps: &tfe.PlanStatusTimestamps{
+			CanceledAt:      ts["canceled-at"],
+			ErroredAt:       ts["errored-at"],
+			FinishedAt:      ts["finished-at"],
+			ForceCanceledAt: ts["force-canceled-at"],
+			QueuedAt:        ts["queued-at"],
+			StartedAt:       ts["started-at"],
+		},
+	}
+}
+
+// ToTFE converts the internal Apply model to TFE format. The log URL is
+// signed per request and filled in by the caller.
+func (a *Apply) ToTFE() *tfe.Apply {
+	ts := a.StatusTimestamps
+	return &tfe.Apply{
+		ID:                   a.ID.String(),
+		ResourceAdditions:    a.ResourceAdditions,
+		ResourceChanges:      a.ResourceChanges,
+		ResourceDestructions: a.ResourceDestructions,
+		ResourceImports:      a.ResourceImports,
+		Status:               tfe.ApplyStatus(a.Status),
+		StatusTimestamps: &tfe.ApplyStatusTimestamps{
+			CanceledAt:      ts["canceled-at"],
+			ErroredAt:       ts["errored-at"],
+			FinishedAt:      ts["finished-at"],
+			ForceCanceledAt: ts["force-canceled-at"],
+			QueuedAt:        ts["queued-at"],
+			StartedAt:       ts["started-at"],
+		},
+	}
+}
+
+func (ts StatusTimestamps) runTimestamps() *tfe.RunStatusTimestamps {
+	return &tfe.RunStatusTimestamps{
+		AppliedAt:            ts["applied-at"],
+		ApplyingAt:           ts["applying-at"],
+		ApplyQueuedAt:        ts["apply-queued-at"],
+		CanceledAt:           ts["canceled-at"],
+		ConfirmedAt:          ts["confirmed-at"],
+		CostEstimatedAt:      ts["cost-estimated-at"],
+		CostEstimatingAt:     ts["cost-estimating-at"],
+		DiscardedAt:          ts["discarded-at"],
+		ErroredAt:            ts["errored-at"],
+		FetchedAt:            ts["fetched-at"],
+		FetchingAt:           ts["fetching-at"],
+		ForceCanceledAt:      ts["force-canceled-at"],
+		PlannedAndFinishedAt: ts["planned-and-finished-at"],
+		PlannedAndSavedAt:    ts["planned-and-saved-at"],
+		PlannedAt:            ts["planned-at"],
+		PlanningAt:           ts["planning-at"],
+		PlanQueueableAt:      ts["plan-queueable-at"],
+		PlanQueuedAt:         ts["plan-queued-at"],
+		PolicyCheckedAt:      ts["policy-checked-at"],
+		PolicySoftFailedAt:   ts["policy-soft-failed-at"],
+		PostPlanCompletedAt:  ts["post-plan-completed-at"],
+		PostPlanRunningAt:    ts["post-plan-running-at"],
+		PrePlanCompletedAt:   ts["pre-plan-completed-at"],
+		PrePlanRunningAt:     ts["pre-plan-running-at"],
+		QueuingAt:            ts["queuing-at"],
+	}
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-tfe"
+	"gorm.io/gorm"
+)
+
+// RunTask is an external service of an organization that runs are sent to
+// at the stages a workspace attaches it to, or, when GlobalEnabled is set,
+// at the global stages of every workspace it is not attached to. Requests
+// are signed with the HMAC key, which is only ever stored encrypted.
+type RunTask struct {
+	gorm.Model
+	ID                     uuid.UUID           `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,tasks"`
+	Name                   string              `gorm:"not null" jsonapi:"attr,name"`
+	URL                    string              `gorm:"type:text;not null" jsonapi:"attr,url"`
+	Description            string              `gorm:"type:text" jsonapi:"attr,description"`
+	Category               string              `gorm:"type:varchar(255);not null" jsonapi:"attr,category"`
+	EncryptedHMACKey       string              `gorm:"type:text"`
+	Enabled                bool                `gorm:"not null" jsonapi:"attr,enabled"`
+	GlobalEnabled          bool                `gorm:"default:false"`
+	GlobalStages           []string            `gorm:"serializer:json"`
+	GlobalEnforcementLevel string              `gorm:"type:varchar(255)"`
+	OrganizationID         uuid.UUID           `gorm:"type:uuid;not null"`
+	Organization           *Organization       `gorm:"foreignKey:OrganizationID"`
+	WorkspaceRunTasks      []*WorkspaceRunTask `gorm:"foreignKey:RunTaskID"`
+}
+
+// WorkspaceRunTask attaches a run task to a workspace at one or more stages.
+// A mandatory task that does not pass stops the run.
+type WorkspaceRunTask struct {
+	gorm.Model
+	ID               uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,workspace-tasks"`
+	EnforcementLevel string     `gorm:"type:varchar(255);not null" jsonapi:"attr,enforcement-level"`
+	Stages           []string   `gorm:"serializer:json" jsonapi:"attr,stages"`
+	WorkspaceID      uuid.UUID  `gorm:"type:uuid;not null"`
+	Workspace        *Workspace `gorm:"foreignKey:WorkspaceID"`
+	RunTaskID        uuid.UUID  `gorm:"type:uuid;not null"`
+	RunTask          *RunTask   `gorm:"foreignKey:RunTaskID"`
+}
+
+// HasStage reports whether the task runs at stage.
+func (wt *WorkspaceRunTask) HasStage(stage string) bool {
+	return hasStage(wt.Stages, stage)
+}
+
+// HasGlobalStage reports whether the task runs at stage of every workspace
+// it is not attached to.
+func (t *RunTask) HasGlobalStage(stage string) bool {
+	return t.GlobalEnabled && hasStage(t.GlobalStages, stage)
+}
+
+func hasStage(stages []string, stage string) bool {
+	for _, s := range stages {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// ToTFE converts the internal RunTask model to TFE format. The HMAC key is
+// never included.
+func (t *RunTask) ToTFE() *tfe.RunTask {
+	task := &tfe.RunTask{
+		ID:          t.ID.String(),
+		Name:        t.Name,
+		URL:         t.URL,
+		Description: t.Description,
+		Category:    t.Category,
+		Enabled:     t.Enabled,
+		Global: &tfe.GlobalRunTask{
+			Enabled:          t.GlobalEnabled,
+			Stages:           make([]tfe.Stage, len(t.GlobalStages)),
+			EnforcementLevel: tfe.TaskEnforcementLevel(t.GlobalEnforcementLevel),
+		},
+		WorkspaceRunTasks: make([]*tfe.WorkspaceRunTask, len(t.WorkspaceRunTasks)),
+	}
+	for i, stage := range t.GlobalStages {
+		task.Global.Stages[i] = tfe.Stage(stage)
+	}
+	if t.Organization != nil {
+		task.Organization = &tfe.Organization{Name: t.Organization.Name}
+	}
+	for i, wt := range t.WorkspaceRunTasks {
+		task.WorkspaceRunTasks[i] = &tfe.WorkspaceRunTask{ID: wt.ID.String()}
+	}
+	return task
+}
+
+// ToTFE converts the internal WorkspaceRunTask model to TFE format
+func (wt *WorkspaceRunTask) ToTFE() *tfe.WorkspaceRunTask {
+	task := &tfe.WorkspaceRunTask{
+		ID:               wt.ID.String(),
+		EnforcementLevel: tfe.TaskEnforcementLevel(wt.EnforcementLevel),
+		Stages:           make([]tfe.Stage, len(wt.Stages)),
+		RunTask:          &tfe.RunTask{ID: wt.RunTaskID.String()},
+		Workspace:        &tfe.Workspace{ID: wt.WorkspaceID.String()},
+	}
+	for i, stage := range wt.Stages {
+		task.Stages[i] = tfe.Stage(stage)
+	}
+	if len(wt.Stages) > 0 {
+		task.Stage = task.Stages[0]
+	}
+	return task
+}
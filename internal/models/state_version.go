@@ -0,0 +1,98 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-tfe"
+	"gorm.io/gorm"
+)
+
+type StateVersion struct {
+	gorm.Model
+	ID                 uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,state-versions"`
+	CreatedAt          time.Time  `jsonapi:"attr,created-at,iso8601"`
+	Status             string     `gorm:"type:varchar(255);not null" jsonapi:"attr,status"`
+	Serial             int64      `gorm:"not null" jsonapi:"attr,serial"`
+	Lineage            string     `gorm:"type:varchar(255)"`
+	MD5                string     `gorm:"column:md5;type:varchar(255);not null"`
+	StateVersion       int        `jsonapi:"attr,state-version"`
+	TerraformVersion   string     `jsonapi:"attr,terraform-version"`
+	ResourcesProcessed bool       `gorm:"default:false" jsonapi:"attr,resources-processed"`
+	Resources          []Resource `gorm:"serializer:json"`
+	WorkspaceID        uuid.UUID  `gorm:"type:uuid;not null"`
+	Workspace          *Workspace `gorm:"foreignKey:WorkspaceID"`
+	RunID              *uuid.UUID `gorm:"type:uuid"`
+	CreatedByID        *uuid.UUID `gorm:"type:uuid"`
+	CreatedByTokenID   *uuid.UUID `gorm:"type:uuid"`
+	Outputs            []*StateVersionOutput
+}
+
+// Resource summarizes the instances of one resource recorded in a state file.
+type Resource struct {
+	Name     string `json:"name"`
+	Count    int    `json:"count"`
+	Type     string `json:"type"`
+	Module   string `json:"module"`
+	Provider string `json:"provider"`
+}
+
+type StateVersionOutput struct {
+	gorm.Model
+	ID             uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,state-version-outputs"`
+	Name           string          `gorm:"not null" jsonapi:"attr,name"`
+	Sensitive      bool            `gorm:"default:false" jsonapi:"attr,sensitive"`
+	Type           string          `jsonapi:"attr,type"`
+	Value          json.RawMessage `gorm:"type:text"`
+	DetailedType   json.RawMessage `gorm:"type:text"`
+	StateVersionID uuid.UUID       `gorm:"type:uuid;not null"`
+}
+
+// ToTFE converts the internal StateVersion model to TFE format. Hosted
+// upload and download URLs are signed per request and filled in by the caller.
+func (sv *StateVersion) ToTFE() *tfe.StateVersion {
+	tfeSV := &tfe.StateVersion{
+		ID:                 sv.ID.String(),
+		CreatedAt:          sv.CreatedAt,
+		Status:             tfe.StateVersionStatus(sv.Status),
+		Serial:             sv.Serial,
+		StateVersion:       sv.StateVersion,
+		TerraformVersion:   sv.TerraformVersion,
+		ResourcesProcessed: sv.ResourcesProcessed,
+		Resources:          make([]*tfe.StateVersionResources, len(sv.Resources)),
+		Outputs:            make([]*tfe.StateVersionOutput, len(sv.Outputs)),
+	}
+	for i, r := range sv.Resources {
+		tfeSV.Resources[i] = &tfe.StateVersionResources{
+			Name:     r.Name,
+			Count:    r.Count,
+			Type:     r.Type,
+			Module:   r.Module,
+			Provider: r.Provider,
+		}
+	}
+	for i, o := range sv.Outputs {
+		tfeSV.Outputs[i] = &tfe.StateVersionOutput{ID: o.ID.String()}
+	}
+	if sv.RunID != nil {
+		tfeSV.Run = &tfe.Run{ID: sv.RunID.String()}
+	}
+	return tfeSV
+}
+
+// ToTFE converts the internal StateVersionOutput model to TFE format. The
+// value of sensitive outputs is only included when withSensitive is set.
+func (o *StateVersionOutput) ToTFE(withSensitive bool) *tfe.StateVersionOutput {
+	output := &tfe.StateVersionOutput{
+		ID:        o.ID.String(),
+		Name:      o.Name,
+		Sensitive: o.Sensitive,
+		Type:      o.Type,
+	}
+	if !o.Sensitive || withSensitive {
+		json.Unmarshal(o.Value, &output.Value)
+	}
+	json.Unmarshal(o.DetailedType, &output.DetailedType)
+	return output
+}
@@ -0,0 +1,252 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-tfe"
+	"gorm.io/gorm"
+)
+
+// TaskStage is a point in a run, such as post_plan, at which run tasks and
+// policies are checked before the run may continue.
+type TaskStage struct {
+	gorm.Model
+	ID                uuid.UUID           `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,task-stages"`
+	Stage             string              `gorm:"type:varchar(255);not null" jsonapi:"attr,stage"`
+	Status            string              `gorm:"type:varchar(255);not null" jsonapi:"attr,status"`
+	StatusTimestamps  StatusTimestamps    `gorm:"type:jsonb;serializer:json"`
+	RunID             uuid.UUID           `gorm:"type:uuid;not null"`
+	TaskResults       []*TaskResult       `gorm:"foreignKey:TaskStageID"`
+	PolicyEvaluations []*PolicyEvaluation `gorm:"foreignKey:TaskStageID"`
+}
+
+// TaskResult is the result of one run task in a task stage. The task's
+// name, URL and enforcement level are copied so that the result survives
+// later changes to the task. The task reports back through a callback
+// authenticated by an access token, of which only the hash is stored.
+type TaskResult struct {
+	gorm.Model
+	ID                   uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,task-results"`
+	Status               string           `gorm:"type:varchar(255);not null" jsonapi:"attr,status"`
+	Message              string           `gorm:"type:text" jsonapi:"attr,message"`
+	URL                  string           `gorm:"type:text" jsonapi:"attr,url"`
+	StatusTimestamps     StatusTimestamps `gorm:"type:jsonb;serializer:json"`
+	TaskName             string           `gorm:"not null" jsonapi:"attr,task-name"`
+	TaskURL              string           `gorm:"type:text;not null" jsonapi:"attr,task-url"`
+	EnforcementLevel     string           `gorm:"type:varchar(255);not null" jsonapi:"attr,workspace-task-enforcement-level"`
+	AccessTokenHash      string           `gorm:"type:varchar(255)"`
+	AccessTokenExpiresAt time.Time
+	RunTaskID            *uuid.UUID `gorm:"type:uuid"`
+	WorkspaceRunTaskID   *uuid.UUID `gorm:"type:uuid"`
+	TaskStageID          uuid.UUID  `gorm:"type:uuid;not null"`
+}
+
+// IsFinal reports whether the task has reported its outcome or can no
+// longer do so.
+func (tr *TaskResult) IsFinal() bool {
+	switch tfe.TaskResultStatus(tr.Status) {
+	case tfe.TaskPending, tfe.TaskRunning:
+		return false
+	default:
+		return true
+	}
+}
+
+// MandatoryFailed reports whether a mandatory task did not pass.
+func (tr *TaskResult) MandatoryFailed() bool {
+	return tr.EnforcementLevel == string(tfe.Mandatory) && tr.Status != string(tfe.TaskPassed)
+}
+
+// PolicyEvaluation is the evaluation of the policy sets of one kind in a
+// task stage.
+type PolicyEvaluation struct {
+	gorm.Model
+	ID                uuid.UUID           `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,policy-evaluations"`
+	Status            string              `gorm:"type:varchar(255);not null" jsonapi:"attr,status"`
+	PolicyKind        string              `gorm:"type:varchar(255);not null" jsonapi:"attr,policy-kind"`
+	StatusTimestamps  StatusTimestamps    `gorm:"type:jsonb;serializer:json"`
+	TaskStageID       uuid.UUID           `gorm:"type:uuid;not null"`
+	PolicySetOutcomes []*PolicySetOutcome `gorm:"foreignKey:PolicyEvaluationID"`
+}
+
+// PolicySetOutcome records how the policies of one policy set fared. The
+// name and settings of the set are copied so that the outcome survives
+// later changes to the set.
+type PolicySetOutcome struct {
+	gorm.Model
+	ID                   uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,policy-set-outcomes"`
+	PolicySetName        string          `gorm:"not null" jsonapi:"attr,policy-set-name"`
+	PolicySetDescription string          `gorm:"type:text" jsonapi:"attr,policy-set-description"`
+	Overridable          bool            `gorm:"not null" jsonapi:"attr,overridable"`
+	Error                string          `gorm:"type:text" jsonapi:"attr,error"`
+	Outcomes             []PolicyOutcome `gorm:"serializer:json"`
+	PolicySetID          *uuid.UUID      `gorm:"type:uuid"`
+	PolicyEvaluationID   uuid.UUID       `gorm:"type:uuid;not null"`
+}
+
+// PolicyOutcome is the result of a single policy.
+type PolicyOutcome struct {
+	PolicyName       string `json:"policy_name"`
+	Description      string `json:"description"`
+	Query            string `json:"query"`
+	EnforcementLevel string `json:"enforcement_level"`
+	Status           string `json:"status"`
+}
+
+// Policy outcome statuses.
+const (
+	PolicyOutcomePassed  = "passed"
+	PolicyOutcomeFailed  = "failed"
+	PolicyOutcomeErrored = "errored"
+)
+
+// ResultCount tallies the outcomes of the set.
+func (o *PolicySetOutcome) ResultCount() tfe.PolicyResultCount {
+	var count tfe.PolicyResultCount
+	for _, outcome := range o.Outcomes {
+		switch {
+		case outcome.Status == PolicyOutcomePassed:
+			count.Passed++
+		case outcome.Status == PolicyOutcomeErrored:
+			count.Errored++
+		case outcome.EnforcementLevel == string(tfe.EnforcementMandatory):
+			count.MandatoryFailed++
+		default:
+			count.AdvisoryFailed++
+		}
+	}
+	return count
+}
+
+// MandatoryFailed reports whether a mandatory policy of the set failed or
+// errored, or whether the set could not be evaluated at all.
+func (o *PolicySetOutcome) MandatoryFailed() bool {
+	if o.Error != "" && len(o.Outcomes) == 0 {
+		return true
+	}
+	for _, outcome := range o.Outcomes {
+		if outcome.Status != PolicyOutcomePassed && outcome.EnforcementLevel == string(tfe.EnforcementMandatory) {
+			return true
+		}
+	}
+	return false
+}
+
+// ToTFE converts the internal TaskStage model to TFE format
+func (ts *TaskStage) ToTFE() *tfe.TaskStage {
+	t := ts.StatusTimestamps
+	overridable := ts.Status == string(tfe.TaskStageAwaitingOverride)
+	stage := &tfe.TaskStage{
+		ID:     ts.ID.String(),
+		Stage:  tfe.Stage(ts.Stage),
+		Status: tfe.TaskStageStatus(ts.Status),
+		StatusTimestamps: tfe.TaskStageStatusTimestamps{
+			ErroredAt:  t["errored-at"],
+			RunningAt:  t["running-at"],
+			CanceledAt: t["canceled-at"],
+			FailedAt:   t["failed-at"],
+			PassedAt:   t["passed-at"],
+		},
+		CreatedAt:         ts.CreatedAt,
+		UpdatedAt:         ts.UpdatedAt,
+		Actions:           &tfe.Actions{IsOverridable: &overridable},
+		Run:               &tfe.Run{ID: ts.RunID.String()},
+		TaskResults:       make([]*tfe.TaskResult, len(ts.TaskResults)),
+		PolicyEvaluations: make([]*tfe.PolicyEvaluation, len(ts.PolicyEvaluations)),
+	}
+	for i, tr := range ts.TaskResults {
+		stage.TaskResults[i] = &tfe.TaskResult{ID: tr.ID.String()}
+	}
+	for i, pe := range ts.PolicyEvaluations {
+		stage.PolicyEvaluations[i] = &tfe.PolicyEvaluation{ID: pe.ID.String()}
+	}
+	return stage
+}
+
+// ToTFE converts the internal TaskResult model to TFE format
+func (tr *TaskResult) ToTFE() *tfe.TaskResult {
+	t := tr.StatusTimestamps
+	result := &tfe.TaskResult{
+		ID:      tr.ID.String(),
+		Status:  tfe.TaskResultStatus(tr.Status),
+		Message: tr.Message,
+		StatusTimestamps: tfe.TaskResultStatusTimestamps{
+			ErroredAt:  t["errored-at"],
+			RunningAt:  t["running-at"],
+			CanceledAt: t["canceled-at"],
+			FailedAt:   t["failed-at"],
+			PassedAt:   t["passed-at"],
+		},
+		URL:                           tr.URL,
+		CreatedAt:                     tr.CreatedAt,
+		UpdatedAt:                     tr.UpdatedAt,
+		TaskName:                      tr.TaskName,
+		TaskURL:                       tr.TaskURL,
+		WorkspaceTaskEnforcementLevel: tfe.TaskEnforcementLevel(tr.EnforcementLevel),
+		TaskStage:                     &tfe.TaskStage{ID: tr.TaskStageID.String()},
+	}
+	if tr.RunTaskID != nil {
+		result.TaskID = tr.RunTaskID.String()
+	}
+	if tr.WorkspaceRunTaskID != nil {
+		result.WorkspaceTaskID = tr.WorkspaceRunTaskID.String()
+	}
+	return result
+}
+
+// ToTFE converts the internal PolicyEvaluation model to TFE format. The
+// result count is only complete when the outcomes are loaded. The task
+// stage is left out: go-tfe models it as attributes, which jsonapi cannot
+// marshal as a relationship.
+func (pe *PolicyEvaluation) ToTFE() *tfe.PolicyEvaluation {
+	t := pe.StatusTimestamps
+	count := &tfe.PolicyResultCount{}
+	for _, outcome := range pe.PolicySetOutcomes {
+		c := outcome.ResultCount()
+		count.AdvisoryFailed += c.AdvisoryFailed
+		count.MandatoryFailed += c.MandatoryFailed
+		count.Passed += c.Passed
+		count.Errored += c.Errored
+	}
+	return &tfe.PolicyEvaluation{
+		ID:         pe.ID.String(),
+		Status:     tfe.PolicyEvaluationStatus(pe.Status),
+		PolicyKind: tfe.PolicyKind(pe.PolicyKind),
+		StatusTimestamps: tfe.PolicyEvaluationStatusTimestamps{
+			ErroredAt:  t["errored-at"],
+			RunningAt:  t["running-at"],
+			CanceledAt: t["canceled-at"],
+			FailedAt:   t["failed-at"],
+			PassedAt:   t["passed-at"],
+		},
+		ResultCount: count,
+		CreatedAt:   pe.CreatedAt,
+		UpdatedAt:   pe.UpdatedAt,
+	}
+}
+
+// ToTFE converts the internal PolicySetOutcome model to TFE format
+func (o *PolicySetOutcome) ToTFE() *tfe.PolicySetOutcome {
+	overridable := o.Overridable
+	outcome := &tfe.PolicySetOutcome{
+		ID:                   o.ID.String(),
+		Outcomes:             make([]tfe.Outcome, len(o.Outcomes)),
+		Error:                o.Error,
+		Overridable:          &overridable,
+		PolicySetName:        o.PolicySetName,
+		PolicySetDescription: o.PolicySetDescription,
+		ResultCount:          o.ResultCount(),
+		PolicyEvaluation:     &tfe.PolicyEvaluation{ID: o.PolicyEvaluationID.String()},
+	}
+	for i, po := range o.Outcomes {
+		outcome.Outcomes[i] = tfe.Outcome{
+			EnforcementLevel: tfe.EnforcementLevel(po.EnforcementLevel),
+			Query:            po.Query,
+			Status:           po.Status,
+			PolicyName:       po.PolicyName,
+			Description:      po.Description,
+		}
+	}
+	return outcome
+}
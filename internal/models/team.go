@@ -0,0 +1,173 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-tfe"
+	"gorm.io/gorm"
+)
+
+// OwnersTeamName is the name of the team every organization is created
+// with. Its members have full control over the organization, so it can be
+// neither renamed nor deleted.
+const OwnersTeamName = "owners"
+
+// Team visibility values.
+const (
+	TeamVisibilitySecret       = "secret"
+	TeamVisibilityOrganization = "organization"
+)
+
+// OrganizationAccess holds the organization-level permissions of a team.
+type OrganizationAccess struct {
+	ManagePolicies           bool `gorm:"default:false" jsonapi:"attr,manage-policies"`
+	ManagePolicyOverrides    bool `gorm:"default:false" jsonapi:"attr,manage-policy-overrides"`
+	ManageWorkspaces         bool `gorm:"default:false" jsonapi:"attr,manage-workspaces"`
+	ManageVCSSettings        bool `gorm:"default:false" jsonapi:"attr,manage-vcs-settings"`
+	ManageProviders          bool `gorm:"default:false" jsonapi:"attr,manage-providers"`
+	ManageModules            bool `gorm:"default:false" jsonapi:"attr,manage-modules"`
+	ManageRunTasks           bool `gorm:"default:false" jsonapi:"attr,manage-run-tasks"`
+	ManageProjects           bool `gorm:"default:false" jsonapi:"attr,manage-projects"`
+	ReadWorkspaces           bool `gorm:"default:false" jsonapi:"attr,read-workspaces"`
+	ReadProjects             bool `gorm:"default:false" jsonapi:"attr,read-projects"`
+	ManageMembership         bool `gorm:"default:false" jsonapi:"attr,manage-membership"`
+	ManageTeams              bool `gorm:"default:false" jsonapi:"attr,manage-teams"`
+	ManageOrganizationAccess bool `gorm:"default:false" jsonapi:"attr,manage-organization-access"`
+	AccessSecretTeams        bool `gorm:"default:false" jsonapi:"attr,access-secret-teams"`
+	ManageAgentPools         bool `gorm:"default:false" jsonapi:"attr,manage-agent-pools"`
+}
+
+type Team struct {
+	gorm.Model
+	ID                         uuid.UUID           `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,teams"`
+	Name                       string              `gorm:"not null" jsonapi:"attr,name"`
+	Visibility                 string              `gorm:"type:varchar(255);not null" jsonapi:"attr,visibility"`
+	SSOTeamID                  string              `gorm:"column:sso_team_id" jsonapi:"attr,sso-team-id"`
+	AllowMemberTokenManagement bool                `gorm:"default:true" jsonapi:"attr,allow-member-token-management"`
+	OrganizationAccess         *OrganizationAccess `gorm:"embedded;embeddedPrefix:organization_access_" jsonapi:"attr,organization-access"`
+	OrganizationID             uuid.UUID           `gorm:"type:uuid;not null"`
+	Organization               *Organization       `gorm:"foreignKey:OrganizationID"`
+	Users                      []*User             `gorm:"many2many:team_users"`
+
+	// OrganizationMemberships holds the memberships of the team's users. It
+	// is loaded separately because memberships are not linked to teams.
+	OrganizationMemberships []*OrganizationMembership `gorm:"-"`
+}
+
+// TeamUser makes a user a member of a team.
+type TeamUser struct {
+	TeamID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Team   *Team     `gorm:"foreignKey:TeamID"`
+}
+
+// IsOwners reports whether the team is the owners team of its organization.
+func (t *Team) IsOwners() bool {
+	return t.Name == OwnersTeamName
+}
+
+// ToTFE converts the internal Team model to TFE format
+func (t *Team) ToTFE() *tfe.Team {
+	team := &tfe.Team{
+		ID:                         t.ID.String(),
+		Name:                       t.Name,
+		Visibility:                 t.Visibility,
+		SSOTeamID:                  t.SSOTeamID,
+		AllowMemberTokenManagement: t.AllowMemberTokenManagement,
+		UserCount:                  len(t.Users),
+		Users:                      make([]*tfe.User, len(t.Users)),
+		OrganizationMemberships:    make([]*tfe.OrganizationMembership, len(t.OrganizationMemberships)),
+		Permissions: &tfe.TeamPermissions{
+			CanDestroy:          !t.IsOwners(),
+			CanUpdateMembership: true,
+		},
+	}
+	if access := t.OrganizationAccess; access != nil {
+		team.OrganizationAccess = &tfe.OrganizationAccess{
+			ManagePolicies:           access.ManagePolicies,
+			ManagePolicyOverrides:    access.ManagePolicyOverrides,
+			ManageWorkspaces:         access.ManageWorkspaces,
+			ManageVCSSettings:        access.ManageVCSSettings,
+			ManageProviders:          access.ManageProviders,
+			ManageModules:            access.ManageModules,
+			ManageRunTasks:           access.ManageRunTasks,
+			ManageProjects:           access.ManageProjects,
+			ReadWorkspaces:           access.ReadWorkspaces,
+			ReadProjects:             access.ReadProjects,
+			ManageMembership:         access.ManageMembership,
+			ManageTeams:              access.ManageTeams,
+			ManageOrganizationAccess: access.ManageOrganizationAccess,
+			AccessSecretTeams:        access.AccessSecretTeams,
+			ManageAgentPools:         access.ManageAgentPools,
+		}
+	}
+	for i, u := range t.Users {
+		team.Users[i] = &tfe.User{
+			ID:        u.ID.String(),
+			Username:  u.Username,
+			Email:     u.Email,
+			AvatarURL: u.AvatarURL,
+		}
+	}
+	for i, m := range t.OrganizationMemberships {
+		team.OrganizationMemberships[i] = &tfe.OrganizationMembership{ID: m.ID.String()}
+	}
+	return team
+}
+
+// ApplyTFEOrganizationAccessOptions copies every permission that was set
+// onto the organization access of the team.
+func (t *Team) ApplyTFEOrganizationAccessOptions(opts *tfe.OrganizationAccessOptions) {
+	if opts == nil {
+		return
+	}
+	if t.OrganizationAccess == nil {
+		t.OrganizationAccess = &OrganizationAccess{}
+	}
+	access := t.OrganizationAccess
+	for _, p := range []struct {
+		dst *bool
+		src *bool
+	}{
+		{&access.ManagePolicies, opts.ManagePolicies},
+		{&access.ManagePolicyOverrides, opts.ManagePolicyOverrides},
+		{&access.ManageWorkspaces, opts.ManageWorkspaces},
+		{&access.ManageVCSSettings, opts.ManageVCSSettings},
+		{&access.ManageProviders, opts.ManageProviders},
+		{&access.ManageModules, opts.ManageModules},
+		{&access.ManageRunTasks, opts.ManageRunTasks},
+		{&access.ManageProjects, opts.ManageProjects},
+		{&access.ReadWorkspaces, opts.ReadWorkspaces},
+		{&access.ReadProjects, opts.ReadProjects},
+		{&access.ManageMembership, opts.ManageMembership},
+		{&access.ManageTeams, opts.ManageTeams},
+		{&access.ManageOrganizationAccess, opts.ManageOrganizationAccess},
+		{&access.AccessSecretTeams, opts.AccessSecretTeams},
+		{&access.ManageAgentPools, opts.ManageAgentPools},
+	} {
+		if p.src != nil {
+			*p.dst = *p.src
+		}
+	}
+}
+
+// FullOrganizationAccess grants every organization-level permission, as
+// held by the owners team.
+func FullOrganizationAccess() *OrganizationAccess {
+	return &OrganizationAccess{
+		ManagePolicies:           true,
+		ManagePolicyOverrides:    true,
+		ManageWorkspaces:         true,
+		ManageVCSSettings:        true,
+		ManageProviders:          true,
+		ManageModules:            true,
+		ManageRunTasks:           true,
+		ManageProjects:           true,
+		ReadWorkspaces:           true,
+		ReadProjects:             true,
+		ManageMembership:         true,
+		ManageTeams:              true,
+		ManageOrganizationAccess: true,
+		AccessSecretTeams:        true,
+		ManageAgentPools:         true,
+	}
+}
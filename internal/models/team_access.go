@@ -0,0 +1,145 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-tfe"
+	"gorm.io/gorm"
+)
+
+// TeamWorkspaceAccess grants a team access to one workspace. The custom
+// permission columns are always stored; for fixed access levels they hold
+// the permissions the level implies.
+type TeamWorkspaceAccess struct {
+	gorm.Model
+	ID               uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,team-workspaces"`
+	Access           string     `gorm:"type:varchar(255);not null" jsonapi:"attr,access"`
+	Runs             string     `gorm:"type:varchar(255);not null" jsonapi:"attr,runs"`
+	Variables        string     `gorm:"type:varchar(255);not null" jsonapi:"attr,variables"`
+	StateVersions    string     `gorm:"type:varchar(255);not null" jsonapi:"attr,state-versions"`
+	SentinelMocks    string     `gorm:"type:varchar(255);not null" jsonapi:"attr,sentinel-mocks"`
+	WorkspaceLocking bool       `gorm:"default:false" jsonapi:"attr,workspace-locking"`
+	RunTasks         bool       `gorm:"default:false" jsonapi:"attr,run-tasks"`
+	TeamID           uuid.UUID  `gorm:"type:uuid;not null"`
+	Team             *Team      `gorm:"foreignKey:TeamID"`
+	WorkspaceID      uuid.UUID  `gorm:"type:uuid;not null"`
+	Workspace        *Workspace `gorm:"foreignKey:WorkspaceID"`
+}
+
+// ApplyAccessLevel sets the permission columns implied by a fixed access
+// level. Custom access keeps the columns as they are.
+func (a *TeamWorkspaceAccess) ApplyAccessLevel() {
+	switch tfe.AccessType(a.Access) {
+	case tfe.AccessRead:
+		a.setPermissions(tfe.RunsPermissionRead, tfe.VariablesPermissionRead, tfe.StateVersionsPermissionRead, tfe.SentinelMocksPermissionNone, false, false)
+	case tfe.AccessPlan:
+		a.setPermissions(tfe.RunsPermissionPlan, tfe.VariablesPermissionRead, tfe.StateVersionsPermissionRead, tfe.SentinelMocksPermissionNone, false, false)
+	case tfe.AccessWrite:
+		a.setPermissions(tfe.RunsPermissionApply, tfe.VariablesPermissionWrite, tfe.StateVersionsPermissionWrite, tfe.SentinelMocksPermissionRead, true, false)
+	case tfe.AccessAdmin:
+		a.setPermissions(tfe.RunsPermissionApply, tfe.VariablesPermissionWrite, tfe.StateVersionsPermissionWrite, tfe.SentinelMocksPermissionRead, true, true)
+	}
+}
+
+func (a *TeamWorkspaceAccess) setPermissions(runs tfe.RunsPermissionType, variables tfe.VariablesPermissionType, stateVersions tfe.StateVersionsPermissionType, sentinelMocks tfe.SentinelMocksPermissionType, locking, runTasks bool) {
+	a.Runs = string(runs)
+	a.Variables = string(variables)
+	a.StateVersions = string(stateVersions)
+	a.SentinelMocks = string(sentinelMocks)
+	a.WorkspaceLocking = locking
+	a.RunTasks = runTasks
+}
+
+// ToTFE converts the internal TeamWorkspaceAccess model to TFE format
+func (a *TeamWorkspaceAccess) ToTFE() *tfe.TeamAccess {
+	return &tfe.TeamAccess{
+		ID:               a.ID.String(),
+		Access:           tfe.AccessType(a.Access),
+		Runs:             tfe.RunsPermissionType(a.Runs),
+		Variables:        tfe.VariablesPermissionType(a.Variables),
+		StateVersions:    tfe.StateVersionsPermissionType(a.StateVersions),
+		SentinelMocks:    tfe.SentinelMocksPermissionType(a.SentinelMocks),
+		WorkspaceLocking: a.WorkspaceLocking,
+		RunTasks:         a.RunTasks,
+		Team:             &tfe.Team{ID: a.TeamID.String()},
+		Workspace:        &tfe.Workspace{ID: a.WorkspaceID.String()},
+	}
+}
+
+// TeamProjectAccess grants a team access to a project and every workspace
+// in it. As with TeamWorkspaceAccess, the custom permission columns always
+// hold the effective permissions.
+type TeamProjectAccess struct {
+	gorm.Model
+	ID                     uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,team-projects"`
+	Access                 string    `gorm:"type:varchar(255);not null" jsonapi:"attr,access"`
+	ProjectSettings        string    `gorm:"type:varchar(255);not null"`
+	ProjectTeams           string    `gorm:"type:varchar(255);not null"`
+	WorkspaceRuns          string    `gorm:"type:varchar(255);not null"`
+	WorkspaceSentinelMocks string    `gorm:"type:varchar(255);not null"`
+	WorkspaceStateVersions string    `gorm:"type:varchar(255);not null"`
+	WorkspaceVariables     string    `gorm:"type:varchar(255);not null"`
+	WorkspaceCreate        bool      `gorm:"default:false"`
+	WorkspaceLocking       bool      `gorm:"default:false"`
+	WorkspaceMove          bool      `gorm:"default:false"`
+	WorkspaceDelete        bool      `gorm:"default:false"`
+	WorkspaceRunTasks      bool      `gorm:"default:false"`
+	TeamID                 uuid.UUID `gorm:"type:uuid;not null"`
+	Team                   *Team     `gorm:"foreignKey:TeamID"`
+	ProjectID              uuid.UUID `gorm:"type:uuid;not null"`
+	Project                *Project  `gorm:"foreignKey:ProjectID"`
+}
+
+// ApplyAccessLevel sets the permission columns implied by a fixed access
+// level. Custom access keeps the columns as they are.
+func (a *TeamProjectAccess) ApplyAccessLevel() {
+	switch tfe.TeamProjectAccessType(a.Access) {
+	case tfe.TeamProjectAccessRead:
+		a.ProjectSettings, a.ProjectTeams = string(tfe.ProjectSettingsPermissionRead), string(tfe.ProjectTeamsPermissionNone)
+		a.setWorkspacePermissions(tfe.WorkspaceRunsPermissionRead, tfe.WorkspaceVariablesPermissionRead, tfe.WorkspaceStateVersionsPermissionRead, tfe.WorkspaceSentinelMocksPermissionNone)
+		a.WorkspaceCreate, a.WorkspaceLocking, a.WorkspaceMove, a.WorkspaceDelete, a.WorkspaceRunTasks = false, false, false, false, false
+	case tfe.TeamProjectAccessWrite:
+		a.ProjectSettings, a.ProjectTeams = string(tfe.ProjectSettingsPermissionRead), string(tfe.ProjectTeamsPermissionNone)
+		a.setWorkspacePermissions(tfe.WorkspaceRunsPermissionApply, tfe.WorkspaceVariablesPermissionWrite, tfe.WorkspaceStateVersionsPermissionWrite, tfe.WorkspaceSentinelMocksPermissionRead)
+		a.WorkspaceCreate, a.WorkspaceLocking, a.WorkspaceMove, a.WorkspaceDelete, a.WorkspaceRunTasks = false, true, false, false, false
+	case tfe.TeamProjectAccessMaintain:
+		a.ProjectSettings, a.ProjectTeams = string(tfe.ProjectSettingsPermissionRead), string(tfe.ProjectTeamsPermissionNone)
+		a.setWorkspacePermissions(tfe.WorkspaceRunsPermissionApply, tfe.WorkspaceVariablesPermissionWrite, tfe.WorkspaceStateVersionsPermissionWrite, tfe.WorkspaceSentinelMocksPermissionRead)
+		a.WorkspaceCreate, a.WorkspaceLocking, a.WorkspaceMove, a.WorkspaceDelete, a.WorkspaceRunTasks = true, true, false, true, true
+	case tfe.TeamProjectAccessAdmin:
+		a.ProjectSettings, a.ProjectTeams = string(tfe.ProjectSettingsPermissionDelete), string(tfe.ProjectTeamsPermissionManage)
+		a.setWorkspacePermissions(tfe.WorkspaceRunsPermissionApply, tfe.WorkspaceVariablesPermissionWrite, tfe.WorkspaceStateVersionsPermissionWrite, tfe.WorkspaceSentinelMocksPermissionRead)
+		a.WorkspaceCreate, a.WorkspaceLocking, a.WorkspaceMove, a.WorkspaceDelete, a.WorkspaceRunTasks = true, true, true, true, true
+	}
+}
+
+func (a *TeamProjectAccess) setWorkspacePermissions(runs tfe.WorkspaceRunsPermissionType, variables tfe.WorkspaceVariablesPermissionType, stateVersions tfe.WorkspaceStateVersionsPermissionType, sentinelMocks tfe.WorkspaceSentinelMocksPermissionType) {
+	a.WorkspaceRuns = string(runs)
+	a.WorkspaceVariables = string(variables)
+	a.WorkspaceStateVersions = string(stateVersions)
+	a.WorkspaceSentinelMocks = string(sentinelMocks)
+}
+
+// ToTFE converts the internal TeamProjectAccess model to TFE format
+func (a *TeamProjectAccess) ToTFE() *tfe.TeamProjectAccess {
+	return &tfe.TeamProjectAccess{
+		ID:     a.ID.String(),
+		Access: tfe.TeamProjectAccessType(a.Access),
+		ProjectAccess: &tfe.TeamProjectAccessProjectPermissions{
+			ProjectSettingsPermission: tfe.ProjectSettingsPermissionType(a.ProjectSettings),
+			ProjectTeamsPermission:    tfe.ProjectTeamsPermissionType(a.ProjectTeams),
+		},
+		WorkspaceAccess: &tfe.TeamProjectAccessWorkspacePermissions{
+			WorkspaceRunsPermission:          tfe.WorkspaceRunsPermissionType(a.WorkspaceRuns),
+			WorkspaceSentinelMocksPermission: tfe.WorkspaceSentinelMocksPermissionType(a.WorkspaceSentinelMocks),
+			WorkspaceStateVersionsPermission: tfe.WorkspaceStateVersionsPermissionType(a.WorkspaceStateVersions),
+			WorkspaceVariablesPermission:     tfe.WorkspaceVariablesPermissionType(a.WorkspaceVariables),
+			WorkspaceCreatePermission:        a.WorkspaceCreate,
+			WorkspaceLockingPermission:       a.WorkspaceLocking,
+			WorkspaceMovePermission:          a.WorkspaceMove,
+			WorkspaceDeletePermission:        a.WorkspaceDelete,
+			WorkspaceRunTasksPermission:      a.WorkspaceRunTasks,
+		},
+		Team:    &tfe.Team{ID: a.TeamID.String()},
+		Project: &tfe.Project{ID: a.ProjectID.String()},
+	}
+}
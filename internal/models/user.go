@@ -38,6 +38,8 @@ type User struct {
 	IsSsoLogin       bool             `gorm:"default:false" jsonapi:"attr,is-sso-login"`
 	Permissions      *UserPermissions `gorm:"embedded;embeddedPrefix:permissions_" jsonapi:"attr,permissions"`
 	LastLoginAt      time.Time        `jsonapi:"attr,last-login-at,iso8601"`
+	// Password holds the bcrypt hash of the user's password and is never serialized.
+	Password string `gorm:"column:password" json:"-"`
 }
 
 // ToTFE converts the internal User model to TFE format
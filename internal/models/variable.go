@@ -0,0 +1,37 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-tfe"
+	"gorm.io/gorm"
+)
+
+// Variable is a terraform or environment variable of a workspace. Sensitive
+// values are only ever stored encrypted, in EncryptedValue.
+type Variable struct {
+	gorm.Model
+	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,vars"`
+	Key            string    `gorm:"not null" jsonapi:"attr,key"`
+	Value          string    `gorm:"type:text" jsonapi:"attr,value"`
+	EncryptedValue string    `gorm:"type:text"`
+	Description    string    `gorm:"type:text" jsonapi:"attr,description"`
+	Category       string    `gorm:"type:varchar(255);not null" jsonapi:"attr,category"`
+	HCL            bool      `gorm:"default:false" jsonapi:"attr,hcl"`
+	Sensitive      bool      `gorm:"default:false" jsonapi:"attr,sensitive"`
+	WorkspaceID    uuid.UUID `gorm:"type:uuid;not null"`
+}
+
+// ToTFE converts the internal Variable model to TFE format. The value of a
+// sensitive variable is never included.
+func (v *Variable) ToTFE() *tfe.Variable {
+	return &tfe.Variable{
+		ID:          v.ID.String(),
+		Key:         v.Key,
+		Value:       v.Value,
+		Description: v.Description,
+		Category:    tfe.CategoryType(v.Category),
+		HCL:         v.HCL,
+		Sensitive:   v.Sensitive,
+		Workspace:   &tfe.Workspace{ID: v.WorkspaceID.String()},
+	}
+}
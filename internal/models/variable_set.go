@@ -0,0 +1,94 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-tfe"
+	"gorm.io/gorm"
+)
+
+// VariableSet is a reusable group of variables in an organization. A set
+// applies to every workspace when Global is set, and otherwise to the
+// workspaces and projects it is attached to. Variables of a priority set
+// override all other variables with the same key.
+type VariableSet struct {
+	gorm.Model
+	ID             uuid.UUID              `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,varsets"`
+	Name           string                 `gorm:"not null" jsonapi:"attr,name"`
+	Description    string                 `gorm:"type:text" jsonapi:"attr,description"`
+	Global         bool                   `gorm:"default:false" jsonapi:"attr,global"`
+	Priority       bool                   `gorm:"default:false" jsonapi:"attr,priority"`
+	OrganizationID uuid.UUID              `gorm:"type:uuid;not null"`
+	Organization   *Organization          `gorm:"foreignKey:OrganizationID"`
+	Workspaces     []*Workspace           `gorm:"many2many:variable_set_workspaces"`
+	Projects       []*Project             `gorm:"many2many:variable_set_projects"`
+	Variables      []*VariableSetVariable `gorm:"foreignKey:VariableSetID"`
+}
+
+// VariableSetWorkspace attaches a variable set to a workspace.
+type VariableSetWorkspace struct {
+	VariableSetID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	WorkspaceID   uuid.UUID `gorm:"type:uuid;primaryKey"`
+}
+
+// VariableSetProject attaches a variable set to every workspace of a project.
+type VariableSetProject struct {
+	VariableSetID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ProjectID     uuid.UUID `gorm:"type:uuid;primaryKey"`
+}
+
+// VariableSetVariable is a variable of a variable set. Like workspace
+// variables, sensitive values are only stored encrypted.
+type VariableSetVariable struct {
+	gorm.Model
+	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,vars"`
+	Key            string    `gorm:"not null" jsonapi:"attr,key"`
+	Value          string    `gorm:"type:text" jsonapi:"attr,value"`
+	EncryptedValue string    `gorm:"type:text"`
+	Description    string    `gorm:"type:text" jsonapi:"attr,description"`
+	Category       string    `gorm:"type:varchar(255);not null" jsonapi:"attr,category"`
+	HCL            bool      `gorm:"default:false" jsonapi:"attr,hcl"`
+	Sensitive      bool      `gorm:"default:false" jsonapi:"attr,sensitive"`
+	VariableSetID  uuid.UUID `gorm:"type:uuid;not null"`
+}
+
+// ToTFE converts the internal VariableSet model to TFE format
+func (vs *VariableSet) ToTFE() *tfe.VariableSet {
+	set := &tfe.VariableSet{
+		ID:          vs.ID.String(),
+		Name:        vs.Name,
+		Description: vs.Description,
+		Global:      vs.Global,
+		Priority:    vs.Priority,
+		Workspaces:  make([]*tfe.Workspace, len(vs.Workspaces)),
+		Projects:    make([]*tfe.Project, len(vs.Projects)),
+		Variables:   make([]*tfe.VariableSetVariable, len(vs.Variables)),
+	}
+	if vs.Organization != nil {
+		set.Organization = &tfe.Organization{Name: vs.Organization.Name}
+	}
+	for i, ws := range vs.Workspaces {
+		set.Workspaces[i] = &tfe.Workspace{ID: ws.ID.String()}
+	}
+	for i, p := range vs.Projects {
+		set.Projects[i] = &tfe.Project{ID: p.ID.String()}
+	}
+	for i, v := range vs.Variables {
+		set.Variables[i] = &tfe.VariableSetVariable{ID: v.ID.String()}
+	}
+	return set
+}
+
+// ToTFE converts the internal VariableSetVariable model to TFE format. The
+// value of a sensitive variable is never included.
+func (v *VariableSetVariable) ToTFE() *tfe.VariableSetVariable {
+	return &tfe.VariableSetVariable{
+		ID:          v.ID.String(),
+		Key:         v.Key,
+		Value:       v.Value,
+		Description: v.Description,
+		Category:    tfe.CategoryType(v.Category),
+		HCL:         v.HCL,
+		Sensitive:   v.Sensitive,
+		VariableSet: &tfe.VariableSet{ID: v.VariableSetID.String()},
+	}
+}
@@ -0,0 +1,223 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-tfe"
+	"gorm.io/gorm"
+)
+
+type Workspace struct {
+	gorm.Model
+	ID                         uuid.UUID     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" jsonapi:"primary,workspaces"`
+	Name                       string        `gorm:"not null" jsonapi:"attr,name"`
+	Description                string        `gorm:"type:text" jsonapi:"attr,description"`
+	AllowDestroyPlan           bool          `jsonapi:"attr,allow-destroy-plan"`
+	AssessmentsEnabled         bool          `jsonapi:"attr,assessments-enabled"`
+	AutoApply                  bool          `jsonapi:"attr,auto-apply"`
+	AutoApplyRunTrigger        bool          `jsonapi:"attr,auto-apply-run-trigger"`
+	CreatedAt                  time.Time     `jsonapi:"attr,created-at,iso8601"`
+	ExecutionMode              string        `gorm:"type:varchar(255);not null" jsonapi:"attr,execution-mode"`
+	FileTriggersEnabled        bool          `jsonapi:"attr,file-triggers-enabled"`
+	GlobalRemoteState          bool          `jsonapi:"attr,global-remote-state"`
+	QueueAllRuns               bool          `jsonapi:"attr,queue-all-runs"`
+	SpeculativeEnabled         bool          `jsonapi:"attr,speculative-enabled"`
+	SourceName                 string        `jsonapi:"attr,source-name"`
+	SourceURL                  string        `jsonapi:"attr,source-url"`
+	StructuredRunOutputEnabled bool          `jsonapi:"attr,structured-run-output-enabled"`
+	TerraformVersion           string        `jsonapi:"attr,terraform-version"`
+	TriggerPrefixes            []string      `gorm:"serializer:json" jsonapi:"attr,trigger-prefixes"`
+	TriggerPatterns            []string      `gorm:"serializer:json" jsonapi:"attr,trigger-patterns"`
+	WorkingDirectory           string        `jsonapi:"attr,working-directory"`
+	UpdatedAt                  time.Time     `jsonapi:"attr,updated-at,iso8601"`
+	OrganizationID             uuid.UUID     `gorm:"type:uuid;not null"`
+	Organization               *Organization `gorm:"foreignKey:OrganizationID" jsonapi:"relation,organization"`
+	ProjectID                  uuid.UUID     `gorm:"type:uuid;not null"`
+	Project                    *Project      `gorm:"foreignKey:ProjectID" jsonapi:"relation,project"`
+	AgentPoolID                *uuid.UUID    `gorm:"type:uuid"`
+	AgentPool                  *AgentPool    `gorm:"foreignKey:AgentPoolID"`
+
+	// Lock state. A workspace is locked by a user, by the API token of a team
+	// or organization, or by a run.
+	Locked          bool   `gorm:"default:false" jsonapi:"attr,locked"`
+	LockReason      string `gorm:"type:text"`
+	LockedAt        *time.Time
+	LockedByUserID  *uuid.UUID           `gorm:"type:uuid"`
+	LockedByUser    *User                `gorm:"foreignKey:LockedByUserID"`
+	LockedByTokenID *uuid.UUID           `gorm:"type:uuid"`
+	LockedByToken   *AuthenticationToken `gorm:"foreignKey:LockedByTokenID"`
+	LockedByRunID   *uuid.UUID           `gorm:"type:uuid"`
+}
+
+// ToTFE converts the internal Workspace model to TFE format
+func (w *Workspace) ToTFE() *tfe.Workspace {
+	ws := &tfe.Workspace{
+		ID:                         w.ID.String(),
+		Name:                       w.Name,
+		Description:                w.Description,
+		AllowDestroyPlan:           w.AllowDestroyPlan,
+		AssessmentsEnabled:         w.AssessmentsEnabled,
+		AutoApply:                  w.AutoApply,
+		AutoApplyRunTrigger:        w.AutoApplyRunTrigger,
+		CanQueueDestroyPlan:        w.AllowDestroyPlan,
+		CreatedAt:                  w.CreatedAt,
+		ExecutionMode:              w.ExecutionMode,
+		FileTriggersEnabled:        w.FileTriggersEnabled,
+		GlobalRemoteState:          w.GlobalRemoteState,
+		Locked:                     w.Locked,
+		Operations:                 w.ExecutionMode != "local",
+		QueueAllRuns:               w.QueueAllRuns,
+		SpeculativeEnabled:         w.SpeculativeEnabled,
+		SourceName:                 w.SourceName,
+		SourceURL:                  w.SourceURL,
+		StructuredRunOutputEnabled: w.StructuredRunOutputEnabled,
+		TerraformVersion:           w.TerraformVersion,
+		TriggerPrefixes:            w.TriggerPrefixes,
+		TriggerPatterns:            w.TriggerPatterns,
+		WorkingDirectory:           w.WorkingDirectory,
+		UpdatedAt:                  w.UpdatedAt,
+		TagNames:                   []string{},
+		Actions: &tfe.WorkspaceActions{
+			IsDestroyable: true,
+		},
+		Permissions: &tfe.WorkspacePermissions{
+			CanDestroy:        true,
+			CanForceUnlock:    true,
+			CanLock:           true,
+			CanManageRunTasks: true,
+			CanQueueApply:     true,
+			CanQueueDestroy:   true,
+			CanQueueRun:       true,
+			CanReadSettings:   true,
+			CanUnlock:         true,
+			CanUpdate:         true,
+			CanUpdateVariable: true,
+		},
+	}
+	if w.Organization != nil {
+		ws.Organization = &tfe.Organization{Name: w.Organization.Name}
+	}
+	if w.Project != nil {
+		ws.Project = w.Project.ToTFE()
+	}
+	if w.AgentPoolID != nil {
+		ws.AgentPool = &tfe.AgentPool{ID: w.AgentPoolID.String()}
+	}
+	switch {
+	case w.LockedByRunID != nil:
+		ws.LockedBy = &tfe.LockedByChoice{Run: &tfe.Run{ID: w.LockedByRunID.String()}}
+	case w.LockedByUser != nil:
+		ws.LockedBy = &tfe.LockedByChoice{User: &tfe.User{ID: w.LockedByUser.ID.String(), Username: w.LockedByUser.Username}}
+	case w.LockedByToken != nil && w.LockedByToken.Team != nil:
+		ws.LockedBy = &tfe.LockedByChoice{Team: &tfe.Team{ID: w.LockedByToken.Team.ID.String(), Name: w.LockedByToken.Team.Name}}
+	}
+	return ws
+}
+
+// FromTFEWorkspaceCreateOptions builds a new Workspace from go-tfe create
+// options, applying the same defaults as Terraform Enterprise.
+func FromTFEWorkspaceCreateOptions(opts tfe.WorkspaceCreateOptions) *Workspace {
+	ws := &Workspace{
+		AllowDestroyPlan:           true,
+		ExecutionMode:              "remote",
+		FileTriggersEnabled:        true,
+		SpeculativeEnabled:         true,
+		StructuredRunOutputEnabled: true,
+		TriggerPrefixes:            []string{},
+		TriggerPatterns:            []string{},
+	}
+	if opts.Name != nil {
+		ws.Name = *opts.Name
+	}
+	if opts.Operations != nil && !*opts.Operations {
+		ws.ExecutionMode = "local"
+	}
+	ws.ApplyTFEUpdateOptions(tfe.WorkspaceUpdateOptions{
+		AllowDestroyPlan:           opts.AllowDestroyPlan,
+		AssessmentsEnabled:         opts.AssessmentsEnabled,
+		AutoApply:                  opts.AutoApply,
+		AutoApplyRunTrigger:        opts.AutoApplyRunTrigger,
+		Description:                opts.Description,
+		ExecutionMode:              opts.ExecutionMode,
+		FileTriggersEnabled:        opts.FileTriggersEnabled,
+		GlobalRemoteState:          opts.GlobalRemoteState,
+		QueueAllRuns:               opts.QueueAllRuns,
+		SpeculativeEnabled:         opts.SpeculativeEnabled,
+		StructuredRunOutputEnabled: opts.StructuredRunOutputEnabled,
+		TerraformVersion:           opts.TerraformVersion,
+		TriggerPrefixes:            opts.TriggerPrefixes,
+		TriggerPatterns:            opts.TriggerPatterns,
+		WorkingDirectory:           opts.WorkingDirectory,
+	})
+	if opts.SourceName != nil {
+		ws.SourceName = *opts.SourceName
+	}
+	if opts.SourceURL != nil {
+		ws.SourceURL = *opts.SourceURL
+	}
+	return ws
+}
+
+// ApplyTFEUpdateOptions copies every option that was set onto the workspace.
+func (w *Workspace) ApplyTFEUpdateOptions(opts tfe.WorkspaceUpdateOptions) {
+	if opts.Name != nil {
+		w.Name = *opts.Name
+	}
+	if opts.Description != nil {
+		w.Description = *opts.Description
+	}
+	if opts.AllowDestroyPlan != nil {
+		w.AllowDestroyPlan = *opts.AllowDestroyPlan
+	}
+	if opts.AssessmentsEnabled != nil {
+		w.AssessmentsEnabled = *opts.AssessmentsEnabled
+	}
+	if opts.AutoApply != nil {
+		w.AutoApply = *opts.AutoApply
+	}
+	if opts.AutoApplyRunTrigger != nil {
+		w.AutoApplyRunTrigger = *opts.AutoApplyRunTrigger
+	}
+	if opts.Operations != nil {
+		if *opts.Operations {
+			w.ExecutionMode = "remote"
+		} else {
+			w.ExecutionMode = "local"
+		}
+	}
+	if opts.ExecutionMode != nil {
+		w.ExecutionMode = *opts.ExecutionMode
+	}
+	if w.ExecutionMode != "agent" {
+		// Only agent execution uses a pool.
+		w.AgentPoolID = nil
+	}
+	if opts.FileTriggersEnabled != nil {
+		w.FileTriggersEnabled = *opts.FileTriggersEnabled
+	}
+	if opts.GlobalRemoteState != nil {
+		w.GlobalRemoteState = *opts.GlobalRemoteState
+	}
+	if opts.QueueAllRuns != nil {
+		w.QueueAllRuns = *opts.QueueAllRuns
+	}
+	if opts.SpeculativeEnabled != nil {
+		w.SpeculativeEnabled = *opts.SpeculativeEnabled
+	}
+	if opts.StructuredRunOutputEnabled != nil {
+		w.StructuredRunOutputEnabled = *opts.StructuredRunOutputEnabled
+	}
+	if opts.TerraformVersion != nil {
+		w.TerraformVersion = *opts.TerraformVersion
+	}
+	if opts.TriggerPrefixes != nil {
+		w.TriggerPrefixes = opts.TriggerPrefixes
+	}
+	if opts.TriggerPatterns != nil {
+		w.TriggerPatterns = opts.TriggerPatterns
+	}
+	if opts.WorkingDirectory != nil {
+		w.WorkingDirectory = *opts.WorkingDirectory
+	}
+}
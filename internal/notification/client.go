@@ -0,0 +1,203 @@
+// Package notification delivers run notifications to generic webhooks,
+// Slack and Microsoft Teams, in the formats of Terraform notifications.
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/open-tfe/tfe-service/internal/constants"
+)
+
+// requestTimeout bounds a single delivery attempt.
+const requestTimeout = 10 * time.Second
+
+// maxResponseBody caps how much of a destination's answer is recorded.
+const maxResponseBody = 4096
+
+// SignatureHeader carries the HMAC-SHA512 of the body of generic
+// notifications, keyed with the configuration's token, when it has one.
+const SignatureHeader = "X-TFE-Notification-Signature"
+
+// Destination types.
+const (
+	DestinationGeneric        = "generic"
+	DestinationSlack          = "slack"
+	DestinationMicrosoftTeams = "microsoft-teams"
+)
+
+// MaxAttempts is how often a notification is sent before it is given up.
+const MaxAttempts = 4
+
+// RetryDelay is how long to wait after a failed attempt, doubling from
+// five seconds.
+func RetryDelay(attempt int) time.Duration {
+	return 5 * time.Second << (attempt - 1)
+}
+
+// Payload is the generic notification payload. Run fields are null in
+// verification payloads.
+type Payload struct {
+	PayloadVersion              int        `json:"payload_version"`
+	NotificationConfigurationID string     `json:"notification_configuration_id"`
+	RunURL                      *string    `json:"run_url"`
+	RunID                       *string    `json:"run_id"`
+	RunMessage                  *string    `json:"run_message"`
+	RunCreatedAt                *time.Time `json:"run_created_at"`
+	RunCreatedBy                *string    `json:"run_created_by"`
+	WorkspaceID                 *string    `json:"workspace_id"`
+	WorkspaceName               *string    `json:"workspace_name"`
+	OrganizationName            *string    `json:"organization_name"`
+	Notifications               []Notice   `json:"notifications"`
+}
+
+// Notice describes what happened to the run.
+type Notice struct {
+	Message      string     `json:"message"`
+	Trigger      string     `json:"trigger"`
+	RunStatus    *string    `json:"run_status"`
+	RunUpdatedAt *time.Time `json:"run_updated_at"`
+	RunUpdatedBy *string    `json:"run_updated_by"`
+}
+
+// Destination is where a notification goes.
+type Destination struct {
+	Type  string
+	URL   string
+	Token string
+}
+
+// Response records the answer of a destination to one delivery attempt.
+type Response struct {
+	URL        string
+	Code       int
+	Headers    map[string][]string
+	Body       string
+	Successful bool
+	SentAt     time.Time
+}
+
+// Retryable reports whether a failed attempt may succeed later: the
+// destination could not be reached, is overloaded, or failed itself.
+func (r *Response) Retryable() bool {
+	return !r.Successful && (r.Code == 0 || r.Code == http.StatusTooManyRequests || r.Code >= 500)
+}
+
+// Client delivers notifications.
+type Client struct {
+	http    *http.Client
+	baseURL string
+}
+
+// NewClient returns a client whose run links point at baseURL, the public
+// address of the API.
+func NewClient(baseURL string) *Client {
+	return &Client{
+		http:    &http.Client{Timeout: requestTimeout},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// RunURL links a notification to its run.
+func (c *Client) RunURL(runID string) string {
+	return c.baseURL + constants.APIVersionPath + "/runs/" + runID
+}
+
+// Deliver sends payload to dest once, formatted for the destination type.
+// Failures are reported in the response rather than as errors; errors are
+// reserved for payloads that cannot be built.
+func (c *Client) Deliver(ctx context.Context, dest Destination, payload *Payload) (*Response, error) {
+	body, err := format(dest.Type, payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dest.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if dest.Type == DestinationGeneric && dest.Token != "" {
+		req.Header.Set(SignatureHeader, sign(dest.Token, body))
+	}
+
+	result := &Response{URL: dest.URL, SentAt: time.Now()}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		result.Body = err.Error()
+		return result, nil
+	}
+	defer resp.Body.Close()
+	answer, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	result.Code = resp.StatusCode
+	result.Headers = resp.Header
+	result.Body = string(answer)
+	result.Successful = resp.StatusCode >= 200 && resp.StatusCode < 300
+	return result, nil
+}
+
+// format renders payload for a destination type.
+func format(destinationType string, payload *Payload) ([]byte, error) {
+	switch destinationType {
+	case DestinationGeneric:
+		return json.Marshal(payload)
+	case DestinationSlack:
+		return json.Marshal(map[string]string{"text": summary(payload)})
+	case DestinationMicrosoftTeams:
+		return json.Marshal(map[string]string{
+			"@type":    "MessageCard",
+			"@context": "http://schema.org/extensions",
+			"summary":  title(payload),
+			"title":    title(payload),
+			"text":     summary(payload),
+		})
+	default:
+		return nil, fmt.Errorf("unsupported destination type %q", destinationType)
+	}
+}
+
+// title is the headline of a notification, such as "Run Errored".
+func title(payload *Payload) string {
+	if len(payload.Notifications) == 0 {
+		return "Notification"
+	}
+	return payload.Notifications[0].Message
+}
+
+// summary is a plain text description of a notification for chat
+// destinations.
+func summary(payload *Payload) string {
+	lines := []string{title(payload)}
+	if payload.OrganizationName != nil && payload.WorkspaceName != nil {
+		lines = append(lines, fmt.Sprintf("Workspace: %s/%s", *payload.OrganizationName, *payload.WorkspaceName))
+	}
+	if payload.RunID != nil {
+		run := *payload.RunID
+		if payload.RunMessage != nil && *payload.RunMessage != "" {
+			run += " (" + *payload.RunMessage + ")"
+		}
+		lines = append(lines, "Run: "+run)
+	}
+	if payload.RunCreatedBy != nil && *payload.RunCreatedBy != "" {
+		lines = append(lines, "Triggered by: "+*payload.RunCreatedBy)
+	}
+	if payload.RunURL != nil {
+		lines = append(lines, *payload.RunURL)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sign returns the hex encoded HMAC-SHA512 of body.
+func sign(token string, body []byte) string {
+	mac := hmac.New(sha512.New, []byte(token))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
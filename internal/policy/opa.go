@@ -0,0 +1,151 @@
+// Package policy evaluates OPA policies against Terraform plans by driving
+// the opa binary.
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// evaluationTimeout bounds a single opa eval.
+const evaluationTimeout = time.Minute
+
+// Module is one Rego policy of a policy set.
+type Module struct {
+	Name   string
+	Source []byte
+}
+
+// Result is the outcome of one query. Err is set when the query could not
+// be evaluated, for example because a module does not compile.
+type Result struct {
+	Failed bool
+	Err    error
+}
+
+// Engine evaluates queries with `opa eval`.
+type Engine struct {
+	bin string
+}
+
+// NewEngine returns an engine that runs bin, looked up in PATH when relative.
+func NewEngine(bin string) *Engine {
+	if bin == "" {
+		bin = "opa"
+	}
+	return &Engine{bin: bin}
+}
+
+// Evaluate loads modules together, so that they may import each other, and
+// evaluates each query against input. Following HCP Terraform, a query
+// fails when it yields anything but undefined, false or an empty
+// collection; queries are therefore usually deny rules.
+func (e *Engine) Evaluate(ctx context.Context, modules []Module, queries []string, input []byte) ([]Result, error) {
+	dir, err := os.MkdirTemp("", "policies-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	// Input lives outside the module directory; opa would otherwise load it
+	// as data.
+	modulesDir := filepath.Join(dir, "policies")
+	if err := os.Mkdir(modulesDir, 0o750); err != nil {
+		return nil, err
+	}
+	for i, module := range modules {
+		path := filepath.Join(modulesDir, fmt.Sprintf("%03d-%s.rego", i, sanitize(module.Name)))
+		if err := os.WriteFile(path, module.Source, 0o640); err != nil {
+			return nil, err
+		}
+	}
+	inputPath := filepath.Join(dir, "input.json")
+	if err := os.WriteFile(inputPath, input, 0o640); err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, len(queries))
+	for i, query := range queries {
+		failed, err := e.eval(ctx, modulesDir, inputPath, query)
+		results[i] = Result{Failed: failed, Err: err}
+	}
+	return results, nil
+}
+
+// eval runs one query and reports whether it failed.
+func (e *Engine) eval(ctx context.Context, dir, inputPath, query string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, evaluationTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.bin, "eval", "--format", "json", "--input", inputPath, "--data", dir, query)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+
+	var output struct {
+		Result []struct {
+			Expressions []struct {
+				Value json.RawMessage `json:"value"`
+			} `json:"expressions"`
+		} `json:"result"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		if runErr != nil {
+			return false, fmt.Errorf("opa eval: %w: %s", runErr, strings.TrimSpace(stderr.String()))
+		}
+		return false, fmt.Errorf("reading opa output: %w", err)
+	}
+	if len(output.Errors) > 0 {
+		messages := make([]string, len(output.Errors))
+		for i, opaErr := range output.Errors {
+			messages[i] = opaErr.Message
+		}
+		return false, errors.New(strings.Join(messages, "; "))
+	}
+	if runErr != nil {
+		return false, fmt.Errorf("opa eval: %w", runErr)
+	}
+
+	for _, result := range output.Result {
+		for _, expression := range result.Expressions {
+			if !empty(expression.Value) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// empty reports whether a query value counts as no violation.
+func empty(value json.RawMessage) bool {
+	switch strings.TrimSpace(string(value)) {
+	case "", "null", "false", "[]", "{}":
+		return true
+	default:
+		return false
+	}
+}
+
+// sanitize makes a policy name safe to use in a file name.
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
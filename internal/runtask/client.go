@@ -0,0 +1,87 @@
+// Package runtask sends runs to the external services of run tasks, in the
+// format of the Terraform run task integration.
+package runtask
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/constants"
+)
+
+// requestTimeout bounds how long a task may take to acknowledge a request.
+// The outcome itself is reported later through the callback.
+const requestTimeout = 10 * time.Second
+
+// SignatureHeader carries the HMAC-SHA512 of the request body, keyed with
+// the task's HMAC key, when the task has one.
+const SignatureHeader = "X-TFC-Task-Signature"
+
+// ErrUnreachable is returned when a task could not be reached at all.
+var ErrUnreachable = errors.New("task unreachable")
+
+// Client sends run task requests.
+type Client struct {
+	http    *http.Client
+	baseURL string
+}
+
+// NewClient returns a client whose callback URLs point at baseURL, the
+// public address of the API.
+func NewClient(baseURL string) *Client {
+	return &Client{
+		http:    &http.Client{Timeout: requestTimeout},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// CallbackURL is where the task reports the outcome of a task result.
+func (c *Client) CallbackURL(taskResultID string) string {
+	return c.baseURL + constants.APIVersionPath + "/task-results/" + taskResultID + "/callback"
+}
+
+// Send posts payload to the task at url. The task accepts the request by
+// answering 200 OK.
+func (c *Client) Send(ctx context.Context, url, hmacKey string, payload *tfe.RunTaskRequest) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if hmacKey != "" {
+		req.Header.Set(SignatureHeader, sign(hmacKey, body))
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnreachable, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("task responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// sign returns the hex encoded HMAC-SHA512 of body.
+func sign(hmacKey string, body []byte) string {
+	mac := hmac.New(sha512.New, []byte(hmacKey))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
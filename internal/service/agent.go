@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// The agent protocol. Agents authenticate with an agent token of their pool,
+// register once, and then check in and claim jobs until they exit. While a
+// phase runs, the claiming agent drives it through the run executor methods
+// in the context returned by AuthorizeAgentRun.
+
+// RegisterAgent registers a new agent with the pool of the caller's agent
+// token.
+func (s *service) RegisterAgent(ctx context.Context, name, ipAddress string) (*tfe.Agent, error) {
+	p := tokenPrincipalFromContext(ctx)
+	if p == nil || p.kind != models.TokenKindAgent {
+		return nil, fmt.Errorf("%w: this operation requires an agent token", ErrForbidden)
+	}
+
+	now := time.Now()
+	agent := &models.Agent{
+		Name:        name,
+		IPAddress:   ipAddress,
+		Status:      models.AgentIdle,
+		LastPingAt:  &now,
+		AgentPoolID: p.agentPoolID,
+	}
+	if err := s.db.Omit("AgentPool").Create(agent).Error; err != nil {
+		s.logger.Error("failed to register agent", zap.Error(err))
+		return nil, err
+	}
+	s.logger.Info("agent registered", zap.String("agent", agent.ID.String()), zap.String("name", name))
+	return agent.ToTFE(), nil
+}
+
+// AgentHeartbeat records that an agent is alive. The agent is busy while it
+// holds a planning or applying run and idle otherwise.
+func (s *service) AgentHeartbeat(ctx context.Context, agentID string) error {
+	agent, err := s.findCallingAgent(ctx, agentID)
+	if err != nil {
+		return err
+	}
+
+	var running int64
+	if err := s.db.Model(&models.Run{}).
+		Where("agent_id = ? AND status IN ?", agent.ID, []string{string(tfe.RunPlanning), string(tfe.RunApplying)}).
+		Count(&running).Error; err != nil {
+		s.logger.Error("failed to count agent runs", zap.Error(err))
+		return err
+	}
+	status := models.AgentIdle
+	if running > 0 {
+		status = models.AgentBusy
+	}
+	return s.updateAgent(agent, status)
+}
+
+// ExitAgent records that an agent shut down. It cannot claim jobs anymore.
+func (s *service) ExitAgent(ctx context.Context, agentID string) error {
+	agent, err := s.findCallingAgent(ctx, agentID)
+	if err != nil {
+		return err
+	}
+	return s.updateAgent(agent, models.AgentExited)
+}
+
+// ClaimAgentJob claims the oldest queued run of the workspaces that use the
+// agent's pool. It returns nil when there is nothing to do.
+func (s *service) ClaimAgentJob(ctx context.Context, agentID string) (*RunJob, error) {
+	agent, err := s.findCallingAgent(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.updateAgent(agent, agent.Status); err != nil {
+		return nil, err
+	}
+
+	job, err := s.claimRunJob(s.db.Where("workspaces.execution_mode = ? AND workspaces.agent_pool_id = ?", "agent", agent.AgentPoolID), &agent.ID)
+	if err != nil || job == nil {
+		return nil, err
+	}
+	if err := s.updateAgent(agent, models.AgentBusy); err != nil {
+		s.logger.Warn("failed to mark agent busy", zap.Error(err))
+	}
+	return job, nil
+}
+
+// AuthorizeAgentRun requires the run's current phase to have been claimed
+// by the calling agent and returns the context in which the agent may drive
+// it through the run executor methods.
+func (s *service) AuthorizeAgentRun(ctx context.Context, agentID, runID string) (context.Context, error) {
+	agent, err := s.findCallingAgent(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+	run, err := s.findRun(runID)
+	if err != nil {
+		return nil, err
+	}
+	if run.AgentID == nil || *run.AgentID != agent.ID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return SystemContext(ctx), nil
+}
+
+// DownloadAgentConfigurationVersion opens the configuration of a run phase
+// the calling agent holds.
+func (s *service) DownloadAgentConfigurationVersion(ctx context.Context, agentID, cvID string) (io.ReadCloser, error) {
+	agent, err := s.findCallingAgent(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+	id, err := uuid.Parse(cvID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var held int64
+	if err := s.db.Model(&models.Run{}).
+		Where("agent_id = ? AND configuration_version_id = ? AND status IN ?", agent.ID, id, []string{string(tfe.RunPlanning), string(tfe.RunApplying)}).
+		Count(&held).Error; err != nil {
+		s.logger.Error("failed to check agent runs", zap.Error(err))
+		return nil, err
+	}
+	if held == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return s.DownloadConfigurationVersion(SystemContext(ctx), cvID)
+}
+
+// findCallingAgent loads an agent of the pool of the caller's agent token.
+// Exited agents cannot act anymore.
+func (s *service) findCallingAgent(ctx context.Context, agentID string) (*models.Agent, error) {
+	p := tokenPrincipalFromContext(ctx)
+	if p == nil || p.kind != models.TokenKindAgent {
+		return nil, fmt.Errorf("%w: this operation requires an agent token", ErrForbidden)
+	}
+	agent, err := s.findAgent(agentID)
+	if err != nil {
+		return nil, err
+	}
+	if agent.AgentPoolID != p.agentPoolID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if agent.Status == models.AgentExited {
+		return nil, fmt.Errorf("%w: agent has exited", ErrConflict)
+	}
+	return agent, nil
+}
+
+func (s *service) updateAgent(agent *models.Agent, status string) error {
+	now := time.Now()
+	if err := s.db.Model(&models.Agent{}).Where("id = ?", agent.ID).
+		Updates(map[string]interface{}{"status": status, "last_ping_at": now}).Error; err != nil {
+		s.logger.Error("failed to update agent", zap.Error(err))
+		return err
+	}
+	agent.Status, agent.LastPingAt = status, &now
+	return nil
+}
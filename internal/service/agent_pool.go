@@ -0,0 +1,449 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (s *service) ListAgentPools(ctx context.Context, organization string, options *tfe.AgentPoolListOptions) ([]*tfe.AgentPool, *tfe.Pagination, error) {
+	role, err := s.authorizeOrganizationName(ctx, organization, orgRead)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db := s.db.Model(&models.AgentPool{}).Where("organization_id = ?", role.orgID)
+	var listOptions tfe.ListOptions
+	if options != nil {
+		listOptions = options.ListOptions
+		if options.Query != "" {
+			db = db.Where("name ILIKE ?", "%"+options.Query+"%")
+		}
+		if options.AllowedWorkspacesName != "" {
+			// Pools the named workspace may use.
+			db = db.Where("organization_scoped OR id IN (?)",
+				s.db.Model(&models.AgentPoolAllowedWorkspace{}).Select("agent_pool_id").
+					Joins("JOIN workspaces ON workspaces.id = agent_pool_allowed_workspaces.workspace_id AND workspaces.deleted_at IS NULL").
+					Where("workspaces.organization_id = ? AND workspaces.name = ?", role.orgID, options.AllowedWorkspacesName))
+		}
+	}
+
+	db, pagination, err := paginate(db, listOptions)
+	if err != nil {
+		s.logger.Error("failed to count agent pools", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var pools []*models.AgentPool
+	if err := preloadAgentPool(db).Order("name ASC").Find(&pools).Error; err != nil {
+		s.logger.Error("failed to list agent pools", zap.Error(err))
+		return nil, nil, err
+	}
+
+	tfePools := make([]*tfe.AgentPool, len(pools))
+	for i, pool := range pools {
+		tfePools[i] = pool.ToTFE()
+	}
+	return tfePools, pagination, nil
+}
+
+func (s *service) CreateAgentPool(ctx context.Context, organization string, options tfe.AgentPoolCreateOptions) (*tfe.AgentPool, error) {
+	role, err := s.authorizeOrganizationName(ctx, organization, orgManageAgentPools)
+	if err != nil {
+		return nil, err
+	}
+	if options.Name == nil {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidAttribute)
+	}
+
+	pool := &models.AgentPool{
+		Name:               *options.Name,
+		OrganizationScoped: true,
+		OrganizationID:     role.orgID,
+	}
+	if options.OrganizationScoped != nil {
+		pool.OrganizationScoped = *options.OrganizationScoped
+	}
+	if err := s.validateAgentPool(pool); err != nil {
+		return nil, err
+	}
+	allowed, err := s.agentPoolWorkspaces(pool, options.AllowedWorkspaces)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(pool).Error; err != nil {
+			return err
+		}
+		return replaceAllowedWorkspaces(tx, pool, allowed)
+	})
+	if err != nil {
+		s.logger.Error("failed to create agent pool", zap.Error(err))
+		return nil, err
+	}
+	created, err := s.ReadAgentPool(ctx, pool.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, role.orgID, models.AuditActionCreate, nil, created)
+	return created, nil
+}
+
+func (s *service) ReadAgentPool(ctx context.Context, agentPoolID string) (*tfe.AgentPool, error) {
+	pool, err := s.findAuthorizedAgentPool(ctx, agentPoolID, orgRead)
+	if err != nil {
+		return nil, err
+	}
+	return pool.ToTFE(), nil
+}
+
+// UpdateAgentPool changes the settings of a pool. A pool that stops being
+// organization scoped must still allow every workspace that uses it.
+func (s *service) UpdateAgentPool(ctx context.Context, agentPoolID string, options tfe.AgentPoolUpdateOptions) (*tfe.AgentPool, error) {
+	pool, err := s.findAuthorizedAgentPool(ctx, agentPoolID, orgManageAgentPools)
+	if err != nil {
+		return nil, err
+	}
+	before := pool.ToTFE()
+
+	if options.Name != nil {
+		pool.Name = *options.Name
+	}
+	if options.OrganizationScoped != nil {
+		pool.OrganizationScoped = *options.OrganizationScoped
+	}
+	if err := s.validateAgentPool(pool); err != nil {
+		return nil, err
+	}
+	allowed := pool.AllowedWorkspaces
+	if options.AllowedWorkspaces != nil {
+		if allowed, err = s.agentPoolWorkspaces(pool, options.AllowedWorkspaces); err != nil {
+			return nil, err
+		}
+	}
+	if !pool.OrganizationScoped {
+		for _, ws := range pool.Workspaces {
+			if !containsWorkspace(allowed, ws.ID) {
+				return nil, fmt.Errorf("%w: workspace %s uses the agent pool and must remain allowed", ErrInvalidAttribute, ws.Name)
+			}
+		}
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(pool).Error; err != nil {
+			return err
+		}
+		if options.AllowedWorkspaces == nil {
+			return nil
+		}
+		return replaceAllowedWorkspaces(tx, pool, allowed)
+	})
+	if err != nil {
+		s.logger.Error("failed to update agent pool", zap.Error(err))
+		return nil, err
+	}
+	updated, err := s.ReadAgentPool(ctx, pool.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, pool.OrganizationID, models.AuditActionUpdate, before, updated)
+	return updated, nil
+}
+
+// DeleteAgentPool deletes a pool that no workspace uses, together with its
+// agents and tokens.
+func (s *service) DeleteAgentPool(ctx context.Context, agentPoolID string) error {
+	pool, err := s.findAuthorizedAgentPool(ctx, agentPoolID, orgManageAgentPools)
+	if err != nil {
+		return err
+	}
+	if len(pool.Workspaces) > 0 {
+		return fmt.Errorf("%w: agent pool is used by %d workspaces", ErrConflict, len(pool.Workspaces))
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Organization{}).Where("default_agent_pool_id = ?", pool.ID).
+			Update("default_agent_pool_id", nil).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&models.AgentPoolAllowedWorkspace{}, &models.Agent{}, &models.AuthenticationToken{}} {
+			if err := tx.Where("agent_pool_id = ?", pool.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("id = ?", pool.ID).Delete(&models.AgentPool{}).Error
+	})
+	if err != nil {
+		s.logger.Error("failed to delete agent pool", zap.Error(err))
+		return err
+	}
+	s.audit(ctx, pool.OrganizationID, models.AuditActionDelete, pool.ToTFE(), nil)
+	return nil
+}
+
+func (s *service) ListAgentTokens(ctx context.Context, agentPoolID string) ([]*tfe.AgentToken, error) {
+	pool, err := s.findAuthorizedAgentPool(ctx, agentPoolID, orgManageAgentPools)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []*models.AuthenticationToken
+	if err := s.db.Where("kind = ? AND agent_pool_id = ?", models.TokenKindAgent, pool.ID).Order("created_at ASC").Find(&tokens).Error; err != nil {
+		s.logger.Error("failed to list agent tokens", zap.Error(err))
+		return nil, err
+	}
+
+	tfeTokens := make([]*tfe.AgentToken, len(tokens))
+	for i, t := range tokens {
+		tfeTokens[i] = t.ToTFEAgentToken()
+	}
+	return tfeTokens, nil
+}
+
+func (s *service) CreateAgentToken(ctx context.Context, agentPoolID string, options TokenCreateOptions) (*tfe.AgentToken, error) {
+	pool, err := s.findAuthorizedAgentPool(ctx, agentPoolID, orgManageAgentPools)
+	if err != nil {
+		return nil, err
+	}
+	if options.Description == "" {
+		return nil, fmt.Errorf("%w: description is required", ErrInvalidAttribute)
+	}
+
+	t := &models.AuthenticationToken{Kind: models.TokenKindAgent, AgentPoolID: &pool.ID}
+	token, err := s.createToken(ctx, t, options)
+	if err != nil {
+		return nil, err
+	}
+	tfeToken := t.ToTFEAgentToken()
+	s.audit(ctx, pool.OrganizationID, models.AuditActionCreate, nil, tfeToken)
+	tfeToken.Token = token
+	return tfeToken, nil
+}
+
+func (s *service) ReadAgentToken(ctx context.Context, tokenID string) (*tfe.AgentToken, error) {
+	t, err := s.findAgentToken(ctx, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	return t.ToTFEAgentToken(), nil
+}
+
+func (s *service) DeleteAgentToken(ctx context.Context, tokenID string) error {
+	t, err := s.findAgentToken(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(t).Error; err != nil {
+		s.logger.Error("failed to delete agent token", zap.Error(err))
+		return err
+	}
+	s.audit(ctx, t.AgentPool.OrganizationID, models.AuditActionDelete, t.ToTFEAgentToken(), nil)
+	return nil
+}
+
+func (s *service) ListAgents(ctx context.Context, agentPoolID string, options *tfe.AgentListOptions) ([]*tfe.Agent, *tfe.Pagination, error) {
+	pool, err := s.findAuthorizedAgentPool(ctx, agentPoolID, orgRead)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db := s.db.Model(&models.Agent{}).Where("agent_pool_id = ?", pool.ID)
+	var listOptions tfe.ListOptions
+	if options != nil {
+		listOptions = options.ListOptions
+		if !options.LastPingSince.IsZero() {
+			db = db.Where("last_ping_at >= ?", options.LastPingSince)
+		}
+	}
+
+	db, pagination, err := paginate(db, listOptions)
+	if err != nil {
+		s.logger.Error("failed to count agents", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var agents []*models.Agent
+	if err := db.Order("created_at ASC").Find(&agents).Error; err != nil {
+		s.logger.Error("failed to list agents", zap.Error(err))
+		return nil, nil, err
+	}
+
+	tfeAgents := make([]*tfe.Agent, len(agents))
+	for i, agent := range agents {
+		tfeAgents[i] = agent.ToTFE()
+	}
+	return tfeAgents, pagination, nil
+}
+
+func (s *service) ReadAgent(ctx context.Context, agentID string) (*tfe.Agent, error) {
+	agent, err := s.findAgent(agentID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.authorizeOrganization(ctx, agent.AgentPool.OrganizationID, orgRead); err != nil {
+		return nil, err
+	}
+	return agent.ToTFE(), nil
+}
+
+// assignWorkspaceAgentPool points the workspace at an agent pool of its
+// organization that serves it. An empty ID removes the pool.
+func (s *service) assignWorkspaceAgentPool(ws *models.Workspace, agentPoolID *string) error {
+	if agentPoolID == nil {
+		return nil
+	}
+	if *agentPoolID == "" {
+		ws.AgentPoolID = nil
+		return nil
+	}
+
+	pool, err := s.findAgentPool(*agentPoolID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && pool.OrganizationID != ws.OrganizationID) {
+		return fmt.Errorf("%w: agent pool %q does not exist in the workspace organization", ErrInvalidAttribute, *agentPoolID)
+	}
+	if err != nil {
+		return err
+	}
+	if !pool.OrganizationScoped && !containsWorkspace(pool.AllowedWorkspaces, ws.ID) {
+		return fmt.Errorf("%w: agent pool %s does not allow the workspace", ErrInvalidAttribute, pool.Name)
+	}
+	ws.AgentPoolID = &pool.ID
+	return nil
+}
+
+// agentPoolWorkspaces resolves workspace references and checks that they
+// belong to the organization of the pool.
+func (s *service) agentPoolWorkspaces(pool *models.AgentPool, refs []*tfe.Workspace) ([]*models.Workspace, error) {
+	ids := make([]uuid.UUID, 0, len(refs))
+	for _, ref := range refs {
+		id, err := uuid.Parse(ref.ID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: workspace %q does not exist", ErrInvalidAttribute, ref.ID)
+		}
+		ids = append(ids, id)
+	}
+
+	var workspaces []*models.Workspace
+	if err := s.db.Where("id IN ? AND organization_id = ?", ids, pool.OrganizationID).Find(&workspaces).Error; err != nil {
+		s.logger.Error("failed to read agent pool workspaces", zap.Error(err))
+		return nil, err
+	}
+	if len(workspaces) != len(ids) {
+		return nil, fmt.Errorf("%w: every allowed workspace must exist in the agent pool's organization", ErrInvalidAttribute)
+	}
+	return workspaces, nil
+}
+
+func (s *service) validateAgentPool(pool *models.AgentPool) error {
+	if pool.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidAttribute)
+	}
+
+	var existing models.AgentPool
+	err := s.db.Where("organization_id = ? AND name = ? AND id <> ?", pool.OrganizationID, pool.Name, pool.ID).First(&existing).Error
+	switch {
+	case err == nil:
+		return fmt.Errorf("%w: name %q has already been taken", ErrInvalidAttribute, pool.Name)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		s.logger.Error("failed to check agent pool name", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (s *service) findAgentPool(agentPoolID string) (*models.AgentPool, error) {
+	id, err := uuid.Parse(agentPoolID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var pool models.AgentPool
+	if err := preloadAgentPool(s.db).Where("id = ?", id).First(&pool).Error; err != nil {
+		s.logger.Error("failed to read agent pool", zap.Error(err))
+		return nil, err
+	}
+	return &pool, nil
+}
+
+// findAuthorizedAgentPool finds an agent pool and requires the caller to
+// hold required in its organization.
+func (s *service) findAuthorizedAgentPool(ctx context.Context, agentPoolID string, required organizationPermission) (*models.AgentPool, error) {
+	pool, err := s.findAgentPool(agentPoolID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.authorizeOrganization(ctx, pool.OrganizationID, required); err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+func (s *service) findAgentToken(ctx context.Context, tokenID string) (*models.AuthenticationToken, error) {
+	id, err := uuid.Parse(tokenID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var t models.AuthenticationToken
+	if err := s.db.Preload("AgentPool").Where("id = ? AND kind = ?", id, models.TokenKindAgent).First(&t).Error; err != nil {
+		s.logger.Debug("failed to read agent token", zap.Error(err))
+		return nil, err
+	}
+	if t.AgentPool == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if _, err := s.authorizeOrganization(ctx, t.AgentPool.OrganizationID, orgManageAgentPools); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (s *service) findAgent(agentID string) (*models.Agent, error) {
+	id, err := uuid.Parse(agentID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var agent models.Agent
+	if err := s.db.Preload("AgentPool").Where("id = ?", id).First(&agent).Error; err != nil {
+		s.logger.Debug("failed to read agent", zap.Error(err))
+		return nil, err
+	}
+	if agent.AgentPool == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &agent, nil
+}
+
+func replaceAllowedWorkspaces(tx *gorm.DB, pool *models.AgentPool, workspaces []*models.Workspace) error {
+	if err := tx.Where("agent_pool_id = ?", pool.ID).Delete(&models.AgentPoolAllowedWorkspace{}).Error; err != nil {
+		return err
+	}
+	for _, ws := range workspaces {
+		if err := tx.Create(&models.AgentPoolAllowedWorkspace{AgentPoolID: pool.ID, WorkspaceID: ws.ID}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func containsWorkspace(workspaces []*models.Workspace, id uuid.UUID) bool {
+	for _, ws := range workspaces {
+		if ws.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func preloadAgentPool(db *gorm.DB) *gorm.DB {
+	return db.Preload("Organization").Preload("Workspaces").Preload("AllowedWorkspaces").Preload("Agents")
+}
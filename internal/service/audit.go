@@ -0,0 +1,353 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/constants"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+)
+
+// Actor types of audit events that were not made with an API token.
+const (
+	auditActorUser   = "user"
+	auditActorSystem = "system"
+)
+
+// auditRedactedAttributes hold secrets. Their changes are recorded without
+// their values.
+var auditRedactedAttributes = map[string]bool{
+	"token":    true,
+	"hmac-key": true,
+	"password": true,
+	"secret":   true,
+}
+
+// auditRedacted replaces the values of redacted attributes.
+const auditRedacted = "[REDACTED]"
+
+// auditIgnoredAttributes say nothing about a mutation: they change with
+// every one, or describe the caller rather than the resource.
+var auditIgnoredAttributes = map[string]bool{
+	"updated-at":  true,
+	"permissions": true,
+}
+
+type tokenIDKey struct{}
+
+func tokenIDFromContext(ctx context.Context) *uuid.UUID {
+	id, ok := ctx.Value(tokenIDKey{}).(uuid.UUID)
+	if !ok {
+		return nil
+	}
+	return &id
+}
+
+// ListAuditTrail lists the audit events of the organization of the
+// calling organization or audit trail token, oldest first, optionally only
+// those after options.Since.
+func (s *service) ListAuditTrail(ctx context.Context, options *tfe.AuditTrailListOptions) ([]*models.AuditTrailEvent, *tfe.Pagination, error) {
+	p := tokenPrincipalFromContext(ctx)
+	if p == nil || (p.kind != models.TokenKindAuditTrail && p.kind != models.TokenKindOrganization) {
+		return nil, nil, fmt.Errorf("%w: the audit trail requires an organization or audit trail token", ErrForbidden)
+	}
+
+	db := s.db.Model(&models.AuditEvent{}).Where("organization_id = ?", p.organizationID)
+	var listOptions tfe.ListOptions
+	if options != nil {
+		if options.ListOptions != nil {
+			listOptions = *options.ListOptions
+		}
+		if !options.Since.IsZero() {
+			db = db.Where("created_at > ?", options.Since)
+		}
+	}
+	db, pagination, err := paginate(db, listOptions)
+	if err != nil {
+		s.logger.Error("failed to count audit events", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var events []*models.AuditEvent
+	if err := db.Order("created_at ASC, id ASC").Find(&events).Error; err != nil {
+		s.logger.Error("failed to list audit events", zap.Error(err))
+		return nil, nil, err
+	}
+
+	trail := make([]*models.AuditTrailEvent, len(events))
+	for i, event := range events {
+		trail[i] = event.ToTFE()
+	}
+	return trail, pagination, nil
+}
+
+// audit records a mutation of a resource of an organization. before and
+// after are the resource, in TFE format, before and after the mutation;
+// before is nil for created resources and after for deleted ones. secrets
+// name attributes that changed but are never part of the TFE format, such
+// as passwords; they are recorded as changed without their values. The
+// mutation has already happened, so failing to record it is logged rather
+// than returned.
+//
+// Every write of the API is audited. The executor and agent protocols
+// (claiming jobs, reporting plans and applies, run logs, heartbeats and run
+// task callbacks) are not: they carry out runs that are audited already.
+func (s *service) audit(ctx context.Context, orgID uuid.UUID, action string, before, after interface{}, secrets ...string) {
+	s.recordAudit(ctx, []uuid.UUID{orgID}, action, before, after, secrets...)
+}
+
+// auditWorkspace records a mutation of a resource of a workspace in the
+// audit trail of the workspace's organization.
+func (s *service) auditWorkspace(ctx context.Context, workspaceID uuid.UUID, action string, before, after interface{}, secrets ...string) {
+	var ws models.Workspace
+	if err := s.db.Unscoped().Select("organization_id").Where("id = ?", workspaceID).First(&ws).Error; err != nil {
+		s.logger.Error("failed to read workspace organization", zap.Error(err))
+		s.recordAudit(ctx, nil, action, before, after, secrets...)
+		return
+	}
+	s.audit(ctx, ws.OrganizationID, action, before, after, secrets...)
+}
+
+// auditUser records a mutation of a user, or of a resource owned by a
+// user, in the audit trail of every organization the user is a member of.
+func (s *service) auditUser(ctx context.Context, userID uuid.UUID, action string, before, after interface{}, secrets ...string) {
+	var orgIDs []uuid.UUID
+	if err := s.db.Model(&models.OrganizationMembership{}).
+		Where("user_id = ?", userID).
+		Distinct().Pluck("organization_id", &orgIDs).Error; err != nil {
+		s.logger.Error("failed to list user organizations", zap.Error(err))
+	}
+	s.recordAudit(ctx, orgIDs, action, before, after, secrets...)
+}
+
+// recordAudit appends an audit event for the mutation to the trail of each
+// organization. Mutations outside any organization are still recorded,
+// without an organization.
+func (s *service) recordAudit(ctx context.Context, orgIDs []uuid.UUID, action string, before, after interface{}, secrets ...string) {
+	resourceType, resourceID, changes, err := auditChanges(before, after)
+	if err != nil {
+		s.logger.Error("failed to compute audit changes", zap.String("action", action), zap.Error(err))
+		return
+	}
+	for _, key := range secrets {
+		changes[key] = models.AuditChange{Before: auditRedacted, After: auditRedacted}
+	}
+
+	actorType, actorID, actorName := s.auditActor(ctx)
+	requestID, _ := ctx.Value(constants.RequestIDKey).(string)
+	ipAddress, _ := ctx.Value(constants.ClientIPKey).(string)
+	newEvent := func(orgID *uuid.UUID) *models.AuditEvent {
+		return &models.AuditEvent{
+			CreatedAt:      time.Now(),
+			OrganizationID: orgID,
+			ActorType:      actorType,
+			ActorID:        actorID,
+			ActorName:      actorName,
+			TokenID:        tokenIDFromContext(ctx),
+			IPAddress:      ipAddress,
+			RequestID:      requestID,
+			ResourceType:   resourceType,
+			ResourceID:     resourceID,
+			Action:         action,
+			Changes:        changes,
+		}
+	}
+
+	events := make([]*models.AuditEvent, 0, len(orgIDs))
+	for i := range orgIDs {
+		events = append(events, newEvent(&orgIDs[i]))
+	}
+	if len(events) == 0 {
+		events = append(events, newEvent(nil))
+	}
+	if err := s.db.Create(&events).Error; err != nil {
+		s.logger.Error("failed to record audit event",
+			zap.String("resource_type", resourceType),
+			zap.String("resource_id", resourceID),
+			zap.String("action", action),
+			zap.Error(err),
+		)
+	}
+}
+
+// auditActor describes who is making the request: the system, the owner
+// of an API token, or a user.
+func (s *service) auditActor(ctx context.Context) (actorType, actorID, actorName string) {
+	if isSystemContext(ctx) {
+		return auditActorSystem, "", auditActorSystem
+	}
+	if p := tokenPrincipalFromContext(ctx); p != nil {
+		actorType = p.kind + "-token"
+		if id := tokenIDFromContext(ctx); id != nil {
+			actorID = id.String()
+		}
+		switch {
+		case p.team != nil:
+			actorName = p.team.Name
+		case p.agentPoolID != uuid.Nil:
+			actorName = p.agentPoolID.String()
+		default:
+			actorName = p.organizationID.String()
+		}
+		return actorType, actorID, actorName
+	}
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		email, _ := ctx.Value(constants.UserEmailKey).(string)
+		return auditActorUser, "", email
+	}
+	return auditActorUser, user.ID.String(), user.Username
+}
+
+// auditChanges compares the JSON:API attributes and relationships of a
+// resource before and after a mutation and returns the resource's type and
+// ID with the ones that changed.
+func auditChanges(before, after interface{}) (resourceType, resourceID string, changes map[string]models.AuditChange, err error) {
+	beforeType, beforeID, beforeAttributes, err := auditAttributes(before)
+	if err != nil {
+		return "", "", nil, err
+	}
+	afterType, afterID, afterAttributes, err := auditAttributes(after)
+	if err != nil {
+		return "", "", nil, err
+	}
+	resourceType, resourceID = afterType, afterID
+	if resourceType == "" {
+		resourceType, resourceID = beforeType, beforeID
+	}
+	if resourceType == "" {
+		return "", "", nil, fmt.Errorf("audited resource has no JSON:API type")
+	}
+
+	changes = make(map[string]models.AuditChange)
+	record := func(key string) {
+		if auditIgnoredAttributes[key] {
+			return
+		}
+		if _, seen := changes[key]; seen {
+			return
+		}
+		b, inBefore := beforeAttributes[key]
+		a, inAfter := afterAttributes[key]
+		if inBefore && inAfter && reflect.DeepEqual(b, a) {
+			return
+		}
+		if auditRedactedAttributes[key] {
+			if inBefore && b != nil && b != "" {
+				b = auditRedacted
+			}
+			if inAfter && a != nil && a != "" {
+				a = auditRedacted
+			}
+		}
+		changes[key] = models.AuditChange{Before: b, After: a}
+	}
+	for key := range beforeAttributes {
+		record(key)
+	}
+	for key := range afterAttributes {
+		record(key)
+	}
+	return resourceType, resourceID, changes, nil
+}
+
+// auditAttributes returns the JSON:API type, ID and attributes of v, a
+// resource in TFE format. A nil v has none. The struct tags are read
+// directly rather than through jsonapi.Marshal, which drops every field
+// following an empty polymorphic relation.
+func auditAttributes(v interface{}) (string, string, map[string]interface{}, error) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || (rv.Kind() == reflect.Ptr && rv.IsNil()) {
+		return "", "", nil, nil
+	}
+	rv = reflect.Indirect(rv)
+	if rv.Kind() != reflect.Struct {
+		return "", "", nil, fmt.Errorf("audited resource %T is not a JSON:API resource", v)
+	}
+
+	var resourceType, resourceID string
+	attributes := make(map[string]interface{})
+	for i := 0; i < rv.NumField(); i++ {
+		args := strings.Split(rv.Type().Field(i).Tag.Get("jsonapi"), ",")
+		if len(args) < 2 {
+			continue
+		}
+		field := rv.Field(i)
+		switch args[0] {
+		case "primary":
+			resourceType, resourceID = args[1], fmt.Sprint(field.Interface())
+		case "attr":
+			// Round trip the attribute so that it compares as it is
+			// reported.
+			raw, err := json.Marshal(field.Interface())
+			if err != nil {
+				return "", "", nil, err
+			}
+			var value interface{}
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return "", "", nil, err
+			}
+			attributes[args[1]] = value
+		case "relation", "polyrelation":
+			// Relationships are compared by the IDs they point to, so
+			// that attaching and detaching resources shows up as a
+			// change.
+			attributes[args[1]] = auditRelationship(field)
+		}
+	}
+	if resourceType == "" {
+		return "", "", nil, fmt.Errorf("audited resource %T has no JSON:API type", v)
+	}
+	return resourceType, resourceID, attributes, nil
+}
+
+// auditRelationship returns the ID a to-one relationship points to, or the
+// sorted IDs of a to-many relationship. Polymorphic relationships point to
+// the first resource set in their choice struct.
+func auditRelationship(field reflect.Value) interface{} {
+	if field.Kind() == reflect.Slice {
+		ids := []interface{}{}
+		for i := 0; i < field.Len(); i++ {
+			if id, ok := auditPrimaryID(field.Index(i)); ok {
+				ids = append(ids, id)
+			}
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i].(string) < ids[j].(string) })
+		return ids
+	}
+	if id, ok := auditPrimaryID(field); ok {
+		return id
+	}
+	if field.Kind() == reflect.Ptr && !field.IsNil() && field.Elem().Kind() == reflect.Struct {
+		choice := field.Elem()
+		for i := 0; i < choice.NumField(); i++ {
+			if id, ok := auditPrimaryID(choice.Field(i)); ok {
+				return id
+			}
+		}
+	}
+	return nil
+}
+
+// auditPrimaryID returns the primary ID of v, a pointer to a resource in
+// TFE format.
+func auditPrimaryID(v reflect.Value) (string, bool) {
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return "", false
+	}
+	v = v.Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if strings.HasPrefix(v.Type().Field(i).Tag.Get("jsonapi"), "primary,") {
+			return fmt.Sprint(v.Field(i).Interface()), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,407 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/constants"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// tokenLastUsedInterval limits how often last-used timestamps are written,
+// so that authenticating a request does not always cost a write.
+const tokenLastUsedInterval = time.Minute
+
+// TokenCreateOptions are the options shared by every kind of API token.
+type TokenCreateOptions struct {
+	Description string
+	ExpiredAt   *time.Time
+}
+
+// tokenPrincipal is the identity of a request authenticated by a team,
+// organization, audit trail or agent token. User tokens authenticate as
+// their user.
+type tokenPrincipal struct {
+	kind           string
+	organizationID uuid.UUID
+	team           *models.Team
+	agentPoolID    uuid.UUID
+}
+
+type tokenPrincipalKey struct{}
+
+func tokenPrincipalFromContext(ctx context.Context) *tokenPrincipal {
+	p, _ := ctx.Value(tokenPrincipalKey{}).(*tokenPrincipal)
+	return p
+}
+
+// AuthenticateToken resolves an opaque API token of the form
+// <id>.atlasv1.<secret> and returns ctx carrying the token's principal.
+func (s *service) AuthenticateToken(ctx context.Context, token string) (context.Context, error) {
+	rawID, secret, ok := strings.Cut(token, constants.APITokenSeparator)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var t models.AuthenticationToken
+	if err := s.db.Preload("User").Preload("Team").Preload("AgentPool").Where("id = ?", id).First(&t).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidToken
+		}
+		s.logger.Error("failed to read authentication token", zap.Error(err))
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(t.SecretHash)) != 1 || t.Expired() {
+		return nil, ErrInvalidToken
+	}
+
+	if t.LastUsedAt == nil || time.Since(*t.LastUsedAt) > tokenLastUsedInterval {
+		if err := s.db.Model(&t).UpdateColumn("last_used_at", time.Now()).Error; err != nil {
+			s.logger.Warn("failed to record token use", zap.Error(err))
+		}
+	}
+
+	// The token is recorded in the audit trail of the request's mutations.
+	ctx = context.WithValue(ctx, tokenIDKey{}, t.ID)
+	switch t.Kind {
+	case models.TokenKindUser:
+		if t.User == nil {
+			return nil, ErrInvalidToken
+		}
+		return context.WithValue(ctx, constants.UserEmailKey, t.User.Email), nil
+	case models.TokenKindTeam:
+		if t.Team == nil {
+			return nil, ErrInvalidToken
+		}
+		return context.WithValue(ctx, tokenPrincipalKey{}, &tokenPrincipal{
+			kind:           t.Kind,
+			organizationID: t.Team.OrganizationID,
+			team:           t.Team,
+		}), nil
+	case models.TokenKindOrganization, models.TokenKindAuditTrail:
+		if t.OrganizationID == nil {
+			return nil, ErrInvalidToken
+		}
+		return context.WithValue(ctx, tokenPrincipalKey{}, &tokenPrincipal{
+			kind:           t.Kind,
+			organizationID: *t.OrganizationID,
+		}), nil
+	case models.TokenKindAgent:
+		if t.AgentPool == nil {
+			return nil, ErrInvalidToken
+		}
+		return context.WithValue(ctx, tokenPrincipalKey{}, &tokenPrincipal{
+			kind:           t.Kind,
+			organizationID: t.AgentPool.OrganizationID,
+			agentPoolID:    t.AgentPool.ID,
+		}), nil
+	default:
+		return nil, ErrInvalidToken
+	}
+}
+
+func (s *service) ListUserTokens(ctx context.Context, userID string) ([]*tfe.UserToken, error) {
+	id, err := s.authorizeUserTokens(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []*models.AuthenticationToken
+	if err := s.db.Where("kind = ? AND user_id = ?", models.TokenKindUser, id).Order("created_at ASC").Find(&tokens).Error; err != nil {
+		s.logger.Error("failed to list user tokens", zap.Error(err))
+		return nil, err
+	}
+
+	tfeTokens := make([]*tfe.UserToken, len(tokens))
+	for i, t := range tokens {
+		tfeTokens[i] = t.ToTFEUserToken()
+	}
+	return tfeTokens, nil
+}
+
+func (s *service) CreateUserToken(ctx context.Context, userID string, options TokenCreateOptions) (*tfe.UserToken, error) {
+	id, err := s.authorizeUserTokens(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &models.AuthenticationToken{Kind: models.TokenKindUser, UserID: &id}
+	token, err := s.createToken(ctx, t, options)
+	if err != nil {
+		return nil, err
+	}
+	tfeToken := t.ToTFEUserToken()
+	s.auditUser(ctx, id, models.AuditActionCreate, nil, tfeToken)
+	tfeToken.Token = token
+	return tfeToken, nil
+}
+
+func (s *service) ReadUserToken(ctx context.Context, tokenID string) (*tfe.UserToken, error) {
+	t, err := s.findUserToken(ctx, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	return t.ToTFEUserToken(), nil
+}
+
+func (s *service) DeleteUserToken(ctx context.Context, tokenID string) error {
+	t, err := s.findUserToken(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(t).Error; err != nil {
+		s.logger.Error("failed to delete user token", zap.Error(err))
+		return err
+	}
+	s.auditUser(ctx, *t.UserID, models.AuditActionDelete, t.ToTFEUserToken(), nil)
+	return nil
+}
+
+// CreateTeamToken creates a team token, replacing any existing one.
+func (s *service) CreateTeamToken(ctx context.Context, teamID string, options TokenCreateOptions) (*tfe.TeamToken, error) {
+	team, err := s.findTokenTeam(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &models.AuthenticationToken{Kind: models.TokenKindTeam, TeamID: &team.ID}
+	token, err := s.createToken(ctx, t, options)
+	if err != nil {
+		return nil, err
+	}
+	tfeToken := t.ToTFETeamToken()
+	s.audit(ctx, team.OrganizationID, models.AuditActionCreate, nil, tfeToken)
+	tfeToken.Token = token
+	return tfeToken, nil
+}
+
+func (s *service) ReadTeamToken(ctx context.Context, teamID string) (*tfe.TeamToken, error) {
+	team, err := s.findTokenTeam(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	var t models.AuthenticationToken
+	if err := s.db.Where("kind = ? AND team_id = ?", models.TokenKindTeam, team.ID).First(&t).Error; err != nil {
+		s.logger.Debug("failed to read team token", zap.Error(err))
+		return nil, err
+	}
+	return t.ToTFETeamToken(), nil
+}
+
+func (s *service) DeleteTeamToken(ctx context.Context, teamID string) error {
+	team, err := s.findTokenTeam(ctx, teamID)
+	if err != nil {
+		return err
+	}
+	tokens, err := s.deleteTokens(&models.AuthenticationToken{Kind: models.TokenKindTeam, TeamID: &team.ID})
+	if err != nil {
+		return err
+	}
+	for _, t := range tokens {
+		s.audit(ctx, team.OrganizationID, models.AuditActionDelete, t.ToTFETeamToken(), nil)
+	}
+	return nil
+}
+
+// CreateOrganizationToken creates an organization token, or an audit trail
+// token when tokenType is tfe.AuditTrailToken, replacing any existing token
+// of the same kind. Only owners may manage organization tokens.
+func (s *service) CreateOrganizationToken(ctx context.Context, organization string, tokenType *tfe.TokenType, options TokenCreateOptions) (*tfe.OrganizationToken, error) {
+	kind, err := organizationTokenKind(tokenType)
+	if err != nil {
+		return nil, err
+	}
+	role, err := s.authorizeOrganizationName(ctx, organization, orgManageSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &models.AuthenticationToken{Kind: kind, OrganizationID: &role.orgID}
+	token, err := s.createToken(ctx, t, options)
+	if err != nil {
+		return nil, err
+	}
+	tfeToken := t.ToTFEOrganizationToken()
+	s.audit(ctx, role.orgID, models.AuditActionCreate, nil, tfeToken)
+	tfeToken.Token = token
+	return tfeToken, nil
+}
+
+func (s *service) ReadOrganizationToken(ctx context.Context, organization string, tokenType *tfe.TokenType) (*tfe.OrganizationToken, error) {
+	kind, err := organizationTokenKind(tokenType)
+	if err != nil {
+		return nil, err
+	}
+	role, err := s.authorizeOrganizationName(ctx, organization, orgManageSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	var t models.AuthenticationToken
+	if err := s.db.Where("kind = ? AND organization_id = ?", kind, role.orgID).First(&t).Error; err != nil {
+		s.logger.Debug("failed to read organization token", zap.Error(err))
+		return nil, err
+	}
+	return t.ToTFEOrganizationToken(), nil
+}
+
+func (s *service) DeleteOrganizationToken(ctx context.Context, organization string, tokenType *tfe.TokenType) error {
+	kind, err := organizationTokenKind(tokenType)
+	if err != nil {
+		return err
+	}
+	role, err := s.authorizeOrganizationName(ctx, organization, orgManageSettings)
+	if err != nil {
+		return err
+	}
+	tokens, err := s.deleteTokens(&models.AuthenticationToken{Kind: kind, OrganizationID: &role.orgID})
+	if err != nil {
+		return err
+	}
+	for _, t := range tokens {
+		s.audit(ctx, role.orgID, models.AuditActionDelete, t.ToTFEOrganizationToken(), nil)
+	}
+	return nil
+}
+
+// createToken stores t with a new secret and returns the token value.
+// Singular kinds replace the existing token of their owner. The creator is
+// the current user unless t already names one.
+func (s *service) createToken(ctx context.Context, t *models.AuthenticationToken, options TokenCreateOptions) (string, error) {
+	if options.ExpiredAt != nil && !options.ExpiredAt.After(time.Now()) {
+		return "", fmt.Errorf("%w: expired-at must be in the future", ErrInvalidAttribute)
+	}
+	if t.CreatedByID == nil {
+		createdByID, err := s.currentUserID(ctx)
+		if err != nil {
+			return "", err
+		}
+		t.CreatedByID = createdByID
+	}
+	secret, err := generateSecret()
+	if err != nil {
+		return "", err
+	}
+
+	t.Description = options.Description
+	t.ExpiredAt = options.ExpiredAt
+	t.SecretHash = hashSecret(secret)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if t.Singular() {
+			if err := tx.Where(&models.AuthenticationToken{Kind: t.Kind, TeamID: t.TeamID, OrganizationID: t.OrganizationID}).
+				Delete(&models.AuthenticationToken{}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Omit("User", "Team", "Organization", "AgentPool").Create(t).Error
+	})
+	if err != nil {
+		s.logger.Error("failed to create authentication token", zap.Error(err))
+		return "", err
+	}
+	return strings.ReplaceAll(t.ID.String(), "-", "") + constants.APITokenSeparator + secret, nil
+}
+
+// deleteTokens revokes the tokens matching the non-zero fields of where
+// and returns them.
+func (s *service) deleteTokens(where *models.AuthenticationToken) ([]*models.AuthenticationToken, error) {
+	var tokens []*models.AuthenticationToken
+	if err := s.db.Where(where).Find(&tokens).Error; err != nil {
+		s.logger.Error("failed to read authentication tokens", zap.Error(err))
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	ids := make([]uuid.UUID, len(tokens))
+	for i, t := range tokens {
+		ids[i] = t.ID
+	}
+	if err := s.db.Where("id IN ?", ids).Delete(&models.AuthenticationToken{}).Error; err != nil {
+		s.logger.Error("failed to delete authentication token", zap.Error(err))
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func organizationTokenKind(tokenType *tfe.TokenType) (string, error) {
+	switch {
+	case tokenType == nil || *tokenType == "":
+		return models.TokenKindOrganization, nil
+	case *tokenType == tfe.AuditTrailToken:
+		return models.TokenKindAuditTrail, nil
+	default:
+		return "", fmt.Errorf("%w: token type %q is not supported", ErrInvalidAttribute, *tokenType)
+	}
+}
+
+// authorizeUserTokens lets users manage their own tokens and site admins
+// manage anyone's. Other callers cannot see the user's tokens at all.
+func (s *service) authorizeUserTokens(ctx context.Context, userID string) (uuid.UUID, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return uuid.Nil, gorm.ErrRecordNotFound
+	}
+	if isSystemContext(ctx) {
+		return id, nil
+	}
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if user.ID != id && !user.IsSiteAdmin {
+		return uuid.Nil, gorm.ErrRecordNotFound
+	}
+	return id, nil
+}
+
+func (s *service) findUserToken(ctx context.Context, tokenID string) (*models.AuthenticationToken, error) {
+	id, err := uuid.Parse(tokenID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var t models.AuthenticationToken
+	if err := s.db.Where("id = ? AND kind = ?", id, models.TokenKindUser).First(&t).Error; err != nil {
+		s.logger.Debug("failed to read user token", zap.Error(err))
+		return nil, err
+	}
+	if _, err := s.authorizeUserTokens(ctx, t.UserID.String()); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// findTokenTeam loads a team whose token the caller may manage: those who
+// manage teams, and members of teams that allow member token management.
+func (s *service) findTokenTeam(ctx context.Context, teamID string) (*models.Team, error) {
+	team, err := s.findTeam(teamID)
+	if err != nil {
+		return nil, err
+	}
+	role, err := s.authorizeOrganization(ctx, team.OrganizationID, orgRead)
+	if err != nil {
+		return nil, err
+	}
+	if !role.canSeeTeam(team) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if role.can(orgManageTeams) || (team.AllowMemberTokenManagement && role.isTeamMember(team.ID)) {
+		return team, nil
+	}
+	return nil, fmt.Errorf("%w: insufficient permissions to manage the team token", ErrForbidden)
+}
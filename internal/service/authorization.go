@@ -0,0 +1,526 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Authorization follows Terraform Enterprise. Callers that may not see a
+// resource get gorm.ErrRecordNotFound, so that its existence is not
+// revealed, and callers that may see it but not act on it get ErrForbidden.
+//
+// Permissions are granted by organization membership: the owners team holds
+// every permission, other teams hold the organization-level permissions of
+// their OrganizationAccess, and team workspace and project accesses grant
+// permissions on individual workspaces and projects.
+
+type systemContextKey struct{}
+
+// SystemContext marks ctx as coming from a trusted internal caller, such as
+// the run worker or a request authenticated by a signed URL, for which
+// authorization is skipped.
+func SystemContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, systemContextKey{}, true)
+}
+
+func isSystemContext(ctx context.Context) bool {
+	system, _ := ctx.Value(systemContextKey{}).(bool)
+	return system
+}
+
+// organizationPermission is a set of permissions in an organization.
+type organizationPermission uint32
+
+const (
+	orgRead organizationPermission = 1 << iota
+	// orgManageSettings covers the organization itself and is held by owners only.
+	orgManageSettings
+	orgManagePolicies
+	orgManagePolicyOverrides
+	orgManageWorkspaces
+	orgManageVCSSettings
+	orgManageProviders
+	orgManageModules
+	orgManageRunTasks
+	orgManageProjects
+	orgReadWorkspaces
+	orgReadProjects
+	orgManageMembership
+	orgManageTeams
+	orgManageOrganizationAccess
+	orgAccessSecretTeams
+	orgManageAgentPools
+
+	orgAll = 1<<iota - 1
+)
+
+// workspacePermission is a set of permissions on a workspace.
+type workspacePermission uint32
+
+const (
+	wsRead workspacePermission = 1 << iota
+	wsQueuePlan
+	wsApplyRun
+	wsReadVariables
+	wsWriteVariables
+	wsReadStateOutputs
+	wsReadState
+	wsWriteState
+	wsReadSentinelMocks
+	wsLock
+	wsManageRunTasks
+	wsMove
+	wsDelete
+	// wsAdmin covers settings, force unlocking and team access.
+	wsAdmin
+
+	wsAll = 1<<iota - 1
+
+	wsReadLevel = wsRead | wsReadVariables | wsReadStateOutputs | wsReadState
+)
+
+// projectPermission is a set of permissions on a project.
+type projectPermission uint32
+
+const (
+	projectRead projectPermission = 1 << iota
+	projectUpdate
+	projectDelete
+	projectReadTeams
+	projectManageTeams
+	projectCreateWorkspace
+
+	projectAll = 1<<iota - 1
+)
+
+// organizationRole is what the caller holds in one organization.
+type organizationRole struct {
+	orgID       uuid.UUID
+	permissions organizationPermission
+	teamIDs     []uuid.UUID
+}
+
+func (r *organizationRole) can(p organizationPermission) bool {
+	return r.permissions&p == p
+}
+
+// authorizeSiteAdmin requires the caller to be a site admin or the system.
+func (s *service) authorizeSiteAdmin(ctx context.Context) error {
+	if isSystemContext(ctx) {
+		return nil
+	}
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+	if !user.IsSiteAdmin {
+		return fmt.Errorf("%w: site admin access required", ErrForbidden)
+	}
+	return nil
+}
+
+// organizationRole resolves the caller's role in an organization. Only
+// active members hold permissions; system callers and site admins hold
+// every permission.
+func (s *service) organizationRole(ctx context.Context, orgID uuid.UUID) (*organizationRole, error) {
+	role := &organizationRole{orgID: orgID}
+	if isSystemContext(ctx) {
+		role.permissions = orgAll
+		return role, nil
+	}
+
+	if p := tokenPrincipalFromContext(ctx); p != nil {
+		if p.organizationID == orgID {
+			role.permissions, role.teamIDs = p.organizationPermissions()
+		}
+		return role, nil
+	}
+
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsSiteAdmin {
+		role.permissions = orgAll
+		return role, nil
+	}
+
+	var members int64
+	if err := s.db.Model(&models.OrganizationMembership{}).
+		Where("organization_id = ? AND user_id = ? AND status = ?", orgID, user.ID, tfe.OrganizationMembershipActive).
+		Count(&members).Error; err != nil {
+		s.logger.Error("failed to read organization membership", zap.Error(err))
+		return nil, err
+	}
+	if members == 0 {
+		return role, nil
+	}
+	role.permissions = orgRead
+
+	var teams []*models.Team
+	if err := s.db.Where("organization_id = ? AND id IN (?)", orgID,
+		s.db.Model(&models.TeamUser{}).Select("team_id").Where("user_id = ?", user.ID)).
+		Find(&teams).Error; err != nil {
+		s.logger.Error("failed to read user teams", zap.Error(err))
+		return nil, err
+	}
+	for _, team := range teams {
+		role.teamIDs = append(role.teamIDs, team.ID)
+		if team.IsOwners() {
+			role.permissions = orgAll
+		}
+		role.permissions |= organizationAccessPermissions(team.OrganizationAccess)
+	}
+	return role, nil
+}
+
+// organizationPermissions are the permissions a token holds in its own
+// organization. Team tokens act as the team, organization tokens hold every
+// permission, and audit trail and agent tokens hold none.
+func (p *tokenPrincipal) organizationPermissions() (organizationPermission, []uuid.UUID) {
+	switch p.kind {
+	case models.TokenKindOrganization:
+		return orgAll, nil
+	case models.TokenKindTeam:
+		if p.team.IsOwners() {
+			return orgAll, []uuid.UUID{p.team.ID}
+		}
+		return orgRead | organizationAccessPermissions(p.team.OrganizationAccess), []uuid.UUID{p.team.ID}
+	default:
+		return 0, nil
+	}
+}
+
+func organizationAccessPermissions(access *models.OrganizationAccess) organizationPermission {
+	if access == nil {
+		return 0
+	}
+	var p organizationPermission
+	for _, grant := range []struct {
+		held       bool
+		permission organizationPermission
+	}{
+		{access.ManagePolicies, orgManagePolicies},
+		{access.ManagePolicyOverrides, orgManagePolicyOverrides},
+		{access.ManageWorkspaces, orgManageWorkspaces},
+		{access.ManageVCSSettings, orgManageVCSSettings},
+		{access.ManageProviders, orgManageProviders},
+		{access.ManageModules, orgManageModules},
+		{access.ManageRunTasks, orgManageRunTasks},
+		{access.ManageProjects, orgManageProjects},
+		{access.ReadWorkspaces, orgReadWorkspaces},
+		{access.ReadProjects, orgReadProjects},
+		{access.ManageMembership, orgManageMembership},
+		{access.ManageTeams, orgManageTeams},
+		{access.ManageOrganizationAccess, orgManageOrganizationAccess},
+		{access.AccessSecretTeams, orgAccessSecretTeams},
+		{access.ManageAgentPools, orgManageAgentPools},
+	} {
+		if grant.held {
+			p |= grant.permission
+		}
+	}
+	return p
+}
+
+// authorizeOrganization requires the caller to be a member of the
+// organization holding every permission in required.
+func (s *service) authorizeOrganization(ctx context.Context, orgID uuid.UUID, required organizationPermission) (*organizationRole, error) {
+	role, err := s.organizationRole(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if !role.can(orgRead) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if !role.can(required) {
+		return nil, fmt.Errorf("%w: insufficient organization permissions", ErrForbidden)
+	}
+	return role, nil
+}
+
+// authorizeOrganizationName is authorizeOrganization for an organization
+// identified by name.
+func (s *service) authorizeOrganizationName(ctx context.Context, organization string, required organizationPermission) (*organizationRole, error) {
+	orgID, err := s.GetOrganizationIDByName(ctx, organization)
+	if err != nil {
+		return nil, err
+	}
+	return s.authorizeOrganization(ctx, orgID, required)
+}
+
+// workspacePermissions are the permissions the role grants on every
+// workspace of the organization.
+func (r *organizationRole) workspacePermissions() workspacePermission {
+	switch {
+	case r.can(orgManageWorkspaces), r.can(orgManageProjects):
+		return wsAll
+	case r.can(orgReadWorkspaces):
+		return wsReadLevel
+	default:
+		return 0
+	}
+}
+
+// projectPermissions resolves the permissions of role on a project, and the
+// permissions it grants on every workspace in the project.
+func (s *service) projectPermissions(role *organizationRole, projectID uuid.UUID) (projectPermission, workspacePermission, error) {
+	var project projectPermission
+	workspace := role.workspacePermissions()
+	switch {
+	case role.can(orgManageProjects):
+		project = projectAll
+	case role.can(orgManageWorkspaces):
+		project = projectRead | projectCreateWorkspace
+	case role.can(orgReadProjects):
+		project = projectRead
+	}
+	if project == projectAll || len(role.teamIDs) == 0 {
+		return project, workspace, nil
+	}
+
+	var accesses []*models.TeamProjectAccess
+	if err := s.db.Where("project_id = ? AND team_id IN ?", projectID, role.teamIDs).Find(&accesses).Error; err != nil {
+		s.logger.Error("failed to read team project access", zap.Error(err))
+		return 0, 0, err
+	}
+	for _, a := range accesses {
+		p, w := teamProjectAccessPermissions(a)
+		project |= p
+		workspace |= w
+	}
+	return project, workspace, nil
+}
+
+func teamProjectAccessPermissions(a *models.TeamProjectAccess) (projectPermission, workspacePermission) {
+	project := projectRead
+	switch tfe.ProjectSettingsPermissionType(a.ProjectSettings) {
+	case tfe.ProjectSettingsPermissionDelete:
+		project |= projectUpdate | projectDelete
+	case tfe.ProjectSettingsPermissionUpdate:
+		project |= projectUpdate
+	}
+	switch tfe.ProjectTeamsPermissionType(a.ProjectTeams) {
+	case tfe.ProjectTeamsPermissionManage:
+		project |= projectReadTeams | projectManageTeams
+	case tfe.ProjectTeamsPermissionRead:
+		project |= projectReadTeams
+	}
+	if a.WorkspaceCreate {
+		project |= projectCreateWorkspace
+	}
+
+	workspace := workspaceAccessPermissions(a.WorkspaceRuns, a.WorkspaceVariables, a.WorkspaceStateVersions, a.WorkspaceSentinelMocks, a.WorkspaceLocking, a.WorkspaceRunTasks)
+	if a.WorkspaceMove {
+		workspace |= wsMove
+	}
+	if a.WorkspaceDelete {
+		workspace |= wsDelete
+	}
+	switch tfe.TeamProjectAccessType(a.Access) {
+	case tfe.TeamProjectAccessMaintain, tfe.TeamProjectAccessAdmin:
+		workspace = wsAll
+	}
+	return project, workspace
+}
+
+// workspaceAccessPermissions translates the custom permissions shared by
+// team workspace and project accesses. Every access grants reading the
+// workspace and its runs.
+func workspaceAccessPermissions(runs, variables, stateVersions, sentinelMocks string, locking, runTasks bool) workspacePermission {
+	p := wsRead
+	switch runs {
+	case string(tfe.RunsPermissionApply):
+		p |= wsQueuePlan | wsApplyRun
+	case string(tfe.RunsPermissionPlan):
+		p |= wsQueuePlan
+	}
+	switch variables {
+	case string(tfe.VariablesPermissionWrite):
+		p |= wsReadVariables | wsWriteVariables
+	case string(tfe.VariablesPermissionRead):
+		p |= wsReadVariables
+	}
+	switch stateVersions {
+	case string(tfe.StateVersionsPermissionWrite):
+		p |= wsReadStateOutputs | wsReadState | wsWriteState
+	case string(tfe.StateVersionsPermissionRead):
+		p |= wsReadStateOutputs | wsReadState
+	case string(tfe.StateVersionsPermissionReadOutputs):
+		p |= wsReadStateOutputs
+	}
+	if sentinelMocks == string(tfe.SentinelMocksPermissionRead) {
+		p |= wsReadSentinelMocks
+	}
+	if locking {
+		p |= wsLock
+	}
+	if runTasks {
+		p |= wsManageRunTasks
+	}
+	return p
+}
+
+// workspacePermissions resolves the permissions of role on a workspace from
+// the organization, its project and the workspace itself.
+func (s *service) workspacePermissions(role *organizationRole, ws *models.Workspace) (workspacePermission, error) {
+	_, permissions, err := s.projectPermissions(role, ws.ProjectID)
+	if err != nil {
+		return 0, err
+	}
+	if permissions == wsAll || len(role.teamIDs) == 0 {
+		return permissions, nil
+	}
+
+	var accesses []*models.TeamWorkspaceAccess
+	if err := s.db.Where("workspace_id = ? AND team_id IN ?", ws.ID, role.teamIDs).Find(&accesses).Error; err != nil {
+		s.logger.Error("failed to read team workspace access", zap.Error(err))
+		return 0, err
+	}
+	for _, a := range accesses {
+		permissions |= workspaceAccessPermissions(a.Runs, a.Variables, a.StateVersions, a.SentinelMocks, a.WorkspaceLocking, a.RunTasks)
+		if tfe.AccessType(a.Access) == tfe.AccessAdmin {
+			permissions = wsAll
+		}
+	}
+	return permissions, nil
+}
+
+// authorizeWorkspace requires the caller to hold every permission in
+// required on the workspace, and returns all of the caller's permissions.
+func (s *service) authorizeWorkspace(ctx context.Context, ws *models.Workspace, required workspacePermission) (workspacePermission, error) {
+	role, err := s.organizationRole(ctx, ws.OrganizationID)
+	if err != nil {
+		return 0, err
+	}
+	if !role.can(orgRead) {
+		return 0, gorm.ErrRecordNotFound
+	}
+	permissions, err := s.workspacePermissions(role, ws)
+	if err != nil {
+		return 0, err
+	}
+	if permissions&wsRead == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	if permissions&required != required {
+		return 0, fmt.Errorf("%w: insufficient workspace permissions", ErrForbidden)
+	}
+	return permissions, nil
+}
+
+// authorizeWorkspaceID is authorizeWorkspace for a workspace identified by ID.
+func (s *service) authorizeWorkspaceID(ctx context.Context, workspaceID uuid.UUID, required workspacePermission) error {
+	var ws models.Workspace
+	if err := s.db.Where("id = ?", workspaceID).First(&ws).Error; err != nil {
+		s.logger.Error("failed to read workspace", zap.Error(err))
+		return err
+	}
+	_, err := s.authorizeWorkspace(ctx, &ws, required)
+	return err
+}
+
+// authorizeProject requires the caller to hold every permission in required
+// on the project.
+func (s *service) authorizeProject(ctx context.Context, project *models.Project, required projectPermission) error {
+	role, err := s.organizationRole(ctx, project.OrganizationID)
+	if err != nil {
+		return err
+	}
+	if !role.can(orgRead) {
+		return gorm.ErrRecordNotFound
+	}
+	permissions, _, err := s.projectPermissions(role, project.ID)
+	if err != nil {
+		return err
+	}
+	if permissions&projectRead == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	if permissions&required != required {
+		return fmt.Errorf("%w: insufficient project permissions", ErrForbidden)
+	}
+	return nil
+}
+
+// readableWorkspaces limits a workspace query to the workspaces role may
+// read. Workspaces are granted through the organization, team project
+// accesses or team workspace accesses.
+func (s *service) readableWorkspaces(db *gorm.DB, role *organizationRole) *gorm.DB {
+	if role.workspacePermissions()&wsRead != 0 {
+		return db
+	}
+	if len(role.teamIDs) == 0 {
+		return db.Where("1 = 0")
+	}
+	return db.Where("(workspaces.id IN (?) OR workspaces.project_id IN (?))",
+		s.db.Model(&models.TeamWorkspaceAccess{}).Select("workspace_id").Where("team_id IN ?", role.teamIDs),
+		s.db.Model(&models.TeamProjectAccess{}).Select("project_id").Where("team_id IN ?", role.teamIDs))
+}
+
+// readableProjects limits a project query to the projects role may read.
+func (s *service) readableProjects(db *gorm.DB, role *organizationRole) *gorm.DB {
+	if role.can(orgReadProjects) || role.can(orgManageWorkspaces) || role.can(orgManageProjects) {
+		return db
+	}
+	if len(role.teamIDs) == 0 {
+		return db.Where("1 = 0")
+	}
+	return db.Where("projects.id IN (?)",
+		s.db.Model(&models.TeamProjectAccess{}).Select("project_id").Where("team_id IN ?", role.teamIDs))
+}
+
+// canSeeTeam reports whether role may see a team. Secret teams are only
+// visible to their members and to those who may access secret teams or
+// manage teams.
+func (r *organizationRole) canSeeTeam(team *models.Team) bool {
+	if team.Visibility == models.TeamVisibilityOrganization || r.canSeeSecretTeams() {
+		return true
+	}
+	return r.isTeamMember(team.ID)
+}
+
+// isTeamMember reports whether the caller acts as a member of a team.
+func (r *organizationRole) isTeamMember(teamID uuid.UUID) bool {
+	return slices.Contains(r.teamIDs, teamID)
+}
+
+func (r *organizationRole) canSeeSecretTeams() bool {
+	return r.can(orgAccessSecretTeams) || r.can(orgManageTeams)
+}
+
+// readableTeams limits a team query to the teams role may see.
+func (s *service) readableTeams(db *gorm.DB, role *organizationRole) *gorm.DB {
+	if role.canSeeSecretTeams() {
+		return db
+	}
+	if len(role.teamIDs) == 0 {
+		return db.Where("teams.visibility = ?", models.TeamVisibilityOrganization)
+	}
+	return db.Where("(teams.visibility = ? OR teams.id IN ?)", models.TeamVisibilityOrganization, role.teamIDs)
+}
+
+// workspacePermissionsToTFE reports the permissions of the caller on a
+// workspace the way clients expect them.
+func workspacePermissionsToTFE(p workspacePermission) *tfe.WorkspacePermissions {
+	return &tfe.WorkspacePermissions{
+		CanDestroy:        p&wsDelete != 0,
+		CanForceUnlock:    p&wsAdmin != 0,
+		CanLock:           p&wsLock != 0,
+		CanManageRunTasks: p&wsManageRunTasks != 0,
+		CanQueueApply:     p&wsApplyRun != 0,
+		CanQueueDestroy:   p&wsApplyRun != 0,
+		CanQueueRun:       p&wsQueuePlan != 0,
+		CanReadSettings:   p&wsRead != 0,
+		CanUnlock:         p&wsLock != 0,
+		CanUpdate:         p&wsAdmin != 0,
+		CanUpdateVariable: p&wsWriteVariables != 0,
+	}
+}
@@ -0,0 +1,403 @@
+package service
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	slug "github.com/hashicorp/go-slug"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Limits on uploaded configuration and module archives. Archives are
+// unpacked on the server to be checked, so both their compressed and their
+// unpacked size are bounded.
+const (
+	maxSlugSize         = 512 << 20
+	maxUnpackedSlugSize = 1 << 30
+	maxSlugEntries      = 100000
+)
+
+// errInvalidSlug is wrapped by the errors of archives that are rejected.
+var errInvalidSlug = errors.New("invalid archive")
+
+func (s *service) ListConfigurationVersions(ctx context.Context, workspaceID string, options *tfe.ConfigurationVersionListOptions) ([]*tfe.ConfigurationVersion, *tfe.Pagination, error) {
+	ws, err := s.findWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, ws, wsRead); err != nil {
+		return nil, nil, err
+	}
+
+	var listOptions tfe.ListOptions
+	if options != nil {
+		listOptions = options.ListOptions
+	}
+	db, pagination, err := paginate(s.db.Model(&models.ConfigurationVersion{}).Where("workspace_id = ?", ws.ID), listOptions)
+	if err != nil {
+		s.logger.Error("failed to count configuration versions", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var cvs []*models.ConfigurationVersion
+	if err := db.Preload("IngressAttributes").Order("created_at DESC").Find(&cvs).Error; err != nil {
+		s.logger.Error("failed to list configuration versions", zap.Error(err))
+		return nil, nil, err
+	}
+
+	tfeCVs := make([]*tfe.ConfigurationVersion, len(cvs))
+	for i, cv := range cvs {
+		tfeCVs[i] = cv.ToTFE()
+	}
+	return tfeCVs, pagination, nil
+}
+
+func (s *service) CreateConfigurationVersion(ctx context.Context, workspaceID string, options tfe.ConfigurationVersionCreateOptions, ingress *tfe.IngressAttributes) (*tfe.ConfigurationVersion, error) {
+	createdByID, err := s.currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ws, err := s.findWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, ws, wsQueuePlan); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	cv := &models.ConfigurationVersion{
+		Status:            string(tfe.ConfigurationPending),
+		Source:            string(tfe.ConfigurationSourceAPI),
+		AutoQueueRuns:     true,
+		WorkspaceID:       ws.ID,
+		CreatedByID:       createdByID,
+		QueuedAt:          &now,
+		IngressAttributes: models.FromTFEIngressAttributes(ingress),
+	}
+	if options.AutoQueueRuns != nil {
+		cv.AutoQueueRuns = *options.AutoQueueRuns
+	}
+	if options.Speculative != nil {
+		cv.Speculative = *options.Speculative
+	}
+	if options.Provisional != nil {
+		cv.Provisional = *options.Provisional
+	}
+	if cv.Speculative && cv.Provisional {
+		return nil, fmt.Errorf("%w: a configuration version cannot be both speculative and provisional", ErrInvalidAttribute)
+	}
+
+	if err := s.db.Create(cv).Error; err != nil {
+		s.logger.Error("failed to create configuration version", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Debug("created configuration version", zap.String("workspace", ws.Name), zap.String("id", cv.ID.String()))
+	created, err := s.ReadConfigurationVersion(ctx, cv.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, ws.OrganizationID, models.AuditActionCreate, nil, created)
+	return created, nil
+}
+
+func (s *service) ReadConfigurationVersion(ctx context.Context, cvID string) (*tfe.ConfigurationVersion, error) {
+	cv, err := s.findConfigurationVersion(cvID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeWorkspaceID(ctx, cv.WorkspaceID, wsRead); err != nil {
+		return nil, err
+	}
+	return cv.ToTFE(), nil
+}
+
+func (s *service) ReadIngressAttributes(ctx context.Context, cvID string) (*tfe.IngressAttributes, error) {
+	cv, err := s.findConfigurationVersion(cvID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeWorkspaceID(ctx, cv.WorkspaceID, wsRead); err != nil {
+		return nil, err
+	}
+	if cv.IngressAttributes == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return cv.IngressAttributes.ToTFE(), nil
+}
+
+// UploadConfigurationVersion streams the archive into the blob store and
+// checks that it unpacks as a valid slug. Invalid archives mark the
+// configuration version as errored.
+func (s *service) UploadConfigurationVersion(ctx context.Context, cvID string, r io.Reader) error {
+	cv, err := s.findConfigurationVersion(cvID)
+	if err != nil {
+		return err
+	}
+	if err := s.authorizeWorkspaceID(ctx, cv.WorkspaceID, wsQueuePlan); err != nil {
+		return err
+	}
+	if cv.Status != string(tfe.ConfigurationPending) {
+		return fmt.Errorf("%w: configuration version has already been uploaded", ErrConflict)
+	}
+	before := cv.ToTFE()
+
+	now := time.Now()
+	if err := s.db.Model(cv).Update("started_at", now).Error; err != nil {
+		s.logger.Error("failed to update configuration version", zap.Error(err))
+		return err
+	}
+
+	key := configurationVersionKey(cv.ID)
+	if err := s.storeSlug(ctx, key, r); err != nil {
+		if !errors.Is(err, errInvalidSlug) {
+			s.logger.Error("failed to store configuration version", zap.Error(err))
+			return err
+		}
+		if uerr := s.transitionConfigurationVersion(cv.ID, tfe.ConfigurationPending, map[string]interface{}{
+			"status":        string(tfe.ConfigurationErrored),
+			"error":         "invalid-archive",
+			"error_message": err.Error(),
+			"finished_at":   time.Now(),
+		}); uerr != nil {
+			return uerr
+		}
+		s.auditConfigurationVersion(ctx, cv.ID, models.AuditActionUpload, before)
+		return fmt.Errorf("%w: %v", ErrInvalidAttribute, err)
+	}
+
+	if err := s.transitionConfigurationVersion(cv.ID, tfe.ConfigurationPending, map[string]interface{}{
+		"status":      string(tfe.ConfigurationUploaded),
+		"finished_at": time.Now(),
+	}); err != nil {
+		return err
+	}
+	s.auditConfigurationVersion(ctx, cv.ID, models.AuditActionUpload, before)
+
+	if cv.AutoQueueRuns {
+		cv.Status = string(tfe.ConfigurationUploaded)
+		return s.queueConfigurationVersionRun(cv)
+	}
+	return nil
+}
+
+func (s *service) DownloadConfigurationVersion(ctx context.Context, cvID string) (io.ReadCloser, error) {
+	cv, err := s.findConfigurationVersion(cvID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeWorkspaceID(ctx, cv.WorkspaceID, wsRead); err != nil {
+		return nil, err
+	}
+	if cv.Status != string(tfe.ConfigurationUploaded) {
+		return nil, fmt.Errorf("%w: only uploaded configuration versions can be downloaded", ErrConflict)
+	}
+	return s.openObject(ctx, configurationVersionKey(cv.ID))
+}
+
+// ArchiveConfigurationVersion discards the archive of an uploaded
+// configuration version without runs in progress, keeping its metadata.
+func (s *service) ArchiveConfigurationVersion(ctx context.Context, cvID string) error {
+	cv, err := s.findConfigurationVersion(cvID)
+	if err != nil {
+		return err
+	}
+	if err := s.authorizeWorkspaceID(ctx, cv.WorkspaceID, wsQueuePlan); err != nil {
+		return err
+	}
+	if cv.Source != string(tfe.ConfigurationSourceAPI) && cv.Source != string(tfe.ConfigurationSourceTerraform) {
+		return fmt.Errorf("%w: only configuration versions created through the API can be archived", ErrConflict)
+	}
+
+	var active int64
+	if err := s.db.Model(&models.Run{}).
+		Where("configuration_version_id = ? AND status NOT IN ?", cv.ID, finalRunStatusList()).
+		Count(&active).Error; err != nil {
+		s.logger.Error("failed to count runs", zap.Error(err))
+		return err
+	}
+	if active > 0 {
+		return fmt.Errorf("%w: configuration version has runs in progress", ErrConflict)
+	}
+
+	if err := s.transitionConfigurationVersion(cv.ID, tfe.ConfigurationUploaded, map[string]interface{}{
+		"status":      string(tfe.ConfigurationArchived),
+		"archived_at": time.Now(),
+	}); err != nil {
+		return err
+	}
+	s.deleteObjects(ctx, configurationVersionKey(cv.ID))
+	s.auditConfigurationVersion(ctx, cv.ID, models.AuditActionArchive, cv.ToTFE())
+	return nil
+}
+
+// auditConfigurationVersion records a change to a configuration version.
+func (s *service) auditConfigurationVersion(ctx context.Context, cvID uuid.UUID, action string, before *tfe.ConfigurationVersion) {
+	cv, err := s.findConfigurationVersion(cvID.String())
+	if err != nil {
+		return
+	}
+	s.auditWorkspace(ctx, cv.WorkspaceID, action, before, cv.ToTFE())
+}
+
+// transitionConfigurationVersion applies updates only while the
+// configuration version is still in the from status.
+func (s *service) transitionConfigurationVersion(id uuid.UUID, from tfe.ConfigurationStatus, updates map[string]interface{}) error {
+	result := s.db.Model(&models.ConfigurationVersion{}).Where("id = ? AND status = ?", id, string(from)).Updates(updates)
+	if result.Error != nil {
+		s.logger.Error("failed to update configuration version", zap.Error(result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: configuration version is not %s", ErrConflict, from)
+	}
+	return nil
+}
+
+// storeSlug stores an uploaded archive under key and checks it. Archives
+// that are too large, corrupt or escape their root are deleted again and
+// reported with errInvalidSlug.
+func (s *service) storeSlug(ctx context.Context, key string, r io.Reader) error {
+	body := &limitedReader{r: r, limit: maxSlugSize}
+	if err := s.store.Put(ctx, key, body, -1); err != nil && !body.exceeded() {
+		return err
+	}
+	err := body.err()
+	if err == nil {
+		err = s.checkSlug(ctx, key)
+	}
+	if err != nil {
+		s.deleteObjects(ctx, key)
+		return fmt.Errorf("%w: %v", errInvalidSlug, err)
+	}
+	return nil
+}
+
+// checkSlug measures the stored archive and then unpacks it into a scratch
+// directory, which rejects corrupt archives and entries that escape the
+// root.
+func (s *service) checkSlug(ctx context.Context, key string) error {
+	if err := s.measureSlug(ctx, key); err != nil {
+		return err
+	}
+
+	rc, err := s.store.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	dir, err := os.MkdirTemp("", "slug-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	return slug.Unpack(rc, dir)
+}
+
+// measureSlug reads the headers of the stored archive and fails when it
+// unpacks to more than maxUnpackedSlugSize bytes or maxSlugEntries entries,
+// before anything is written to disk.
+func (s *service) measureSlug(ctx context.Context, key string) error {
+	rc, err := s.store.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	gz, err := gzip.NewReader(rc)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	// The decompressed stream holds the file contents plus headers and
+	// padding, so it is bounded as well.
+	stream := &limitedReader{r: gz, limit: 2 * maxUnpackedSlugSize}
+	tr := tar.NewReader(stream)
+	var size int64
+	for entries := 1; ; entries++ {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if stream.exceeded() {
+				return stream.err()
+			}
+			return err
+		}
+		if entries > maxSlugEntries {
+			return fmt.Errorf("archive has more than %d entries", maxSlugEntries)
+		}
+		size += header.Size
+		if size > maxUnpackedSlugSize {
+			return fmt.Errorf("archive unpacks to more than %d bytes", maxUnpackedSlugSize)
+		}
+	}
+}
+
+// limitedReader reads from r and fails once more than limit bytes have
+// been read.
+type limitedReader struct {
+	r     io.Reader
+	limit int64
+	read  int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.exceeded() {
+		return 0, l.err()
+	}
+	if max := l.limit - l.read + 1; int64(len(p)) > max {
+		p = p[:max]
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.exceeded() {
+		return n, l.err()
+	}
+	return n, err
+}
+
+func (l *limitedReader) exceeded() bool {
+	return l.read > l.limit
+}
+
+// err returns the error of a reader that exceeded its limit, or nil.
+func (l *limitedReader) err() error {
+	if !l.exceeded() {
+		return nil
+	}
+	return fmt.Errorf("archive exceeds %d bytes", l.limit)
+}
+
+func (s *service) findConfigurationVersion(cvID string) (*models.ConfigurationVersion, error) {
+	id, err := uuid.Parse(cvID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var cv models.ConfigurationVersion
+	if err := s.db.Preload("IngressAttributes").Where("id = ?", id).First(&cv).Error; err != nil {
+		s.logger.Error("failed to read configuration version", zap.Error(err))
+		return nil, err
+	}
+	return &cv, nil
+}
+
+func configurationVersionKey(cvID uuid.UUID) string {
+	return "configuration-versions/" + cvID.String() + "/slug.tar.gz"
+}
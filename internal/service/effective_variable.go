@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+)
+
+// Sources of effective variables.
+const (
+	VariableSourceVariableSet = "variable-set"
+	VariableSourceWorkspace   = "workspace"
+	VariableSourceRun         = "run"
+)
+
+// Variable set scopes, from least to most specific.
+const (
+	variableSetScopeGlobal = iota
+	variableSetScopeProject
+	variableSetScopeWorkspace
+)
+
+// EffectiveVariable is a variable as a run in a workspace sees it once
+// variable sets, workspace variables and run variables are merged, together
+// with the source that provided the value.
+type EffectiveVariable struct {
+	ID         string `jsonapi:"primary,effective-variables"`
+	Key        string `jsonapi:"attr,key"`
+	Value      string `jsonapi:"attr,value"`
+	Category   string `jsonapi:"attr,category"`
+	HCL        bool   `jsonapi:"attr,hcl"`
+	Sensitive  bool   `jsonapi:"attr,sensitive"`
+	SourceType string `jsonapi:"attr,source-type"`
+	SourceID   string `jsonapi:"attr,source-id"`
+	SourceName string `jsonapi:"attr,source-name"`
+	Priority   bool   `jsonapi:"attr,priority"`
+}
+
+// ListEffectiveVariables returns the variables the next run of a workspace
+// will receive. Sensitive values are left out.
+func (s *service) ListEffectiveVariables(ctx context.Context, workspaceID string) ([]*EffectiveVariable, error) {
+	ws, err := s.findWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, ws, wsReadVariables); err != nil {
+		return nil, err
+	}
+	return s.effectiveVariables(ws, nil, false)
+}
+
+// effectiveVariables merges the variables that apply to a run in ws, from
+// lowest to highest precedence:
+//
+//  1. variable sets, global first, then project, then workspace scoped
+//  2. workspace variables
+//  3. run variables
+//  4. priority variable sets, in the same scope order
+//
+// Between sets of the same scope, the set whose name sorts first wins. A
+// later source replaces an earlier variable with the same key and category.
+// Sensitive values are only decrypted when decrypt is set.
+func (s *service) effectiveVariables(ws *models.Workspace, run *models.Run, decrypt bool) ([]*EffectiveVariable, error) {
+	sets, scopes, err := s.workspaceVariableSets(ws)
+	if err != nil {
+		return nil, err
+	}
+	var variables []*models.Variable
+	if err := s.db.Where("workspace_id = ?", ws.ID).Find(&variables).Error; err != nil {
+		s.logger.Error("failed to list variables", zap.Error(err))
+		return nil, err
+	}
+
+	merged := make(map[string]*EffectiveVariable)
+	set := func(ev *EffectiveVariable, encrypted string) error {
+		if ev.Sensitive {
+			ev.Value = ""
+			if decrypt {
+				value, err := s.secrets.Decrypt(encrypted)
+				if err != nil {
+					s.logger.Error("failed to decrypt variable", zap.String("variable", ev.ID), zap.Error(err))
+					return err
+				}
+				ev.Value = value
+			}
+		}
+		merged[ev.Category+"/"+ev.Key] = ev
+		return nil
+	}
+	applySets := func(priority bool) error {
+		for _, vs := range sets {
+			if vs.Priority != priority {
+				continue
+			}
+			for _, v := range vs.Variables {
+				err := set(&EffectiveVariable{
+					ID:         v.ID.String(),
+					Key:        v.Key,
+					Value:      v.Value,
+					Category:   v.Category,
+					HCL:        v.HCL,
+					Sensitive:  v.Sensitive,
+					SourceType: VariableSourceVariableSet,
+					SourceID:   vs.ID.String(),
+					SourceName: vs.Name,
+					Priority:   vs.Priority,
+				}, v.EncryptedValue)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	// Order sets so that the set that must win is applied last.
+	sort.SliceStable(sets, func(i, j int) bool {
+		a, b := sets[i], sets[j]
+		if scopes[a.ID] != scopes[b.ID] {
+			return scopes[a.ID] < scopes[b.ID]
+		}
+		return a.Name > b.Name
+	})
+
+	if err := applySets(false); err != nil {
+		return nil, err
+	}
+	for _, v := range variables {
+		err := set(&EffectiveVariable{
+			ID:         v.ID.String(),
+			Key:        v.Key,
+			Value:      v.Value,
+			Category:   v.Category,
+			HCL:        v.HCL,
+			Sensitive:  v.Sensitive,
+			SourceType: VariableSourceWorkspace,
+			SourceID:   ws.ID.String(),
+			SourceName: ws.Name,
+		}, v.EncryptedValue)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if run != nil {
+		for _, v := range run.Variables {
+			merged[string(tfe.CategoryTerraform)+"/"+v.Key] = &EffectiveVariable{
+				ID:         run.ID.String() + "/" + v.Key,
+				Key:        v.Key,
+				Value:      v.Value,
+				Category:   string(tfe.CategoryTerraform),
+				HCL:        true,
+				SourceType: VariableSourceRun,
+				SourceID:   run.ID.String(),
+			}
+		}
+	}
+	if err := applySets(true); err != nil {
+		return nil, err
+	}
+
+	effective := make([]*EffectiveVariable, 0, len(merged))
+	for _, ev := range merged {
+		effective = append(effective, ev)
+	}
+	sort.Slice(effective, func(i, j int) bool {
+		if effective[i].Category != effective[j].Category {
+			return effective[i].Category < effective[j].Category
+		}
+		return effective[i].Key < effective[j].Key
+	})
+	return effective, nil
+}
+
+// workspaceVariableSets loads the variable sets that apply to ws with their
+// variables, and the most specific scope through which each one applies.
+func (s *service) workspaceVariableSets(ws *models.Workspace) ([]*models.VariableSet, map[uuid.UUID]int, error) {
+	var sets []*models.VariableSet
+	if err := s.applicableVariableSets(ws).Preload("Variables").Find(&sets).Error; err != nil {
+		s.logger.Error("failed to list variable sets", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var workspaceAttachments []*models.VariableSetWorkspace
+	if err := s.db.Where("workspace_id = ?", ws.ID).Find(&workspaceAttachments).Error; err != nil {
+		s.logger.Error("failed to list variable set attachments", zap.Error(err))
+		return nil, nil, err
+	}
+	var projectAttachments []*models.VariableSetProject
+	if err := s.db.Where("project_id = ?", ws.ProjectID).Find(&projectAttachments).Error; err != nil {
+		s.logger.Error("failed to list variable set attachments", zap.Error(err))
+		return nil, nil, err
+	}
+
+	scopes := make(map[uuid.UUID]int, len(sets))
+	for _, a := range projectAttachments {
+		scopes[a.VariableSetID] = variableSetScopeProject
+	}
+	for _, a := range workspaceAttachments {
+		scopes[a.VariableSetID] = variableSetScopeWorkspace
+	}
+	return sets, scopes, nil
+}
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidAttribute is wrapped by every validation error so handlers can answer 422.
+	ErrInvalidAttribute = errors.New("invalid attribute")
+	// ErrConflict is wrapped by errors caused by the current state of a resource.
+	ErrConflict = errors.New("conflict")
+	// ErrForbidden is wrapped by errors caused by missing permissions.
+	ErrForbidden = errors.New("forbidden")
+
+	ErrInvalidToken = errors.New("invalid or expired token")
+
+	ErrWorkspaceLocked    = fmt.Errorf("%w: workspace already locked", ErrConflict)
+	ErrWorkspaceNotLocked = fmt.Errorf("%w: workspace already unlocked", ErrConflict)
+)
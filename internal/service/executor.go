@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Run phases handed to executors.
+const (
+	PhasePlan  = "plan"
+	PhaseApply = "apply"
+)
+
+// RunJob is a claimed run phase together with everything an executor needs
+// to carry it out.
+type RunJob struct {
+	Phase     string          `json:"phase"`
+	Run       *tfe.Run        `json:"run"`
+	Workspace *tfe.Workspace  `json:"workspace"`
+	Variables []*tfe.Variable `json:"variables"`
+}
+
+// PlanResult is reported by an executor when a plan completes.
+type PlanResult struct {
+	HasChanges bool `json:"has-changes"`
+}
+
+// ClaimRunJob moves the oldest queued run of a remote-execution workspace
+// into planning or applying and returns it. It returns nil when there is
+// nothing to do.
+func (s *service) ClaimRunJob(ctx context.Context) (*RunJob, error) {
+	return s.claimRunJob(s.db.Where("workspaces.execution_mode = ?", "remote"), nil)
+}
+
+// claimRunJob claims the oldest queued run of the workspaces matched by
+// scope, recording the claiming agent if there is one.
+func (s *service) claimRunJob(scope *gorm.DB, agentID *uuid.UUID) (*RunJob, error) {
+	var candidates []*models.Run
+	if err := s.db.Model(&models.Run{}).
+		Joins("JOIN workspaces ON workspaces.id = runs.workspace_id AND workspaces.deleted_at IS NULL").
+		Where("runs.status IN ?", []string{string(tfe.RunPlanQueued), string(tfe.RunApplyQueued)}).
+		Where(scope).
+		Order("runs.updated_at ASC").Limit(10).Find(&candidates).Error; err != nil {
+		s.logger.Error("failed to list queued runs", zap.Error(err))
+		return nil, err
+	}
+
+	for _, run := range candidates {
+		phase, to := PhasePlan, tfe.RunPlanning
+		if run.Status == string(tfe.RunApplyQueued) {
+			phase, to = PhaseApply, tfe.RunApplying
+		}
+		if err := s.transitionRun(run, to, map[string]interface{}{"agent_id": agentID}); err != nil {
+			if errors.Is(err, ErrConflict) {
+				// Another executor claimed it first.
+				continue
+			}
+			return nil, err
+		}
+		return s.runJob(run.ID, phase)
+	}
+	return nil, nil
+}
+
+// FinishPlan records a successful plan and decides where the run goes
+// next. Runs of workspaces in scope of policy sets or with post_plan run
+// tasks pass through the post_plan stage first.
+func (s *service) FinishPlan(ctx context.Context, runID string, result PlanResult) error {
+	run, err := s.findRun(runID)
+	if err != nil {
+		return err
+	}
+	if run.Status != string(tfe.RunPlanning) {
+		return fmt.Errorf("%w: run is %s, not planning", ErrConflict, run.Status)
+	}
+
+	if err := s.db.Model(&models.Plan{}).Where("run_id = ?", run.ID).Update("has_changes", result.HasChanges).Error; err != nil {
+		s.logger.Error("failed to update plan", zap.Error(err))
+		return err
+	}
+	run.HasChanges = result.HasChanges
+	updates := map[string]interface{}{"has_changes": result.HasChanges}
+
+	sets, err := s.runPolicySets(run)
+	if err != nil {
+		return err
+	}
+	tasks, err := s.stageRunTasks(run, tfe.PostPlan)
+	if err != nil {
+		return err
+	}
+	if len(sets) == 0 && len(tasks) == 0 {
+		return s.completePlan(run, tfe.RunPlanned, updates)
+	}
+	if err := s.transitionRun(run, tfe.RunPostPlanRunning, updates); err != nil {
+		return err
+	}
+	return s.startTaskStage(ctx, run, tfe.PostPlan, tasks, sets)
+}
+
+// completePlan moves a run whose plan and post-plan checks are done on:
+// runs that cannot be applied finish, others wait in confirmable for
+// confirmation or are confirmed right away when they auto-apply.
+func (s *service) completePlan(run *models.Run, confirmable tfe.RunStatus, updates map[string]interface{}) error {
+	switch {
+	case run.PlanOnly:
+		return s.transitionRun(run, tfe.RunPlannedAndFinished, updates)
+	case run.SavePlan:
+		return s.transitionRun(run, tfe.RunPlannedAndSaved, updates)
+	case !run.HasChanges && !run.AllowEmptyApply:
+		return s.transitionRun(run, tfe.RunPlannedAndFinished, updates)
+	}
+
+	if err := s.transitionRun(run, confirmable, updates); err != nil {
+		return err
+	}
+	if run.AutoApply {
+		if err := s.transitionRun(run, tfe.RunConfirmed, nil); err != nil {
+			return err
+		}
+		return s.queueApply(run)
+	}
+	return nil
+}
+
+// FinishApply records a successful apply.
+func (s *service) FinishApply(ctx context.Context, runID string) error {
+	run, err := s.findRun(runID)
+	if err != nil {
+		return err
+	}
+	return s.transitionRun(run, tfe.RunApplied, nil)
+}
+
+// FailRun marks a planning or applying run as errored.
+func (s *service) FailRun(ctx context.Context, runID string, cause string) error {
+	run, err := s.findRun(runID)
+	if err != nil {
+		return err
+	}
+	s.logger.Info("run errored", zap.String("run", runID), zap.String("cause", cause))
+	return s.transitionRun(run, tfe.RunErrored, nil)
+}
+
+// AcknowledgeRunCancel marks a run whose executor stopped after a cancel request as canceled.
+func (s *service) AcknowledgeRunCancel(ctx context.Context, runID string) error {
+	run, err := s.findRun(runID)
+	if err != nil {
+		return err
+	}
+	if run.Status == string(tfe.RunCanceled) {
+		// Already force-canceled.
+		return nil
+	}
+	return s.transitionRun(run, tfe.RunCanceled, nil)
+}
+
+// DownloadRunState opens the current state of the run's workspace.
+func (s *service) DownloadRunState(ctx context.Context, runID string) (io.ReadCloser, error) {
+	run, err := s.findRun(runID)
+	if err != nil {
+		return nil, err
+	}
+	sv, err := s.currentStateVersion(run.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.openObject(ctx, stateKey(sv.ID))
+}
+
+// CreateRunStateVersion stores state produced by an applying run. The run
+// holds the workspace lock, so no user lock is required.
+func (s *service) CreateRunStateVersion(ctx context.Context, runID string, options tfe.StateVersionCreateOptions, r io.Reader) (*tfe.StateVersion, error) {
+	if options.Serial == nil || options.MD5 == nil {
+		return nil, fmt.Errorf("%w: serial and md5 are required", ErrInvalidAttribute)
+	}
+	run, err := s.findRun(runID)
+	if err != nil {
+		return nil, err
+	}
+	if run.Status != string(tfe.RunApplying) {
+		return nil, fmt.Errorf("%w: run is %s, not applying", ErrConflict, run.Status)
+	}
+
+	sv := &models.StateVersion{
+		ID:          uuid.New(),
+		Status:      string(tfe.StateVersionPending),
+		Serial:      *options.Serial,
+		MD5:         *options.MD5,
+		WorkspaceID: run.WorkspaceID,
+		RunID:       &run.ID,
+		CreatedByID: run.CreatedByID,
+	}
+	if options.Lineage != nil {
+		sv.Lineage = *options.Lineage
+	}
+	if err := s.checkStateVersionSuccession(run.WorkspaceID, sv); err != nil {
+		return nil, err
+	}
+	if err := s.storeState(ctx, sv, r); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(sv).Error; err != nil {
+		s.logger.Error("failed to create state version", zap.Error(err))
+		s.deleteObjects(ctx, stateKey(sv.ID))
+		return nil, err
+	}
+	created, err := s.ReadStateVersion(ctx, sv.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, run.Workspace.OrganizationID, models.AuditActionCreate, nil, created)
+	return created, nil
+}
+
+// UploadPlanFile stores the binary plan of a run for its apply phase.
+func (s *service) UploadPlanFile(ctx context.Context, runID string, r io.Reader) error {
+	run, err := s.findRun(runID)
+	if err != nil {
+		return err
+	}
+	if err := s.store.Put(ctx, planFileKey(run.Plan.ID), r, -1); err != nil {
+		s.logger.Error("failed to store plan file", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// DownloadPlanFile opens the binary plan of a run.
+func (s *service) DownloadPlanFile(ctx context.Context, runID string) (io.ReadCloser, error) {
+	run, err := s.findRun(runID)
+	if err != nil {
+		return nil, err
+	}
+	return s.openObject(ctx, planFileKey(run.Plan.ID))
+}
+
+func (s *service) runJob(runID uuid.UUID, phase string) (*RunJob, error) {
+	run, err := s.findRun(runID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	variables, err := s.effectiveVariables(run.Workspace, run, true)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &RunJob{
+		Phase:     phase,
+		Run:       run.ToTFE(),
+		Workspace: run.Workspace.ToTFE(),
+		Variables: make([]*tfe.Variable, len(variables)),
+	}
+	for i, v := range variables {
+		job.Variables[i] = &tfe.Variable{
+			Key:       v.Key,
+			Value:     v.Value,
+			Category:  tfe.CategoryType(v.Category),
+			HCL:       v.HCL,
+			Sensitive: v.Sensitive,
+		}
+	}
+	return job, nil
+}
+
+func planFileKey(planID uuid.UUID) string {
+	return "plans/" + planID.String() + "/plan.out"
+}
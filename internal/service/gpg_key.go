@@ -0,0 +1,218 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ListGPGKeys lists the keys of the given namespaces, which are
+// organization names. The caller must be a member of each of them.
+func (s *service) ListGPGKeys(ctx context.Context, namespaces []string, listOptions tfe.ListOptions) ([]*tfe.GPGKey, *tfe.Pagination, error) {
+	if len(namespaces) == 0 {
+		return nil, nil, fmt.Errorf("%w: at least one namespace is required", ErrInvalidAttribute)
+	}
+	var orgIDs []uuid.UUID
+	for _, namespace := range namespaces {
+		role, err := s.authorizeOrganizationName(ctx, namespace, orgRead)
+		if err != nil {
+			return nil, nil, err
+		}
+		orgIDs = append(orgIDs, role.orgID)
+	}
+
+	db, pagination, err := paginate(s.db.Model(&models.GPGKey{}).Where("organization_id IN ?", orgIDs), listOptions)
+	if err != nil {
+		s.logger.Error("failed to count GPG keys", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var keys []*models.GPGKey
+	if err := db.Preload("Organization").Order("created_at ASC").Find(&keys).Error; err != nil {
+		s.logger.Error("failed to list GPG keys", zap.Error(err))
+		return nil, nil, err
+	}
+
+	tfeKeys := make([]*tfe.GPGKey, len(keys))
+	for i, k := range keys {
+		tfeKeys[i] = k.ToTFE()
+	}
+	return tfeKeys, pagination, nil
+}
+
+// CreateGPGKey adds a public key to the namespace of an organization. The
+// key ID is read from the key itself.
+func (s *service) CreateGPGKey(ctx context.Context, registryName tfe.RegistryName, options tfe.GPGKeyCreateOptions) (*tfe.GPGKey, error) {
+	if registryName != tfe.PrivateRegistry {
+		return nil, gorm.ErrRecordNotFound
+	}
+	role, err := s.authorizeOrganizationName(ctx, options.Namespace, orgManageProviders)
+	if err != nil {
+		return nil, err
+	}
+	keyID, err := parseGPGKey(options.AsciiArmor)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing int64
+	if err := s.db.Model(&models.GPGKey{}).Where("organization_id = ? AND key_id = ?", role.orgID, keyID).Count(&existing).Error; err != nil {
+		s.logger.Error("failed to check GPG key", zap.Error(err))
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, fmt.Errorf("%w: key %s already exists in namespace %s", ErrInvalidAttribute, keyID, options.Namespace)
+	}
+
+	key := &models.GPGKey{
+		KeyID:          keyID,
+		AsciiArmor:     options.AsciiArmor,
+		OrganizationID: role.orgID,
+	}
+	if err := s.db.Omit("Organization").Create(key).Error; err != nil {
+		s.logger.Error("failed to create GPG key", zap.Error(err))
+		return nil, err
+	}
+	created, err := s.ReadGPGKey(ctx, tfe.GPGKeyID{RegistryName: registryName, Namespace: options.Namespace, KeyID: keyID})
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, role.orgID, models.AuditActionCreate, nil, created)
+	return created, nil
+}
+
+func (s *service) ReadGPGKey(ctx context.Context, keyID tfe.GPGKeyID) (*tfe.GPGKey, error) {
+	key, err := s.findGPGKey(ctx, keyID, orgRead)
+	if err != nil {
+		return nil, err
+	}
+	return key.ToTFE(), nil
+}
+
+// UpdateGPGKey moves a key to the namespace of another organization. The
+// caller must be allowed to manage providers in both.
+func (s *service) UpdateGPGKey(ctx context.Context, keyID tfe.GPGKeyID, options tfe.GPGKeyUpdateOptions) (*tfe.GPGKey, error) {
+	key, err := s.findGPGKey(ctx, keyID, orgManageProviders)
+	if err != nil {
+		return nil, err
+	}
+	role, err := s.authorizeOrganizationName(ctx, options.Namespace, orgManageProviders)
+	if err != nil {
+		return nil, fmt.Errorf("%w: namespace not authorized", ErrForbidden)
+	}
+	if role.orgID == key.OrganizationID {
+		return key.ToTFE(), nil
+	}
+	if err := s.checkGPGKeyUnused(key); err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(&models.GPGKey{}).Where("id = ?", key.ID).Update("organization_id", role.orgID).Error; err != nil {
+		s.logger.Error("failed to update GPG key", zap.Error(err))
+		return nil, err
+	}
+	updated, err := s.ReadGPGKey(ctx, tfe.GPGKeyID{RegistryName: tfe.PrivateRegistry, Namespace: options.Namespace, KeyID: key.KeyID})
+	if err != nil {
+		return nil, err
+	}
+	// The key leaves one organization for another; both keep a record.
+	s.recordAudit(ctx, []uuid.UUID{key.OrganizationID, role.orgID}, models.AuditActionUpdate, key.ToTFE(), updated)
+	return updated, nil
+}
+
+// DeleteGPGKey deletes a key that signs no provider release.
+func (s *service) DeleteGPGKey(ctx context.Context, keyID tfe.GPGKeyID) error {
+	key, err := s.findGPGKey(ctx, keyID, orgManageProviders)
+	if err != nil {
+		return err
+	}
+	if err := s.checkGPGKeyUnused(key); err != nil {
+		return err
+	}
+	if err := s.db.Delete(key).Error; err != nil {
+		s.logger.Error("failed to delete GPG key", zap.Error(err))
+		return err
+	}
+	s.audit(ctx, key.OrganizationID, models.AuditActionDelete, key.ToTFE(), nil)
+	return nil
+}
+
+// checkGPGKeyUnused refuses to take a key away from the provider releases
+// it signs, whose signatures could no longer be verified.
+func (s *service) checkGPGKeyUnused(key *models.GPGKey) error {
+	var used int64
+	if err := s.db.Model(&models.RegistryProviderVersion{}).
+		Joins("JOIN registry_providers ON registry_providers.id = registry_provider_versions.registry_provider_id AND registry_providers.deleted_at IS NULL").
+		Where("registry_providers.organization_id = ? AND registry_provider_versions.key_id = ?", key.OrganizationID, key.KeyID).
+		Count(&used).Error; err != nil {
+		s.logger.Error("failed to count provider versions", zap.Error(err))
+		return err
+	}
+	if used > 0 {
+		return fmt.Errorf("%w: key %s signs %d provider versions", ErrConflict, key.KeyID, used)
+	}
+	return nil
+}
+
+func (s *service) findGPGKey(ctx context.Context, keyID tfe.GPGKeyID, required organizationPermission) (*models.GPGKey, error) {
+	if keyID.RegistryName != tfe.PrivateRegistry {
+		return nil, gorm.ErrRecordNotFound
+	}
+	role, err := s.authorizeOrganizationName(ctx, keyID.Namespace, required)
+	if err != nil {
+		return nil, err
+	}
+	return s.findOrganizationGPGKey(role.orgID, keyID.KeyID)
+}
+
+func (s *service) findOrganizationGPGKey(orgID uuid.UUID, keyID string) (*models.GPGKey, error) {
+	var key models.GPGKey
+	if err := s.db.Preload("Organization").Where("organization_id = ? AND key_id = ?", orgID, strings.ToUpper(keyID)).First(&key).Error; err != nil {
+		s.logger.Debug("failed to read GPG key", zap.Error(err))
+		return nil, err
+	}
+	return &key, nil
+}
+
+// parseGPGKey reads an ASCII-armored public key and returns its long key ID.
+func parseGPGKey(armor string) (string, error) {
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armor))
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid ASCII-armored GPG key: %v", ErrInvalidAttribute, err)
+	}
+	if len(keyring) != 1 {
+		return "", fmt.Errorf("%w: exactly one GPG key is required, got %d", ErrInvalidAttribute, len(keyring))
+	}
+	return keyring[0].PrimaryKey.KeyIdString(), nil
+}
+
+// verifyGPGSignature checks a detached binary signature of signed against
+// the ASCII-armored key.
+func verifyGPGSignature(armor string, signed, signature []byte) error {
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armor))
+	if err != nil {
+		return err
+	}
+	_, err = openpgp.CheckDetachedSignature(keyring, bytes.NewReader(signed), bytes.NewReader(signature), nil)
+	return err
+}
+
+// readLimited reads r completely, failing when it holds more than limit bytes.
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: upload exceeds %d bytes", ErrInvalidAttribute, limit)
+	}
+	return data, nil
+}
@@ -2,10 +2,17 @@ package service
 
 import (
 	"context"
+	"io"
 
 	"github.com/google/uuid"
 	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/mail"
 	"github.com/open-tfe/tfe-service/internal/models"
+	"github.com/open-tfe/tfe-service/internal/notification"
+	"github.com/open-tfe/tfe-service/internal/policy"
+	"github.com/open-tfe/tfe-service/internal/runtask"
+	"github.com/open-tfe/tfe-service/internal/secrets"
+	"github.com/open-tfe/tfe-service/internal/storage"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -20,6 +27,9 @@ type Service interface {
 	GetOrganizationIDByName(ctx context.Context, name string) (uuid.UUID, error)
 	ReadOrganizationEntitlements(ctx context.Context, name string) (*tfe.Entitlements, error)
 
+	// Audit trail methods
+	ListAuditTrail(ctx context.Context, options *tfe.AuditTrailListOptions) ([]*models.AuditTrailEvent, *tfe.Pagination, error)
+
 	// Project methods
 	ListProjects(ctx context.Context, orgID uuid.UUID) ([]*models.Project, []*tfe.Project, error)
 	CreateProject(ctx context.Context, project *tfe.Project) (*tfe.Project, error)
@@ -27,6 +37,194 @@ type Service interface {
 	UpdateProject(ctx context.Context, project *tfe.Project) (*tfe.Project, error)
 	DeleteProject(ctx context.Context, projectID string) error
 
+	// Workspace methods
+	ListWorkspaces(ctx context.Context, organization string, options *tfe.WorkspaceListOptions) ([]*tfe.Workspace, *tfe.Pagination, error)
+	CreateWorkspace(ctx context.Context, organization string, options tfe.WorkspaceCreateOptions) (*tfe.Workspace, error)
+	ReadWorkspace(ctx context.Context, organization, workspace string) (*tfe.Workspace, error)
+	ReadWorkspaceByID(ctx context.Context, workspaceID string) (*tfe.Workspace, error)
+	UpdateWorkspace(ctx context.Context, organization, workspace string, options tfe.WorkspaceUpdateOptions) (*tfe.Workspace, error)
+	UpdateWorkspaceByID(ctx context.Context, workspaceID string, options tfe.WorkspaceUpdateOptions) (*tfe.Workspace, error)
+	DeleteWorkspace(ctx context.Context, organization, workspace string) error
+	DeleteWorkspaceByID(ctx context.Context, workspaceID string) error
+	SafeDeleteWorkspace(ctx context.Context, organization, workspace string) error
+	SafeDeleteWorkspaceByID(ctx context.Context, workspaceID string) error
+	LockWorkspace(ctx context.Context, workspaceID string, options tfe.WorkspaceLockOptions) (*tfe.Workspace, error)
+	UnlockWorkspace(ctx context.Context, workspaceID string) (*tfe.Workspace, error)
+	ForceUnlockWorkspace(ctx context.Context, workspaceID string) (*tfe.Workspace, error)
+
+	// State version methods
+	ListStateVersions(ctx context.Context, options *tfe.StateVersionListOptions) ([]*tfe.StateVersion, *tfe.Pagination, error)
+	CreateStateVersion(ctx context.Context, workspaceID string, options tfe.StateVersionCreateOptions) (*tfe.StateVersion, error)
+	ReadStateVersion(ctx context.Context, svID string) (*tfe.StateVersion, error)
+	ReadCurrentStateVersion(ctx context.Context, workspaceID string) (*tfe.StateVersion, error)
+	UploadStateVersionContent(ctx context.Context, svID string, r io.Reader) error
+	UploadStateVersionJSONContent(ctx context.Context, svID string, r io.Reader) error
+	DownloadStateVersionContent(ctx context.Context, svID string) (io.ReadCloser, error)
+	DownloadStateVersionJSONContent(ctx context.Context, svID string) (io.ReadCloser, error)
+	ListStateVersionOutputs(ctx context.Context, svID string) ([]*tfe.StateVersionOutput, error)
+	ReadCurrentStateVersionOutputs(ctx context.Context, workspaceID string) ([]*tfe.StateVersionOutput, error)
+	ReadStateVersionOutput(ctx context.Context, outputID string) (*tfe.StateVersionOutput, error)
+
+	// Configuration version methods
+	ListConfigurationVersions(ctx context.Context, workspaceID string, options *tfe.ConfigurationVersionListOptions) ([]*tfe.ConfigurationVersion, *tfe.Pagination, error)
+	CreateConfigurationVersion(ctx context.Context, workspaceID string, options tfe.ConfigurationVersionCreateOptions, ingress *tfe.IngressAttributes) (*tfe.ConfigurationVersion, error)
+	ReadConfigurationVersion(ctx context.Context, cvID string) (*tfe.ConfigurationVersion, error)
+	ReadIngressAttributes(ctx context.Context, cvID string) (*tfe.IngressAttributes, error)
+	UploadConfigurationVersion(ctx context.Context, cvID string, r io.Reader) error
+	DownloadConfigurationVersion(ctx context.Context, cvID string) (io.ReadCloser, error)
+	ArchiveConfigurationVersion(ctx context.Context, cvID string) error
+
+	// Run methods
+	ListRuns(ctx context.Context, workspaceID string, options *tfe.RunListOptions) ([]*tfe.Run, *tfe.Pagination, error)
+	ListOrganizationRuns(ctx context.Context, organization string, options *tfe.RunListOptions) ([]*tfe.Run, *tfe.Pagination, error)
+	ReadRunQueue(ctx context.Context, organization string, options tfe.ReadRunQueueOptions) ([]*tfe.Run, *tfe.Pagination, error)
+	CreateRun(ctx context.Context, options tfe.RunCreateOptions) (*tfe.Run, error)
+	ReadRun(ctx context.Context, runID string) (*tfe.Run, error)
+	ApplyRun(ctx context.Context, runID string, options tfe.RunApplyOptions) error
+	DiscardRun(ctx context.Context, runID string, options tfe.RunDiscardOptions) error
+	CancelRun(ctx context.Context, runID string, options tfe.RunCancelOptions) error
+	ForceCancelRun(ctx context.Context, runID string, options tfe.RunForceCancelOptions) error
+	ForceExecuteRun(ctx context.Context, runID string) error
+	ReadPlan(ctx context.Context, planID string) (*tfe.Plan, error)
+	ReadApply(ctx context.Context, applyID string) (*tfe.Apply, error)
+	ReadPlanLog(ctx context.Context, planID string, offset, limit int64) ([]byte, error)
+	ReadApplyLog(ctx context.Context, applyID string, offset, limit int64) ([]byte, error)
+	ReadPlanJSONOutput(ctx context.Context, planID string) (io.ReadCloser, error)
+	ReadRunPlanJSONOutput(ctx context.Context, runID string) (io.ReadCloser, error)
+
+	// Run executor methods
+	ClaimRunJob(ctx context.Context) (*RunJob, error)
+	FinishPlan(ctx context.Context, runID string, result PlanResult) error
+	FinishApply(ctx context.Context, runID string) error
+	FailRun(ctx context.Context, runID string, cause string) error
+	AcknowledgeRunCancel(ctx context.Context, runID string) error
+	DownloadRunState(ctx context.Context, runID string) (io.ReadCloser, error)
+	CreateRunStateVersion(ctx context.Context, runID string, options tfe.StateVersionCreateOptions, r io.Reader) (*tfe.StateVersion, error)
+	UploadPlanFile(ctx context.Context, runID string, r io.Reader) error
+	DownloadPlanFile(ctx context.Context, runID string) (io.ReadCloser, error)
+	AppendRunLog(ctx context.Context, runID, phase string, data []byte) error
+	SealRunLog(ctx context.Context, runID, phase string) error
+	UploadPlanJSON(ctx context.Context, runID string, r io.Reader) error
+
+	// Variable methods
+	ListVariables(ctx context.Context, workspaceID string, options *tfe.VariableListOptions) ([]*tfe.Variable, *tfe.Pagination, error)
+	CreateVariable(ctx context.Context, workspaceID string, options tfe.VariableCreateOptions) (*tfe.Variable, error)
+	ReadVariable(ctx context.Context, workspaceID, variableID string) (*tfe.Variable, error)
+	UpdateVariable(ctx context.Context, workspaceID, variableID string, options tfe.VariableUpdateOptions) (*tfe.Variable, error)
+	DeleteVariable(ctx context.Context, workspaceID, variableID string) error
+	ListEffectiveVariables(ctx context.Context, workspaceID string) ([]*EffectiveVariable, error)
+
+	// Variable set methods
+	ListVariableSets(ctx context.Context, organization string, options *tfe.VariableSetListOptions) ([]*tfe.VariableSet, *tfe.Pagination, error)
+	ListWorkspaceVariableSets(ctx context.Context, workspaceID string, options *tfe.VariableSetListOptions) ([]*tfe.VariableSet, *tfe.Pagination, error)
+	ListProjectVariableSets(ctx context.Context, projectID string, options *tfe.VariableSetListOptions) ([]*tfe.VariableSet, *tfe.Pagination, error)
+	CreateVariableSet(ctx context.Context, organization string, options tfe.VariableSetCreateOptions) (*tfe.VariableSet, error)
+	ReadVariableSet(ctx context.Context, variableSetID string) (*tfe.VariableSet, error)
+	UpdateVariableSet(ctx context.Context, variableSetID string, options tfe.VariableSetUpdateOptions) (*tfe.VariableSet, error)
+	DeleteVariableSet(ctx context.Context, variableSetID string) error
+	ApplyVariableSetToWorkspaces(ctx context.Context, variableSetID string, workspaceIDs []string) error
+	RemoveVariableSetFromWorkspaces(ctx context.Context, variableSetID string, workspaceIDs []string) error
+	ReplaceVariableSetWorkspaces(ctx context.Context, variableSetID string, workspaceIDs []string) (*tfe.VariableSet, error)
+	ApplyVariableSetToProjects(ctx context.Context, variableSetID string, projectIDs []string) error
+	RemoveVariableSetFromProjects(ctx context.Context, variableSetID string, projectIDs []string) error
+	ListVariableSetVariables(ctx context.Context, variableSetID string, options *tfe.VariableSetVariableListOptions) ([]*tfe.VariableSetVariable, *tfe.Pagination, error)
+	CreateVariableSetVariable(ctx context.Context, variableSetID string, options tfe.VariableSetVariableCreateOptions) (*tfe.VariableSetVariable, error)
+	ReadVariableSetVariable(ctx context.Context, variableSetID, variableID string) (*tfe.VariableSetVariable, error)
+	UpdateVariableSetVariable(ctx context.Context, variableSetID, variableID string, options tfe.VariableSetVariableUpdateOptions) (*tfe.VariableSetVariable, error)
+	DeleteVariableSetVariable(ctx context.Context, variableSetID, variableID string) error
+
+	// Policy methods
+	ListPolicies(ctx context.Context, organization string, options *tfe.PolicyListOptions) ([]*tfe.Policy, *tfe.Pagination, error)
+	CreatePolicy(ctx context.Context, organization string, options tfe.PolicyCreateOptions) (*tfe.Policy, error)
+	ReadPolicy(ctx context.Context, policyID string) (*tfe.Policy, error)
+	UpdatePolicy(ctx context.Context, policyID string, options tfe.PolicyUpdateOptions) (*tfe.Policy, error)
+	DeletePolicy(ctx context.Context, policyID string) error
+	UploadPolicy(ctx context.Context, policyID string, r io.Reader) error
+	DownloadPolicy(ctx context.Context, policyID string) (io.ReadCloser, error)
+
+	// Policy set methods
+	ListPolicySets(ctx context.Context, organization string, options *tfe.PolicySetListOptions) ([]*tfe.PolicySet, *tfe.Pagination, error)
+	CreatePolicySet(ctx context.Context, organization string, options tfe.PolicySetCreateOptions) (*tfe.PolicySet, error)
+	ReadPolicySet(ctx context.Context, policySetID string) (*tfe.PolicySet, error)
+	UpdatePolicySet(ctx context.Context, policySetID string, options tfe.PolicySetUpdateOptions) (*tfe.PolicySet, error)
+	DeletePolicySet(ctx context.Context, policySetID string) error
+	AddPolicySetPolicies(ctx context.Context, policySetID string, policyIDs []string) error
+	RemovePolicySetPolicies(ctx context.Context, policySetID string, policyIDs []string) error
+	AddPolicySetWorkspaces(ctx context.Context, policySetID string, workspaceIDs []string) error
+	RemovePolicySetWorkspaces(ctx context.Context, policySetID string, workspaceIDs []string) error
+	AddPolicySetWorkspaceExclusions(ctx context.Context, policySetID string, workspaceIDs []string) error
+	RemovePolicySetWorkspaceExclusions(ctx context.Context, policySetID string, workspaceIDs []string) error
+	AddPolicySetProjects(ctx context.Context, policySetID string, projectIDs []string) error
+	RemovePolicySetProjects(ctx context.Context, policySetID string, projectIDs []string) error
+
+	// Task stage methods
+	ListRunTaskStages(ctx context.Context, runID string, options *tfe.TaskStageListOptions) ([]*tfe.TaskStage, *tfe.Pagination, error)
+	ReadTaskStage(ctx context.Context, taskStageID string) (*tfe.TaskStage, error)
+	OverrideTaskStage(ctx context.Context, taskStageID string, options tfe.TaskStageOverrideOptions) (*tfe.TaskStage, error)
+	ListPolicyEvaluations(ctx context.Context, taskStageID string, options *tfe.PolicyEvaluationListOptions) ([]*tfe.PolicyEvaluation, *tfe.Pagination, error)
+	ListPolicySetOutcomes(ctx context.Context, policyEvaluationID string, options *tfe.PolicySetOutcomeListOptions) ([]*tfe.PolicySetOutcome, *tfe.Pagination, error)
+	ReadPolicySetOutcome(ctx context.Context, policySetOutcomeID string) (*tfe.PolicySetOutcome, error)
+
+	// Run task methods
+	ListRunTasks(ctx context.Context, organization string, options *tfe.RunTaskListOptions) ([]*tfe.RunTask, *tfe.Pagination, error)
+	CreateRunTask(ctx context.Context, organization string, options tfe.RunTaskCreateOptions) (*tfe.RunTask, error)
+	ReadRunTask(ctx context.Context, runTaskID string) (*tfe.RunTask, error)
+	UpdateRunTask(ctx context.Context, runTaskID string, options tfe.RunTaskUpdateOptions) (*tfe.RunTask, error)
+	DeleteRunTask(ctx context.Context, runTaskID string) error
+
+	// Workspace run task methods
+	ListWorkspaceRunTasks(ctx context.Context, workspaceID string, options *tfe.WorkspaceRunTaskListOptions) ([]*tfe.WorkspaceRunTask, *tfe.Pagination, error)
+	CreateWorkspaceRunTask(ctx context.Context, workspaceID string, options tfe.WorkspaceRunTaskCreateOptions) (*tfe.WorkspaceRunTask, error)
+	ReadWorkspaceRunTask(ctx context.Context, workspaceID, workspaceTaskID string) (*tfe.WorkspaceRunTask, error)
+	UpdateWorkspaceRunTask(ctx context.Context, workspaceID, workspaceTaskID string, options tfe.WorkspaceRunTaskUpdateOptions) (*tfe.WorkspaceRunTask, error)
+	DeleteWorkspaceRunTask(ctx context.Context, workspaceID, workspaceTaskID string) error
+
+	// Task result methods
+	ReadTaskResult(ctx context.Context, taskResultID string) (*tfe.TaskResult, error)
+	CallbackTaskResult(ctx context.Context, taskResultID, token string, options tfe.TaskResultCallbackRequestOptions) error
+	ExpireTaskResults(ctx context.Context) error
+
+	// Notification configuration methods
+	ListNotificationConfigurations(ctx context.Context, workspaceID string, options *tfe.NotificationConfigurationListOptions) ([]*tfe.NotificationConfiguration, *tfe.Pagination, error)
+	CreateNotificationConfiguration(ctx context.Context, workspaceID string, options tfe.NotificationConfigurationCreateOptions) (*tfe.NotificationConfiguration, error)
+	ReadNotificationConfiguration(ctx context.Context, notificationConfigurationID string) (*tfe.NotificationConfiguration, error)
+	UpdateNotificationConfiguration(ctx context.Context, notificationConfigurationID string, options tfe.NotificationConfigurationUpdateOptions) (*tfe.NotificationConfiguration, error)
+	DeleteNotificationConfiguration(ctx context.Context, notificationConfigurationID string) error
+	VerifyNotificationConfiguration(ctx context.Context, notificationConfigurationID string) (*tfe.NotificationConfiguration, error)
+
+	// Team methods
+	ListTeams(ctx context.Context, organization string, options *tfe.TeamListOptions) ([]*tfe.Team, *tfe.Pagination, error)
+	CreateTeam(ctx context.Context, organization string, options tfe.TeamCreateOptions) (*tfe.Team, error)
+	ReadTeam(ctx context.Context, teamID string) (*tfe.Team, error)
+	UpdateTeam(ctx context.Context, teamID string, options tfe.TeamUpdateOptions) (*tfe.Team, error)
+	DeleteTeam(ctx context.Context, teamID string) error
+	AddTeamMembers(ctx context.Context, teamID string, options tfe.TeamMemberAddOptions) error
+	RemoveTeamMembers(ctx context.Context, teamID string, options tfe.TeamMemberRemoveOptions) error
+
+	// Team access methods
+	ListTeamWorkspaceAccesses(ctx context.Context, options *tfe.TeamAccessListOptions) ([]*tfe.TeamAccess, *tfe.Pagination, error)
+	AddTeamWorkspaceAccess(ctx context.Context, options tfe.TeamAccessAddOptions) (*tfe.TeamAccess, error)
+	ReadTeamWorkspaceAccess(ctx context.Context, teamAccessID string) (*tfe.TeamAccess, error)
+	UpdateTeamWorkspaceAccess(ctx context.Context, teamAccessID string, options tfe.TeamAccessUpdateOptions) (*tfe.TeamAccess, error)
+	RemoveTeamWorkspaceAccess(ctx context.Context, teamAccessID string) error
+	ListTeamProjectAccesses(ctx context.Context, options *tfe.TeamProjectAccessListOptions) ([]*tfe.TeamProjectAccess, *tfe.Pagination, error)
+	AddTeamProjectAccess(ctx context.Context, options tfe.TeamProjectAccessAddOptions) (*tfe.TeamProjectAccess, error)
+	ReadTeamProjectAccess(ctx context.Context, teamProjectAccessID string) (*tfe.TeamProjectAccess, error)
+	UpdateTeamProjectAccess(ctx context.Context, teamProjectAccessID string, options tfe.TeamProjectAccessUpdateOptions) (*tfe.TeamProjectAccess, error)
+	RemoveTeamProjectAccess(ctx context.Context, teamProjectAccessID string) error
+
+	// Organization membership methods
+	ListOrganizationMemberships(ctx context.Context, organization string, options *tfe.OrganizationMembershipListOptions) ([]*tfe.OrganizationMembership, *tfe.Pagination, error)
+	ListCurrentUserOrganizationMemberships(ctx context.Context) ([]*tfe.OrganizationMembership, error)
+	CreateOrganizationMembership(ctx context.Context, organization string, options tfe.OrganizationMembershipCreateOptions, baseURL string) (*tfe.OrganizationMembership, error)
+	ReadOrganizationMembership(ctx context.Context, membershipID string) (*tfe.OrganizationMembership, error)
+	DeleteOrganizationMembership(ctx context.Context, membershipID string) error
+	AcceptOrganizationMembership(ctx context.Context, membershipID string) (*tfe.OrganizationMembership, error)
+	DeclineOrganizationMembership(ctx context.Context, membershipID string) error
+	ReadInvitation(ctx context.Context, token string) (*Invitation, error)
+	AcceptInvitation(ctx context.Context, token, password string) error
+	DeclineInvitation(ctx context.Context, token string) error
+
 	// User methods
 	ListUsers(ctx context.Context) ([]*tfe.User, error)
 	CreateUser(ctx context.Context, user *tfe.User) (*tfe.User, error)
@@ -34,16 +232,125 @@ type Service interface {
 	UpdateUser(ctx context.Context, userID string, user *tfe.User) (*tfe.User, error)
 	DeleteUser(ctx context.Context, userID string) error
 	ReadCurrentUser(ctx context.Context) (*tfe.User, error)
+	UpdateCurrentUserPassword(ctx context.Context, currentPassword, newPassword string) error
+
+	// Login methods
+	AuthenticateUser(ctx context.Context, login, password string) (*tfe.User, error)
+	CreateAuthorizationCode(ctx context.Context, userID, clientID, redirectURI, codeChallenge string) (string, error)
+	ExchangeAuthorizationCode(ctx context.Context, code, clientID, redirectURI, codeVerifier string) (*tfe.UserToken, error)
+
+	// Authentication token methods
+	AuthenticateToken(ctx context.Context, token string) (context.Context, error)
+	ListUserTokens(ctx context.Context, userID string) ([]*tfe.UserToken, error)
+	CreateUserToken(ctx context.Context, userID string, options TokenCreateOptions) (*tfe.UserToken, error)
+	ReadUserToken(ctx context.Context, tokenID string) (*tfe.UserToken, error)
+	DeleteUserToken(ctx context.Context, tokenID string) error
+	CreateTeamToken(ctx context.Context, teamID string, options TokenCreateOptions) (*tfe.TeamToken, error)
+	ReadTeamToken(ctx context.Context, teamID string) (*tfe.TeamToken, error)
+	DeleteTeamToken(ctx context.Context, teamID string) error
+	CreateOrganizationToken(ctx context.Context, organization string, tokenType *tfe.TokenType, options TokenCreateOptions) (*tfe.OrganizationToken, error)
+	ReadOrganizationToken(ctx context.Context, organization string, tokenType *tfe.TokenType) (*tfe.OrganizationToken, error)
+	DeleteOrganizationToken(ctx context.Context, organization string, tokenType *tfe.TokenType) error
+
+	// Agent pool methods
+	ListAgentPools(ctx context.Context, organization string, options *tfe.AgentPoolListOptions) ([]*tfe.AgentPool, *tfe.Pagination, error)
+	CreateAgentPool(ctx context.Context, organization string, options tfe.AgentPoolCreateOptions) (*tfe.AgentPool, error)
+	ReadAgentPool(ctx context.Context, agentPoolID string) (*tfe.AgentPool, error)
+	UpdateAgentPool(ctx context.Context, agentPoolID string, options tfe.AgentPoolUpdateOptions) (*tfe.AgentPool, error)
+	DeleteAgentPool(ctx context.Context, agentPoolID string) error
+	ListAgentTokens(ctx context.Context, agentPoolID string) ([]*tfe.AgentToken, error)
+	CreateAgentToken(ctx context.Context, agentPoolID string, options TokenCreateOptions) (*tfe.AgentToken, error)
+	ReadAgentToken(ctx context.Context, tokenID string) (*tfe.AgentToken, error)
+	DeleteAgentToken(ctx context.Context, tokenID string) error
+	ListAgents(ctx context.Context, agentPoolID string, options *tfe.AgentListOptions) ([]*tfe.Agent, *tfe.Pagination, error)
+	ReadAgent(ctx context.Context, agentID string) (*tfe.Agent, error)
+
+	// Agent protocol methods
+	RegisterAgent(ctx context.Context, name, ipAddress string) (*tfe.Agent, error)
+	AgentHeartbeat(ctx context.Context, agentID string) error
+	ExitAgent(ctx context.Context, agentID string) error
+	ClaimAgentJob(ctx context.Context, agentID string) (*RunJob, error)
+	AuthorizeAgentRun(ctx context.Context, agentID, runID string) (context.Context, error)
+	DownloadAgentConfigurationVersion(ctx context.Context, agentID, cvID string) (io.ReadCloser, error)
+
+	// Registry module methods
+	ListRegistryModules(ctx context.Context, organization string, options *tfe.RegistryModuleListOptions) ([]*tfe.RegistryModule, *tfe.Pagination, error)
+	CreateRegistryModule(ctx context.Context, organization string, options tfe.RegistryModuleCreateOptions) (*tfe.RegistryModule, error)
+	ReadRegistryModule(ctx context.Context, moduleID tfe.RegistryModuleID) (*tfe.RegistryModule, error)
+	UpdateRegistryModule(ctx context.Context, moduleID tfe.RegistryModuleID, options tfe.RegistryModuleUpdateOptions) (*tfe.RegistryModule, error)
+	DeleteRegistryModule(ctx context.Context, moduleID tfe.RegistryModuleID) error
+	CreateRegistryModuleVersion(ctx context.Context, moduleID tfe.RegistryModuleID, options tfe.RegistryModuleCreateVersionOptions) (*tfe.RegistryModuleVersion, error)
+	ReadRegistryModuleVersion(ctx context.Context, moduleID tfe.RegistryModuleID, version string) (*tfe.RegistryModuleVersion, error)
+	DeleteRegistryModuleVersion(ctx context.Context, moduleID tfe.RegistryModuleID, version string) error
+	UploadRegistryModuleVersion(ctx context.Context, versionID string, r io.Reader) error
+	DownloadRegistryModuleVersion(ctx context.Context, versionID string) (io.ReadCloser, error)
+	ListModuleProducers(ctx context.Context, organization string) ([]*tfe.Organization, error)
+
+	// Module registry protocol methods
+	ListModuleRegistryVersions(ctx context.Context, namespace, name, provider string) ([]string, error)
+	ReadModuleRegistryVersion(ctx context.Context, namespace, name, provider, version string) (*tfe.RegistryModuleVersion, error)
+
+	// GPG key methods
+	ListGPGKeys(ctx context.Context, namespaces []string, listOptions tfe.ListOptions) ([]*tfe.GPGKey, *tfe.Pagination, error)
+	CreateGPGKey(ctx context.Context, registryName tfe.RegistryName, options tfe.GPGKeyCreateOptions) (*tfe.GPGKey, error)
+	ReadGPGKey(ctx context.Context, keyID tfe.GPGKeyID) (*tfe.GPGKey, error)
+	UpdateGPGKey(ctx context.Context, keyID tfe.GPGKeyID, options tfe.GPGKeyUpdateOptions) (*tfe.GPGKey, error)
+	DeleteGPGKey(ctx context.Context, keyID tfe.GPGKeyID) error
+
+	// Registry provider methods
+	ListRegistryProviders(ctx context.Context, organization string, options *tfe.RegistryProviderListOptions) ([]*tfe.RegistryProvider, *tfe.Pagination, error)
+	CreateRegistryProvider(ctx context.Context, organization string, options tfe.RegistryProviderCreateOptions) (*tfe.RegistryProvider, error)
+	ReadRegistryProvider(ctx context.Context, providerID tfe.RegistryProviderID) (*tfe.RegistryProvider, error)
+	DeleteRegistryProvider(ctx context.Context, providerID tfe.RegistryProviderID) error
+	ListRegistryProviderVersions(ctx context.Context, providerID tfe.RegistryProviderID, options *tfe.RegistryProviderVersionListOptions) ([]*tfe.RegistryProviderVersion, *tfe.Pagination, error)
+	CreateRegistryProviderVersion(ctx context.Context, providerID tfe.RegistryProviderID, options tfe.RegistryProviderVersionCreateOptions) (*tfe.RegistryProviderVersion, error)
+	ReadRegistryProviderVersion(ctx context.Context, versionID tfe.RegistryProviderVersionID) (*tfe.RegistryProviderVersion, error)
+	DeleteRegistryProviderVersion(ctx context.Context, versionID tfe.RegistryProviderVersionID) error
+	ListRegistryProviderPlatforms(ctx context.Context, versionID tfe.RegistryProviderVersionID, options *tfe.RegistryProviderPlatformListOptions) ([]*tfe.RegistryProviderPlatform, *tfe.Pagination, error)
+	CreateRegistryProviderPlatform(ctx context.Context, versionID tfe.RegistryProviderVersionID, options tfe.RegistryProviderPlatformCreateOptions) (*tfe.RegistryProviderPlatform, error)
+	ReadRegistryProviderPlatform(ctx context.Context, platformID tfe.RegistryProviderPlatformID) (*tfe.RegistryProviderPlatform, error)
+	DeleteRegistryProviderPlatform(ctx context.Context, platformID tfe.RegistryProviderPlatformID) error
+	UploadProviderShasums(ctx context.Context, versionID string, r io.Reader) error
+	UploadProviderShasumsSignature(ctx context.Context, versionID string, r io.Reader) error
+	DownloadProviderShasums(ctx context.Context, versionID string) (io.ReadCloser, error)
+	DownloadProviderShasumsSignature(ctx context.Context, versionID string) (io.ReadCloser, error)
+	UploadProviderBinary(ctx context.Context, platformID string, r io.Reader) error
+	DownloadProviderBinary(ctx context.Context, platformID string) (io.ReadCloser, error)
+
+	// Provider registry protocol methods
+	ListProviderRegistryVersions(ctx context.Context, namespace, name string) ([]ProviderRegistryVersion, error)
+	ReadProviderRegistryPackage(ctx context.Context, namespace, name, version, os, arch string) (*ProviderPackage, error)
+
+	// Provider mirror methods
+	ListProviderMirrorPackages(ctx context.Context, listOptions tfe.ListOptions) ([]*ProviderMirrorPackage, *tfe.Pagination, error)
+	UploadProviderMirrorPackage(ctx context.Context, packageID ProviderMirrorPackageID, r io.Reader) (*ProviderMirrorPackage, error)
+	ReadProviderMirrorPackage(ctx context.Context, packageID ProviderMirrorPackageID) (*ProviderMirrorPackage, error)
+	DeleteProviderMirrorPackage(ctx context.Context, packageID ProviderMirrorPackageID) error
+	DownloadProviderMirrorPackage(ctx context.Context, packageID string) (io.ReadCloser, error)
+	ListProviderMirrorVersions(ctx context.Context, hostname, namespace, providerType string) ([]string, error)
+	ListProviderMirrorArchives(ctx context.Context, hostname, namespace, providerType, version string) ([]*ProviderMirrorPackage, error)
 }
 
 type service struct {
-	db     *gorm.DB
-	logger *zap.Logger
+	db            *gorm.DB
+	store         storage.BlobStore
+	secrets       *secrets.Cipher
+	mailer        mail.Mailer
+	policies      *policy.Engine
+	runTasks      *runtask.Client
+	notifications *notification.Client
+	logger        *zap.Logger
 }
 
-func NewService(db *gorm.DB, logger *zap.Logger) Service {
+func NewService(db *gorm.DB, store storage.BlobStore, secrets *secrets.Cipher, mailer mail.Mailer, policies *policy.Engine, runTasks *runtask.Client, notifications *notification.Client, logger *zap.Logger) Service {
 	return &service{
-		db:     db,
-		logger: logger.With(zap.String("component", "services")),
+		db:            db,
+		store:         store,
+		secrets:       secrets,
+		mailer:        mailer,
+		policies:      policies,
+		runTasks:      runTasks,
+		notifications: notifications,
+		logger:        logger.With(zap.String("component", "services")),
 	}
 }
@@ -0,0 +1,333 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"github.com/open-tfe/tfe-service/internal/storage"
+	"go.uber.org/zap"
+)
+
+// Plan and apply logs follow the archivist protocol used by go-tfe: a log
+// starts with STX and, once sealed, ends with ETX. While a phase executes its
+// log is kept as chunk objects named by their offset, so readers can tail it;
+// sealing concatenates the chunks into a single object.
+const (
+	logStartOfText = 0x02
+	logEndOfText   = 0x03
+
+	// maxLogReadLimit caps the size of a single log read.
+	maxLogReadLimit = 1 << 20
+)
+
+var (
+	planSummaryRegexp    = regexp.MustCompile(`Plan: (?:(\d+) to import, )?(\d+) to add, (\d+) to change, (\d+) to destroy\.`)
+	applySummaryRegexp   = regexp.MustCompile(`Resources: (?:(\d+) imported, )?(\d+) added, (\d+) changed, (\d+) destroyed\.`)
+	destroySummaryRegexp = regexp.MustCompile(`Destroy complete! Resources: (\d+) destroyed\.`)
+)
+
+// resourceCounts are the resource totals reported by a plan or apply log.
+type resourceCounts struct {
+	Imports      int
+	Additions    int
+	Changes      int
+	Destructions int
+}
+
+// AppendRunLog appends executor output to the log of a run phase.
+func (s *service) AppendRunLog(ctx context.Context, runID, phase string, data []byte) error {
+	prefix, err := s.runLogPrefix(runID, phase)
+	if err != nil {
+		return err
+	}
+	if _, err := s.store.Stat(ctx, logKey(prefix)); err == nil {
+		return fmt.Errorf("%w: the %s log is already sealed", ErrConflict, phase)
+	}
+
+	chunks, err := s.store.List(ctx, logChunkPrefix(prefix))
+	if err != nil {
+		s.logger.Error("failed to list log chunks", zap.Error(err))
+		return err
+	}
+	var offset int64
+	for _, chunk := range chunks {
+		offset += chunk.Size
+	}
+	if offset == 0 {
+		data = append([]byte{logStartOfText}, data...)
+	}
+
+	if err := s.store.Put(ctx, logChunkKey(prefix, offset), bytes.NewReader(data), int64(len(data))); err != nil {
+		s.logger.Error("failed to store log chunk", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// SealRunLog terminates the log of a run phase and records the resource
+// counts it reports. Sealing an already sealed log is a no-op.
+func (s *service) SealRunLog(ctx context.Context, runID, phase string) error {
+	run, err := s.findRun(runID)
+	if err != nil {
+		return err
+	}
+
+	var prefix string
+	var model interface{}
+	var summary func([]byte) (resourceCounts, bool)
+	switch phase {
+	case PhasePlan:
+		prefix, model, summary = planLogPrefix(run.Plan.ID), &models.Plan{}, planSummary
+	case PhaseApply:
+		prefix, model, summary = applyLogPrefix(run.Apply.ID), &models.Apply{}, applySummary
+	default:
+		return fmt.Errorf("%w: unknown phase %q", ErrInvalidAttribute, phase)
+	}
+
+	if _, err := s.store.Stat(ctx, logKey(prefix)); err == nil {
+		return nil
+	}
+	chunks, err := s.store.List(ctx, logChunkPrefix(prefix))
+	if err != nil {
+		s.logger.Error("failed to list log chunks", zap.Error(err))
+		return err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(s.concatenateLog(ctx, pw, chunks))
+	}()
+	if err := s.store.Put(ctx, logKey(prefix), pr, -1); err != nil {
+		pr.CloseWithError(err)
+		s.logger.Error("failed to seal log", zap.Error(err))
+		return err
+	}
+	for _, chunk := range chunks {
+		s.deleteObjects(ctx, chunk.Key)
+	}
+
+	counts, err := s.scanLog(ctx, logKey(prefix), summary)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Model(model).Where("run_id = ?", run.ID).Updates(map[string]interface{}{
+		"resource_imports":      counts.Imports,
+		"resource_additions":    counts.Additions,
+		"resource_changes":      counts.Changes,
+		"resource_destructions": counts.Destructions,
+	}).Error; err != nil {
+		s.logger.Error("failed to update resource counts", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// ReadPlanLog returns up to limit bytes of a plan log starting at offset.
+func (s *service) ReadPlanLog(ctx context.Context, planID string, offset, limit int64) ([]byte, error) {
+	plan, err := s.findPlan(planID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeRunID(ctx, plan.RunID, wsRead); err != nil {
+		return nil, err
+	}
+	return s.readLog(ctx, planLogPrefix(plan.ID), offset, limit)
+}
+
+// ReadApplyLog returns up to limit bytes of an apply log starting at offset.
+func (s *service) ReadApplyLog(ctx context.Context, applyID string, offset, limit int64) ([]byte, error) {
+	apply, err := s.findApply(applyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeRunID(ctx, apply.RunID, wsRead); err != nil {
+		return nil, err
+	}
+	return s.readLog(ctx, applyLogPrefix(apply.ID), offset, limit)
+}
+
+// readLog reads from the sealed log when there is one and from the chunks of
+// a log in progress otherwise. A log that has not started reads as empty.
+func (s *service) readLog(ctx context.Context, prefix string, offset, limit int64) ([]byte, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("%w: offset must not be negative", ErrInvalidAttribute)
+	}
+	if limit <= 0 || limit > maxLogReadLimit {
+		limit = maxLogReadLimit
+	}
+
+	data, err := s.readObjectRange(ctx, logKey(prefix), offset, limit)
+	if !errors.Is(err, storage.ErrNotFound) {
+		return data, err
+	}
+
+	chunks, err := s.store.List(ctx, logChunkPrefix(prefix))
+	if err != nil {
+		s.logger.Error("failed to list log chunks", zap.Error(err))
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	var pos int64
+	for _, chunk := range chunks {
+		start := pos
+		pos += chunk.Size
+		if pos <= offset {
+			continue
+		}
+		skip := int64(0)
+		if offset > start {
+			skip = offset - start
+		}
+		data, err := s.readObjectRange(ctx, chunk.Key, skip, limit-int64(buf.Len()))
+		if errors.Is(err, storage.ErrNotFound) {
+			// The log was sealed while reading; the next read will use it.
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+		if int64(buf.Len()) >= limit {
+			break
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// readObjectRange returns up to limit bytes of an object starting at offset.
+func (s *service) readObjectRange(ctx context.Context, key string, offset, limit int64) ([]byte, error) {
+	rc, err := s.store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	if _, err := io.CopyN(io.Discard, rc, offset); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return io.ReadAll(io.LimitReader(rc, limit))
+}
+
+// concatenateLog writes the chunks of a log in order, framed by STX and ETX.
+func (s *service) concatenateLog(ctx context.Context, w io.Writer, chunks []storage.ObjectInfo) error {
+	if len(chunks) == 0 {
+		if _, err := w.Write([]byte{logStartOfText}); err != nil {
+			return err
+		}
+	}
+	for _, chunk := range chunks {
+		rc, err := s.store.Get(ctx, chunk.Key)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	_, err := w.Write([]byte{logEndOfText})
+	return err
+}
+
+// scanLog returns the last resource summary found in a log.
+func (s *service) scanLog(ctx context.Context, key string, summary func([]byte) (resourceCounts, bool)) (resourceCounts, error) {
+	var counts resourceCounts
+	rc, err := s.store.Get(ctx, key)
+	if err != nil {
+		s.logger.Error("failed to read log", zap.Error(err))
+		return counts, err
+	}
+	defer rc.Close()
+
+	scanner := bufio.NewScanner(rc)
+	scanner.Buffer(make([]byte, 64*1024), maxLogReadLimit)
+	for scanner.Scan() {
+		if c, ok := summary(scanner.Bytes()); ok {
+			counts = c
+		}
+	}
+	return counts, scanner.Err()
+}
+
+func planSummary(line []byte) (resourceCounts, bool) {
+	m := planSummaryRegexp.FindSubmatch(line)
+	if m == nil {
+		return resourceCounts{}, false
+	}
+	return resourceCounts{
+		Imports:      atoi(m[1]),
+		Additions:    atoi(m[2]),
+		Changes:      atoi(m[3]),
+		Destructions: atoi(m[4]),
+	}, true
+}
+
+func applySummary(line []byte) (resourceCounts, bool) {
+	if m := destroySummaryRegexp.FindSubmatch(line); m != nil {
+		return resourceCounts{Destructions: atoi(m[1])}, true
+	}
+	m := applySummaryRegexp.FindSubmatch(line)
+	if m == nil {
+		return resourceCounts{}, false
+	}
+	return resourceCounts{
+		Imports:      atoi(m[1]),
+		Additions:    atoi(m[2]),
+		Changes:      atoi(m[3]),
+		Destructions: atoi(m[4]),
+	}, true
+}
+
+func atoi(b []byte) int {
+	n, _ := strconv.Atoi(string(b))
+	return n
+}
+
+func (s *service) runLogPrefix(runID, phase string) (string, error) {
+	run, err := s.findRun(runID)
+	if err != nil {
+		return "", err
+	}
+	switch phase {
+	case PhasePlan:
+		return planLogPrefix(run.Plan.ID), nil
+	case PhaseApply:
+		return applyLogPrefix(run.Apply.ID), nil
+	default:
+		return "", fmt.Errorf("%w: unknown phase %q", ErrInvalidAttribute, phase)
+	}
+}
+
+func planLogPrefix(planID uuid.UUID) string {
+	return "plans/" + planID.String()
+}
+
+func applyLogPrefix(applyID uuid.UUID) string {
+	return "applies/" + applyID.String()
+}
+
+func logKey(prefix string) string {
+	return prefix + "/log"
+}
+
+func logChunkPrefix(prefix string) string {
+	return prefix + "/log-chunks/"
+}
+
+// logChunkKey zero-pads the offset so that chunks list in log order.
+func logChunkKey(prefix string, offset int64) string {
+	return fmt.Sprintf("%s%016d", logChunkPrefix(prefix), offset)
+}
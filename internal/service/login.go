@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/constants"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// authorizationCodeTTL bounds how long a `terraform login` authorization code stays valid.
+const authorizationCodeTTL = 5 * time.Minute
+
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrInvalidGrant       = errors.New("invalid or expired authorization code")
+)
+
+func (s *service) AuthenticateUser(ctx context.Context, login, password string) (*tfe.User, error) {
+	var user models.User
+	if err := s.db.Where("email = ? OR username = ?", login, login).First(&user).Error; err != nil {
+		s.logger.Debug("login attempt for unknown user", zap.String("login", login))
+		return nil, ErrInvalidCredentials
+	}
+	if user.Password == "" {
+		s.logger.Debug("login attempt for user without password", zap.String("login", login))
+		return nil, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		s.logger.Debug("password mismatch", zap.String("login", login))
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := s.db.Model(&user).Update("last_login_at", time.Now()).Error; err != nil {
+		s.logger.Error("failed to record last login", zap.Error(err))
+	}
+	return user.ToTFE(), nil
+}
+
+func (s *service) UpdateCurrentUserPassword(ctx context.Context, currentPassword, newPassword string) error {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Users created through the API start without a password and may set one directly.
+	if user.Password != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPassword)); err != nil {
+			return ErrInvalidCredentials
+		}
+	}
+
+	hash, err := hashPassword(newPassword)
+	if err != nil {
+		s.logger.Error("failed to hash password", zap.Error(err))
+		return err
+	}
+	if err := s.db.Model(user).Update("password", hash).Error; err != nil {
+		s.logger.Error("failed to update password", zap.Error(err))
+		return err
+	}
+	s.auditUser(ctx, user.ID, models.AuditActionUpdate, user.ToTFE(), user.ToTFE(), "password")
+	return nil
+}
+
+func (s *service) CreateAuthorizationCode(ctx context.Context, userID, clientID, redirectURI, codeChallenge string) (string, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return "", err
+	}
+
+	code, err := generateSecret()
+	if err != nil {
+		return "", err
+	}
+
+	authCode := &models.OAuthAuthorizationCode{
+		CodeHash:      hashSecret(code),
+		ClientID:      clientID,
+		RedirectURI:   redirectURI,
+		CodeChallenge: codeChallenge,
+		ExpiresAt:     time.Now().Add(authorizationCodeTTL),
+		UserID:        id,
+	}
+	if err := s.db.Create(authCode).Error; err != nil {
+		s.logger.Error("failed to create authorization code", zap.Error(err))
+		return "", err
+	}
+	return code, nil
+}
+
+// ExchangeAuthorizationCode consumes an authorization code and issues a
+// user token for `terraform login`.
+func (s *service) ExchangeAuthorizationCode(ctx context.Context, code, clientID, redirectURI, codeVerifier string) (*tfe.UserToken, error) {
+	var authCode models.OAuthAuthorizationCode
+	if err := s.db.Preload("User").Where("code_hash = ?", hashSecret(code)).First(&authCode).Error; err != nil {
+		return nil, ErrInvalidGrant
+	}
+
+	// Codes are single use: whoever deletes the row wins the exchange.
+	result := s.db.Unscoped().Where("id = ?", authCode.ID).Delete(&models.OAuthAuthorizationCode{})
+	if result.Error != nil {
+		s.logger.Error("failed to consume authorization code", zap.Error(result.Error))
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidGrant
+	}
+
+	if time.Now().After(authCode.ExpiresAt) ||
+		authCode.ClientID != clientID ||
+		authCode.RedirectURI != redirectURI ||
+		pkceChallenge(codeVerifier) != authCode.CodeChallenge {
+		return nil, ErrInvalidGrant
+	}
+	if authCode.User == nil {
+		return nil, ErrInvalidGrant
+	}
+
+	expiredAt := time.Now().Add(constants.LoginTokenTTL)
+	t := &models.AuthenticationToken{
+		Kind:        models.TokenKindUser,
+		UserID:      &authCode.User.ID,
+		CreatedByID: &authCode.User.ID,
+	}
+	token, err := s.createToken(ctx, t, TokenCreateOptions{Description: "terraform login", ExpiredAt: &expiredAt})
+	if err != nil {
+		return nil, err
+	}
+	tfeToken := t.ToTFEUserToken()
+	s.auditUser(ctx, authCode.User.ID, models.AuditActionCreate, nil, tfeToken)
+	tfeToken.Token = token
+	return tfeToken, nil
+}
+
+// hashPassword returns the bcrypt hash stored for a password.
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// pkceChallenge derives the S256 code challenge for a PKCE code verifier.
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// generateSecret returns a random, URL safe secret for one-time codes and tokens.
+func generateSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// hashSecret returns the hex encoded SHA-256 digest used to store secrets at rest.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
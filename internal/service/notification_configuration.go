@@ -0,0 +1,515 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"github.com/open-tfe/tfe-service/internal/notification"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// notificationPayloadVersion is the version of the generic payload.
+const notificationPayloadVersion = 1
+
+// notificationVerificationTrigger marks the payload sent by the verify
+// action.
+const notificationVerificationTrigger = "verification"
+
+// deliveryResponsesKept is how many delivery responses are kept per
+// configuration.
+const deliveryResponsesKept = 10
+
+// notificationDestinations are the supported destination types. Email
+// destinations are not offered: the service has no users' mailboxes to
+// notify on their behalf.
+var notificationDestinations = map[tfe.NotificationDestinationType]bool{
+	tfe.NotificationDestinationTypeGeneric:        true,
+	tfe.NotificationDestinationTypeSlack:          true,
+	tfe.NotificationDestinationTypeMicrosoftTeams: true,
+}
+
+// notificationTriggers are the triggers a configuration may subscribe to.
+// Assessment and auto-destroy triggers are accepted for compatibility but
+// never fire, as workspaces have no health assessments or auto-destroy.
+var notificationTriggers = map[tfe.NotificationTriggerType]bool{
+	tfe.NotificationTriggerCreated:                        true,
+	tfe.NotificationTriggerPlanning:                       true,
+	tfe.NotificationTriggerNeedsAttention:                 true,
+	tfe.NotificationTriggerApplying:                       true,
+	tfe.NotificationTriggerCompleted:                      true,
+	tfe.NotificationTriggerErrored:                        true,
+	tfe.NotificationTriggerAssessmentDrifted:              true,
+	tfe.NotificationTriggerAssessmentFailed:               true,
+	tfe.NotificationTriggerAssessmentCheckFailed:          true,
+	tfe.NotificationTriggerWorkspaceAutoDestroyReminder:   true,
+	tfe.NotificationTriggerWorkspaceAutoDestroyRunResults: true,
+}
+
+// notificationMessages are the headlines of run notifications.
+var notificationMessages = map[tfe.NotificationTriggerType]string{
+	tfe.NotificationTriggerCreated:        "Run Created",
+	tfe.NotificationTriggerPlanning:       "Run Planning",
+	tfe.NotificationTriggerNeedsAttention: "Run Needs Attention",
+	tfe.NotificationTriggerApplying:       "Run Applying",
+	tfe.NotificationTriggerCompleted:      "Run Completed",
+	tfe.NotificationTriggerErrored:        "Run Errored",
+}
+
+func (s *service) ListNotificationConfigurations(ctx context.Context, workspaceID string, options *tfe.NotificationConfigurationListOptions) ([]*tfe.NotificationConfiguration, *tfe.Pagination, error) {
+	ws, err := s.findWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, ws, wsRead); err != nil {
+		return nil, nil, err
+	}
+
+	var listOptions tfe.ListOptions
+	if options != nil {
+		listOptions = options.ListOptions
+	}
+	db, pagination, err := paginate(s.db.Model(&models.NotificationConfiguration{}).Where("workspace_id = ?", ws.ID), listOptions)
+	if err != nil {
+		s.logger.Error("failed to count notification configurations", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var configs []*models.NotificationConfiguration
+	if err := db.Order("created_at ASC").Find(&configs).Error; err != nil {
+		s.logger.Error("failed to list notification configurations", zap.Error(err))
+		return nil, nil, err
+	}
+
+	tfeConfigs := make([]*tfe.NotificationConfiguration, len(configs))
+	for i, config := range configs {
+		if err := s.loadDeliveryResponses(config); err != nil {
+			return nil, nil, err
+		}
+		tfeConfigs[i] = config.ToTFE()
+	}
+	return tfeConfigs, pagination, nil
+}
+
+// CreateNotificationConfiguration subscribes a destination to runs of the
+// workspace. The token is stored encrypted and never returned.
+func (s *service) CreateNotificationConfiguration(ctx context.Context, workspaceID string, options tfe.NotificationConfigurationCreateOptions) (*tfe.NotificationConfiguration, error) {
+	ws, err := s.findWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, ws, wsAdmin); err != nil {
+		return nil, err
+	}
+	if options.DestinationType == nil {
+		return nil, fmt.Errorf("%w: destination type is required", ErrInvalidAttribute)
+	}
+	if options.Enabled == nil {
+		return nil, fmt.Errorf("%w: enabled is required", ErrInvalidAttribute)
+	}
+
+	config := &models.NotificationConfiguration{
+		DestinationType: string(*options.DestinationType),
+		Enabled:         *options.Enabled,
+		Triggers:        notificationTriggerNames(options.Triggers),
+		WorkspaceID:     ws.ID,
+	}
+	if options.Name != nil {
+		config.Name = *options.Name
+	}
+	if options.URL != nil {
+		config.URL = *options.URL
+	}
+	if options.Token != nil {
+		if err := s.setNotificationToken(config, *options.Token); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateNotificationConfiguration(config); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Omit(clause.Associations).Create(config).Error; err != nil {
+		s.logger.Error("failed to create notification configuration", zap.Error(err))
+		return nil, err
+	}
+	created, err := s.ReadNotificationConfiguration(ctx, config.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, ws.OrganizationID, models.AuditActionCreate, nil, created)
+	return created, nil
+}
+
+func (s *service) ReadNotificationConfiguration(ctx context.Context, notificationConfigurationID string) (*tfe.NotificationConfiguration, error) {
+	config, err := s.findAuthorizedNotificationConfiguration(ctx, notificationConfigurationID, wsRead)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadDeliveryResponses(config); err != nil {
+		return nil, err
+	}
+	return config.ToTFE(), nil
+}
+
+// UpdateNotificationConfiguration updates a notification configuration.
+// An empty token removes the token.
+func (s *service) UpdateNotificationConfiguration(ctx context.Context, notificationConfigurationID string, options tfe.NotificationConfigurationUpdateOptions) (*tfe.NotificationConfiguration, error) {
+	config, err := s.findAuthorizedNotificationConfiguration(ctx, notificationConfigurationID, wsAdmin)
+	if err != nil {
+		return nil, err
+	}
+	before, err := s.notificationConfigurationSnapshot(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Name != nil {
+		config.Name = *options.Name
+	}
+	if options.Enabled != nil {
+		config.Enabled = *options.Enabled
+	}
+	if options.URL != nil {
+		config.URL = *options.URL
+	}
+	if options.Triggers != nil {
+		config.Triggers = notificationTriggerNames(options.Triggers)
+	}
+	if options.Token != nil {
+		if err := s.setNotificationToken(config, *options.Token); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateNotificationConfiguration(config); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Omit(clause.Associations).Save(config).Error; err != nil {
+		s.logger.Error("failed to update notification configuration", zap.Error(err))
+		return nil, err
+	}
+	var secrets []string
+	if options.Token != nil {
+		secrets = append(secrets, "token")
+	}
+	return s.auditNotificationConfiguration(ctx, config, models.AuditActionUpdate, before, secrets...)
+}
+
+// DeleteNotificationConfiguration deletes a notification configuration and
+// its delivery responses.
+func (s *service) DeleteNotificationConfiguration(ctx context.Context, notificationConfigurationID string) error {
+	config, err := s.findAuthorizedNotificationConfiguration(ctx, notificationConfigurationID, wsAdmin)
+	if err != nil {
+		return err
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("notification_configuration_id = ?", config.ID).Delete(&models.NotificationDeliveryResponse{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", config.ID).Delete(&models.NotificationConfiguration{}).Error
+	})
+	if err != nil {
+		s.logger.Error("failed to delete notification configuration", zap.Error(err))
+		return err
+	}
+	s.auditWorkspace(ctx, config.WorkspaceID, models.AuditActionDelete, config.ToTFE(), nil)
+	return nil
+}
+
+// VerifyNotificationConfiguration sends a test payload to the destination,
+// whether or not the configuration is enabled, and returns the
+// configuration with the destination's response recorded. The test
+// payload is sent once and not retried.
+func (s *service) VerifyNotificationConfiguration(ctx context.Context, notificationConfigurationID string) (*tfe.NotificationConfiguration, error) {
+	config, err := s.findAuthorizedNotificationConfiguration(ctx, notificationConfigurationID, wsAdmin)
+	if err != nil {
+		return nil, err
+	}
+	before, err := s.notificationConfigurationSnapshot(config)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := &notification.Payload{
+		PayloadVersion:              notificationPayloadVersion,
+		NotificationConfigurationID: config.ID.String(),
+		Notifications: []notification.Notice{{
+			Message: "Verification of " + config.Name,
+			Trigger: notificationVerificationTrigger,
+		}},
+	}
+	if _, err := s.deliverNotification(ctx, config, payload); err != nil {
+		return nil, err
+	}
+	return s.auditNotificationConfiguration(ctx, config, models.AuditActionVerify, before)
+}
+
+// notificationConfigurationSnapshot returns a configuration as it is read,
+// delivery responses included, to compare it after a change.
+func (s *service) notificationConfigurationSnapshot(config *models.NotificationConfiguration) (*tfe.NotificationConfiguration, error) {
+	if err := s.loadDeliveryResponses(config); err != nil {
+		return nil, err
+	}
+	return config.ToTFE(), nil
+}
+
+// auditNotificationConfiguration records a change to a notification
+// configuration and returns the configuration as it is now.
+func (s *service) auditNotificationConfiguration(ctx context.Context, config *models.NotificationConfiguration, action string, before *tfe.NotificationConfiguration, secrets ...string) (*tfe.NotificationConfiguration, error) {
+	updated, err := s.ReadNotificationConfiguration(ctx, config.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	s.auditWorkspace(ctx, config.WorkspaceID, action, before, updated, secrets...)
+	return updated, nil
+}
+
+// notificationTrigger returns the trigger fired by a run entering status,
+// if any. Confirmable runs only need attention when someone has to confirm
+// them.
+func notificationTrigger(run *models.Run, status tfe.RunStatus) (tfe.NotificationTriggerType, bool) {
+	switch status {
+	case tfe.RunPending:
+		return tfe.NotificationTriggerCreated, true
+	case tfe.RunPlanning:
+		return tfe.NotificationTriggerPlanning, true
+	case tfe.RunPlanned, tfe.RunCostEstimated, tfe.RunPolicyChecked, tfe.RunPostPlanCompleted:
+		if run.AutoApply || run.PlanOnly {
+			return "", false
+		}
+		return tfe.NotificationTriggerNeedsAttention, true
+	case tfe.RunPostPlanAwaitingDecision:
+		return tfe.NotificationTriggerNeedsAttention, true
+	case tfe.RunApplying:
+		return tfe.NotificationTriggerApplying, true
+	case tfe.RunApplied, tfe.RunPlannedAndFinished, tfe.RunPlannedAndSaved:
+		return tfe.NotificationTriggerCompleted, true
+	case tfe.RunErrored:
+		return tfe.NotificationTriggerErrored, true
+	default:
+		return "", false
+	}
+}
+
+// notifyRun notifies the enabled configurations of the run's workspace
+// that subscribe to the trigger fired by the run entering status.
+// Deliveries happen in the background; failing to notify never fails the
+// run.
+func (s *service) notifyRun(run *models.Run, status tfe.RunStatus) {
+	trigger, ok := notificationTrigger(run, status)
+	if !ok {
+		return
+	}
+
+	var configs []*models.NotificationConfiguration
+	if err := s.db.Where("workspace_id = ? AND enabled = ?", run.WorkspaceID, true).Find(&configs).Error; err != nil {
+		s.logger.Error("failed to list notification configurations", zap.Error(err))
+		return
+	}
+	var subscribed []*models.NotificationConfiguration
+	for _, config := range configs {
+		if config.HasTrigger(string(trigger)) {
+			subscribed = append(subscribed, config)
+		}
+	}
+	if len(subscribed) == 0 {
+		return
+	}
+
+	payload, err := s.runNotificationPayload(run, status, trigger)
+	if err != nil {
+		s.logger.Warn("failed to build notification payload", zap.String("run", run.ID.String()), zap.Error(err))
+		return
+	}
+	for _, config := range subscribed {
+		configPayload := *payload
+		configPayload.NotificationConfigurationID = config.ID.String()
+		go s.sendNotification(config, &configPayload)
+	}
+}
+
+// runNotificationPayload describes a run that fired trigger.
+func (s *service) runNotificationPayload(run *models.Run, status tfe.RunStatus, trigger tfe.NotificationTriggerType) (*notification.Payload, error) {
+	run, err := s.findRun(run.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	var org models.Organization
+	if err := s.db.Select("name").Where("id = ?", run.Workspace.OrganizationID).First(&org).Error; err != nil {
+		s.logger.Error("failed to read organization", zap.Error(err))
+		return nil, err
+	}
+
+	runID := run.ID.String()
+	runURL := s.notifications.RunURL(runID)
+	workspaceID := run.Workspace.ID.String()
+	runStatus := string(status)
+	var createdBy *string
+	if run.CreatedBy != nil {
+		createdBy = &run.CreatedBy.Username
+	}
+	return &notification.Payload{
+		PayloadVersion:   notificationPayloadVersion,
+		RunURL:           &runURL,
+		RunID:            &runID,
+		RunMessage:       &run.Message,
+		RunCreatedAt:     &run.CreatedAt,
+		RunCreatedBy:     createdBy,
+		WorkspaceID:      &workspaceID,
+		WorkspaceName:    &run.Workspace.Name,
+		OrganizationName: &org.Name,
+		Notifications: []notification.Notice{{
+			Message:      notificationMessages[trigger],
+			Trigger:      string(trigger),
+			RunStatus:    &runStatus,
+			RunUpdatedAt: &run.UpdatedAt,
+		}},
+	}, nil
+}
+
+// sendNotification delivers payload, retrying with backoff while the
+// destination cannot be reached or fails itself.
+func (s *service) sendNotification(config *models.NotificationConfiguration, payload *notification.Payload) {
+	for attempt := 1; ; attempt++ {
+		response, err := s.deliverNotification(context.Background(), config, payload)
+		if err != nil || response.Successful || !response.Retryable() || attempt == notification.MaxAttempts {
+			return
+		}
+		time.Sleep(notification.RetryDelay(attempt))
+	}
+}
+
+// deliverNotification sends payload to the destination of config once and
+// records the destination's response, keeping only the most recent ones.
+func (s *service) deliverNotification(ctx context.Context, config *models.NotificationConfiguration, payload *notification.Payload) (*notification.Response, error) {
+	var token string
+	if config.EncryptedToken != "" {
+		var err error
+		if token, err = s.secrets.Decrypt(config.EncryptedToken); err != nil {
+			s.logger.Error("failed to decrypt notification token", zap.Error(err))
+			return nil, err
+		}
+	}
+	response, err := s.notifications.Deliver(ctx, notification.Destination{
+		Type:  config.DestinationType,
+		URL:   config.URL,
+		Token: token,
+	}, payload)
+	if err != nil {
+		s.logger.Error("failed to build notification", zap.String("notification_configuration", config.ID.String()), zap.Error(err))
+		return nil, err
+	}
+	if !response.Successful {
+		s.logger.Info("notification delivery failed",
+			zap.String("notification_configuration", config.ID.String()),
+			zap.Int("code", response.Code),
+		)
+	}
+
+	record := &models.NotificationDeliveryResponse{
+		URL:                         response.URL,
+		Code:                        response.Code,
+		Headers:                     response.Headers,
+		Body:                        response.Body,
+		Successful:                  response.Successful,
+		SentAt:                      response.SentAt,
+		NotificationConfigurationID: config.ID,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(record).Error; err != nil {
+			return err
+		}
+		kept := tx.Model(&models.NotificationDeliveryResponse{}).Select("id").
+			Where("notification_configuration_id = ?", config.ID).
+			Order("sent_at DESC").Limit(deliveryResponsesKept)
+		return tx.Unscoped().
+			Where("notification_configuration_id = ? AND id NOT IN (?)", config.ID, kept).
+			Delete(&models.NotificationDeliveryResponse{}).Error
+	})
+	if err != nil {
+		s.logger.Error("failed to record notification delivery", zap.Error(err))
+		return nil, err
+	}
+	return response, nil
+}
+
+// loadDeliveryResponses loads the recorded responses of config, most
+// recent first.
+func (s *service) loadDeliveryResponses(config *models.NotificationConfiguration) error {
+	if err := s.db.Where("notification_configuration_id = ?", config.ID).
+		Order("sent_at DESC").Limit(deliveryResponsesKept).
+		Find(&config.DeliveryResponses).Error; err != nil {
+		s.logger.Error("failed to list notification delivery responses", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// setNotificationToken stores the token that signs generic notifications.
+func (s *service) setNotificationToken(config *models.NotificationConfiguration, token string) error {
+	if token == "" {
+		config.EncryptedToken = ""
+		return nil
+	}
+	encrypted, err := s.secrets.Encrypt(token)
+	if err != nil {
+		s.logger.Error("failed to encrypt notification token", zap.Error(err))
+		return err
+	}
+	config.EncryptedToken = encrypted
+	return nil
+}
+
+func validateNotificationConfiguration(config *models.NotificationConfiguration) error {
+	if config.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidAttribute)
+	}
+	if !notificationDestinations[tfe.NotificationDestinationType(config.DestinationType)] {
+		return fmt.Errorf("%w: destination type %q is not supported", ErrInvalidAttribute, config.DestinationType)
+	}
+	u, err := url.Parse(config.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidAttribute)
+	}
+	for _, trigger := range config.Triggers {
+		if !notificationTriggers[tfe.NotificationTriggerType(trigger)] {
+			return fmt.Errorf("%w: trigger %q is not supported", ErrInvalidAttribute, trigger)
+		}
+	}
+	return nil
+}
+
+func notificationTriggerNames(triggers []tfe.NotificationTriggerType) []string {
+	names := make([]string, len(triggers))
+	for i, trigger := range triggers {
+		names[i] = string(trigger)
+	}
+	return names
+}
+
+func (s *service) findAuthorizedNotificationConfiguration(ctx context.Context, notificationConfigurationID string, required workspacePermission) (*models.NotificationConfiguration, error) {
+	id, err := uuid.Parse(notificationConfigurationID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	var config models.NotificationConfiguration
+	if err := s.db.Where("id = ?", id).First(&config).Error; err != nil {
+		s.logger.Debug("failed to read notification configuration", zap.Error(err))
+		return nil, err
+	}
+	ws, err := s.findWorkspaceByID(ctx, config.WorkspaceID.String())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, ws, required); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
@@ -2,16 +2,34 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/hashicorp/go-tfe"
 	"github.com/open-tfe/tfe-service/internal/models"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
+// ListOrganizations lists the organizations the authenticated user is an
+// active member of. Site admins see every organization, and team,
+// organization and audit trail tokens see only their own.
 func (s *service) ListOrganizations(ctx context.Context, query string) ([]*tfe.Organization, error) {
 	var orgs []*models.Organization
 	db := s.db
+	if p := tokenPrincipalFromContext(ctx); p != nil {
+		db = db.Where("id = ?", p.organizationID)
+	} else {
+		user, err := s.currentUser(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !user.IsSiteAdmin {
+			db = db.Where("id IN (?)", s.db.Model(&models.OrganizationMembership{}).Select("organization_id").
+				Where("user_id = ? AND status = ?", user.ID, tfe.OrganizationMembershipActive))
+		}
+	}
 
 	if query != "" {
 		db = db.Where("name ILIKE ? OR email ILIKE ?", "%"+query+"%", "%"+query+"%")
@@ -31,15 +49,40 @@ func (s *service) ListOrganizations(ctx context.Context, query string) ([]*tfe.O
 }
 
 func (s *service) CreateOrganization(ctx context.Context, org *tfe.Organization) (*tfe.Organization, error) {
+	owner, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	tforg := models.FromTFEOrganization(org)
 	s.logger.Debug("converting from TFE organization", zap.Any("organization", org))
 
-	if err := s.db.Create(tforg).Error; err != nil {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("DefaultProject").Create(tforg).Error; err != nil {
+			return err
+		}
+
+		// Every organization starts with a default project for new workspaces.
+		tforg.DefaultProject = &models.Project{
+			ID:             uuid.New(),
+			Name:           "Default Project",
+			OrganizationID: tforg.ID,
+		}
+		if err := tx.Create(tforg.DefaultProject).Error; err != nil {
+			return err
+		}
+
+		// The creator owns the organization through its owners team.
+		return createOwnersTeam(tx, tforg.ID, owner)
+	})
+	if err != nil {
 		s.logger.Error("failed to create organization", zap.Error(err))
 		return nil, err
 	}
 
-	return tforg.ToTFE(), nil
+	created := tforg.ToTFE()
+	s.audit(ctx, tforg.ID, models.AuditActionCreate, nil, created)
+	return created, nil
 }
 
 func (s *service) ReadOrganization(ctx context.Context, name string) (*tfe.Organization, error) {
@@ -48,6 +91,9 @@ func (s *service) ReadOrganization(ctx context.Context, name string) (*tfe.Organ
 		s.logger.Error("failed to read organization", zap.Error(err))
 		return nil, err
 	}
+	if _, err := s.authorizeOrganization(ctx, org.ID, orgRead); err != nil {
+		return nil, err
+	}
 	projects, _, err := s.ListProjects(ctx, org.ID)
 	if err != nil {
 		s.logger.Error("failed to list projects", zap.Error(err))
@@ -55,7 +101,11 @@ func (s *service) ReadOrganization(ctx context.Context, name string) (*tfe.Organ
 	}
 	org.Projects = projects
 	if org.DefaultProject == nil {
-		org.DefaultProject = projects[0]
+		defaultProject, err := s.defaultProject(org.ID)
+		if err != nil {
+			return nil, err
+		}
+		org.DefaultProject = defaultProject
 	}
 
 	s.logger.Debug("converting to TFE organization", zap.Any("organization", org))
@@ -63,24 +113,63 @@ func (s *service) ReadOrganization(ctx context.Context, name string) (*tfe.Organ
 }
 
 func (s *service) UpdateOrganization(ctx context.Context, name string, org *tfe.Organization) error {
+	role, err := s.authorizeOrganizationName(ctx, name, orgManageSettings)
+	if err != nil {
+		return err
+	}
 	tforg := models.FromTFEOrganization(org)
 	s.logger.Debug("converting from TFE organization", zap.Any("organization", org))
+	if tforg.DefaultAgentPoolID != nil {
+		pool, err := s.findAgentPool(tforg.DefaultAgentPoolID.String())
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && pool.OrganizationID != role.orgID) {
+			return fmt.Errorf("%w: default agent pool does not exist in the organization", ErrInvalidAttribute)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	before, err := s.findOrganization(role.orgID)
+	if err != nil {
+		return err
+	}
 
 	if err := s.db.Where("name = ?", name).Updates(tforg).Error; err != nil {
 		s.logger.Error("failed to update organization", zap.Error(err))
 		return err
 	}
+	if after, err := s.findOrganization(role.orgID); err == nil {
+		s.audit(ctx, role.orgID, models.AuditActionUpdate, before.ToTFE(), after.ToTFE())
+	}
 	return nil
 }
 
 func (s *service) DeleteOrganization(ctx context.Context, name string) error {
+	role, err := s.authorizeOrganizationName(ctx, name, orgManageSettings)
+	if err != nil {
+		return err
+	}
+	org, err := s.findOrganization(role.orgID)
+	if err != nil {
+		return err
+	}
 	if err := s.db.Where("name = ?", name).Delete(&models.Organization{}).Error; err != nil {
 		s.logger.Error("failed to delete organization", zap.Error(err))
 		return err
 	}
+	s.audit(ctx, org.ID, models.AuditActionDelete, org.ToTFE(), nil)
 	return nil
 }
 
+// findOrganization reads an organization by ID.
+func (s *service) findOrganization(orgID uuid.UUID) (*models.Organization, error) {
+	var org models.Organization
+	if err := s.db.Where("id = ?", orgID).First(&org).Error; err != nil {
+		s.logger.Error("failed to read organization", zap.Error(err))
+		return nil, err
+	}
+	return &org, nil
+}
+
 func (s *service) GetOrganizationIDByName(ctx context.Context, name string) (uuid.UUID, error) {
 	var org models.Organization
 	if err := s.db.Where("name = ?", name).First(&org).Error; err != nil {
@@ -91,7 +180,9 @@ func (s *service) GetOrganizationIDByName(ctx context.Context, name string) (uui
 }
 
 func (s *service) ReadOrganizationEntitlements(ctx context.Context, name string) (*tfe.Entitlements, error) {
-
+	if _, err := s.authorizeOrganizationName(ctx, name, orgRead); err != nil {
+		return nil, err
+	}
 	entitlements := &tfe.Entitlements{
 		Agents:                     true,
 		AuditLogging:               true,
@@ -0,0 +1,561 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/constants"
+	"github.com/open-tfe/tfe-service/internal/mail"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// minPasswordLength applies to passwords chosen when accepting an invitation.
+const minPasswordLength = 8
+
+var (
+	emailRegexp = regexp.MustCompile(`^[^@\s]+@[^@\s]+$`)
+	// usernameInvalidChars matches what may not appear in usernames derived
+	// from an invited email address.
+	usernameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9\-_]+`)
+)
+
+// Invitation describes a pending organization invitation to whoever holds
+// its token.
+type Invitation struct {
+	Organization string
+	Email        string
+	// NeedsPassword is set when the invited user has no account yet and
+	// must choose a password to accept.
+	NeedsPassword bool
+}
+
+func (s *service) ListOrganizationMemberships(ctx context.Context, organization string, options *tfe.OrganizationMembershipListOptions) ([]*tfe.OrganizationMembership, *tfe.Pagination, error) {
+	role, err := s.authorizeOrganizationName(ctx, organization, orgRead)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db := s.db.Model(&models.OrganizationMembership{}).Where("organization_id = ?", role.orgID)
+	var listOptions tfe.ListOptions
+	if options != nil {
+		listOptions = options.ListOptions
+		if options.Status != "" {
+			db = db.Where("status = ?", options.Status)
+		}
+		if len(options.Emails) > 0 {
+			db = db.Where("user_id IN (?)", s.db.Model(&models.User{}).Select("id").Where("email IN ?", options.Emails))
+		}
+		if options.Query != "" {
+			like := "%" + options.Query + "%"
+			db = db.Where("user_id IN (?)", s.db.Model(&models.User{}).Select("id").Where("username ILIKE ? OR email ILIKE ?", like, like))
+		}
+	}
+
+	db, pagination, err := paginate(db, listOptions)
+	if err != nil {
+		s.logger.Error("failed to count organization memberships", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var memberships []*models.OrganizationMembership
+	if err := preloadOrganizationMembership(db).Order("created_at ASC").Find(&memberships).Error; err != nil {
+		s.logger.Error("failed to list organization memberships", zap.Error(err))
+		return nil, nil, err
+	}
+	return s.organizationMembershipsToTFE(memberships, pagination)
+}
+
+// ListCurrentUserOrganizationMemberships lists the memberships of the
+// authenticated user across all organizations, including open invitations.
+func (s *service) ListCurrentUserOrganizationMemberships(ctx context.Context) ([]*tfe.OrganizationMembership, error) {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var memberships []*models.OrganizationMembership
+	if err := preloadOrganizationMembership(s.db).Where("user_id = ?", user.ID).Order("created_at ASC").Find(&memberships).Error; err != nil {
+		s.logger.Error("failed to list organization memberships", zap.Error(err))
+		return nil, err
+	}
+	tfeMemberships, _, err := s.organizationMembershipsToTFE(memberships, nil)
+	return tfeMemberships, err
+}
+
+// CreateOrganizationMembership invites the owner of an email address to an
+// organization. Addresses without an account get a new user whose email
+// stays unconfirmed until the invitation is accepted. The invitation email
+// links to baseURL.
+func (s *service) CreateOrganizationMembership(ctx context.Context, organization string, options tfe.OrganizationMembershipCreateOptions, baseURL string) (*tfe.OrganizationMembership, error) {
+	inviter, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var org models.Organization
+	if err := s.db.Where("name = ?", organization).First(&org).Error; err != nil {
+		s.logger.Error("failed to read organization", zap.Error(err))
+		return nil, err
+	}
+	if _, err := s.authorizeOrganization(ctx, org.ID, orgManageMembership); err != nil {
+		return nil, err
+	}
+	if options.Email == nil || !emailRegexp.MatchString(*options.Email) {
+		return nil, fmt.Errorf("%w: email must be a valid email address", ErrInvalidAttribute)
+	}
+	email := strings.TrimSpace(*options.Email)
+
+	teamIDs, err := s.membershipTeams(org.ID, options.Teams)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	membership := &models.OrganizationMembership{
+		Status:              string(tfe.OrganizationMembershipInvited),
+		OrganizationID:      org.ID,
+		InvitationTokenHash: hashSecret(token),
+		InvitedByID:         &inviter.ID,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		user, err := findOrInviteUser(tx, email)
+		if err != nil {
+			return err
+		}
+		membership.UserID = user.ID
+
+		var count int64
+		if err := tx.Model(&models.OrganizationMembership{}).Where("organization_id = ? AND user_id = ?", org.ID, user.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: %s is already a member of the organization", ErrInvalidAttribute, email)
+		}
+
+		if err := tx.Omit(clause.Associations).Create(membership).Error; err != nil {
+			return err
+		}
+		for _, teamID := range teamIDs {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.TeamUser{TeamID: teamID, UserID: user.ID}).Error; err != nil {
+				return err
+			}
+		}
+
+		// Sending last rolls the invitation back when delivery fails.
+		return s.mailer.Send(ctx, invitationMessage(email, inviter, &org, baseURL, token))
+	})
+	if err != nil {
+		if !errors.Is(err, ErrInvalidAttribute) {
+			s.logger.Error("failed to create organization membership", zap.Error(err))
+		}
+		return nil, err
+	}
+	created, err := s.ReadOrganizationMembership(ctx, membership.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, org.ID, models.AuditActionCreate, nil, created)
+	return created, nil
+}
+
+func (s *service) ReadOrganizationMembership(ctx context.Context, membershipID string) (*tfe.OrganizationMembership, error) {
+	membership, err := s.findOrganizationMembership(membershipID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeOrganizationMembership(ctx, membership, orgRead); err != nil {
+		return nil, err
+	}
+	if err := s.loadMembershipTeams([]*models.OrganizationMembership{membership}); err != nil {
+		return nil, err
+	}
+	return membership.ToTFE(), nil
+}
+
+// DeleteOrganizationMembership removes a user from an organization and all
+// of its teams. Users may leave an organization themselves. The last owner
+// cannot be removed.
+func (s *service) DeleteOrganizationMembership(ctx context.Context, membershipID string) error {
+	membership, err := s.findOrganizationMembership(membershipID)
+	if err != nil {
+		return err
+	}
+	if err := s.authorizeOrganizationMembership(ctx, membership, orgManageMembership); err != nil {
+		return err
+	}
+	before, err := s.membershipSnapshot(membership)
+	if err != nil {
+		return err
+	}
+	if err := s.removeOrganizationMembership(membership); err != nil {
+		if !errors.Is(err, ErrInvalidAttribute) {
+			s.logger.Error("failed to delete organization membership", zap.Error(err))
+		}
+		return err
+	}
+	s.audit(ctx, membership.OrganizationID, models.AuditActionDelete, before, nil)
+	return nil
+}
+
+// AcceptOrganizationMembership accepts an invitation addressed to the
+// authenticated user.
+func (s *service) AcceptOrganizationMembership(ctx context.Context, membershipID string) (*tfe.OrganizationMembership, error) {
+	membership, err := s.findCurrentUserInvitation(ctx, membershipID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.acceptInvitation(ctx, membership, ""); err != nil {
+		return nil, err
+	}
+	return s.ReadOrganizationMembership(ctx, membershipID)
+}
+
+// DeclineOrganizationMembership declines an invitation addressed to the
+// authenticated user.
+func (s *service) DeclineOrganizationMembership(ctx context.Context, membershipID string) error {
+	membership, err := s.findCurrentUserInvitation(ctx, membershipID)
+	if err != nil {
+		return err
+	}
+	return s.declineInvitation(ctx, membership)
+}
+
+// ReadInvitation looks up the open invitation identified by the token from
+// an invitation email.
+func (s *service) ReadInvitation(ctx context.Context, token string) (*Invitation, error) {
+	membership, err := s.findInvitationByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return &Invitation{
+		Organization:  membership.Organization.Name,
+		Email:         membership.User.Email,
+		NeedsPassword: membership.User.Password == "",
+	}, nil
+}
+
+// AcceptInvitation accepts the invitation identified by token, confirming
+// the invited email address. Users without an account choose their
+// password here.
+func (s *service) AcceptInvitation(ctx context.Context, token, password string) error {
+	membership, err := s.findInvitationByToken(token)
+	if err != nil {
+		return err
+	}
+	if membership.User.Password == "" && len(password) < minPasswordLength {
+		return fmt.Errorf("%w: password must be at least %d characters", ErrInvalidAttribute, minPasswordLength)
+	}
+	return s.acceptInvitation(ctx, membership, password)
+}
+
+// DeclineInvitation declines the invitation identified by token.
+func (s *service) DeclineInvitation(ctx context.Context, token string) error {
+	membership, err := s.findInvitationByToken(token)
+	if err != nil {
+		return err
+	}
+	return s.declineInvitation(ctx, membership)
+}
+
+// acceptInvitation activates an invited membership and confirms the email
+// address of its user. password is only set for users without one.
+func (s *service) acceptInvitation(ctx context.Context, membership *models.OrganizationMembership, password string) error {
+	before, err := s.membershipSnapshot(membership)
+	if err != nil {
+		return err
+	}
+	user := membership.User
+	updates := map[string]interface{}{}
+	if user.UnconfirmedEmail != "" && user.UnconfirmedEmail == user.Email {
+		updates["unconfirmed_email"] = ""
+	}
+	if user.Password == "" && password != "" {
+		hash, err := hashPassword(password)
+		if err != nil {
+			s.logger.Error("failed to hash password", zap.Error(err))
+			return err
+		}
+		updates["password"] = hash
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Only one answer to an invitation wins.
+		result := tx.Model(&models.OrganizationMembership{}).
+			Where("id = ? AND status = ?", membership.ID, tfe.OrganizationMembershipInvited).
+			Updates(map[string]interface{}{"status": tfe.OrganizationMembershipActive, "invitation_token_hash": ""})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: invitation has already been answered", ErrConflict)
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		return tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(updates).Error
+	})
+	if err != nil {
+		if !errors.Is(err, ErrConflict) {
+			s.logger.Error("failed to accept invitation", zap.Error(err))
+		}
+		return err
+	}
+
+	accepted, err := s.findOrganizationMembership(membership.ID.String())
+	if err != nil {
+		return err
+	}
+	after, err := s.membershipSnapshot(accepted)
+	if err != nil {
+		return err
+	}
+	var secrets []string
+	if _, ok := updates["password"]; ok {
+		secrets = append(secrets, "password")
+	}
+	s.audit(ctx, membership.OrganizationID, models.AuditActionAccept, before, after, secrets...)
+	return nil
+}
+
+func (s *service) declineInvitation(ctx context.Context, membership *models.OrganizationMembership) error {
+	before, err := s.membershipSnapshot(membership)
+	if err != nil {
+		return err
+	}
+	if err := s.removeOrganizationMembership(membership); err != nil {
+		if !errors.Is(err, ErrInvalidAttribute) {
+			s.logger.Error("failed to decline invitation", zap.Error(err))
+		}
+		return err
+	}
+	s.audit(ctx, membership.OrganizationID, models.AuditActionDecline, before, nil)
+	return nil
+}
+
+// membershipSnapshot returns a membership as it is read, teams included,
+// to compare it after a change.
+func (s *service) membershipSnapshot(membership *models.OrganizationMembership) (*tfe.OrganizationMembership, error) {
+	if err := s.loadMembershipTeams([]*models.OrganizationMembership{membership}); err != nil {
+		return nil, err
+	}
+	return membership.ToTFE(), nil
+}
+
+// removeOrganizationMembership deletes a membership together with the
+// user's membership in the organization's teams.
+func (s *service) removeOrganizationMembership(membership *models.OrganizationMembership) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		teams := tx.Model(&models.Team{}).Select("id").Where("organization_id = ?", membership.OrganizationID)
+		if err := tx.Where("user_id = ? AND team_id IN (?)", membership.UserID, teams).Delete(&models.TeamUser{}).Error; err != nil {
+			return err
+		}
+		if err := ensureOwnersRemain(tx, membership.OrganizationID); err != nil {
+			return err
+		}
+		return tx.Where("id = ?", membership.ID).Delete(&models.OrganizationMembership{}).Error
+	})
+}
+
+// membershipTeams resolves the teams an invited user is added to and checks
+// that they belong to the organization.
+func (s *service) membershipTeams(orgID uuid.UUID, teams []*tfe.Team) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(teams))
+	for _, t := range teams {
+		id, err := uuid.Parse(t.ID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: team %q not found", ErrInvalidAttribute, t.ID)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var count int64
+	if err := s.db.Model(&models.Team{}).Where("id IN ? AND organization_id = ?", ids, orgID).Count(&count).Error; err != nil {
+		s.logger.Error("failed to check teams", zap.Error(err))
+		return nil, err
+	}
+	if int(count) != len(ids) {
+		return nil, fmt.Errorf("%w: every team must exist in the organization", ErrInvalidAttribute)
+	}
+	return ids, nil
+}
+
+// loadMembershipTeams fills in the teams of each membership's user within
+// the membership's organization.
+func (s *service) loadMembershipTeams(memberships []*models.OrganizationMembership) error {
+	if len(memberships) == 0 {
+		return nil
+	}
+	userIDs := make([]uuid.UUID, len(memberships))
+	for i, m := range memberships {
+		userIDs[i] = m.UserID
+	}
+
+	var links []*models.TeamUser
+	if err := s.db.Preload("Team").Where("user_id IN ?", userIDs).Find(&links).Error; err != nil {
+		s.logger.Error("failed to load membership teams", zap.Error(err))
+		return err
+	}
+	for _, m := range memberships {
+		m.Teams = []*models.Team{}
+		for _, link := range links {
+			if link.Team != nil && link.UserID == m.UserID && link.Team.OrganizationID == m.OrganizationID {
+				m.Teams = append(m.Teams, link.Team)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *service) organizationMembershipsToTFE(memberships []*models.OrganizationMembership, pagination *tfe.Pagination) ([]*tfe.OrganizationMembership, *tfe.Pagination, error) {
+	if err := s.loadMembershipTeams(memberships); err != nil {
+		return nil, nil, err
+	}
+	tfeMemberships := make([]*tfe.OrganizationMembership, len(memberships))
+	for i, m := range memberships {
+		tfeMemberships[i] = m.ToTFE()
+	}
+	return tfeMemberships, pagination, nil
+}
+
+func (s *service) findOrganizationMembership(membershipID string) (*models.OrganizationMembership, error) {
+	id, err := uuid.Parse(membershipID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var membership models.OrganizationMembership
+	if err := preloadOrganizationMembership(s.db).Where("id = ?", id).First(&membership).Error; err != nil {
+		s.logger.Error("failed to read organization membership", zap.Error(err))
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// authorizeOrganizationMembership lets users act on their own membership and
+// otherwise requires required in the membership's organization.
+func (s *service) authorizeOrganizationMembership(ctx context.Context, membership *models.OrganizationMembership, required organizationPermission) error {
+	userID, err := s.currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+	if userID != nil && *userID == membership.UserID {
+		return nil
+	}
+	_, err = s.authorizeOrganization(ctx, membership.OrganizationID, required)
+	return err
+}
+
+// findCurrentUserInvitation finds an open invitation of the authenticated
+// user. Memberships of other users are reported as missing.
+func (s *service) findCurrentUserInvitation(ctx context.Context, membershipID string) (*models.OrganizationMembership, error) {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	membership, err := s.findOrganizationMembership(membershipID)
+	if err != nil {
+		return nil, err
+	}
+	if membership.UserID != user.ID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if membership.Status != string(tfe.OrganizationMembershipInvited) {
+		return nil, fmt.Errorf("%w: invitation has already been answered", ErrConflict)
+	}
+	return membership, nil
+}
+
+func (s *service) findInvitationByToken(token string) (*models.OrganizationMembership, error) {
+	if token == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var membership models.OrganizationMembership
+	err := preloadOrganizationMembership(s.db).
+		Where("invitation_token_hash = ? AND status = ?", hashSecret(token), tfe.OrganizationMembershipInvited).
+		First(&membership).Error
+	if err != nil {
+		s.logger.Debug("invitation not found", zap.Error(err))
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// findOrInviteUser returns the user registered with email, creating one
+// with an unconfirmed email address when there is none.
+func findOrInviteUser(tx *gorm.DB, email string) (*models.User, error) {
+	var user models.User
+	err := tx.Where("email = ?", email).First(&user).Error
+	if err == nil {
+		return &user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	username, err := availableUsername(tx, email)
+	if err != nil {
+		return nil, err
+	}
+	user = models.User{
+		Email:            email,
+		UnconfirmedEmail: email,
+		Username:         username,
+		TwoFactor:        &models.TwoFactor{},
+		Permissions:      &models.UserPermissions{},
+	}
+	if err := tx.Create(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// availableUsername derives an unused username from the local part of email.
+func availableUsername(tx *gorm.DB, email string) (string, error) {
+	local, _, _ := strings.Cut(email, "@")
+	base := strings.Trim(usernameInvalidChars.ReplaceAllString(local, "-"), "-")
+	if base == "" {
+		base = "user"
+	}
+
+	username := base
+	for i := 2; ; i++ {
+		var count int64
+		if err := tx.Unscoped().Model(&models.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return username, nil
+		}
+		username = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+func invitationMessage(email string, inviter *models.User, org *models.Organization, baseURL, token string) mail.Message {
+	link := strings.TrimSuffix(baseURL, "/") + constants.InvitationPath + "/" + token
+	return mail.Message{
+		To:      email,
+		Subject: fmt.Sprintf("You have been invited to join %s", org.Name),
+		Body: fmt.Sprintf("%s has invited you to join the %s organization.\n\n"+
+			"Accept or decline the invitation at:\n\n%s\n", inviter.Username, org.Name, link),
+	}
+}
+
+func preloadOrganizationMembership(db *gorm.DB) *gorm.DB {
+	return db.Preload("Organization").Preload("User")
+}
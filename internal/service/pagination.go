@@ -0,0 +1,51 @@
+package service
+
+import (
+	tfe "github.com/hashicorp/go-tfe"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// paginate counts the rows matched by db and returns a query limited to the
+// requested page together with the pagination details for the response.
+func paginate(db *gorm.DB, opts tfe.ListOptions) (*gorm.DB, *tfe.Pagination, error) {
+	var total int64
+	if err := db.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, nil, err
+	}
+
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	page := opts.PageNumber
+	if page <= 0 {
+		page = 1
+	}
+
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	pagination := &tfe.Pagination{
+		CurrentPage: page,
+		TotalPages:  totalPages,
+		TotalCount:  int(total),
+	}
+	if page > 1 {
+		pagination.PreviousPage = page - 1
+	}
+	if page < totalPages {
+		pagination.NextPage = page + 1
+	}
+
+	return db.Offset((page - 1) * pageSize).Limit(pageSize), pagination, nil
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func (s *service) ReadPlan(ctx context.Context, planID string) (*tfe.Plan, error) {
+	plan, err := s.findPlan(planID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeRunID(ctx, plan.RunID, wsRead); err != nil {
+		return nil, err
+	}
+	return plan.ToTFE(), nil
+}
+
+func (s *service) ReadApply(ctx context.Context, applyID string) (*tfe.Apply, error) {
+	apply, err := s.findApply(applyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeRunID(ctx, apply.RunID, wsRead); err != nil {
+		return nil, err
+	}
+	return apply.ToTFE(), nil
+}
+
+func (s *service) findPlan(planID string) (*models.Plan, error) {
+	id, err := uuid.Parse(planID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var plan models.Plan
+	if err := s.db.Where("id = ?", id).First(&plan).Error; err != nil {
+		s.logger.Error("failed to read plan", zap.Error(err))
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func (s *service) findApply(applyID string) (*models.Apply, error) {
+	id, err := uuid.Parse(applyID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var apply models.Apply
+	if err := s.db.Where("id = ?", id).First(&apply).Error; err != nil {
+		s.logger.Error("failed to read apply", zap.Error(err))
+		return nil, err
+	}
+	return &apply, nil
+}
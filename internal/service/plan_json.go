@@ -0,0 +1,198 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// redactedValue replaces every sensitive value in a redacted JSON plan.
+const redactedValue = "(sensitive value)"
+
+// UploadPlanJSON stores the `terraform show -json` output of a run's plan,
+// together with a copy in which sensitive values are masked.
+func (s *service) UploadPlanJSON(ctx context.Context, runID string, r io.Reader) error {
+	run, err := s.findRun(runID)
+	if err != nil {
+		return err
+	}
+
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	redacted, err := redactPlanJSON(raw)
+	if err != nil {
+		return fmt.Errorf("%w: invalid JSON plan: %v", ErrInvalidAttribute, err)
+	}
+
+	if err := s.store.Put(ctx, planJSONKey(run.Plan.ID), bytes.NewReader(raw), int64(len(raw))); err != nil {
+		s.logger.Error("failed to store JSON plan", zap.Error(err))
+		return err
+	}
+	if err := s.store.Put(ctx, redactedPlanJSONKey(run.Plan.ID), bytes.NewReader(redacted), int64(len(redacted))); err != nil {
+		s.logger.Error("failed to store redacted JSON plan", zap.Error(err))
+		s.deleteObjects(ctx, planJSONKey(run.Plan.ID))
+		return err
+	}
+	return nil
+}
+
+// ReadPlanJSONOutput opens the redacted JSON plan of a plan.
+func (s *service) ReadPlanJSONOutput(ctx context.Context, planID string) (io.ReadCloser, error) {
+	plan, err := s.findPlan(planID)
+	if err != nil {
+		return nil, err
+	}
+	run, err := s.findRun(plan.RunID.String())
+	if err != nil {
+		return nil, err
+	}
+	return s.readPlanJSONOutput(ctx, run.WorkspaceID, plan.ID)
+}
+
+// ReadRunPlanJSONOutput opens the redacted JSON plan of a run.
+func (s *service) ReadRunPlanJSONOutput(ctx context.Context, runID string) (io.ReadCloser, error) {
+	run, err := s.findRun(runID)
+	if err != nil {
+		return nil, err
+	}
+	return s.readPlanJSONOutput(ctx, run.WorkspaceID, run.Plan.ID)
+}
+
+func (s *service) readPlanJSONOutput(ctx context.Context, workspaceID, planID uuid.UUID) (io.ReadCloser, error) {
+	if err := s.authorizeWorkspaceID(ctx, workspaceID, wsRead); err != nil {
+		return nil, err
+	}
+	return s.openObject(ctx, redactedPlanJSONKey(planID))
+}
+
+// redactPlanJSON masks sensitive values in a plan in the `terraform show
+// -json` format. Terraform marks sensitive values with mirror structures
+// (sensitive_values, before_sensitive, after_sensitive) and, for root
+// module variables, in the configuration.
+func redactPlanJSON(raw []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var plan map[string]interface{}
+	if err := decoder.Decode(&plan); err != nil {
+		return nil, err
+	}
+
+	redactPlanVariables(plan)
+	redactModuleValues(asMap(plan["planned_values"]))
+	if prior := asMap(plan["prior_state"]); prior != nil {
+		redactModuleValues(asMap(prior["values"]))
+	}
+	for _, key := range []string{"resource_changes", "resource_drift"} {
+		for _, rc := range asSlice(plan[key]) {
+			redactChange(asMap(asMap(rc)["change"]))
+		}
+	}
+	for _, change := range asMap(plan["output_changes"]) {
+		redactChange(asMap(change))
+	}
+
+	return json.Marshal(plan)
+}
+
+// redactPlanVariables masks the values of root module variables that the
+// configuration declares sensitive.
+func redactPlanVariables(plan map[string]interface{}) {
+	config := asMap(asMap(asMap(plan["configuration"])["root_module"])["variables"])
+	for name, variable := range asMap(plan["variables"]) {
+		if sensitive, _ := asMap(config[name])["sensitive"].(bool); sensitive {
+			asMap(variable)["value"] = redactedValue
+		}
+	}
+}
+
+// redactModuleValues masks outputs and resource attributes in a values
+// representation such as planned_values.
+func redactModuleValues(values map[string]interface{}) {
+	if values == nil {
+		return
+	}
+	for _, output := range asMap(values["outputs"]) {
+		output := asMap(output)
+		if sensitive, _ := output["sensitive"].(bool); sensitive {
+			output["value"] = redactedValue
+		}
+	}
+	redactModule(asMap(values["root_module"]))
+}
+
+func redactModule(module map[string]interface{}) {
+	if module == nil {
+		return
+	}
+	for _, resource := range asSlice(module["resources"]) {
+		resource := asMap(resource)
+		if resource != nil {
+			resource["values"] = redact(resource["values"], resource["sensitive_values"])
+		}
+	}
+	for _, child := range asSlice(module["child_modules"]) {
+		redactModule(asMap(child))
+	}
+}
+
+func redactChange(change map[string]interface{}) {
+	if change == nil {
+		return
+	}
+	change["before"] = redact(change["before"], change["before_sensitive"])
+	change["after"] = redact(change["after"], change["after_sensitive"])
+}
+
+// redact walks value alongside its sensitivity marker, which is either true
+// for a wholly sensitive value or a structure mirroring value.
+func redact(value, sensitive interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	switch marker := sensitive.(type) {
+	case bool:
+		if marker {
+			return redactedValue
+		}
+	case map[string]interface{}:
+		if object, ok := value.(map[string]interface{}); ok {
+			for key, v := range object {
+				object[key] = redact(v, marker[key])
+			}
+		}
+	case []interface{}:
+		if list, ok := value.([]interface{}); ok {
+			for i := range list {
+				if i < len(marker) {
+					list[i] = redact(list[i], marker[i])
+				}
+			}
+		}
+	}
+	return value
+}
+
+func asMap(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+func asSlice(v interface{}) []interface{} {
+	s, _ := v.([]interface{})
+	return s
+}
+
+func planJSONKey(planID uuid.UUID) string {
+	return "plans/" + planID.String() + "/plan.json"
+}
+
+func redactedPlanJSONKey(planID uuid.UUID) string {
+	return "plans/" + planID.String() + "/plan-redacted.json"
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Only OPA policies are supported; Sentinel's enforcement levels do not
+// apply to them.
+var opaEnforcementLevels = map[tfe.EnforcementLevel]bool{
+	tfe.EnforcementAdvisory:  true,
+	tfe.EnforcementMandatory: true,
+}
+
+func (s *service) ListPolicies(ctx context.Context, organization string, options *tfe.PolicyListOptions) ([]*tfe.Policy, *tfe.Pagination, error) {
+	role, err := s.authorizeOrganizationName(ctx, organization, orgRead)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db := s.db.Model(&models.Policy{}).Where("organization_id = ?", role.orgID)
+	var listOptions tfe.ListOptions
+	if options != nil {
+		listOptions = options.ListOptions
+		if options.Search != "" {
+			db = db.Where("name ILIKE ?", "%"+options.Search+"%")
+		}
+		if options.Kind != "" {
+			db = db.Where("kind = ?", options.Kind)
+		}
+	}
+
+	db, pagination, err := paginate(db, listOptions)
+	if err != nil {
+		s.logger.Error("failed to count policies", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var policies []*models.Policy
+	if err := preloadPolicy(db).Order("name ASC").Find(&policies).Error; err != nil {
+		s.logger.Error("failed to list policies", zap.Error(err))
+		return nil, nil, err
+	}
+
+	tfePolicies := make([]*tfe.Policy, len(policies))
+	for i, p := range policies {
+		tfePolicies[i] = p.ToTFE()
+	}
+	return tfePolicies, pagination, nil
+}
+
+// CreatePolicy creates an OPA policy. Its Rego source is uploaded
+// separately with UploadPolicy.
+func (s *service) CreatePolicy(ctx context.Context, organization string, options tfe.PolicyCreateOptions) (*tfe.Policy, error) {
+	role, err := s.authorizeOrganizationName(ctx, organization, orgManagePolicies)
+	if err != nil {
+		return nil, err
+	}
+	if options.Name == nil || *options.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidAttribute)
+	}
+
+	p := &models.Policy{
+		Name:             *options.Name,
+		Kind:             string(tfe.OPA),
+		EnforcementLevel: string(tfe.EnforcementAdvisory),
+		OrganizationID:   role.orgID,
+	}
+	if options.Kind != "" {
+		p.Kind = string(options.Kind)
+	}
+	if options.Description != nil {
+		p.Description = *options.Description
+	}
+	if options.Query != nil {
+		p.Query = *options.Query
+	}
+	if options.EnforcementLevel != nil {
+		p.EnforcementLevel = string(*options.EnforcementLevel)
+	}
+	if err := s.validatePolicy(p); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Omit("PolicySets").Create(p).Error; err != nil {
+		s.logger.Error("failed to create policy", zap.Error(err))
+		return nil, err
+	}
+	created, err := s.ReadPolicy(ctx, p.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, role.orgID, models.AuditActionCreate, nil, created)
+	return created, nil
+}
+
+func (s *service) ReadPolicy(ctx context.Context, policyID string) (*tfe.Policy, error) {
+	p, err := s.findAuthorizedPolicy(ctx, policyID, orgRead)
+	if err != nil {
+		return nil, err
+	}
+	return p.ToTFE(), nil
+}
+
+func (s *service) UpdatePolicy(ctx context.Context, policyID string, options tfe.PolicyUpdateOptions) (*tfe.Policy, error) {
+	p, err := s.findAuthorizedPolicy(ctx, policyID, orgManagePolicies)
+	if err != nil {
+		return nil, err
+	}
+	before := p.ToTFE()
+
+	if options.Description != nil {
+		p.Description = *options.Description
+	}
+	if options.Query != nil {
+		p.Query = *options.Query
+	}
+	if options.EnforcementLevel != nil {
+		p.EnforcementLevel = string(*options.EnforcementLevel)
+	}
+	if err := s.validatePolicy(p); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Omit("Organization", "PolicySets").Save(p).Error; err != nil {
+		s.logger.Error("failed to update policy", zap.Error(err))
+		return nil, err
+	}
+	updated, err := s.ReadPolicy(ctx, p.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, p.OrganizationID, models.AuditActionUpdate, before, updated)
+	return updated, nil
+}
+
+// DeletePolicy deletes a policy and removes it from its policy sets.
+func (s *service) DeletePolicy(ctx context.Context, policyID string) error {
+	p, err := s.findAuthorizedPolicy(ctx, policyID, orgManagePolicies)
+	if err != nil {
+		return err
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("policy_id = ?", p.ID).Delete(&models.PolicySetPolicy{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", p.ID).Delete(&models.Policy{}).Error
+	})
+	if err != nil {
+		s.logger.Error("failed to delete policy", zap.Error(err))
+		return err
+	}
+	s.deleteObjects(ctx, policyKey(p.ID))
+	s.audit(ctx, p.OrganizationID, models.AuditActionDelete, p.ToTFE(), nil)
+	return nil
+}
+
+// UploadPolicy stores the Rego source of a policy, replacing any earlier upload.
+func (s *service) UploadPolicy(ctx context.Context, policyID string, r io.Reader) error {
+	p, err := s.findAuthorizedPolicy(ctx, policyID, orgManagePolicies)
+	if err != nil {
+		return err
+	}
+	if err := s.store.Put(ctx, policyKey(p.ID), r, -1); err != nil {
+		s.logger.Error("failed to store policy", zap.Error(err))
+		return err
+	}
+	before := p.ToTFE()
+	if err := s.db.Model(p).Omit("Organization", "PolicySets").Update("uploaded", true).Error; err != nil {
+		s.logger.Error("failed to update policy", zap.Error(err))
+		return err
+	}
+	s.audit(ctx, p.OrganizationID, models.AuditActionUpload, before, p.ToTFE())
+	return nil
+}
+
+// DownloadPolicy opens the Rego source of a policy.
+func (s *service) DownloadPolicy(ctx context.Context, policyID string) (io.ReadCloser, error) {
+	p, err := s.findAuthorizedPolicy(ctx, policyID, orgRead)
+	if err != nil {
+		return nil, err
+	}
+	if !p.Uploaded {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return s.openObject(ctx, policyKey(p.ID))
+}
+
+func (s *service) validatePolicy(p *models.Policy) error {
+	if p.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidAttribute)
+	}
+	if p.Kind != string(tfe.OPA) {
+		return fmt.Errorf("%w: only %s policies are supported", ErrInvalidAttribute, tfe.OPA)
+	}
+	if p.Query == "" {
+		return fmt.Errorf("%w: query is required for %s policies", ErrInvalidAttribute, tfe.OPA)
+	}
+	if !opaEnforcementLevels[tfe.EnforcementLevel(p.EnforcementLevel)] {
+		return fmt.Errorf("%w: enforcement level must be %s or %s", ErrInvalidAttribute, tfe.EnforcementAdvisory, tfe.EnforcementMandatory)
+	}
+
+	var existing models.Policy
+	err := s.db.Where("organization_id = ? AND name = ? AND id <> ?", p.OrganizationID, p.Name, p.ID).First(&existing).Error
+	switch {
+	case err == nil:
+		return fmt.Errorf("%w: name %q has already been taken", ErrInvalidAttribute, p.Name)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		s.logger.Error("failed to check policy name", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// findAuthorizedPolicy finds a policy and requires the caller to hold
+// required in its organization.
+func (s *service) findAuthorizedPolicy(ctx context.Context, policyID string, required organizationPermission) (*models.Policy, error) {
+	id, err := uuid.Parse(policyID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var p models.Policy
+	if err := preloadPolicy(s.db).Where("id = ?", id).First(&p).Error; err != nil {
+		s.logger.Error("failed to read policy", zap.Error(err))
+		return nil, err
+	}
+	if _, err := s.authorizeOrganization(ctx, p.OrganizationID, required); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func preloadPolicy(db *gorm.DB) *gorm.DB {
+	return db.Preload("Organization").Preload("PolicySets")
+}
+
+func policyKey(policyID uuid.UUID) string {
+	return "policies/" + policyID.String() + "/policy.rego"
+}
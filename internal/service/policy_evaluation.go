@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"github.com/open-tfe/tfe-service/internal/policy"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// policyInput is the input document of OPA policies, following HCP
+// Terraform: the JSON plan under plan and a description of the run under
+// run.
+type policyInput struct {
+	Plan json.RawMessage `json:"plan"`
+	Run  policyInputRun  `json:"run"`
+}
+
+type policyInputRun struct {
+	ID        string               `json:"id"`
+	Message   string               `json:"message"`
+	IsDestroy bool                 `json:"is_destroy"`
+	Workspace policyInputWorkspace `json:"workspace"`
+}
+
+type policyInputWorkspace struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Organization string `json:"organization"`
+}
+
+// runPolicySets lists the policy sets, with their policies, that apply to
+// the workspace of a run.
+func (s *service) runPolicySets(run *models.Run) ([]*models.PolicySet, error) {
+	var sets []*models.PolicySet
+	if err := s.applicablePolicySets(run.Workspace).Preload("Policies").Order("name ASC").Find(&sets).Error; err != nil {
+		s.logger.Error("failed to list policy sets of run", zap.Error(err))
+		return nil, err
+	}
+	return sets, nil
+}
+
+// checkPolicies evaluates the policy sets of a run in a task stage and
+// records the outcomes. The evaluation fails when a mandatory policy
+// fails; what that means for the run is decided when the stage completes.
+func (s *service) checkPolicies(ctx context.Context, run *models.Run, stage *models.TaskStage, sets []*models.PolicySet) error {
+	evaluation := &models.PolicyEvaluation{
+		Status:           string(tfe.PolicyEvaluationRunning),
+		PolicyKind:       string(tfe.OPA),
+		StatusTimestamps: models.StatusTimestamps{"running-at": time.Now()},
+		TaskStageID:      stage.ID,
+	}
+	if err := s.db.Create(evaluation).Error; err != nil {
+		s.logger.Error("failed to create policy evaluation", zap.Error(err))
+		return err
+	}
+
+	input, inputErr := s.policyInput(ctx, run)
+	outcomes := make([]*models.PolicySetOutcome, len(sets))
+	mandatoryFailed := false
+	for i, set := range sets {
+		if inputErr != nil {
+			outcomes[i] = newPolicySetOutcome(set, evaluation)
+			outcomes[i].Error = inputErr.Error()
+		} else {
+			outcomes[i] = s.evaluatePolicySet(ctx, set, evaluation, input)
+		}
+		mandatoryFailed = mandatoryFailed || outcomes[i].MandatoryFailed()
+	}
+
+	status, key := tfe.PolicyEvaluationPassed, "passed-at"
+	if mandatoryFailed {
+		status, key = tfe.PolicyEvaluationFailed, "failed-at"
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, outcome := range outcomes {
+			if err := tx.Create(outcome).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(evaluation).Omit("PolicySetOutcomes").
+			Updates(withTimestamps(map[string]interface{}{"status": status}, time.Now(), key)).Error
+	})
+	if err != nil {
+		s.logger.Error("failed to record policy evaluation", zap.Error(err))
+		return err
+	}
+	s.logger.Debug("policies evaluated",
+		zap.String("run", run.ID.String()),
+		zap.String("status", string(status)),
+		zap.Int("policy_sets", len(sets)),
+	)
+	return nil
+}
+
+// evaluatePolicySet evaluates the policies of one set. Policies without
+// uploaded source error, as does every policy of a set whose modules
+// cannot be loaded.
+func (s *service) evaluatePolicySet(ctx context.Context, set *models.PolicySet, evaluation *models.PolicyEvaluation, input []byte) *models.PolicySetOutcome {
+	outcome := newPolicySetOutcome(set, evaluation)
+
+	var modules []policy.Module
+	var queries []string
+	var evaluated []*models.Policy
+	for _, p := range set.Policies {
+		result := models.PolicyOutcome{
+			PolicyName:       p.Name,
+			Description:      p.Description,
+			Query:            p.Query,
+			EnforcementLevel: p.EnforcementLevel,
+			Status:           models.PolicyOutcomeErrored,
+		}
+		source, err := s.readPolicySource(ctx, p)
+		if err != nil {
+			if outcome.Error == "" {
+				outcome.Error = fmt.Sprintf("%s: %v", p.Name, err)
+			}
+			outcome.Outcomes = append(outcome.Outcomes, result)
+			continue
+		}
+		modules = append(modules, policy.Module{Name: p.Name, Source: source})
+		queries = append(queries, p.Query)
+		evaluated = append(evaluated, p)
+	}
+	if len(queries) == 0 {
+		return outcome
+	}
+
+	results, err := s.policies.Evaluate(ctx, modules, queries, input)
+	if err != nil {
+		s.logger.Error("failed to evaluate policy set", zap.String("policy_set", set.ID.String()), zap.Error(err))
+		outcome.Error = err.Error()
+		results = make([]policy.Result, len(queries))
+		for i := range results {
+			results[i].Err = err
+		}
+	}
+	for i, p := range evaluated {
+		result := models.PolicyOutcome{
+			PolicyName:       p.Name,
+			Description:      p.Description,
+			Query:            p.Query,
+			EnforcementLevel: p.EnforcementLevel,
+			Status:           models.PolicyOutcomePassed,
+		}
+		switch {
+		case results[i].Err != nil:
+			result.Status = models.PolicyOutcomeErrored
+			if outcome.Error == "" {
+				outcome.Error = fmt.Sprintf("%s: %v", p.Name, results[i].Err)
+			}
+		case results[i].Failed:
+			result.Status = models.PolicyOutcomeFailed
+		}
+		outcome.Outcomes = append(outcome.Outcomes, result)
+	}
+	return outcome
+}
+
+// policyInput builds the input document for the policies of a run.
+func (s *service) policyInput(ctx context.Context, run *models.Run) ([]byte, error) {
+	rc, err := s.openObject(ctx, planJSONKey(run.Plan.ID))
+	if err != nil {
+		return nil, fmt.Errorf("reading JSON plan: %w", err)
+	}
+	defer rc.Close()
+	plan, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("reading JSON plan: %w", err)
+	}
+
+	var org models.Organization
+	if err := s.db.Select("name").Where("id = ?", run.Workspace.OrganizationID).First(&org).Error; err != nil {
+		s.logger.Error("failed to read organization", zap.Error(err))
+		return nil, err
+	}
+	return json.Marshal(policyInput{
+		Plan: plan,
+		Run: policyInputRun{
+			ID:        run.ID.String(),
+			Message:   run.Message,
+			IsDestroy: run.IsDestroy,
+			Workspace: policyInputWorkspace{
+				ID:           run.Workspace.ID.String(),
+				Name:         run.Workspace.Name,
+				Organization: org.Name,
+			},
+		},
+	})
+}
+
+func (s *service) readPolicySource(ctx context.Context, p *models.Policy) ([]byte, error) {
+	if !p.Uploaded {
+		return nil, fmt.Errorf("policy %s has not been uploaded", p.Name)
+	}
+	rc, err := s.openObject(ctx, policyKey(p.ID))
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func newPolicySetOutcome(set *models.PolicySet, evaluation *models.PolicyEvaluation) *models.PolicySetOutcome {
+	return &models.PolicySetOutcome{
+		PolicySetName:        set.Name,
+		PolicySetDescription: set.Description,
+		Overridable:          set.Overridable,
+		Outcomes:             []models.PolicyOutcome{},
+		PolicySetID:          &set.ID,
+		PolicyEvaluationID:   evaluation.ID,
+	}
+}
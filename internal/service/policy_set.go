@@ -0,0 +1,542 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (s *service) ListPolicySets(ctx context.Context, organization string, options *tfe.PolicySetListOptions) ([]*tfe.PolicySet, *tfe.Pagination, error) {
+	role, err := s.authorizeOrganizationName(ctx, organization, orgRead)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db := s.db.Model(&models.PolicySet{}).Where("organization_id = ?", role.orgID)
+	var listOptions tfe.ListOptions
+	if options != nil {
+		listOptions = options.ListOptions
+		if options.Search != "" {
+			db = db.Where("name ILIKE ?", "%"+options.Search+"%")
+		}
+		if options.Kind != "" {
+			db = db.Where("kind = ?", options.Kind)
+		}
+	}
+
+	db, pagination, err := paginate(db, listOptions)
+	if err != nil {
+		s.logger.Error("failed to count policy sets", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var sets []*models.PolicySet
+	if err := preloadPolicySet(db).Order("name ASC").Find(&sets).Error; err != nil {
+		s.logger.Error("failed to list policy sets", zap.Error(err))
+		return nil, nil, err
+	}
+
+	tfeSets := make([]*tfe.PolicySet, len(sets))
+	for i, set := range sets {
+		tfeSets[i] = set.ToTFE()
+	}
+	return tfeSets, pagination, nil
+}
+
+// CreatePolicySet creates a policy set together with the policies,
+// workspaces, exclusions and projects given as relationships. Sets are
+// overridable unless requested otherwise.
+func (s *service) CreatePolicySet(ctx context.Context, organization string, options tfe.PolicySetCreateOptions) (*tfe.PolicySet, error) {
+	role, err := s.authorizeOrganizationName(ctx, organization, orgManagePolicies)
+	if err != nil {
+		return nil, err
+	}
+	if options.Name == nil || *options.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidAttribute)
+	}
+	if options.VCSRepo != nil || options.PoliciesPath != nil {
+		return nil, fmt.Errorf("%w: versioned policy sets are not supported", ErrInvalidAttribute)
+	}
+
+	set := &models.PolicySet{
+		Name:           *options.Name,
+		Kind:           string(tfe.OPA),
+		Overridable:    true,
+		OrganizationID: role.orgID,
+	}
+	if options.Kind != "" {
+		set.Kind = string(options.Kind)
+	}
+	if options.Description != nil {
+		set.Description = *options.Description
+	}
+	if options.Global != nil {
+		set.Global = *options.Global
+	}
+	if options.Overridable != nil {
+		set.Overridable = *options.Overridable
+	}
+	if err := s.validatePolicySet(set); err != nil {
+		return nil, err
+	}
+	if set.Global && (len(options.Workspaces) > 0 || len(options.Projects) > 0) {
+		return nil, fmt.Errorf("%w: global policy sets apply to every workspace", ErrInvalidAttribute)
+	}
+
+	policies, err := s.policySetTargets(&models.Policy{}, set, policyIDs(options.Policies))
+	if err != nil {
+		return nil, err
+	}
+	workspaces, err := s.policySetTargets(&models.Workspace{}, set, workspaceIDs(options.Workspaces))
+	if err != nil {
+		return nil, err
+	}
+	exclusions, err := s.policySetTargets(&models.Workspace{}, set, workspaceIDs(options.WorkspaceExclusions))
+	if err != nil {
+		return nil, err
+	}
+	projects, err := s.policySetTargets(&models.Project{}, set, projectIDs(options.Projects))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(set).Error; err != nil {
+			return err
+		}
+		for _, id := range policies {
+			if err := tx.Create(&models.PolicySetPolicy{PolicySetID: set.ID, PolicyID: id}).Error; err != nil {
+				return err
+			}
+		}
+		for _, id := range workspaces {
+			if err := tx.Create(&models.PolicySetWorkspace{PolicySetID: set.ID, WorkspaceID: id}).Error; err != nil {
+				return err
+			}
+		}
+		for _, id := range exclusions {
+			if err := tx.Create(&models.PolicySetWorkspaceExclusion{PolicySetID: set.ID, WorkspaceID: id}).Error; err != nil {
+				return err
+			}
+		}
+		for _, id := range projects {
+			if err := tx.Create(&models.PolicySetProject{PolicySetID: set.ID, ProjectID: id}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to create policy set", zap.Error(err))
+		return nil, err
+	}
+	created, err := s.ReadPolicySet(ctx, set.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, role.orgID, models.AuditActionCreate, nil, created)
+	return created, nil
+}
+
+func (s *service) ReadPolicySet(ctx context.Context, policySetID string) (*tfe.PolicySet, error) {
+	set, err := s.findAuthorizedPolicySet(ctx, policySetID, orgRead)
+	if err != nil {
+		return nil, err
+	}
+	return set.ToTFE(), nil
+}
+
+func (s *service) UpdatePolicySet(ctx context.Context, policySetID string, options tfe.PolicySetUpdateOptions) (*tfe.PolicySet, error) {
+	set, err := s.findAuthorizedPolicySet(ctx, policySetID, orgManagePolicies)
+	if err != nil {
+		return nil, err
+	}
+	if options.VCSRepo != nil || options.PoliciesPath != nil {
+		return nil, fmt.Errorf("%w: versioned policy sets are not supported", ErrInvalidAttribute)
+	}
+	before := set.ToTFE()
+
+	if options.Name != nil {
+		set.Name = *options.Name
+	}
+	if options.Description != nil {
+		set.Description = *options.Description
+	}
+	if options.Global != nil {
+		set.Global = *options.Global
+	}
+	if options.Overridable != nil {
+		set.Overridable = *options.Overridable
+	}
+	if err := s.validatePolicySet(set); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(set).Error; err != nil {
+			return err
+		}
+		if set.Global {
+			// Global sets apply everywhere but their exclusions, so
+			// attachments are meaningless.
+			if err := tx.Where("policy_set_id = ?", set.ID).Delete(&models.PolicySetWorkspace{}).Error; err != nil {
+				return err
+			}
+			return tx.Where("policy_set_id = ?", set.ID).Delete(&models.PolicySetProject{}).Error
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to update policy set", zap.Error(err))
+		return nil, err
+	}
+	return s.auditPolicySet(ctx, set, before)
+}
+
+func (s *service) DeletePolicySet(ctx context.Context, policySetID string) error {
+	set, err := s.findAuthorizedPolicySet(ctx, policySetID, orgManagePolicies)
+	if err != nil {
+		return err
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&models.PolicySetPolicy{}, &models.PolicySetWorkspace{}, &models.PolicySetWorkspaceExclusion{}, &models.PolicySetProject{}} {
+			if err := tx.Where("policy_set_id = ?", set.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("id = ?", set.ID).Delete(&models.PolicySet{}).Error
+	})
+	if err != nil {
+		s.logger.Error("failed to delete policy set", zap.Error(err))
+		return err
+	}
+	s.audit(ctx, set.OrganizationID, models.AuditActionDelete, set.ToTFE(), nil)
+	return nil
+}
+
+// AddPolicySetPolicies adds policies of the set's organization to a policy set.
+func (s *service) AddPolicySetPolicies(ctx context.Context, policySetID string, policyIDs []string) error {
+	set, err := s.findAuthorizedPolicySet(ctx, policySetID, orgManagePolicies)
+	if err != nil {
+		return err
+	}
+	ids, err := s.policySetTargets(&models.Policy{}, set, policyIDs)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	attachments := make([]*models.PolicySetPolicy, len(ids))
+	for i, id := range ids {
+		attachments[i] = &models.PolicySetPolicy{PolicySetID: set.ID, PolicyID: id}
+	}
+	before := set.ToTFE()
+	if err := s.attachToPolicySet(attachments); err != nil {
+		return err
+	}
+	_, err = s.auditPolicySet(ctx, set, before)
+	return err
+}
+
+// RemovePolicySetPolicies removes policies from a policy set.
+func (s *service) RemovePolicySetPolicies(ctx context.Context, policySetID string, policyIDs []string) error {
+	set, err := s.findAuthorizedPolicySet(ctx, policySetID, orgManagePolicies)
+	if err != nil {
+		return err
+	}
+	before := set.ToTFE()
+	if err := s.detachFromPolicySet(&models.PolicySetPolicy{}, "policy_id", set, policyIDs); err != nil {
+		return err
+	}
+	_, err = s.auditPolicySet(ctx, set, before)
+	return err
+}
+
+// AddPolicySetWorkspaces attaches a non-global policy set to workspaces of its organization.
+func (s *service) AddPolicySetWorkspaces(ctx context.Context, policySetID string, workspaceIDs []string) error {
+	set, err := s.findAttachablePolicySet(ctx, policySetID)
+	if err != nil {
+		return err
+	}
+	ids, err := s.policySetTargets(&models.Workspace{}, set, workspaceIDs)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	attachments := make([]*models.PolicySetWorkspace, len(ids))
+	for i, id := range ids {
+		attachments[i] = &models.PolicySetWorkspace{PolicySetID: set.ID, WorkspaceID: id}
+	}
+	before := set.ToTFE()
+	if err := s.attachToPolicySet(attachments); err != nil {
+		return err
+	}
+	_, err = s.auditPolicySet(ctx, set, before)
+	return err
+}
+
+// RemovePolicySetWorkspaces detaches a policy set from workspaces.
+func (s *service) RemovePolicySetWorkspaces(ctx context.Context, policySetID string, workspaceIDs []string) error {
+	set, err := s.findAuthorizedPolicySet(ctx, policySetID, orgManagePolicies)
+	if err != nil {
+		return err
+	}
+	before := set.ToTFE()
+	if err := s.detachFromPolicySet(&models.PolicySetWorkspace{}, "workspace_id", set, workspaceIDs); err != nil {
+		return err
+	}
+	_, err = s.auditPolicySet(ctx, set, before)
+	return err
+}
+
+// AddPolicySetWorkspaceExclusions keeps workspaces out of the scope of a
+// policy set, even when the set is global or attached to their project.
+func (s *service) AddPolicySetWorkspaceExclusions(ctx context.Context, policySetID string, workspaceIDs []string) error {
+	set, err := s.findAuthorizedPolicySet(ctx, policySetID, orgManagePolicies)
+	if err != nil {
+		return err
+	}
+	ids, err := s.policySetTargets(&models.Workspace{}, set, workspaceIDs)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	attachments := make([]*models.PolicySetWorkspaceExclusion, len(ids))
+	for i, id := range ids {
+		attachments[i] = &models.PolicySetWorkspaceExclusion{PolicySetID: set.ID, WorkspaceID: id}
+	}
+	before := set.ToTFE()
+	if err := s.attachToPolicySet(attachments); err != nil {
+		return err
+	}
+	_, err = s.auditPolicySet(ctx, set, before)
+	return err
+}
+
+// RemovePolicySetWorkspaceExclusions brings excluded workspaces back into
+// the scope of a policy set.
+func (s *service) RemovePolicySetWorkspaceExclusions(ctx context.Context, policySetID string, workspaceIDs []string) error {
+	set, err := s.findAuthorizedPolicySet(ctx, policySetID, orgManagePolicies)
+	if err != nil {
+		return err
+	}
+	before := set.ToTFE()
+	if err := s.detachFromPolicySet(&models.PolicySetWorkspaceExclusion{}, "workspace_id", set, workspaceIDs); err != nil {
+		return err
+	}
+	_, err = s.auditPolicySet(ctx, set, before)
+	return err
+}
+
+// AddPolicySetProjects attaches a non-global policy set to projects of its organization.
+func (s *service) AddPolicySetProjects(ctx context.Context, policySetID string, projectIDs []string) error {
+	set, err := s.findAttachablePolicySet(ctx, policySetID)
+	if err != nil {
+		return err
+	}
+	ids, err := s.policySetTargets(&models.Project{}, set, projectIDs)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	attachments := make([]*models.PolicySetProject, len(ids))
+	for i, id := range ids {
+		attachments[i] = &models.PolicySetProject{PolicySetID: set.ID, ProjectID: id}
+	}
+	before := set.ToTFE()
+	if err := s.attachToPolicySet(attachments); err != nil {
+		return err
+	}
+	_, err = s.auditPolicySet(ctx, set, before)
+	return err
+}
+
+// RemovePolicySetProjects detaches a policy set from projects.
+func (s *service) RemovePolicySetProjects(ctx context.Context, policySetID string, projectIDs []string) error {
+	set, err := s.findAuthorizedPolicySet(ctx, policySetID, orgManagePolicies)
+	if err != nil {
+		return err
+	}
+	before := set.ToTFE()
+	if err := s.detachFromPolicySet(&models.PolicySetProject{}, "project_id", set, projectIDs); err != nil {
+		return err
+	}
+	_, err = s.auditPolicySet(ctx, set, before)
+	return err
+}
+
+// auditPolicySet records an update of a policy set and returns the set as
+// it is now.
+func (s *service) auditPolicySet(ctx context.Context, set *models.PolicySet, before *tfe.PolicySet) (*tfe.PolicySet, error) {
+	updated, err := s.ReadPolicySet(ctx, set.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, set.OrganizationID, models.AuditActionUpdate, before, updated)
+	return updated, nil
+}
+
+// applicablePolicySets scopes a query to the policy sets that apply to a
+// workspace: global sets and sets attached to the workspace or its
+// project, less the sets that exclude it.
+func (s *service) applicablePolicySets(ws *models.Workspace) *gorm.DB {
+	return s.db.Model(&models.PolicySet{}).
+		Where("organization_id = ?", ws.OrganizationID).
+		Where("global OR id IN (?) OR id IN (?)",
+			s.db.Model(&models.PolicySetWorkspace{}).Select("policy_set_id").Where("workspace_id = ?", ws.ID),
+			s.db.Model(&models.PolicySetProject{}).Select("policy_set_id").Where("project_id = ?", ws.ProjectID),
+		).
+		Where("id NOT IN (?)",
+			s.db.Model(&models.PolicySetWorkspaceExclusion{}).Select("policy_set_id").Where("workspace_id = ?", ws.ID),
+		)
+}
+
+// policySetTargets resolves policy, workspace or project IDs and checks
+// that they belong to the organization of the set.
+func (s *service) policySetTargets(model interface{}, set *models.PolicySet, ids []string) ([]uuid.UUID, error) {
+	parsed := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		u, err := uuid.Parse(id)
+		if err != nil {
+			return nil, gorm.ErrRecordNotFound
+		}
+		parsed = append(parsed, u)
+	}
+	if len(parsed) == 0 {
+		return nil, nil
+	}
+
+	var count int64
+	if err := s.db.Model(model).Where("id IN ? AND organization_id = ?", parsed, set.OrganizationID).Count(&count).Error; err != nil {
+		s.logger.Error("failed to check policy set targets", zap.Error(err))
+		return nil, err
+	}
+	if int(count) != len(parsed) {
+		return nil, fmt.Errorf("%w: every target must exist in the policy set's organization", ErrInvalidAttribute)
+	}
+	return parsed, nil
+}
+
+func (s *service) attachToPolicySet(attachments interface{}) error {
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(attachments).Error; err != nil {
+		s.logger.Error("failed to attach to policy set", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (s *service) detachFromPolicySet(model interface{}, column string, set *models.PolicySet, ids []string) error {
+	parsed := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if u, err := uuid.Parse(id); err == nil {
+			parsed = append(parsed, u)
+		}
+	}
+	if len(parsed) == 0 {
+		return nil
+	}
+	if err := s.db.Where("policy_set_id = ? AND "+column+" IN ?", set.ID, parsed).Delete(model).Error; err != nil {
+		s.logger.Error("failed to detach from policy set", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (s *service) validatePolicySet(set *models.PolicySet) error {
+	if set.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidAttribute)
+	}
+	if set.Kind != string(tfe.OPA) {
+		return fmt.Errorf("%w: only %s policy sets are supported", ErrInvalidAttribute, tfe.OPA)
+	}
+
+	var existing models.PolicySet
+	err := s.db.Where("organization_id = ? AND name = ? AND id <> ?", set.OrganizationID, set.Name, set.ID).First(&existing).Error
+	switch {
+	case err == nil:
+		return fmt.Errorf("%w: name %q has already been taken", ErrInvalidAttribute, set.Name)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		s.logger.Error("failed to check policy set name", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// findAuthorizedPolicySet finds a policy set and requires the caller to
+// hold required in its organization.
+func (s *service) findAuthorizedPolicySet(ctx context.Context, policySetID string, required organizationPermission) (*models.PolicySet, error) {
+	id, err := uuid.Parse(policySetID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var set models.PolicySet
+	if err := preloadPolicySet(s.db).Where("id = ?", id).First(&set).Error; err != nil {
+		s.logger.Error("failed to read policy set", zap.Error(err))
+		return nil, err
+	}
+	if _, err := s.authorizeOrganization(ctx, set.OrganizationID, required); err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
+
+// findAttachablePolicySet finds a policy set that may be attached to
+// workspaces and projects.
+func (s *service) findAttachablePolicySet(ctx context.Context, policySetID string) (*models.PolicySet, error) {
+	set, err := s.findAuthorizedPolicySet(ctx, policySetID, orgManagePolicies)
+	if err != nil {
+		return nil, err
+	}
+	if set.Global {
+		return nil, fmt.Errorf("%w: global policy sets apply to every workspace", ErrInvalidAttribute)
+	}
+	return set, nil
+}
+
+func policyIDs(policies []*tfe.Policy) []string {
+	ids := make([]string, len(policies))
+	for i, p := range policies {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+func workspaceIDs(workspaces []*tfe.Workspace) []string {
+	ids := make([]string, len(workspaces))
+	for i, ws := range workspaces {
+		ids[i] = ws.ID
+	}
+	return ids
+}
+
+func projectIDs(projects []*tfe.Project) []string {
+	ids := make([]string, len(projects))
+	for i, p := range projects {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+func preloadPolicySet(db *gorm.DB) *gorm.DB {
+	return db.Preload("Organization").Preload("Policies").Preload("Workspaces").
+		Preload("WorkspaceExclusions").Preload("Projects")
+}
@@ -2,16 +2,24 @@ package service
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/google/uuid"
 	tfe "github.com/hashicorp/go-tfe"
 	"github.com/open-tfe/tfe-service/internal/models"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
+// ListProjects lists the projects of an organization the caller may read.
 func (s *service) ListProjects(ctx context.Context, orgID uuid.UUID) ([]*models.Project, []*tfe.Project, error) {
+	role, err := s.authorizeOrganization(ctx, orgID, orgRead)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	var projects []*models.Project
-	if err := s.db.Where("organization_id = ?", orgID).Find(&projects).Error; err != nil {
+	if err := s.readableProjects(s.db.Where("organization_id = ?", orgID), role).Find(&projects).Error; err != nil {
 		s.logger.Error("failed to list projects", zap.Error(err))
 		return nil, nil, err
 	}
@@ -26,20 +34,33 @@ func (s *service) ListProjects(ctx context.Context, orgID uuid.UUID) ([]*models.
 }
 
 func (s *service) CreateProject(ctx context.Context, project *tfe.Project) (*tfe.Project, error) {
+	if project.Organization == nil {
+		return nil, fmt.Errorf("%w: organization is required", ErrInvalidAttribute)
+	}
+	role, err := s.authorizeOrganizationName(ctx, project.Organization.Name, orgManageProjects)
+	if err != nil {
+		return nil, err
+	}
+
 	dbProject := models.FromTFEProject(project)
 	s.logger.Debug("converting from TFE project", zap.Any("project", project))
+	dbProject.OrganizationID = role.orgID
 
 	if err := s.db.Create(dbProject).Error; err != nil {
 		s.logger.Error("failed to create project", zap.Error(err))
 		return nil, err
 	}
-	return dbProject.ToTFE(), nil
+	created := dbProject.ToTFE()
+	s.audit(ctx, role.orgID, models.AuditActionCreate, nil, created)
+	return created, nil
 }
 
 func (s *service) ReadProject(ctx context.Context, projectID string) (*tfe.Project, error) {
-	var project models.Project
-	if err := s.db.Where("id = ?", projectID).First(&project).Error; err != nil {
-		s.logger.Error("failed to read project", zap.Error(err))
+	project, err := s.findProject(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeProject(ctx, project, projectRead); err != nil {
 		return nil, err
 	}
 	s.logger.Debug("converting to TFE project", zap.Any("project", project))
@@ -47,20 +68,67 @@ func (s *service) ReadProject(ctx context.Context, projectID string) (*tfe.Proje
 }
 
 func (s *service) UpdateProject(ctx context.Context, project *tfe.Project) (*tfe.Project, error) {
+	existing, err := s.findProject(project.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeProject(ctx, existing, projectUpdate); err != nil {
+		return nil, err
+	}
+
 	dbProject := models.FromTFEProject(project)
 	s.logger.Debug("converting from TFE project", zap.Any("project", project))
 
-	if err := s.db.Save(dbProject).Error; err != nil {
+	if err := s.db.Model(dbProject).Select("name", "description").Updates(dbProject).Error; err != nil {
 		s.logger.Error("failed to update project", zap.Error(err))
 		return nil, err
 	}
-	return dbProject.ToTFE(), nil
+	updated, err := s.ReadProject(ctx, dbProject.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, existing.OrganizationID, models.AuditActionUpdate, existing.ToTFE(), updated)
+	return updated, nil
 }
 
 func (s *service) DeleteProject(ctx context.Context, projectID string) error {
-	if err := s.db.Where("id = ?", projectID).Delete(&models.Project{}).Error; err != nil {
+	project, err := s.findProject(projectID)
+	if err != nil {
+		return err
+	}
+	if err := s.authorizeProject(ctx, project, projectDelete); err != nil {
+		return err
+	}
+
+	if err := s.db.Where("id = ?", project.ID).Delete(&models.Project{}).Error; err != nil {
 		s.logger.Error("failed to delete project", zap.Error(err))
 		return err
 	}
+	s.audit(ctx, project.OrganizationID, models.AuditActionDelete, project.ToTFE(), nil)
 	return nil
 }
+
+// defaultProject returns the project that receives workspaces created without
+// an explicit project, which is the first project created in the organization.
+func (s *service) defaultProject(orgID uuid.UUID) (*models.Project, error) {
+	var project models.Project
+	if err := s.db.Where("organization_id = ?", orgID).Order("created_at ASC").First(&project).Error; err != nil {
+		s.logger.Error("failed to find default project", zap.Error(err))
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (s *service) findProject(projectID string) (*models.Project, error) {
+	id, err := uuid.Parse(projectID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var project models.Project
+	if err := s.db.Preload("Organization").Where("id = ?", id).First(&project).Error; err != nil {
+		s.logger.Error("failed to read project", zap.Error(err))
+		return nil, err
+	}
+	return &project, nil
+}
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/go-version"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	mirrorHostnameRegexp = regexp.MustCompile(`^[a-z0-9]([a-z0-9.-]*[a-z0-9])?(:[0-9]+)?$`)
+	mirrorNameRegexp     = regexp.MustCompile(`^[a-z0-9]([a-z0-9-_]*[a-z0-9])?$`)
+	mirrorPlatformRegexp = regexp.MustCompile(`^[a-z0-9]+$`)
+)
+
+// ProviderMirrorPackageID addresses a package of the provider network
+// mirror by the provider's origin address, version and platform.
+type ProviderMirrorPackageID struct {
+	Hostname  string
+	Namespace string
+	Type      string
+	Version   string
+	OS        string
+	Arch      string
+}
+
+// ProviderMirrorPackage is a package served by the provider network mirror.
+// Hashes hold the "h1:" and "zh:" hashes Terraform records in lock files.
+type ProviderMirrorPackage struct {
+	ID        string    `jsonapi:"primary,provider-mirror-packages"`
+	Hostname  string    `jsonapi:"attr,hostname"`
+	Namespace string    `jsonapi:"attr,namespace"`
+	Type      string    `jsonapi:"attr,type"`
+	Version   string    `jsonapi:"attr,version"`
+	OS        string    `jsonapi:"attr,os"`
+	Arch      string    `jsonapi:"attr,arch"`
+	Filename  string    `jsonapi:"attr,filename"`
+	Hashes    []string  `jsonapi:"attr,hashes"`
+	CreatedAt time.Time `jsonapi:"attr,created-at,iso8601"`
+	UpdatedAt time.Time `jsonapi:"attr,updated-at,iso8601"`
+}
+
+// ListProviderMirrorPackages lists every package of the mirror. Only site
+// admins manage the mirror.
+func (s *service) ListProviderMirrorPackages(ctx context.Context, listOptions tfe.ListOptions) ([]*ProviderMirrorPackage, *tfe.Pagination, error) {
+	if err := s.authorizeSiteAdmin(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	db, pagination, err := paginate(s.db.Model(&models.ProviderMirrorPackage{}), listOptions)
+	if err != nil {
+		s.logger.Error("failed to count mirror packages", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var packages []*models.ProviderMirrorPackage
+	if err := db.Order("hostname, namespace, type, version, os, arch").Find(&packages).Error; err != nil {
+		s.logger.Error("failed to list mirror packages", zap.Error(err))
+		return nil, nil, err
+	}
+
+	result := make([]*ProviderMirrorPackage, len(packages))
+	for i, p := range packages {
+		result[i] = providerMirrorPackage(p)
+	}
+	return result, pagination, nil
+}
+
+// UploadProviderMirrorPackage stores a provider package zip in the mirror,
+// replacing the package of the same version and platform if there is one.
+func (s *service) UploadProviderMirrorPackage(ctx context.Context, packageID ProviderMirrorPackageID, r io.Reader) (*ProviderMirrorPackage, error) {
+	if err := s.authorizeSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+	packageID, err := normalizeMirrorPackageID(packageID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The zip is spooled to disk because the h1 hash needs random access
+	// to its entries.
+	f, err := os.CreateTemp("", "provider-mirror-*.zip")
+	if err != nil {
+		s.logger.Error("failed to create mirror spool file", zap.Error(err))
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(f, io.TeeReader(r, hash))
+	if err != nil {
+		return nil, err
+	}
+	hash1, err := zipHash1(f, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: provider package is not a valid zip archive: %v", ErrInvalidAttribute, err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var before *ProviderMirrorPackage
+	pkg, err := s.findProviderMirrorPackage(packageID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		pkg = &models.ProviderMirrorPackage{
+			ID:        uuid.New(),
+			Hostname:  packageID.Hostname,
+			Namespace: packageID.Namespace,
+			Type:      packageID.Type,
+			Version:   packageID.Version,
+			OS:        packageID.OS,
+			Arch:      packageID.Arch,
+		}
+	} else if err != nil {
+		return nil, err
+	} else {
+		before = providerMirrorPackage(pkg)
+	}
+
+	if err := s.store.Put(ctx, providerMirrorKey(pkg), f, size); err != nil {
+		s.logger.Error("failed to store mirror package", zap.Error(err))
+		return nil, err
+	}
+	pkg.Shasum = hex.EncodeToString(hash.Sum(nil))
+	pkg.Hash1 = hash1
+	if pkg.CreatedAt.IsZero() {
+		err = s.db.Create(pkg).Error
+	} else {
+		err = s.db.Model(pkg).Updates(map[string]interface{}{"shasum": pkg.Shasum, "hash1": pkg.Hash1}).Error
+	}
+	if err != nil {
+		s.logger.Error("failed to save mirror package", zap.Error(err))
+		return nil, err
+	}
+	uploaded := providerMirrorPackage(pkg)
+	// The mirror belongs to no organization.
+	s.recordAudit(ctx, nil, models.AuditActionUpload, before, uploaded)
+	return uploaded, nil
+}
+
+// ReadProviderMirrorPackage is open to every authenticated caller, like the
+// mirror protocol itself.
+func (s *service) ReadProviderMirrorPackage(ctx context.Context, packageID ProviderMirrorPackageID) (*ProviderMirrorPackage, error) {
+	packageID, err := normalizeMirrorPackageID(packageID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	pkg, err := s.findProviderMirrorPackage(packageID)
+	if err != nil {
+		return nil, err
+	}
+	return providerMirrorPackage(pkg), nil
+}
+
+func (s *service) DeleteProviderMirrorPackage(ctx context.Context, packageID ProviderMirrorPackageID) error {
+	if err := s.authorizeSiteAdmin(ctx); err != nil {
+		return err
+	}
+	packageID, err := normalizeMirrorPackageID(packageID)
+	if err != nil {
+		return gorm.ErrRecordNotFound
+	}
+	pkg, err := s.findProviderMirrorPackage(packageID)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(pkg).Error; err != nil {
+		s.logger.Error("failed to delete mirror package", zap.Error(err))
+		return err
+	}
+	s.deleteObjects(ctx, providerMirrorKey(pkg))
+	s.recordAudit(ctx, nil, models.AuditActionDelete, providerMirrorPackage(pkg), nil)
+	return nil
+}
+
+// DownloadProviderMirrorPackage streams a package through the signed URL
+// handed out by the mirror protocol.
+func (s *service) DownloadProviderMirrorPackage(ctx context.Context, packageID string) (io.ReadCloser, error) {
+	id, err := uuid.Parse(packageID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var pkg models.ProviderMirrorPackage
+	if err := s.db.Where("id = ?", id).First(&pkg).Error; err != nil {
+		s.logger.Debug("failed to read mirror package", zap.Error(err))
+		return nil, err
+	}
+	return s.openObject(ctx, providerMirrorKey(&pkg))
+}
+
+// ListProviderMirrorVersions returns the mirrored versions of a provider.
+func (s *service) ListProviderMirrorVersions(ctx context.Context, hostname, namespace, providerType string) ([]string, error) {
+	var versions []string
+	if err := s.db.Model(&models.ProviderMirrorPackage{}).
+		Where("hostname = ? AND namespace = ? AND type = ?", strings.ToLower(hostname), strings.ToLower(namespace), strings.ToLower(providerType)).
+		Distinct().Pluck("version", &versions).Error; err != nil {
+		s.logger.Error("failed to list mirror versions", zap.Error(err))
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return versions, nil
+}
+
+// ListProviderMirrorArchives returns the packages of one mirrored version,
+// one per platform.
+func (s *service) ListProviderMirrorArchives(ctx context.Context, hostname, namespace, providerType, providerVersion string) ([]*ProviderMirrorPackage, error) {
+	var packages []*models.ProviderMirrorPackage
+	if err := s.db.
+		Where("hostname = ? AND namespace = ? AND type = ? AND version = ?", strings.ToLower(hostname), strings.ToLower(namespace), strings.ToLower(providerType), providerVersion).
+		Order("os, arch").Find(&packages).Error; err != nil {
+		s.logger.Error("failed to list mirror packages", zap.Error(err))
+		return nil, err
+	}
+	if len(packages) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	result := make([]*ProviderMirrorPackage, len(packages))
+	for i, p := range packages {
+		result[i] = providerMirrorPackage(p)
+	}
+	return result, nil
+}
+
+func (s *service) findProviderMirrorPackage(packageID ProviderMirrorPackageID) (*models.ProviderMirrorPackage, error) {
+	var pkg models.ProviderMirrorPackage
+	if err := s.db.Where("hostname = ? AND namespace = ? AND type = ? AND version = ? AND os = ? AND arch = ?",
+		packageID.Hostname, packageID.Namespace, packageID.Type, packageID.Version, packageID.OS, packageID.Arch).
+		First(&pkg).Error; err != nil {
+		s.logger.Debug("failed to read mirror package", zap.Error(err))
+		return nil, err
+	}
+	return &pkg, nil
+}
+
+// normalizeMirrorPackageID validates a package address and lower-cases the
+// parts Terraform compares case-insensitively.
+func normalizeMirrorPackageID(packageID ProviderMirrorPackageID) (ProviderMirrorPackageID, error) {
+	packageID.Hostname = strings.ToLower(packageID.Hostname)
+	packageID.Namespace = strings.ToLower(packageID.Namespace)
+	packageID.Type = strings.ToLower(packageID.Type)
+	if !mirrorHostnameRegexp.MatchString(packageID.Hostname) {
+		return packageID, fmt.Errorf("%w: invalid hostname %q", ErrInvalidAttribute, packageID.Hostname)
+	}
+	if !mirrorNameRegexp.MatchString(packageID.Namespace) || !mirrorNameRegexp.MatchString(packageID.Type) {
+		return packageID, fmt.Errorf("%w: invalid provider %s/%s", ErrInvalidAttribute, packageID.Namespace, packageID.Type)
+	}
+	if _, err := version.NewSemver(packageID.Version); err != nil {
+		return packageID, fmt.Errorf("%w: version %q is not a valid semantic version", ErrInvalidAttribute, packageID.Version)
+	}
+	if !mirrorPlatformRegexp.MatchString(packageID.OS) || !mirrorPlatformRegexp.MatchString(packageID.Arch) {
+		return packageID, fmt.Errorf("%w: invalid platform %s_%s", ErrInvalidAttribute, packageID.OS, packageID.Arch)
+	}
+	return packageID, nil
+}
+
+// zipHash1 computes the "h1:" hash of a zip archive's contents, which is
+// the SHA-256 of a listing of the SHA-256 and name of every entry, sorted
+// by name.
+func zipHash1(r io.ReaderAt, size int64) (string, error) {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return "", err
+	}
+	files := make([]*zip.File, len(z.File))
+	copy(files, z.File)
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	summary := sha256.New()
+	for _, file := range files {
+		if strings.Contains(file.Name, "\n") {
+			return "", fmt.Errorf("file name %q contains a newline", file.Name)
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return "", err
+		}
+		h := sha256.New()
+		_, err = io.Copy(h, rc)
+		rc.Close()
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(summary, "%x  %s\n", h.Sum(nil), file.Name)
+	}
+	return "h1:" + base64.StdEncoding.EncodeToString(summary.Sum(nil)), nil
+}
+
+func providerMirrorPackage(p *models.ProviderMirrorPackage) *ProviderMirrorPackage {
+	return &ProviderMirrorPackage{
+		ID:        p.ID.String(),
+		Hostname:  p.Hostname,
+		Namespace: p.Namespace,
+		Type:      p.Type,
+		Version:   p.Version,
+		OS:        p.OS,
+		Arch:      p.Arch,
+		Filename:  p.Filename(),
+		Hashes:    []string{p.Hash1, "zh:" + p.Shasum},
+		CreatedAt: p.CreatedAt,
+		UpdatedAt: p.UpdatedAt,
+	}
+}
+
+func providerMirrorKey(p *models.ProviderMirrorPackage) string {
+	return "provider-mirror-packages/" + p.ID.String() + "/" + p.Filename()
+}
@@ -0,0 +1,450 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/go-version"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Module names and providers follow the Terraform module registry rules.
+var (
+	registryModuleNameRegexp     = regexp.MustCompile(`^[0-9A-Za-z](?:[0-9A-Za-z_-]{0,62}[0-9A-Za-z])?$`)
+	registryModuleProviderRegexp = regexp.MustCompile(`^[0-9a-z]{1,64}$`)
+)
+
+func (s *service) ListRegistryModules(ctx context.Context, organization string, options *tfe.RegistryModuleListOptions) ([]*tfe.RegistryModule, *tfe.Pagination, error) {
+	role, err := s.authorizeOrganizationName(ctx, organization, orgRead)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var listOptions tfe.ListOptions
+	if options != nil {
+		listOptions = options.ListOptions
+	}
+	db, pagination, err := paginate(s.db.Model(&models.RegistryModule{}).Where("organization_id = ?", role.orgID), listOptions)
+	if err != nil {
+		s.logger.Error("failed to count registry modules", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var modules []*models.RegistryModule
+	if err := db.Preload("Organization").Preload("Versions").Order("name ASC, provider ASC").Find(&modules).Error; err != nil {
+		s.logger.Error("failed to list registry modules", zap.Error(err))
+		return nil, nil, err
+	}
+
+	tfeModules := make([]*tfe.RegistryModule, len(modules))
+	for i, m := range modules {
+		tfeModules[i] = registryModuleToTFE(m, role)
+	}
+	return tfeModules, pagination, nil
+}
+
+// CreateRegistryModule creates a private module without versions. Versions
+// are published with CreateRegistryModuleVersion.
+func (s *service) CreateRegistryModule(ctx context.Context, organization string, options tfe.RegistryModuleCreateOptions) (*tfe.RegistryModule, error) {
+	role, err := s.authorizeOrganizationName(ctx, organization, orgManageModules)
+	if err != nil {
+		return nil, err
+	}
+	if options.RegistryName != "" && options.RegistryName != tfe.PrivateRegistry {
+		return nil, fmt.Errorf("%w: only private registry modules are supported", ErrInvalidAttribute)
+	}
+	if options.Namespace != "" && options.Namespace != organization {
+		return nil, fmt.Errorf("%w: namespace must be the organization name", ErrInvalidAttribute)
+	}
+	if options.Name == nil || !registryModuleNameRegexp.MatchString(*options.Name) {
+		return nil, fmt.Errorf("%w: name must contain only letters, numbers, dashes and underscores", ErrInvalidAttribute)
+	}
+	if options.Provider == nil || !registryModuleProviderRegexp.MatchString(*options.Provider) {
+		return nil, fmt.Errorf("%w: provider must contain only lowercase letters and numbers", ErrInvalidAttribute)
+	}
+
+	var existing int64
+	if err := s.db.Model(&models.RegistryModule{}).
+		Where("organization_id = ? AND name = ? AND provider = ?", role.orgID, *options.Name, *options.Provider).
+		Count(&existing).Error; err != nil {
+		s.logger.Error("failed to check registry module", zap.Error(err))
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, fmt.Errorf("%w: module %s/%s already exists", ErrInvalidAttribute, *options.Name, *options.Provider)
+	}
+
+	module := &models.RegistryModule{
+		Name:           *options.Name,
+		Provider:       *options.Provider,
+		OrganizationID: role.orgID,
+	}
+	if options.NoCode != nil {
+		module.NoCode = *options.NoCode
+	}
+	if err := s.db.Omit("Organization", "Versions").Create(module).Error; err != nil {
+		s.logger.Error("failed to create registry module", zap.Error(err))
+		return nil, err
+	}
+	created, err := s.ReadRegistryModule(ctx, tfe.RegistryModuleID{ID: module.ID.String()})
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, role.orgID, models.AuditActionCreate, nil, created)
+	return created, nil
+}
+
+func (s *service) ReadRegistryModule(ctx context.Context, moduleID tfe.RegistryModuleID) (*tfe.RegistryModule, error) {
+	module, role, err := s.findRegistryModule(ctx, moduleID, orgRead)
+	if err != nil {
+		return nil, err
+	}
+	return registryModuleToTFE(module, role), nil
+}
+
+// UpdateRegistryModule changes the settings of a module. Modules are not
+// backed by VCS, so only the no-code flag can change.
+func (s *service) UpdateRegistryModule(ctx context.Context, moduleID tfe.RegistryModuleID, options tfe.RegistryModuleUpdateOptions) (*tfe.RegistryModule, error) {
+	module, role, err := s.findRegistryModule(ctx, moduleID, orgManageModules)
+	if err != nil {
+		return nil, err
+	}
+	if options.VCSRepo != nil {
+		return nil, fmt.Errorf("%w: VCS-backed modules are not supported", ErrInvalidAttribute)
+	}
+	before := registryModuleToTFE(module, role)
+
+	if options.NoCode != nil {
+		if err := s.db.Model(&models.RegistryModule{}).Where("id = ?", module.ID).Update("no_code", *options.NoCode).Error; err != nil {
+			s.logger.Error("failed to update registry module", zap.Error(err))
+			return nil, err
+		}
+	}
+	updated, err := s.ReadRegistryModule(ctx, tfe.RegistryModuleID{ID: module.ID.String()})
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, module.OrganizationID, models.AuditActionUpdate, before, updated)
+	return updated, nil
+}
+
+// DeleteRegistryModule deletes a module with all its versions. Without a
+// provider in moduleID, every provider of the module name is deleted.
+func (s *service) DeleteRegistryModule(ctx context.Context, moduleID tfe.RegistryModuleID) error {
+	role, err := s.authorizeOrganizationName(ctx, moduleID.Organization, orgManageModules)
+	if err != nil {
+		return err
+	}
+	if !privateNamespace(moduleID) {
+		return gorm.ErrRecordNotFound
+	}
+
+	db := s.db.Where("organization_id = ? AND name = ?", role.orgID, moduleID.Name)
+	if moduleID.Provider != "" {
+		db = db.Where("provider = ?", moduleID.Provider)
+	}
+	var modules []*models.RegistryModule
+	if err := db.Preload("Versions").Find(&modules).Error; err != nil {
+		s.logger.Error("failed to read registry modules", zap.Error(err))
+		return err
+	}
+	if len(modules) == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	var ids []uuid.UUID
+	var keys []string
+	for _, m := range modules {
+		ids = append(ids, m.ID)
+		for _, v := range m.Versions {
+			keys = append(keys, registryModuleVersionKey(v.ID))
+		}
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("registry_module_id IN ?", ids).Delete(&models.RegistryModuleVersion{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", ids).Delete(&models.RegistryModule{}).Error
+	})
+	if err != nil {
+		s.logger.Error("failed to delete registry module", zap.Error(err))
+		return err
+	}
+	s.deleteObjects(ctx, keys...)
+	for _, m := range modules {
+		s.audit(ctx, role.orgID, models.AuditActionDelete, registryModuleToTFE(m, role), nil)
+	}
+	return nil
+}
+
+// CreateRegistryModuleVersion adds a pending version to a module. The
+// version is published once its archive is uploaded.
+func (s *service) CreateRegistryModuleVersion(ctx context.Context, moduleID tfe.RegistryModuleID, options tfe.RegistryModuleCreateVersionOptions) (*tfe.RegistryModuleVersion, error) {
+	module, _, err := s.findRegistryModule(ctx, moduleID, orgManageModules)
+	if err != nil {
+		return nil, err
+	}
+	if options.Version == nil {
+		return nil, fmt.Errorf("%w: version is required", ErrInvalidAttribute)
+	}
+	if _, err := version.NewSemver(*options.Version); err != nil {
+		return nil, fmt.Errorf("%w: version %q is not a valid semantic version", ErrInvalidAttribute, *options.Version)
+	}
+	for _, v := range module.Versions {
+		if v.Version == *options.Version {
+			return nil, fmt.Errorf("%w: version %s already exists", ErrInvalidAttribute, v.Version)
+		}
+	}
+
+	v := &models.RegistryModuleVersion{
+		Version:          *options.Version,
+		Status:           string(tfe.RegistryModuleVersionStatusPending),
+		Source:           models.RegistryModuleVersionSourceAPI,
+		RegistryModuleID: module.ID,
+		RegistryModule:   module,
+	}
+	if err := s.db.Omit("RegistryModule").Create(v).Error; err != nil {
+		s.logger.Error("failed to create registry module version", zap.Error(err))
+		return nil, err
+	}
+	created := v.ToTFE()
+	s.audit(ctx, module.OrganizationID, models.AuditActionCreate, nil, created)
+	return created, nil
+}
+
+func (s *service) ReadRegistryModuleVersion(ctx context.Context, moduleID tfe.RegistryModuleID, moduleVersion string) (*tfe.RegistryModuleVersion, error) {
+	module, _, err := s.findRegistryModule(ctx, moduleID, orgRead)
+	if err != nil {
+		return nil, err
+	}
+	v, err := findModuleVersion(module, moduleVersion)
+	if err != nil {
+		return nil, err
+	}
+	return v.ToTFE(), nil
+}
+
+func (s *service) DeleteRegistryModuleVersion(ctx context.Context, moduleID tfe.RegistryModuleID, moduleVersion string) error {
+	module, _, err := s.findRegistryModule(ctx, moduleID, orgManageModules)
+	if err != nil {
+		return err
+	}
+	v, err := findModuleVersion(module, moduleVersion)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Delete(v).Error; err != nil {
+		s.logger.Error("failed to delete registry module version", zap.Error(err))
+		return err
+	}
+	s.deleteObjects(ctx, registryModuleVersionKey(v.ID))
+	s.audit(ctx, module.OrganizationID, models.AuditActionDelete, v.ToTFE(), nil)
+	return nil
+}
+
+// UploadRegistryModuleVersion stores the archive of a pending version and
+// checks that it unpacks as a valid slug. Invalid archives fail the version.
+func (s *service) UploadRegistryModuleVersion(ctx context.Context, versionID string, r io.Reader) error {
+	v, err := s.findRegistryModuleVersion(ctx, versionID, orgManageModules)
+	if err != nil {
+		return err
+	}
+	if v.Status != string(tfe.RegistryModuleVersionStatusPending) {
+		return fmt.Errorf("%w: module version has already been uploaded", ErrConflict)
+	}
+	before := v.ToTFE()
+
+	key := registryModuleVersionKey(v.ID)
+	if err := s.storeSlug(ctx, key, r); err != nil {
+		if !errors.Is(err, errInvalidSlug) {
+			s.logger.Error("failed to store registry module version", zap.Error(err))
+			return err
+		}
+		if uerr := s.updateRegistryModuleVersion(v, tfe.RegistryModuleVersionStatusRegIngressFailed, err.Error()); uerr != nil {
+			return uerr
+		}
+		s.audit(ctx, v.RegistryModule.OrganizationID, models.AuditActionUpload, before, v.ToTFE())
+		return fmt.Errorf("%w: %v", ErrInvalidAttribute, err)
+	}
+	if err := s.updateRegistryModuleVersion(v, tfe.RegistryModuleVersionStatusOk, ""); err != nil {
+		return err
+	}
+	s.audit(ctx, v.RegistryModule.OrganizationID, models.AuditActionUpload, before, v.ToTFE())
+	return nil
+}
+
+// DownloadRegistryModuleVersion opens the archive of a published version.
+func (s *service) DownloadRegistryModuleVersion(ctx context.Context, versionID string) (io.ReadCloser, error) {
+	v, err := s.findRegistryModuleVersion(ctx, versionID, orgRead)
+	if err != nil {
+		return nil, err
+	}
+	if v.Status != string(tfe.RegistryModuleVersionStatusOk) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return s.openObject(ctx, registryModuleVersionKey(v.ID))
+}
+
+// ListModuleRegistryVersions returns the published versions of a module for
+// the module registry protocol, newest first.
+func (s *service) ListModuleRegistryVersions(ctx context.Context, namespace, name, provider string) ([]string, error) {
+	module, _, err := s.findRegistryModule(ctx, registryProtocolModuleID(namespace, name, provider), orgRead)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []string{}
+	for _, v := range models.SortRegistryModuleVersions(module.Versions) {
+		if v.Status == string(tfe.RegistryModuleVersionStatusOk) {
+			versions = append(versions, v.Version)
+		}
+	}
+	return versions, nil
+}
+
+// ReadModuleRegistryVersion resolves a published version for the module
+// registry protocol.
+func (s *service) ReadModuleRegistryVersion(ctx context.Context, namespace, name, provider, moduleVersion string) (*tfe.RegistryModuleVersion, error) {
+	rmv, err := s.ReadRegistryModuleVersion(ctx, registryProtocolModuleID(namespace, name, provider), moduleVersion)
+	if err != nil {
+		return nil, err
+	}
+	if rmv.Status != tfe.RegistryModuleVersionStatusOk {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return rmv, nil
+}
+
+// ListModuleProducers returns the organizations whose modules the
+// organization can use. Modules are not shared between organizations, so
+// that is the organization itself once it publishes a module.
+func (s *service) ListModuleProducers(ctx context.Context, organization string) ([]*tfe.Organization, error) {
+	role, err := s.authorizeOrganizationName(ctx, organization, orgRead)
+	if err != nil {
+		return nil, err
+	}
+
+	var modules int64
+	if err := s.db.Model(&models.RegistryModule{}).Where("organization_id = ?", role.orgID).Count(&modules).Error; err != nil {
+		s.logger.Error("failed to count registry modules", zap.Error(err))
+		return nil, err
+	}
+	if modules == 0 {
+		return []*tfe.Organization{}, nil
+	}
+	return []*tfe.Organization{{Name: organization}}, nil
+}
+
+func (s *service) updateRegistryModuleVersion(v *models.RegistryModuleVersion, status tfe.RegistryModuleVersionStatus, cause string) error {
+	if err := s.db.Model(&models.RegistryModuleVersion{}).Where("id = ?", v.ID).
+		Updates(map[string]interface{}{"status": string(status), "error": cause}).Error; err != nil {
+		s.logger.Error("failed to update registry module version", zap.Error(err))
+		return err
+	}
+	v.Status, v.Error = string(status), cause
+	return nil
+}
+
+// findRegistryModule loads a module by ID or by organization, name and
+// provider, and authorizes the caller in its organization.
+func (s *service) findRegistryModule(ctx context.Context, moduleID tfe.RegistryModuleID, required organizationPermission) (*models.RegistryModule, *organizationRole, error) {
+	db := s.db.Preload("Organization").Preload("Versions")
+	if moduleID.ID != "" {
+		id, err := uuid.Parse(moduleID.ID)
+		if err != nil {
+			return nil, nil, gorm.ErrRecordNotFound
+		}
+		db = db.Where("id = ?", id)
+	} else {
+		if !privateNamespace(moduleID) {
+			return nil, nil, gorm.ErrRecordNotFound
+		}
+		orgID, err := s.GetOrganizationIDByName(ctx, moduleID.Organization)
+		if err != nil {
+			return nil, nil, err
+		}
+		db = db.Where("organization_id = ? AND name = ? AND provider = ?", orgID, moduleID.Name, moduleID.Provider)
+	}
+
+	var module models.RegistryModule
+	if err := db.First(&module).Error; err != nil {
+		s.logger.Debug("failed to read registry module", zap.Error(err))
+		return nil, nil, err
+	}
+	role, err := s.authorizeOrganization(ctx, module.OrganizationID, required)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &module, role, nil
+}
+
+func (s *service) findRegistryModuleVersion(ctx context.Context, versionID string, required organizationPermission) (*models.RegistryModuleVersion, error) {
+	id, err := uuid.Parse(versionID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var v models.RegistryModuleVersion
+	if err := s.db.Preload("RegistryModule").Where("id = ?", id).First(&v).Error; err != nil {
+		s.logger.Debug("failed to read registry module version", zap.Error(err))
+		return nil, err
+	}
+	if v.RegistryModule == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if _, err := s.authorizeOrganization(ctx, v.RegistryModule.OrganizationID, required); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func findModuleVersion(module *models.RegistryModule, moduleVersion string) (*models.RegistryModuleVersion, error) {
+	for _, v := range module.Versions {
+		if v.Version == moduleVersion {
+			v.RegistryModule = module
+			return v, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// privateNamespace reports whether moduleID addresses the private registry
+// of its organization, the only registry modules are published to.
+func privateNamespace(moduleID tfe.RegistryModuleID) bool {
+	if moduleID.RegistryName != "" && moduleID.RegistryName != tfe.PrivateRegistry {
+		return false
+	}
+	return moduleID.Namespace == "" || moduleID.Namespace == moduleID.Organization
+}
+
+// registryProtocolModuleID addresses a module by its registry source
+// address, whose namespace is the organization name.
+func registryProtocolModuleID(namespace, name, provider string) tfe.RegistryModuleID {
+	return tfe.RegistryModuleID{
+		Organization: namespace,
+		Namespace:    namespace,
+		Name:         name,
+		Provider:     provider,
+		RegistryName: tfe.PrivateRegistry,
+	}
+}
+
+func registryModuleToTFE(module *models.RegistryModule, role *organizationRole) *tfe.RegistryModule {
+	rm := module.ToTFE()
+	rm.Permissions = &tfe.RegistryModulePermissions{
+		CanDelete: role.can(orgManageModules),
+	}
+	return rm
+}
+
+func registryModuleVersionKey(versionID uuid.UUID) string {
+	return "registry-module-versions/" + versionID.String() + "/module.tar.gz"
+}
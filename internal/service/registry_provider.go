@@ -0,0 +1,841 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/go-version"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxShasumsSize bounds SHA256SUMS files and their signatures, which are
+// read into memory to be verified.
+const maxShasumsSize = 1 << 20
+
+var (
+	registryProviderNameRegexp = regexp.MustCompile(`^[0-9a-z](?:[0-9a-z-]{0,62}[0-9a-z])?$`)
+	providerProtocolRegexp     = regexp.MustCompile(`^\d+(\.\d+)?$`)
+	shasumRegexp               = regexp.MustCompile(`^[0-9a-f]{64}$`)
+	shasumLineRegexp           = regexp.MustCompile(`^([0-9a-f]{64})\s+(\S+)$`)
+)
+
+// ProviderRegistryVersion is an installable release in the provider
+// registry protocol.
+type ProviderRegistryVersion struct {
+	Version   string                     `json:"version"`
+	Protocols []string                   `json:"protocols"`
+	Platforms []ProviderRegistryPlatform `json:"platforms"`
+}
+
+type ProviderRegistryPlatform struct {
+	OS   string `json:"os"`
+	Arch string `json:"arch"`
+}
+
+// ProviderPackage describes the package of a release for one platform.
+// The handler turns the version and platform IDs into download URLs.
+type ProviderPackage struct {
+	VersionID   string
+	PlatformID  string
+	Protocols   []string
+	OS          string
+	Arch        string
+	Filename    string
+	Shasum      string
+	SigningKeys []*tfe.GPGKey
+}
+
+func (s *service) ListRegistryProviders(ctx context.Context, organization string, options *tfe.RegistryProviderListOptions) ([]*tfe.RegistryProvider, *tfe.Pagination, error) {
+	role, err := s.authorizeOrganizationName(ctx, organization, orgRead)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db := s.db.Model(&models.RegistryProvider{}).Where("organization_id = ?", role.orgID)
+	var listOptions tfe.ListOptions
+	if options != nil {
+		listOptions = options.ListOptions
+		if options.Search != "" {
+			db = db.Where("name ILIKE ?", "%"+options.Search+"%")
+		}
+		if options.RegistryName != "" && options.RegistryName != tfe.PrivateRegistry {
+			// Only private providers are hosted.
+			db = db.Where("FALSE")
+		}
+	}
+	db, pagination, err := paginate(db, listOptions)
+	if err != nil {
+		s.logger.Error("failed to count registry providers", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var providers []*models.RegistryProvider
+	if err := db.Preload("Organization").Preload("Versions").Order("name ASC").Find(&providers).Error; err != nil {
+		s.logger.Error("failed to list registry providers", zap.Error(err))
+		return nil, nil, err
+	}
+
+	tfeProviders := make([]*tfe.RegistryProvider, len(providers))
+	for i, p := range providers {
+		tfeProviders[i] = registryProviderToTFE(p, role)
+	}
+	return tfeProviders, pagination, nil
+}
+
+// CreateRegistryProvider creates a private provider without releases.
+func (s *service) CreateRegistryProvider(ctx context.Context, organization string, options tfe.RegistryProviderCreateOptions) (*tfe.RegistryProvider, error) {
+	role, err := s.authorizeOrganizationName(ctx, organization, orgManageProviders)
+	if err != nil {
+		return nil, err
+	}
+	if options.RegistryName != tfe.PrivateRegistry {
+		return nil, fmt.Errorf("%w: only private registry providers are supported", ErrInvalidAttribute)
+	}
+	if options.Namespace != organization {
+		return nil, fmt.Errorf("%w: namespace must be the organization name", ErrInvalidAttribute)
+	}
+	if !registryProviderNameRegexp.MatchString(options.Name) {
+		return nil, fmt.Errorf("%w: name must contain only lowercase letters, numbers and dashes", ErrInvalidAttribute)
+	}
+
+	var existing int64
+	if err := s.db.Model(&models.RegistryProvider{}).Where("organization_id = ? AND name = ?", role.orgID, options.Name).Count(&existing).Error; err != nil {
+		s.logger.Error("failed to check registry provider", zap.Error(err))
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, fmt.Errorf("%w: provider %s already exists", ErrInvalidAttribute, options.Name)
+	}
+
+	provider := &models.RegistryProvider{Name: options.Name, OrganizationID: role.orgID}
+	if err := s.db.Omit("Organization", "Versions").Create(provider).Error; err != nil {
+		s.logger.Error("failed to create registry provider", zap.Error(err))
+		return nil, err
+	}
+	created, err := s.ReadRegistryProvider(ctx, tfe.RegistryProviderID{
+		OrganizationName: organization,
+		RegistryName:     tfe.PrivateRegistry,
+		Namespace:        organization,
+		Name:             provider.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, role.orgID, models.AuditActionCreate, nil, created)
+	return created, nil
+}
+
+func (s *service) ReadRegistryProvider(ctx context.Context, providerID tfe.RegistryProviderID) (*tfe.RegistryProvider, error) {
+	provider, role, err := s.findRegistryProvider(ctx, providerID, orgRead)
+	if err != nil {
+		return nil, err
+	}
+	return registryProviderToTFE(provider, role), nil
+}
+
+// DeleteRegistryProvider deletes a provider with all its releases.
+func (s *service) DeleteRegistryProvider(ctx context.Context, providerID tfe.RegistryProviderID) error {
+	provider, role, err := s.findRegistryProvider(ctx, providerID, orgManageProviders)
+	if err != nil {
+		return err
+	}
+
+	var versions []*models.RegistryProviderVersion
+	if err := s.db.Preload("Platforms").Where("registry_provider_id = ?", provider.ID).Find(&versions).Error; err != nil {
+		s.logger.Error("failed to read provider versions", zap.Error(err))
+		return err
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, v := range versions {
+			if err := deleteProviderVersion(tx, v); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(provider).Error
+	})
+	if err != nil {
+		s.logger.Error("failed to delete registry provider", zap.Error(err))
+		return err
+	}
+	for _, v := range versions {
+		s.deleteObjects(ctx, providerVersionKeys(v)...)
+	}
+	s.audit(ctx, provider.OrganizationID, models.AuditActionDelete, registryProviderToTFE(provider, role), nil)
+	return nil
+}
+
+func (s *service) ListRegistryProviderVersions(ctx context.Context, providerID tfe.RegistryProviderID, options *tfe.RegistryProviderVersionListOptions) ([]*tfe.RegistryProviderVersion, *tfe.Pagination, error) {
+	provider, role, err := s.findRegistryProvider(ctx, providerID, orgRead)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var listOptions tfe.ListOptions
+	if options != nil {
+		listOptions = options.ListOptions
+	}
+	db, pagination, err := paginate(s.db.Model(&models.RegistryProviderVersion{}).Where("registry_provider_id = ?", provider.ID), listOptions)
+	if err != nil {
+		s.logger.Error("failed to count provider versions", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var versions []*models.RegistryProviderVersion
+	if err := db.Preload("Platforms").Order("created_at DESC").Find(&versions).Error; err != nil {
+		s.logger.Error("failed to list provider versions", zap.Error(err))
+		return nil, nil, err
+	}
+
+	tfeVersions := make([]*tfe.RegistryProviderVersion, len(versions))
+	for i, v := range versions {
+		v.RegistryProvider = provider
+		tfeVersions[i] = providerVersionToTFE(v, role)
+	}
+	return tfeVersions, pagination, nil
+}
+
+// CreateRegistryProviderVersion adds a release signed by a GPG key of the
+// organization. Its SHA256SUMS file, signature and binaries are uploaded
+// afterwards.
+func (s *service) CreateRegistryProviderVersion(ctx context.Context, providerID tfe.RegistryProviderID, options tfe.RegistryProviderVersionCreateOptions) (*tfe.RegistryProviderVersion, error) {
+	provider, role, err := s.findRegistryProvider(ctx, providerID, orgManageProviders)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := version.NewSemver(options.Version); err != nil {
+		return nil, fmt.Errorf("%w: version %q is not a valid semantic version", ErrInvalidAttribute, options.Version)
+	}
+	for _, v := range provider.Versions {
+		if v.Version == options.Version {
+			return nil, fmt.Errorf("%w: version %s already exists", ErrInvalidAttribute, v.Version)
+		}
+	}
+	if len(options.Protocols) == 0 {
+		return nil, fmt.Errorf("%w: at least one protocol is required", ErrInvalidAttribute)
+	}
+	for _, protocol := range options.Protocols {
+		if !providerProtocolRegexp.MatchString(protocol) {
+			return nil, fmt.Errorf("%w: protocol %q must be a major or major.minor version", ErrInvalidAttribute, protocol)
+		}
+	}
+	key, err := s.findOrganizationGPGKey(provider.OrganizationID, options.KeyID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: GPG key %s does not exist in namespace %s", ErrInvalidAttribute, options.KeyID, providerID.Namespace)
+	}
+
+	v := &models.RegistryProviderVersion{
+		Version:            options.Version,
+		KeyID:              key.KeyID,
+		Protocols:          strings.Join(options.Protocols, ","),
+		RegistryProviderID: provider.ID,
+		RegistryProvider:   provider,
+	}
+	if err := s.db.Omit("RegistryProvider", "Platforms").Create(v).Error; err != nil {
+		s.logger.Error("failed to create provider version", zap.Error(err))
+		return nil, err
+	}
+	created := providerVersionToTFE(v, role)
+	s.audit(ctx, provider.OrganizationID, models.AuditActionCreate, nil, created)
+	return created, nil
+}
+
+func (s *service) ReadRegistryProviderVersion(ctx context.Context, versionID tfe.RegistryProviderVersionID) (*tfe.RegistryProviderVersion, error) {
+	v, role, err := s.findProviderVersion(ctx, versionID, orgRead)
+	if err != nil {
+		return nil, err
+	}
+	return providerVersionToTFE(v, role), nil
+}
+
+func (s *service) DeleteRegistryProviderVersion(ctx context.Context, versionID tfe.RegistryProviderVersionID) error {
+	v, role, err := s.findProviderVersion(ctx, versionID, orgManageProviders)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Transaction(func(tx *gorm.DB) error { return deleteProviderVersion(tx, v) }); err != nil {
+		s.logger.Error("failed to delete provider version", zap.Error(err))
+		return err
+	}
+	s.deleteObjects(ctx, providerVersionKeys(v)...)
+	s.audit(ctx, v.RegistryProvider.OrganizationID, models.AuditActionDelete, providerVersionToTFE(v, role), nil)
+	return nil
+}
+
+func (s *service) ListRegistryProviderPlatforms(ctx context.Context, versionID tfe.RegistryProviderVersionID, options *tfe.RegistryProviderPlatformListOptions) ([]*tfe.RegistryProviderPlatform, *tfe.Pagination, error) {
+	v, _, err := s.findProviderVersion(ctx, versionID, orgRead)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var listOptions tfe.ListOptions
+	if options != nil {
+		listOptions = options.ListOptions
+	}
+	db, pagination, err := paginate(s.db.Model(&models.RegistryProviderPlatform{}).Where("registry_provider_version_id = ?", v.ID), listOptions)
+	if err != nil {
+		s.logger.Error("failed to count provider platforms", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var platforms []*models.RegistryProviderPlatform
+	if err := db.Order("os ASC, arch ASC").Find(&platforms).Error; err != nil {
+		s.logger.Error("failed to list provider platforms", zap.Error(err))
+		return nil, nil, err
+	}
+
+	tfePlatforms := make([]*tfe.RegistryProviderPlatform, len(platforms))
+	for i, p := range platforms {
+		p.RegistryProviderVersion = v
+		tfePlatforms[i] = p.ToTFE()
+	}
+	return tfePlatforms, pagination, nil
+}
+
+// CreateRegistryProviderPlatform adds a platform to a release. Once the
+// SHA256SUMS file is uploaded, the platform must be listed in it.
+func (s *service) CreateRegistryProviderPlatform(ctx context.Context, versionID tfe.RegistryProviderVersionID, options tfe.RegistryProviderPlatformCreateOptions) (*tfe.RegistryProviderPlatform, error) {
+	v, _, err := s.findProviderVersion(ctx, versionID, orgManageProviders)
+	if err != nil {
+		return nil, err
+	}
+	if options.OS == "" || options.Arch == "" {
+		return nil, fmt.Errorf("%w: os and arch are required", ErrInvalidAttribute)
+	}
+	if options.Filename == "" || strings.ContainsAny(options.Filename, "/\\") {
+		return nil, fmt.Errorf("%w: filename must be a file name", ErrInvalidAttribute)
+	}
+	if !shasumRegexp.MatchString(options.Shasum) {
+		return nil, fmt.Errorf("%w: shasum must be a hex-encoded SHA-256 checksum", ErrInvalidAttribute)
+	}
+	for _, p := range v.Platforms {
+		if p.OS == options.OS && p.Arch == options.Arch {
+			return nil, fmt.Errorf("%w: platform %s_%s already exists", ErrInvalidAttribute, p.OS, p.Arch)
+		}
+	}
+
+	platform := &models.RegistryProviderPlatform{
+		OS:                        options.OS,
+		Arch:                      options.Arch,
+		Filename:                  options.Filename,
+		Shasum:                    options.Shasum,
+		RegistryProviderVersionID: v.ID,
+		RegistryProviderVersion:   v,
+	}
+	if v.ShasumsUploaded {
+		sums, err := s.readProviderShasums(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkPlatformShasum(sums, platform); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.db.Omit("RegistryProviderVersion").Create(platform).Error; err != nil {
+		s.logger.Error("failed to create provider platform", zap.Error(err))
+		return nil, err
+	}
+	created := platform.ToTFE()
+	s.audit(ctx, v.RegistryProvider.OrganizationID, models.AuditActionCreate, nil, created)
+	return created, nil
+}
+
+func (s *service) ReadRegistryProviderPlatform(ctx context.Context, platformID tfe.RegistryProviderPlatformID) (*tfe.RegistryProviderPlatform, error) {
+	platform, err := s.findProviderPlatform(ctx, platformID, orgRead)
+	if err != nil {
+		return nil, err
+	}
+	return platform.ToTFE(), nil
+}
+
+func (s *service) DeleteRegistryProviderPlatform(ctx context.Context, platformID tfe.RegistryProviderPlatformID) error {
+	platform, err := s.findProviderPlatform(ctx, platformID, orgManageProviders)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(platform).Error; err != nil {
+		s.logger.Error("failed to delete provider platform", zap.Error(err))
+		return err
+	}
+	s.deleteObjects(ctx, providerBinaryKey(platform.ID))
+	s.audit(ctx, platform.RegistryProviderVersion.RegistryProvider.OrganizationID, models.AuditActionDelete, platform.ToTFE(), nil)
+	return nil
+}
+
+// UploadProviderShasums stores the SHA256SUMS file of a release. It must
+// list every platform of the release and, when the signature is already
+// uploaded, match it.
+func (s *service) UploadProviderShasums(ctx context.Context, versionID string, r io.Reader) error {
+	v, err := s.findProviderVersionByID(ctx, versionID, orgManageProviders)
+	if err != nil {
+		return err
+	}
+	if v.ShasumsUploaded {
+		return fmt.Errorf("%w: SHA256SUMS has already been uploaded", ErrConflict)
+	}
+	data, err := readLimited(r, maxShasumsSize)
+	if err != nil {
+		return err
+	}
+	sums, err := parseShasums(data)
+	if err != nil {
+		return err
+	}
+	for _, p := range v.Platforms {
+		if err := checkPlatformShasum(sums, p); err != nil {
+			return err
+		}
+	}
+
+	before := v.ToTFE()
+	if err := s.storeProviderShasumsFile(ctx, v, false, data); err != nil {
+		return err
+	}
+	v.ShasumsUploaded = true
+	s.audit(ctx, v.RegistryProvider.OrganizationID, models.AuditActionUpload, before, v.ToTFE())
+	return nil
+}
+
+// UploadProviderShasumsSignature stores the detached signature of the
+// SHA256SUMS file of a release. It must be made by the release's GPG key.
+func (s *service) UploadProviderShasumsSignature(ctx context.Context, versionID string, r io.Reader) error {
+	v, err := s.findProviderVersionByID(ctx, versionID, orgManageProviders)
+	if err != nil {
+		return err
+	}
+	if v.ShasumsSigUploaded {
+		return fmt.Errorf("%w: SHA256SUMS signature has already been uploaded", ErrConflict)
+	}
+	sig, err := readLimited(r, maxShasumsSize)
+	if err != nil {
+		return err
+	}
+
+	before := v.ToTFE()
+	if err := s.storeProviderShasumsFile(ctx, v, true, sig); err != nil {
+		return err
+	}
+	v.ShasumsSigUploaded = true
+	s.audit(ctx, v.RegistryProvider.OrganizationID, models.AuditActionUpload, before, v.ToTFE())
+	return nil
+}
+
+// storeProviderShasumsFile stores the SHA256SUMS file of a release, or its
+// signature, while holding the version row so that concurrent uploads are
+// serialized. Whichever of the two files arrives last is verified against
+// the other before its flag is set, so a release never has both without a
+// valid signature.
+func (s *service) storeProviderShasumsFile(ctx context.Context, v *models.RegistryProviderVersion, signature bool, data []byte) error {
+	name, column, key := "SHA256SUMS", "shasums_uploaded", providerShasumsKey(v.ID)
+	if signature {
+		name, column, key = "SHA256SUMS signature", "shasums_sig_uploaded", providerShasumsSigKey(v.ID)
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var locked models.RegistryProviderVersion
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", v.ID).First(&locked).Error; err != nil {
+			s.logger.Error("failed to lock provider version", zap.Error(err))
+			return err
+		}
+		uploaded, otherUploaded := locked.ShasumsUploaded, locked.ShasumsSigUploaded
+		if signature {
+			uploaded, otherUploaded = otherUploaded, uploaded
+		}
+		if uploaded {
+			return fmt.Errorf("%w: %s has already been uploaded", ErrConflict, name)
+		}
+		if otherUploaded {
+			if err := s.verifyProviderShasumsFile(ctx, v, signature, data); err != nil {
+				return err
+			}
+		}
+
+		if err := s.store.Put(ctx, key, bytes.NewReader(data), int64(len(data))); err != nil {
+			s.logger.Error("failed to store provider checksums", zap.String("file", name), zap.Error(err))
+			return err
+		}
+		result := tx.Model(&models.RegistryProviderVersion{}).
+			Where("id = ? AND NOT "+column, v.ID).
+			Update(column, true)
+		if result.Error != nil {
+			s.logger.Error("failed to update provider version", zap.Error(result.Error))
+			s.deleteObjects(ctx, key)
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: %s has already been uploaded", ErrConflict, name)
+		}
+		return nil
+	})
+}
+
+// verifyProviderShasumsFile verifies a newly uploaded SHA256SUMS file, or
+// signature, against the stored counterpart.
+func (s *service) verifyProviderShasumsFile(ctx context.Context, v *models.RegistryProviderVersion, signature bool, data []byte) error {
+	if signature {
+		shasums, err := s.readObject(ctx, providerShasumsKey(v.ID))
+		if err != nil {
+			return err
+		}
+		return s.verifyProviderShasums(v, shasums, data)
+	}
+	sig, err := s.readObject(ctx, providerShasumsSigKey(v.ID))
+	if err != nil {
+		return err
+	}
+	return s.verifyProviderShasums(v, data, sig)
+}
+
+func (s *service) DownloadProviderShasums(ctx context.Context, versionID string) (io.ReadCloser, error) {
+	v, err := s.findProviderVersionByID(ctx, versionID, orgRead)
+	if err != nil {
+		return nil, err
+	}
+	if !v.ShasumsUploaded {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return s.openObject(ctx, providerShasumsKey(v.ID))
+}
+
+func (s *service) DownloadProviderShasumsSignature(ctx context.Context, versionID string) (io.ReadCloser, error) {
+	v, err := s.findProviderVersionByID(ctx, versionID, orgRead)
+	if err != nil {
+		return nil, err
+	}
+	if !v.ShasumsSigUploaded {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return s.openObject(ctx, providerShasumsSigKey(v.ID))
+}
+
+// UploadProviderBinary stores the package of a platform. Its SHA-256
+// checksum must match the one the platform was created with.
+func (s *service) UploadProviderBinary(ctx context.Context, platformID string, r io.Reader) error {
+	platform, err := s.findProviderPlatformByID(ctx, platformID, orgManageProviders)
+	if err != nil {
+		return err
+	}
+	if platform.ProviderBinaryUploaded {
+		return fmt.Errorf("%w: provider binary has already been uploaded", ErrConflict)
+	}
+
+	key := providerBinaryKey(platform.ID)
+	hash := sha256.New()
+	if err := s.store.Put(ctx, key, io.TeeReader(r, hash), -1); err != nil {
+		s.logger.Error("failed to store provider binary", zap.Error(err))
+		return err
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != platform.Shasum {
+		s.deleteObjects(ctx, key)
+		return fmt.Errorf("%w: binary checksum %s does not match %s", ErrInvalidAttribute, sum, platform.Shasum)
+	}
+
+	before := platform.ToTFE()
+	if err := s.db.Model(&models.RegistryProviderPlatform{}).Where("id = ?", platform.ID).Update("provider_binary_uploaded", true).Error; err != nil {
+		s.logger.Error("failed to update provider platform", zap.Error(err))
+		return err
+	}
+	platform.ProviderBinaryUploaded = true
+	s.audit(ctx, platform.RegistryProviderVersion.RegistryProvider.OrganizationID, models.AuditActionUpload, before, platform.ToTFE())
+	return nil
+}
+
+func (s *service) DownloadProviderBinary(ctx context.Context, platformID string) (io.ReadCloser, error) {
+	platform, err := s.findProviderPlatformByID(ctx, platformID, orgRead)
+	if err != nil {
+		return nil, err
+	}
+	if !platform.ProviderBinaryUploaded {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return s.openObject(ctx, providerBinaryKey(platform.ID))
+}
+
+// ListProviderRegistryVersions returns the installable releases of a
+// provider for the provider registry protocol. A release is installable
+// once it is signed and has at least one uploaded platform.
+func (s *service) ListProviderRegistryVersions(ctx context.Context, namespace, name string) ([]ProviderRegistryVersion, error) {
+	provider, _, err := s.findRegistryProvider(ctx, registryProtocolProviderID(namespace, name), orgRead)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []*models.RegistryProviderVersion
+	if err := s.db.Preload("Platforms", "provider_binary_uploaded = ?", true).
+		Where("registry_provider_id = ? AND shasums_uploaded AND shasums_sig_uploaded", provider.ID).
+		Order("created_at DESC").Find(&versions).Error; err != nil {
+		s.logger.Error("failed to list provider versions", zap.Error(err))
+		return nil, err
+	}
+
+	releases := []ProviderRegistryVersion{}
+	for _, v := range versions {
+		if len(v.Platforms) == 0 {
+			continue
+		}
+		release := ProviderRegistryVersion{Version: v.Version, Protocols: v.ProtocolList()}
+		for _, p := range v.Platforms {
+			release.Platforms = append(release.Platforms, ProviderRegistryPlatform{OS: p.OS, Arch: p.Arch})
+		}
+		releases = append(releases, release)
+	}
+	return releases, nil
+}
+
+// ReadProviderRegistryPackage resolves the package of an installable
+// release for one platform, with the key that signs it.
+func (s *service) ReadProviderRegistryPackage(ctx context.Context, namespace, name, providerVersion, os, arch string) (*ProviderPackage, error) {
+	v, _, err := s.findProviderVersion(ctx, tfe.RegistryProviderVersionID{
+		RegistryProviderID: registryProtocolProviderID(namespace, name),
+		Version:            providerVersion,
+	}, orgRead)
+	if err != nil {
+		return nil, err
+	}
+	if !v.ShasumsUploaded || !v.ShasumsSigUploaded {
+		return nil, gorm.ErrRecordNotFound
+	}
+	var platform *models.RegistryProviderPlatform
+	for _, p := range v.Platforms {
+		if p.OS == os && p.Arch == arch && p.ProviderBinaryUploaded {
+			platform = p
+		}
+	}
+	if platform == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	key, err := s.findOrganizationGPGKey(v.RegistryProvider.OrganizationID, v.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProviderPackage{
+		VersionID:   v.ID.String(),
+		PlatformID:  platform.ID.String(),
+		Protocols:   v.ProtocolList(),
+		OS:          platform.OS,
+		Arch:        platform.Arch,
+		Filename:    platform.Filename,
+		Shasum:      platform.Shasum,
+		SigningKeys: []*tfe.GPGKey{key.ToTFE()},
+	}, nil
+}
+
+func (s *service) verifyProviderShasums(v *models.RegistryProviderVersion, shasums, signature []byte) error {
+	key, err := s.findOrganizationGPGKey(v.RegistryProvider.OrganizationID, v.KeyID)
+	if err != nil {
+		return fmt.Errorf("%w: GPG key %s of the version no longer exists", ErrInvalidAttribute, v.KeyID)
+	}
+	if err := verifyGPGSignature(key.AsciiArmor, shasums, signature); err != nil {
+		return fmt.Errorf("%w: SHA256SUMS signature does not verify with key %s: %v", ErrInvalidAttribute, v.KeyID, err)
+	}
+	return nil
+}
+
+func (s *service) readProviderShasums(ctx context.Context, v *models.RegistryProviderVersion) (map[string]string, error) {
+	data, err := s.readObject(ctx, providerShasumsKey(v.ID))
+	if err != nil {
+		return nil, err
+	}
+	return parseShasums(data)
+}
+
+func (s *service) readObject(ctx context.Context, key string) ([]byte, error) {
+	rc, err := s.openObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// findRegistryProvider loads a private provider by organization and name
+// and authorizes the caller in the organization.
+func (s *service) findRegistryProvider(ctx context.Context, providerID tfe.RegistryProviderID, required organizationPermission) (*models.RegistryProvider, *organizationRole, error) {
+	if providerID.RegistryName != tfe.PrivateRegistry || providerID.Namespace != providerID.OrganizationName {
+		return nil, nil, gorm.ErrRecordNotFound
+	}
+	role, err := s.authorizeOrganizationName(ctx, providerID.OrganizationName, required)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var provider models.RegistryProvider
+	if err := s.db.Preload("Organization").Preload("Versions").
+		Where("organization_id = ? AND name = ?", role.orgID, providerID.Name).First(&provider).Error; err != nil {
+		s.logger.Debug("failed to read registry provider", zap.Error(err))
+		return nil, nil, err
+	}
+	return &provider, role, nil
+}
+
+func (s *service) findProviderVersion(ctx context.Context, versionID tfe.RegistryProviderVersionID, required organizationPermission) (*models.RegistryProviderVersion, *organizationRole, error) {
+	provider, role, err := s.findRegistryProvider(ctx, versionID.RegistryProviderID, required)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var v models.RegistryProviderVersion
+	if err := s.db.Preload("Platforms").Where("registry_provider_id = ? AND version = ?", provider.ID, versionID.Version).First(&v).Error; err != nil {
+		s.logger.Debug("failed to read provider version", zap.Error(err))
+		return nil, nil, err
+	}
+	v.RegistryProvider = provider
+	return &v, role, nil
+}
+
+func (s *service) findProviderPlatform(ctx context.Context, platformID tfe.RegistryProviderPlatformID, required organizationPermission) (*models.RegistryProviderPlatform, error) {
+	v, _, err := s.findProviderVersion(ctx, platformID.RegistryProviderVersionID, required)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range v.Platforms {
+		if p.OS == platformID.OS && p.Arch == platformID.Arch {
+			p.RegistryProviderVersion = v
+			return p, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// findProviderVersionByID loads a release for a signed transfer URL.
+func (s *service) findProviderVersionByID(ctx context.Context, versionID string, required organizationPermission) (*models.RegistryProviderVersion, error) {
+	id, err := uuid.Parse(versionID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var v models.RegistryProviderVersion
+	if err := s.db.Preload("RegistryProvider").Preload("Platforms").Where("id = ?", id).First(&v).Error; err != nil {
+		s.logger.Debug("failed to read provider version", zap.Error(err))
+		return nil, err
+	}
+	if v.RegistryProvider == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if _, err := s.authorizeOrganization(ctx, v.RegistryProvider.OrganizationID, required); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// findProviderPlatformByID loads a platform for a signed transfer URL.
+func (s *service) findProviderPlatformByID(ctx context.Context, platformID string, required organizationPermission) (*models.RegistryProviderPlatform, error) {
+	id, err := uuid.Parse(platformID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var platform models.RegistryProviderPlatform
+	if err := s.db.Preload("RegistryProviderVersion.RegistryProvider").Where("id = ?", id).First(&platform).Error; err != nil {
+		s.logger.Debug("failed to read provider platform", zap.Error(err))
+		return nil, err
+	}
+	if platform.RegistryProviderVersion == nil || platform.RegistryProviderVersion.RegistryProvider == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if _, err := s.authorizeOrganization(ctx, platform.RegistryProviderVersion.RegistryProvider.OrganizationID, required); err != nil {
+		return nil, err
+	}
+	return &platform, nil
+}
+
+func deleteProviderVersion(tx *gorm.DB, v *models.RegistryProviderVersion) error {
+	if err := tx.Where("registry_provider_version_id = ?", v.ID).Delete(&models.RegistryProviderPlatform{}).Error; err != nil {
+		return err
+	}
+	return tx.Delete(v).Error
+}
+
+// parseShasums reads a SHA256SUMS file into a map from file name to
+// checksum.
+func parseShasums(data []byte) (map[string]string, error) {
+	sums := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		match := shasumLineRegexp.FindStringSubmatch(text)
+		if match == nil {
+			return nil, fmt.Errorf("%w: SHA256SUMS line %d is not a checksum and file name", ErrInvalidAttribute, line)
+		}
+		sums[match[2]] = match[1]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: invalid SHA256SUMS: %v", ErrInvalidAttribute, err)
+	}
+	if len(sums) == 0 {
+		return nil, fmt.Errorf("%w: SHA256SUMS lists no files", ErrInvalidAttribute)
+	}
+	return sums, nil
+}
+
+func checkPlatformShasum(sums map[string]string, p *models.RegistryProviderPlatform) error {
+	sum, ok := sums[p.Filename]
+	if !ok {
+		return fmt.Errorf("%w: SHA256SUMS does not list %s", ErrInvalidAttribute, p.Filename)
+	}
+	if sum != p.Shasum {
+		return fmt.Errorf("%w: SHA256SUMS lists %s with checksum %s, not %s", ErrInvalidAttribute, p.Filename, sum, p.Shasum)
+	}
+	return nil
+}
+
+// registryProtocolProviderID addresses a provider by its registry source
+// address, whose namespace is the organization name.
+func registryProtocolProviderID(namespace, name string) tfe.RegistryProviderID {
+	return tfe.RegistryProviderID{
+		OrganizationName: namespace,
+		RegistryName:     tfe.PrivateRegistry,
+		Namespace:        namespace,
+		Name:             name,
+	}
+}
+
+func registryProviderToTFE(provider *models.RegistryProvider, role *organizationRole) *tfe.RegistryProvider {
+	p := provider.ToTFE()
+	p.Permissions = tfe.RegistryProviderPermissions{CanDelete: role.can(orgManageProviders)}
+	return p
+}
+
+func providerVersionToTFE(v *models.RegistryProviderVersion, role *organizationRole) *tfe.RegistryProviderVersion {
+	rpv := v.ToTFE()
+	rpv.Permissions = tfe.RegistryProviderVersionPermissions{
+		CanDelete:      role.can(orgManageProviders),
+		CanUploadAsset: role.can(orgManageProviders),
+	}
+	return rpv
+}
+
+func providerVersionKeys(v *models.RegistryProviderVersion) []string {
+	keys := []string{providerShasumsKey(v.ID), providerShasumsSigKey(v.ID)}
+	for _, p := range v.Platforms {
+		keys = append(keys, providerBinaryKey(p.ID))
+	}
+	return keys
+}
+
+func providerShasumsKey(versionID uuid.UUID) string {
+	return "registry-provider-versions/" + versionID.String() + "/SHA256SUMS"
+}
+
+func providerShasumsSigKey(versionID uuid.UUID) string {
+	return "registry-provider-versions/" + versionID.String() + "/SHA256SUMS.sig"
+}
+
+func providerBinaryKey(platformID uuid.UUID) string {
+	return "registry-provider-platforms/" + platformID.String() + "/provider.zip"
+}
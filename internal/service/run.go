@@ -0,0 +1,481 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/constants"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const defaultRunMessage = "Queued manually via the Terraform Enterprise API"
+
+// runOperations maps the filter[operation] values to the runs they select.
+var runOperations = map[string]string{
+	"plan_only":      "runs.plan_only = true",
+	"plan_and_apply": "runs.plan_only = false AND runs.is_destroy = false AND runs.refresh_only = false",
+	"destroy":        "runs.is_destroy = true",
+	"refresh_only":   "runs.refresh_only = true",
+	"save_plan":      "runs.save_plan = true",
+}
+
+func (s *service) ListRuns(ctx context.Context, workspaceID string, options *tfe.RunListOptions) ([]*tfe.Run, *tfe.Pagination, error) {
+	ws, err := s.findWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, ws, wsRead); err != nil {
+		return nil, nil, err
+	}
+	return s.listRuns(s.db.Model(&models.Run{}).Where("runs.workspace_id = ?", ws.ID), options)
+}
+
+// ListOrganizationRuns lists the runs of the workspaces in an organization
+// the caller may read.
+func (s *service) ListOrganizationRuns(ctx context.Context, organization string, options *tfe.RunListOptions) ([]*tfe.Run, *tfe.Pagination, error) {
+	role, err := s.authorizeOrganizationName(ctx, organization, orgRead)
+	if err != nil {
+		return nil, nil, err
+	}
+	db := s.db.Model(&models.Run{}).
+		Joins("JOIN workspaces ON workspaces.id = runs.workspace_id AND workspaces.deleted_at IS NULL").
+		Where("workspaces.organization_id = ?", role.orgID)
+	return s.listRuns(s.readableWorkspaces(db, role), options)
+}
+
+// ReadRunQueue lists the runs of an organization that are waiting for a worker.
+func (s *service) ReadRunQueue(ctx context.Context, organization string, options tfe.ReadRunQueueOptions) ([]*tfe.Run, *tfe.Pagination, error) {
+	return s.ListOrganizationRuns(ctx, organization, &tfe.RunListOptions{
+		ListOptions: options.ListOptions,
+		Status:      strings.Join([]string{string(tfe.RunPlanQueued), string(tfe.RunApplyQueued)}, ","),
+	})
+}
+
+func (s *service) listRuns(db *gorm.DB, options *tfe.RunListOptions) ([]*tfe.Run, *tfe.Pagination, error) {
+	var listOptions tfe.ListOptions
+	if options != nil {
+		listOptions = options.ListOptions
+		if options.Status != "" {
+			db = db.Where("runs.status IN ?", strings.Split(options.Status, ","))
+		}
+		if options.Source != "" {
+			db = db.Where("runs.source IN ?", strings.Split(options.Source, ","))
+		}
+		if options.Operation != "" {
+			var clauses []string
+			for _, op := range strings.Split(options.Operation, ",") {
+				clause, ok := runOperations[op]
+				if !ok {
+					return nil, nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidAttribute, op)
+				}
+				clauses = append(clauses, "("+clause+")")
+			}
+			db = db.Where("(" + strings.Join(clauses, " OR ") + ")")
+		}
+		if options.User != "" {
+			db = db.Where("runs.created_by_id IN (?)", s.db.Model(&models.User{}).Select("id").Where("username = ?", options.User))
+		}
+		if options.Commit != "" {
+			db = db.Where("runs.configuration_version_id IN (?)",
+				s.db.Model(&models.IngressAttributes{}).Select("configuration_version_id").Where("commit_sha = ?", options.Commit))
+		}
+		if options.Search != "" {
+			db = db.Where("(runs.message ILIKE ? OR CAST(runs.id AS text) = ?)", "%"+options.Search+"%", options.Search)
+		}
+	}
+
+	db, pagination, err := paginate(db, listOptions)
+	if err != nil {
+		s.logger.Error("failed to count runs", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var runs []*models.Run
+	if err := db.Preload("Plan").Preload("Apply").Preload("CreatedBy").Order("runs.created_at DESC").Find(&runs).Error; err != nil {
+		s.logger.Error("failed to list runs", zap.Error(err))
+		return nil, nil, err
+	}
+
+	tfeRuns := make([]*tfe.Run, len(runs))
+	for i, run := range runs {
+		tfeRuns[i] = run.ToTFE()
+	}
+	return tfeRuns, pagination, nil
+}
+
+func (s *service) CreateRun(ctx context.Context, options tfe.RunCreateOptions) (*tfe.Run, error) {
+	if options.Workspace == nil || options.Workspace.ID == "" {
+		return nil, fmt.Errorf("%w: workspace is required", ErrInvalidAttribute)
+	}
+
+	createdByID, err := s.currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ws, err := s.findWorkspaceByID(ctx, options.Workspace.ID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, ws, wsQueuePlan); err != nil {
+		return nil, err
+	}
+
+	var cv *models.ConfigurationVersion
+	if options.ConfigurationVersion != nil && options.ConfigurationVersion.ID != "" {
+		cv, err = s.findConfigurationVersion(options.ConfigurationVersion.ID)
+		if err != nil {
+			return nil, err
+		}
+		if cv.WorkspaceID != ws.ID {
+			return nil, fmt.Errorf("%w: configuration version does not belong to the workspace", ErrInvalidAttribute)
+		}
+	} else {
+		cv, err = s.latestConfigurationVersion(ws.ID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: the workspace has no uploaded configuration version", ErrInvalidAttribute)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	run := &models.Run{
+		Message:          defaultRunMessage,
+		Source:           string(tfe.RunSourceAPI),
+		Refresh:          true,
+		AutoApply:        ws.AutoApply,
+		TerraformVersion: ws.TerraformVersion,
+		PlanOnly:         cv.Speculative,
+		CreatedByID:      createdByID,
+	}
+	if options.Message != nil {
+		run.Message = *options.Message
+	}
+	if options.IsDestroy != nil {
+		run.IsDestroy = *options.IsDestroy
+	}
+	if options.PlanOnly != nil && *options.PlanOnly {
+		run.PlanOnly = true
+	}
+	if options.Refresh != nil {
+		run.Refresh = *options.Refresh
+	}
+	if options.RefreshOnly != nil {
+		run.RefreshOnly = *options.RefreshOnly
+	}
+	if options.AutoApply != nil {
+		run.AutoApply = *options.AutoApply
+	}
+	if options.AllowEmptyApply != nil {
+		run.AllowEmptyApply = *options.AllowEmptyApply
+	}
+	if options.AllowConfigGeneration != nil {
+		run.AllowConfigGeneration = *options.AllowConfigGeneration
+	}
+	if options.SavePlan != nil {
+		run.SavePlan = *options.SavePlan
+	}
+	if options.TerraformVersion != nil {
+		if !run.PlanOnly {
+			return nil, fmt.Errorf("%w: terraform-version can only be set on plan-only runs", ErrInvalidAttribute)
+		}
+		run.TerraformVersion = *options.TerraformVersion
+	}
+	run.TargetAddrs = options.TargetAddrs
+	run.ReplaceAddrs = options.ReplaceAddrs
+	for _, v := range options.Variables {
+		if v != nil {
+			run.Variables = append(run.Variables, models.RunVariable{Key: v.Key, Value: v.Value})
+		}
+	}
+
+	if err := s.createRun(ws, cv, run); err != nil {
+		return nil, err
+	}
+	created, err := s.ReadRun(ctx, run.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, ws.OrganizationID, models.AuditActionCreate, nil, created)
+	return created, nil
+}
+
+func (s *service) ReadRun(ctx context.Context, runID string) (*tfe.Run, error) {
+	run, err := s.findRun(runID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, run.Workspace, wsRead); err != nil {
+		return nil, err
+	}
+	return run.ToTFE(), nil
+}
+
+// ApplyRun confirms a run that is waiting for confirmation and queues its
+// apply, after its pre_apply run tasks if it has any.
+func (s *service) ApplyRun(ctx context.Context, runID string, options tfe.RunApplyOptions) error {
+	run, err := s.findRun(runID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.authorizeWorkspace(ctx, run.Workspace, wsApplyRun); err != nil {
+		return err
+	}
+	if !run.IsConfirmable() && run.Status != string(tfe.RunPlannedAndSaved) {
+		return fmt.Errorf("%w: run is not confirmable", ErrConflict)
+	}
+	before := run.ToTFE()
+	saved := run.Status == string(tfe.RunPlannedAndSaved)
+	if saved {
+		// Saved plans released the workspace when they finished planning.
+		locked, err := s.lockWorkspaceForRun(run.WorkspaceID, run.ID)
+		if err != nil {
+			return err
+		}
+		if !locked {
+			return fmt.Errorf("%w: workspace is locked", ErrConflict)
+		}
+	}
+	if err := s.transitionRun(run, tfe.RunConfirmed, nil); err != nil {
+		if saved {
+			s.db.Model(&models.Workspace{}).Where("id = ? AND locked_by_run_id = ?", run.WorkspaceID, run.ID).Updates(unlockedColumns())
+		}
+		return err
+	}
+	err = s.queueApply(run)
+	s.auditRun(ctx, run, models.AuditActionApply, before)
+	return err
+}
+
+func (s *service) DiscardRun(ctx context.Context, runID string, options tfe.RunDiscardOptions) error {
+	run, err := s.findRun(runID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.authorizeWorkspace(ctx, run.Workspace, wsApplyRun); err != nil {
+		return err
+	}
+	if !run.IsDiscardable() {
+		return fmt.Errorf("%w: run is not discardable", ErrConflict)
+	}
+	before := run.ToTFE()
+	if err := s.transitionRun(run, tfe.RunDiscarded, nil); err != nil {
+		return err
+	}
+	s.auditRun(ctx, run, models.AuditActionDiscard, before)
+	return nil
+}
+
+// CancelRun cancels a queued run immediately. A run that is executing is
+// asked to stop; it can be force-canceled once the cooldown has passed.
+func (s *service) CancelRun(ctx context.Context, runID string, options tfe.RunCancelOptions) error {
+	run, err := s.findRun(runID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.authorizeWorkspace(ctx, run.Workspace, wsQueuePlan); err != nil {
+		return err
+	}
+	if !run.IsCancelable() {
+		return fmt.Errorf("%w: run is not cancelable", ErrConflict)
+	}
+	before := run.ToTFE()
+
+	switch tfe.RunStatus(run.Status) {
+	case tfe.RunPlanning, tfe.RunApplying:
+		if run.ForceCancelAvailableAt != nil {
+			return nil
+		}
+		available := time.Now().Add(constants.ForceCancelCooldown)
+		if err := s.db.Model(run).Update("force_cancel_available_at", available).Error; err != nil {
+			s.logger.Error("failed to request run cancelation", zap.Error(err))
+			return err
+		}
+		s.logger.Debug("run cancelation requested", zap.String("run", run.ID.String()))
+	default:
+		if err := s.transitionRun(run, tfe.RunCanceled, nil); err != nil {
+			return err
+		}
+	}
+	s.auditRun(ctx, run, models.AuditActionCancel, before)
+	return nil
+}
+
+func (s *service) ForceCancelRun(ctx context.Context, runID string, options tfe.RunForceCancelOptions) error {
+	run, err := s.findRun(runID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.authorizeWorkspace(ctx, run.Workspace, wsAdmin); err != nil {
+		return err
+	}
+	if !run.IsForceCancelable() {
+		return fmt.Errorf("%w: run is not force-cancelable", ErrConflict)
+	}
+	before := run.ToTFE()
+	if err := s.transitionRun(run, tfe.RunCanceled, nil, "force-canceled-at"); err != nil {
+		return err
+	}
+	s.auditRun(ctx, run, models.AuditActionForceCancel, before)
+	return nil
+}
+
+// ForceExecuteRun moves a pending run to the front of the workspace queue by
+// discarding or canceling every unfinished run created before it.
+func (s *service) ForceExecuteRun(ctx context.Context, runID string) error {
+	run, err := s.findRun(runID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.authorizeWorkspace(ctx, run.Workspace, wsApplyRun); err != nil {
+		return err
+	}
+	if run.Status != string(tfe.RunPending) || run.PlanOnly {
+		return fmt.Errorf("%w: only pending runs can be force-executed", ErrConflict)
+	}
+	if run.Workspace.Locked && run.Workspace.LockedByRunID == nil {
+		return fmt.Errorf("%w: the workspace is locked by a user", ErrConflict)
+	}
+
+	var ahead []*models.Run
+	if err := s.db.Where("workspace_id = ? AND plan_only = ? AND created_at < ? AND status NOT IN ?",
+		run.WorkspaceID, false, run.CreatedAt, append(finalRunStatusList(), string(tfe.RunPlannedAndSaved))).Find(&ahead).Error; err != nil {
+		s.logger.Error("failed to list runs ahead", zap.Error(err))
+		return err
+	}
+	for _, other := range ahead {
+		to := tfe.RunCanceled
+		if other.IsDiscardable() {
+			to = tfe.RunDiscarded
+		}
+		if err := s.transitionRun(other, to, nil); err != nil {
+			return err
+		}
+	}
+	before := run.ToTFE()
+	if err := s.scheduleRuns(run.WorkspaceID); err != nil {
+		return err
+	}
+	s.auditRun(ctx, run, models.AuditActionForceRun, before)
+	return nil
+}
+
+// auditRun records an action on a run, comparing it with the run as it
+// was before.
+func (s *service) auditRun(ctx context.Context, run *models.Run, action string, before *tfe.Run) {
+	after, err := s.findRun(run.ID.String())
+	if err != nil {
+		return
+	}
+	s.audit(ctx, run.Workspace.OrganizationID, action, before, after.ToTFE())
+}
+
+// createRun stores a new run with its plan and apply and hands it to the scheduler.
+func (s *service) createRun(ws *models.Workspace, cv *models.ConfigurationVersion, run *models.Run) error {
+	if cv.Status != string(tfe.ConfigurationUploaded) {
+		return fmt.Errorf("%w: configuration version is %s, not uploaded", ErrInvalidAttribute, cv.Status)
+	}
+	if ws.ExecutionMode == "local" {
+		return fmt.Errorf("%w: workspace %s uses local execution", ErrConflict, ws.Name)
+	}
+	if run.RefreshOnly && run.IsDestroy {
+		return fmt.Errorf("%w: refresh-only runs cannot destroy", ErrInvalidAttribute)
+	}
+
+	run.Status = string(tfe.RunPending)
+	run.StatusTimestamps = models.StatusTimestamps{}
+	run.WorkspaceID = ws.ID
+	run.ConfigurationVersionID = cv.ID
+	if run.TargetAddrs == nil {
+		run.TargetAddrs = []string{}
+	}
+	if run.ReplaceAddrs == nil {
+		run.ReplaceAddrs = []string{}
+	}
+	if run.Variables == nil {
+		run.Variables = []models.RunVariable{}
+	}
+	run.Plan = &models.Plan{Status: string(tfe.PlanPending), StatusTimestamps: models.StatusTimestamps{}}
+	run.Apply = &models.Apply{Status: string(tfe.ApplyPending), StatusTimestamps: models.StatusTimestamps{}}
+
+	if err := s.db.Omit("Workspace", "ConfigurationVersion", "CreatedBy").Create(run).Error; err != nil {
+		s.logger.Error("failed to create run", zap.Error(err))
+		return err
+	}
+
+	s.logger.Debug("created run", zap.String("workspace", ws.Name), zap.String("run", run.ID.String()), zap.Bool("plan_only", run.PlanOnly))
+	s.notifyRun(run, tfe.RunPending)
+	return s.scheduleRuns(ws.ID)
+}
+
+// queueConfigurationVersionRun starts a run for a configuration version that
+// was created with auto-queue-runs.
+func (s *service) queueConfigurationVersionRun(cv *models.ConfigurationVersion) error {
+	var ws models.Workspace
+	if err := s.db.Where("id = ?", cv.WorkspaceID).First(&ws).Error; err != nil {
+		s.logger.Error("failed to read workspace", zap.Error(err))
+		return err
+	}
+
+	run := &models.Run{
+		Message:          "Triggered via configuration version upload",
+		Source:           string(tfe.RunSourceConfigurationVersion),
+		Refresh:          true,
+		AutoApply:        ws.AutoApply,
+		TerraformVersion: ws.TerraformVersion,
+		PlanOnly:         cv.Speculative,
+		CreatedByID:      cv.CreatedByID,
+	}
+	return s.createRun(&ws, cv, run)
+}
+
+// latestConfigurationVersion returns the newest uploaded configuration
+// version that can be applied to the workspace.
+func (s *service) latestConfigurationVersion(workspaceID uuid.UUID) (*models.ConfigurationVersion, error) {
+	var cv models.ConfigurationVersion
+	if err := s.db.Where("workspace_id = ? AND status = ? AND speculative = ?", workspaceID, tfe.ConfigurationUploaded, false).
+		Order("created_at DESC").First(&cv).Error; err != nil {
+		return nil, err
+	}
+	return &cv, nil
+}
+
+// authorizeRunID requires the caller to hold required on the workspace of a run.
+func (s *service) authorizeRunID(ctx context.Context, runID uuid.UUID, required workspacePermission) error {
+	var run models.Run
+	if err := s.db.Select("id", "workspace_id").Where("id = ?", runID).First(&run).Error; err != nil {
+		s.logger.Error("failed to read run", zap.Error(err))
+		return err
+	}
+	return s.authorizeWorkspaceID(ctx, run.WorkspaceID, required)
+}
+
+func (s *service) findRun(runID string) (*models.Run, error) {
+	id, err := uuid.Parse(runID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var run models.Run
+	if err := s.db.Preload("Workspace").Preload("ConfigurationVersion").Preload("CreatedBy").
+		Preload("Plan").Preload("Apply").Where("id = ?", id).First(&run).Error; err != nil {
+		s.logger.Error("failed to read run", zap.Error(err))
+		return nil, err
+	}
+	return &run, nil
+}
+
+func finalRunStatusList() []string {
+	statuses := make([]string, 0, len(finalRunStatuses))
+	for status := range finalRunStatuses {
+		statuses = append(statuses, string(status))
+	}
+	return statuses
+}
@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// runTransitions lists the statuses a run may move to from each status.
+// Every transition is a conditional UPDATE on the current status, so
+// concurrent actors (users, workers, the scheduler) see exactly one winner.
+var runTransitions = map[tfe.RunStatus][]tfe.RunStatus{
+	tfe.RunPending:         {tfe.RunPlanQueued, tfe.RunPrePlanRunning, tfe.RunDiscarded, tfe.RunCanceled},
+	tfe.RunPlanQueued:      {tfe.RunPlanning, tfe.RunCanceled, tfe.RunErrored},
+	tfe.RunPlanning:        {tfe.RunPlanned, tfe.RunPlannedAndFinished, tfe.RunPlannedAndSaved, tfe.RunPostPlanRunning, tfe.RunCanceled, tfe.RunErrored},
+	tfe.RunPlanned:         {tfe.RunCostEstimated, tfe.RunPolicyChecked, tfe.RunConfirmed, tfe.RunPlannedAndFinished, tfe.RunDiscarded, tfe.RunCanceled, tfe.RunErrored},
+	tfe.RunCostEstimated:   {tfe.RunPolicyChecked, tfe.RunConfirmed, tfe.RunPlannedAndFinished, tfe.RunDiscarded, tfe.RunCanceled, tfe.RunErrored},
+	tfe.RunPolicyChecked:   {tfe.RunConfirmed, tfe.RunPlannedAndFinished, tfe.RunDiscarded, tfe.RunCanceled, tfe.RunErrored},
+	tfe.RunPlannedAndSaved: {tfe.RunConfirmed, tfe.RunDiscarded, tfe.RunCanceled, tfe.RunErrored},
+	tfe.RunConfirmed:       {tfe.RunApplyQueued, tfe.RunPreApplyRunning, tfe.RunCanceled, tfe.RunErrored},
+	tfe.RunApplyQueued:     {tfe.RunApplying, tfe.RunCanceled, tfe.RunErrored},
+	tfe.RunApplying:        {tfe.RunApplied, tfe.RunCanceled, tfe.RunErrored},
+
+	tfe.RunPrePlanRunning:   {tfe.RunPrePlanCompleted, tfe.RunCanceled, tfe.RunErrored},
+	tfe.RunPrePlanCompleted: {tfe.RunPlanQueued, tfe.RunCanceled, tfe.RunErrored},
+
+	tfe.RunPostPlanRunning:          {tfe.RunPostPlanCompleted, tfe.RunPostPlanAwaitingDecision, tfe.RunPlannedAndFinished, tfe.RunPlannedAndSaved, tfe.RunCanceled, tfe.RunErrored},
+	tfe.RunPostPlanAwaitingDecision: {tfe.RunPostPlanCompleted, tfe.RunPlannedAndFinished, tfe.RunPlannedAndSaved, tfe.RunDiscarded, tfe.RunCanceled, tfe.RunErrored},
+	tfe.RunPostPlanCompleted:        {tfe.RunConfirmed, tfe.RunDiscarded, tfe.RunCanceled, tfe.RunErrored},
+
+	tfe.RunPreApplyRunning:   {tfe.RunPreApplyCompleted, tfe.RunCanceled, tfe.RunErrored},
+	tfe.RunPreApplyCompleted: {tfe.RunApplyQueued, tfe.RunCanceled, tfe.RunErrored},
+}
+
+// finalRunStatuses release the workspace and let the next run start.
+var finalRunStatuses = map[tfe.RunStatus]bool{
+	tfe.RunApplied:            true,
+	tfe.RunPlannedAndFinished: true,
+	tfe.RunDiscarded:          true,
+	tfe.RunErrored:            true,
+	tfe.RunCanceled:           true,
+}
+
+// releasesWorkspace reports whether a run in status no longer holds its
+// workspace. Besides the final statuses, saved plans wait for an apply
+// without blocking the runs queued behind them; applying one takes the
+// workspace again.
+func releasesWorkspace(status tfe.RunStatus) bool {
+	return finalRunStatuses[status] || status == tfe.RunPlannedAndSaved
+}
+
+// phaseTransition describes how a run status is reflected on its plan or apply.
+type phaseTransition struct {
+	model  interface{}
+	from   []string
+	status string
+	key    string
+}
+
+// phaseTransitions maps run statuses to the plan and apply updates they imply.
+var phaseTransitions = map[tfe.RunStatus][]phaseTransition{
+	tfe.RunPlanQueued: {
+		{&models.Plan{}, []string{string(tfe.PlanPending)}, string(tfe.PlanQueued), "queued-at"},
+	},
+	tfe.RunPlanning: {
+		{&models.Plan{}, []string{string(tfe.PlanQueued)}, string(tfe.PlanRunning), "started-at"},
+	},
+	tfe.RunPlanned: {
+		{&models.Plan{}, []string{string(tfe.PlanRunning)}, string(tfe.PlanFinished), "finished-at"},
+	},
+	tfe.RunPlannedAndFinished: {
+		{&models.Plan{}, []string{string(tfe.PlanRunning)}, string(tfe.PlanFinished), "finished-at"},
+	},
+	tfe.RunPlannedAndSaved: {
+		{&models.Plan{}, []string{string(tfe.PlanRunning)}, string(tfe.PlanFinished), "finished-at"},
+	},
+	tfe.RunPostPlanRunning: {
+		{&models.Plan{}, []string{string(tfe.PlanRunning)}, string(tfe.PlanFinished), "finished-at"},
+	},
+	tfe.RunApplyQueued: {
+		{&models.Apply{}, []string{string(tfe.ApplyPending)}, string(tfe.ApplyQueued), "queued-at"},
+	},
+	tfe.RunApplying: {
+		{&models.Apply{}, []string{string(tfe.ApplyQueued)}, string(tfe.ApplyRunning), "started-at"},
+	},
+	tfe.RunApplied: {
+		{&models.Apply{}, []string{string(tfe.ApplyRunning)}, string(tfe.ApplyFinished), "finished-at"},
+	},
+	tfe.RunErrored: {
+		{&models.Plan{}, []string{string(tfe.PlanQueued), string(tfe.PlanRunning)}, string(tfe.PlanErrored), "errored-at"},
+		{&models.Apply{}, []string{string(tfe.ApplyQueued), string(tfe.ApplyRunning)}, string(tfe.ApplyErrored), "errored-at"},
+	},
+	tfe.RunCanceled: {
+		{&models.Plan{}, []string{string(tfe.PlanQueued), string(tfe.PlanRunning)}, string(tfe.PlanCanceled), "canceled-at"},
+		{&models.Apply{}, []string{string(tfe.ApplyQueued), string(tfe.ApplyRunning)}, string(tfe.ApplyCanceled), "canceled-at"},
+	},
+}
+
+// runTransitionSources inverts runTransitions: it lists, for each status,
+// the statuses a run may come from.
+var runTransitionSources = func() map[tfe.RunStatus][]string {
+	sources := make(map[tfe.RunStatus][]string)
+	for from, targets := range runTransitions {
+		for _, to := range targets {
+			sources[to] = append(sources[to], string(from))
+		}
+	}
+	return sources
+}()
+
+// statusTimestampKey returns the status-timestamps key for status, for
+// example "plan-queued-at" for plan_queued.
+func statusTimestampKey(status string) string {
+	return strings.ReplaceAll(status, "_", "-") + "-at"
+}
+
+// withTimestamps adds the given status-timestamps keys to updates.
+func withTimestamps(updates map[string]interface{}, now time.Time, keys ...string) map[string]interface{} {
+	expr := "COALESCE(status_timestamps, '{}'::jsonb)"
+	args := make([]interface{}, 0, 2*len(keys))
+	for _, key := range keys {
+		expr += " || jsonb_build_object(?::text, ?::text)"
+		args = append(args, key, now.UTC().Format(time.RFC3339Nano))
+	}
+	updates["status_timestamps"] = gorm.Expr(expr, args...)
+	return updates
+}
+
+// transitionRun moves the run to status and records the transition on the
+// run and its plan or apply, together with any extra timestamp keys, and
+// notifies the workspace's notification configurations. Final statuses and
+// saved plans release the workspace so the next pending run can start.
+func (s *service) transitionRun(run *models.Run, to tfe.RunStatus, updates map[string]interface{}, extraKeys ...string) error {
+	now := time.Now()
+	if updates == nil {
+		updates = map[string]interface{}{}
+	}
+	updates["status"] = string(to)
+	keys := append([]string{statusTimestampKey(string(to))}, extraKeys...)
+	withTimestamps(updates, now, keys...)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Run{}).
+			Where("id = ? AND status IN ?", run.ID, runTransitionSources[to]).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: run cannot move from %s to %s", ErrConflict, run.Status, to)
+		}
+
+		for _, phase := range phaseTransitions[to] {
+			phaseKeys := append([]string{phase.key}, extraKeys...)
+			if err := tx.Model(phase.model).
+				Where("run_id = ? AND status IN ?", run.ID, phase.from).
+				Updates(withTimestamps(map[string]interface{}{"status": phase.status}, now, phaseKeys...)).Error; err != nil {
+				return err
+			}
+		}
+
+		if finalRunStatuses[to] {
+			// Phases that never started will not run anymore.
+			for _, model := range []interface{}{&models.Plan{}, &models.Apply{}} {
+				if err := tx.Model(model).
+					Where("run_id = ? AND status = ?", run.ID, tfe.PlanPending).
+					Update("status", tfe.PlanUnreachable).Error; err != nil {
+					return err
+				}
+			}
+			// Neither will task stages that are still waiting.
+			if err := tx.Model(&models.TaskStage{}).
+				Where("run_id = ? AND status IN ?", run.ID, []string{string(tfe.TaskStageRunning), string(tfe.TaskStageAwaitingOverride)}).
+				Updates(withTimestamps(map[string]interface{}{"status": tfe.TaskStageCanceled}, now, "canceled-at")).Error; err != nil {
+				return err
+			}
+		}
+		if releasesWorkspace(to) {
+			if err := tx.Model(&models.Workspace{}).
+				Where("id = ? AND locked_by_run_id = ?", run.WorkspaceID, run.ID).
+				Updates(unlockedColumns()).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Debug("failed to transition run", zap.String("run", run.ID.String()), zap.String("to", string(to)), zap.Error(err))
+		return err
+	}
+
+	s.logger.Debug("run transitioned",
+		zap.String("run", run.ID.String()),
+		zap.String("from", run.Status),
+		zap.String("to", string(to)),
+	)
+	run.Status = string(to)
+	s.notifyRun(run, to)
+
+	if releasesWorkspace(to) {
+		return s.scheduleRuns(run.WorkspaceID)
+	}
+	return nil
+}
+
+// scheduleRuns queues pending runs of a workspace. Plan-only runs never
+// hold the workspace and are queued right away; other runs are queued one
+// at a time, in creation order, by locking the workspace on their behalf.
+func (s *service) scheduleRuns(workspaceID uuid.UUID) error {
+	var speculative []*models.Run
+	if err := s.db.Where("workspace_id = ? AND status = ? AND plan_only = ?", workspaceID, tfe.RunPending, true).
+		Order("created_at ASC").Find(&speculative).Error; err != nil {
+		s.logger.Error("failed to list pending plan-only runs", zap.Error(err))
+		return err
+	}
+	for _, run := range speculative {
+		if err := s.queuePlan(run); err != nil {
+			s.logger.Warn("failed to queue plan-only run", zap.String("run", run.ID.String()), zap.Error(err))
+		}
+	}
+
+	var next models.Run
+	err := s.db.Where("workspace_id = ? AND status = ? AND plan_only = ?", workspaceID, tfe.RunPending, false).
+		Order("created_at ASC").First(&next).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		s.logger.Error("failed to find next pending run", zap.Error(err))
+		return err
+	}
+
+	locked, err := s.lockWorkspaceForRun(workspaceID, next.ID)
+	if err != nil {
+		return err
+	}
+	if !locked {
+		// The workspace is busy; the run stays pending until it is released.
+		return nil
+	}
+
+	if err := s.queuePlan(&next); err != nil {
+		s.db.Model(&models.Workspace{}).Where("id = ? AND locked_by_run_id = ?", workspaceID, next.ID).Updates(unlockedColumns())
+		return err
+	}
+	return nil
+}
+
+// lockWorkspaceForRun locks an unlocked workspace on behalf of a run. It
+// reports false when the workspace is already locked.
+func (s *service) lockWorkspaceForRun(workspaceID, runID uuid.UUID) (bool, error) {
+	result := s.db.Model(&models.Workspace{}).
+		Where("id = ? AND locked = ?", workspaceID, false).
+		Updates(map[string]interface{}{
+			"locked":             true,
+			"lock_reason":        "",
+			"locked_at":          time.Now(),
+			"locked_by_user_id":  nil,
+			"locked_by_token_id": nil,
+			"locked_by_run_id":   runID,
+		})
+	if result.Error != nil {
+		s.logger.Error("failed to lock workspace for run", zap.Error(result.Error))
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// queuePlan queues the plan of a pending run, passing it through the
+// pre_plan stage first when run tasks run there.
+func (s *service) queuePlan(run *models.Run) error {
+	tasks, err := s.stageRunTasks(run, tfe.PrePlan)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		return s.transitionRun(run, tfe.RunPlanQueued, nil)
+	}
+	if err := s.transitionRun(run, tfe.RunPrePlanRunning, nil); err != nil {
+		return err
+	}
+	return s.startTaskStage(context.Background(), run, tfe.PrePlan, tasks, nil)
+}
+
+// queueApply queues the apply of a confirmed run, passing it through the
+// pre_apply stage first when run tasks run there.
+func (s *service) queueApply(run *models.Run) error {
+	tasks, err := s.stageRunTasks(run, tfe.PreApply)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		return s.transitionRun(run, tfe.RunApplyQueued, nil)
+	}
+	if err := s.transitionRun(run, tfe.RunPreApplyRunning, nil); err != nil {
+		return err
+	}
+	return s.startTaskStage(context.Background(), run, tfe.PreApply, tasks, nil)
+}
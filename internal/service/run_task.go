@@ -0,0 +1,459 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// runTaskCategory is the only category of run tasks.
+const runTaskCategory = "task"
+
+// runTaskStages are the stages run tasks can be attached to. Runs have no
+// stage after their apply, so post_apply is not offered.
+var runTaskStages = map[tfe.Stage]bool{
+	tfe.PrePlan:  true,
+	tfe.PostPlan: true,
+	tfe.PreApply: true,
+}
+
+func (s *service) ListRunTasks(ctx context.Context, organization string, options *tfe.RunTaskListOptions) ([]*tfe.RunTask, *tfe.Pagination, error) {
+	role, err := s.authorizeOrganizationName(ctx, organization, orgRead)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var listOptions tfe.ListOptions
+	if options != nil {
+		listOptions = options.ListOptions
+	}
+	db, pagination, err := paginate(s.db.Model(&models.RunTask{}).Where("organization_id = ?", role.orgID), listOptions)
+	if err != nil {
+		s.logger.Error("failed to count run tasks", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var tasks []*models.RunTask
+	if err := db.Preload("Organization").Preload("WorkspaceRunTasks").Order("name ASC").Find(&tasks).Error; err != nil {
+		s.logger.Error("failed to list run tasks", zap.Error(err))
+		return nil, nil, err
+	}
+
+	tfeTasks := make([]*tfe.RunTask, len(tasks))
+	for i, task := range tasks {
+		tfeTasks[i] = task.ToTFE()
+	}
+	return tfeTasks, pagination, nil
+}
+
+// CreateRunTask creates a run task. Tasks are enabled unless requested
+// otherwise; the HMAC key is stored encrypted and never returned.
+func (s *service) CreateRunTask(ctx context.Context, organization string, options tfe.RunTaskCreateOptions) (*tfe.RunTask, error) {
+	role, err := s.authorizeOrganizationName(ctx, organization, orgManageRunTasks)
+	if err != nil {
+		return nil, err
+	}
+
+	task := &models.RunTask{
+		Name:           options.Name,
+		URL:            options.URL,
+		Category:       options.Category,
+		Enabled:        true,
+		OrganizationID: role.orgID,
+	}
+	if options.Description != nil {
+		task.Description = *options.Description
+	}
+	if options.Enabled != nil {
+		task.Enabled = *options.Enabled
+	}
+	if options.HMACKey != nil {
+		if err := s.setRunTaskHMACKey(task, *options.HMACKey); err != nil {
+			return nil, err
+		}
+	}
+	applyGlobalRunTaskOptions(task, options.Global)
+	if err := s.validateRunTask(task); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Omit(clause.Associations).Create(task).Error; err != nil {
+		s.logger.Error("failed to create run task", zap.Error(err))
+		return nil, err
+	}
+	created, err := s.ReadRunTask(ctx, task.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, role.orgID, models.AuditActionCreate, nil, created)
+	return created, nil
+}
+
+func (s *service) ReadRunTask(ctx context.Context, runTaskID string) (*tfe.RunTask, error) {
+	task, err := s.findAuthorizedRunTask(ctx, runTaskID, orgRead)
+	if err != nil {
+		return nil, err
+	}
+	return task.ToTFE(), nil
+}
+
+// UpdateRunTask updates a run task. An empty HMAC key removes the key.
+func (s *service) UpdateRunTask(ctx context.Context, runTaskID string, options tfe.RunTaskUpdateOptions) (*tfe.RunTask, error) {
+	task, err := s.findAuthorizedRunTask(ctx, runTaskID, orgManageRunTasks)
+	if err != nil {
+		return nil, err
+	}
+	before := task.ToTFE()
+
+	if options.Name != nil {
+		task.Name = *options.Name
+	}
+	if options.URL != nil {
+		task.URL = *options.URL
+	}
+	if options.Description != nil {
+		task.Description = *options.Description
+	}
+	if options.Category != nil {
+		task.Category = *options.Category
+	}
+	if options.Enabled != nil {
+		task.Enabled = *options.Enabled
+	}
+	if options.HMACKey != nil {
+		if err := s.setRunTaskHMACKey(task, *options.HMACKey); err != nil {
+			return nil, err
+		}
+	}
+	applyGlobalRunTaskOptions(task, options.Global)
+	if err := s.validateRunTask(task); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Omit(clause.Associations).Save(task).Error; err != nil {
+		s.logger.Error("failed to update run task", zap.Error(err))
+		return nil, err
+	}
+	updated, err := s.ReadRunTask(ctx, task.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	var secrets []string
+	if options.HMACKey != nil {
+		secrets = append(secrets, "hmac-key")
+	}
+	s.audit(ctx, task.OrganizationID, models.AuditActionUpdate, before, updated, secrets...)
+	return updated, nil
+}
+
+// DeleteRunTask deletes a run task and detaches it from every workspace.
+// Task results already recorded are kept.
+func (s *service) DeleteRunTask(ctx context.Context, runTaskID string) error {
+	task, err := s.findAuthorizedRunTask(ctx, runTaskID, orgManageRunTasks)
+	if err != nil {
+		return err
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("run_task_id = ?", task.ID).Delete(&models.WorkspaceRunTask{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", task.ID).Delete(&models.RunTask{}).Error
+	})
+	if err != nil {
+		s.logger.Error("failed to delete run task", zap.Error(err))
+		return err
+	}
+	s.audit(ctx, task.OrganizationID, models.AuditActionDelete, task.ToTFE(), nil)
+	return nil
+}
+
+func (s *service) ListWorkspaceRunTasks(ctx context.Context, workspaceID string, options *tfe.WorkspaceRunTaskListOptions) ([]*tfe.WorkspaceRunTask, *tfe.Pagination, error) {
+	ws, err := s.findWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, ws, wsRead); err != nil {
+		return nil, nil, err
+	}
+
+	var listOptions tfe.ListOptions
+	if options != nil {
+		listOptions = options.ListOptions
+	}
+	db, pagination, err := paginate(s.db.Model(&models.WorkspaceRunTask{}).Where("workspace_id = ?", ws.ID), listOptions)
+	if err != nil {
+		s.logger.Error("failed to count workspace run tasks", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var tasks []*models.WorkspaceRunTask
+	if err := db.Order("created_at ASC").Find(&tasks).Error; err != nil {
+		s.logger.Error("failed to list workspace run tasks", zap.Error(err))
+		return nil, nil, err
+	}
+
+	tfeTasks := make([]*tfe.WorkspaceRunTask, len(tasks))
+	for i, task := range tasks {
+		tfeTasks[i] = task.ToTFE()
+	}
+	return tfeTasks, pagination, nil
+}
+
+// CreateWorkspaceRunTask attaches a run task of the workspace's
+// organization to the workspace. Tasks run in post_plan unless other
+// stages are requested.
+func (s *service) CreateWorkspaceRunTask(ctx context.Context, workspaceID string, options tfe.WorkspaceRunTaskCreateOptions) (*tfe.WorkspaceRunTask, error) {
+	ws, err := s.findWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, ws, wsManageRunTasks); err != nil {
+		return nil, err
+	}
+	if options.RunTask == nil || options.RunTask.ID == "" {
+		return nil, fmt.Errorf("%w: task is required", ErrInvalidAttribute)
+	}
+	taskID, err := uuid.Parse(options.RunTask.ID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	var task models.RunTask
+	if err := s.db.Where("id = ? AND organization_id = ?", taskID, ws.OrganizationID).First(&task).Error; err != nil {
+		s.logger.Debug("failed to read run task", zap.Error(err))
+		return nil, err
+	}
+
+	wt := &models.WorkspaceRunTask{
+		EnforcementLevel: string(options.EnforcementLevel),
+		Stages:           workspaceRunTaskStages(options.Stage, options.Stages),
+		WorkspaceID:      ws.ID,
+		RunTaskID:        task.ID,
+	}
+	if len(wt.Stages) == 0 {
+		wt.Stages = []string{string(tfe.PostPlan)}
+	}
+	if err := validateRunTaskAttachment(wt.EnforcementLevel, wt.Stages); err != nil {
+		return nil, err
+	}
+
+	var existing models.WorkspaceRunTask
+	err = s.db.Where("workspace_id = ? AND run_task_id = ?", ws.ID, task.ID).First(&existing).Error
+	switch {
+	case err == nil:
+		return nil, fmt.Errorf("%w: task %q is already attached to the workspace", ErrInvalidAttribute, task.Name)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		s.logger.Error("failed to check workspace run task", zap.Error(err))
+		return nil, err
+	}
+
+	if err := s.db.Omit(clause.Associations).Create(wt).Error; err != nil {
+		s.logger.Error("failed to create workspace run task", zap.Error(err))
+		return nil, err
+	}
+	created := wt.ToTFE()
+	s.audit(ctx, ws.OrganizationID, models.AuditActionCreate, nil, created)
+	return created, nil
+}
+
+func (s *service) ReadWorkspaceRunTask(ctx context.Context, workspaceID, workspaceTaskID string) (*tfe.WorkspaceRunTask, error) {
+	wt, err := s.findAuthorizedWorkspaceRunTask(ctx, workspaceID, workspaceTaskID, wsRead)
+	if err != nil {
+		return nil, err
+	}
+	return wt.ToTFE(), nil
+}
+
+func (s *service) UpdateWorkspaceRunTask(ctx context.Context, workspaceID, workspaceTaskID string, options tfe.WorkspaceRunTaskUpdateOptions) (*tfe.WorkspaceRunTask, error) {
+	wt, err := s.findAuthorizedWorkspaceRunTask(ctx, workspaceID, workspaceTaskID, wsManageRunTasks)
+	if err != nil {
+		return nil, err
+	}
+	before := wt.ToTFE()
+
+	if options.EnforcementLevel != "" {
+		wt.EnforcementLevel = string(options.EnforcementLevel)
+	}
+	if stages := workspaceRunTaskStages(options.Stage, options.Stages); len(stages) > 0 {
+		wt.Stages = stages
+	}
+	if err := validateRunTaskAttachment(wt.EnforcementLevel, wt.Stages); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Omit(clause.Associations).Save(wt).Error; err != nil {
+		s.logger.Error("failed to update workspace run task", zap.Error(err))
+		return nil, err
+	}
+	updated := wt.ToTFE()
+	s.auditWorkspace(ctx, wt.WorkspaceID, models.AuditActionUpdate, before, updated)
+	return updated, nil
+}
+
+func (s *service) DeleteWorkspaceRunTask(ctx context.Context, workspaceID, workspaceTaskID string) error {
+	wt, err := s.findAuthorizedWorkspaceRunTask(ctx, workspaceID, workspaceTaskID, wsManageRunTasks)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Where("id = ?", wt.ID).Delete(&models.WorkspaceRunTask{}).Error; err != nil {
+		s.logger.Error("failed to delete workspace run task", zap.Error(err))
+		return err
+	}
+	s.auditWorkspace(ctx, wt.WorkspaceID, models.AuditActionDelete, wt.ToTFE(), nil)
+	return nil
+}
+
+// setRunTaskHMACKey encrypts key into the task, or removes the task's key
+// when key is empty.
+func (s *service) setRunTaskHMACKey(task *models.RunTask, key string) error {
+	if key == "" {
+		task.EncryptedHMACKey = ""
+		return nil
+	}
+	encrypted, err := s.secrets.Encrypt(key)
+	if err != nil {
+		s.logger.Error("failed to encrypt run task HMAC key", zap.Error(err))
+		return err
+	}
+	task.EncryptedHMACKey = encrypted
+	return nil
+}
+
+// applyGlobalRunTaskOptions applies the settings of a task that runs for
+// every workspace of its organization.
+func applyGlobalRunTaskOptions(task *models.RunTask, options *tfe.GlobalRunTaskOptions) {
+	if options == nil {
+		return
+	}
+	if options.Enabled != nil {
+		task.GlobalEnabled = *options.Enabled
+	}
+	if options.Stages != nil {
+		task.GlobalStages = workspaceRunTaskStages(nil, options.Stages)
+	}
+	if options.EnforcementLevel != nil {
+		task.GlobalEnforcementLevel = string(*options.EnforcementLevel)
+	}
+}
+
+func (s *service) validateRunTask(task *models.RunTask) error {
+	if task.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidAttribute)
+	}
+	if task.Category != runTaskCategory {
+		return fmt.Errorf("%w: category must be %q", ErrInvalidAttribute, runTaskCategory)
+	}
+	u, err := url.Parse(task.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidAttribute)
+	}
+	if task.GlobalEnabled {
+		if len(task.GlobalStages) == 0 {
+			task.GlobalStages = []string{string(tfe.PostPlan)}
+		}
+		if task.GlobalEnforcementLevel == "" {
+			task.GlobalEnforcementLevel = string(tfe.Advisory)
+		}
+		if err := validateRunTaskAttachment(task.GlobalEnforcementLevel, task.GlobalStages); err != nil {
+			return err
+		}
+	}
+
+	var existing models.RunTask
+	err = s.db.Where("organization_id = ? AND name = ? AND id <> ?", task.OrganizationID, task.Name, task.ID).First(&existing).Error
+	switch {
+	case err == nil:
+		return fmt.Errorf("%w: name %q has already been taken", ErrInvalidAttribute, task.Name)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		s.logger.Error("failed to check run task name", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// validateRunTaskAttachment checks the enforcement level and stages a task
+// runs with.
+func validateRunTaskAttachment(enforcementLevel string, stages []string) error {
+	switch tfe.TaskEnforcementLevel(enforcementLevel) {
+	case tfe.Advisory, tfe.Mandatory:
+	default:
+		return fmt.Errorf("%w: enforcement level must be %s or %s", ErrInvalidAttribute, tfe.Advisory, tfe.Mandatory)
+	}
+	for _, stage := range stages {
+		if !runTaskStages[tfe.Stage(stage)] {
+			return fmt.Errorf("%w: run tasks cannot run in stage %q", ErrInvalidAttribute, stage)
+		}
+	}
+	return nil
+}
+
+// workspaceRunTaskStages merges the deprecated single stage with the list
+// of stages, dropping duplicates.
+func workspaceRunTaskStages(stage *tfe.Stage, stages *[]tfe.Stage) []string {
+	var all []tfe.Stage
+	if stages != nil {
+		all = append(all, *stages...)
+	}
+	if stage != nil && len(all) == 0 {
+		all = append(all, *stage)
+	}
+
+	seen := make(map[tfe.Stage]bool, len(all))
+	var merged []string
+	for _, s := range all {
+		if !seen[s] {
+			seen[s] = true
+			merged = append(merged, string(s))
+		}
+	}
+	return merged
+}
+
+// findAuthorizedRunTask finds a run task and requires the caller to hold
+// required in its organization.
+func (s *service) findAuthorizedRunTask(ctx context.Context, runTaskID string, required organizationPermission) (*models.RunTask, error) {
+	id, err := uuid.Parse(runTaskID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var task models.RunTask
+	if err := s.db.Preload("Organization").Preload("WorkspaceRunTasks").Where("id = ?", id).First(&task).Error; err != nil {
+		s.logger.Error("failed to read run task", zap.Error(err))
+		return nil, err
+	}
+	if _, err := s.authorizeOrganization(ctx, task.OrganizationID, required); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// findAuthorizedWorkspaceRunTask finds a run task attached to a workspace
+// and requires the caller to hold required on the workspace.
+func (s *service) findAuthorizedWorkspaceRunTask(ctx context.Context, workspaceID, workspaceTaskID string, required workspacePermission) (*models.WorkspaceRunTask, error) {
+	ws, err := s.findWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, ws, required); err != nil {
+		return nil, err
+	}
+	id, err := uuid.Parse(workspaceTaskID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var wt models.WorkspaceRunTask
+	if err := s.db.Where("id = ? AND workspace_id = ?", id, ws.ID).First(&wt).Error; err != nil {
+		s.logger.Error("failed to read workspace run task", zap.Error(err))
+		return nil, err
+	}
+	return &wt, nil
+}
@@ -0,0 +1,566 @@
+package service
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"github.com/open-tfe/tfe-service/internal/storage"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// stateFile is the subset of the Terraform state format read when a state
+// version is finalized.
+type stateFile struct {
+	Version          int    `json:"version"`
+	TerraformVersion string `json:"terraform_version"`
+	Serial           int64  `json:"serial"`
+	Lineage          string `json:"lineage"`
+	Outputs          map[string]struct {
+		Value     json.RawMessage `json:"value"`
+		Type      json.RawMessage `json:"type"`
+		Sensitive bool            `json:"sensitive"`
+	} `json:"outputs"`
+	Resources []struct {
+		Module    string     `json:"module"`
+		Mode      string     `json:"mode"`
+		Type      string     `json:"type"`
+		Name      string     `json:"name"`
+		Provider  string     `json:"provider"`
+		Instances []struct{} `json:"instances"`
+	} `json:"resources"`
+}
+
+func (s *service) ListStateVersions(ctx context.Context, options *tfe.StateVersionListOptions) ([]*tfe.StateVersion, *tfe.Pagination, error) {
+	if options == nil || options.Organization == "" || options.Workspace == "" {
+		return nil, nil, fmt.Errorf("%w: filter[organization][name] and filter[workspace][name] are required", ErrInvalidAttribute)
+	}
+
+	ws, err := s.findWorkspace(ctx, options.Organization, options.Workspace)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, ws, wsReadState); err != nil {
+		return nil, nil, err
+	}
+
+	db, pagination, err := paginate(s.db.Model(&models.StateVersion{}).Where("workspace_id = ?", ws.ID), options.ListOptions)
+	if err != nil {
+		s.logger.Error("failed to count state versions", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var stateVersions []*models.StateVersion
+	if err := db.Preload("Outputs").Order("created_at DESC").Find(&stateVersions).Error; err != nil {
+		s.logger.Error("failed to list state versions", zap.Error(err))
+		return nil, nil, err
+	}
+
+	tfeStateVersions := make([]*tfe.StateVersion, len(stateVersions))
+	for i, sv := range stateVersions {
+		tfeStateVersions[i] = sv.ToTFE()
+	}
+	return tfeStateVersions, pagination, nil
+}
+
+func (s *service) CreateStateVersion(ctx context.Context, workspaceID string, options tfe.StateVersionCreateOptions) (*tfe.StateVersion, error) {
+	if options.Serial == nil || options.MD5 == nil {
+		return nil, fmt.Errorf("%w: serial and md5 are required", ErrInvalidAttribute)
+	}
+
+	holder, err := s.currentLockHolder(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ws, err := s.findWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, ws, wsWriteState); err != nil {
+		return nil, err
+	}
+	if !holder.holds(ws) {
+		return nil, fmt.Errorf("%w: the workspace must be locked by the current user or token before creating a state version", ErrConflict)
+	}
+
+	sv := &models.StateVersion{
+		Status:           string(tfe.StateVersionPending),
+		Serial:           *options.Serial,
+		MD5:              *options.MD5,
+		WorkspaceID:      ws.ID,
+		CreatedByID:      holder.userID,
+		CreatedByTokenID: holder.tokenID,
+	}
+	if options.Lineage != nil {
+		sv.Lineage = *options.Lineage
+	}
+	if options.Run != nil && options.Run.ID != "" {
+		runID, err := uuid.Parse(options.Run.ID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid run ID", ErrInvalidAttribute)
+		}
+		sv.RunID = &runID
+	}
+
+	force := options.Force != nil && *options.Force
+	if !force {
+		if err := s.checkStateVersionSuccession(ws.ID, sv); err != nil {
+			return nil, err
+		}
+	}
+
+	// Inline content is stored before the row exists, so the ID is assigned up front.
+	sv.ID = uuid.New()
+	if options.State != nil {
+		raw := base64.NewDecoder(base64.StdEncoding, strings.NewReader(*options.State))
+		if err := s.storeState(ctx, sv, raw); err != nil {
+			return nil, err
+		}
+	}
+	if options.JSONState != nil {
+		raw := base64.NewDecoder(base64.StdEncoding, strings.NewReader(*options.JSONState))
+		if err := s.storeJSONState(ctx, sv, raw); err != nil {
+			s.deleteObjects(ctx, stateKey(sv.ID))
+			return nil, err
+		}
+	}
+
+	if err := s.db.Create(sv).Error; err != nil {
+		s.logger.Error("failed to create state version", zap.Error(err))
+		s.deleteObjects(ctx, stateKey(sv.ID), jsonStateKey(sv.ID))
+		return nil, err
+	}
+
+	s.logger.Debug("created state version",
+		zap.String("workspace", ws.Name),
+		zap.Int64("serial", sv.Serial),
+		zap.String("status", sv.Status),
+	)
+	created, err := s.ReadStateVersion(ctx, sv.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, ws.OrganizationID, models.AuditActionCreate, nil, created)
+	return created, nil
+}
+
+func (s *service) ReadStateVersion(ctx context.Context, svID string) (*tfe.StateVersion, error) {
+	sv, err := s.findStateVersion(svID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeWorkspaceID(ctx, sv.WorkspaceID, wsReadState); err != nil {
+		return nil, err
+	}
+	return sv.ToTFE(), nil
+}
+
+func (s *service) ReadCurrentStateVersion(ctx context.Context, workspaceID string) (*tfe.StateVersion, error) {
+	ws, err := s.findWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, ws, wsReadState); err != nil {
+		return nil, err
+	}
+
+	sv, err := s.currentStateVersion(ws.ID)
+	if err != nil {
+		return nil, err
+	}
+	return sv.ToTFE(), nil
+}
+
+func (s *service) UploadStateVersionContent(ctx context.Context, svID string, r io.Reader) error {
+	sv, err := s.findStateVersion(svID)
+	if err != nil {
+		return err
+	}
+	if err := s.authorizeWorkspaceID(ctx, sv.WorkspaceID, wsWriteState); err != nil {
+		return err
+	}
+	if sv.Status != string(tfe.StateVersionPending) {
+		return fmt.Errorf("%w: state version has already been uploaded", ErrConflict)
+	}
+	before := sv.ToTFE()
+	if err := s.storeState(ctx, sv, r); err != nil {
+		return err
+	}
+	resources, err := json.Marshal(sv.Resources)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.StateVersion{}).
+			Where("id = ? AND status = ?", sv.ID, tfe.StateVersionPending).
+			Updates(map[string]interface{}{
+				"status":              sv.Status,
+				"state_version":       sv.StateVersion,
+				"terraform_version":   sv.TerraformVersion,
+				"resources":           string(resources),
+				"resources_processed": sv.ResourcesProcessed,
+			})
+		if result.Error != nil {
+			s.logger.Error("failed to store state", zap.Error(result.Error))
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: state version has already been uploaded", ErrConflict)
+		}
+
+		for _, output := range sv.Outputs {
+			output.StateVersionID = sv.ID
+		}
+		if len(sv.Outputs) > 0 {
+			if err := tx.Create(sv.Outputs).Error; err != nil {
+				s.logger.Error("failed to store state outputs", zap.Error(err))
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.auditStateVersionUpload(ctx, sv.ID, before)
+	return nil
+}
+
+func (s *service) UploadStateVersionJSONContent(ctx context.Context, svID string, r io.Reader) error {
+	sv, err := s.findStateVersion(svID)
+	if err != nil {
+		return err
+	}
+	if err := s.authorizeWorkspaceID(ctx, sv.WorkspaceID, wsWriteState); err != nil {
+		return err
+	}
+	// go-tfe uploads both representations concurrently, so the state upload
+	// may finalize the version first. Once finalized, only the first JSON
+	// upload is accepted.
+	if sv.Status != string(tfe.StateVersionPending) {
+		_, err := s.store.Stat(ctx, jsonStateKey(sv.ID))
+		if err == nil {
+			return fmt.Errorf("%w: state version has already been uploaded", ErrConflict)
+		}
+		if !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+	}
+	before := sv.ToTFE()
+	if err := s.storeJSONState(ctx, sv, r); err != nil {
+		return err
+	}
+	s.auditStateVersionUpload(ctx, sv.ID, before)
+	return nil
+}
+
+// auditStateVersionUpload records the upload of state version content.
+func (s *service) auditStateVersionUpload(ctx context.Context, svID uuid.UUID, before *tfe.StateVersion) {
+	sv, err := s.findStateVersion(svID.String())
+	if err != nil {
+		return
+	}
+	s.auditWorkspace(ctx, sv.WorkspaceID, models.AuditActionUpload, before, sv.ToTFE())
+}
+
+func (s *service) DownloadStateVersionContent(ctx context.Context, svID string) (io.ReadCloser, error) {
+	sv, err := s.findStateVersion(svID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeWorkspaceID(ctx, sv.WorkspaceID, wsReadState); err != nil {
+		return nil, err
+	}
+	if sv.Status != string(tfe.StateVersionFinalized) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return s.openObject(ctx, stateKey(sv.ID))
+}
+
+func (s *service) DownloadStateVersionJSONContent(ctx context.Context, svID string) (io.ReadCloser, error) {
+	sv, err := s.findStateVersion(svID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeWorkspaceID(ctx, sv.WorkspaceID, wsReadState); err != nil {
+		return nil, err
+	}
+	return s.openObject(ctx, jsonStateKey(sv.ID))
+}
+
+func (s *service) ListStateVersionOutputs(ctx context.Context, svID string) ([]*tfe.StateVersionOutput, error) {
+	sv, err := s.findStateVersion(svID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeWorkspaceID(ctx, sv.WorkspaceID, wsReadStateOutputs); err != nil {
+		return nil, err
+	}
+
+	outputs := make([]*tfe.StateVersionOutput, len(sv.Outputs))
+	for i, output := range sv.Outputs {
+		outputs[i] = output.ToTFE(false)
+	}
+	return outputs, nil
+}
+
+func (s *service) ReadCurrentStateVersionOutputs(ctx context.Context, workspaceID string) ([]*tfe.StateVersionOutput, error) {
+	ws, err := s.findWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, ws, wsReadStateOutputs); err != nil {
+		return nil, err
+	}
+	sv, err := s.currentStateVersion(ws.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.ListStateVersionOutputs(ctx, sv.ID.String())
+}
+
+func (s *service) ReadStateVersionOutput(ctx context.Context, outputID string) (*tfe.StateVersionOutput, error) {
+	id, err := uuid.Parse(outputID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var output models.StateVersionOutput
+	if err := s.db.Where("id = ?", id).First(&output).Error; err != nil {
+		s.logger.Error("failed to read state version output", zap.Error(err))
+		return nil, err
+	}
+	var sv models.StateVersion
+	if err := s.db.Where("id = ?", output.StateVersionID).First(&sv).Error; err != nil {
+		s.logger.Error("failed to read state version", zap.Error(err))
+		return nil, err
+	}
+	if err := s.authorizeWorkspaceID(ctx, sv.WorkspaceID, wsReadStateOutputs); err != nil {
+		return nil, err
+	}
+	return output.ToTFE(true), nil
+}
+
+// checkStateVersionSuccession rejects state that would roll the workspace
+// back or replace it with an unrelated lineage.
+func (s *service) checkStateVersionSuccession(workspaceID uuid.UUID, next *models.StateVersion) error {
+	current, err := s.currentStateVersion(workspaceID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case next.Lineage != "" && current.Lineage != "" && next.Lineage != current.Lineage:
+		return fmt.Errorf("%w: lineage %q does not match the current state lineage %q", ErrConflict, next.Lineage, current.Lineage)
+	case next.Serial < current.Serial:
+		return fmt.Errorf("%w: serial %d is lower than the current state serial %d", ErrConflict, next.Serial, current.Serial)
+	case next.Serial == current.Serial && next.MD5 != current.MD5:
+		return fmt.Errorf("%w: a different state with serial %d already exists", ErrConflict, next.Serial)
+	}
+	return nil
+}
+
+func (s *service) currentStateVersion(workspaceID uuid.UUID) (*models.StateVersion, error) {
+	var sv models.StateVersion
+	if err := s.db.Preload("Outputs").
+		Where("workspace_id = ? AND status = ?", workspaceID, tfe.StateVersionFinalized).
+		Order("serial DESC, created_at DESC").First(&sv).Error; err != nil {
+		return nil, err
+	}
+	return &sv, nil
+}
+
+func (s *service) findStateVersion(svID string) (*models.StateVersion, error) {
+	id, err := uuid.Parse(svID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var sv models.StateVersion
+	if err := s.db.Preload("Outputs").Where("id = ?", id).First(&sv).Error; err != nil {
+		s.logger.Error("failed to read state version", zap.Error(err))
+		return nil, err
+	}
+	return &sv, nil
+}
+
+func stateKey(svID uuid.UUID) string {
+	return "state-versions/" + svID.String() + "/state"
+}
+
+func jsonStateKey(svID uuid.UUID) string {
+	return "state-versions/" + svID.String() + "/json-state"
+}
+
+// storeState streams r into the blob store and then finalizes the state
+// version from the stored copy. The object is removed again when the upload
+// fails validation, so a pending state version can be retried.
+func (s *service) storeState(ctx context.Context, sv *models.StateVersion, r io.Reader) error {
+	key := stateKey(sv.ID)
+	hash := md5.New()
+	if err := s.store.Put(ctx, key, io.TeeReader(r, hash), -1); err != nil {
+		s.logger.Error("failed to store state", zap.Error(err))
+		if isBase64Error(err) {
+			return fmt.Errorf("%w: state must be base64 encoded", ErrInvalidAttribute)
+		}
+		return err
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != sv.MD5 {
+		s.deleteObjects(ctx, key)
+		return fmt.Errorf("%w: state content does not match md5 %s", ErrInvalidAttribute, sv.MD5)
+	}
+
+	rc, err := s.store.Get(ctx, key)
+	if err != nil {
+		s.logger.Error("failed to read stored state", zap.Error(err))
+		return err
+	}
+	defer rc.Close()
+
+	var state stateFile
+	if err := json.NewDecoder(rc).Decode(&state); err != nil {
+		s.deleteObjects(ctx, key)
+		return fmt.Errorf("%w: state is not valid JSON: %v", ErrInvalidAttribute, err)
+	}
+	if err := finalizeStateVersion(sv, &state); err != nil {
+		s.deleteObjects(ctx, key)
+		return err
+	}
+	return nil
+}
+
+// storeJSONState streams r into the blob store and checks that it is a
+// single well-formed JSON document.
+func (s *service) storeJSONState(ctx context.Context, sv *models.StateVersion, r io.Reader) error {
+	key := jsonStateKey(sv.ID)
+	if err := s.store.Put(ctx, key, r, -1); err != nil {
+		s.logger.Error("failed to store JSON state", zap.Error(err))
+		if isBase64Error(err) {
+			return fmt.Errorf("%w: json-state must be base64 encoded", ErrInvalidAttribute)
+		}
+		return err
+	}
+
+	rc, err := s.store.Get(ctx, key)
+	if err != nil {
+		s.logger.Error("failed to read stored JSON state", zap.Error(err))
+		return err
+	}
+	defer rc.Close()
+
+	if err := json.NewDecoder(rc).Decode(&struct{}{}); err != nil {
+		s.deleteObjects(ctx, key)
+		return fmt.Errorf("%w: JSON state is not valid JSON", ErrInvalidAttribute)
+	}
+	return nil
+}
+
+// openObject opens a stored object, reporting missing objects as not found.
+func (s *service) openObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	rc, err := s.store.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		s.logger.Error("failed to open object", zap.String("key", key), zap.Error(err))
+		return nil, err
+	}
+	return rc, nil
+}
+
+// deleteObjects removes objects on a best-effort basis; failures only leave
+// unreferenced blobs behind.
+func (s *service) deleteObjects(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := s.store.Delete(ctx, key); err != nil {
+			s.logger.Warn("failed to delete object", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
+
+func isBase64Error(err error) bool {
+	var corrupt base64.CorruptInputError
+	return errors.As(err, &corrupt)
+}
+
+// finalizeStateVersion validates the parsed state against the declared
+// serial and lineage, then records its outputs and resources.
+func finalizeStateVersion(sv *models.StateVersion, state *stateFile) error {
+	if state.Serial != sv.Serial {
+		return fmt.Errorf("%w: state serial %d does not match %d", ErrInvalidAttribute, state.Serial, sv.Serial)
+	}
+	if sv.Lineage != "" && state.Lineage != sv.Lineage {
+		return fmt.Errorf("%w: state lineage %q does not match %q", ErrInvalidAttribute, state.Lineage, sv.Lineage)
+	}
+
+	sv.Lineage = state.Lineage
+	sv.StateVersion = state.Version
+	sv.TerraformVersion = state.TerraformVersion
+	sv.Status = string(tfe.StateVersionFinalized)
+	sv.ResourcesProcessed = true
+
+	sv.Resources = make([]models.Resource, 0, len(state.Resources))
+	for _, r := range state.Resources {
+		if r.Mode != "managed" {
+			continue
+		}
+		sv.Resources = append(sv.Resources, models.Resource{
+			Name:     r.Name,
+			Count:    len(r.Instances),
+			Type:     r.Type,
+			Module:   r.Module,
+			Provider: r.Provider,
+		})
+	}
+
+	sv.Outputs = make([]*models.StateVersionOutput, 0, len(state.Outputs))
+	for name, output := range state.Outputs {
+		sv.Outputs = append(sv.Outputs, &models.StateVersionOutput{
+			ID:           uuid.New(),
+			Name:         name,
+			Sensitive:    output.Sensitive,
+			Type:         outputKind(output.Type),
+			Value:        output.Value,
+			DetailedType: output.Type,
+		})
+	}
+	return nil
+}
+
+// outputKind maps a Terraform type constraint to the coarse output type
+// reported by Terraform Enterprise.
+func outputKind(typ json.RawMessage) string {
+	var primitive string
+	if err := json.Unmarshal(typ, &primitive); err == nil {
+		return primitive
+	}
+
+	var complex []json.RawMessage
+	if err := json.Unmarshal(typ, &complex); err != nil || len(complex) == 0 {
+		return ""
+	}
+	var kind string
+	json.Unmarshal(complex[0], &kind)
+	switch kind {
+	case "list", "set", "tuple":
+		return "array"
+	case "map", "object":
+		return "object"
+	default:
+		return kind
+	}
+}
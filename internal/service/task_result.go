@@ -0,0 +1,431 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"github.com/open-tfe/tfe-service/internal/runtask"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// taskResultTimeout is how long a task has to report its outcome before
+// its result errors.
+const taskResultTimeout = 10 * time.Minute
+
+// runTaskPayloadVersion is the version of the run task request payload.
+const runTaskPayloadVersion = 1
+
+// stageTask is a run task that runs in a task stage, either because it is
+// attached to the workspace or because it runs globally.
+type stageTask struct {
+	task             *models.RunTask
+	workspaceTaskID  *uuid.UUID
+	enforcementLevel string
+}
+
+func (s *service) ReadTaskResult(ctx context.Context, taskResultID string) (*tfe.TaskResult, error) {
+	id, err := uuid.Parse(taskResultID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	var result models.TaskResult
+	if err := s.db.Where("id = ?", id).First(&result).Error; err != nil {
+		s.logger.Error("failed to read task result", zap.Error(err))
+		return nil, err
+	}
+	if _, _, err := s.findAuthorizedTaskStage(ctx, result.TaskStageID.String()); err != nil {
+		return nil, err
+	}
+	return result.ToTFE(), nil
+}
+
+// CallbackTaskResult records the outcome a task reports for a task result.
+// The task authenticates with the access token it was sent, which expires
+// once the task reports a final outcome or runs out of time.
+func (s *service) CallbackTaskResult(ctx context.Context, taskResultID, token string, options tfe.TaskResultCallbackRequestOptions) error {
+	id, err := uuid.Parse(taskResultID)
+	if err != nil {
+		return gorm.ErrRecordNotFound
+	}
+	var result models.TaskResult
+	if err := s.db.Where("id = ?", id).First(&result).Error; err != nil {
+		s.logger.Debug("failed to read task result", zap.Error(err))
+		return err
+	}
+	if result.AccessTokenHash == "" || time.Now().After(result.AccessTokenExpiresAt) ||
+		subtle.ConstantTimeCompare([]byte(hashSecret(token)), []byte(result.AccessTokenHash)) != 1 {
+		return ErrInvalidToken
+	}
+
+	switch options.Status {
+	case tfe.TaskPassed, tfe.TaskFailed, tfe.TaskRunning:
+	default:
+		return fmt.Errorf("%w: status must be %s, %s or %s", ErrInvalidAttribute, tfe.TaskPassed, tfe.TaskFailed, tfe.TaskRunning)
+	}
+
+	updates := map[string]interface{}{
+		"status":  string(options.Status),
+		"message": options.Message,
+		"url":     options.URL,
+	}
+	final := options.Status != tfe.TaskRunning
+	if final {
+		updates["access_token_hash"] = ""
+	}
+	updated, err := s.updateTaskResult(result.ID, pendingTaskResultStatuses(), updates)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return fmt.Errorf("%w: task result is already %s", ErrConflict, result.Status)
+	}
+	s.logger.Debug("task result reported",
+		zap.String("task_result", taskResultID),
+		zap.String("status", string(options.Status)),
+	)
+
+	if final {
+		return s.completeTaskStage(result.TaskStageID)
+	}
+	return nil
+}
+
+// ExpireTaskResults errors the results of tasks that did not report their
+// outcome in time and lets their task stages complete.
+func (s *service) ExpireTaskResults(ctx context.Context) error {
+	if !isSystemContext(ctx) {
+		return fmt.Errorf("%w: task results are expired by the system", ErrForbidden)
+	}
+
+	var results []*models.TaskResult
+	if err := s.db.Where("status IN ? AND access_token_expires_at < ?", pendingTaskResultStatuses(), time.Now()).
+		Find(&results).Error; err != nil {
+		s.logger.Error("failed to list expired task results", zap.Error(err))
+		return err
+	}
+
+	stages := make(map[uuid.UUID]bool)
+	for _, result := range results {
+		updated, err := s.updateTaskResult(result.ID, pendingTaskResultStatuses(), map[string]interface{}{
+			"status":            string(tfe.TaskErrored),
+			"message":           "Task did not report a result in time",
+			"access_token_hash": "",
+		})
+		if err != nil {
+			return err
+		}
+		if updated {
+			s.logger.Info("task result expired", zap.String("task_result", result.ID.String()))
+			stages[result.TaskStageID] = true
+		}
+	}
+	for stageID := range stages {
+		if err := s.completeTaskStage(stageID); err != nil {
+			s.logger.Warn("failed to complete task stage", zap.String("task_stage", stageID.String()), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// stageRunTasks lists the enabled run tasks that run in stage for a run:
+// the tasks attached to its workspace at that stage, and the global tasks
+// of the organization that are not attached to it.
+func (s *service) stageRunTasks(run *models.Run, stage tfe.Stage) ([]*stageTask, error) {
+	var attached []*models.WorkspaceRunTask
+	if err := s.db.Preload("RunTask").Where("workspace_id = ?", run.WorkspaceID).
+		Order("created_at ASC").Find(&attached).Error; err != nil {
+		s.logger.Error("failed to list workspace run tasks", zap.Error(err))
+		return nil, err
+	}
+
+	var tasks []*stageTask
+	attachedIDs := []uuid.UUID{uuid.Nil}
+	for _, wt := range attached {
+		attachedIDs = append(attachedIDs, wt.RunTaskID)
+		if wt.RunTask == nil || !wt.RunTask.Enabled || !wt.HasStage(string(stage)) {
+			continue
+		}
+		id := wt.ID
+		tasks = append(tasks, &stageTask{task: wt.RunTask, workspaceTaskID: &id, enforcementLevel: wt.EnforcementLevel})
+	}
+
+	var global []*models.RunTask
+	if err := s.db.Where("organization_id = (?) AND enabled AND global_enabled AND id NOT IN ?",
+		s.db.Model(&models.Workspace{}).Select("organization_id").Where("id = ?", run.WorkspaceID), attachedIDs).
+		Order("name ASC").Find(&global).Error; err != nil {
+		s.logger.Error("failed to list global run tasks", zap.Error(err))
+		return nil, err
+	}
+	for _, task := range global {
+		if task.HasGlobalStage(string(stage)) {
+			tasks = append(tasks, &stageTask{task: task, enforcementLevel: task.GlobalEnforcementLevel})
+		}
+	}
+	return tasks, nil
+}
+
+// startTaskStage runs the tasks and policy sets of a stage of a run that
+// has just entered the stage. Policies are evaluated right away; tasks are
+// sent their requests in the background, and the stage completes once
+// every task has reported back.
+func (s *service) startTaskStage(ctx context.Context, run *models.Run, stage tfe.Stage, tasks []*stageTask, sets []*models.PolicySet) error {
+	payload, err := s.runTaskRequest(run, stage)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	taskStage := &models.TaskStage{
+		Stage:            string(stage),
+		Status:           string(tfe.TaskStageRunning),
+		StatusTimestamps: models.StatusTimestamps{"running-at": now},
+		RunID:            run.ID,
+	}
+	results := make([]*models.TaskResult, len(tasks))
+	tokens := make([]string, len(tasks))
+	for i, t := range tasks {
+		token, err := generateSecret()
+		if err != nil {
+			return err
+		}
+		tokens[i] = token
+		taskID := t.task.ID
+		results[i] = &models.TaskResult{
+			Status:               string(tfe.TaskPending),
+			StatusTimestamps:     models.StatusTimestamps{},
+			TaskName:             t.task.Name,
+			TaskURL:              t.task.URL,
+			EnforcementLevel:     t.enforcementLevel,
+			AccessTokenHash:      hashSecret(token),
+			AccessTokenExpiresAt: now.Add(taskResultTimeout),
+			RunTaskID:            &taskID,
+			WorkspaceRunTaskID:   t.workspaceTaskID,
+		}
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(taskStage).Error; err != nil {
+			return err
+		}
+		for _, result := range results {
+			result.TaskStageID = taskStage.ID
+			if err := tx.Create(result).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to create task stage", zap.Error(err))
+		if terr := s.transitionRun(run, tfe.RunErrored, nil); terr != nil {
+			s.logger.Warn("failed to error run", zap.String("run", run.ID.String()), zap.Error(terr))
+		}
+		return err
+	}
+
+	if len(sets) > 0 {
+		if err := s.checkPolicies(ctx, run, taskStage, sets); err != nil {
+			return err
+		}
+	}
+	if len(tasks) == 0 {
+		return s.completeTaskStage(taskStage.ID)
+	}
+	for i, t := range tasks {
+		request := *payload
+		request.AccessToken = tokens[i]
+		request.TaskResultID = results[i].ID.String()
+		request.TaskResultCallbackURL = s.runTasks.CallbackURL(results[i].ID.String())
+		request.TaskResultEnforcementLevel = t.enforcementLevel
+		go s.sendRunTask(t.task, results[i], &request)
+	}
+	return nil
+}
+
+// runTaskRequest builds the parts of the run task request that are shared
+// by every task of a stage.
+func (s *service) runTaskRequest(run *models.Run, stage tfe.Stage) (*tfe.RunTaskRequest, error) {
+	run, err := s.findRun(run.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	var org models.Organization
+	if err := s.db.Select("name").Where("id = ?", run.Workspace.OrganizationID).First(&org).Error; err != nil {
+		s.logger.Error("failed to read organization", zap.Error(err))
+		return nil, err
+	}
+
+	request := &tfe.RunTaskRequest{
+		PayloadVersion:            runTaskPayloadVersion,
+		Stage:                     string(stage),
+		IsSpeculative:             run.PlanOnly,
+		OrganizationName:          org.Name,
+		RunID:                     run.ID.String(),
+		RunMessage:                run.Message,
+		RunCreatedAt:              run.CreatedAt,
+		WorkspaceID:               run.Workspace.ID.String(),
+		WorkspaceName:             run.Workspace.Name,
+		WorkspaceWorkingDirectory: run.Workspace.WorkingDirectory,
+		ConfigurationVersionID:    run.ConfigurationVersionID.String(),
+	}
+	if run.CreatedBy != nil {
+		request.RunCreatedBy = run.CreatedBy.Username
+	}
+	return request, nil
+}
+
+// sendRunTask sends a task its request. A task that accepts the request is
+// running until it reports back; one that cannot be reached or refuses the
+// request does not get to report back at all.
+func (s *service) sendRunTask(task *models.RunTask, result *models.TaskResult, request *tfe.RunTaskRequest) {
+	var hmacKey string
+	var err error
+	if task.EncryptedHMACKey != "" {
+		hmacKey, err = s.secrets.Decrypt(task.EncryptedHMACKey)
+	}
+	if err == nil {
+		err = s.runTasks.Send(context.Background(), task.URL, hmacKey, request)
+	}
+
+	updates := map[string]interface{}{"status": string(tfe.TaskRunning)}
+	if err != nil {
+		s.logger.Info("run task request failed", zap.String("task_result", result.ID.String()), zap.Error(err))
+		status := tfe.TaskErrored
+		if errors.Is(err, runtask.ErrUnreachable) {
+			status = tfe.TaskUnreachable
+		}
+		updates = map[string]interface{}{
+			"status":            string(status),
+			"message":           err.Error(),
+			"access_token_hash": "",
+		}
+	}
+	// The task may already have reported back, in which case the result
+	// is left alone.
+	updated, err := s.updateTaskResult(result.ID, []string{string(tfe.TaskPending)}, updates)
+	if err != nil || !updated {
+		return
+	}
+	if err := s.completeTaskStage(result.TaskStageID); err != nil {
+		s.logger.Warn("failed to complete task stage", zap.String("task_stage", result.TaskStageID.String()), zap.Error(err))
+	}
+}
+
+// completeTaskStage decides a running task stage once every task has
+// reported back and moves its run on. A mandatory task that did not pass
+// fails the stage; mandatory policy failures hold it for an override when
+// every failing set is overridable and the run can be applied.
+func (s *service) completeTaskStage(taskStageID uuid.UUID) error {
+	var stage models.TaskStage
+	if err := s.db.Preload("TaskResults").Preload("PolicyEvaluations.PolicySetOutcomes").
+		Where("id = ?", taskStageID).First(&stage).Error; err != nil {
+		s.logger.Error("failed to read task stage", zap.Error(err))
+		return err
+	}
+	if stage.Status != string(tfe.TaskStageRunning) {
+		return nil
+	}
+	taskFailed := false
+	for _, result := range stage.TaskResults {
+		if !result.IsFinal() {
+			return nil
+		}
+		taskFailed = taskFailed || result.MandatoryFailed()
+	}
+	policyFailed, overridable := false, true
+	for _, evaluation := range stage.PolicyEvaluations {
+		for _, outcome := range evaluation.PolicySetOutcomes {
+			if outcome.MandatoryFailed() {
+				policyFailed = true
+				overridable = overridable && outcome.Overridable
+			}
+		}
+	}
+
+	run, err := s.findRun(stage.RunID.String())
+	if err != nil {
+		return err
+	}
+	status, key := tfe.TaskStagePassed, "passed-at"
+	switch {
+	case taskFailed:
+		status, key = tfe.TaskStageFailed, "failed-at"
+	case policyFailed && overridable && !run.PlanOnly:
+		status, key = tfe.TaskStageAwaitingOverride, "failed-at"
+	case policyFailed:
+		status, key = tfe.TaskStageFailed, "failed-at"
+	}
+
+	result := s.db.Model(&models.TaskStage{}).
+		Where("id = ? AND status = ?", stage.ID, tfe.TaskStageRunning).
+		Updates(withTimestamps(map[string]interface{}{"status": string(status)}, time.Now(), key))
+	if result.Error != nil {
+		s.logger.Error("failed to complete task stage", zap.Error(result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		// Completed concurrently, or canceled with its run.
+		return nil
+	}
+	s.logger.Debug("task stage completed",
+		zap.String("task_stage", stage.ID.String()),
+		zap.String("run", run.ID.String()),
+		zap.String("status", string(status)),
+	)
+
+	switch tfe.Stage(stage.Stage) {
+	case tfe.PrePlan:
+		if status != tfe.TaskStagePassed {
+			return s.transitionRun(run, tfe.RunErrored, nil)
+		}
+		if err := s.transitionRun(run, tfe.RunPrePlanCompleted, nil); err != nil {
+			return err
+		}
+		return s.transitionRun(run, tfe.RunPlanQueued, nil)
+	case tfe.PostPlan:
+		switch {
+		case status == tfe.TaskStagePassed || run.PlanOnly:
+			// Plan-only runs cannot be applied anyway.
+			return s.completePlan(run, tfe.RunPostPlanCompleted, nil)
+		case status == tfe.TaskStageAwaitingOverride:
+			return s.transitionRun(run, tfe.RunPostPlanAwaitingDecision, nil)
+		default:
+			return s.transitionRun(run, tfe.RunErrored, nil)
+		}
+	case tfe.PreApply:
+		if status != tfe.TaskStagePassed {
+			return s.transitionRun(run, tfe.RunErrored, nil)
+		}
+		if err := s.transitionRun(run, tfe.RunPreApplyCompleted, nil); err != nil {
+			return err
+		}
+		return s.transitionRun(run, tfe.RunApplyQueued, nil)
+	}
+	return nil
+}
+
+// updateTaskResult updates a task result that is still in one of the from
+// statuses, recording when it reached its new status. It reports whether
+// the result was updated.
+func (s *service) updateTaskResult(id uuid.UUID, from []string, updates map[string]interface{}) (bool, error) {
+	key := statusTimestampKey(updates["status"].(string))
+	result := s.db.Model(&models.TaskResult{}).
+		Where("id = ? AND status IN ?", id, from).
+		Updates(withTimestamps(updates, time.Now(), key))
+	if result.Error != nil {
+		s.logger.Error("failed to update task result", zap.Error(result.Error))
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// pendingTaskResultStatuses are the statuses of tasks yet to report back.
+func pendingTaskResultStatuses() []string {
+	return []string{string(tfe.TaskPending), string(tfe.TaskRunning)}
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func (s *service) ListRunTaskStages(ctx context.Context, runID string, options *tfe.TaskStageListOptions) ([]*tfe.TaskStage, *tfe.Pagination, error) {
+	run, err := s.findRun(runID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, run.Workspace, wsRead); err != nil {
+		return nil, nil, err
+	}
+	role, err := s.organizationRole(ctx, run.Workspace.OrganizationID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var listOptions tfe.ListOptions
+	if options != nil {
+		listOptions = options.ListOptions
+	}
+	db, pagination, err := paginate(s.db.Model(&models.TaskStage{}).Where("run_id = ?", run.ID), listOptions)
+	if err != nil {
+		s.logger.Error("failed to count task stages", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var stages []*models.TaskStage
+	if err := db.Preload("TaskResults").Preload("PolicyEvaluations").Order("created_at ASC").Find(&stages).Error; err != nil {
+		s.logger.Error("failed to list task stages", zap.Error(err))
+		return nil, nil, err
+	}
+
+	tfeStages := make([]*tfe.TaskStage, len(stages))
+	for i, stage := range stages {
+		tfeStages[i] = taskStageToTFE(stage, role)
+	}
+	return tfeStages, pagination, nil
+}
+
+func (s *service) ReadTaskStage(ctx context.Context, taskStageID string) (*tfe.TaskStage, error) {
+	stage, role, err := s.findAuthorizedTaskStage(ctx, taskStageID)
+	if err != nil {
+		return nil, err
+	}
+	return taskStageToTFE(stage, role), nil
+}
+
+// OverrideTaskStage lets a run held by failed mandatory policies continue.
+// It requires the permission to manage policy overrides.
+func (s *service) OverrideTaskStage(ctx context.Context, taskStageID string, options tfe.TaskStageOverrideOptions) (*tfe.TaskStage, error) {
+	stage, role, err := s.findAuthorizedTaskStage(ctx, taskStageID)
+	if err != nil {
+		return nil, err
+	}
+	if !role.can(orgManagePolicyOverrides) {
+		return nil, fmt.Errorf("%w: overriding policies requires the manage policy overrides permission", ErrForbidden)
+	}
+	if stage.Status != string(tfe.TaskStageAwaitingOverride) {
+		return nil, fmt.Errorf("%w: task stage is %s, not awaiting override", ErrConflict, stage.Status)
+	}
+	run, err := s.findRun(stage.RunID.String())
+	if err != nil {
+		return nil, err
+	}
+	before := taskStageToTFE(stage, role)
+
+	now := time.Now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.TaskStage{}).
+			Where("id = ? AND status = ?", stage.ID, tfe.TaskStageAwaitingOverride).
+			Updates(withTimestamps(map[string]interface{}{"status": tfe.TaskStagePassed}, now, "passed-at"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: task stage is no longer awaiting override", ErrConflict)
+		}
+		return tx.Model(&models.PolicyEvaluation{}).
+			Where("task_stage_id = ? AND status = ?", stage.ID, tfe.PolicyEvaluationFailed).
+			Updates(withTimestamps(map[string]interface{}{"status": tfe.PolicyEvaluationOverridden}, now, "overridden-at")).Error
+	})
+	if err != nil {
+		s.logger.Debug("failed to override task stage", zap.String("task_stage", taskStageID), zap.Error(err))
+		return nil, err
+	}
+
+	userID, err := s.currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fields := []zap.Field{zap.String("task_stage", taskStageID), zap.String("run", run.ID.String())}
+	if userID != nil {
+		fields = append(fields, zap.String("user", userID.String()))
+	}
+	if options.Comment != nil {
+		fields = append(fields, zap.String("comment", *options.Comment))
+	}
+	s.logger.Info("policies overridden", fields...)
+
+	if err := s.completePlan(run, tfe.RunPostPlanCompleted, nil); err != nil {
+		return nil, err
+	}
+	overridden, err := s.ReadTaskStage(ctx, taskStageID)
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, role.orgID, models.AuditActionOverride, before, overridden)
+	return overridden, nil
+}
+
+func (s *service) ListPolicyEvaluations(ctx context.Context, taskStageID string, options *tfe.PolicyEvaluationListOptions) ([]*tfe.PolicyEvaluation, *tfe.Pagination, error) {
+	stage, _, err := s.findAuthorizedTaskStage(ctx, taskStageID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var listOptions tfe.ListOptions
+	if options != nil {
+		listOptions = options.ListOptions
+	}
+	db, pagination, err := paginate(s.db.Model(&models.PolicyEvaluation{}).Where("task_stage_id = ?", stage.ID), listOptions)
+	if err != nil {
+		s.logger.Error("failed to count policy evaluations", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var evaluations []*models.PolicyEvaluation
+	if err := db.Preload("PolicySetOutcomes").Order("created_at ASC").Find(&evaluations).Error; err != nil {
+		s.logger.Error("failed to list policy evaluations", zap.Error(err))
+		return nil, nil, err
+	}
+
+	tfeEvaluations := make([]*tfe.PolicyEvaluation, len(evaluations))
+	for i, pe := range evaluations {
+		tfeEvaluations[i] = pe.ToTFE()
+	}
+	return tfeEvaluations, pagination, nil
+}
+
+// ListPolicySetOutcomes lists the outcomes of a policy evaluation. Filters
+// narrow the policy outcomes of each set to those with a matching status
+// and enforcement level; sets left without outcomes are skipped.
+func (s *service) ListPolicySetOutcomes(ctx context.Context, policyEvaluationID string, options *tfe.PolicySetOutcomeListOptions) ([]*tfe.PolicySetOutcome, *tfe.Pagination, error) {
+	evaluation, err := s.findPolicyEvaluation(policyEvaluationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, _, err := s.findAuthorizedTaskStage(ctx, evaluation.TaskStageID.String()); err != nil {
+		return nil, nil, err
+	}
+
+	var listOptions tfe.ListOptions
+	var filters map[string]tfe.PolicySetOutcomeListFilter
+	if options != nil {
+		if options.ListOptions != nil {
+			listOptions = *options.ListOptions
+		}
+		filters = options.Filter
+	}
+	db, pagination, err := paginate(s.db.Model(&models.PolicySetOutcome{}).Where("policy_evaluation_id = ?", evaluation.ID), listOptions)
+	if err != nil {
+		s.logger.Error("failed to count policy set outcomes", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var outcomes []*models.PolicySetOutcome
+	if err := db.Order("policy_set_name ASC").Find(&outcomes).Error; err != nil {
+		s.logger.Error("failed to list policy set outcomes", zap.Error(err))
+		return nil, nil, err
+	}
+
+	tfeOutcomes := make([]*tfe.PolicySetOutcome, 0, len(outcomes))
+	for _, o := range outcomes {
+		if len(filters) > 0 {
+			o.Outcomes = filterPolicyOutcomes(o.Outcomes, filters)
+			if len(o.Outcomes) == 0 {
+				continue
+			}
+		}
+		tfeOutcomes = append(tfeOutcomes, o.ToTFE())
+	}
+	return tfeOutcomes, pagination, nil
+}
+
+func (s *service) ReadPolicySetOutcome(ctx context.Context, policySetOutcomeID string) (*tfe.PolicySetOutcome, error) {
+	id, err := uuid.Parse(policySetOutcomeID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	var outcome models.PolicySetOutcome
+	if err := s.db.Where("id = ?", id).First(&outcome).Error; err != nil {
+		s.logger.Error("failed to read policy set outcome", zap.Error(err))
+		return nil, err
+	}
+	evaluation, err := s.findPolicyEvaluation(outcome.PolicyEvaluationID.String())
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := s.findAuthorizedTaskStage(ctx, evaluation.TaskStageID.String()); err != nil {
+		return nil, err
+	}
+	return outcome.ToTFE(), nil
+}
+
+// findAuthorizedTaskStage loads a task stage the caller can read, together
+// with the caller's role in the organization of its run.
+func (s *service) findAuthorizedTaskStage(ctx context.Context, taskStageID string) (*models.TaskStage, *organizationRole, error) {
+	id, err := uuid.Parse(taskStageID)
+	if err != nil {
+		return nil, nil, gorm.ErrRecordNotFound
+	}
+	var stage models.TaskStage
+	if err := s.db.Preload("TaskResults").Preload("PolicyEvaluations").Where("id = ?", id).First(&stage).Error; err != nil {
+		s.logger.Error("failed to read task stage", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var run models.Run
+	if err := s.db.Preload("Workspace").Select("id", "workspace_id").Where("id = ?", stage.RunID).First(&run).Error; err != nil {
+		s.logger.Error("failed to read run", zap.Error(err))
+		return nil, nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, run.Workspace, wsRead); err != nil {
+		return nil, nil, err
+	}
+	role, err := s.organizationRole(ctx, run.Workspace.OrganizationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &stage, role, nil
+}
+
+func (s *service) findPolicyEvaluation(policyEvaluationID string) (*models.PolicyEvaluation, error) {
+	id, err := uuid.Parse(policyEvaluationID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	var evaluation models.PolicyEvaluation
+	if err := s.db.Where("id = ?", id).First(&evaluation).Error; err != nil {
+		s.logger.Error("failed to read policy evaluation", zap.Error(err))
+		return nil, err
+	}
+	return &evaluation, nil
+}
+
+// taskStageToTFE converts a task stage, filling in what the caller may do with it.
+func taskStageToTFE(stage *models.TaskStage, role *organizationRole) *tfe.TaskStage {
+	ts := stage.ToTFE()
+	canOverride := role.can(orgManagePolicyOverrides)
+	ts.Permissions = &tfe.Permissions{
+		CanOverridePolicy: &canOverride,
+		CanOverrideTasks:  &canOverride,
+		CanOverride:       &canOverride,
+	}
+	return ts
+}
+
+func filterPolicyOutcomes(outcomes []models.PolicyOutcome, filters map[string]tfe.PolicySetOutcomeListFilter) []models.PolicyOutcome {
+	var matched []models.PolicyOutcome
+	for _, outcome := range outcomes {
+		for _, f := range filters {
+			if (f.Status == "" || f.Status == outcome.Status) &&
+				(f.EnforcementLevel == "" || f.EnforcementLevel == outcome.EnforcementLevel) {
+				matched = append(matched, outcome)
+				break
+			}
+		}
+	}
+	return matched
+}
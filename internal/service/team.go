@@ -0,0 +1,451 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (s *service) ListTeams(ctx context.Context, organization string, options *tfe.TeamListOptions) ([]*tfe.Team, *tfe.Pagination, error) {
+	role, err := s.authorizeOrganizationName(ctx, organization, orgRead)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db := s.readableTeams(s.db.Model(&models.Team{}).Where("organization_id = ?", role.orgID), role)
+	var listOptions tfe.ListOptions
+	if options != nil {
+		listOptions = options.ListOptions
+		if options.Query != "" {
+			db = db.Where("name ILIKE ?", "%"+options.Query+"%")
+		}
+		if len(options.Names) > 0 {
+			db = db.Where("name IN ?", options.Names)
+		}
+	}
+
+	db, pagination, err := paginate(db, listOptions)
+	if err != nil {
+		s.logger.Error("failed to count teams", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var teams []*models.Team
+	if err := db.Preload("Users").Order("name ASC").Find(&teams).Error; err != nil {
+		s.logger.Error("failed to list teams", zap.Error(err))
+		return nil, nil, err
+	}
+	if err := s.loadTeamMemberships(teams); err != nil {
+		return nil, nil, err
+	}
+
+	tfeTeams := make([]*tfe.Team, len(teams))
+	for i, team := range teams {
+		tfeTeams[i] = team.ToTFE()
+	}
+	return tfeTeams, pagination, nil
+}
+
+func (s *service) CreateTeam(ctx context.Context, organization string, options tfe.TeamCreateOptions) (*tfe.Team, error) {
+	required := orgManageTeams
+	if options.OrganizationAccess != nil {
+		required |= orgManageOrganizationAccess
+	}
+	role, err := s.authorizeOrganizationName(ctx, organization, required)
+	if err != nil {
+		return nil, err
+	}
+	if options.Name == nil || *options.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidAttribute)
+	}
+
+	team := &models.Team{
+		Name:                       *options.Name,
+		Visibility:                 models.TeamVisibilitySecret,
+		AllowMemberTokenManagement: true,
+		OrganizationAccess:         &models.OrganizationAccess{},
+		OrganizationID:             role.orgID,
+	}
+	if options.Visibility != nil {
+		team.Visibility = *options.Visibility
+	}
+	if options.SSOTeamID != nil {
+		team.SSOTeamID = *options.SSOTeamID
+	}
+	if options.AllowMemberTokenManagement != nil {
+		team.AllowMemberTokenManagement = *options.AllowMemberTokenManagement
+	}
+	team.ApplyTFEOrganizationAccessOptions(options.OrganizationAccess)
+	if err := s.validateTeam(team); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Omit(clause.Associations).Create(team).Error; err != nil {
+		s.logger.Error("failed to create team", zap.Error(err))
+		return nil, err
+	}
+	created, err := s.ReadTeam(ctx, team.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, role.orgID, models.AuditActionCreate, nil, created)
+	return created, nil
+}
+
+func (s *service) ReadTeam(ctx context.Context, teamID string) (*tfe.Team, error) {
+	team, err := s.findTeam(teamID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeTeam(ctx, team, orgRead); err != nil {
+		return nil, err
+	}
+	if err := s.loadTeamMemberships([]*models.Team{team}); err != nil {
+		return nil, err
+	}
+	return team.ToTFE(), nil
+}
+
+func (s *service) UpdateTeam(ctx context.Context, teamID string, options tfe.TeamUpdateOptions) (*tfe.Team, error) {
+	team, err := s.findTeam(teamID)
+	if err != nil {
+		return nil, err
+	}
+	required := orgManageTeams
+	if options.OrganizationAccess != nil {
+		required |= orgManageOrganizationAccess
+	}
+	if err := s.authorizeTeam(ctx, team, required); err != nil {
+		return nil, err
+	}
+	before, err := s.teamSnapshot(team)
+	if err != nil {
+		return nil, err
+	}
+
+	if team.IsOwners() {
+		if options.Name != nil && *options.Name != team.Name {
+			return nil, fmt.Errorf("%w: the owners team cannot be renamed", ErrInvalidAttribute)
+		}
+		if options.OrganizationAccess != nil {
+			return nil, fmt.Errorf("%w: the organization access of the owners team cannot be changed", ErrInvalidAttribute)
+		}
+	}
+	if options.Name != nil {
+		team.Name = *options.Name
+	}
+	if options.Visibility != nil {
+		team.Visibility = *options.Visibility
+	}
+	if options.SSOTeamID != nil {
+		team.SSOTeamID = *options.SSOTeamID
+	}
+	if options.AllowMemberTokenManagement != nil {
+		team.AllowMemberTokenManagement = *options.AllowMemberTokenManagement
+	}
+	team.ApplyTFEOrganizationAccessOptions(options.OrganizationAccess)
+	if err := s.validateTeam(team); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Omit(clause.Associations).Save(team).Error; err != nil {
+		s.logger.Error("failed to update team", zap.Error(err))
+		return nil, err
+	}
+	return s.auditTeam(ctx, team, before)
+}
+
+func (s *service) DeleteTeam(ctx context.Context, teamID string) error {
+	team, err := s.findTeam(teamID)
+	if err != nil {
+		return err
+	}
+	if err := s.authorizeTeam(ctx, team, orgManageTeams); err != nil {
+		return err
+	}
+	if team.IsOwners() {
+		return fmt.Errorf("%w: the owners team cannot be deleted", ErrInvalidAttribute)
+	}
+	before, err := s.teamSnapshot(team)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("team_id = ?", team.ID).Delete(&models.TeamUser{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", team.ID).Delete(&models.Team{}).Error
+	})
+	if err != nil {
+		s.logger.Error("failed to delete team", zap.Error(err))
+		return err
+	}
+	s.audit(ctx, team.OrganizationID, models.AuditActionDelete, before, nil)
+	return nil
+}
+
+// AddTeamMembers adds users to a team, identified either by username or by
+// organization membership. Users must be members of the team's organization.
+func (s *service) AddTeamMembers(ctx context.Context, teamID string, options tfe.TeamMemberAddOptions) error {
+	team, err := s.findTeam(teamID)
+	if err != nil {
+		return err
+	}
+	if err := s.authorizeTeam(ctx, team, orgManageMembership); err != nil {
+		return err
+	}
+	userIDs, err := s.teamMemberUsers(team, options.Usernames, options.OrganizationMembershipIDs)
+	if err != nil {
+		return err
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	before, err := s.teamSnapshot(team)
+	if err != nil {
+		return err
+	}
+	members := make([]*models.TeamUser, len(userIDs))
+	for i, id := range userIDs {
+		members[i] = &models.TeamUser{TeamID: team.ID, UserID: id}
+	}
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(members).Error; err != nil {
+		s.logger.Error("failed to add team members", zap.Error(err))
+		return err
+	}
+	_, err = s.auditTeam(ctx, team, before)
+	return err
+}
+
+// RemoveTeamMembers removes users from a team, identified either by
+// username or by organization membership. The owners team always keeps at
+// least one member.
+func (s *service) RemoveTeamMembers(ctx context.Context, teamID string, options tfe.TeamMemberRemoveOptions) error {
+	team, err := s.findTeam(teamID)
+	if err != nil {
+		return err
+	}
+	if err := s.authorizeTeam(ctx, team, orgManageMembership); err != nil {
+		return err
+	}
+	userIDs, err := s.teamMemberUsers(team, options.Usernames, options.OrganizationMembershipIDs)
+	if err != nil {
+		return err
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+	before, err := s.teamSnapshot(team)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("team_id = ? AND user_id IN ?", team.ID, userIDs).Delete(&models.TeamUser{}).Error; err != nil {
+			return err
+		}
+		return ensureOwnersRemain(tx, team.OrganizationID)
+	})
+	if err != nil {
+		if !errors.Is(err, ErrInvalidAttribute) {
+			s.logger.Error("failed to remove team members", zap.Error(err))
+		}
+		return err
+	}
+	_, err = s.auditTeam(ctx, team, before)
+	return err
+}
+
+// teamSnapshot returns a team as it is read, members included, to compare
+// it after a change.
+func (s *service) teamSnapshot(team *models.Team) (*tfe.Team, error) {
+	if err := s.loadTeamMemberships([]*models.Team{team}); err != nil {
+		return nil, err
+	}
+	return team.ToTFE(), nil
+}
+
+// auditTeam records an update of a team and returns the team as it is now.
+func (s *service) auditTeam(ctx context.Context, team *models.Team, before *tfe.Team) (*tfe.Team, error) {
+	updated, err := s.ReadTeam(ctx, team.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, team.OrganizationID, models.AuditActionUpdate, before, updated)
+	return updated, nil
+}
+
+// ensureOwnersRemain fails when the owners team of an organization has no
+// members left. It locks the owners team so that concurrent removals
+// cannot both leave it empty.
+func ensureOwnersRemain(tx *gorm.DB, orgID uuid.UUID) error {
+	var owners models.Team
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("organization_id = ? AND name = ?", orgID, models.OwnersTeamName).First(&owners).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var remaining int64
+	if err := tx.Model(&models.TeamUser{}).Where("team_id = ?", owners.ID).Count(&remaining).Error; err != nil {
+		return err
+	}
+	if remaining == 0 {
+		return fmt.Errorf("%w: the owners team must have at least one member", ErrInvalidAttribute)
+	}
+	return nil
+}
+
+// createOwnersTeam creates the owners team of a new organization with the
+// creating user, who becomes an active member of the organization, as its
+// only member.
+func createOwnersTeam(tx *gorm.DB, orgID uuid.UUID, owner *models.User) error {
+	membership := &models.OrganizationMembership{
+		Status:         string(tfe.OrganizationMembershipActive),
+		OrganizationID: orgID,
+		UserID:         owner.ID,
+	}
+	if err := tx.Omit(clause.Associations).Create(membership).Error; err != nil {
+		return err
+	}
+
+	team := &models.Team{
+		Name:                       models.OwnersTeamName,
+		Visibility:                 models.TeamVisibilityOrganization,
+		AllowMemberTokenManagement: true,
+		OrganizationAccess:         models.FullOrganizationAccess(),
+		OrganizationID:             orgID,
+	}
+	if err := tx.Omit(clause.Associations).Create(team).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.TeamUser{TeamID: team.ID, UserID: owner.ID}).Error
+}
+
+func (s *service) validateTeam(team *models.Team) error {
+	if team.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidAttribute)
+	}
+	switch team.Visibility {
+	case models.TeamVisibilitySecret, models.TeamVisibilityOrganization:
+	default:
+		return fmt.Errorf("%w: visibility %q must be secret or organization", ErrInvalidAttribute, team.Visibility)
+	}
+
+	var existing models.Team
+	err := s.db.Where("organization_id = ? AND name = ? AND id <> ?", team.OrganizationID, team.Name, team.ID).First(&existing).Error
+	switch {
+	case err == nil:
+		return fmt.Errorf("%w: name %q has already been taken", ErrInvalidAttribute, team.Name)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		s.logger.Error("failed to check team name", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// authorizeTeam requires the caller to see the team and to hold required in
+// its organization.
+func (s *service) authorizeTeam(ctx context.Context, team *models.Team, required organizationPermission) error {
+	role, err := s.authorizeOrganization(ctx, team.OrganizationID, orgRead)
+	if err != nil {
+		return err
+	}
+	if !role.canSeeTeam(team) {
+		return gorm.ErrRecordNotFound
+	}
+	if !role.can(required) {
+		return fmt.Errorf("%w: insufficient organization permissions", ErrForbidden)
+	}
+	return nil
+}
+
+func (s *service) findTeam(teamID string) (*models.Team, error) {
+	id, err := uuid.Parse(teamID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var team models.Team
+	if err := s.db.Preload("Organization").Preload("Users").Where("id = ?", id).First(&team).Error; err != nil {
+		s.logger.Error("failed to read team", zap.Error(err))
+		return nil, err
+	}
+	return &team, nil
+}
+
+// teamMemberUsers resolves usernames or organization membership IDs to the
+// IDs of users that are members of the team's organization.
+func (s *service) teamMemberUsers(team *models.Team, usernames, membershipIDs []string) ([]uuid.UUID, error) {
+	db := s.db.Model(&models.OrganizationMembership{}).Where("organization_id = ?", team.OrganizationID)
+	var want int
+	switch {
+	case len(usernames) > 0:
+		db = db.Where("user_id IN (?)", s.db.Model(&models.User{}).Select("id").Where("username IN ?", usernames))
+		want = len(usernames)
+	case len(membershipIDs) > 0:
+		ids := make([]uuid.UUID, 0, len(membershipIDs))
+		for _, id := range membershipIDs {
+			u, err := uuid.Parse(id)
+			if err != nil {
+				return nil, fmt.Errorf("%w: organization membership %q not found", ErrInvalidAttribute, id)
+			}
+			ids = append(ids, u)
+		}
+		db = db.Where("id IN ?", ids)
+		want = len(ids)
+	default:
+		return nil, nil
+	}
+
+	var userIDs []uuid.UUID
+	if err := db.Distinct().Pluck("user_id", &userIDs).Error; err != nil {
+		s.logger.Error("failed to resolve team members", zap.Error(err))
+		return nil, err
+	}
+	if len(userIDs) != want {
+		return nil, fmt.Errorf("%w: every user must be a member of the team's organization", ErrInvalidAttribute)
+	}
+	return userIDs, nil
+}
+
+// loadTeamMemberships fills in the organization memberships of each team's users.
+func (s *service) loadTeamMemberships(teams []*models.Team) error {
+	var userIDs []uuid.UUID
+	for _, t := range teams {
+		for _, u := range t.Users {
+			userIDs = append(userIDs, u.ID)
+		}
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	var memberships []*models.OrganizationMembership
+	if err := s.db.Where("user_id IN ?", userIDs).Find(&memberships).Error; err != nil {
+		s.logger.Error("failed to load team memberships", zap.Error(err))
+		return err
+	}
+	for _, t := range teams {
+		for _, u := range t.Users {
+			for _, m := range memberships {
+				if m.UserID == u.ID && m.OrganizationID == t.OrganizationID {
+					t.OrganizationMemberships = append(t.OrganizationMemberships, m)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,435 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ListTeamWorkspaceAccesses lists the team accesses of a workspace. The
+// workspace filter is required, as it is in Terraform Enterprise.
+func (s *service) ListTeamWorkspaceAccesses(ctx context.Context, options *tfe.TeamAccessListOptions) ([]*tfe.TeamAccess, *tfe.Pagination, error) {
+	if options == nil || options.WorkspaceID == "" {
+		return nil, nil, fmt.Errorf("%w: filter[workspace][id] is required", ErrInvalidAttribute)
+	}
+	ws, err := s.findWorkspaceByID(ctx, options.WorkspaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, ws, wsRead); err != nil {
+		return nil, nil, err
+	}
+
+	db, pagination, err := paginate(s.db.Model(&models.TeamWorkspaceAccess{}).Where("workspace_id = ?", ws.ID), options.ListOptions)
+	if err != nil {
+		s.logger.Error("failed to count team workspace accesses", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var accesses []*models.TeamWorkspaceAccess
+	if err := db.Order("created_at ASC").Find(&accesses).Error; err != nil {
+		s.logger.Error("failed to list team workspace accesses", zap.Error(err))
+		return nil, nil, err
+	}
+
+	tfeAccesses := make([]*tfe.TeamAccess, len(accesses))
+	for i, a := range accesses {
+		tfeAccesses[i] = a.ToTFE()
+	}
+	return tfeAccesses, pagination, nil
+}
+
+// AddTeamWorkspaceAccess grants a team access to a workspace. Workspace
+// admins may grant access to any team they can see.
+func (s *service) AddTeamWorkspaceAccess(ctx context.Context, options tfe.TeamAccessAddOptions) (*tfe.TeamAccess, error) {
+	if options.Access == nil {
+		return nil, fmt.Errorf("%w: access is required", ErrInvalidAttribute)
+	}
+	if options.Team == nil || options.Workspace == nil {
+		return nil, fmt.Errorf("%w: team and workspace are required", ErrInvalidAttribute)
+	}
+	ws, err := s.findWorkspaceByID(ctx, options.Workspace.ID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, ws, wsAdmin); err != nil {
+		return nil, err
+	}
+	team, err := s.findAccessTeam(ctx, options.Team.ID, ws.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	access := &models.TeamWorkspaceAccess{
+		Access:        string(*options.Access),
+		Runs:          string(tfe.RunsPermissionRead),
+		Variables:     string(tfe.VariablesPermissionNone),
+		StateVersions: string(tfe.StateVersionsPermissionNone),
+		SentinelMocks: string(tfe.SentinelMocksPermissionNone),
+		TeamID:        team.ID,
+		WorkspaceID:   ws.ID,
+	}
+	if err := applyTeamWorkspaceAccessOptions(access, options.Runs, options.Variables, options.StateVersions, options.SentinelMocks, options.WorkspaceLocking, options.RunTasks); err != nil {
+		return nil, err
+	}
+
+	var existing int64
+	if err := s.db.Model(&models.TeamWorkspaceAccess{}).Where("team_id = ? AND workspace_id = ?", team.ID, ws.ID).Count(&existing).Error; err != nil {
+		s.logger.Error("failed to check team workspace access", zap.Error(err))
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, fmt.Errorf("%w: team %q has already been taken", ErrInvalidAttribute, team.Name)
+	}
+
+	if err := s.db.Omit("Team", "Workspace").Create(access).Error; err != nil {
+		s.logger.Error("failed to create team workspace access", zap.Error(err))
+		return nil, err
+	}
+	created := access.ToTFE()
+	s.audit(ctx, ws.OrganizationID, models.AuditActionCreate, nil, created)
+	return created, nil
+}
+
+func (s *service) ReadTeamWorkspaceAccess(ctx context.Context, teamAccessID string) (*tfe.TeamAccess, error) {
+	access, err := s.findTeamWorkspaceAccess(ctx, teamAccessID, wsRead)
+	if err != nil {
+		return nil, err
+	}
+	return access.ToTFE(), nil
+}
+
+func (s *service) UpdateTeamWorkspaceAccess(ctx context.Context, teamAccessID string, options tfe.TeamAccessUpdateOptions) (*tfe.TeamAccess, error) {
+	access, err := s.findTeamWorkspaceAccess(ctx, teamAccessID, wsAdmin)
+	if err != nil {
+		return nil, err
+	}
+	before := access.ToTFE()
+	if options.Access != nil {
+		access.Access = string(*options.Access)
+	}
+	if err := applyTeamWorkspaceAccessOptions(access, options.Runs, options.Variables, options.StateVersions, options.SentinelMocks, options.WorkspaceLocking, options.RunTasks); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Omit("Team", "Workspace").Save(access).Error; err != nil {
+		s.logger.Error("failed to update team workspace access", zap.Error(err))
+		return nil, err
+	}
+	updated := access.ToTFE()
+	s.audit(ctx, access.Workspace.OrganizationID, models.AuditActionUpdate, before, updated)
+	return updated, nil
+}
+
+func (s *service) RemoveTeamWorkspaceAccess(ctx context.Context, teamAccessID string) error {
+	access, err := s.findTeamWorkspaceAccess(ctx, teamAccessID, wsAdmin)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(access).Error; err != nil {
+		s.logger.Error("failed to delete team workspace access", zap.Error(err))
+		return err
+	}
+	s.audit(ctx, access.Workspace.OrganizationID, models.AuditActionDelete, access.ToTFE(), nil)
+	return nil
+}
+
+// ListTeamProjectAccesses lists the team accesses of a project. The project
+// filter is required.
+func (s *service) ListTeamProjectAccesses(ctx context.Context, options *tfe.TeamProjectAccessListOptions) ([]*tfe.TeamProjectAccess, *tfe.Pagination, error) {
+	if options == nil || options.ProjectID == "" {
+		return nil, nil, fmt.Errorf("%w: filter[project][id] is required", ErrInvalidAttribute)
+	}
+	project, err := s.findProject(options.ProjectID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.authorizeProject(ctx, project, projectReadTeams); err != nil {
+		return nil, nil, err
+	}
+
+	db, pagination, err := paginate(s.db.Model(&models.TeamProjectAccess{}).Where("project_id = ?", project.ID), options.ListOptions)
+	if err != nil {
+		s.logger.Error("failed to count team project accesses", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var accesses []*models.TeamProjectAccess
+	if err := db.Order("created_at ASC").Find(&accesses).Error; err != nil {
+		s.logger.Error("failed to list team project accesses", zap.Error(err))
+		return nil, nil, err
+	}
+
+	tfeAccesses := make([]*tfe.TeamProjectAccess, len(accesses))
+	for i, a := range accesses {
+		tfeAccesses[i] = a.ToTFE()
+	}
+	return tfeAccesses, pagination, nil
+}
+
+// AddTeamProjectAccess grants a team access to a project and its workspaces.
+func (s *service) AddTeamProjectAccess(ctx context.Context, options tfe.TeamProjectAccessAddOptions) (*tfe.TeamProjectAccess, error) {
+	if options.Access == "" {
+		return nil, fmt.Errorf("%w: access is required", ErrInvalidAttribute)
+	}
+	if options.Team == nil || options.Project == nil {
+		return nil, fmt.Errorf("%w: team and project are required", ErrInvalidAttribute)
+	}
+	project, err := s.findProject(options.Project.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeProject(ctx, project, projectManageTeams); err != nil {
+		return nil, err
+	}
+	team, err := s.findAccessTeam(ctx, options.Team.ID, project.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	access := &models.TeamProjectAccess{
+		Access:                 string(options.Access),
+		ProjectSettings:        string(tfe.ProjectSettingsPermissionRead),
+		ProjectTeams:           string(tfe.ProjectTeamsPermissionNone),
+		WorkspaceRuns:          string(tfe.WorkspaceRunsPermissionRead),
+		WorkspaceSentinelMocks: string(tfe.WorkspaceSentinelMocksPermissionNone),
+		WorkspaceStateVersions: string(tfe.WorkspaceStateVersionsPermissionNone),
+		WorkspaceVariables:     string(tfe.WorkspaceVariablesPermissionNone),
+		TeamID:                 team.ID,
+		ProjectID:              project.ID,
+	}
+	if err := applyTeamProjectAccessOptions(access, options.ProjectAccess, options.WorkspaceAccess); err != nil {
+		return nil, err
+	}
+
+	var existing int64
+	if err := s.db.Model(&models.TeamProjectAccess{}).Where("team_id = ? AND project_id = ?", team.ID, project.ID).Count(&existing).Error; err != nil {
+		s.logger.Error("failed to check team project access", zap.Error(err))
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, fmt.Errorf("%w: team %q has already been taken", ErrInvalidAttribute, team.Name)
+	}
+
+	if err := s.db.Omit("Team", "Project").Create(access).Error; err != nil {
+		s.logger.Error("failed to create team project access", zap.Error(err))
+		return nil, err
+	}
+	created := access.ToTFE()
+	s.audit(ctx, project.OrganizationID, models.AuditActionCreate, nil, created)
+	return created, nil
+}
+
+func (s *service) ReadTeamProjectAccess(ctx context.Context, teamProjectAccessID string) (*tfe.TeamProjectAccess, error) {
+	access, err := s.findTeamProjectAccess(ctx, teamProjectAccessID, projectReadTeams)
+	if err != nil {
+		return nil, err
+	}
+	return access.ToTFE(), nil
+}
+
+func (s *service) UpdateTeamProjectAccess(ctx context.Context, teamProjectAccessID string, options tfe.TeamProjectAccessUpdateOptions) (*tfe.TeamProjectAccess, error) {
+	access, err := s.findTeamProjectAccess(ctx, teamProjectAccessID, projectManageTeams)
+	if err != nil {
+		return nil, err
+	}
+	before := access.ToTFE()
+	if options.Access != nil {
+		access.Access = string(*options.Access)
+	}
+	if err := applyTeamProjectAccessOptions(access, options.ProjectAccess, options.WorkspaceAccess); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Omit("Team", "Project").Save(access).Error; err != nil {
+		s.logger.Error("failed to update team project access", zap.Error(err))
+		return nil, err
+	}
+	updated := access.ToTFE()
+	s.audit(ctx, access.Project.OrganizationID, models.AuditActionUpdate, before, updated)
+	return updated, nil
+}
+
+func (s *service) RemoveTeamProjectAccess(ctx context.Context, teamProjectAccessID string) error {
+	access, err := s.findTeamProjectAccess(ctx, teamProjectAccessID, projectManageTeams)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(access).Error; err != nil {
+		s.logger.Error("failed to delete team project access", zap.Error(err))
+		return err
+	}
+	s.audit(ctx, access.Project.OrganizationID, models.AuditActionDelete, access.ToTFE(), nil)
+	return nil
+}
+
+// applyTeamWorkspaceAccessOptions validates the access level and applies the
+// custom permissions, which may only be set with custom access.
+func applyTeamWorkspaceAccessOptions(access *models.TeamWorkspaceAccess, runs *tfe.RunsPermissionType, variables *tfe.VariablesPermissionType, stateVersions *tfe.StateVersionsPermissionType, sentinelMocks *tfe.SentinelMocksPermissionType, locking, runTasks *bool) error {
+	switch tfe.AccessType(access.Access) {
+	case tfe.AccessRead, tfe.AccessPlan, tfe.AccessWrite, tfe.AccessAdmin:
+		if runs != nil || variables != nil || stateVersions != nil || sentinelMocks != nil || locking != nil || runTasks != nil {
+			return fmt.Errorf("%w: custom permissions can only be set with custom access", ErrInvalidAttribute)
+		}
+		access.ApplyAccessLevel()
+		return nil
+	case tfe.AccessCustom:
+	default:
+		return fmt.Errorf("%w: access %q must be one of read, plan, write, admin or custom", ErrInvalidAttribute, access.Access)
+	}
+
+	if runs != nil {
+		access.Runs = string(*runs)
+	}
+	if variables != nil {
+		access.Variables = string(*variables)
+	}
+	if stateVersions != nil {
+		access.StateVersions = string(*stateVersions)
+	}
+	if sentinelMocks != nil {
+		access.SentinelMocks = string(*sentinelMocks)
+	}
+	if locking != nil {
+		access.WorkspaceLocking = *locking
+	}
+	if runTasks != nil {
+		access.RunTasks = *runTasks
+	}
+	return errors.Join(
+		validatePermission("runs", access.Runs, tfe.RunsPermissionRead, tfe.RunsPermissionPlan, tfe.RunsPermissionApply),
+		validatePermission("variables", access.Variables, tfe.VariablesPermissionNone, tfe.VariablesPermissionRead, tfe.VariablesPermissionWrite),
+		validatePermission("state-versions", access.StateVersions, tfe.StateVersionsPermissionNone, tfe.StateVersionsPermissionReadOutputs, tfe.StateVersionsPermissionRead, tfe.StateVersionsPermissionWrite),
+		validatePermission("sentinel-mocks", access.SentinelMocks, tfe.SentinelMocksPermissionNone, tfe.SentinelMocksPermissionRead),
+	)
+}
+
+// applyTeamProjectAccessOptions is applyTeamWorkspaceAccessOptions for team
+// project accesses.
+func applyTeamProjectAccessOptions(access *models.TeamProjectAccess, project *tfe.TeamProjectAccessProjectPermissionsOptions, workspace *tfe.TeamProjectAccessWorkspacePermissionsOptions) error {
+	switch tfe.TeamProjectAccessType(access.Access) {
+	case tfe.TeamProjectAccessRead, tfe.TeamProjectAccessWrite, tfe.TeamProjectAccessMaintain, tfe.TeamProjectAccessAdmin:
+		if project != nil || workspace != nil {
+			return fmt.Errorf("%w: custom permissions can only be set with custom access", ErrInvalidAttribute)
+		}
+		access.ApplyAccessLevel()
+		return nil
+	case tfe.TeamProjectAccessCustom:
+	default:
+		return fmt.Errorf("%w: access %q must be one of read, write, maintain, admin or custom", ErrInvalidAttribute, access.Access)
+	}
+
+	if project != nil {
+		if project.Settings != nil {
+			access.ProjectSettings = string(*project.Settings)
+		}
+		if project.Teams != nil {
+			access.ProjectTeams = string(*project.Teams)
+		}
+	}
+	if workspace != nil {
+		if workspace.Runs != nil {
+			access.WorkspaceRuns = string(*workspace.Runs)
+		}
+		if workspace.SentinelMocks != nil {
+			access.WorkspaceSentinelMocks = string(*workspace.SentinelMocks)
+		}
+		if workspace.StateVersions != nil {
+			access.WorkspaceStateVersions = string(*workspace.StateVersions)
+		}
+		if workspace.Variables != nil {
+			access.WorkspaceVariables = string(*workspace.Variables)
+		}
+		if workspace.Create != nil {
+			access.WorkspaceCreate = *workspace.Create
+		}
+		if workspace.Locking != nil {
+			access.WorkspaceLocking = *workspace.Locking
+		}
+		if workspace.Move != nil {
+			access.WorkspaceMove = *workspace.Move
+		}
+		if workspace.Delete != nil {
+			access.WorkspaceDelete = *workspace.Delete
+		}
+		if workspace.RunTasks != nil {
+			access.WorkspaceRunTasks = *workspace.RunTasks
+		}
+	}
+	return errors.Join(
+		validatePermission("project-access.settings", access.ProjectSettings, tfe.ProjectSettingsPermissionRead, tfe.ProjectSettingsPermissionUpdate, tfe.ProjectSettingsPermissionDelete),
+		validatePermission("project-access.teams", access.ProjectTeams, tfe.ProjectTeamsPermissionNone, tfe.ProjectTeamsPermissionRead, tfe.ProjectTeamsPermissionManage),
+		validatePermission("workspace-access.runs", access.WorkspaceRuns, tfe.WorkspaceRunsPermissionRead, tfe.WorkspaceRunsPermissionPlan, tfe.WorkspaceRunsPermissionApply),
+		validatePermission("workspace-access.sentinel-mocks", access.WorkspaceSentinelMocks, tfe.WorkspaceSentinelMocksPermissionNone, tfe.WorkspaceSentinelMocksPermissionRead),
+		validatePermission("workspace-access.state-versions", access.WorkspaceStateVersions, tfe.WorkspaceStateVersionsPermissionNone, tfe.WorkspaceStateVersionsPermissionReadOutputs, tfe.WorkspaceStateVersionsPermissionRead, tfe.WorkspaceStateVersionsPermissionWrite),
+		validatePermission("workspace-access.variables", access.WorkspaceVariables, tfe.WorkspaceVariablesPermissionNone, tfe.WorkspaceVariablesPermissionRead, tfe.WorkspaceVariablesPermissionWrite),
+	)
+}
+
+func validatePermission[T ~string](name, value string, allowed ...T) error {
+	if slices.Contains(allowed, T(value)) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s permission %q is not valid", ErrInvalidAttribute, name, value)
+}
+
+// findAccessTeam loads a team to grant access to. The team must belong to
+// the organization and be visible to the caller.
+func (s *service) findAccessTeam(ctx context.Context, teamID string, orgID uuid.UUID) (*models.Team, error) {
+	team, err := s.findTeam(teamID)
+	if err != nil {
+		return nil, err
+	}
+	if team.OrganizationID != orgID {
+		return nil, fmt.Errorf("%w: team does not belong to the organization", ErrInvalidAttribute)
+	}
+	if err := s.authorizeTeam(ctx, team, orgRead); err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// findTeamWorkspaceAccess loads a team workspace access and requires the
+// caller to hold required on its workspace.
+func (s *service) findTeamWorkspaceAccess(ctx context.Context, teamAccessID string, required workspacePermission) (*models.TeamWorkspaceAccess, error) {
+	id, err := uuid.Parse(teamAccessID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var access models.TeamWorkspaceAccess
+	if err := s.db.Preload("Workspace").Where("id = ?", id).First(&access).Error; err != nil {
+		s.logger.Error("failed to read team workspace access", zap.Error(err))
+		return nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, access.Workspace, required); err != nil {
+		return nil, err
+	}
+	return &access, nil
+}
+
+// findTeamProjectAccess loads a team project access and requires the caller
+// to hold required on its project.
+func (s *service) findTeamProjectAccess(ctx context.Context, teamProjectAccessID string, required projectPermission) (*models.TeamProjectAccess, error) {
+	id, err := uuid.Parse(teamProjectAccessID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var access models.TeamProjectAccess
+	if err := s.db.Preload("Project").Where("id = ?", id).First(&access).Error; err != nil {
+		s.logger.Error("failed to read team project access", zap.Error(err))
+		return nil, err
+	}
+	if err := s.authorizeProject(ctx, access.Project, required); err != nil {
+		return nil, err
+	}
+	return &access, nil
+}
@@ -9,9 +9,14 @@ import (
 	"github.com/open-tfe/tfe-service/internal/constants"
 	"github.com/open-tfe/tfe-service/internal/models"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 func (s *service) ListUsers(ctx context.Context) ([]*tfe.User, error) {
+	if err := s.authorizeSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
 	var users []*models.User
 	if err := s.db.Find(&users).Error; err != nil {
 		s.logger.Error("failed to list users", zap.Error(err))
@@ -27,6 +32,10 @@ func (s *service) ListUsers(ctx context.Context) ([]*tfe.User, error) {
 }
 
 func (s *service) CreateUser(ctx context.Context, user *tfe.User) (*tfe.User, error) {
+	if err := s.authorizeSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
 	dbUser := models.FromTFEUser(user)
 	s.logger.Debug("converting from TFE user", zap.Any("user", user))
 
@@ -34,13 +43,15 @@ func (s *service) CreateUser(ctx context.Context, user *tfe.User) (*tfe.User, er
 		s.logger.Error("failed to create user", zap.Error(err))
 		return nil, err
 	}
-	return dbUser.ToTFE(), nil
+	created := dbUser.ToTFE()
+	s.auditUser(ctx, dbUser.ID, models.AuditActionCreate, nil, created)
+	return created, nil
 }
 
 func (s *service) ReadUser(ctx context.Context, userID string) (*tfe.User, error) {
 	id, err := uuid.Parse(userID)
 	if err != nil {
-		return nil, err
+		return nil, gorm.ErrRecordNotFound
 	}
 
 	var user models.User
@@ -52,47 +63,100 @@ func (s *service) ReadUser(ctx context.Context, userID string) (*tfe.User, error
 	return user.ToTFE(), nil
 }
 
+// UpdateUser updates a user's profile. Users may update themselves; only
+// site admins may update other users or change the admin flag.
 func (s *service) UpdateUser(ctx context.Context, userID string, user *tfe.User) (*tfe.User, error) {
 	id, err := uuid.Parse(userID)
 	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var existing models.User
+	if err := s.db.Where("id = ?", id).First(&existing).Error; err != nil {
+		s.logger.Error("failed to read user", zap.Error(err))
 		return nil, err
 	}
+	siteAdmin := s.authorizeSiteAdmin(ctx) == nil
+	if !siteAdmin {
+		current, err := s.currentUser(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if current.ID != existing.ID {
+			return nil, fmt.Errorf("%w: only site admins can update other users", ErrForbidden)
+		}
+	}
 
-	dbUser := models.FromTFEUser(user)
-	dbUser.ID = id
-	s.logger.Debug("converting from TFE user", zap.Any("user", user))
+	updates := userUpdates(user, siteAdmin)
+	s.logger.Debug("updating user", zap.Any("user", user))
 
-	if err := s.db.Save(dbUser).Error; err != nil {
-		s.logger.Error("failed to update user", zap.Error(err))
+	if len(updates) > 0 {
+		if err := s.db.Model(&models.User{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			s.logger.Error("failed to update user", zap.Error(err))
+			return nil, err
+		}
+	}
+	var dbUser models.User
+	if err := s.db.Where("id = ?", id).First(&dbUser).Error; err != nil {
+		s.logger.Error("failed to read user", zap.Error(err))
 		return nil, err
 	}
-	return dbUser.ToTFE(), nil
+	updated := dbUser.ToTFE()
+	s.auditUser(ctx, id, models.AuditActionUpdate, existing.ToTFE(), updated)
+	return updated, nil
+}
+
+// userUpdates returns the columns a user update request sets. Attributes
+// left out of the request are not written, and only site admins may change
+// the admin flags.
+func userUpdates(user *tfe.User, siteAdmin bool) map[string]interface{} {
+	updates := map[string]interface{}{}
+	if user.Username != "" {
+		updates["username"] = user.Username
+	}
+	if user.Email != "" {
+		updates["email"] = user.Email
+	}
+	if user.AvatarURL != "" {
+		updates["avatar_url"] = user.AvatarURL
+	}
+	if !siteAdmin {
+		return updates
+	}
+	if user.IsSiteAdmin != nil {
+		updates["is_site_admin"] = *user.IsSiteAdmin
+	}
+	if user.IsAdmin != nil {
+		updates["is_admin"] = *user.IsAdmin
+	}
+	return updates
 }
 
 func (s *service) DeleteUser(ctx context.Context, userID string) error {
+	if err := s.authorizeSiteAdmin(ctx); err != nil {
+		return err
+	}
 	id, err := uuid.Parse(userID)
 	if err != nil {
-		return err
+		return gorm.ErrRecordNotFound
 	}
 
+	var user models.User
+	if err := s.db.Where("id = ?", id).First(&user).Error; err != nil {
+		s.logger.Error("failed to read user", zap.Error(err))
+		return err
+	}
 	if err := s.db.Where("id = ?", id).Delete(&models.User{}).Error; err != nil {
 		s.logger.Error("failed to delete user", zap.Error(err))
 		return err
 	}
+	s.auditUser(ctx, id, models.AuditActionDelete, user.ToTFE(), nil)
 	return nil
 }
 
 func (s *service) ReadCurrentUser(ctx context.Context) (*tfe.User, error) {
-	// Get the email from the context
-	email, ok := ctx.Value(constants.UserEmailKey).(string)
-	if !ok || email == "" {
-		s.logger.Error("user email not found in context")
-		return nil, fmt.Errorf("user email not found in context")
-	}
-
-	var user models.User
-	if err := s.db.Where("email = ?", email).First(&user).Error; err != nil {
-		s.logger.Error("failed to read current user", zap.Error(err), zap.String("email", email))
+	user, err := s.currentUser(ctx)
+	if err != nil {
 		return nil, err
 	}
 	user.Permissions = &models.UserPermissions{
@@ -110,3 +174,36 @@ func (s *service) ReadCurrentUser(ctx context.Context) (*tfe.User, error) {
 	s.logger.Debug("converting current user to TFE user", zap.Any("user", user))
 	return user.ToTFE(), nil
 }
+
+// currentUser loads the authenticated user identified by the request context.
+func (s *service) currentUser(ctx context.Context) (*models.User, error) {
+	if tokenPrincipalFromContext(ctx) != nil {
+		return nil, fmt.Errorf("%w: this operation requires a user token", ErrForbidden)
+	}
+	email, ok := ctx.Value(constants.UserEmailKey).(string)
+	if !ok || email == "" {
+		s.logger.Error("user email not found in context")
+		return nil, fmt.Errorf("user email not found in context")
+	}
+
+	var user models.User
+	if err := s.db.Where("email = ?", email).First(&user).Error; err != nil {
+		s.logger.Error("failed to read current user", zap.Error(err), zap.String("email", email))
+		return nil, err
+	}
+	return &user, nil
+}
+
+// currentUserID returns the ID of the authenticated user for recording who
+// created a resource, or nil for the system and for team, organization and
+// audit trail tokens.
+func (s *service) currentUserID(ctx context.Context) (*uuid.UUID, error) {
+	if isSystemContext(ctx) || tokenPrincipalFromContext(ctx) != nil {
+		return nil, nil
+	}
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &user.ID, nil
+}
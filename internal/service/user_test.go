@@ -0,0 +1,40 @@
+package service
+
+import (
+	"testing"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+func TestUserUpdates(t *testing.T) {
+	yes, no := true, false
+
+	t.Run("SiteAdminUpdatesOwnProfile", func(t *testing.T) {
+		updates := userUpdates(&tfe.User{Username: "admin", Email: "admin@example.com"}, true)
+		if _, ok := updates["is_site_admin"]; ok {
+			t.Errorf("profile update writes is_site_admin: %v", updates)
+		}
+		for _, column := range []string{"is_admin", "last_login_at", "unconfirmed_email", "password"} {
+			if _, ok := updates[column]; ok {
+				t.Errorf("profile update writes %s: %v", column, updates)
+			}
+		}
+		if updates["username"] != "admin" || updates["email"] != "admin@example.com" {
+			t.Errorf("profile update = %v, want username and email", updates)
+		}
+	})
+
+	t.Run("SiteAdminChangesFlags", func(t *testing.T) {
+		updates := userUpdates(&tfe.User{IsSiteAdmin: &no, IsAdmin: &yes}, true)
+		if updates["is_site_admin"] != false || updates["is_admin"] != true {
+			t.Errorf("admin update = %v, want both flags", updates)
+		}
+	})
+
+	t.Run("UserCannotChangeFlags", func(t *testing.T) {
+		updates := userUpdates(&tfe.User{Username: "user", IsSiteAdmin: &yes, IsAdmin: &yes}, false)
+		if len(updates) != 1 || updates["username"] != "user" {
+			t.Errorf("user update = %v, want only username", updates)
+		}
+	})
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	terraformVariableKeyRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
+	envVariableKeyRegexp       = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+func (s *service) ListVariables(ctx context.Context, workspaceID string, options *tfe.VariableListOptions) ([]*tfe.Variable, *tfe.Pagination, error) {
+	ws, err := s.findWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, ws, wsReadVariables); err != nil {
+		return nil, nil, err
+	}
+
+	var listOptions tfe.ListOptions
+	if options != nil {
+		listOptions = options.ListOptions
+	}
+	db, pagination, err := paginate(s.db.Model(&models.Variable{}).Where("workspace_id = ?", ws.ID), listOptions)
+	if err != nil {
+		s.logger.Error("failed to count variables", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var variables []*models.Variable
+	if err := db.Order("key ASC").Find(&variables).Error; err != nil {
+		s.logger.Error("failed to list variables", zap.Error(err))
+		return nil, nil, err
+	}
+
+	tfeVariables := make([]*tfe.Variable, len(variables))
+	for i, v := range variables {
+		tfeVariables[i] = v.ToTFE()
+	}
+	return tfeVariables, pagination, nil
+}
+
+func (s *service) CreateVariable(ctx context.Context, workspaceID string, options tfe.VariableCreateOptions) (*tfe.Variable, error) {
+	ws, err := s.findWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, ws, wsWriteVariables); err != nil {
+		return nil, err
+	}
+	if options.Key == nil || options.Category == nil {
+		return nil, fmt.Errorf("%w: key and category are required", ErrInvalidAttribute)
+	}
+
+	v := &models.Variable{
+		Key:         *options.Key,
+		Category:    string(*options.Category),
+		WorkspaceID: ws.ID,
+	}
+	if options.Description != nil {
+		v.Description = *options.Description
+	}
+	if options.HCL != nil {
+		v.HCL = *options.HCL
+	}
+	if options.Sensitive != nil {
+		v.Sensitive = *options.Sensitive
+	}
+	var value string
+	if options.Value != nil {
+		value = *options.Value
+	}
+	if err := s.setVariableValue(v, value); err != nil {
+		return nil, err
+	}
+	if err := s.validateVariable(v); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(v).Error; err != nil {
+		s.logger.Error("failed to create variable", zap.Error(err))
+		return nil, err
+	}
+	created := v.ToTFE()
+	s.audit(ctx, ws.OrganizationID, models.AuditActionCreate, nil, created)
+	return created, nil
+}
+
+func (s *service) ReadVariable(ctx context.Context, workspaceID, variableID string) (*tfe.Variable, error) {
+	v, err := s.findVariable(workspaceID, variableID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeWorkspaceID(ctx, v.WorkspaceID, wsReadVariables); err != nil {
+		return nil, err
+	}
+	return v.ToTFE(), nil
+}
+
+func (s *service) UpdateVariable(ctx context.Context, workspaceID, variableID string, options tfe.VariableUpdateOptions) (*tfe.Variable, error) {
+	v, err := s.findVariable(workspaceID, variableID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeWorkspaceID(ctx, v.WorkspaceID, wsWriteVariables); err != nil {
+		return nil, err
+	}
+	before := v.ToTFE()
+
+	if options.Key != nil {
+		v.Key = *options.Key
+	}
+	if options.Category != nil {
+		v.Category = string(*options.Category)
+	}
+	if options.Description != nil {
+		v.Description = *options.Description
+	}
+	if options.HCL != nil {
+		v.HCL = *options.HCL
+	}
+	if options.Sensitive != nil {
+		if v.Sensitive && !*options.Sensitive {
+			return nil, fmt.Errorf("%w: a sensitive variable cannot be made non-sensitive", ErrInvalidAttribute)
+		}
+		if *options.Sensitive && !v.Sensitive {
+			// Move the existing value into encrypted storage.
+			v.Sensitive = true
+			if err := s.setVariableValue(v, v.Value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if options.Value != nil {
+		if err := s.setVariableValue(v, *options.Value); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.validateVariable(v); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Save(v).Error; err != nil {
+		s.logger.Error("failed to update variable", zap.Error(err))
+		return nil, err
+	}
+	updated := v.ToTFE()
+	s.auditWorkspace(ctx, v.WorkspaceID, models.AuditActionUpdate, before, updated)
+	return updated, nil
+}
+
+func (s *service) DeleteVariable(ctx context.Context, workspaceID, variableID string) error {
+	v, err := s.findVariable(workspaceID, variableID)
+	if err != nil {
+		return err
+	}
+	if err := s.authorizeWorkspaceID(ctx, v.WorkspaceID, wsWriteVariables); err != nil {
+		return err
+	}
+	if err := s.db.Delete(v).Error; err != nil {
+		s.logger.Error("failed to delete variable", zap.Error(err))
+		return err
+	}
+	s.auditWorkspace(ctx, v.WorkspaceID, models.AuditActionDelete, v.ToTFE(), nil)
+	return nil
+}
+
+// setVariableValue stores value in plaintext or, for sensitive variables,
+// encrypted.
+func (s *service) setVariableValue(v *models.Variable, value string) error {
+	plain, encrypted, err := s.sealVariableValue(v.Sensitive, value)
+	if err != nil {
+		return err
+	}
+	v.Value, v.EncryptedValue = plain, encrypted
+	return nil
+}
+
+// sealVariableValue returns the plaintext and encrypted columns for a
+// variable value. Exactly one of them is set for a non-empty value.
+func (s *service) sealVariableValue(sensitive bool, value string) (plain, encrypted string, err error) {
+	if !sensitive {
+		return value, "", nil
+	}
+	encrypted, err = s.secrets.Encrypt(value)
+	if err != nil {
+		s.logger.Error("failed to encrypt variable", zap.Error(err))
+		return "", "", err
+	}
+	return "", encrypted, nil
+}
+
+func (s *service) validateVariable(v *models.Variable) error {
+	if err := validateVariableKey(v.Category, v.Key); err != nil {
+		return err
+	}
+
+	var existing models.Variable
+	err := s.db.Where("workspace_id = ? AND key = ? AND category = ? AND id <> ?", v.WorkspaceID, v.Key, v.Category, v.ID).First(&existing).Error
+	switch {
+	case err == nil:
+		return fmt.Errorf("%w: key %q has already been taken", ErrInvalidAttribute, v.Key)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		s.logger.Error("failed to check variable key", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func validateVariableKey(category, key string) error {
+	switch tfe.CategoryType(category) {
+	case tfe.CategoryTerraform:
+		if !terraformVariableKeyRegexp.MatchString(key) {
+			return fmt.Errorf("%w: %q is not a valid terraform variable name", ErrInvalidAttribute, key)
+		}
+	case tfe.CategoryEnv:
+		if !envVariableKeyRegexp.MatchString(key) {
+			return fmt.Errorf("%w: %q is not a valid environment variable name", ErrInvalidAttribute, key)
+		}
+	default:
+		return fmt.Errorf("%w: category must be terraform or env", ErrInvalidAttribute)
+	}
+	return nil
+}
+
+func (s *service) findVariable(workspaceID, variableID string) (*models.Variable, error) {
+	wsID, err := uuid.Parse(workspaceID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	id, err := uuid.Parse(variableID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var v models.Variable
+	if err := s.db.Where("id = ? AND workspace_id = ?", id, wsID).First(&v).Error; err != nil {
+		s.logger.Error("failed to read variable", zap.Error(err))
+		return nil, err
+	}
+	return &v, nil
+}
@@ -0,0 +1,609 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (s *service) ListVariableSets(ctx context.Context, organization string, options *tfe.VariableSetListOptions) ([]*tfe.VariableSet, *tfe.Pagination, error) {
+	role, err := s.authorizeOrganizationName(ctx, organization, orgRead)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.listVariableSets(s.db.Model(&models.VariableSet{}).Where("organization_id = ?", role.orgID), options)
+}
+
+// ListWorkspaceVariableSets lists the variable sets that apply to a
+// workspace: global sets and sets attached to the workspace or its project.
+func (s *service) ListWorkspaceVariableSets(ctx context.Context, workspaceID string, options *tfe.VariableSetListOptions) ([]*tfe.VariableSet, *tfe.Pagination, error) {
+	ws, err := s.findWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, ws, wsReadVariables); err != nil {
+		return nil, nil, err
+	}
+	return s.listVariableSets(s.applicableVariableSets(ws), options)
+}
+
+// ListProjectVariableSets lists the variable sets attached to a project.
+func (s *service) ListProjectVariableSets(ctx context.Context, projectID string, options *tfe.VariableSetListOptions) ([]*tfe.VariableSet, *tfe.Pagination, error) {
+	project, err := s.findProject(projectID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.authorizeProject(ctx, project, projectRead); err != nil {
+		return nil, nil, err
+	}
+	db := s.db.Model(&models.VariableSet{}).
+		Where("id IN (?)", s.db.Model(&models.VariableSetProject{}).Select("variable_set_id").Where("project_id = ?", project.ID))
+	return s.listVariableSets(db, options)
+}
+
+func (s *service) CreateVariableSet(ctx context.Context, organization string, options tfe.VariableSetCreateOptions) (*tfe.VariableSet, error) {
+	role, err := s.authorizeOrganizationName(ctx, organization, orgManageWorkspaces)
+	if err != nil {
+		return nil, err
+	}
+	if options.Name == nil || *options.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidAttribute)
+	}
+
+	set := &models.VariableSet{
+		Name:           *options.Name,
+		OrganizationID: role.orgID,
+	}
+	if options.Description != nil {
+		set.Description = *options.Description
+	}
+	if options.Global != nil {
+		set.Global = *options.Global
+	}
+	if options.Priority != nil {
+		set.Priority = *options.Priority
+	}
+	if err := s.validateVariableSet(set); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Omit(clause.Associations).Create(set).Error; err != nil {
+		s.logger.Error("failed to create variable set", zap.Error(err))
+		return nil, err
+	}
+	created, err := s.ReadVariableSet(ctx, set.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, role.orgID, models.AuditActionCreate, nil, created)
+	return created, nil
+}
+
+func (s *service) ReadVariableSet(ctx context.Context, variableSetID string) (*tfe.VariableSet, error) {
+	set, err := s.findAuthorizedVariableSet(ctx, variableSetID, orgRead)
+	if err != nil {
+		return nil, err
+	}
+	return set.ToTFE(), nil
+}
+
+func (s *service) UpdateVariableSet(ctx context.Context, variableSetID string, options tfe.VariableSetUpdateOptions) (*tfe.VariableSet, error) {
+	set, err := s.findAuthorizedVariableSet(ctx, variableSetID, orgManageWorkspaces)
+	if err != nil {
+		return nil, err
+	}
+	before := set.ToTFE()
+
+	if options.Name != nil {
+		set.Name = *options.Name
+	}
+	if options.Description != nil {
+		set.Description = *options.Description
+	}
+	if options.Global != nil {
+		set.Global = *options.Global
+	}
+	if options.Priority != nil {
+		set.Priority = *options.Priority
+	}
+	if err := s.validateVariableSet(set); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(set).Error; err != nil {
+			return err
+		}
+		if set.Global {
+			// Global sets apply everywhere, so attachments are meaningless.
+			if err := tx.Where("variable_set_id = ?", set.ID).Delete(&models.VariableSetWorkspace{}).Error; err != nil {
+				return err
+			}
+			return tx.Where("variable_set_id = ?", set.ID).Delete(&models.VariableSetProject{}).Error
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to update variable set", zap.Error(err))
+		return nil, err
+	}
+	return s.auditVariableSet(ctx, set, before)
+}
+
+func (s *service) DeleteVariableSet(ctx context.Context, variableSetID string) error {
+	set, err := s.findAuthorizedVariableSet(ctx, variableSetID, orgManageWorkspaces)
+	if err != nil {
+		return err
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&models.VariableSetWorkspace{}, &models.VariableSetProject{}, &models.VariableSetVariable{}} {
+			if err := tx.Where("variable_set_id = ?", set.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("id = ?", set.ID).Delete(&models.VariableSet{}).Error
+	})
+	if err != nil {
+		s.logger.Error("failed to delete variable set", zap.Error(err))
+		return err
+	}
+	s.audit(ctx, set.OrganizationID, models.AuditActionDelete, set.ToTFE(), nil)
+	return nil
+}
+
+// ApplyVariableSetToWorkspaces attaches a non-global variable set to workspaces of its organization.
+func (s *service) ApplyVariableSetToWorkspaces(ctx context.Context, variableSetID string, workspaceIDs []string) error {
+	set, err := s.findAttachableVariableSet(ctx, variableSetID)
+	if err != nil {
+		return err
+	}
+	ids, err := s.variableSetTargets(&models.Workspace{}, set, workspaceIDs)
+	if err != nil {
+		return err
+	}
+
+	attachments := make([]*models.VariableSetWorkspace, len(ids))
+	for i, id := range ids {
+		attachments[i] = &models.VariableSetWorkspace{VariableSetID: set.ID, WorkspaceID: id}
+	}
+	before := set.ToTFE()
+	if err := s.attach(attachments); err != nil {
+		return err
+	}
+	_, err = s.auditVariableSet(ctx, set, before)
+	return err
+}
+
+// RemoveVariableSetFromWorkspaces detaches a variable set from workspaces.
+func (s *service) RemoveVariableSetFromWorkspaces(ctx context.Context, variableSetID string, workspaceIDs []string) error {
+	set, err := s.findAttachableVariableSet(ctx, variableSetID)
+	if err != nil {
+		return err
+	}
+	before := set.ToTFE()
+	if err := s.detach(&models.VariableSetWorkspace{}, "workspace_id", set, workspaceIDs); err != nil {
+		return err
+	}
+	_, err = s.auditVariableSet(ctx, set, before)
+	return err
+}
+
+// ReplaceVariableSetWorkspaces makes a variable set apply to exactly the
+// given workspaces, turning a global set into an attached one.
+func (s *service) ReplaceVariableSetWorkspaces(ctx context.Context, variableSetID string, workspaceIDs []string) (*tfe.VariableSet, error) {
+	set, err := s.findAuthorizedVariableSet(ctx, variableSetID, orgManageWorkspaces)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := s.variableSetTargets(&models.Workspace{}, set, workspaceIDs)
+	if err != nil {
+		return nil, err
+	}
+	before := set.ToTFE()
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(set).Omit(clause.Associations).Update("global", false).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("variable_set_id = ?", set.ID).Delete(&models.VariableSetWorkspace{}).Error; err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := tx.Create(&models.VariableSetWorkspace{VariableSetID: set.ID, WorkspaceID: id}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to update variable set workspaces", zap.Error(err))
+		return nil, err
+	}
+	return s.auditVariableSet(ctx, set, before)
+}
+
+// ApplyVariableSetToProjects attaches a non-global variable set to projects of its organization.
+func (s *service) ApplyVariableSetToProjects(ctx context.Context, variableSetID string, projectIDs []string) error {
+	set, err := s.findAttachableVariableSet(ctx, variableSetID)
+	if err != nil {
+		return err
+	}
+	ids, err := s.variableSetTargets(&models.Project{}, set, projectIDs)
+	if err != nil {
+		return err
+	}
+
+	attachments := make([]*models.VariableSetProject, len(ids))
+	for i, id := range ids {
+		attachments[i] = &models.VariableSetProject{VariableSetID: set.ID, ProjectID: id}
+	}
+	before := set.ToTFE()
+	if err := s.attach(attachments); err != nil {
+		return err
+	}
+	_, err = s.auditVariableSet(ctx, set, before)
+	return err
+}
+
+// RemoveVariableSetFromProjects detaches a variable set from projects.
+func (s *service) RemoveVariableSetFromProjects(ctx context.Context, variableSetID string, projectIDs []string) error {
+	set, err := s.findAttachableVariableSet(ctx, variableSetID)
+	if err != nil {
+		return err
+	}
+	before := set.ToTFE()
+	if err := s.detach(&models.VariableSetProject{}, "project_id", set, projectIDs); err != nil {
+		return err
+	}
+	_, err = s.auditVariableSet(ctx, set, before)
+	return err
+}
+
+func (s *service) ListVariableSetVariables(ctx context.Context, variableSetID string, options *tfe.VariableSetVariableListOptions) ([]*tfe.VariableSetVariable, *tfe.Pagination, error) {
+	set, err := s.findAuthorizedVariableSet(ctx, variableSetID, orgRead)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var listOptions tfe.ListOptions
+	if options != nil {
+		listOptions = options.ListOptions
+	}
+	db, pagination, err := paginate(s.db.Model(&models.VariableSetVariable{}).Where("variable_set_id = ?", set.ID), listOptions)
+	if err != nil {
+		s.logger.Error("failed to count variable set variables", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var variables []*models.VariableSetVariable
+	if err := db.Order("key ASC").Find(&variables).Error; err != nil {
+		s.logger.Error("failed to list variable set variables", zap.Error(err))
+		return nil, nil, err
+	}
+
+	tfeVariables := make([]*tfe.VariableSetVariable, len(variables))
+	for i, v := range variables {
+		tfeVariables[i] = v.ToTFE()
+	}
+	return tfeVariables, pagination, nil
+}
+
+func (s *service) CreateVariableSetVariable(ctx context.Context, variableSetID string, options tfe.VariableSetVariableCreateOptions) (*tfe.VariableSetVariable, error) {
+	set, err := s.findAuthorizedVariableSet(ctx, variableSetID, orgManageWorkspaces)
+	if err != nil {
+		return nil, err
+	}
+	if options.Key == nil || options.Category == nil {
+		return nil, fmt.Errorf("%w: key and category are required", ErrInvalidAttribute)
+	}
+
+	v := &models.VariableSetVariable{
+		Key:           *options.Key,
+		Category:      string(*options.Category),
+		VariableSetID: set.ID,
+	}
+	if options.Description != nil {
+		v.Description = *options.Description
+	}
+	if options.HCL != nil {
+		v.HCL = *options.HCL
+	}
+	if options.Sensitive != nil {
+		v.Sensitive = *options.Sensitive
+	}
+	var value string
+	if options.Value != nil {
+		value = *options.Value
+	}
+	if v.Value, v.EncryptedValue, err = s.sealVariableValue(v.Sensitive, value); err != nil {
+		return nil, err
+	}
+	if err := s.validateVariableSetVariable(v); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(v).Error; err != nil {
+		s.logger.Error("failed to create variable set variable", zap.Error(err))
+		return nil, err
+	}
+	created := v.ToTFE()
+	s.audit(ctx, set.OrganizationID, models.AuditActionCreate, nil, created)
+	return created, nil
+}
+
+func (s *service) ReadVariableSetVariable(ctx context.Context, variableSetID, variableID string) (*tfe.VariableSetVariable, error) {
+	if _, err := s.findAuthorizedVariableSet(ctx, variableSetID, orgRead); err != nil {
+		return nil, err
+	}
+	v, err := s.findVariableSetVariable(variableSetID, variableID)
+	if err != nil {
+		return nil, err
+	}
+	return v.ToTFE(), nil
+}
+
+func (s *service) UpdateVariableSetVariable(ctx context.Context, variableSetID, variableID string, options tfe.VariableSetVariableUpdateOptions) (*tfe.VariableSetVariable, error) {
+	set, err := s.findAuthorizedVariableSet(ctx, variableSetID, orgManageWorkspaces)
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.findVariableSetVariable(variableSetID, variableID)
+	if err != nil {
+		return nil, err
+	}
+	before := v.ToTFE()
+
+	if options.Key != nil {
+		v.Key = *options.Key
+	}
+	if options.Description != nil {
+		v.Description = *options.Description
+	}
+	if options.HCL != nil {
+		v.HCL = *options.HCL
+	}
+	if options.Sensitive != nil {
+		if v.Sensitive && !*options.Sensitive {
+			return nil, fmt.Errorf("%w: a sensitive variable cannot be made non-sensitive", ErrInvalidAttribute)
+		}
+		if *options.Sensitive && !v.Sensitive {
+			v.Sensitive = true
+			if v.Value, v.EncryptedValue, err = s.sealVariableValue(true, v.Value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if options.Value != nil {
+		if v.Value, v.EncryptedValue, err = s.sealVariableValue(v.Sensitive, *options.Value); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.validateVariableSetVariable(v); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Save(v).Error; err != nil {
+		s.logger.Error("failed to update variable set variable", zap.Error(err))
+		return nil, err
+	}
+	updated := v.ToTFE()
+	s.audit(ctx, set.OrganizationID, models.AuditActionUpdate, before, updated)
+	return updated, nil
+}
+
+func (s *service) DeleteVariableSetVariable(ctx context.Context, variableSetID, variableID string) error {
+	set, err := s.findAuthorizedVariableSet(ctx, variableSetID, orgManageWorkspaces)
+	if err != nil {
+		return err
+	}
+	v, err := s.findVariableSetVariable(variableSetID, variableID)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(v).Error; err != nil {
+		s.logger.Error("failed to delete variable set variable", zap.Error(err))
+		return err
+	}
+	s.audit(ctx, set.OrganizationID, models.AuditActionDelete, v.ToTFE(), nil)
+	return nil
+}
+
+func (s *service) listVariableSets(db *gorm.DB, options *tfe.VariableSetListOptions) ([]*tfe.VariableSet, *tfe.Pagination, error) {
+	var listOptions tfe.ListOptions
+	if options != nil {
+		listOptions = options.ListOptions
+		if options.Query != "" {
+			db = db.Where("name ILIKE ?", "%"+options.Query+"%")
+		}
+	}
+
+	db, pagination, err := paginate(db, listOptions)
+	if err != nil {
+		s.logger.Error("failed to count variable sets", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var sets []*models.VariableSet
+	if err := preloadVariableSet(db).Order("name ASC").Find(&sets).Error; err != nil {
+		s.logger.Error("failed to list variable sets", zap.Error(err))
+		return nil, nil, err
+	}
+
+	tfeSets := make([]*tfe.VariableSet, len(sets))
+	for i, set := range sets {
+		tfeSets[i] = set.ToTFE()
+	}
+	return tfeSets, pagination, nil
+}
+
+// applicableVariableSets scopes a query to the variable sets that apply to a workspace.
+func (s *service) applicableVariableSets(ws *models.Workspace) *gorm.DB {
+	return s.db.Model(&models.VariableSet{}).
+		Where("organization_id = ?", ws.OrganizationID).
+		Where("global OR id IN (?) OR id IN (?)",
+			s.db.Model(&models.VariableSetWorkspace{}).Select("variable_set_id").Where("workspace_id = ?", ws.ID),
+			s.db.Model(&models.VariableSetProject{}).Select("variable_set_id").Where("project_id = ?", ws.ProjectID),
+		)
+}
+
+// variableSetTargets resolves workspace or project IDs and checks that they
+// belong to the organization of the set.
+func (s *service) variableSetTargets(model interface{}, set *models.VariableSet, ids []string) ([]uuid.UUID, error) {
+	parsed := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		u, err := uuid.Parse(id)
+		if err != nil {
+			return nil, gorm.ErrRecordNotFound
+		}
+		parsed = append(parsed, u)
+	}
+
+	var count int64
+	if err := s.db.Model(model).Where("id IN ? AND organization_id = ?", parsed, set.OrganizationID).Count(&count).Error; err != nil {
+		s.logger.Error("failed to check variable set targets", zap.Error(err))
+		return nil, err
+	}
+	if int(count) != len(parsed) {
+		return nil, fmt.Errorf("%w: every target must exist in the variable set's organization", ErrInvalidAttribute)
+	}
+	return parsed, nil
+}
+
+// auditVariableSet records an update of a variable set and returns the set
+// as it is now.
+func (s *service) auditVariableSet(ctx context.Context, set *models.VariableSet, before *tfe.VariableSet) (*tfe.VariableSet, error) {
+	updated, err := s.ReadVariableSet(ctx, set.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, set.OrganizationID, models.AuditActionUpdate, before, updated)
+	return updated, nil
+}
+
+func (s *service) attach(attachments interface{}) error {
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(attachments).Error; err != nil {
+		s.logger.Error("failed to attach variable set", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (s *service) detach(model interface{}, column string, set *models.VariableSet, ids []string) error {
+	parsed := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if u, err := uuid.Parse(id); err == nil {
+			parsed = append(parsed, u)
+		}
+	}
+	if len(parsed) == 0 {
+		return nil
+	}
+	if err := s.db.Where("variable_set_id = ? AND "+column+" IN ?", set.ID, parsed).Delete(model).Error; err != nil {
+		s.logger.Error("failed to detach variable set", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (s *service) validateVariableSet(set *models.VariableSet) error {
+	if set.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidAttribute)
+	}
+
+	var existing models.VariableSet
+	err := s.db.Where("organization_id = ? AND name = ? AND id <> ?", set.OrganizationID, set.Name, set.ID).First(&existing).Error
+	switch {
+	case err == nil:
+		return fmt.Errorf("%w: name %q has already been taken", ErrInvalidAttribute, set.Name)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		s.logger.Error("failed to check variable set name", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (s *service) validateVariableSetVariable(v *models.VariableSetVariable) error {
+	if err := validateVariableKey(v.Category, v.Key); err != nil {
+		return err
+	}
+
+	var existing models.VariableSetVariable
+	err := s.db.Where("variable_set_id = ? AND key = ? AND category = ? AND id <> ?", v.VariableSetID, v.Key, v.Category, v.ID).First(&existing).Error
+	switch {
+	case err == nil:
+		return fmt.Errorf("%w: key %q has already been taken", ErrInvalidAttribute, v.Key)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		s.logger.Error("failed to check variable key", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (s *service) findVariableSet(variableSetID string) (*models.VariableSet, error) {
+	id, err := uuid.Parse(variableSetID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var set models.VariableSet
+	if err := preloadVariableSet(s.db).Where("id = ?", id).First(&set).Error; err != nil {
+		s.logger.Error("failed to read variable set", zap.Error(err))
+		return nil, err
+	}
+	return &set, nil
+}
+
+// findAuthorizedVariableSet finds a variable set and requires the caller to
+// hold required in its organization.
+func (s *service) findAuthorizedVariableSet(ctx context.Context, variableSetID string, required organizationPermission) (*models.VariableSet, error) {
+	set, err := s.findVariableSet(variableSetID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.authorizeOrganization(ctx, set.OrganizationID, required); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// findAttachableVariableSet finds a variable set that may be attached to or
+// detached from workspaces and projects.
+func (s *service) findAttachableVariableSet(ctx context.Context, variableSetID string) (*models.VariableSet, error) {
+	set, err := s.findAuthorizedVariableSet(ctx, variableSetID, orgManageWorkspaces)
+	if err != nil {
+		return nil, err
+	}
+	if set.Global {
+		return nil, fmt.Errorf("%w: global variable sets apply to every workspace", ErrInvalidAttribute)
+	}
+	return set, nil
+}
+
+func (s *service) findVariableSetVariable(variableSetID, variableID string) (*models.VariableSetVariable, error) {
+	setID, err := uuid.Parse(variableSetID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	id, err := uuid.Parse(variableID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var v models.VariableSetVariable
+	if err := s.db.Where("id = ? AND variable_set_id = ?", id, setID).First(&v).Error; err != nil {
+		s.logger.Error("failed to read variable set variable", zap.Error(err))
+		return nil, err
+	}
+	return &v, nil
+}
+
+func preloadVariableSet(db *gorm.DB) *gorm.DB {
+	return db.Preload("Organization").Preload("Workspaces").Preload("Projects").Preload("Variables")
+}
@@ -0,0 +1,401 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var workspaceNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9\-_]+$`)
+
+// workspaceLockColumns are only written through the locking methods so that
+// settings updates can never clobber a concurrently acquired lock.
+var workspaceLockColumns = []string{"locked", "lock_reason", "locked_at", "locked_by_user_id", "locked_by_token_id", "locked_by_run_id"}
+
+// ListWorkspaces lists the workspaces of an organization the caller may read.
+func (s *service) ListWorkspaces(ctx context.Context, organization string, options *tfe.WorkspaceListOptions) ([]*tfe.Workspace, *tfe.Pagination, error) {
+	role, err := s.authorizeOrganizationName(ctx, organization, orgRead)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db := s.readableWorkspaces(s.db.Model(&models.Workspace{}).Where("organization_id = ?", role.orgID), role)
+	var listOptions tfe.ListOptions
+	if options != nil {
+		listOptions = options.ListOptions
+		if options.Search != "" {
+			db = db.Where("name ILIKE ?", "%"+options.Search+"%")
+		}
+		if options.WildcardName != "" {
+			db = db.Where("name ILIKE ?", wildcardPattern(options.WildcardName))
+		}
+		if options.ProjectID != "" {
+			db = db.Where("project_id = ?", options.ProjectID)
+		}
+	}
+
+	db, pagination, err := paginate(db, listOptions)
+	if err != nil {
+		s.logger.Error("failed to count workspaces", zap.Error(err))
+		return nil, nil, err
+	}
+
+	var workspaces []*models.Workspace
+	if err := db.Preload("Organization").Preload("Project").Preload("LockedByUser").Preload("LockedByToken.Team").Order("name ASC").Find(&workspaces).Error; err != nil {
+		s.logger.Error("failed to list workspaces", zap.Error(err))
+		return nil, nil, err
+	}
+
+	tfeWorkspaces := make([]*tfe.Workspace, len(workspaces))
+	for i, ws := range workspaces {
+		permissions, err := s.workspacePermissions(role, ws)
+		if err != nil {
+			return nil, nil, err
+		}
+		tfeWorkspaces[i] = ws.ToTFE()
+		tfeWorkspaces[i].Permissions = workspacePermissionsToTFE(permissions)
+	}
+	return tfeWorkspaces, pagination, nil
+}
+
+func (s *service) CreateWorkspace(ctx context.Context, organization string, options tfe.WorkspaceCreateOptions) (*tfe.Workspace, error) {
+	role, err := s.authorizeOrganizationName(ctx, organization, orgRead)
+	if err != nil {
+		return nil, err
+	}
+
+	ws := models.FromTFEWorkspaceCreateOptions(options)
+	ws.OrganizationID = role.orgID
+	if options.ExecutionMode == nil && options.Operations == nil {
+		if err := s.applyOrganizationExecutionDefaults(ws); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.assignWorkspaceAgentPool(ws, options.AgentPoolID); err != nil {
+		return nil, err
+	}
+	if err := validateWorkspace(ws); err != nil {
+		return nil, err
+	}
+
+	var projectID string
+	if options.Project != nil {
+		projectID = options.Project.ID
+	}
+	if err := s.assignWorkspaceProject(ws, projectID); err != nil {
+		return nil, err
+	}
+	if err := s.authorizeWorkspaceCreation(role, ws.ProjectID); err != nil {
+		return nil, err
+	}
+
+	s.logger.Debug("creating workspace", zap.String("organization", organization), zap.String("name", ws.Name))
+	if err := s.db.Create(ws).Error; err != nil {
+		s.logger.Error("failed to create workspace", zap.Error(err))
+		return nil, err
+	}
+
+	created, err := s.ReadWorkspaceByID(ctx, ws.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, role.orgID, models.AuditActionCreate, nil, created)
+	return created, nil
+}
+
+func (s *service) ReadWorkspace(ctx context.Context, organization, workspace string) (*tfe.Workspace, error) {
+	ws, err := s.findWorkspace(ctx, organization, workspace)
+	if err != nil {
+		return nil, err
+	}
+	return s.readWorkspace(ctx, ws)
+}
+
+func (s *service) ReadWorkspaceByID(ctx context.Context, workspaceID string) (*tfe.Workspace, error) {
+	ws, err := s.findWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.readWorkspace(ctx, ws)
+}
+
+func (s *service) UpdateWorkspace(ctx context.Context, organization, workspace string, options tfe.WorkspaceUpdateOptions) (*tfe.Workspace, error) {
+	ws, err := s.findWorkspace(ctx, organization, workspace)
+	if err != nil {
+		return nil, err
+	}
+	return s.updateWorkspace(ctx, ws, options)
+}
+
+func (s *service) UpdateWorkspaceByID(ctx context.Context, workspaceID string, options tfe.WorkspaceUpdateOptions) (*tfe.Workspace, error) {
+	ws, err := s.findWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.updateWorkspace(ctx, ws, options)
+}
+
+func (s *service) DeleteWorkspace(ctx context.Context, organization, workspace string) error {
+	ws, err := s.findWorkspace(ctx, organization, workspace)
+	if err != nil {
+		return err
+	}
+	return s.deleteWorkspace(ctx, ws, false)
+}
+
+func (s *service) DeleteWorkspaceByID(ctx context.Context, workspaceID string) error {
+	ws, err := s.findWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+	return s.deleteWorkspace(ctx, ws, false)
+}
+
+// SafeDeleteWorkspace deletes a workspace only if its current state does
+// not manage any resources.
+func (s *service) SafeDeleteWorkspace(ctx context.Context, organization, workspace string) error {
+	ws, err := s.findWorkspace(ctx, organization, workspace)
+	if err != nil {
+		return err
+	}
+	return s.deleteWorkspace(ctx, ws, true)
+}
+
+func (s *service) SafeDeleteWorkspaceByID(ctx context.Context, workspaceID string) error {
+	ws, err := s.findWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+	return s.deleteWorkspace(ctx, ws, true)
+}
+
+// wildcardPattern translates a search[wildcard-name] filter, in which *
+// matches any characters, into a LIKE pattern. Without a * the name must
+// match exactly.
+func wildcardPattern(name string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(name)
+	return strings.ReplaceAll(escaped, "*", "%")
+}
+
+func (s *service) readWorkspace(ctx context.Context, ws *models.Workspace) (*tfe.Workspace, error) {
+	permissions, err := s.authorizeWorkspace(ctx, ws, wsRead)
+	if err != nil {
+		return nil, err
+	}
+	tfeWS := ws.ToTFE()
+	tfeWS.Permissions = workspacePermissionsToTFE(permissions)
+	return tfeWS, nil
+}
+
+// updateWorkspace requires admin access to change settings. Moving the
+// workspace to another project requires the permission to move it and to
+// create workspaces in the destination.
+func (s *service) updateWorkspace(ctx context.Context, ws *models.Workspace, options tfe.WorkspaceUpdateOptions) (*tfe.Workspace, error) {
+	moving := options.Project != nil && options.Project.ID != "" && options.Project.ID != ws.ProjectID.String()
+	settings := options
+	settings.Type, settings.Project = "", nil
+
+	required := workspacePermission(wsAdmin)
+	if moving {
+		required = wsMove
+		if !reflect.DeepEqual(settings, tfe.WorkspaceUpdateOptions{}) {
+			required |= wsAdmin
+		}
+	}
+	if _, err := s.authorizeWorkspace(ctx, ws, required); err != nil {
+		return nil, err
+	}
+	before := ws.ToTFE()
+
+	ws.ApplyTFEUpdateOptions(options)
+	if err := s.assignWorkspaceAgentPool(ws, options.AgentPoolID); err != nil {
+		return nil, err
+	}
+	if err := validateWorkspace(ws); err != nil {
+		return nil, err
+	}
+	if moving {
+		if err := s.assignWorkspaceProject(ws, options.Project.ID); err != nil {
+			return nil, err
+		}
+		role, err := s.organizationRole(ctx, ws.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.authorizeWorkspaceCreation(role, ws.ProjectID); err != nil {
+			return nil, err
+		}
+	}
+
+	omit := append([]string{"Organization", "Project", "LockedByUser", "LockedByToken", "AgentPool"}, workspaceLockColumns...)
+	if err := s.db.Omit(omit...).Save(ws).Error; err != nil {
+		s.logger.Error("failed to update workspace", zap.Error(err))
+		return nil, err
+	}
+	updated, err := s.ReadWorkspaceByID(ctx, ws.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, ws.OrganizationID, models.AuditActionUpdate, before, updated)
+	return updated, nil
+}
+
+func (s *service) deleteWorkspace(ctx context.Context, ws *models.Workspace, safe bool) error {
+	if _, err := s.authorizeWorkspace(ctx, ws, wsDelete); err != nil {
+		return err
+	}
+	if safe {
+		managed, err := s.managesResources(ws.ID)
+		if err != nil {
+			return err
+		}
+		if managed {
+			return fmt.Errorf("%w: workspace %s is currently managing resources", ErrConflict, ws.Name)
+		}
+	}
+	if err := s.db.Where("id = ?", ws.ID).Delete(&models.Workspace{}).Error; err != nil {
+		s.logger.Error("failed to delete workspace", zap.Error(err))
+		return err
+	}
+	s.audit(ctx, ws.OrganizationID, models.AuditActionDelete, ws.ToTFE(), nil)
+	return nil
+}
+
+// managesResources reports whether the current state of a workspace
+// contains managed resource instances.
+func (s *service) managesResources(workspaceID uuid.UUID) (bool, error) {
+	sv, err := s.currentStateVersion(workspaceID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		s.logger.Error("failed to read current state version", zap.Error(err))
+		return false, err
+	}
+	for _, r := range sv.Resources {
+		if r.Count > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// authorizeWorkspaceCreation requires role to be allowed to create
+// workspaces in a project.
+func (s *service) authorizeWorkspaceCreation(role *organizationRole, projectID uuid.UUID) error {
+	permissions, _, err := s.projectPermissions(role, projectID)
+	if err != nil {
+		return err
+	}
+	if permissions&projectRead == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	if permissions&projectCreateWorkspace == 0 {
+		return fmt.Errorf("%w: insufficient permissions to create workspaces in the project", ErrForbidden)
+	}
+	return nil
+}
+
+// assignWorkspaceProject places the workspace in the given project, or the
+// organization's default project when projectID is empty.
+func (s *service) assignWorkspaceProject(ws *models.Workspace, projectID string) error {
+	if projectID == "" {
+		project, err := s.defaultProject(ws.OrganizationID)
+		if err != nil {
+			return err
+		}
+		ws.ProjectID = project.ID
+		return nil
+	}
+
+	id, err := uuid.Parse(projectID)
+	if err != nil {
+		return gorm.ErrRecordNotFound
+	}
+
+	var project models.Project
+	if err := s.db.Where("id = ?", id).First(&project).Error; err != nil {
+		s.logger.Error("failed to read project", zap.Error(err))
+		return err
+	}
+	if project.OrganizationID != ws.OrganizationID {
+		return fmt.Errorf("%w: project does not belong to the workspace organization", ErrInvalidAttribute)
+	}
+	ws.ProjectID = project.ID
+	return nil
+}
+
+func (s *service) findWorkspace(ctx context.Context, organization, workspace string) (*models.Workspace, error) {
+	orgID, err := s.GetOrganizationIDByName(ctx, organization)
+	if err != nil {
+		return nil, err
+	}
+
+	var ws models.Workspace
+	if err := s.db.Preload("Organization").Preload("Project").Preload("LockedByUser").Preload("LockedByToken.Team").
+		Where("organization_id = ? AND name = ?", orgID, workspace).First(&ws).Error; err != nil {
+		s.logger.Error("failed to read workspace", zap.Error(err))
+		return nil, err
+	}
+	return &ws, nil
+}
+
+func (s *service) findWorkspaceByID(ctx context.Context, workspaceID string) (*models.Workspace, error) {
+	id, err := uuid.Parse(workspaceID)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var ws models.Workspace
+	if err := s.db.Preload("Organization").Preload("Project").Preload("LockedByUser").Preload("LockedByToken.Team").Where("id = ?", id).First(&ws).Error; err != nil {
+		s.logger.Error("failed to read workspace", zap.Error(err))
+		return nil, err
+	}
+	return &ws, nil
+}
+
+func validateWorkspace(ws *models.Workspace) error {
+	if !workspaceNameRegexp.MatchString(ws.Name) {
+		return fmt.Errorf("%w: workspace name can only include letters, numbers, -, and _", ErrInvalidAttribute)
+	}
+	switch ws.ExecutionMode {
+	case "remote", "local":
+		if ws.AgentPoolID != nil {
+			return fmt.Errorf("%w: an agent pool can only be set with agent execution mode", ErrInvalidAttribute)
+		}
+		return nil
+	case "agent":
+		if ws.AgentPoolID == nil {
+			return fmt.Errorf("%w: agent execution mode requires an agent pool", ErrInvalidAttribute)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: execution mode %q must be remote, local or agent", ErrInvalidAttribute, ws.ExecutionMode)
+	}
+}
+
+// applyOrganizationExecutionDefaults gives a new workspace the default
+// execution mode and agent pool of its organization.
+func (s *service) applyOrganizationExecutionDefaults(ws *models.Workspace) error {
+	var org models.Organization
+	if err := s.db.Where("id = ?", ws.OrganizationID).First(&org).Error; err != nil {
+		s.logger.Error("failed to read organization", zap.Error(err))
+		return err
+	}
+	if org.DefaultExecutionMode != "" {
+		ws.ExecutionMode = org.DefaultExecutionMode
+	}
+	if ws.ExecutionMode == "agent" {
+		ws.AgentPoolID = org.DefaultAgentPoolID
+	}
+	return nil
+}
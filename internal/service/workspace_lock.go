@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/models"
+	"go.uber.org/zap"
+)
+
+// Locks are taken and released with conditional UPDATE statements so that
+// concurrent clients racing for the same workspace see exactly one winner.
+
+// lockHolder identifies who takes or releases a lock: a user, or the API
+// token of a team or organization.
+type lockHolder struct {
+	userID  *uuid.UUID
+	tokenID *uuid.UUID
+	name    string
+}
+
+// currentLockHolder returns the lock holder of the request.
+func (s *service) currentLockHolder(ctx context.Context) (*lockHolder, error) {
+	if p := tokenPrincipalFromContext(ctx); p != nil {
+		id := tokenIDFromContext(ctx)
+		if id == nil {
+			return nil, fmt.Errorf("%w: the API token of the request is unknown", ErrForbidden)
+		}
+		return &lockHolder{tokenID: id, name: p.kind + " token " + id.String()}, nil
+	}
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &lockHolder{userID: &user.ID, name: user.Username}, nil
+}
+
+// holds reports whether the holder has locked ws.
+func (h *lockHolder) holds(ws *models.Workspace) bool {
+	if !ws.Locked || ws.LockedByRunID != nil {
+		return false
+	}
+	if h.userID != nil {
+		return ws.LockedByUserID != nil && *ws.LockedByUserID == *h.userID
+	}
+	return ws.LockedByTokenID != nil && *ws.LockedByTokenID == *h.tokenID
+}
+
+func (s *service) LockWorkspace(ctx context.Context, workspaceID string, options tfe.WorkspaceLockOptions) (*tfe.Workspace, error) {
+	holder, err := s.currentLockHolder(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ws, err := s.findWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, ws, wsLock); err != nil {
+		return nil, err
+	}
+
+	var reason string
+	if options.Reason != nil {
+		reason = *options.Reason
+	}
+
+	result := s.db.Model(&models.Workspace{}).
+		Where("id = ? AND locked = ?", ws.ID, false).
+		Updates(map[string]interface{}{
+			"locked":             true,
+			"lock_reason":        reason,
+			"locked_at":          time.Now(),
+			"locked_by_user_id":  holder.userID,
+			"locked_by_token_id": holder.tokenID,
+			"locked_by_run_id":   nil,
+		})
+	if result.Error != nil {
+		s.logger.Error("failed to lock workspace", zap.Error(result.Error))
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrWorkspaceLocked
+	}
+
+	s.logger.Debug("workspace locked", zap.String("workspace", ws.Name), zap.String("holder", holder.name))
+	return s.auditWorkspaceLock(ctx, ws, models.AuditActionLock)
+}
+
+func (s *service) UnlockWorkspace(ctx context.Context, workspaceID string) (*tfe.Workspace, error) {
+	holder, err := s.currentLockHolder(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ws, err := s.findWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, ws, wsLock); err != nil {
+		return nil, err
+	}
+
+	db := s.db.Model(&models.Workspace{}).Where("id = ? AND locked = ? AND locked_by_run_id IS NULL", ws.ID, true)
+	if holder.userID != nil {
+		db = db.Where("locked_by_user_id = ?", *holder.userID)
+	} else {
+		db = db.Where("locked_by_token_id = ?", *holder.tokenID)
+	}
+	result := db.Updates(unlockedColumns())
+	if result.Error != nil {
+		s.logger.Error("failed to unlock workspace", zap.Error(result.Error))
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, s.unlockConflict(ctx, workspaceID)
+	}
+
+	s.logger.Debug("workspace unlocked", zap.String("workspace", ws.Name), zap.String("holder", holder.name))
+	if err := s.scheduleRuns(ws.ID); err != nil {
+		s.logger.Warn("failed to schedule pending runs", zap.Error(err))
+	}
+	return s.auditWorkspaceLock(ctx, ws, models.AuditActionUnlock)
+}
+
+func (s *service) ForceUnlockWorkspace(ctx context.Context, workspaceID string) (*tfe.Workspace, error) {
+	holder, err := s.currentLockHolder(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ws, err := s.findWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.authorizeWorkspace(ctx, ws, wsAdmin); err != nil {
+		return nil, err
+	}
+
+	result := s.db.Model(&models.Workspace{}).
+		Where("id = ? AND locked = ?", ws.ID, true).
+		Updates(unlockedColumns())
+	if result.Error != nil {
+		s.logger.Error("failed to force unlock workspace", zap.Error(result.Error))
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrWorkspaceNotLocked
+	}
+
+	s.logger.Info("workspace force unlocked", zap.String("workspace", ws.Name), zap.String("holder", holder.name))
+	if err := s.scheduleRuns(ws.ID); err != nil {
+		s.logger.Warn("failed to schedule pending runs", zap.Error(err))
+	}
+	return s.auditWorkspaceLock(ctx, ws, models.AuditActionForceUnlock)
+}
+
+// unlockConflict explains why an unlock by the current user did not match.
+// The wording matches Terraform Enterprise so go-tfe can map it to its errors.
+func (s *service) unlockConflict(ctx context.Context, workspaceID string) error {
+	ws, err := s.findWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !ws.Locked:
+		return ErrWorkspaceNotLocked
+	case ws.LockedByRunID != nil:
+		return fmt.Errorf("%w: Unable to unlock workspace. The workspace is locked by Run %s", ErrConflict, ws.LockedByRunID)
+	case ws.LockedByUser != nil:
+		return fmt.Errorf("%w: Unable to unlock workspace. The workspace is locked by User %s", ErrConflict, ws.LockedByUser.Username)
+	case ws.LockedByToken != nil && ws.LockedByToken.Team != nil:
+		return fmt.Errorf("%w: Unable to unlock workspace. The workspace is locked by Team %s", ErrConflict, ws.LockedByToken.Team.Name)
+	default:
+		return ErrWorkspaceLocked
+	}
+}
+
+// auditWorkspaceLock records a change to the lock of ws and returns the
+// workspace as it is now.
+func (s *service) auditWorkspaceLock(ctx context.Context, ws *models.Workspace, action string) (*tfe.Workspace, error) {
+	updated, err := s.ReadWorkspaceByID(ctx, ws.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, ws.OrganizationID, action, ws.ToTFE(), updated)
+	return updated, nil
+}
+
+func unlockedColumns() map[string]interface{} {
+	return map[string]interface{}{
+		"locked":             false,
+		"lock_reason":        "",
+		"locked_at":          nil,
+		"locked_by_user_id":  nil,
+		"locked_by_token_id": nil,
+		"locked_by_run_id":   nil,
+	}
+}
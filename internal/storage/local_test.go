@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testBlobStore(t, store, "")
+}
+
+func TestLocalStoreRejectsEscapingKeys(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalStore(filepath.Join(dir, "root"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "../outside", strings.NewReader("x"), -1); err == nil {
+		t.Error("Put outside the root succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "outside")); !os.IsNotExist(err) {
+		t.Errorf("Put wrote outside the root: %v", err)
+	}
+	if _, err := store.List(ctx, "../"); err == nil {
+		t.Error("List outside the root succeeded")
+	}
+}
+
+func TestLocalStoreListSkipsPartialUploads(t *testing.T) {
+	root := t.TempDir()
+	store, err := NewLocalStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "logs/object", strings.NewReader("x"), -1); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "logs", ".upload-123"), []byte("partial"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	objects, err := store.List(ctx, "logs/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 1 || objects[0].Key != "logs/object" {
+		t.Errorf("List = %+v, want only logs/object", objects)
+	}
+}
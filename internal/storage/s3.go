@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// DefaultS3PartSize is the part size of multipart uploads of unknown
+// length. Each such upload buffers one part in memory.
+const DefaultS3PartSize = 16 << 20
+
+// S3Config holds the connection settings for an S3 compatible object store.
+type S3Config struct {
+	Endpoint  string
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	// PartSize overrides DefaultS3PartSize when positive.
+	PartSize uint64
+}
+
+// S3Store keeps objects in a bucket of an S3 compatible service such as AWS S3 or MinIO.
+type S3Store struct {
+	client   *minio.Client
+	bucket   string
+	partSize uint64
+}
+
+// NewS3Store connects to the configured endpoint and creates the bucket when missing.
+func NewS3Store(ctx context.Context, cfg S3Config) (*S3Store, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{Region: cfg.Region}); err != nil {
+			return nil, err
+		}
+	}
+
+	partSize := cfg.PartSize
+	if partSize == 0 {
+		partSize = DefaultS3PartSize
+	}
+	return &S3Store{client: client, bucket: cfg.Bucket, partSize: partSize}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	if size < 0 {
+		size = readerSize(r)
+	}
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, s.putOptions(size))
+	return err
+}
+
+// readerSize returns the number of bytes left in readers that know it, such
+// as files and in-memory buffers, or -1.
+func readerSize(r io.Reader) int64 {
+	switch r := r.(type) {
+	case interface{ Len() int }:
+		return int64(r.Len())
+	case *os.File:
+		info, err := r.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return -1
+		}
+		offset, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		return info.Size() - offset
+	default:
+		return -1
+	}
+}
+
+// putOptions bounds the memory of uploads of unknown length. Without a part
+// size minio-go picks one that fits a 5 TiB object and buffers over 500 MiB
+// per upload.
+func (s *S3Store) putOptions(size int64) minio.PutObjectOptions {
+	opts := minio.PutObjectOptions{ContentType: "application/octet-stream"}
+	if size < 0 {
+		opts.PartSize = s.partSize
+	}
+	return opts
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	// GetObject is lazy, so stat first to report missing objects up front.
+	if _, err := s.Stat(ctx, key); err != nil {
+		return nil, err
+	}
+	return s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (s *S3Store) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	info, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, s.translate(err)
+	}
+	return &ObjectInfo{Key: info.Key, Size: info.Size, ModTime: info.LastModified}, nil
+}
+
+func (s *S3Store) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	for object := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if object.Err != nil {
+			return nil, object.Err
+		}
+		objects = append(objects, ObjectInfo{Key: object.Key, Size: object.Size, ModTime: object.LastModified})
+	}
+	return objects, nil
+}
+
+func (s *S3Store) translate(err error) error {
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NotFound":
+		return ErrNotFound
+	default:
+		return err
+	}
+}
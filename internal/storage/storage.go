@@ -0,0 +1,40 @@
+// Package storage provides blob storage for state files, configuration
+// tarballs, run logs and other artifacts that do not belong in Postgres.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned when an object does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// BlobStore stores opaque objects addressed by slash separated keys.
+// Implementations stream object contents and never buffer whole objects.
+type BlobStore interface {
+	// Put stores the contents of r under key, replacing any existing object.
+	// size may be -1 when the length is not known in advance.
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+
+	// Get opens the object stored under key. The caller must close the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the object stored under key. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+
+	// Stat returns metadata for the object stored under key.
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+
+	// List returns every object whose key starts with prefix, ordered by key.
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+}
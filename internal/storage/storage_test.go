@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// testBlobStore exercises the BlobStore contract against store. Every key
+// lives below root so that stores shared between runs stay separate.
+func testBlobStore(t *testing.T, store BlobStore, root string) {
+	ctx := context.Background()
+	key := func(name string) string { return root + name }
+
+	put := func(name, content string, size int64) {
+		t.Helper()
+		if err := store.Put(ctx, key(name), strings.NewReader(content), size); err != nil {
+			t.Fatalf("Put(%s): %v", name, err)
+		}
+	}
+	get := func(name string) string {
+		t.Helper()
+		rc, err := store.Get(ctx, key(name))
+		if err != nil {
+			t.Fatalf("Get(%s): %v", name, err)
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("reading %s: %v", name, err)
+		}
+		return string(data)
+	}
+	list := func(prefix string) []string {
+		t.Helper()
+		objects, err := store.List(ctx, key(prefix))
+		if err != nil {
+			t.Fatalf("List(%s): %v", prefix, err)
+		}
+		keys := make([]string, len(objects))
+		for i, object := range objects {
+			keys[i] = strings.TrimPrefix(object.Key, root)
+		}
+		return keys
+	}
+
+	t.Run("PutGet", func(t *testing.T) {
+		put("objects/a", "first", 5)
+		if got := get("objects/a"); got != "first" {
+			t.Errorf("Get = %q, want %q", got, "first")
+		}
+		put("objects/a", "replaced", -1)
+		if got := get("objects/a"); got != "replaced" {
+			t.Errorf("Get after replace = %q, want %q", got, "replaced")
+		}
+	})
+
+	t.Run("Stat", func(t *testing.T) {
+		put("stat/object", "12345", -1)
+		info, err := store.Stat(ctx, key("stat/object"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Key != key("stat/object") || info.Size != 5 || info.ModTime.IsZero() {
+			t.Errorf("Stat = %+v", info)
+		}
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		if _, err := store.Get(ctx, key("missing")); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get of a missing object = %v, want ErrNotFound", err)
+		}
+		if _, err := store.Stat(ctx, key("missing")); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Stat of a missing object = %v, want ErrNotFound", err)
+		}
+		if got := list("missing/"); len(got) != 0 {
+			t.Errorf("List of a missing prefix = %v, want none", got)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		put("logs/run-1/plan/0002", "b", -1)
+		put("logs/run-1/plan/0001", "a", -1)
+		put("logs/run-1/apply/0001", "c", -1)
+		put("logs/run-10/plan/0001", "d", -1)
+
+		want := []string{"logs/run-1/plan/0001", "logs/run-1/plan/0002"}
+		if got := list("logs/run-1/plan/"); !equal(got, want) {
+			t.Errorf("List(logs/run-1/plan/) = %v, want %v", got, want)
+		}
+		want = []string{"logs/run-1/apply/0001", "logs/run-1/plan/0001", "logs/run-1/plan/0002"}
+		if got := list("logs/run-1/"); !equal(got, want) {
+			t.Errorf("List(logs/run-1/) = %v, want %v", got, want)
+		}
+		// A prefix that ends within a name matches every name it starts.
+		want = append(want, "logs/run-10/plan/0001")
+		if got := list("logs/run-1"); !equal(got, want) {
+			t.Errorf("List(logs/run-1) = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		put("delete/object", "gone", -1)
+		if err := store.Delete(ctx, key("delete/object")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.Get(ctx, key("delete/object")); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+		}
+		if err := store.Delete(ctx, key("delete/object")); err != nil {
+			t.Errorf("Delete of a missing object = %v, want nil", err)
+		}
+	})
+
+	t.Run("Large", func(t *testing.T) {
+		data := bytes.Repeat([]byte("0123456789abcdef"), 1<<16)
+		if err := store.Put(ctx, key("large"), bytes.NewReader(data), -1); err != nil {
+			t.Fatal(err)
+		}
+		if got := get("large"); got != string(data) {
+			t.Errorf("Get of a large object returned %d bytes, want %d", len(got), len(data))
+		}
+	})
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,388 @@
+package worker
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	slug "github.com/hashicorp/go-slug"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	stateFile    = "terraform.tfstate"
+	planFile     = "tfplan"
+	planJSONFile = "tfplan.json"
+
+	// backendOverride makes terraform keep state in the working directory;
+	// the worker moves it to and from the service itself.
+	backendOverride = "zz_tfe_backend_override.tf"
+	backendConfig   = "terraform {\n  backend \"local\" {}\n}\n"
+
+	variablesFile = "zz_tfe_variables.auto.tfvars"
+)
+
+// execution holds the state of one claimed run phase.
+type execution struct {
+	job      *service.RunJob
+	tf       *terraform
+	logs     *logWriter
+	stateMD5 string
+	canceled atomic.Bool
+	logger   *zap.Logger
+}
+
+func (w *Worker) execute(ctx context.Context, job *service.RunJob) {
+	logger := w.logger.With(zap.String("run", job.Run.ID), zap.String("phase", job.Phase))
+	exec := &execution{
+		job:    job,
+		logs:   newLogWriter(w.svc, job.Run.ID, job.Phase, logger),
+		logger: logger,
+	}
+	exec.logger.Info("Executing run")
+
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	go w.watch(runCtx, exec, stop)
+
+	dir, err := os.MkdirTemp(w.cfg.WorkDir, "run-")
+	if err == nil {
+		defer os.RemoveAll(dir)
+		err = w.executePhase(runCtx, exec, dir)
+	}
+	if err != nil && !exec.canceled.Load() {
+		fmt.Fprintf(exec.logs, "\nError: %s\n", err)
+	}
+	if err := exec.logs.Close(); err != nil {
+		exec.logger.Warn("failed to seal run log", zap.Error(err))
+	}
+
+	// Report with a fresh context: runCtx is canceled on cancel requests and
+	// ctx on shutdown, but the outcome must still be recorded.
+	report := service.SystemContext(context.Background())
+	switch {
+	case exec.canceled.Load():
+		exec.logger.Info("Run canceled")
+		err = w.svc.AcknowledgeRunCancel(report, job.Run.ID)
+	case ctx.Err() != nil:
+		err = w.svc.FailRun(report, job.Run.ID, "worker shut down during execution")
+	case err != nil:
+		exec.logger.Info("Run failed", zap.Error(err))
+		err = w.svc.FailRun(report, job.Run.ID, err.Error())
+	}
+	if err != nil {
+		exec.logger.Error("failed to report run outcome", zap.Error(err))
+	}
+}
+
+// watch stops the execution when the run is canceled by a user.
+func (w *Worker) watch(ctx context.Context, exec *execution, stop context.CancelFunc) {
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		run, err := w.svc.ReadRun(ctx, exec.job.Run.ID)
+		if err != nil {
+			exec.logger.Warn("failed to poll run", zap.Error(err))
+			continue
+		}
+		if run.Status == tfe.RunCanceled || !run.ForceCancelAvailableAt.IsZero() {
+			exec.canceled.Store(true)
+			stop()
+			return
+		}
+	}
+}
+
+func (w *Worker) executePhase(ctx context.Context, exec *execution, dir string) error {
+	if err := w.prepare(ctx, exec, dir); err != nil {
+		return err
+	}
+	if code, err := exec.tf.run(ctx, "init", "-input=false", "-no-color"); err != nil || code != 0 {
+		return commandError("init", code, err)
+	}
+
+	switch exec.job.Phase {
+	case service.PhasePlan:
+		return w.plan(ctx, exec)
+	case service.PhaseApply:
+		return w.apply(ctx, exec)
+	default:
+		return fmt.Errorf("unknown phase %q", exec.job.Phase)
+	}
+}
+
+// prepare unpacks the configuration and current state into dir and sets up
+// variables and the backend override.
+func (w *Worker) prepare(ctx context.Context, exec *execution, dir string) error {
+	job := exec.job
+
+	cv, err := w.svc.DownloadConfigurationVersion(ctx, job.Run.ConfigurationVersion.ID)
+	if err != nil {
+		return fmt.Errorf("downloading configuration: %w", err)
+	}
+	err = slug.Unpack(cv, dir)
+	cv.Close()
+	if err != nil {
+		return fmt.Errorf("unpacking configuration: %w", err)
+	}
+
+	workdir := filepath.Join(dir, job.Workspace.WorkingDirectory)
+	if rel, err := filepath.Rel(dir, workdir); err != nil || strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("working directory %q escapes the configuration", job.Workspace.WorkingDirectory)
+	}
+	if err := os.MkdirAll(workdir, 0o750); err != nil {
+		return err
+	}
+
+	state, err := w.svc.DownloadRunState(ctx, job.Run.ID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+	case err != nil:
+		return fmt.Errorf("downloading state: %w", err)
+	default:
+		exec.stateMD5, err = writeFile(filepath.Join(workdir, stateFile), state)
+		state.Close()
+		if err != nil {
+			return fmt.Errorf("writing state: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(workdir, backendOverride), []byte(backendConfig), 0o640); err != nil {
+		return err
+	}
+
+	env := append(baseEnv(), "TF_IN_AUTOMATION=1", "TF_INPUT=0", "CHECKPOINT_DISABLE=1")
+	var tfvars strings.Builder
+	for _, v := range job.Variables {
+		switch v.Category {
+		case tfe.CategoryEnv:
+			env = append(env, v.Key+"="+v.Value)
+		case tfe.CategoryTerraform:
+			value := v.Value
+			if !v.HCL {
+				value = hclString(value)
+			}
+			fmt.Fprintf(&tfvars, "%s = %s\n", v.Key, value)
+		}
+	}
+	if tfvars.Len() > 0 {
+		if err := os.WriteFile(filepath.Join(workdir, variablesFile), []byte(tfvars.String()), 0o640); err != nil {
+			return err
+		}
+	}
+
+	exec.tf = &terraform{
+		bin: w.cfg.TerraformBin,
+		dir: workdir,
+		env: env,
+		out: exec.logs,
+	}
+	return nil
+}
+
+// baseEnv returns the part of the worker's environment that terraform sees:
+// PATH, HOME and TF_* settings. Everything else, such as the database
+// credentials and encryption key, stays out of reach of the configuration.
+func baseEnv() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		if key == "PATH" || key == "HOME" || strings.HasPrefix(key, "TF_") {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
+
+func (w *Worker) plan(ctx context.Context, exec *execution) error {
+	run := exec.job.Run
+
+	args := []string{"plan", "-input=false", "-no-color", "-detailed-exitcode", "-out=" + planFile}
+	if run.IsDestroy {
+		args = append(args, "-destroy")
+	}
+	if run.RefreshOnly {
+		args = append(args, "-refresh-only")
+	}
+	if !run.Refresh {
+		args = append(args, "-refresh=false")
+	}
+	for _, addr := range run.TargetAddrs {
+		args = append(args, "-target="+addr)
+	}
+	for _, addr := range run.ReplaceAddrs {
+		args = append(args, "-replace="+addr)
+	}
+
+	// With -detailed-exitcode, 0 means no changes and 2 means changes.
+	code, err := exec.tf.run(ctx, args...)
+	if err != nil || (code != 0 && code != 2) {
+		return commandError("plan", code, err)
+	}
+
+	if err := w.uploadPlanJSON(ctx, exec); err != nil {
+		return err
+	}
+	if !run.PlanOnly {
+		f, err := os.Open(filepath.Join(exec.tf.dir, planFile))
+		if err != nil {
+			return err
+		}
+		err = w.svc.UploadPlanFile(ctx, run.ID, f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("uploading plan: %w", err)
+		}
+	}
+
+	// Seal before finishing so that clients waiting for the plan to finish
+	// have the complete log.
+	if err := exec.logs.Close(); err != nil {
+		return fmt.Errorf("sealing log: %w", err)
+	}
+	return w.svc.FinishPlan(ctx, run.ID, service.PlanResult{HasChanges: code == 2})
+}
+
+func (w *Worker) apply(ctx context.Context, exec *execution) error {
+	run := exec.job.Run
+
+	plan, err := w.svc.DownloadPlanFile(ctx, run.ID)
+	if err != nil {
+		return fmt.Errorf("downloading plan: %w", err)
+	}
+	_, err = writeFile(filepath.Join(exec.tf.dir, planFile), plan)
+	plan.Close()
+	if err != nil {
+		return err
+	}
+
+	code, applyErr := exec.tf.run(ctx, "apply", "-input=false", "-no-color", planFile)
+
+	// A failed or interrupted apply may still have changed infrastructure,
+	// so whatever state terraform left behind is always recorded.
+	if err := w.uploadState(service.SystemContext(context.Background()), exec); err != nil {
+		return fmt.Errorf("uploading state: %w", err)
+	}
+	if applyErr != nil || code != 0 {
+		return commandError("apply", code, applyErr)
+	}
+
+	if err := exec.logs.Close(); err != nil {
+		return fmt.Errorf("sealing log: %w", err)
+	}
+	return w.svc.FinishApply(ctx, run.ID)
+}
+
+// uploadPlanJSON stores the machine-readable form of the plan.
+func (w *Worker) uploadPlanJSON(ctx context.Context, exec *execution) error {
+	path := filepath.Join(exec.tf.dir, planJSONFile)
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	code, err := exec.tf.output(ctx, f, "show", "-json", planFile)
+	f.Close()
+	if err != nil || code != 0 {
+		return commandError("show", code, err)
+	}
+
+	f, err = os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := w.svc.UploadPlanJSON(ctx, exec.job.Run.ID, f); err != nil {
+		return fmt.Errorf("uploading JSON plan: %w", err)
+	}
+	return nil
+}
+
+// uploadState records the state in the working directory as a new state
+// version when it differs from the state the run started with.
+func (w *Worker) uploadState(ctx context.Context, exec *execution) error {
+	path := filepath.Join(exec.tf.dir, stateFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	sum := md5.Sum(data)
+	checksum := hex.EncodeToString(sum[:])
+	if checksum == exec.stateMD5 {
+		return nil
+	}
+
+	var header struct {
+		Serial  int64  `json:"serial"`
+		Lineage string `json:"lineage"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return fmt.Errorf("reading state: %w", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = w.svc.CreateRunStateVersion(ctx, exec.job.Run.ID, tfe.StateVersionCreateOptions{
+		Serial:  tfe.Int64(header.Serial),
+		MD5:     tfe.String(checksum),
+		Lineage: tfe.String(header.Lineage),
+	}, f)
+	return err
+}
+
+// writeFile streams r into path and returns the MD5 of the written content.
+func writeFile(path string, r io.Reader) (string, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return "", err
+	}
+	hash := md5.New()
+	if _, err := io.Copy(io.MultiWriter(f, hash), r); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// hclString quotes s as an HCL string literal without template interpolation.
+func hclString(s string) string {
+	b, _ := json.Marshal(s)
+	quoted := string(b)
+	quoted = strings.ReplaceAll(quoted, "${", "$${")
+	return strings.ReplaceAll(quoted, "%{", "%%{")
+}
+
+func commandError(command string, code int, err error) error {
+	if err != nil {
+		return fmt.Errorf("terraform %s: %w", command, err)
+	}
+	return fmt.Errorf("terraform %s exited with code %d", command, code)
+}
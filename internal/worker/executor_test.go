@@ -0,0 +1,312 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	slug "github.com/hashicorp/go-slug"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// stubTerraform imitates the terraform commands the worker runs. It records
+// its environment in $TF_STUB_ENV so tests can check what terraform sees.
+const stubTerraform = `#!/bin/sh
+env > "$TF_STUB_ENV"
+case "$1" in
+init)
+	echo "Terraform has been successfully initialized!"
+	;;
+plan)
+	test -f main.tf || exit 1
+	cat zz_tfe_variables.auto.tfvars
+	echo "planned" > tfplan
+	echo "Plan: 1 to add, 0 to change, 0 to destroy."
+	exit 2
+	;;
+show)
+	echo '{"format_version":"1.2"}'
+	;;
+apply)
+	test "$(cat tfplan)" = "planned" || exit 1
+	echo '{"version":4,"serial":3,"lineage":"stub-lineage"}' > terraform.tfstate
+	echo "Apply complete! Resources: 1 added, 0 changed, 0 destroyed."
+	;;
+*)
+	exit 1
+	;;
+esac
+`
+
+// fakeBackend serves a single run from memory.
+type fakeBackend struct {
+	config []byte
+
+	mu         sync.Mutex
+	logs       map[string]*bytes.Buffer
+	sealed     map[string]bool
+	planFile   []byte
+	planJSON   []byte
+	planResult *service.PlanResult
+	applied    bool
+	state      []byte
+	stateOpts  tfe.StateVersionCreateOptions
+	failure    string
+}
+
+func (b *fakeBackend) ClaimRunJob(ctx context.Context) (*service.RunJob, error) {
+	return nil, nil
+}
+
+func (b *fakeBackend) ReadRun(ctx context.Context, runID string) (*tfe.Run, error) {
+	return &tfe.Run{ID: runID, Status: tfe.RunPlanning}, nil
+}
+
+func (b *fakeBackend) DownloadConfigurationVersion(ctx context.Context, cvID string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(b.config)), nil
+}
+
+func (b *fakeBackend) DownloadRunState(ctx context.Context, runID string) (io.ReadCloser, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (b *fakeBackend) DownloadPlanFile(ctx context.Context, runID string) (io.ReadCloser, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return io.NopCloser(bytes.NewReader(b.planFile)), nil
+}
+
+func (b *fakeBackend) UploadPlanFile(ctx context.Context, runID string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.planFile = data
+	return err
+}
+
+func (b *fakeBackend) UploadPlanJSON(ctx context.Context, runID string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.planJSON = data
+	return err
+}
+
+func (b *fakeBackend) CreateRunStateVersion(ctx context.Context, runID string, options tfe.StateVersionCreateOptions, r io.Reader) (*tfe.StateVersion, error) {
+	data, err := io.ReadAll(r)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = data
+	b.stateOpts = options
+	return &tfe.StateVersion{ID: "sv-stub"}, err
+}
+
+func (b *fakeBackend) AppendRunLog(ctx context.Context, runID, phase string, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.logs[phase] == nil {
+		b.logs[phase] = &bytes.Buffer{}
+	}
+	b.logs[phase].Write(data)
+	return nil
+}
+
+func (b *fakeBackend) SealRunLog(ctx context.Context, runID, phase string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sealed[phase] = true
+	return nil
+}
+
+func (b *fakeBackend) FinishPlan(ctx context.Context, runID string, result service.PlanResult) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.planResult = &result
+	return nil
+}
+
+func (b *fakeBackend) FinishApply(ctx context.Context, runID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.applied = true
+	return nil
+}
+
+func (b *fakeBackend) AcknowledgeRunCancel(ctx context.Context, runID string) error {
+	return nil
+}
+
+func (b *fakeBackend) FailRun(ctx context.Context, runID string, cause string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failure = cause
+	return nil
+}
+
+func (b *fakeBackend) log(phase string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.logs[phase] == nil {
+		return ""
+	}
+	return b.logs[phase].String()
+}
+
+func newTestWorker(t *testing.T) (*Worker, *fakeBackend, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the stub terraform is a shell script")
+	}
+	dir := t.TempDir()
+
+	bin := filepath.Join(dir, "terraform")
+	if err := os.WriteFile(bin, []byte(stubTerraform), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	src := filepath.Join(dir, "config")
+	if err := os.MkdirAll(src, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "main.tf"), []byte("resource \"null_resource\" \"stub\" {}\n"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	var config bytes.Buffer
+	if _, err := slug.Pack(src, &config, true); err != nil {
+		t.Fatal(err)
+	}
+
+	envFile := filepath.Join(dir, "env")
+	t.Setenv("TF_STUB_ENV", envFile)
+	t.Setenv("ENCRYPTION_KEY", "worker-secret")
+
+	backend := &fakeBackend{
+		config: config.Bytes(),
+		logs:   map[string]*bytes.Buffer{},
+		sealed: map[string]bool{},
+	}
+	w := New(backend, Config{
+		TerraformBin: bin,
+		WorkDir:      filepath.Join(dir, "work"),
+		PollInterval: time.Hour,
+	}, zap.NewNop())
+	if err := os.MkdirAll(w.cfg.WorkDir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	return w, backend, envFile
+}
+
+func testJob(phase string) *service.RunJob {
+	return &service.RunJob{
+		Phase: phase,
+		Run: &tfe.Run{
+			ID:                   "run-stub",
+			Refresh:              true,
+			ConfigurationVersion: &tfe.ConfigurationVersion{ID: "cv-stub"},
+		},
+		Workspace: &tfe.Workspace{ID: "ws-stub"},
+		Variables: []*tfe.Variable{
+			{Key: "AWS_REGION", Value: "eu-west-1", Category: tfe.CategoryEnv},
+			{Key: "name", Value: "${stub}", Category: tfe.CategoryTerraform},
+		},
+	}
+}
+
+func TestExecutePlanAndApply(t *testing.T) {
+	w, backend, envFile := newTestWorker(t)
+	ctx := service.SystemContext(context.Background())
+
+	w.execute(ctx, testJob(service.PhasePlan))
+	if backend.failure != "" {
+		t.Fatalf("plan failed: %s\nlog:\n%s", backend.failure, backend.log(service.PhasePlan))
+	}
+	if backend.planResult == nil || !backend.planResult.HasChanges {
+		t.Fatalf("plan result = %+v, want changes", backend.planResult)
+	}
+	if got := strings.TrimSpace(string(backend.planFile)); got != "planned" {
+		t.Errorf("uploaded plan = %q, want %q", got, "planned")
+	}
+	if !json.Valid(backend.planJSON) {
+		t.Errorf("uploaded JSON plan is not valid JSON: %q", backend.planJSON)
+	}
+	log := backend.log(service.PhasePlan)
+	if !strings.Contains(log, "Plan: 1 to add") {
+		t.Errorf("plan log is missing terraform output:\n%s", log)
+	}
+	if !strings.Contains(log, `name = "$${stub}"`) {
+		t.Errorf("plan log is missing the escaped terraform variable:\n%s", log)
+	}
+	if !backend.sealed[service.PhasePlan] {
+		t.Error("plan log was not sealed")
+	}
+
+	w.execute(ctx, testJob(service.PhaseApply))
+	if backend.failure != "" {
+		t.Fatalf("apply failed: %s\nlog:\n%s", backend.failure, backend.log(service.PhaseApply))
+	}
+	if !backend.applied {
+		t.Fatal("apply was not finished")
+	}
+	if backend.stateOpts.Serial == nil || *backend.stateOpts.Serial != 3 {
+		t.Errorf("state serial = %v, want 3", backend.stateOpts.Serial)
+	}
+	if backend.stateOpts.Lineage == nil || *backend.stateOpts.Lineage != "stub-lineage" {
+		t.Errorf("state lineage = %v, want stub-lineage", backend.stateOpts.Lineage)
+	}
+	if !strings.Contains(backend.log(service.PhaseApply), "Apply complete!") {
+		t.Errorf("apply log is missing terraform output:\n%s", backend.log(service.PhaseApply))
+	}
+
+	env, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"AWS_REGION=eu-west-1", "TF_IN_AUTOMATION=1", "PATH="} {
+		if !strings.Contains(string(env), want) {
+			t.Errorf("terraform environment is missing %s", want)
+		}
+	}
+	if strings.Contains(string(env), "ENCRYPTION_KEY") {
+		t.Error("terraform environment leaks ENCRYPTION_KEY")
+	}
+}
+
+func TestExecutePlanFailure(t *testing.T) {
+	w, backend, _ := newTestWorker(t)
+	ctx := service.SystemContext(context.Background())
+
+	job := testJob(service.PhasePlan)
+	job.Run.ConfigurationVersion.ID = "cv-empty"
+	backend.config = emptySlug(t)
+
+	w.execute(ctx, job)
+	if backend.failure == "" {
+		t.Fatal("plan of an empty configuration did not fail")
+	}
+	if backend.planResult != nil {
+		t.Error("failed plan was reported as finished")
+	}
+	if !strings.Contains(backend.log(service.PhasePlan), "terraform plan exited with code 1") {
+		t.Errorf("plan log is missing the error:\n%s", backend.log(service.PhasePlan))
+	}
+}
+
+func emptySlug(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if _, err := slug.Pack(t.TempDir(), &buf, true); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
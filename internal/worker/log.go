@@ -0,0 +1,106 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+const (
+	// logFlushInterval bounds how far a tailing client lags behind terraform.
+	logFlushInterval = time.Second
+	// logChunkSize flushes early when terraform produces a lot of output.
+	logChunkSize = 256 * 1024
+)
+
+// logWriter streams terraform output into the log of a run phase in chunks,
+// so clients can tail it while the phase executes.
+type logWriter struct {
+	svc    Backend
+	runID  string
+	phase  string
+	logger *zap.Logger
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+
+	stop      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func newLogWriter(svc Backend, runID, phase string, logger *zap.Logger) *logWriter {
+	l := &logWriter{
+		svc:     svc,
+		runID:   runID,
+		phase:   phase,
+		logger:  logger,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go l.flushPeriodically()
+	return l
+}
+
+func (l *logWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.buf.Write(p)
+	if l.buf.Len() >= logChunkSize {
+		l.flush()
+	}
+	return len(p), nil
+}
+
+// Close flushes the remaining output and seals the log. It is safe to call
+// more than once.
+func (l *logWriter) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.stop)
+		<-l.stopped
+
+		l.mu.Lock()
+		l.flush()
+		l.mu.Unlock()
+
+		// Seal even when the run context is gone so clients see the end of the log.
+		l.closeErr = l.svc.SealRunLog(service.SystemContext(context.Background()), l.runID, l.phase)
+	})
+	return l.closeErr
+}
+
+func (l *logWriter) flushPeriodically() {
+	defer close(l.stopped)
+
+	ticker := time.NewTicker(logFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			l.mu.Lock()
+			l.flush()
+			l.mu.Unlock()
+		}
+	}
+}
+
+// flush sends the buffered output. The caller must hold l.mu. Output that
+// cannot be stored is dropped rather than failing the run.
+func (l *logWriter) flush() {
+	if l.buf.Len() == 0 {
+		return
+	}
+	err := l.svc.AppendRunLog(service.SystemContext(context.Background()), l.runID, l.phase, l.buf.Bytes())
+	l.buf.Reset()
+	if err != nil {
+		l.logger.Warn("failed to append run log", zap.Error(err))
+	}
+}
@@ -0,0 +1,52 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// interruptGrace is how long terraform gets to clean up after an interrupt
+// before it is killed.
+const interruptGrace = 30 * time.Second
+
+// terraform runs terraform commands in one working directory.
+type terraform struct {
+	bin string
+	dir string
+	env []string
+	out io.Writer
+}
+
+// run executes terraform with args and returns its exit code. Canceling ctx
+// interrupts terraform the same way Ctrl-C would.
+func (tf *terraform) run(ctx context.Context, args ...string) (int, error) {
+	return tf.output(ctx, tf.out, args...)
+}
+
+// output is like run but writes the standard output of terraform to stdout
+// instead of the log.
+func (tf *terraform) output(ctx context.Context, stdout io.Writer, args ...string) (int, error) {
+	cmd := exec.CommandContext(ctx, tf.bin, args...)
+	cmd.Dir = tf.dir
+	cmd.Env = tf.env
+	cmd.Stdout = stdout
+	cmd.Stderr = tf.out
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = interruptGrace
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && ctx.Err() == nil {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return -1, err
+	}
+	return 0, nil
+}
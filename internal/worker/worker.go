@@ -0,0 +1,118 @@
+// Package worker executes queued runs by driving the terraform binary in
+// scratch working directories.
+package worker
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/open-tfe/tfe-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// Backend is the part of the service a worker drives runs through. The
+// service implements it directly; agents implement it over the agent API.
+type Backend interface {
+	ClaimRunJob(ctx context.Context) (*service.RunJob, error)
+	ReadRun(ctx context.Context, runID string) (*tfe.Run, error)
+	DownloadConfigurationVersion(ctx context.Context, cvID string) (io.ReadCloser, error)
+	DownloadRunState(ctx context.Context, runID string) (io.ReadCloser, error)
+	DownloadPlanFile(ctx context.Context, runID string) (io.ReadCloser, error)
+	UploadPlanFile(ctx context.Context, runID string, r io.Reader) error
+	UploadPlanJSON(ctx context.Context, runID string, r io.Reader) error
+	CreateRunStateVersion(ctx context.Context, runID string, options tfe.StateVersionCreateOptions, r io.Reader) (*tfe.StateVersion, error)
+	AppendRunLog(ctx context.Context, runID, phase string, data []byte) error
+	SealRunLog(ctx context.Context, runID, phase string) error
+	FinishPlan(ctx context.Context, runID string, result service.PlanResult) error
+	FinishApply(ctx context.Context, runID string) error
+	AcknowledgeRunCancel(ctx context.Context, runID string) error
+	FailRun(ctx context.Context, runID string, cause string) error
+}
+
+// Config controls how runs are executed.
+type Config struct {
+	// TerraformBin is the terraform executable, looked up in PATH when relative.
+	TerraformBin string
+	// WorkDir is the parent directory of the per-run scratch directories.
+	WorkDir string
+	// Concurrency is the number of runs executed at the same time.
+	Concurrency int
+	// PollInterval is how often the queue and running jobs are checked.
+	PollInterval time.Duration
+}
+
+// Worker claims queued runs and executes them.
+type Worker struct {
+	svc    Backend
+	cfg    Config
+	logger *zap.Logger
+}
+
+func New(svc Backend, cfg Config, logger *zap.Logger) *Worker {
+	if cfg.TerraformBin == "" {
+		cfg.TerraformBin = "terraform"
+	}
+	if cfg.WorkDir == "" {
+		cfg.WorkDir = os.TempDir()
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 2 * time.Second
+	}
+	return &Worker{
+		svc:    svc,
+		cfg:    cfg,
+		logger: logger.With(zap.String("component", "worker")),
+	}
+}
+
+// Run polls for queued runs until ctx is canceled and waits for in-flight
+// runs to stop before returning.
+func (w *Worker) Run(ctx context.Context) error {
+	// The worker acts for whoever queued each run, so it runs as the system.
+	ctx = service.SystemContext(ctx)
+	if err := os.MkdirAll(w.cfg.WorkDir, 0o750); err != nil {
+		return err
+	}
+
+	w.logger.Info("Worker started",
+		zap.String("terraform", w.cfg.TerraformBin),
+		zap.Int("concurrency", w.cfg.Concurrency),
+	)
+
+	var wg sync.WaitGroup
+	for i := 0; i < w.cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (w *Worker) loop(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := w.svc.ClaimRunJob(ctx)
+		if err != nil {
+			w.logger.Error("failed to claim run", zap.Error(err))
+		}
+		if job != nil {
+			w.execute(ctx, job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.cfg.PollInterval):
+		}
+	}
+}
@@ -0,0 +1,141 @@
+table "agent_pools" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "name" {
+    type = varchar(255)
+    null = false
+  }
+  column "organization_scoped" {
+    type = boolean
+    default = true
+  }
+  column "organization_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_agent_pools_organization" {
+    columns = [column.organization_id]
+    ref_columns = [table.organizations.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_agent_pools_name_org" {
+    columns = [column.name, column.organization_id]
+    unique = true
+    where = "deleted_at IS NULL"
+  }
+
+  index "idx_agent_pools_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
+
+table "agent_pool_allowed_workspaces" {
+  schema = schema.public
+  column "agent_pool_id" {
+    type = uuid
+    null = false
+  }
+  column "workspace_id" {
+    type = uuid
+    null = false
+  }
+
+  primary_key {
+    columns = [column.agent_pool_id, column.workspace_id]
+  }
+
+  foreign_key "fk_agent_pool_allowed_workspaces_agent_pool" {
+    columns = [column.agent_pool_id]
+    ref_columns = [table.agent_pools.column.id]
+    on_delete = CASCADE
+  }
+
+  foreign_key "fk_agent_pool_allowed_workspaces_workspace" {
+    columns = [column.workspace_id]
+    ref_columns = [table.workspaces.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_agent_pool_allowed_workspaces_workspace_id" {
+    columns = [column.workspace_id]
+  }
+}
+
+table "agents" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "name" {
+    type = varchar(255)
+    null = true
+  }
+  column "ip_address" {
+    type = varchar(255)
+    null = true
+  }
+  column "status" {
+    type = varchar(255)
+    null = false
+  }
+  column "last_ping_at" {
+    type = timestamp
+    null = true
+  }
+  column "agent_pool_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_agents_agent_pool" {
+    columns = [column.agent_pool_id]
+    ref_columns = [table.agent_pools.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_agents_agent_pool_id" {
+    columns = [column.agent_pool_id]
+  }
+
+  index "idx_agents_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
@@ -0,0 +1,63 @@
+table "audit_events" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "organization_id" {
+    type = uuid
+    null = true
+  }
+  column "actor_type" {
+    type = varchar(255)
+    null = false
+  }
+  column "actor_id" {
+    type = varchar(255)
+    null = true
+  }
+  column "actor_name" {
+    type = text
+    null = true
+  }
+  column "token_id" {
+    type = uuid
+    null = true
+  }
+  column "ip_address" {
+    type = varchar(255)
+    null = true
+  }
+  column "request_id" {
+    type = varchar(255)
+    null = true
+  }
+  column "resource_type" {
+    type = varchar(255)
+    null = false
+  }
+  column "resource_id" {
+    type = varchar(255)
+    null = false
+  }
+  column "action" {
+    type = varchar(255)
+    null = false
+  }
+  column "changes" {
+    type = jsonb
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  index "idx_audit_events_organization_id_created_at" {
+    columns = [column.organization_id, column.created_at]
+  }
+}
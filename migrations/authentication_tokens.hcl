@@ -0,0 +1,113 @@
+table "authentication_tokens" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "kind" {
+    type = varchar(255)
+    null = false
+  }
+  column "description" {
+    type = text
+    null = true
+  }
+  column "secret_hash" {
+    type = varchar(255)
+    null = false
+  }
+  column "expired_at" {
+    type = timestamp
+    null = true
+  }
+  column "last_used_at" {
+    type = timestamp
+    null = true
+  }
+  column "user_id" {
+    type = uuid
+    null = true
+  }
+  column "team_id" {
+    type = uuid
+    null = true
+  }
+  column "organization_id" {
+    type = uuid
+    null = true
+  }
+  column "agent_pool_id" {
+    type = uuid
+    null = true
+  }
+  column "created_by_id" {
+    type = uuid
+    null = true
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_authentication_tokens_user" {
+    columns = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_delete = CASCADE
+  }
+
+  foreign_key "fk_authentication_tokens_team" {
+    columns = [column.team_id]
+    ref_columns = [table.teams.column.id]
+    on_delete = CASCADE
+  }
+
+  foreign_key "fk_authentication_tokens_organization" {
+    columns = [column.organization_id]
+    ref_columns = [table.organizations.column.id]
+    on_delete = CASCADE
+  }
+
+  foreign_key "fk_authentication_tokens_created_by" {
+    columns = [column.created_by_id]
+    ref_columns = [table.users.column.id]
+    on_delete = SET_NULL
+  }
+
+  foreign_key "fk_authentication_tokens_agent_pool" {
+    columns = [column.agent_pool_id]
+    ref_columns = [table.agent_pools.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_authentication_tokens_user_id" {
+    columns = [column.user_id]
+  }
+
+  index "idx_authentication_tokens_team_id" {
+    columns = [column.team_id]
+  }
+
+  index "idx_authentication_tokens_organization_id" {
+    columns = [column.organization_id]
+  }
+
+  index "idx_authentication_tokens_agent_pool_id" {
+    columns = [column.agent_pool_id]
+  }
+
+  index "idx_authentication_tokens_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
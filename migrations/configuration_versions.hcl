@@ -0,0 +1,206 @@
+table "configuration_versions" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "status" {
+    type = varchar(255)
+    null = false
+  }
+  column "source" {
+    type = varchar(255)
+    null = false
+  }
+  column "auto_queue_runs" {
+    type = boolean
+    default = false
+  }
+  column "speculative" {
+    type = boolean
+    default = false
+  }
+  column "provisional" {
+    type = boolean
+    default = false
+  }
+  column "error" {
+    type = varchar(255)
+    null = true
+  }
+  column "error_message" {
+    type = text
+    null = true
+  }
+  column "workspace_id" {
+    type = uuid
+    null = false
+  }
+  column "created_by_id" {
+    type = uuid
+    null = true
+  }
+  column "queued_at" {
+    type = timestamp
+    null = true
+  }
+  column "started_at" {
+    type = timestamp
+    null = true
+  }
+  column "finished_at" {
+    type = timestamp
+    null = true
+  }
+  column "archived_at" {
+    type = timestamp
+    null = true
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_configuration_versions_workspace" {
+    columns = [column.workspace_id]
+    ref_columns = [table.workspaces.column.id]
+    on_delete = CASCADE
+  }
+
+  foreign_key "fk_configuration_versions_created_by" {
+    columns = [column.created_by_id]
+    ref_columns = [table.users.column.id]
+    on_delete = SET_NULL
+  }
+
+  index "idx_configuration_versions_workspace_id" {
+    columns = [column.workspace_id]
+  }
+
+  index "idx_configuration_versions_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
+
+table "ingress_attributes" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "branch" {
+    type = varchar(255)
+    null = true
+  }
+  column "clone_url" {
+    type = varchar(255)
+    null = true
+  }
+  column "commit_message" {
+    type = text
+    null = true
+  }
+  column "commit_sha" {
+    type = varchar(255)
+    null = true
+  }
+  column "commit_url" {
+    type = varchar(255)
+    null = true
+  }
+  column "compare_url" {
+    type = varchar(255)
+    null = true
+  }
+  column "identifier" {
+    type = varchar(255)
+    null = true
+  }
+  column "is_pull_request" {
+    type = boolean
+    default = false
+  }
+  column "on_default_branch" {
+    type = boolean
+    default = false
+  }
+  column "pull_request_number" {
+    type = integer
+    null = true
+  }
+  column "pull_request_url" {
+    type = varchar(255)
+    null = true
+  }
+  column "pull_request_title" {
+    type = varchar(255)
+    null = true
+  }
+  column "pull_request_body" {
+    type = text
+    null = true
+  }
+  column "tag" {
+    type = varchar(255)
+    null = true
+  }
+  column "sender_username" {
+    type = varchar(255)
+    null = true
+  }
+  column "sender_avatar_url" {
+    type = varchar(255)
+    null = true
+  }
+  column "sender_html_url" {
+    type = varchar(255)
+    null = true
+  }
+  column "configuration_version_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_ingress_attributes_configuration_version" {
+    columns = [column.configuration_version_id]
+    ref_columns = [table.configuration_versions.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_ingress_attributes_configuration_version_id" {
+    columns = [column.configuration_version_id]
+    unique = true
+  }
+
+  index "idx_ingress_attributes_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
@@ -0,0 +1,51 @@
+table "gpg_keys" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "key_id" {
+    type = varchar(255)
+    null = false
+  }
+  column "ascii_armor" {
+    type = text
+    null = false
+  }
+  column "organization_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_gpg_keys_organization" {
+    columns = [column.organization_id]
+    ref_columns = [table.organizations.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_gpg_keys_key_id_org" {
+    columns = [column.organization_id, column.key_id]
+    unique = true
+    where = "deleted_at IS NULL"
+  }
+
+  index "idx_gpg_keys_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
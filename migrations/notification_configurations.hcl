@@ -0,0 +1,131 @@
+table "notification_configurations" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "name" {
+    type = varchar(255)
+    null = false
+  }
+  column "destination_type" {
+    type = varchar(255)
+    null = false
+  }
+  column "enabled" {
+    type = boolean
+    default = false
+  }
+  column "url" {
+    type = text
+    null = false
+  }
+  column "encrypted_token" {
+    type = text
+    null = true
+  }
+  column "triggers" {
+    type = text
+    null = true
+  }
+  column "workspace_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_notification_configurations_workspace" {
+    columns = [column.workspace_id]
+    ref_columns = [table.workspaces.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_notification_configurations_workspace_id" {
+    columns = [column.workspace_id]
+  }
+
+  index "idx_notification_configurations_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
+
+table "notification_delivery_responses" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "url" {
+    type = text
+    null = false
+  }
+  column "code" {
+    type = integer
+    null = false
+  }
+  column "headers" {
+    type = text
+    null = true
+  }
+  column "body" {
+    type = text
+    null = true
+  }
+  column "successful" {
+    type = boolean
+    null = false
+  }
+  column "sent_at" {
+    type = timestamp
+    null = false
+  }
+  column "notification_configuration_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_notification_delivery_responses_configuration" {
+    columns = [column.notification_configuration_id]
+    ref_columns = [table.notification_configurations.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_notification_delivery_responses_configuration_sent_at" {
+    columns = [column.notification_configuration_id, column.sent_at]
+  }
+
+  index "idx_notification_delivery_responses_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
@@ -0,0 +1,62 @@
+table "oauth_authorization_codes" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "code_hash" {
+    type = varchar(255)
+    null = false
+  }
+  column "client_id" {
+    type = varchar(255)
+    null = false
+  }
+  column "redirect_uri" {
+    type = text
+    null = false
+  }
+  column "code_challenge" {
+    type = varchar(255)
+    null = false
+  }
+  column "expires_at" {
+    type = timestamp
+    null = false
+  }
+  column "user_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_oauth_authorization_codes_user" {
+    columns = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_oauth_authorization_codes_code_hash" {
+    columns = [column.code_hash]
+    unique = true
+  }
+
+  index "idx_oauth_authorization_codes_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
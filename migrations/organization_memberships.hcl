@@ -0,0 +1,79 @@
+table "organization_memberships" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "status" {
+    type = varchar(255)
+    null = false
+  }
+  column "organization_id" {
+    type = uuid
+    null = false
+  }
+  column "user_id" {
+    type = uuid
+    null = false
+  }
+  column "invitation_token_hash" {
+    type = varchar(255)
+    null = true
+  }
+  column "invited_by_id" {
+    type = uuid
+    null = true
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_organization_memberships_organization" {
+    columns = [column.organization_id]
+    ref_columns = [table.organizations.column.id]
+    on_delete = CASCADE
+  }
+
+  foreign_key "fk_organization_memberships_user" {
+    columns = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_delete = CASCADE
+  }
+
+  foreign_key "fk_organization_memberships_invited_by" {
+    columns = [column.invited_by_id]
+    ref_columns = [table.users.column.id]
+    on_delete = SET_NULL
+  }
+
+  index "idx_organization_memberships_org_user" {
+    columns = [column.organization_id, column.user_id]
+    unique = true
+    where = "deleted_at IS NULL"
+  }
+
+  index "idx_organization_memberships_user_id" {
+    columns = [column.user_id]
+  }
+
+  index "idx_organization_memberships_invitation_token_hash" {
+    columns = [column.invitation_token_hash]
+  }
+
+  index "idx_organization_memberships_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
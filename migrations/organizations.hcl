@@ -80,6 +80,10 @@ table "organizations" {
     type = boolean
     default = false
   }
+  column "default_agent_pool_id" {
+    type = uuid
+    null = true
+  }
   column "updated_at" {
     type = timestamp
     default = sql("NOW()")
@@ -93,6 +97,12 @@ table "organizations" {
     columns = [column.id]
   }
 
+  foreign_key "fk_organizations_default_agent_pool" {
+    columns = [column.default_agent_pool_id]
+    ref_columns = [table.agent_pools.column.id]
+    on_delete = SET_NULL
+  }
+
   index "idx_organizations_name" {
     columns = [column.name]
     unique = true
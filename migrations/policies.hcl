@@ -0,0 +1,259 @@
+table "policies" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "name" {
+    type = varchar(255)
+    null = false
+  }
+  column "description" {
+    type = text
+    null = true
+  }
+  column "kind" {
+    type = varchar(255)
+    null = false
+  }
+  column "query" {
+    type = text
+    null = true
+  }
+  column "enforcement_level" {
+    type = varchar(255)
+    null = false
+  }
+  column "uploaded" {
+    type = boolean
+    default = false
+  }
+  column "organization_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_policies_organization" {
+    columns = [column.organization_id]
+    ref_columns = [table.organizations.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_policies_name_org" {
+    columns = [column.name, column.organization_id]
+    unique = true
+    where = "deleted_at IS NULL"
+  }
+
+  index "idx_policies_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
+
+table "policy_sets" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "name" {
+    type = varchar(255)
+    null = false
+  }
+  column "description" {
+    type = text
+    null = true
+  }
+  column "kind" {
+    type = varchar(255)
+    null = false
+  }
+  column "global" {
+    type = boolean
+    default = false
+  }
+  column "overridable" {
+    type = boolean
+    default = true
+  }
+  column "organization_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_policy_sets_organization" {
+    columns = [column.organization_id]
+    ref_columns = [table.organizations.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_policy_sets_name_org" {
+    columns = [column.name, column.organization_id]
+    unique = true
+    where = "deleted_at IS NULL"
+  }
+
+  index "idx_policy_sets_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
+
+table "policy_set_policies" {
+  schema = schema.public
+  column "policy_set_id" {
+    type = uuid
+    null = false
+  }
+  column "policy_id" {
+    type = uuid
+    null = false
+  }
+
+  primary_key {
+    columns = [column.policy_set_id, column.policy_id]
+  }
+
+  foreign_key "fk_policy_set_policies_policy_set" {
+    columns = [column.policy_set_id]
+    ref_columns = [table.policy_sets.column.id]
+    on_delete = CASCADE
+  }
+
+  foreign_key "fk_policy_set_policies_policy" {
+    columns = [column.policy_id]
+    ref_columns = [table.policies.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_policy_set_policies_policy_id" {
+    columns = [column.policy_id]
+  }
+}
+
+table "policy_set_workspaces" {
+  schema = schema.public
+  column "policy_set_id" {
+    type = uuid
+    null = false
+  }
+  column "workspace_id" {
+    type = uuid
+    null = false
+  }
+
+  primary_key {
+    columns = [column.policy_set_id, column.workspace_id]
+  }
+
+  foreign_key "fk_policy_set_workspaces_policy_set" {
+    columns = [column.policy_set_id]
+    ref_columns = [table.policy_sets.column.id]
+    on_delete = CASCADE
+  }
+
+  foreign_key "fk_policy_set_workspaces_workspace" {
+    columns = [column.workspace_id]
+    ref_columns = [table.workspaces.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_policy_set_workspaces_workspace_id" {
+    columns = [column.workspace_id]
+  }
+}
+
+table "policy_set_workspace_exclusions" {
+  schema = schema.public
+  column "policy_set_id" {
+    type = uuid
+    null = false
+  }
+  column "workspace_id" {
+    type = uuid
+    null = false
+  }
+
+  primary_key {
+    columns = [column.policy_set_id, column.workspace_id]
+  }
+
+  foreign_key "fk_policy_set_workspace_exclusions_policy_set" {
+    columns = [column.policy_set_id]
+    ref_columns = [table.policy_sets.column.id]
+    on_delete = CASCADE
+  }
+
+  foreign_key "fk_policy_set_workspace_exclusions_workspace" {
+    columns = [column.workspace_id]
+    ref_columns = [table.workspaces.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_policy_set_workspace_exclusions_workspace_id" {
+    columns = [column.workspace_id]
+  }
+}
+
+table "policy_set_projects" {
+  schema = schema.public
+  column "policy_set_id" {
+    type = uuid
+    null = false
+  }
+  column "project_id" {
+    type = uuid
+    null = false
+  }
+
+  primary_key {
+    columns = [column.policy_set_id, column.project_id]
+  }
+
+  foreign_key "fk_policy_set_projects_policy_set" {
+    columns = [column.policy_set_id]
+    ref_columns = [table.policy_sets.column.id]
+    on_delete = CASCADE
+  }
+
+  foreign_key "fk_policy_set_projects_project" {
+    columns = [column.project_id]
+    ref_columns = [table.projects.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_policy_set_projects_project_id" {
+    columns = [column.project_id]
+  }
+}
@@ -0,0 +1,65 @@
+table "provider_mirror_packages" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "hostname" {
+    type = varchar(255)
+    null = false
+  }
+  column "namespace" {
+    type = varchar(255)
+    null = false
+  }
+  column "type" {
+    type = varchar(255)
+    null = false
+  }
+  column "version" {
+    type = varchar(255)
+    null = false
+  }
+  column "os" {
+    type = varchar(255)
+    null = false
+  }
+  column "arch" {
+    type = varchar(255)
+    null = false
+  }
+  column "shasum" {
+    type = varchar(255)
+    null = false
+  }
+  column "hash1" {
+    type = varchar(255)
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  index "idx_provider_mirror_packages_platform" {
+    columns = [column.hostname, column.namespace, column.type, column.version, column.os, column.arch]
+    unique = true
+    where = "deleted_at IS NULL"
+  }
+
+  index "idx_provider_mirror_packages_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
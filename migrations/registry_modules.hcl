@@ -0,0 +1,115 @@
+table "registry_modules" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "name" {
+    type = varchar(255)
+    null = false
+  }
+  column "provider" {
+    type = varchar(255)
+    null = false
+  }
+  column "no_code" {
+    type = boolean
+    default = false
+  }
+  column "organization_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_registry_modules_organization" {
+    columns = [column.organization_id]
+    ref_columns = [table.organizations.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_registry_modules_name_provider_org" {
+    columns = [column.organization_id, column.name, column.provider]
+    unique = true
+    where = "deleted_at IS NULL"
+  }
+
+  index "idx_registry_modules_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
+
+table "registry_module_versions" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "version" {
+    type = varchar(255)
+    null = false
+  }
+  column "status" {
+    type = varchar(255)
+    null = false
+  }
+  column "source" {
+    type = varchar(255)
+    null = true
+  }
+  column "error" {
+    type = text
+    null = true
+  }
+  column "registry_module_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_registry_module_versions_registry_module" {
+    columns = [column.registry_module_id]
+    ref_columns = [table.registry_modules.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_registry_module_versions_module_version" {
+    columns = [column.registry_module_id, column.version]
+    unique = true
+    where = "deleted_at IS NULL"
+  }
+
+  index "idx_registry_module_versions_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
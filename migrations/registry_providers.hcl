@@ -0,0 +1,175 @@
+table "registry_providers" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "name" {
+    type = varchar(255)
+    null = false
+  }
+  column "organization_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_registry_providers_organization" {
+    columns = [column.organization_id]
+    ref_columns = [table.organizations.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_registry_providers_name_org" {
+    columns = [column.organization_id, column.name]
+    unique = true
+    where = "deleted_at IS NULL"
+  }
+
+  index "idx_registry_providers_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
+
+table "registry_provider_versions" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "version" {
+    type = varchar(255)
+    null = false
+  }
+  column "key_id" {
+    type = varchar(255)
+    null = false
+  }
+  column "protocols" {
+    type = varchar(255)
+    null = false
+  }
+  column "shasums_uploaded" {
+    type = boolean
+    default = false
+  }
+  column "shasums_sig_uploaded" {
+    type = boolean
+    default = false
+  }
+  column "registry_provider_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_registry_provider_versions_registry_provider" {
+    columns = [column.registry_provider_id]
+    ref_columns = [table.registry_providers.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_registry_provider_versions_provider_version" {
+    columns = [column.registry_provider_id, column.version]
+    unique = true
+    where = "deleted_at IS NULL"
+  }
+
+  index "idx_registry_provider_versions_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
+
+table "registry_provider_platforms" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "os" {
+    type = varchar(255)
+    null = false
+  }
+  column "arch" {
+    type = varchar(255)
+    null = false
+  }
+  column "filename" {
+    type = varchar(255)
+    null = false
+  }
+  column "shasum" {
+    type = varchar(255)
+    null = false
+  }
+  column "provider_binary_uploaded" {
+    type = boolean
+    default = false
+  }
+  column "registry_provider_version_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_registry_provider_platforms_registry_provider_version" {
+    columns = [column.registry_provider_version_id]
+    ref_columns = [table.registry_provider_versions.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_registry_provider_platforms_version_os_arch" {
+    columns = [column.registry_provider_version_id, column.os, column.arch]
+    unique = true
+    where = "deleted_at IS NULL"
+  }
+
+  index "idx_registry_provider_platforms_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
@@ -0,0 +1,145 @@
+table "run_tasks" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "name" {
+    type = varchar(255)
+    null = false
+  }
+  column "url" {
+    type = text
+    null = false
+  }
+  column "description" {
+    type = text
+    null = true
+  }
+  column "category" {
+    type = varchar(255)
+    null = false
+  }
+  column "encrypted_hmac_key" {
+    type = text
+    null = true
+  }
+  column "enabled" {
+    type = boolean
+    default = true
+  }
+  column "global_enabled" {
+    type = boolean
+    default = false
+  }
+  column "global_stages" {
+    type = text
+    null = true
+  }
+  column "global_enforcement_level" {
+    type = varchar(255)
+    null = true
+  }
+  column "organization_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_run_tasks_organization" {
+    columns = [column.organization_id]
+    ref_columns = [table.organizations.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_run_tasks_name_org" {
+    columns = [column.name, column.organization_id]
+    unique = true
+    where = "deleted_at IS NULL"
+  }
+
+  index "idx_run_tasks_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
+
+table "workspace_run_tasks" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "enforcement_level" {
+    type = varchar(255)
+    null = false
+  }
+  column "stages" {
+    type = text
+    null = true
+  }
+  column "workspace_id" {
+    type = uuid
+    null = false
+  }
+  column "run_task_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_workspace_run_tasks_workspace" {
+    columns = [column.workspace_id]
+    ref_columns = [table.workspaces.column.id]
+    on_delete = CASCADE
+  }
+
+  foreign_key "fk_workspace_run_tasks_run_task" {
+    columns = [column.run_task_id]
+    ref_columns = [table.run_tasks.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_workspace_run_tasks_workspace_task" {
+    columns = [column.workspace_id, column.run_task_id]
+    unique = true
+    where = "deleted_at IS NULL"
+  }
+
+  index "idx_workspace_run_tasks_run_task_id" {
+    columns = [column.run_task_id]
+  }
+
+  index "idx_workspace_run_tasks_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
@@ -0,0 +1,286 @@
+table "runs" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "status" {
+    type = varchar(255)
+    null = false
+  }
+  column "status_timestamps" {
+    type = jsonb
+    null = false
+    default = sql("'{}'::jsonb")
+  }
+  column "message" {
+    type = text
+    null = true
+  }
+  column "source" {
+    type = varchar(255)
+    null = false
+  }
+  column "is_destroy" {
+    type = boolean
+    default = false
+  }
+  column "plan_only" {
+    type = boolean
+    default = false
+  }
+  column "refresh" {
+    type = boolean
+    default = true
+  }
+  column "refresh_only" {
+    type = boolean
+    default = false
+  }
+  column "auto_apply" {
+    type = boolean
+    default = false
+  }
+  column "allow_empty_apply" {
+    type = boolean
+    default = false
+  }
+  column "allow_config_generation" {
+    type = boolean
+    default = false
+  }
+  column "save_plan" {
+    type = boolean
+    default = false
+  }
+  column "has_changes" {
+    type = boolean
+    default = false
+  }
+  column "terraform_version" {
+    type = varchar(255)
+    null = true
+  }
+  column "target_addrs" {
+    type = text
+    null = true
+  }
+  column "replace_addrs" {
+    type = text
+    null = true
+  }
+  column "variables" {
+    type = text
+    null = true
+  }
+  column "force_cancel_available_at" {
+    type = timestamp
+    null = true
+  }
+  column "workspace_id" {
+    type = uuid
+    null = false
+  }
+  column "configuration_version_id" {
+    type = uuid
+    null = false
+  }
+  column "created_by_id" {
+    type = uuid
+    null = true
+  }
+  column "agent_id" {
+    type = uuid
+    null = true
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_runs_workspace" {
+    columns = [column.workspace_id]
+    ref_columns = [table.workspaces.column.id]
+    on_delete = CASCADE
+  }
+
+  foreign_key "fk_runs_configuration_version" {
+    columns = [column.configuration_version_id]
+    ref_columns = [table.configuration_versions.column.id]
+    on_delete = CASCADE
+  }
+
+  foreign_key "fk_runs_created_by" {
+    columns = [column.created_by_id]
+    ref_columns = [table.users.column.id]
+    on_delete = SET_NULL
+  }
+
+  foreign_key "fk_runs_agent" {
+    columns = [column.agent_id]
+    ref_columns = [table.agents.column.id]
+    on_delete = SET_NULL
+  }
+
+  index "idx_runs_workspace_status" {
+    columns = [column.workspace_id, column.status]
+  }
+
+  index "idx_runs_configuration_version_id" {
+    columns = [column.configuration_version_id]
+  }
+
+  index "idx_runs_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
+
+table "plans" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "status" {
+    type = varchar(255)
+    null = false
+  }
+  column "status_timestamps" {
+    type = jsonb
+    null = false
+    default = sql("'{}'::jsonb")
+  }
+  column "has_changes" {
+    type = boolean
+    default = false
+  }
+  column "resource_additions" {
+    type = integer
+    default = 0
+  }
+  column "resource_changes" {
+    type = integer
+    default = 0
+  }
+  column "resource_destructions" {
+    type = integer
+    default = 0
+  }
+  column "resource_imports" {
+    type = integer
+    default = 0
+  }
+  column "run_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_plans_run" {
+    columns = [column.run_id]
+    ref_columns = [table.runs.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_plans_run_id" {
+    columns = [column.run_id]
+  }
+
+  index "idx_plans_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
+
+table "applies" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "status" {
+    type = varchar(255)
+    null = false
+  }
+  column "status_timestamps" {
+    type = jsonb
+    null = false
+    default = sql("'{}'::jsonb")
+  }
+  column "resource_additions" {
+    type = integer
+    default = 0
+  }
+  column "resource_changes" {
+    type = integer
+    default = 0
+  }
+  column "resource_destructions" {
+    type = integer
+    default = 0
+  }
+  column "resource_imports" {
+    type = integer
+    default = 0
+  }
+  column "run_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_applies_run" {
+    columns = [column.run_id]
+    ref_columns = [table.runs.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_applies_run_id" {
+    columns = [column.run_id]
+  }
+
+  index "idx_applies_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
@@ -0,0 +1,159 @@
+table "state_versions" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "status" {
+    type = varchar(255)
+    null = false
+  }
+  column "serial" {
+    type = bigint
+    null = false
+  }
+  column "lineage" {
+    type = varchar(255)
+    null = true
+  }
+  column "md5" {
+    type = varchar(255)
+    null = false
+  }
+  column "state_version" {
+    type = integer
+    null = true
+  }
+  column "terraform_version" {
+    type = varchar(255)
+    null = true
+  }
+  column "resources_processed" {
+    type = boolean
+    default = false
+  }
+  column "resources" {
+    type = text
+    null = true
+  }
+  column "workspace_id" {
+    type = uuid
+    null = false
+  }
+  column "run_id" {
+    type = uuid
+    null = true
+  }
+  column "created_by_id" {
+    type = uuid
+    null = true
+  }
+  column "created_by_token_id" {
+    type = uuid
+    null = true
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_state_versions_workspace" {
+    columns = [column.workspace_id]
+    ref_columns = [table.workspaces.column.id]
+    on_delete = CASCADE
+  }
+
+  foreign_key "fk_state_versions_created_by" {
+    columns = [column.created_by_id]
+    ref_columns = [table.users.column.id]
+    on_delete = SET_NULL
+  }
+
+  foreign_key "fk_state_versions_created_by_token" {
+    columns = [column.created_by_token_id]
+    ref_columns = [table.authentication_tokens.column.id]
+    on_delete = SET_NULL
+  }
+
+  index "idx_state_versions_workspace_serial" {
+    columns = [column.workspace_id, column.serial]
+  }
+
+  index "idx_state_versions_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
+
+table "state_version_outputs" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "name" {
+    type = varchar(255)
+    null = false
+  }
+  column "sensitive" {
+    type = boolean
+    default = false
+  }
+  column "type" {
+    type = varchar(255)
+    null = true
+  }
+  column "value" {
+    type = text
+    null = true
+  }
+  column "detailed_type" {
+    type = text
+    null = true
+  }
+  column "state_version_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_state_version_outputs_state_version" {
+    columns = [column.state_version_id]
+    ref_columns = [table.state_versions.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_state_version_outputs_state_version_id" {
+    columns = [column.state_version_id]
+  }
+
+  index "idx_state_version_outputs_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
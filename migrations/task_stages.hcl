@@ -0,0 +1,284 @@
+table "task_stages" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "stage" {
+    type = varchar(255)
+    null = false
+  }
+  column "status" {
+    type = varchar(255)
+    null = false
+  }
+  column "status_timestamps" {
+    type = jsonb
+    null = false
+    default = sql("'{}'::jsonb")
+  }
+  column "run_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_task_stages_run" {
+    columns = [column.run_id]
+    ref_columns = [table.runs.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_task_stages_run_id" {
+    columns = [column.run_id]
+  }
+
+  index "idx_task_stages_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
+
+table "task_results" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "status" {
+    type = varchar(255)
+    null = false
+  }
+  column "message" {
+    type = text
+    null = true
+  }
+  column "url" {
+    type = text
+    null = true
+  }
+  column "status_timestamps" {
+    type = jsonb
+    null = false
+    default = sql("'{}'::jsonb")
+  }
+  column "task_name" {
+    type = varchar(255)
+    null = false
+  }
+  column "task_url" {
+    type = text
+    null = false
+  }
+  column "enforcement_level" {
+    type = varchar(255)
+    null = false
+  }
+  column "access_token_hash" {
+    type = varchar(255)
+    null = true
+  }
+  column "access_token_expires_at" {
+    type = timestamp
+    null = true
+  }
+  column "run_task_id" {
+    type = uuid
+    null = true
+  }
+  column "workspace_run_task_id" {
+    type = uuid
+    null = true
+  }
+  column "task_stage_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_task_results_run_task" {
+    columns = [column.run_task_id]
+    ref_columns = [table.run_tasks.column.id]
+    on_delete = SET_NULL
+  }
+
+  foreign_key "fk_task_results_workspace_run_task" {
+    columns = [column.workspace_run_task_id]
+    ref_columns = [table.workspace_run_tasks.column.id]
+    on_delete = SET_NULL
+  }
+
+  foreign_key "fk_task_results_task_stage" {
+    columns = [column.task_stage_id]
+    ref_columns = [table.task_stages.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_task_results_task_stage_id" {
+    columns = [column.task_stage_id]
+  }
+
+  index "idx_task_results_status" {
+    columns = [column.status]
+  }
+
+  index "idx_task_results_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
+
+table "policy_evaluations" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "status" {
+    type = varchar(255)
+    null = false
+  }
+  column "policy_kind" {
+    type = varchar(255)
+    null = false
+  }
+  column "status_timestamps" {
+    type = jsonb
+    null = false
+    default = sql("'{}'::jsonb")
+  }
+  column "task_stage_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_policy_evaluations_task_stage" {
+    columns = [column.task_stage_id]
+    ref_columns = [table.task_stages.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_policy_evaluations_task_stage_id" {
+    columns = [column.task_stage_id]
+  }
+
+  index "idx_policy_evaluations_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
+
+table "policy_set_outcomes" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "policy_set_name" {
+    type = varchar(255)
+    null = false
+  }
+  column "policy_set_description" {
+    type = text
+    null = true
+  }
+  column "overridable" {
+    type = boolean
+    default = true
+  }
+  column "error" {
+    type = text
+    null = true
+  }
+  column "outcomes" {
+    type = text
+    null = true
+  }
+  column "policy_set_id" {
+    type = uuid
+    null = true
+  }
+  column "policy_evaluation_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_policy_set_outcomes_policy_set" {
+    columns = [column.policy_set_id]
+    ref_columns = [table.policy_sets.column.id]
+    on_delete = SET_NULL
+  }
+
+  foreign_key "fk_policy_set_outcomes_policy_evaluation" {
+    columns = [column.policy_evaluation_id]
+    ref_columns = [table.policy_evaluations.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_policy_set_outcomes_policy_evaluation_id" {
+    columns = [column.policy_evaluation_id]
+  }
+
+  index "idx_policy_set_outcomes_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
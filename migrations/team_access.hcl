@@ -0,0 +1,191 @@
+table "team_workspace_accesses" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "access" {
+    type = varchar(255)
+    null = false
+  }
+  column "runs" {
+    type = varchar(255)
+    null = false
+  }
+  column "variables" {
+    type = varchar(255)
+    null = false
+  }
+  column "state_versions" {
+    type = varchar(255)
+    null = false
+  }
+  column "sentinel_mocks" {
+    type = varchar(255)
+    null = false
+  }
+  column "workspace_locking" {
+    type = boolean
+    default = false
+  }
+  column "run_tasks" {
+    type = boolean
+    default = false
+  }
+  column "team_id" {
+    type = uuid
+    null = false
+  }
+  column "workspace_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_team_workspace_accesses_team" {
+    columns = [column.team_id]
+    ref_columns = [table.teams.column.id]
+    on_delete = CASCADE
+  }
+
+  foreign_key "fk_team_workspace_accesses_workspace" {
+    columns = [column.workspace_id]
+    ref_columns = [table.workspaces.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_team_workspace_accesses_team_workspace" {
+    columns = [column.team_id, column.workspace_id]
+    unique = true
+    where = "deleted_at IS NULL"
+  }
+
+  index "idx_team_workspace_accesses_workspace_id" {
+    columns = [column.workspace_id]
+  }
+
+  index "idx_team_workspace_accesses_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
+
+table "team_project_accesses" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "access" {
+    type = varchar(255)
+    null = false
+  }
+  column "project_settings" {
+    type = varchar(255)
+    null = false
+  }
+  column "project_teams" {
+    type = varchar(255)
+    null = false
+  }
+  column "workspace_runs" {
+    type = varchar(255)
+    null = false
+  }
+  column "workspace_sentinel_mocks" {
+    type = varchar(255)
+    null = false
+  }
+  column "workspace_state_versions" {
+    type = varchar(255)
+    null = false
+  }
+  column "workspace_variables" {
+    type = varchar(255)
+    null = false
+  }
+  column "workspace_create" {
+    type = boolean
+    default = false
+  }
+  column "workspace_locking" {
+    type = boolean
+    default = false
+  }
+  column "workspace_move" {
+    type = boolean
+    default = false
+  }
+  column "workspace_delete" {
+    type = boolean
+    default = false
+  }
+  column "workspace_run_tasks" {
+    type = boolean
+    default = false
+  }
+  column "team_id" {
+    type = uuid
+    null = false
+  }
+  column "project_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_team_project_accesses_team" {
+    columns = [column.team_id]
+    ref_columns = [table.teams.column.id]
+    on_delete = CASCADE
+  }
+
+  foreign_key "fk_team_project_accesses_project" {
+    columns = [column.project_id]
+    ref_columns = [table.projects.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_team_project_accesses_team_project" {
+    columns = [column.team_id, column.project_id]
+    unique = true
+    where = "deleted_at IS NULL"
+  }
+
+  index "idx_team_project_accesses_project_id" {
+    columns = [column.project_id]
+  }
+
+  index "idx_team_project_accesses_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
@@ -0,0 +1,151 @@
+table "teams" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "name" {
+    type = varchar(255)
+    null = false
+  }
+  column "visibility" {
+    type = varchar(255)
+    null = false
+  }
+  column "sso_team_id" {
+    type = varchar(255)
+    null = true
+  }
+  column "allow_member_token_management" {
+    type = boolean
+    default = true
+  }
+  column "organization_access_manage_policies" {
+    type = boolean
+    default = false
+  }
+  column "organization_access_manage_policy_overrides" {
+    type = boolean
+    default = false
+  }
+  column "organization_access_manage_workspaces" {
+    type = boolean
+    default = false
+  }
+  column "organization_access_manage_vcs_settings" {
+    type = boolean
+    default = false
+  }
+  column "organization_access_manage_providers" {
+    type = boolean
+    default = false
+  }
+  column "organization_access_manage_modules" {
+    type = boolean
+    default = false
+  }
+  column "organization_access_manage_run_tasks" {
+    type = boolean
+    default = false
+  }
+  column "organization_access_manage_projects" {
+    type = boolean
+    default = false
+  }
+  column "organization_access_read_workspaces" {
+    type = boolean
+    default = false
+  }
+  column "organization_access_read_projects" {
+    type = boolean
+    default = false
+  }
+  column "organization_access_manage_membership" {
+    type = boolean
+    default = false
+  }
+  column "organization_access_manage_teams" {
+    type = boolean
+    default = false
+  }
+  column "organization_access_manage_organization_access" {
+    type = boolean
+    default = false
+  }
+  column "organization_access_access_secret_teams" {
+    type = boolean
+    default = false
+  }
+  column "organization_access_manage_agent_pools" {
+    type = boolean
+    default = false
+  }
+  column "organization_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_teams_organization" {
+    columns = [column.organization_id]
+    ref_columns = [table.organizations.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_teams_name_org" {
+    columns = [column.name, column.organization_id]
+    unique = true
+    where = "deleted_at IS NULL"
+  }
+
+  index "idx_teams_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
+
+table "team_users" {
+  schema = schema.public
+  column "team_id" {
+    type = uuid
+    null = false
+  }
+  column "user_id" {
+    type = uuid
+    null = false
+  }
+
+  primary_key {
+    columns = [column.team_id, column.user_id]
+  }
+
+  foreign_key "fk_team_users_team" {
+    columns = [column.team_id]
+    ref_columns = [table.teams.column.id]
+    on_delete = CASCADE
+  }
+
+  foreign_key "fk_team_users_user" {
+    columns = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_team_users_user_id" {
+    columns = [column.user_id]
+  }
+}
@@ -15,6 +15,7 @@ table "users" {
   column "password" {
     type = varchar(255)
     null = false
+    default = ""
   }
   column "is_service_account" {
     type = boolean
@@ -0,0 +1,195 @@
+table "variable_sets" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "name" {
+    type = varchar(255)
+    null = false
+  }
+  column "description" {
+    type = text
+    null = true
+  }
+  column "global" {
+    type = boolean
+    default = false
+  }
+  column "priority" {
+    type = boolean
+    default = false
+  }
+  column "organization_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_variable_sets_organization" {
+    columns = [column.organization_id]
+    ref_columns = [table.organizations.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_variable_sets_name_org" {
+    columns = [column.name, column.organization_id]
+    unique = true
+    where = "deleted_at IS NULL"
+  }
+
+  index "idx_variable_sets_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
+
+table "variable_set_workspaces" {
+  schema = schema.public
+  column "variable_set_id" {
+    type = uuid
+    null = false
+  }
+  column "workspace_id" {
+    type = uuid
+    null = false
+  }
+
+  primary_key {
+    columns = [column.variable_set_id, column.workspace_id]
+  }
+
+  foreign_key "fk_variable_set_workspaces_variable_set" {
+    columns = [column.variable_set_id]
+    ref_columns = [table.variable_sets.column.id]
+    on_delete = CASCADE
+  }
+
+  foreign_key "fk_variable_set_workspaces_workspace" {
+    columns = [column.workspace_id]
+    ref_columns = [table.workspaces.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_variable_set_workspaces_workspace_id" {
+    columns = [column.workspace_id]
+  }
+}
+
+table "variable_set_projects" {
+  schema = schema.public
+  column "variable_set_id" {
+    type = uuid
+    null = false
+  }
+  column "project_id" {
+    type = uuid
+    null = false
+  }
+
+  primary_key {
+    columns = [column.variable_set_id, column.project_id]
+  }
+
+  foreign_key "fk_variable_set_projects_variable_set" {
+    columns = [column.variable_set_id]
+    ref_columns = [table.variable_sets.column.id]
+    on_delete = CASCADE
+  }
+
+  foreign_key "fk_variable_set_projects_project" {
+    columns = [column.project_id]
+    ref_columns = [table.projects.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_variable_set_projects_project_id" {
+    columns = [column.project_id]
+  }
+}
+
+table "variable_set_variables" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "key" {
+    type = varchar(255)
+    null = false
+  }
+  column "value" {
+    type = text
+    null = true
+  }
+  column "encrypted_value" {
+    type = text
+    null = true
+  }
+  column "description" {
+    type = text
+    null = true
+  }
+  column "category" {
+    type = varchar(255)
+    null = false
+  }
+  column "hcl" {
+    type = boolean
+    default = false
+  }
+  column "sensitive" {
+    type = boolean
+    default = false
+  }
+  column "variable_set_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_variable_set_variables_variable_set" {
+    columns = [column.variable_set_id]
+    ref_columns = [table.variable_sets.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_variable_set_variables_set_key_category" {
+    columns = [column.variable_set_id, column.key, column.category]
+    unique = true
+    where = "deleted_at IS NULL"
+  }
+
+  index "idx_variable_set_variables_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
@@ -0,0 +1,71 @@
+table "variables" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "key" {
+    type = varchar(255)
+    null = false
+  }
+  column "value" {
+    type = text
+    null = true
+  }
+  column "encrypted_value" {
+    type = text
+    null = true
+  }
+  column "description" {
+    type = text
+    null = true
+  }
+  column "category" {
+    type = varchar(255)
+    null = false
+  }
+  column "hcl" {
+    type = boolean
+    default = false
+  }
+  column "sensitive" {
+    type = boolean
+    default = false
+  }
+  column "workspace_id" {
+    type = uuid
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_variables_workspace" {
+    columns = [column.workspace_id]
+    ref_columns = [table.workspaces.column.id]
+    on_delete = CASCADE
+  }
+
+  index "idx_variables_workspace_key_category" {
+    columns = [column.workspace_id, column.key, column.category]
+    unique = true
+    where = "deleted_at IS NULL"
+  }
+
+  index "idx_variables_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}
@@ -0,0 +1,179 @@
+table "workspaces" {
+  schema = schema.public
+  column "id" {
+    type = uuid
+    default = sql("gen_random_uuid()")
+  }
+  column "name" {
+    type = varchar(255)
+    null = false
+  }
+  column "description" {
+    type = text
+    null = true
+  }
+  column "allow_destroy_plan" {
+    type = boolean
+    default = true
+  }
+  column "assessments_enabled" {
+    type = boolean
+    default = false
+  }
+  column "auto_apply" {
+    type = boolean
+    default = false
+  }
+  column "auto_apply_run_trigger" {
+    type = boolean
+    default = false
+  }
+  column "execution_mode" {
+    type = varchar(255)
+    default = "remote"
+  }
+  column "file_triggers_enabled" {
+    type = boolean
+    default = true
+  }
+  column "global_remote_state" {
+    type = boolean
+    default = false
+  }
+  column "queue_all_runs" {
+    type = boolean
+    default = false
+  }
+  column "speculative_enabled" {
+    type = boolean
+    default = true
+  }
+  column "source_name" {
+    type = varchar(255)
+    null = true
+  }
+  column "source_url" {
+    type = text
+    null = true
+  }
+  column "structured_run_output_enabled" {
+    type = boolean
+    default = true
+  }
+  column "terraform_version" {
+    type = varchar(255)
+    null = true
+  }
+  column "trigger_prefixes" {
+    type = text
+    null = true
+  }
+  column "trigger_patterns" {
+    type = text
+    null = true
+  }
+  column "working_directory" {
+    type = text
+    null = true
+  }
+  column "organization_id" {
+    type = uuid
+    null = false
+  }
+  column "project_id" {
+    type = uuid
+    null = false
+  }
+  column "agent_pool_id" {
+    type = uuid
+    null = true
+  }
+  column "locked" {
+    type = boolean
+    default = false
+  }
+  column "lock_reason" {
+    type = text
+    null = true
+  }
+  column "locked_at" {
+    type = timestamp
+    null = true
+  }
+  column "locked_by_user_id" {
+    type = uuid
+    null = true
+  }
+  column "locked_by_token_id" {
+    type = uuid
+    null = true
+  }
+  column "locked_by_run_id" {
+    type = uuid
+    null = true
+  }
+  column "created_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "updated_at" {
+    type = timestamp
+    default = sql("NOW()")
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "fk_workspaces_organization" {
+    columns = [column.organization_id]
+    ref_columns = [table.organizations.column.id]
+    on_delete = CASCADE
+  }
+
+  foreign_key "fk_workspaces_project" {
+    columns = [column.project_id]
+    ref_columns = [table.projects.column.id]
+    on_delete = RESTRICT
+  }
+
+  foreign_key "fk_workspaces_locked_by_user" {
+    columns = [column.locked_by_user_id]
+    ref_columns = [table.users.column.id]
+    on_delete = SET_NULL
+  }
+
+  foreign_key "fk_workspaces_locked_by_token" {
+    columns = [column.locked_by_token_id]
+    ref_columns = [table.authentication_tokens.column.id]
+    on_delete = SET_NULL
+  }
+
+  foreign_key "fk_workspaces_agent_pool" {
+    columns = [column.agent_pool_id]
+    ref_columns = [table.agent_pools.column.id]
+    on_delete = SET_NULL
+  }
+
+  index "idx_workspaces_name_org" {
+    columns = [column.name, column.organization_id]
+    unique = true
+    where = "deleted_at IS NULL"
+  }
+
+  index "idx_workspaces_project_id" {
+    columns = [column.project_id]
+  }
+
+  index "idx_workspaces_agent_pool_id" {
+    columns = [column.agent_pool_id]
+  }
+
+  index "idx_workspaces_deleted_at" {
+    columns = [column.deleted_at]
+  }
+}